@@ -0,0 +1,238 @@
+// Package alerting generates recommended Prometheus alerting rules for the
+// metrics the runner pushes, scaled to the configured backup interval.
+package alerting
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultStaleIntervals is how many backup intervals may pass without a
+	// backup before it is considered stale.
+	DefaultStaleIntervals = 3
+	// DefaultFailureStreak is how many intervals of failed runs raise an alert.
+	DefaultFailureStreak = 3
+	// DefaultSizeDropPercent is how far the total size of the saves may
+	// drop below its weekly maximum before it is considered an anomaly.
+	DefaultSizeDropPercent = 50
+
+	groupName = "ludusavi-runner"
+)
+
+// RuleFile is a Prometheus rule file.
+type RuleFile struct {
+	Groups []Group `yaml:"groups"`
+}
+
+// Group is a group of rules evaluated together.
+type Group struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is an alerting rule.
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// generator holds the parameters of the generated rules.
+type generator struct {
+	interval        time.Duration
+	staleIntervals  int
+	failureStreak   int
+	sizeDropPercent int
+	metricPrefix    string
+}
+
+// Option configures the generated rules.
+type Option func(*generator)
+
+// WithStaleIntervals sets how many intervals may pass without a backup.
+func WithStaleIntervals(n int) Option {
+	return func(g *generator) {
+		g.staleIntervals = n
+	}
+}
+
+// WithFailureStreak sets how many intervals of failed runs raise an alert.
+func WithFailureStreak(n int) Option {
+	return func(g *generator) {
+		g.failureStreak = n
+	}
+}
+
+// WithSizeDropPercent sets the size drop, in percent of the weekly maximum,
+// that is considered an anomaly.
+func WithSizeDropPercent(percent int) Option {
+	return func(g *generator) {
+		g.sizeDropPercent = percent
+	}
+}
+
+// WithMetricPrefix sets the prefix the metrics are pushed with in place of
+// metrics.DefaultPrefix.
+func WithMetricPrefix(prefix string) Option {
+	return func(g *generator) {
+		g.metricPrefix = prefix
+	}
+}
+
+// NewRules returns the recommended alerting rules for backups running every
+// interval.
+func NewRules(interval time.Duration, opts ...Option) *RuleFile {
+	g := &generator{
+		interval:        interval,
+		staleIntervals:  DefaultStaleIntervals,
+		failureStreak:   DefaultFailureStreak,
+		sizeDropPercent: DefaultSizeDropPercent,
+		metricPrefix:    metrics.DefaultPrefix,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	rules := g.rules()
+	for i := range rules {
+		rules[i].Expr = metrics.RenameQuery(rules[i].Expr, g.metricPrefix)
+	}
+	return &RuleFile{Groups: []Group{{Name: groupName, Rules: rules}}}
+}
+
+// YAML returns the rule file in YAML, ready for rule_files in prometheus.yml.
+func (f *RuleFile) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return nil, fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Exprs returns the PromQL expressions of all rules.
+func (f *RuleFile) Exprs() []string {
+	var exprs []string
+	for _, g := range f.Groups {
+		for _, r := range g.Rules {
+			exprs = append(exprs, r.Expr)
+		}
+	}
+	return exprs
+}
+
+// rules returns the alerting rules.
+func (g *generator) rules() []Rule {
+	local := metrics.LabelDestination + `=""`
+	// Failures during maintenance are deliberate, so they don't alert
+	live := metrics.LabelMaintenance + `!="true"`
+	stale := time.Duration(g.staleIntervals) * g.interval
+	streak := time.Duration(g.failureStreak) * g.interval
+	// Give a run in progress an interval to finish before alerting
+	pending := Duration(g.interval)
+
+	return []Rule{
+		{
+			Alert: "LudusaviBackupStale",
+			Expr: fmt.Sprintf(`time() - max by (instance) (%s{%s=~"backup|fast_backup",%s}) > %d`,
+				metrics.MetricLastRunTimestamp, metrics.LabelOperation, local, int64(stale.Seconds())),
+			For:    pending,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "No game save backup on {{ $labels.instance }} for " + Duration(stale),
+				"description": fmt.Sprintf("The last backup on {{ $labels.instance }} ended {{ $value | humanizeDuration }} ago, "+
+					"more than %d backup intervals of %s. Check that the service is running.", g.staleIntervals, Duration(g.interval)),
+			},
+		},
+		{
+			Alert: "LudusaviBackupFailing",
+			Expr: fmt.Sprintf(`max_over_time(%s{%s,%s}[%s]) == 0`,
+				metrics.MetricLastRunSuccess, local, live, Duration(streak)),
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary": "{{ $labels.operation }} on {{ $labels.instance }} failing for " + Duration(streak),
+				"description": fmt.Sprintf("Every {{ $labels.operation }} run on {{ $labels.instance }} in the last %d "+
+					"backup intervals failed. Check the service log or notifications for the error.", g.failureStreak),
+			},
+		},
+		{
+			Alert: "LudusaviDestinationFailing",
+			Expr: fmt.Sprintf(`max_over_time(%s{%s!="",%s}[%s]) == 0`,
+				metrics.MetricLastRunSuccess, metrics.LabelDestination, live, Duration(streak)),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Backups to {{ $labels.destination }} on {{ $labels.instance }} failing for " + Duration(streak),
+				"description": fmt.Sprintf("Every backup to destination {{ $labels.destination }} in the last %d backup intervals failed.", g.failureStreak),
+			},
+		},
+		{
+			Alert: "LudusaviBackupSizeDropped",
+			Expr: fmt.Sprintf(`%[1]s{%[2]s="backup",%[3]s,%[5]s} < %.2[4]f * max_over_time(%[1]s{%[2]s="backup",%[3]s,%[5]s}[7d])`,
+				metrics.MetricBytesTotal, metrics.LabelOperation, local, float64(100-g.sizeDropPercent)/100, live),
+			For:    pending,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "Game saves on {{ $labels.instance }} shrank by more than " + fmt.Sprintf("%d%%", g.sizeDropPercent),
+				"description": fmt.Sprintf("The total size of the backed up saves on {{ $labels.instance }} is {{ $value | humanize1024 }}B, "+
+					"more than %d%% below its maximum over the last week. Saves may have been deleted or a "+
+					"launcher's save location may have moved.", g.sizeDropPercent),
+			},
+		},
+		{
+			Alert:  "LudusaviGameCountDropped",
+			Expr:   fmt.Sprintf(`%s == 1`, metrics.MetricGameCountRegressed),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "Ludusavi finds far fewer games on {{ $labels.instance }}",
+				"description": "The last full backup on {{ $labels.instance }} found far fewer games than the rolling average. " +
+					"A ludusavi manifest update may be broken or a game library may have moved.",
+			},
+		},
+		{
+			Alert: "LudusaviNotificationsFailing",
+			Expr: fmt.Sprintf(`sum by (instance, %[2]s) (increase(%[1]s{%[3]s="%[4]s"}[%[6]s])) > 0 `+
+				`unless sum by (instance, %[2]s) (increase(%[1]s{%[3]s="%[5]s"}[%[6]s])) > 0`,
+				metrics.MetricNotifications, metrics.LabelTarget, metrics.LabelOutcome,
+				metrics.OutcomeFailed, metrics.OutcomeSent, Duration(streak)),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "Notifications to {{ $labels.target }} from {{ $labels.instance }} failing",
+				"description": fmt.Sprintf("No notification to {{ $labels.target }} from {{ $labels.instance }} went out in the last %d "+
+					"backup intervals, so failed backups may go unnoticed. Check that the Apprise server is reachable.", g.failureStreak),
+			},
+		},
+	}
+}
+
+// Duration formats d as a Prometheus duration, e.g. "1h30m".
+func Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d <= 0 {
+		return "0s"
+	}
+
+	var b strings.Builder
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / unit.size; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit.suffix)
+			d -= n * unit.size
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// metricPattern matches metric names in PromQL expressions.
+var metricPattern = regexp.MustCompile(`\bludusavi_[a-z_]+`)
+
+func TestNewRules(t *testing.T) {
+	rules := NewRules(20*time.Minute, WithFailureStreak(4), WithSizeDropPercent(30)).Groups[0].Rules
+	byName := make(map[string]Rule)
+	for _, r := range rules {
+		byName[r.Alert] = r
+	}
+
+	stale := byName["LudusaviBackupStale"]
+	assert.Contains(t, stale.Expr, "> 3600")
+	assert.Equal(t, "20m", stale.For)
+
+	failing := byName["LudusaviBackupFailing"]
+	assert.Equal(t, `max_over_time(ludusavi_last_run_success{destination="",maintenance!="true"}[1h20m]) == 0`, failing.Expr)
+	assert.Equal(t, "critical", failing.Labels["severity"])
+
+	destination := byName["LudusaviDestinationFailing"]
+	assert.Equal(t, `max_over_time(ludusavi_last_run_success{destination!="",maintenance!="true"}[1h20m]) == 0`, destination.Expr)
+
+	size := byName["LudusaviBackupSizeDropped"]
+	assert.Equal(t, `ludusavi_bytes_total{operation="backup",destination="",maintenance!="true"} < 0.70 * `+
+		`max_over_time(ludusavi_bytes_total{operation="backup",destination="",maintenance!="true"}[7d])`, size.Expr)
+
+	games := byName["LudusaviGameCountDropped"]
+	assert.Equal(t, "ludusavi_game_count_regression == 1", games.Expr)
+
+	notifications := byName["LudusaviNotificationsFailing"]
+	assert.Equal(t, `sum by (instance, target) (increase(ludusavi_runner_notifications_total{outcome="failed"}[1h20m])) > 0 `+
+		`unless sum by (instance, target) (increase(ludusavi_runner_notifications_total{outcome="sent"}[1h20m])) > 0`, notifications.Expr)
+
+	for _, expr := range NewRules(time.Hour).Exprs() {
+		for _, name := range metricPattern.FindAllString(expr, -1) {
+			_, ok := metrics.Lookup(name)
+			assert.True(t, ok, "rule uses unknown metric %s: %s", name, expr)
+		}
+	}
+}
+
+func TestNewRules_MetricPrefix(t *testing.T) {
+	for _, expr := range NewRules(time.Hour, WithMetricPrefix("saves_")).Exprs() {
+		assert.NotContains(t, expr, metrics.DefaultPrefix)
+		assert.Contains(t, expr, "saves_")
+	}
+}
+
+func TestRuleFile_YAML(t *testing.T) {
+	data, err := NewRules(time.Hour).YAML()
+	require.NoError(t, err)
+
+	var file RuleFile
+	require.NoError(t, yaml.Unmarshal(data, &file))
+	require.Len(t, file.Groups, 1)
+	assert.Equal(t, "ludusavi-runner", file.Groups[0].Name)
+	assert.NotEmpty(t, file.Groups[0].Rules)
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{90 * time.Second, "1m30s"},
+		{time.Hour, "1h"},
+		{3 * 20 * time.Minute, "1h"},
+		{50 * time.Hour, "2d2h"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Duration(tt.d), tt.d.String())
+	}
+}
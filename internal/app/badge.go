@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/badge"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// staleIntervals is how many backup intervals may pass without a successful
+// backup before the badge turns yellow, unless badge.stale_after is set.
+const staleIntervals = 3
+
+// recordRun remembers result for the status badge, rewrites the badge file
+// and adds it to the run history, if configured.
+func (r *Runner) recordRun(result *domain.RunResult) {
+	if r.history != nil {
+		if err := r.history.Record(result); err != nil {
+			r.logger.Warn("failed to record run history", "error", err)
+		}
+	}
+
+	r.statsMu.Lock()
+	r.lastRun = result
+	if result.Success {
+		r.lastSuccess = result.EndTime
+	}
+	r.statsMu.Unlock()
+
+	if err := r.WriteBadge(); err != nil {
+		r.logger.Warn("failed to write status badge", "error", err)
+	}
+}
+
+// Badge returns the status badge for the latest runs.
+func (r *Runner) Badge() badge.Badge {
+	r.statsMu.Lock()
+	last, lastSuccess := r.lastRun, r.lastSuccess
+	r.statsMu.Unlock()
+
+	stale := r.config.Badge.StaleAfter
+	if stale == 0 {
+		stale = staleIntervals * r.config.Interval
+	}
+	label := r.config.Badge.Label
+	if label == "" {
+		label = config.DefaultBadgeLabel
+	}
+	return badge.ForRun(label, last, lastSuccess, time.Now(), stale)
+}
+
+// WriteBadge writes the status badge to badge.path, if set. The file is
+// replaced atomically so a dashboard never reads half a badge.
+func (r *Runner) WriteBadge() error {
+	path := r.config.Badge.Path
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create badge directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	// #nosec G306 -- the badge is meant to be served to dashboards
+	if err := os.WriteFile(tmp, r.Badge().SVG(), 0644); err != nil {
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write badge: %w", err)
+	}
+	return nil
+}
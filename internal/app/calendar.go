@@ -0,0 +1,248 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CalendarKind is the kind of a calendar exception.
+type CalendarKind string
+
+const (
+	// CalendarSkip skips scheduled backups from Start to End.
+	CalendarSkip CalendarKind = "skip"
+	// CalendarRun runs an extra full backup at Start.
+	CalendarRun CalendarKind = "run"
+)
+
+// ErrCalendarNotFound is returned when removing an unknown calendar exception.
+var ErrCalendarNotFound = errors.New("calendar exception not found")
+
+// CalendarException is a one-off exception to the backup schedule.
+type CalendarException struct {
+	ID    string       `json:"id"`
+	Kind  CalendarKind `json:"kind"`
+	Start time.Time    `json:"start"`
+	// End is when a skip ends; unset for runs.
+	End    time.Time `json:"end,omitzero"`
+	Reason string    `json:"reason,omitempty"`
+	// Config is set for exceptions from the config file, which can't be
+	// removed at runtime.
+	Config bool `json:"config,omitempty"`
+}
+
+// over reports whether the exception has no effect after now.
+func (e CalendarException) over(now time.Time) bool {
+	if e.Kind == CalendarSkip {
+		return !e.End.After(now)
+	}
+	return e.Start.Before(now)
+}
+
+// Calendar holds one-off exceptions to the backup schedule: dates or time
+// ranges without scheduled backups, and extra runs. Exceptions added at
+// runtime are saved to a state file, if set, so they survive restarts.
+type Calendar struct {
+	statePath string
+	logger    *slog.Logger
+
+	mu         sync.Mutex
+	exceptions []CalendarException
+}
+
+// CalendarOption configures a Calendar.
+type CalendarOption func(*Calendar)
+
+// WithCalendarStatePath sets the file exceptions added at runtime are saved to.
+func WithCalendarStatePath(path string) CalendarOption {
+	return func(c *Calendar) {
+		c.statePath = path
+	}
+}
+
+// WithCalendarLogger sets the logger.
+func WithCalendarLogger(l *slog.Logger) CalendarOption {
+	return func(c *Calendar) {
+		c.logger = l
+	}
+}
+
+// NewCalendar creates a calendar with the exceptions from the config file,
+// along with those added at runtime before a restart.
+func NewCalendar(exceptions []CalendarException, opts ...CalendarOption) *Calendar {
+	c := &Calendar{logger: slog.Default()}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	for _, e := range exceptions {
+		e.ID = newCalendarID()
+		e.Config = true
+		c.exceptions = append(c.exceptions, e)
+	}
+	c.exceptions = append(c.exceptions, c.load()...)
+	c.sort()
+
+	return c
+}
+
+// List returns the exceptions that still have an effect, by start time.
+func (c *Calendar) List() []CalendarException {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	list := make([]CalendarException, 0, len(c.exceptions))
+	for _, e := range c.exceptions {
+		if !e.over(now) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Add adds an exception at runtime and returns it with its ID.
+func (c *Calendar) Add(e CalendarException) (CalendarException, error) {
+	now := time.Now()
+	switch e.Kind {
+	case CalendarSkip:
+		if !e.End.After(e.Start) {
+			return CalendarException{}, fmt.Errorf("skip must end after it starts")
+		}
+	case CalendarRun:
+		e.End = time.Time{}
+	default:
+		return CalendarException{}, fmt.Errorf("unknown calendar exception kind %q", e.Kind)
+	}
+	if e.over(now) {
+		return CalendarException{}, fmt.Errorf("%s is in the past", e.Kind)
+	}
+	e.ID = newCalendarID()
+	e.Config = false
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.exceptions = append(c.exceptions, e)
+	c.sort()
+	c.save(now)
+	c.logger.Info("calendar exception added", "id", e.ID, "kind", e.Kind, "start", e.Start, "reason", e.Reason)
+	return e, nil
+}
+
+// Remove removes an exception added at runtime.
+func (c *Calendar) Remove(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := slices.IndexFunc(c.exceptions, func(e CalendarException) bool { return e.ID == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrCalendarNotFound, id)
+	}
+	if c.exceptions[i].Config {
+		return fmt.Errorf("calendar exception %s is set in the config file", id)
+	}
+	c.exceptions = slices.Delete(c.exceptions, i, i+1)
+	c.save(time.Now())
+	c.logger.Info("calendar exception removed", "id", id)
+	return nil
+}
+
+// Skip returns the skip covering t, if any.
+func (c *Calendar) Skip(t time.Time) (CalendarException, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.exceptions {
+		if e.Kind == CalendarSkip && !t.Before(e.Start) && t.Before(e.End) {
+			return e, true
+		}
+	}
+	return CalendarException{}, false
+}
+
+// Runs returns the extra runs due after since, up to and including until.
+func (c *Calendar) Runs(since, until time.Time) []CalendarException {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var runs []CalendarException
+	for _, e := range c.exceptions {
+		if e.Kind == CalendarRun && e.Start.After(since) && !e.Start.After(until) {
+			runs = append(runs, e)
+		}
+	}
+	return runs
+}
+
+// sort orders the exceptions by start time. The caller must hold mu.
+func (c *Calendar) sort() {
+	slices.SortStableFunc(c.exceptions, func(a, b CalendarException) int {
+		return a.Start.Compare(b.Start)
+	})
+}
+
+// load reads the exceptions added at runtime, leaving out those over.
+func (c *Calendar) load() []CalendarException {
+	if c.statePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logger.Warn("failed to read calendar state", "error", err)
+		}
+		return nil
+	}
+	var saved []CalendarException
+	if err := json.Unmarshal(data, &saved); err != nil {
+		c.logger.Warn("ignoring unreadable calendar state", "error", err)
+		return nil
+	}
+
+	now := time.Now()
+	return slices.DeleteFunc(saved, func(e CalendarException) bool { return e.over(now) })
+}
+
+// save writes the exceptions added at runtime that aren't over yet. The
+// caller must hold mu.
+func (c *Calendar) save(now time.Time) {
+	if c.statePath == "" {
+		return
+	}
+
+	added := make([]CalendarException, 0, len(c.exceptions))
+	for _, e := range c.exceptions {
+		if !e.Config && !e.over(now) {
+			added = append(added, e)
+		}
+	}
+
+	data, err := json.Marshal(added)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(c.statePath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(c.statePath, data, 0600)
+	}
+	if err != nil {
+		c.logger.Warn("failed to save calendar state", "error", err)
+	}
+}
+
+// newCalendarID returns a short random ID for a calendar exception.
+func newCalendarID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "calendar.json")
+	now := time.Now()
+	lanParty := CalendarException{Kind: CalendarSkip, Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "LAN party"}
+
+	calendar := NewCalendar([]CalendarException{lanParty}, WithCalendarStatePath(statePath))
+	skip, ok := calendar.Skip(now)
+	require.True(t, ok)
+	assert.Equal(t, "LAN party", skip.Reason)
+	assert.True(t, skip.Config)
+	_, ok = calendar.Skip(now.Add(2 * time.Hour))
+	assert.False(t, ok)
+
+	// Exceptions set in the config file can't be removed
+	assert.ErrorContains(t, calendar.Remove(skip.ID), "set in the config file")
+	assert.ErrorIs(t, calendar.Remove("nope"), ErrCalendarNotFound)
+
+	_, err := calendar.Add(CalendarException{Kind: CalendarRun, Start: now.Add(-time.Minute)})
+	assert.ErrorContains(t, err, "run is in the past")
+	_, err = calendar.Add(CalendarException{Kind: CalendarSkip, Start: now.Add(time.Hour), End: now})
+	assert.ErrorContains(t, err, "skip must end after it starts")
+	_, err = calendar.Add(CalendarException{Kind: "nap", Start: now.Add(time.Hour)})
+	assert.ErrorContains(t, err, `unknown calendar exception kind "nap"`)
+
+	run, err := calendar.Add(CalendarException{Kind: CalendarRun, Start: now.Add(30 * time.Minute), Reason: "before the tournament"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, run.ID)
+	assert.Empty(t, calendar.Runs(now, now.Add(time.Minute)))
+	runs := calendar.Runs(now, now.Add(time.Hour))
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID, runs[0].ID)
+
+	// Exceptions added at runtime survive restarts; those from the config
+	// file come from the config file again
+	restarted := NewCalendar(nil, WithCalendarStatePath(statePath))
+	list := restarted.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, run.ID, list[0].ID)
+	assert.True(t, run.Start.Equal(list[0].Start))
+	assert.Equal(t, "before the tournament", list[0].Reason)
+
+	require.NoError(t, restarted.Remove(run.ID))
+	assert.Empty(t, NewCalendar(nil, WithCalendarStatePath(statePath)).List())
+}
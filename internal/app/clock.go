@@ -0,0 +1,68 @@
+package app
+
+import "time"
+
+// clockJumpTolerance is how far the wall clock may move away from the
+// monotonic clock between two checks before the scheduler treats it as a
+// sleep or a clock change and resyncs its schedule.
+const clockJumpTolerance = 30 * time.Second
+
+// clockWatch notices the wall clock moving apart from the monotonic clock,
+// which stands still while the system sleeps and ignores clock changes.
+type clockWatch struct {
+	wall     func() time.Time
+	lastWall time.Time
+	lastMono time.Time
+}
+
+// newClockWatch starts watching the wall clock read by wall.
+func newClockWatch(wall func() time.Time) *clockWatch {
+	return &clockWatch{wall: wall, lastWall: wall(), lastMono: time.Now()}
+}
+
+// jump returns how far the wall clock moved ahead of the monotonic clock
+// since the last call, or behind it if negative.
+func (c *clockWatch) jump() time.Duration {
+	wall, mono := c.wall(), time.Now()
+	jump := wall.Sub(c.lastWall) - mono.Sub(c.lastMono)
+	c.lastWall, c.lastMono = wall, mono
+	return jump
+}
+
+// wallClock returns the wall clock time, stripped of its monotonic reading so
+// comparisons with it follow the wall clock.
+func wallClock() time.Time {
+	return time.Now().Round(0)
+}
+
+// untilNextRun returns the wall clock time left until the next scheduled full
+// run, negative if it is overdue.
+func (s *Scheduler) untilNextRun() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextRunAt.Round(0).Sub(s.wallClock())
+}
+
+// resync realigns the schedule with the wall clock after it jumped: a full
+// run the system slept through runs once now instead of whenever the
+// monotonic timers catch up, and a clock set back doesn't postpone the next
+// run by more than an interval, while the times of a cron schedule stay put.
+// It reports whether a run is due now.
+func (s *Scheduler) resync(jump time.Duration, ticker *time.Ticker) bool {
+	remaining := s.untilNextRun()
+	s.logger.Info("system clock jumped, resyncing schedule",
+		"jump", jump.Round(time.Second),
+		"next_run_in", max(remaining, 0).Round(time.Second),
+	)
+
+	switch {
+	case remaining <= 0:
+		return true
+	case remaining > s.interval && s.cron == nil:
+		ticker.Reset(s.interval)
+		s.setNextRun()
+	default:
+		ticker.Reset(remaining)
+	}
+	return false
+}
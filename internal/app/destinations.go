@@ -0,0 +1,325 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+	"github.com/sharkusmanch/ludusavi-runner/internal/volume"
+)
+
+// destinationState records when a removable destination was last mounted.
+type destinationState struct {
+	LastSeen time.Time `json:"last_seen"`
+	// Warned is set once the unseen warning was sent, until it is seen again.
+	Warned bool `json:"warned,omitempty"`
+}
+
+// RunDestinations backs up to the named destinations only, as when a
+// removable drive has just been plugged in. Metrics are pushed and
+// notifications sent as for a full run.
+func (r *Runner) RunDestinations(ctx context.Context, names []string) (*domain.RunResult, error) {
+	result := domain.NewRunResult(r.config.DryRun)
+	ctx = logging.WithAttrs(ctx, logging.KeyRunID, result.ID)
+
+	ctx = tracing.ContextWithTracer(ctx, r.tracer)
+	ctx, span := tracing.Start(ctx, "destination backup run", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("host.name", r.hostname)
+	span.SetAttribute("run.id", result.ID)
+	span.SetAttribute("dry_run", r.config.DryRun)
+
+	r.log(ctx).Info("starting destination backup run", "destinations", names)
+
+	if r.executor != nil {
+		for _, dest := range r.config.BackupDestinations {
+			for _, name := range names {
+				if dest.Name == name {
+					r.runDestination(ctx, dest, result)
+				}
+			}
+		}
+	}
+
+	result.Maintenance = r.inMaintenance()
+	result.Complete()
+
+	if err := r.pushMetrics(ctx, result); err != nil {
+		r.log(ctx).Error("failed to push metrics", "error", err)
+		result.AddError(err)
+	}
+
+	if err := r.sendNotifications(ctx, result); err != nil {
+		r.log(ctx).Error("failed to send notification", "error", err)
+	}
+
+	r.publishResult(ctx, result)
+
+	r.log(ctx).Info("destination backup run completed",
+		"success", result.Success,
+		"duration", result.Duration,
+	)
+	r.recordRun(result)
+
+	span.SetSuccess(result.Success, strings.Join(result.Errors, "; "))
+
+	return result, nil
+}
+
+// CheckDestinations looks for removable destinations located by volume and
+// returns the names of those plugged in since the previous check. The first
+// check only records which are mounted.
+func (r *Runner) CheckDestinations(ctx context.Context) []string {
+	r.destMu.Lock()
+	first := r.destMounted == nil
+	if first {
+		r.destMounted = make(map[string]bool)
+	}
+	r.destMu.Unlock()
+
+	var appeared []string
+	for _, dest := range r.config.BackupDestinations {
+		if !dest.HasVolume() {
+			continue
+		}
+
+		_, mounted, err := r.locateDestination(dest)
+		if err != nil {
+			r.log(ctx).Warn("failed to locate backup destination", "destination", dest.Name, "error", err)
+			continue
+		}
+
+		r.destMu.Lock()
+		wasMounted := r.destMounted[dest.Name]
+		r.destMounted[dest.Name] = mounted
+		r.destMu.Unlock()
+
+		if mounted && !wasMounted && !first {
+			r.log(ctx).Info("backup destination plugged in", "destination", dest.Name)
+			appeared = append(appeared, dest.Name)
+		}
+	}
+
+	r.checkUnseenDestinations(ctx)
+
+	return appeared
+}
+
+// runDestinationBackups backs up to each additional destination in turn. Each
+// destination gets its own result, so one failing doesn't affect the others.
+func (r *Runner) runDestinationBackups(ctx context.Context, result *domain.RunResult) {
+	for _, dest := range r.config.BackupDestinations {
+		r.runDestination(ctx, dest, result)
+	}
+	r.checkUnseenDestinations(ctx)
+}
+
+// runDestination backs up to dest and adds its result to result.
+func (r *Runner) runDestination(ctx context.Context, dest config.BackupDestinationConfig, result *domain.RunResult) {
+	destResult, err := r.runDestinationBackup(ctx, dest)
+	if err != nil {
+		r.log(ctx).Error("destination backup failed", "destination", dest.Name, "error", err)
+		result.AddError(operationError(domain.OperationBackup, err))
+		return
+	}
+	result.Destinations = append(result.Destinations, destResult)
+}
+
+// runDestinationBackup backs up to an additional destination. A removable
+// destination that isn't mounted is skipped rather than failed.
+func (r *Runner) runDestinationBackup(ctx context.Context, dest config.BackupDestinationConfig) (*domain.BackupResult, error) {
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, domain.OperationBackup.String())
+	ctx, span := tracing.Start(ctx, "backup destination", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("destination", dest.Name)
+
+	path, mounted, err := r.locateDestination(dest)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to locate backup destination %s: %w", dest.Name, err)
+	}
+
+	r.log(ctx).Debug("starting destination backup", "destination", dest.Name, "path", path)
+
+	if !mounted {
+		r.log(ctx).Info("backup destination not mounted, skipping", "destination", dest.Name, "path", path)
+		span.SetAttribute("skipped", true)
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Destination = dest.Name
+		result.Skipped = true
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping destination backup", "destination", dest.Name)
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Destination = dest.Name
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	// Quarantined games are only retried by the local backup
+	result, err := r.executor.Backup(ctx, domain.BackupOptions{Force: true, Path: path, Exclude: r.quarantineExclude(false)})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("backup to %s error: %w", dest.Name, err)
+	}
+	result.Destination = dest.Name
+	recordResult(span, result)
+
+	if result.Success {
+		r.log(ctx).Info("destination backup completed",
+			"destination", dest.Name,
+			"games_processed", result.Stats.ProcessedGames,
+			"bytes_processed", result.Stats.ProcessedBytes,
+			"duration", result.Duration,
+		)
+	} else {
+		r.log(ctx).Warn("destination backup failed", "destination", dest.Name, "error", result.Error)
+	}
+
+	return result, nil
+}
+
+// locateDestination returns the backup path of dest and whether it is
+// mounted. Removable destinations are recorded as seen when mounted.
+func (r *Runner) locateDestination(dest config.BackupDestinationConfig) (string, bool, error) {
+	if !dest.Removable {
+		return dest.Path, true, nil
+	}
+
+	path, mounted := dest.Path, false
+	if dest.HasVolume() {
+		root, err := r.findVolume(volume.Volume{Label: dest.VolumeLabel, UUID: dest.VolumeUUID})
+		if err != nil {
+			return "", false, err
+		}
+		if root != "" {
+			path, mounted = filepath.Join(root, dest.Path), true
+		}
+	} else {
+		info, err := os.Stat(path)
+		mounted = err == nil && info.IsDir()
+	}
+
+	if mounted {
+		r.markDestinationSeen(dest.Name)
+	}
+	return path, mounted, nil
+}
+
+// markDestinationSeen records that a removable destination is mounted now.
+// The record is only refreshed hourly, which is plenty for a warning measured
+// in days, to avoid rewriting the state file on every check.
+func (r *Runner) markDestinationSeen(name string) {
+	r.destMu.Lock()
+	defer r.destMu.Unlock()
+
+	states := r.loadDestinationStates()
+	if state, ok := states[name]; ok && !state.Warned && time.Since(state.LastSeen) < time.Hour {
+		return
+	}
+	states[name] = destinationState{LastSeen: time.Now()}
+	r.saveDestinationStates(states)
+}
+
+// checkUnseenDestinations warns about removable destinations that haven't
+// been mounted for longer than their unseen_warning_days, once per absence.
+// Destinations never seen start counting from their first check.
+func (r *Runner) checkUnseenDestinations(ctx context.Context) {
+	r.destMu.Lock()
+	states := r.loadDestinationStates()
+	now := time.Now()
+	var unseen []config.BackupDestinationConfig
+	var lastSeen []time.Time
+	changed := false
+	for _, dest := range r.config.BackupDestinations {
+		if dest.UnseenWarningDays <= 0 {
+			continue
+		}
+		state, ok := states[dest.Name]
+		switch {
+		case !ok:
+			states[dest.Name] = destinationState{LastSeen: now}
+			changed = true
+		case !state.Warned && now.Sub(state.LastSeen) > time.Duration(dest.UnseenWarningDays)*24*time.Hour:
+			state.Warned = true
+			states[dest.Name] = state
+			changed = true
+			unseen = append(unseen, dest)
+			lastSeen = append(lastSeen, state.LastSeen)
+		}
+	}
+	if changed {
+		r.saveDestinationStates(states)
+	}
+	r.destMu.Unlock()
+
+	for i, dest := range unseen {
+		r.log(ctx).Warn("backup destination not seen recently", "destination", dest.Name, "last_seen", lastSeen[i])
+		if r.notifier == nil {
+			continue
+		}
+		notification := domain.WarningNotification(
+			"Ludusavi Backup Drive Not Seen",
+			fmt.Sprintf("Backup destination %s on %s hasn't been plugged in for more than %d days.\nLast seen: %s",
+				dest.Name, r.hostname, dest.UnseenWarningDays, lastSeen[i].Format(time.RFC1123)),
+		)
+		if err := r.notifier.Notify(ctx, notification); err != nil {
+			r.log(ctx).Error("failed to send notification", "error", err)
+		}
+	}
+}
+
+// loadDestinationStates reads the destination states. The caller must hold
+// destMu. Without a state file, states are kept in memory only.
+func (r *Runner) loadDestinationStates() map[string]destinationState {
+	if r.destStates != nil {
+		return r.destStates
+	}
+	r.destStates = make(map[string]destinationState)
+	if r.destStatePath == "" {
+		return r.destStates
+	}
+
+	data, err := os.ReadFile(r.destStatePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.logger.Warn("failed to read destination state", "error", err)
+		}
+		return r.destStates
+	}
+	if err := json.Unmarshal(data, &r.destStates); err != nil {
+		r.logger.Warn("ignoring unreadable destination state", "error", err)
+		r.destStates = make(map[string]destinationState)
+	}
+	return r.destStates
+}
+
+// saveDestinationStates writes the destination states. The caller must hold destMu.
+func (r *Runner) saveDestinationStates(states map[string]destinationState) {
+	r.destStates = states
+	if r.destStatePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(states)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.destStatePath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.destStatePath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save destination state", "error", err)
+	}
+}
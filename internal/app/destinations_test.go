@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/volume"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usbConfig returns a config with a removable destination on the volume labelled BACKUP.
+func usbConfig() *config.Config {
+	cfg := testConfig()
+	cfg.BackupDestinations = []config.BackupDestinationConfig{{
+		Name:              "usb",
+		Path:              "ludusavi",
+		Removable:         true,
+		VolumeLabel:       "BACKUP",
+		UnseenWarningDays: 7,
+	}}
+	return cfg
+}
+
+func TestRunner_CheckDestinations(t *testing.T) {
+	var paths []string
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			paths = append(paths, opts.Path)
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(true, nil)
+			return result, nil
+		},
+	}
+
+	root := t.TempDir()
+	mountedAt := ""
+	runner := NewRunner(usbConfig(), WithExecutor(mockExec))
+	runner.findVolume = func(v volume.Volume) (string, error) {
+		assert.Equal(t, volume.Volume{Label: "BACKUP"}, v)
+		return mountedAt, nil
+	}
+
+	// The first check only records the current state
+	assert.Empty(t, runner.CheckDestinations(context.Background()))
+
+	mountedAt = root
+	assert.Equal(t, []string{"usb"}, runner.CheckDestinations(context.Background()))
+
+	// Still plugged in, so nothing new
+	assert.Empty(t, runner.CheckDestinations(context.Background()))
+
+	result, err := runner.RunDestinations(context.Background(), []string{"usb"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, result.Destinations, 1)
+	assert.Equal(t, "usb", result.Destinations[0].Destination)
+	assert.Nil(t, result.Backup)
+	assert.Equal(t, []string{filepath.Join(root, "ludusavi")}, paths)
+
+	// Unplugged and plugged in again
+	mountedAt = ""
+	assert.Empty(t, runner.CheckDestinations(context.Background()))
+	mountedAt = root
+	assert.Equal(t, []string{"usb"}, runner.CheckDestinations(context.Background()))
+}
+
+func TestRunner_Run_VolumeNotMounted(t *testing.T) {
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			assert.Empty(t, opts.Path, "unmounted destination should not be backed up")
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(true, nil)
+			return result, nil
+		},
+	}
+
+	runner := NewRunner(usbConfig(), WithExecutor(mockExec))
+	runner.findVolume = func(volume.Volume) (string, error) { return "", nil }
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, result.Destinations, 1)
+	assert.True(t, result.Destinations[0].Skipped)
+}
+
+func TestRunner_UnseenDestinationWarning(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "destinations.json")
+	lastSeen := time.Now().Add(-8 * 24 * time.Hour).UTC()
+	data, err := json.Marshal(map[string]destinationState{"usb": {LastSeen: lastSeen}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(statePath, data, 0600))
+
+	mockNotifier := &notify.MockNotifier{}
+	newRunner := func() *Runner {
+		r := NewRunner(usbConfig(), WithNotifier(mockNotifier), WithDestinationStatePath(statePath))
+		r.findVolume = func(volume.Volume) (string, error) { return "", nil }
+		return r
+	}
+
+	runner := newRunner()
+	runner.CheckDestinations(context.Background())
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelWarning, mockNotifier.Notifications[0].Level)
+	assert.Equal(t, "Ludusavi Backup Drive Not Seen", mockNotifier.Notifications[0].Title)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "usb")
+
+	// Warned once per absence, even across restarts
+	runner.CheckDestinations(context.Background())
+	newRunner().CheckDestinations(context.Background())
+	assert.Len(t, mockNotifier.Notifications, 1)
+
+	// Seeing the drive again resets the warning
+	runner = newRunner()
+	runner.findVolume = func(volume.Volume) (string, error) { return t.TempDir(), nil }
+	runner.CheckDestinations(context.Background())
+	runner.destStates = nil
+	states := runner.loadDestinationStates()
+	assert.False(t, states["usb"].Warned)
+	assert.WithinDuration(t, time.Now(), states["usb"].LastSeen, time.Minute)
+}
+
+func TestRunner_UnseenDestinationWarning_NeverSeen(t *testing.T) {
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(usbConfig(), WithNotifier(mockNotifier))
+	runner.findVolume = func(volume.Volume) (string, error) { return "", nil }
+
+	// A destination never seen starts counting from now
+	runner.CheckDestinations(context.Background())
+	assert.Empty(t, mockNotifier.Notifications)
+	assert.WithinDuration(t, time.Now(), runner.destStates["usb"].LastSeen, time.Minute)
+}
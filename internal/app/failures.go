@@ -0,0 +1,153 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// ErrAckNotFound is returned when acknowledging a failure that recovered,
+// changed or was never notified.
+var ErrAckNotFound = errors.New("no such failure, it may have recovered or changed since")
+
+// failureState records how many runs in a row have failed, and whether the
+// user acknowledged their failure.
+type failureState struct {
+	Streak int `json:"streak"`
+	// Signature identifies the errors of the latest failed run.
+	Signature string `json:"signature,omitempty"`
+	// Token acknowledges the failure with Signature through its ack link.
+	Token string `json:"token,omitempty"`
+	// Acked is set once the failure was acknowledged, until it recovers or
+	// fails differently.
+	Acked bool `json:"acked,omitempty"`
+}
+
+// Acknowledgment is the failure acknowledged through an ack link.
+type Acknowledgment struct {
+	FailedRuns int `json:"failed_runs"`
+}
+
+// trackFailure updates the failure state with the outcome of a run and
+// returns it. Any failed run extends the streak, but only a successful full
+// run ends it: fast, game and destination runs back up part of the library,
+// so their success doesn't show the failure is gone. A run failing only
+// because a destination is offline leaves the state as it is, as that is
+// expected to recover on its own. A failure with other errors than the last
+// one needs acknowledging again.
+func (r *Runner) trackFailure(result *domain.RunResult) failureState {
+	r.failureMu.Lock()
+	defer r.failureMu.Unlock()
+
+	state := r.loadFailureState()
+	switch {
+	case result.DestinationOffline():
+		return *state
+	case !result.Success:
+		state.Streak++
+		if signature := failureSignature(result); signature != state.Signature {
+			state.Signature = signature
+			state.Token = rand.Text()
+			state.Acked = false
+		}
+	case isFullRun(result) && !result.Preempted && state.Streak > 0:
+		r.logger.Info("backups recovered", "failed_runs", state.Streak)
+		state = &failureState{}
+	default:
+		return *state
+	}
+	r.saveFailureState(state)
+	return *state
+}
+
+// Acknowledge acknowledges the failure the ack link with token was sent
+// for, so its notifications aren't repeated until it recovers or fails
+// differently.
+func (r *Runner) Acknowledge(token string) (*Acknowledgment, error) {
+	r.failureMu.Lock()
+	defer r.failureMu.Unlock()
+
+	state := r.loadFailureState()
+	if state.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(state.Token)) != 1 {
+		return nil, ErrAckNotFound
+	}
+	if !state.Acked {
+		r.logger.Info("failure acknowledged, suppressing repeat notifications", "failed_runs", state.Streak)
+		state.Acked = true
+		r.saveFailureState(state)
+	}
+	return &Acknowledgment{FailedRuns: state.Streak}, nil
+}
+
+// failureSignature identifies the errors of a failed run. Operations are
+// left out, so a fast backup failing like the full one before it counts as
+// the same failure.
+func failureSignature(result *domain.RunResult) string {
+	var b strings.Builder
+	for _, op := range result.Operations() {
+		if op.Success {
+			continue
+		}
+		b.WriteString(op.Destination + ": " + op.Error + "\n")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isFullRun returns true if result is of a full run rather than a fast,
+// game or destination run.
+func isFullRun(result *domain.RunResult) bool {
+	return result.Backup != nil && result.Backup.Operation == domain.OperationBackup
+}
+
+// loadFailureState reads the failure state. The caller must hold failureMu.
+// Without a state file, the state is kept in memory only.
+func (r *Runner) loadFailureState() *failureState {
+	if r.failures != nil {
+		return r.failures
+	}
+	r.failures = &failureState{}
+	if r.failurePath == "" {
+		return r.failures
+	}
+
+	data, err := os.ReadFile(r.failurePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.logger.Warn("failed to read failure state", "error", err)
+		}
+		return r.failures
+	}
+	if err := json.Unmarshal(data, r.failures); err != nil {
+		r.logger.Warn("ignoring unreadable failure state", "error", err)
+		r.failures = &failureState{}
+	}
+	return r.failures
+}
+
+// saveFailureState writes the failure state. The caller must hold failureMu.
+func (r *Runner) saveFailureState(state *failureState) {
+	r.failures = state
+	if r.failurePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.failurePath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.failurePath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save failure state", "error", err)
+	}
+}
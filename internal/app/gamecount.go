@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// minGameCountRuns is how many full backups are needed in the rolling average
+// before a drop is considered a regression.
+const minGameCountRuns = 3
+
+// gameCountState records the games found by the latest full backups.
+type gameCountState struct {
+	Counts []int `json:"counts"`
+	// Warned is set once a regression was notified, until the count recovers.
+	Warned bool `json:"warned,omitempty"`
+}
+
+// checkGameCount compares the games found by a full backup with the rolling
+// average of the previous ones and warns, once per regression, when it drops
+// by more than game_count.drop_percent. The count is added to the average
+// either way, so it adapts to a lasting drop within game_count.window runs.
+func (r *Runner) checkGameCount(ctx context.Context, backup *domain.BackupResult) {
+	cfg := r.config.GameCount
+	if !cfg.Enabled || backup == nil || !backup.Success || backup.Skipped {
+		return
+	}
+	total := backup.Stats.TotalGames
+
+	r.gameCountMu.Lock()
+	state := r.loadGameCountState()
+	average := float64(total)
+	if len(state.Counts) > 0 {
+		sum := 0
+		for _, n := range state.Counts {
+			sum += n
+		}
+		average = float64(sum) / float64(len(state.Counts))
+	}
+	regressed := len(state.Counts) >= minGameCountRuns &&
+		float64(total) < average*float64(100-cfg.DropPercent)/100
+	notify := regressed && !state.Warned
+	runs := len(state.Counts)
+
+	state.Warned = regressed
+	state.Counts = append(state.Counts, total)
+	if len(state.Counts) > cfg.Window {
+		state.Counts = state.Counts[len(state.Counts)-cfg.Window:]
+	}
+	r.saveGameCountState(state)
+	r.gameCountStats = &domain.GameCountStats{Average: average, Regressed: regressed}
+	r.gameCountMu.Unlock()
+
+	if !notify {
+		return
+	}
+
+	r.log(ctx).Warn("game count dropped", "games", total, "average", average, "runs", runs)
+	drop := 100 - float64(total)/average*100
+	notification := domain.WarningNotification(
+		"Ludusavi Game Count Dropped",
+		fmt.Sprintf("Ludusavi found %d games on %s, %.0f%% fewer than the average of %.1f over the last %d backups.\n"+
+			"A broken ludusavi manifest update or a moved game library may keep it from finding saves. "+
+			"Check the roots in ludusavi's config and what `ludusavi backup --preview` finds.",
+			total, r.hostname, drop, average, runs),
+	)
+	if err := r.notifier.Notify(ctx, notification); err != nil {
+		r.log(ctx).Error("failed to send notification", "error", err)
+	}
+}
+
+// gameCount returns the outcome of the latest game count check, if any.
+func (r *Runner) gameCount() *domain.GameCountStats {
+	r.gameCountMu.Lock()
+	defer r.gameCountMu.Unlock()
+	return r.gameCountStats
+}
+
+// loadGameCountState reads the game count state. The caller must hold
+// gameCountMu. Without a state file, the state is kept in memory only.
+func (r *Runner) loadGameCountState() *gameCountState {
+	if r.gameCounts != nil {
+		return r.gameCounts
+	}
+	r.gameCounts = &gameCountState{}
+	if r.gameCountPath == "" {
+		return r.gameCounts
+	}
+
+	data, err := os.ReadFile(r.gameCountPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.logger.Warn("failed to read game count state", "error", err)
+		}
+		return r.gameCounts
+	}
+	if err := json.Unmarshal(data, r.gameCounts); err != nil {
+		r.logger.Warn("ignoring unreadable game count state", "error", err)
+		r.gameCounts = &gameCountState{}
+	}
+	return r.gameCounts
+}
+
+// saveGameCountState writes the game count state. The caller must hold gameCountMu.
+func (r *Runner) saveGameCountState(state *gameCountState) {
+	r.gameCounts = state
+	if r.gameCountPath == "" {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.gameCountPath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.gameCountPath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save game count state", "error", err)
+	}
+}
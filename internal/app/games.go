@@ -0,0 +1,74 @@
+package app
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// TriggerGame requests a backup of the game titled title, as ludusavi names
+// it, because a session of it ended. The backup waits for the game debounce
+// period, which starts over with each further request, so a launcher
+// reporting the same session twice, or several games quitting together,
+// leads to a single backup of all of them. It is skipped while paused.
+func (s *Scheduler) TriggerGame(title string) {
+	s.logger.Info("game session ended, backup pending", logging.KeyGame, title, "debounce", s.gameDebounce)
+
+	s.gameMu.Lock()
+	defer s.gameMu.Unlock()
+
+	s.addGames(title)
+	if s.gameTimer == nil {
+		s.gameTimer = time.AfterFunc(s.gameDebounce, s.gamesDue)
+	} else {
+		s.gameTimer.Reset(s.gameDebounce)
+	}
+}
+
+// TriggerGames requests a backup of the games with these titles now, even
+// while paused, like Trigger does a full run. Games pending from TriggerGame
+// are backed up along with them.
+func (s *Scheduler) TriggerGames(titles ...string) {
+	s.gameMu.Lock()
+	s.addGames(titles...)
+	s.gamesManual = true
+	if s.gameTimer != nil {
+		s.gameTimer.Stop()
+	}
+	s.gameMu.Unlock()
+
+	s.gamesDue()
+}
+
+// addGames adds titles to the games pending backup. The caller must hold
+// gameMu.
+func (s *Scheduler) addGames(titles ...string) {
+	if s.pendingGames == nil {
+		s.pendingGames = make(map[string]bool)
+	}
+	for _, title := range titles {
+		s.pendingGames[title] = true
+	}
+}
+
+// gamesDue wakes the scheduler loop to back up the pending games.
+func (s *Scheduler) gamesDue() {
+	select {
+	case s.gamesC <- struct{}{}:
+	default:
+	}
+}
+
+// takeGames returns the titles of the games pending backup, sorted, and
+// whether it was requested manually, and clears them.
+func (s *Scheduler) takeGames() ([]string, bool) {
+	s.gameMu.Lock()
+	defer s.gameMu.Unlock()
+
+	games := slices.Sorted(maps.Keys(s.pendingGames))
+	manual := s.gamesManual
+	s.pendingGames, s.gamesManual = nil, false
+	return games, manual
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/hooks"
+)
+
+// runHook runs the hook of event for the run of result, if any. A failed
+// hook is recorded in result, failing the run. Hooks don't run in dry runs.
+func (r *Runner) runHook(ctx context.Context, result *domain.RunResult, event hooks.Event) {
+	if r.hooks == nil {
+		return
+	}
+	if r.config.DryRun {
+		r.log(ctx).Debug("dry run: skipping hook", "hook", event.Name())
+		return
+	}
+
+	event.RunID = result.ID
+	if err := r.hooks.Run(ctx, event); err != nil {
+		r.log(ctx).Error("hook failed", "hook", event.Name(), "error", err)
+		result.AddError(domain.WithErrorCode(domain.CodeHookFailed, err))
+		result.FailedHooks = append(result.FailedHooks, event.Name())
+	}
+}
+
+// runPostHook runs the post_backup hook with the completed result, which is
+// completed again if the hook failed.
+func (r *Runner) runPostHook(ctx context.Context, result *domain.RunResult) {
+	failed := len(result.FailedHooks)
+	r.runHook(ctx, result, hooks.Event{Stage: hooks.Post, Success: result.Success, Input: result})
+	if len(result.FailedHooks) > failed {
+		result.Complete()
+	}
+}
+
+// runOperation runs the operation op of the run of result with run, between
+// the operation's pre and post hooks. The post hook is given the
+// operation's result. An error is returned with the code of the failure.
+func (r *Runner) runOperation(ctx context.Context, result *domain.RunResult, op domain.OperationType,
+	run func(context.Context) (*domain.BackupResult, error)) (*domain.BackupResult, error) {
+	r.runHook(ctx, result, hooks.Event{Stage: hooks.Pre, Operation: op})
+	opResult, err := run(ctx)
+	r.runHook(ctx, result, hooks.Event{
+		Stage:     hooks.Post,
+		Operation: op,
+		Success:   err == nil && opResult != nil && opResult.Success,
+		Input:     opResult,
+	})
+	return opResult, operationError(op, err)
+}
+
+// operationError returns err, an error running op, with the code of a
+// failure of op unless its cause is known more precisely.
+func operationError(op domain.OperationType, err error) error {
+	if domain.ErrorCodeOf(err) != domain.CodeUnknown {
+		return err
+	}
+	return domain.WithErrorCode(op.FailureCode(), err)
+}
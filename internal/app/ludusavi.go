@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// ludusaviPollInterval is how often the runner checks whether another
+// ludusavi instance has exited.
+const ludusaviPollInterval = 5 * time.Second
+
+// waitForLudusavi delays a run while another ludusavi instance, such as the
+// GUI, is running, so they don't write to the backup directory at once. The
+// run goes ahead anyway after the configured wait, as the GUI may just be
+// left open.
+func (r *Runner) waitForLudusavi(ctx context.Context) {
+	if r.findLudusavi == nil || r.config.DryRun {
+		return
+	}
+	pids, err := r.findLudusavi()
+	if err != nil {
+		r.log(ctx).Debug("failed to look for a running ludusavi", "error", err)
+		return
+	}
+	if len(pids) == 0 {
+		return
+	}
+
+	r.log(ctx).Info("ludusavi is running, waiting for it to exit before backing up",
+		"pids", pids, "max_wait", r.ludusaviWait)
+	deadline := time.NewTimer(r.ludusaviWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(r.ludusaviPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			r.log(ctx).Warn("ludusavi is still running, backing up anyway", "pids", pids)
+			return
+		case <-ticker.C:
+			if pids, err = r.findLudusavi(); err != nil || len(pids) == 0 {
+				r.log(ctx).Info("ludusavi exited, backing up")
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Maintenance is a maintenance window, set while the user deliberately
+// breaks backups, as when reorganizing drives. Unlike a paused schedule,
+// backups keep running, but failures aren't notified and metrics are
+// labeled maintenance="true".
+type Maintenance struct {
+	Since time.Time `json:"since"`
+	// Until is when maintenance ends by itself, or zero to keep it on until
+	// turned off.
+	Until time.Time `json:"until,omitempty"`
+}
+
+// Active returns true if m is on at now.
+func (m *Maintenance) Active(now time.Time) bool {
+	return m != nil && (m.Until.IsZero() || now.Before(m.Until))
+}
+
+// ReadMaintenance reads the maintenance window from the state file at path,
+// returning nil if maintenance was never turned on or was turned off.
+func ReadMaintenance(path string) (*Maintenance, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read maintenance state: %w", err)
+	}
+	var m Maintenance
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance state: %w", err)
+	}
+	return &m, nil
+}
+
+// StartMaintenance turns maintenance on until until, or until turned off if
+// zero, by writing the state file at path.
+func StartMaintenance(path string, until time.Time) (*Maintenance, error) {
+	m := &Maintenance{Since: time.Now().Round(0), Until: until}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode maintenance state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write maintenance state: %w", err)
+	}
+	return m, nil
+}
+
+// EndMaintenance turns maintenance off by removing the state file at path.
+func EndMaintenance(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove maintenance state: %w", err)
+	}
+	return nil
+}
+
+// inMaintenance returns true if maintenance is on. The state file is read
+// each time, so turning maintenance on or off applies to the next run of
+// the service without restarting it.
+func (r *Runner) inMaintenance() bool {
+	if r.maintenancePath == "" {
+		return false
+	}
+	m, err := ReadMaintenance(r.maintenancePath)
+	if err != nil {
+		r.logger.Warn("ignoring unreadable maintenance state", "error", err)
+		return false
+	}
+	return m.Active(time.Now())
+}
+
+// maintenanceNotifier drops warnings and errors while maintenance is on, as
+// failures are expected then.
+type maintenanceNotifier struct {
+	domain.Notifier
+	r *Runner
+}
+
+// Notify sends a notification unless it is a warning or error sent during
+// maintenance.
+func (n *maintenanceNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	if notification.Level != domain.NotificationLevelInfo && n.r.inMaintenance() {
+		n.r.log(ctx).Info("notification suppressed, maintenance mode is on",
+			"title", notification.Title, "level", notification.Level)
+		return nil
+	}
+	return n.Notifier.Notify(ctx, notification)
+}
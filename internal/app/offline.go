@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// probeCacheTTL is how long a network probe result is reused, so a run
+// probes once rather than before every push and notification.
+const probeCacheTTL = 30 * time.Second
+
+// networkDown returns why the network is down, wrapping
+// domain.ErrNetworkOffline, or nil when it is up or offline mode is off.
+func (r *Runner) networkDown(ctx context.Context) error {
+	if r.probe == nil {
+		return nil
+	}
+
+	r.probeMu.Lock()
+	defer r.probeMu.Unlock()
+
+	if !r.probedAt.IsZero() && time.Since(r.probedAt) < probeCacheTTL {
+		return r.probeErr
+	}
+	err := r.probe(ctx)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", domain.ErrNetworkOffline, err)
+	}
+	switch {
+	case err != nil && r.probeErr == nil:
+		r.log(ctx).Warn("network offline, skipping network operations", "error", err)
+	case err == nil && r.probeErr != nil:
+		r.log(ctx).Info("network back online")
+	}
+	r.probedAt, r.probeErr = time.Now(), err
+	return err
+}
+
+// markOffline marks the run offline-degraded when the network is down, or
+// was when the cloud download or upload was due.
+func (r *Runner) markOffline(ctx context.Context, result *domain.RunResult) {
+	if r.probe == nil {
+		return
+	}
+	result.Offline = r.networkDown(ctx) != nil ||
+		(result.CloudUpload != nil && result.CloudUpload.Offline) ||
+		(result.CloudDownload != nil && result.CloudDownload.Offline)
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Limits of the metrics pushes and notifications held for later delivery;
+// the oldest are dropped beyond them.
+const (
+	maxHeldMetrics       = 20
+	maxHeldNotifications = 50
+)
+
+// outboxState holds the metrics pushes and notifications waiting to be
+// delivered, oldest first.
+type outboxState struct {
+	Metrics       []*heldMetrics      `json:"metrics,omitempty"`
+	Notifications []*heldNotification `json:"notifications,omitempty"`
+}
+
+// heldMetrics is a metrics push waiting to be delivered.
+type heldMetrics struct {
+	HeldAt  time.Time       `json:"held_at"`
+	Metrics *domain.Metrics `json:"metrics"`
+}
+
+// heldNotification is a notification waiting to be delivered.
+type heldNotification struct {
+	HeldAt       time.Time            `json:"held_at"`
+	Notification *domain.Notification `json:"notification"`
+}
+
+// outboxPusher holds back metrics pushes while the network is down, and
+// failed ones with the outbox enabled, and delivers them, oldest first,
+// before the next push.
+type outboxPusher struct {
+	domain.MetricsPusher
+	r *Runner
+}
+
+// Push pushes metrics, or holds them for a later push.
+func (p *outboxPusher) Push(ctx context.Context, metrics *domain.Metrics) error {
+	r := p.r
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	state := r.loadOutbox()
+	defer r.saveOutbox(state)
+
+	if err := r.networkDown(ctx); err != nil {
+		r.holdMetrics(state, metrics)
+		r.log(ctx).Warn("holding back metrics until the network is back", "held", len(state.Metrics))
+		return nil
+	}
+
+	if len(state.Metrics) == 0 {
+		err := p.MetricsPusher.Push(ctx, metrics)
+		if err != nil && r.outboxRetry {
+			r.holdMetrics(state, metrics)
+			return fmt.Errorf("%w (held for retry)", err)
+		}
+		return err
+	}
+
+	// Held pushes go out with this one as a single push rather than one
+	// request each, and this one is held too when they can't be delivered
+	if err := p.MetricsPusher.Push(ctx, mergeMetrics(state.Metrics, metrics)); err != nil {
+		r.holdMetrics(state, metrics)
+		return fmt.Errorf("failed to deliver held metrics: %w", err)
+	}
+	state.Metrics = nil
+	return nil
+}
+
+// mergeMetrics combines held pushes and the latest one into a single push
+// that leaves the metrics as pushing each in turn would: the service state
+// and counters of the latest, and the latest result of each operation and
+// destination, including those only the held pushes have.
+func mergeMetrics(held []*heldMetrics, latest *domain.Metrics) *domain.Metrics {
+	batches := make([][]*domain.BackupResult, 0, len(held)+1)
+	for _, h := range held {
+		batches = append(batches, h.Metrics.Results)
+	}
+
+	merged := *latest
+	merged.Results = mergeResults(append(batches, latest.Results)...)
+	return &merged
+}
+
+// mergeResults returns the latest result of each operation and destination
+// in batches, oldest first, in the order they first appear.
+func mergeResults(batches ...[]*domain.BackupResult) []*domain.BackupResult {
+	type key struct {
+		op          domain.OperationType
+		destination string
+	}
+
+	var merged []*domain.BackupResult
+	index := make(map[key]int)
+	for _, results := range batches {
+		for _, result := range results {
+			k := key{result.Operation, result.Destination}
+			if i, ok := index[k]; ok {
+				merged[i] = result
+				continue
+			}
+			index[k] = len(merged)
+			merged = append(merged, result)
+		}
+	}
+	return merged
+}
+
+// outboxNotifier holds back notifications while the network is down, and
+// failed ones with the outbox enabled, and sends them, oldest first, before
+// the next notification.
+type outboxNotifier struct {
+	domain.Notifier
+	r *Runner
+}
+
+// Notify sends a notification, or holds it for a later run.
+func (n *outboxNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	r := n.r
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	state := r.loadOutbox()
+	defer r.saveOutbox(state)
+
+	if err := r.networkDown(ctx); err != nil {
+		r.holdNotification(state, notification)
+		r.log(ctx).Warn("holding back notification until the network is back",
+			"title", notification.Title, "held", len(state.Notifications))
+		return nil
+	}
+
+	if err := n.flush(ctx, state); err != nil {
+		r.holdNotification(state, notification)
+		return err
+	}
+	err := n.Notifier.Notify(ctx, notification)
+	if err != nil && r.outboxRetry {
+		r.holdNotification(state, notification)
+		return fmt.Errorf("%w (held for retry)", err)
+	}
+	return err
+}
+
+// deliverHeld sends the held notifications if the network is up, for runs
+// that have nothing new to notify.
+func (n *outboxNotifier) deliverHeld(ctx context.Context) error {
+	r := n.r
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	state := r.loadOutbox()
+
+	if len(state.Notifications) == 0 || r.networkDown(ctx) != nil {
+		return nil
+	}
+	defer r.saveOutbox(state)
+	return n.flush(ctx, state)
+}
+
+// flush sends the held notifications, oldest first, each noting when it was
+// due. The caller must hold outboxMu.
+func (n *outboxNotifier) flush(ctx context.Context, state *outboxState) error {
+	for len(state.Notifications) > 0 {
+		held := state.Notifications[0]
+		late := *held.Notification
+		late.Body = fmt.Sprintf("%s\n\nDelayed: could not be sent at %s.",
+			late.Body, held.HeldAt.In(n.r.config.Location()).Format("Jan 2 15:04"))
+		if err := n.Notifier.Notify(ctx, &late); err != nil {
+			return fmt.Errorf("failed to send held notifications: %w", err)
+		}
+		state.Notifications = state.Notifications[1:]
+	}
+	return nil
+}
+
+// holdMetrics adds metrics to the held pushes. The caller must hold outboxMu.
+func (r *Runner) holdMetrics(state *outboxState, metrics *domain.Metrics) {
+	state.Metrics = append(state.Metrics, &heldMetrics{HeldAt: time.Now(), Metrics: metrics})
+	if len(state.Metrics) > maxHeldMetrics {
+		state.Metrics = state.Metrics[len(state.Metrics)-maxHeldMetrics:]
+	}
+}
+
+// holdNotification adds notification to the held notifications. The caller
+// must hold outboxMu.
+func (r *Runner) holdNotification(state *outboxState, notification *domain.Notification) {
+	state.Notifications = append(state.Notifications, &heldNotification{HeldAt: time.Now(), Notification: notification})
+	if len(state.Notifications) > maxHeldNotifications {
+		state.Notifications = state.Notifications[len(state.Notifications)-maxHeldNotifications:]
+	}
+}
+
+// loadOutbox reads the outbox, dropping what was held longer than the
+// outbox TTL. The caller must hold outboxMu. Without an outbox file, the
+// outbox is kept in memory only.
+func (r *Runner) loadOutbox() *outboxState {
+	if r.outbox == nil {
+		r.outbox = &outboxState{}
+		if r.outboxPath != "" {
+			data, err := os.ReadFile(r.outboxPath)
+			if err == nil {
+				err = json.Unmarshal(data, r.outbox)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				r.logger.Warn("ignoring unreadable outbox", "error", err)
+				r.outbox = &outboxState{}
+			}
+		}
+	}
+	if r.outboxTTL <= 0 {
+		return r.outbox
+	}
+
+	cutoff := time.Now().Add(-r.outboxTTL)
+	metrics := r.outbox.Metrics[:0]
+	for _, m := range r.outbox.Metrics {
+		if m.HeldAt.After(cutoff) {
+			metrics = append(metrics, m)
+		}
+	}
+	notifications := r.outbox.Notifications[:0]
+	for _, n := range r.outbox.Notifications {
+		if n.HeldAt.After(cutoff) {
+			notifications = append(notifications, n)
+		}
+	}
+	if expired := len(r.outbox.Metrics) - len(metrics) + len(r.outbox.Notifications) - len(notifications); expired > 0 {
+		r.logger.Warn("dropping expired metrics and notifications from the outbox",
+			"metrics", len(r.outbox.Metrics)-len(metrics),
+			"notifications", len(r.outbox.Notifications)-len(notifications),
+			"ttl", r.outboxTTL,
+		)
+	}
+	r.outbox.Metrics, r.outbox.Notifications = metrics, notifications
+	return r.outbox
+}
+
+// saveOutbox writes the outbox. The caller must hold outboxMu.
+func (r *Runner) saveOutbox(state *outboxState) {
+	r.outbox = state
+	if r.outboxPath == "" {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.outboxPath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.outboxPath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save outbox", "error", err)
+	}
+}
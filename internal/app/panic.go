@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
+)
+
+// panicReportTimeout bounds how long reporting a recovered panic may take.
+const panicReportTimeout = 30 * time.Second
+
+// Panics returns the number of panics recovered since the runner was created.
+func (r *Runner) Panics() int64 {
+	return r.panics.Load()
+}
+
+// handlePanic reports a panic recovered from a backup run: it logs the stack
+// trace, optionally writes a crash dump, pushes the panic counter, and sends
+// an error notification. The caller keeps the service running.
+func (r *Runner) handlePanic(value any, stack []byte) {
+	r.panics.Add(1)
+
+	r.logger.Error("recovered from panic during backup run",
+		"panic", fmt.Sprint(value),
+		"stack", string(stack),
+	)
+
+	var dumpPath string
+	if r.crashDumpDir != "" {
+		path, err := writeCrashDump(r.crashDumpDir, value, stack, time.Now())
+		if err != nil {
+			r.logger.Warn("failed to write crash dump", "error", err)
+		} else {
+			r.logger.Info("crash dump written", "path", path)
+			dumpPath = path
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), panicReportTimeout)
+	defer cancel()
+
+	if r.metricsPusher != nil {
+		if err := r.metricsPusher.Push(ctx, r.newMetrics()); err != nil {
+			r.logger.Warn("failed to push panic metrics", "error", err)
+		}
+	}
+
+	if r.notifier != nil {
+		notification := domain.ErrorNotification("Ludusavi Runner Crashed", r.buildPanicMessage(value, dumpPath))
+		if err := r.notifier.Notify(ctx, notification); err != nil {
+			r.logger.Warn("failed to send panic notification", "error", err)
+		}
+	}
+}
+
+// buildPanicMessage builds a notification message for a recovered panic.
+func (r *Runner) buildPanicMessage(value any, dumpPath string) string {
+	msg := fmt.Sprintf("The backup run on %s crashed and was recovered.\n", r.hostname)
+	msg += fmt.Sprintf("Panic: %v\n", value)
+
+	if dumpPath != "" {
+		msg += fmt.Sprintf("Crash dump: %s\n", dumpPath)
+	}
+
+	msg += "The service is still running and will retry on the next interval."
+
+	return msg
+}
+
+// writeCrashDump writes the panic, its stack trace, and the stacks of all
+// goroutines to a new file in dir, for attaching to bug reports.
+func writeCrashDump(dir string, value any, stack []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create crash dump directory: %w", err)
+	}
+
+	var b strings.Builder
+	info := version.Get()
+	fmt.Fprintf(&b, "ludusavi-runner crash dump\n\n")
+	fmt.Fprintf(&b, "Time:    %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version: %s (%s)\n", info.Version, info.Commit)
+	fmt.Fprintf(&b, "Go:      %s %s/%s\n\n", info.GoVersion, info.OS, info.Arch)
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", value, stack)
+	fmt.Fprintf(&b, "All goroutines:\n\n%s", allStacks())
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s.txt", now.UTC().Format("20060102T150405.000Z")))
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash dump: %w", err)
+	}
+
+	return path, nil
+}
+
+// allStacks returns the stack traces of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
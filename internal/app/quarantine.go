@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// QuarantinedGame is a game left out of backups for failing in too many
+// backups in a row.
+type QuarantinedGame struct {
+	Title string `json:"title"`
+	// Failures is how many backups in a row failed the game.
+	Failures int `json:"failures"`
+	// Error is the error of its latest failure.
+	Error string    `json:"error"`
+	Since time.Time `json:"since"`
+	// RetryAt is when the game is next tried again.
+	RetryAt time.Time `json:"retry_at"`
+}
+
+// quarantineState records the games failing to back up, by title.
+type quarantineState struct {
+	Games map[string]*gameFailures `json:"games,omitempty"`
+}
+
+// gameFailures records the failures in a row of a game.
+type gameFailures struct {
+	Failures int    `json:"failures"`
+	Error    string `json:"error"`
+	// Since is when the game was quarantined, or zero while it isn't.
+	Since time.Time `json:"since,omitzero"`
+	// TriedAt is when the game in quarantine was last tried.
+	TriedAt time.Time `json:"tried_at,omitzero"`
+}
+
+// quarantineExclude returns the titles of the games to leave out of a
+// backup: the games in quarantine, except with retries those due to be
+// tried again. It returns nil with quarantine disabled.
+func (r *Runner) quarantineExclude(retries bool) []string {
+	if !r.config.Quarantine.Enabled {
+		return nil
+	}
+
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	var titles []string
+	for title, game := range r.loadQuarantineState().Games {
+		if game.Since.IsZero() || retries && !time.Now().Before(game.TriedAt.Add(r.config.Quarantine.RetryInterval)) {
+			continue
+		}
+		titles = append(titles, title)
+	}
+	slices.Sort(titles)
+	return titles
+}
+
+// trackQuarantine counts the games that failed in a local backup, and
+// quarantines those that failed quarantine.after backups in a row. Games that
+// backed up are released. It returns true if every game that failed was in
+// quarantine already, so only retries failed, which doesn't fail the backup.
+func (r *Runner) trackQuarantine(ctx context.Context, backup *domain.BackupResult) bool {
+	if !r.config.Quarantine.Enabled {
+		return false
+	}
+
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	state := r.loadQuarantineState()
+	if state.Games == nil {
+		state.Games = make(map[string]*gameFailures)
+	}
+	now := time.Now()
+	retriesOnly := len(backup.FailedGames) > 0
+	changed := false
+
+	for _, title := range slices.Sorted(maps.Keys(backup.FailedGames)) {
+		game := state.Games[title]
+		if game == nil {
+			game = &gameFailures{}
+			state.Games[title] = game
+		}
+		game.Failures++
+		game.Error = backup.FailedGames[title]
+		changed = true
+
+		switch {
+		case !game.Since.IsZero():
+			game.TriedAt = now
+			r.log(ctx).Warn("quarantined game failed again", logging.KeyGame, title,
+				"failures", game.Failures, "retry_in", r.config.Quarantine.RetryInterval)
+		case game.Failures >= r.config.Quarantine.After:
+			game.Since, game.TriedAt = now, now
+			retriesOnly = false
+			r.log(ctx).Warn("game quarantined, leaving it out of backups", logging.KeyGame, title,
+				"failures", game.Failures, "error", game.Error, "retry_in", r.config.Quarantine.RetryInterval)
+		default:
+			retriesOnly = false
+		}
+	}
+
+	for title := range backup.GameBytes {
+		game := state.Games[title]
+		if game == nil || backup.FailedGames[title] != "" {
+			continue
+		}
+		if !game.Since.IsZero() {
+			r.log(ctx).Info("quarantined game backed up, releasing it", logging.KeyGame, title, "failures", game.Failures)
+		}
+		delete(state.Games, title)
+		changed = true
+	}
+
+	if changed {
+		r.saveQuarantineState(state)
+	}
+	return retriesOnly
+}
+
+// Quarantined returns the games in quarantine, by title.
+func (r *Runner) Quarantined() []QuarantinedGame {
+	if !r.config.Quarantine.Enabled {
+		return nil
+	}
+
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	state := r.loadQuarantineState()
+	var games []QuarantinedGame
+	for _, title := range slices.Sorted(maps.Keys(state.Games)) {
+		game := state.Games[title]
+		if game.Since.IsZero() {
+			continue
+		}
+		games = append(games, QuarantinedGame{
+			Title:    title,
+			Failures: game.Failures,
+			Error:    game.Error,
+			Since:    game.Since,
+			RetryAt:  game.TriedAt.Add(r.config.Quarantine.RetryInterval),
+		})
+	}
+	return games
+}
+
+// loadQuarantineState reads the quarantine state. The caller must hold
+// quarantineMu. Without a state file, the state is kept in memory only.
+func (r *Runner) loadQuarantineState() *quarantineState {
+	if r.quarantine != nil {
+		return r.quarantine
+	}
+	r.quarantine = &quarantineState{}
+	if r.quarantinePath == "" {
+		return r.quarantine
+	}
+
+	data, err := os.ReadFile(r.quarantinePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.logger.Warn("failed to read quarantine state", "error", err)
+		}
+		return r.quarantine
+	}
+	if err := json.Unmarshal(data, r.quarantine); err != nil {
+		r.logger.Warn("ignoring unreadable quarantine state", "error", err)
+		r.quarantine = &quarantineState{}
+	}
+	return r.quarantine
+}
+
+// saveQuarantineState writes the quarantine state. The caller must hold
+// quarantineMu.
+func (r *Runner) saveQuarantineState(state *quarantineState) {
+	r.quarantine = state
+	if r.quarantinePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.quarantinePath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.quarantinePath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save quarantine state", "error", err)
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// jobKind is the kind of run a job is.
+type jobKind string
+
+const (
+	jobFull         jobKind = "full"
+	jobFast         jobKind = "fast"
+	jobGames        jobKind = "games"
+	jobDestinations jobKind = "destinations"
+	jobRestore      jobKind = "restore"
+)
+
+// job is a backup or restore run waiting in the queue.
+type job struct {
+	kind jobKind
+
+	// what describes what queued the job, e.g. "scheduled backup", for
+	// logs and calendar skips.
+	what string
+
+	// manual jobs run even while scheduled backups are paused or skipped.
+	manual bool
+
+	// names are the games of a games or restore job, or the destinations
+	// of a destinations job.
+	names []string
+
+	// restore is what a restore job restores. Its result is sent to done,
+	// nil if it didn't run, unless caller, the context of the request it
+	// runs for, was cancelled while it was queued.
+	restore domain.RestoreOptions
+	caller  context.Context
+	done    chan *domain.RunResult
+}
+
+// String describes the job in the scheduler status.
+func (j *job) String() string {
+	if len(j.names) == 0 {
+		return j.what
+	}
+	return fmt.Sprintf("%s (%s)", j.what, strings.Join(j.names, ", "))
+}
+
+// priority orders the queue: manual runs go first, then those triggered by
+// events such as a game session ending, then scheduled runs.
+func (j *job) priority() int {
+	switch {
+	case j.manual:
+		return 2
+	case j.eventDriven():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// eventDriven reports whether j was queued by an event rather than by hand
+// or the schedule: a game session ending or a backup drive plugged in.
+func (j *job) eventDriven() bool {
+	return !j.manual && (j.kind == jobGames || j.kind == jobDestinations)
+}
+
+// merge folds other, a job of the same kind or one j covers, into j.
+func (j *job) merge(other *job) {
+	if other.manual && !j.manual {
+		j.manual = true
+		j.what = other.what
+	}
+	if other.kind == j.kind {
+		for _, name := range other.names {
+			if !slices.Contains(j.names, name) {
+				j.names = append(j.names, name)
+			}
+		}
+		slices.Sort(j.names)
+	}
+}
+
+// covers reports whether j, once run, makes other redundant: a full run
+// backs up every game, so it covers fast and game backups, unless other
+// would run while paused and j wouldn't.
+func (j *job) covers(other *job) bool {
+	return j.kind == jobFull &&
+		(other.kind == jobFast || other.kind == jobGames) &&
+		(j.manual || !other.manual)
+}
+
+// jobQueue holds the backup runs waiting for the one in progress to finish,
+// by priority and then in the order they were queued. Runs coalesce rather than pile up: a job of
+// a kind already queued merges into that job, and a full run takes the place
+// of the fast and game backups it covers. Restores, each waited for by its
+// caller, never coalesce.
+type jobQueue struct {
+	mu   sync.Mutex
+	jobs []*job
+
+	// ready is signalled when a job is queued.
+	ready chan struct{}
+}
+
+// newJobQueue creates an empty jobQueue.
+func newJobQueue() *jobQueue {
+	return &jobQueue{ready: make(chan struct{}, 1)}
+}
+
+// push queues j, or merges it into a queued job, and reports whether it was
+// queued as a new job.
+func (q *jobQueue) push(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, queued := range q.jobs {
+		if queued.kind == j.kind && j.kind != jobRestore || queued.covers(j) {
+			queued.merge(j)
+			q.sort()
+			return false
+		}
+	}
+
+	q.jobs = slices.DeleteFunc(q.jobs, j.covers)
+	q.jobs = append(q.jobs, j)
+	q.sort()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// sort orders the jobs by priority, keeping the order they were queued in
+// otherwise. q.mu must be held.
+func (q *jobQueue) sort() {
+	slices.SortStableFunc(q.jobs, func(a, b *job) int {
+		return cmp.Compare(b.priority(), a.priority())
+	})
+}
+
+// pop removes and returns the next job, or nil if there is none.
+func (q *jobQueue) pop() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.jobs) == 0 {
+		return nil
+	}
+	j := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	return j
+}
+
+// list describes the queued jobs, in order.
+func (q *jobQueue) list() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return describeJobs(q.jobs)
+}
+
+// clear empties the queue, returning descriptions of the jobs it held.
+func (q *jobQueue) clear() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	list := describeJobs(q.jobs)
+	q.jobs = nil
+	return list
+}
+
+// describeJobs returns the descriptions of jobs, or nil if there are none.
+func describeJobs(jobs []*job) []string {
+	if len(jobs) == 0 {
+		return nil
+	}
+	list := make([]string, len(jobs))
+	for i, j := range jobs {
+		list[i] = j.String()
+	}
+	return list
+}
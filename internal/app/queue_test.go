@@ -0,0 +1,75 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQueue(t *testing.T) {
+	t.Run("coalesces jobs of the same kind", func(t *testing.T) {
+		q := newJobQueue()
+		assert.True(t, q.push(&job{kind: jobGames, what: "game backup", names: []string{"Hades"}}))
+		assert.True(t, q.push(&job{kind: jobFast, what: "fast backup"}))
+		assert.False(t, q.push(&job{kind: jobGames, what: "game backup", names: []string{"Celeste", "Hades"}}))
+		assert.False(t, q.push(&job{kind: jobFast, what: "fast backup"}))
+
+		assert.Equal(t, []string{"game backup (Celeste, Hades)", "fast backup"}, q.list())
+	})
+
+	t.Run("full run covers fast and game backups", func(t *testing.T) {
+		q := newJobQueue()
+		q.push(&job{kind: jobFast, what: "fast backup"})
+		q.push(&job{kind: jobDestinations, what: "destination backup", names: []string{"usb"}})
+		q.push(&job{kind: jobGames, what: "game backup", names: []string{"Hades"}})
+		assert.True(t, q.push(&job{kind: jobFull, what: "scheduled backup"}))
+		assert.Equal(t, []string{"destination backup (usb)", "scheduled backup"}, q.list())
+
+		// and those queued after it
+		assert.False(t, q.push(&job{kind: jobGames, what: "game backup", names: []string{"Celeste"}}))
+		assert.Equal(t, []string{"destination backup (usb)", "scheduled backup"}, q.list())
+	})
+
+	t.Run("manual jobs are not covered by scheduled ones", func(t *testing.T) {
+		q := newJobQueue()
+		q.push(&job{kind: jobGames, what: "game backup", manual: true, names: []string{"Hades"}})
+		q.push(&job{kind: jobFull, what: "scheduled backup"})
+		assert.Equal(t, []string{"game backup (Hades)", "scheduled backup"}, q.list())
+
+		// A manual run makes the job it merges into manual
+		q.push(&job{kind: jobFull, what: "manual backup", manual: true})
+		assert.Equal(t, []string{"game backup (Hades)", "manual backup"}, q.list())
+	})
+
+	t.Run("orders jobs by priority", func(t *testing.T) {
+		q := newJobQueue()
+		q.push(&job{kind: jobFull, what: "scheduled backup"})
+		q.push(&job{kind: jobDestinations, what: "destination backup", names: []string{"usb"}})
+		q.push(&job{kind: jobGames, what: "game backup", manual: true, names: []string{"Hades"}})
+		assert.Equal(t, []string{"game backup (Hades)", "destination backup (usb)", "scheduled backup"}, q.list())
+
+		// A job made manual by a merge moves up
+		q.push(&job{kind: jobDestinations, what: "manual destination backup", manual: true, names: []string{"nas"}})
+		assert.Equal(t, []string{"game backup (Hades)", "manual destination backup (nas, usb)", "scheduled backup"}, q.list())
+	})
+
+	t.Run("restores never coalesce", func(t *testing.T) {
+		q := newJobQueue()
+		q.push(&job{kind: jobFull, what: "scheduled backup"})
+		assert.True(t, q.push(&job{kind: jobRestore, what: "restore", manual: true, names: []string{"Hades"}}))
+		assert.True(t, q.push(&job{kind: jobRestore, what: "restore", manual: true, names: []string{"Celeste"}}))
+		assert.Equal(t, []string{"restore (Hades)", "restore (Celeste)", "scheduled backup"}, q.list())
+	})
+
+	t.Run("pop", func(t *testing.T) {
+		q := newJobQueue()
+		q.push(&job{kind: jobFast, what: "fast backup"})
+		q.push(&job{kind: jobFull, what: "manual backup", manual: true})
+
+		j := q.pop()
+		assert.Equal(t, jobFull, j.kind)
+		assert.True(t, j.manual)
+		assert.Nil(t, q.pop())
+		assert.Empty(t, q.clear())
+	})
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// swappableNotifier sends notifications to a notifier that can be replaced
+// while the runner is in use, along with the level notifications are sent
+// at, when the config is reloaded.
+type swappableNotifier struct {
+	mu       sync.RWMutex
+	notifier domain.Notifier
+	level    config.NotifyLevel
+}
+
+// current returns the notifier and level in use.
+func (n *swappableNotifier) current() (domain.Notifier, config.NotifyLevel) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.notifier, n.level
+}
+
+// Notify sends the notification with the notifier in use.
+func (n *swappableNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	notifier, _ := n.current()
+	return notifier.Notify(ctx, notification)
+}
+
+// Validate checks the notifier in use.
+func (n *swappableNotifier) Validate(ctx context.Context) error {
+	notifier, _ := n.current()
+	return notifier.Validate(ctx)
+}
+
+// SetNotifications replaces the notifier and the level notifications are
+// sent at, as when the config is reloaded. A notification being sent
+// meanwhile still goes to the previous notifier.
+func (r *Runner) SetNotifications(level config.NotifyLevel, notifier domain.Notifier) {
+	r.notifications.mu.Lock()
+	defer r.notifications.mu.Unlock()
+	r.notifications.notifier = notifier
+	r.notifications.level = level
+}
+
+// notifyLevel returns the level notifications are sent at.
+func (r *Runner) notifyLevel() config.NotifyLevel {
+	_, level := r.notifications.current()
+	return level
+}
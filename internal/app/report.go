@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/report"
+)
+
+// reportPeriod is the period a report covers.
+const reportPeriod = 7 * 24 * time.Hour
+
+// reportRecheck bounds how long the reporter sleeps, so it keeps to the
+// wall clock across sleeps and clock changes.
+const reportRecheck = time.Hour
+
+// reportState records the last report made.
+type reportState struct {
+	LastReport time.Time `json:"last_report"`
+}
+
+// Reporter makes a weekly report on the backups from the run history, and
+// sends it through a notifier and/or writes it to a directory.
+type Reporter struct {
+	history   *history.Store
+	weekday   time.Weekday
+	at        time.Duration
+	loc       *time.Location
+	hostname  string
+	notifier  domain.Notifier
+	dir       string
+	statePath string
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// ReporterOption configures a Reporter.
+type ReporterOption func(*Reporter)
+
+// WithReportSchedule sets when reports are made: on weekday, at the offset
+// at from midnight in loc.
+func WithReportSchedule(weekday time.Weekday, at time.Duration, loc *time.Location) ReporterOption {
+	return func(r *Reporter) {
+		r.weekday = weekday
+		r.at = at
+		r.loc = loc
+	}
+}
+
+// WithReportNotifier sends reports through notifier.
+func WithReportNotifier(notifier domain.Notifier) ReporterOption {
+	return func(r *Reporter) {
+		r.notifier = notifier
+	}
+}
+
+// WithReportDir writes reports to dir.
+func WithReportDir(dir string) ReporterOption {
+	return func(r *Reporter) {
+		r.dir = dir
+	}
+}
+
+// WithReportStatePath sets the file recording the last report, so a report
+// missed while stopped is made on start.
+func WithReportStatePath(path string) ReporterOption {
+	return func(r *Reporter) {
+		r.statePath = path
+	}
+}
+
+// WithReporterLogger sets the logger.
+func WithReporterLogger(logger *slog.Logger) ReporterOption {
+	return func(r *Reporter) {
+		r.logger = logger
+	}
+}
+
+// NewReporter creates a new Reporter on the runs in store, by default each
+// Monday at 09:00 local time.
+func NewReporter(store *history.Store, opts ...ReporterOption) *Reporter {
+	hostname, _ := os.Hostname()
+	r := &Reporter{
+		history:  store,
+		weekday:  time.Monday,
+		at:       9 * time.Hour,
+		loc:      time.Local,
+		hostname: hostname,
+		logger:   slog.Default(),
+		now:      wallClock,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run makes a report each week until ctx is cancelled. The first report is
+// made at the first scheduled time after the reporter first ran.
+func (r *Reporter) Run(ctx context.Context) {
+	last, err := r.lastReport()
+	if err != nil {
+		r.logger.Warn("failed to read report state", "error", err)
+	}
+	if last.IsZero() {
+		last = r.due(r.now())
+		r.saveLastReport(last)
+	}
+
+	for {
+		if due := r.due(r.now()); due.After(last) {
+			if err := r.Send(ctx, due); err != nil {
+				r.logger.Warn("failed to send weekly report", "error", err)
+			}
+			last = due
+			r.saveLastReport(last)
+		}
+
+		next := r.due(r.now()).Add(reportPeriod)
+		timer := time.NewTimer(min(next.Sub(r.now()), reportRecheck))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Report returns the report on the week up to to.
+func (r *Reporter) Report(to time.Time) (*report.Report, error) {
+	from := to.Add(-reportPeriod)
+	records, err := r.history.Since(from)
+	if err != nil {
+		return nil, err
+	}
+	return report.New(r.hostname, records, from, to, r.loc), nil
+}
+
+// Send makes the report on the week up to to, writes it to the directory
+// and sends it through the notifier. Returns an error if either fails, but
+// attempts both.
+func (r *Reporter) Send(ctx context.Context, to time.Time) error {
+	rep, err := r.Report(to)
+	if err != nil {
+		return err
+	}
+	r.logger.Info("sending weekly report", "runs", rep.Total(), "failed", rep.Failed)
+
+	var errs []error
+	if r.dir != "" {
+		if err := r.write(rep); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.notifier != nil {
+		notification := &domain.Notification{
+			Title: rep.Title(),
+			Body:  rep.Markdown(),
+			Level: domain.NotificationLevelInfo,
+		}
+		if err := r.notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify report: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// write writes the report to the directory as HTML and Markdown, and as
+// latest.html.
+func (r *Reporter) write(rep *report.Report) error {
+	page, err := rep.HTML()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0750); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	name := "report-" + rep.To.Format(time.DateOnly)
+	files := map[string]string{
+		name + ".html": page,
+		name + ".md":   rep.Markdown(),
+		"latest.html":  page,
+	}
+	for file, content := range files {
+		// #nosec G306 -- reports are for dashboards and contain no secrets
+		if err := os.WriteFile(filepath.Join(r.dir, file), []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
+
+// due returns the latest scheduled report time at or before now.
+func (r *Reporter) due(now time.Time) time.Time {
+	now = now.In(r.loc)
+	days := (int(now.Weekday()) - int(r.weekday) + 7) % 7
+	y, m, d := now.Date()
+	hour, minute := int(r.at/time.Hour), int(r.at%time.Hour/time.Minute)
+	due := time.Date(y, m, d-days, hour, minute, 0, 0, r.loc)
+	if due.After(now) {
+		due = time.Date(y, m, d-days-7, hour, minute, 0, 0, r.loc)
+	}
+	return due
+}
+
+// lastReport reads the time of the last report, zero if none was made.
+func (r *Reporter) lastReport() (time.Time, error) {
+	if r.statePath == "" {
+		return time.Time{}, nil
+	}
+	data, err := os.ReadFile(r.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	var state reportState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return time.Time{}, err
+	}
+	return state.LastReport, nil
+}
+
+// saveLastReport records the time of the last report.
+func (r *Reporter) saveLastReport(t time.Time) {
+	if r.statePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(reportState{LastReport: t})
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.statePath), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(r.statePath, data, 0600)
+	}
+	if err != nil {
+		r.logger.Warn("failed to save report state", "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter_Due(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	reporter := NewReporter(nil, WithReportSchedule(time.Monday, 9*time.Hour+30*time.Minute, loc))
+	monday := time.Date(2026, 3, 2, 9, 30, 0, 0, loc)
+
+	assert.Equal(t, monday, reporter.due(monday))
+	assert.Equal(t, monday, reporter.due(monday.Add(3*24*time.Hour)))
+	assert.Equal(t, monday.AddDate(0, 0, -7), reporter.due(monday.Add(-time.Minute)))
+	// Times are read in the schedule's timezone
+	assert.Equal(t, monday, reporter.due(time.Date(2026, 3, 2, 7, 30, 0, 0, time.UTC)))
+}
+
+func TestReporter_Send(t *testing.T) {
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	result := domain.NewRunResult(false)
+	result.Backup = domain.NewBackupResult(domain.OperationBackup)
+	result.Backup.Complete(true, nil)
+	result.Complete()
+	require.NoError(t, store.Record(result))
+
+	dir := filepath.Join(t.TempDir(), "reports")
+	mockNotifier := &notify.MockNotifier{}
+	reporter := NewReporter(store, WithReportDir(dir), WithReportNotifier(mockNotifier))
+
+	to := time.Now().Add(time.Minute)
+	require.NoError(t, reporter.Send(context.Background(), to))
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	notification := mockNotifier.Notifications[0]
+	assert.Equal(t, domain.NotificationLevelInfo, notification.Level)
+	assert.Contains(t, notification.Title, "Ludusavi Backup Report")
+	assert.Contains(t, notification.Body, "- Runs: 1 (1 full)")
+
+	name := "report-" + to.Format(time.DateOnly)
+	for _, file := range []string{name + ".html", name + ".md", "latest.html"} {
+		assert.FileExists(t, filepath.Join(dir, file))
+	}
+}
+
+func TestReporter_Run_CatchesUp(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "report.json")
+	dir := filepath.Join(t.TempDir(), "reports")
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	reporter := NewReporter(store, WithReportDir(dir), WithReportStatePath(statePath))
+
+	run := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			reporter.Run(ctx)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		<-done
+	}
+
+	// The first start only records when reports start from
+	run()
+	assert.NoDirExists(t, dir)
+	last, err := reporter.lastReport()
+	require.NoError(t, err)
+	assert.True(t, reporter.due(time.Now()).Equal(last))
+
+	// A report missed while stopped is made on start
+	reporter.saveLastReport(last.AddDate(0, 0, -7))
+	run()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	last, err = reporter.lastReport()
+	require.NoError(t, err)
+	assert.True(t, reporter.due(time.Now()).Equal(last))
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+)
+
+// Restore restores saves from the backups as a run of its own. Its result
+// is pushed as metrics of the restore operation, notified by the configured
+// level and kept in the run history, but doesn't count towards the backup
+// status or the failure streak of backups. A preview writes nothing, so it
+// is only logged.
+func (r *Runner) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.RunResult, error) {
+	result := domain.NewRunResult(r.config.DryRun)
+	ctx = logging.WithAttrs(ctx, logging.KeyRunID, result.ID)
+
+	ctx = tracing.ContextWithTracer(ctx, r.tracer)
+	ctx, span := tracing.Start(ctx, "restore run", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("host.name", r.hostname)
+	span.SetAttribute("run.id", result.ID)
+	span.SetAttribute("dry_run", r.config.DryRun)
+	span.SetAttribute("preview", opts.Preview)
+	if len(opts.Games) > 0 {
+		ctx = logging.WithAttrs(ctx, logging.KeyGames, opts.Games)
+		span.SetAttribute("games", strings.Join(opts.Games, ", "))
+	}
+
+	r.log(ctx).Info("starting restore run", "dry_run", r.config.DryRun, "preview", opts.Preview)
+
+	if r.executor != nil {
+		r.waitForLudusavi(ctx)
+		restoreResult, err := r.runRestore(ctx, opts)
+		if err != nil {
+			r.log(ctx).Error("restore failed", "error", err)
+			result.AddError(operationError(domain.OperationRestore, err))
+		}
+		result.Restore = restoreResult
+	}
+
+	result.Maintenance = r.inMaintenance()
+	result.Complete()
+
+	if !opts.Preview {
+		if err := r.pushMetrics(ctx, result); err != nil {
+			r.log(ctx).Error("failed to push metrics", "error", err)
+			result.AddError(err)
+		}
+		if err := r.notifyRestore(ctx, result); err != nil {
+			r.log(ctx).Error("failed to send notification", "error", err)
+		}
+		if r.history != nil {
+			if err := r.history.Record(result); err != nil {
+				r.log(ctx).Warn("failed to record run history", "error", err)
+			}
+		}
+	}
+
+	r.log(ctx).Info("restore run completed",
+		"success", result.Success,
+		"duration", result.Duration,
+	)
+
+	span.SetSuccess(result.Success, strings.Join(result.Errors, "; "))
+
+	return result, nil
+}
+
+// runRestore executes the restore operation.
+func (r *Runner) runRestore(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, domain.OperationRestore.String())
+	ctx, span := tracing.Start(ctx, "restore", tracing.SpanKindInternal)
+	defer span.End()
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping restore")
+		result := domain.NewBackupResult(domain.OperationRestore)
+		result.Games = opts.Games
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	result, err := r.executor.Restore(ctx, opts)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("restore error: %w", err)
+	}
+	recordResult(span, result)
+
+	switch {
+	case !result.Success:
+		r.log(ctx).Warn("restore failed", "error", result.Error)
+	case opts.Preview:
+		r.log(ctx).Info("restore preview completed",
+			"games_total", result.Stats.TotalGames,
+			"games_changed", result.Stats.ChangedGames,
+			"bytes_total", result.Stats.TotalBytes,
+			"duration", result.Duration,
+		)
+	default:
+		r.log(ctx).Info("restore completed",
+			"games_processed", result.Stats.ProcessedGames,
+			"bytes_processed", result.Stats.ProcessedBytes,
+			"duration", result.Duration,
+		)
+	}
+
+	return result, nil
+}
+
+// notifyRestore notifies the result of a restore run: failures at any
+// level, successes at the "always" level.
+func (r *Runner) notifyRestore(ctx context.Context, result *domain.RunResult) error {
+	if r.notifier == nil {
+		return nil
+	}
+
+	var notification *domain.Notification
+	switch {
+	case !result.Success:
+		msg := fmt.Sprintf("Restore failed on %s.\n", r.hostname)
+		if result.Restore != nil && result.Restore.Error != "" {
+			msg += fmt.Sprintf("Restore error: %s [%s]\n", result.Restore.Error, result.Restore.ErrorCode())
+		}
+		msg += errorLines(result)
+		msg += hintLines(result)
+		notification = domain.ErrorNotification("Ludusavi Restore Failed", msg)
+	case r.notifyLevel() == config.NotifyAlways:
+		msg := fmt.Sprintf("Restore completed successfully on %s.\n", r.hostname)
+		if result.Restore != nil {
+			msg += fmt.Sprintf("Games: %d restored\n", result.Restore.Stats.ProcessedGames)
+		}
+		msg += fmt.Sprintf("Duration: %s", result.Duration.Round(100000000)) // Round to 0.1s
+		notification = domain.InfoNotification("Ludusavi Restore Completed", msg)
+	default:
+		return nil
+	}
+
+	notification.Body = strings.TrimRight(notification.Body, "\n") + "\n\nRun ID: " + result.ID
+	notification.Result = result
+
+	ctx, span := tracing.Start(ctx, "notify", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("notification.level", string(notification.Level))
+
+	err := r.notifier.Notify(ctx, notification)
+	span.RecordError(err)
+	return err
+}
@@ -3,22 +3,156 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/config"
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/hooks"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/procstats"
+	"github.com/sharkusmanch/ludusavi-runner/internal/snapshot"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+	"github.com/sharkusmanch/ludusavi-runner/internal/volume"
 )
 
 // Runner orchestrates backup operations.
 type Runner struct {
 	executor      domain.Executor
+	archiver      domain.Archiver
+	custom        domain.CustomBackuper
+	extras        domain.CustomBackuper
 	metricsPusher domain.MetricsPusher
 	notifier      domain.Notifier
+	publisher     domain.RunPublisher
+	tracer        *tracing.Tracer
 	config        *config.Config
 	logger        *slog.Logger
 	hostname      string
+	crashDumpDir  string
+
+	// panics counts panics recovered from runs since the runner was created.
+	panics atomic.Int64
+
+	// cloudDownloaded is set once a full run downloaded from the cloud, for
+	// the "startup" cloud download mode.
+	cloudDownloaded atomic.Bool
+
+	// paused is set while the scheduler has scheduled runs paused, for
+	// metrics.
+	paused atomic.Bool
+
+	statsMu            sync.Mutex
+	watchdogRecoveries map[string]int64
+	pushErrors         map[string]int64
+	pushes             domain.DeliveryCounts
+	errorCodes         map[domain.ErrorCode]int64
+
+	// notifications is the notifier set with WithNotifier, and the level
+	// notifications are sent at, both replaced on config reload; see
+	// reload.go.
+	notifications *swappableNotifier
+
+	// notificationCounts returns the notifications sent and failed by
+	// target, if they are counted.
+	notificationCounts func() map[string]domain.DeliveryCounts
+
+	// The metrics pusher and notifier without the outbox and maintenance
+	// mode, for the startup self-test; see selftest.go.
+	selfTestPusher   domain.MetricsPusher
+	selfTestNotifier domain.Notifier
+
+	// The latest run and the end of the latest successful run, for the
+	// status badge; guarded by statsMu. See badge.go.
+	lastRun     *domain.RunResult
+	lastSuccess time.Time
+
+	// hooks, if set, runs commands before and after runs and their
+	// operations; see hooks.go.
+	hooks *hooks.Runner
+
+	// storeSnapshots, if set, snapshots the backup store before and/or
+	// after the local backup of full runs.
+	storeSnapshots *snapshot.Manager
+	snapshotPre    bool
+	snapshotPost   bool
+
+	// Removable destination tracking; see destinations.go.
+	findVolume    func(volume.Volume) (string, error)
+	destStatePath string
+	destMu        sync.Mutex
+	destMounted   map[string]bool
+	destStates    map[string]destinationState
+
+	// tokenSource, if set, is checked for expiring cloud tokens after each
+	// full run; see tokens.go.
+	tokenSource domain.CloudTokenSource
+	tokenMu     sync.Mutex
+	tokenWarned map[string]time.Time
+
+	// Games already warned about by the size guard; see sizeguard.go.
+	sizeMu     sync.Mutex
+	sizeWarned map[string]bool
+
+	// Game count regression check; see gamecount.go.
+	gameCountPath  string
+	gameCountMu    sync.Mutex
+	gameCounts     *gameCountState
+	gameCountStats *domain.GameCountStats
+
+	// Games failing to back up, and those in quarantine; see quarantine.go.
+	quarantinePath string
+	quarantineMu   sync.Mutex
+	quarantine     *quarantineState
+
+	// Runs failed in a row, for escalating notifications; see failures.go.
+	failurePath string
+	failureMu   sync.Mutex
+	failures    *failureState
+	// ackURL, if set, is the base URL of the embedded HTTP server, which
+	// failure notifications link to for acknowledging them.
+	ackURL string
+
+	// history, if set, keeps the result of every run.
+	history domain.RunHistory
+
+	// findLudusavi, if set, returns other running ludusavi instances, which
+	// runs wait up to ludusaviWait for to exit; see ludusavi.go.
+	findLudusavi func() ([]int, error)
+	ludusaviWait time.Duration
+	ludusaviPoll time.Duration
+
+	// maintenancePath is the maintenance mode state file; see maintenance.go.
+	maintenancePath string
+
+	// probe, if set, checks the network before network operations, which
+	// are skipped or held back while it is down; see offline.go.
+	probe    func(ctx context.Context) error
+	probeMu  sync.Mutex
+	probedAt time.Time
+	probeErr error
+
+	// Metrics pushes and notifications held for later delivery, while the
+	// network is down or, with outboxRetry, after failing; see outbox.go.
+	outboxPath     string
+	outboxTTL      time.Duration
+	outboxRetry    bool
+	outboxMu       sync.Mutex
+	outbox         *outboxState
+	outboxNotifier *outboxNotifier
+
+	// pushed, guarded by pushedMu, is the latest result of each operation
+	// and destination pushed as metrics; see pushMetrics.
+	pushedMu sync.Mutex
+	pushed   []*domain.BackupResult
 }
 
 // RunnerOption configures a Runner.
@@ -31,6 +165,29 @@ func WithExecutor(e domain.Executor) RunnerOption {
 	}
 }
 
+// WithArchiver sets the archiver used to export backups after each run.
+func WithArchiver(a domain.Archiver) RunnerOption {
+	return func(r *Runner) {
+		r.archiver = a
+	}
+}
+
+// WithCustomBackuper sets the backuper of custom games, run after the local
+// backup of each full run.
+func WithCustomBackuper(b domain.CustomBackuper) RunnerOption {
+	return func(r *Runner) {
+		r.custom = b
+	}
+}
+
+// WithExtrasBackuper sets the backuper of screenshots and game config files,
+// run after custom games in each full run.
+func WithExtrasBackuper(b domain.CustomBackuper) RunnerOption {
+	return func(r *Runner) {
+		r.extras = b
+	}
+}
+
 // WithMetricsPusher sets the metrics pusher.
 func WithMetricsPusher(m domain.MetricsPusher) RunnerOption {
 	return func(r *Runner) {
@@ -45,6 +202,147 @@ func WithNotifier(n domain.Notifier) RunnerOption {
 	}
 }
 
+// WithNotificationCounts sets where the counts of notifications sent and
+// failed by target, pushed with the metrics, come from.
+func WithNotificationCounts(counts func() map[string]domain.DeliveryCounts) RunnerOption {
+	return func(r *Runner) {
+		r.notificationCounts = counts
+	}
+}
+
+// WithRunPublisher sets where the result of each run is published.
+func WithRunPublisher(p domain.RunPublisher) RunnerOption {
+	return func(r *Runner) {
+		r.publisher = p
+	}
+}
+
+// WithTracer sets the tracer used to record a trace of each run.
+func WithTracer(t *tracing.Tracer) RunnerOption {
+	return func(r *Runner) {
+		r.tracer = t
+	}
+}
+
+// WithHooks runs the hook commands of h before and after each backup run
+// and its operations.
+func WithHooks(h *hooks.Runner) RunnerOption {
+	return func(r *Runner) {
+		r.hooks = h
+	}
+}
+
+// WithStoreSnapshots snapshots the backup store with m before (pre) and/or
+// after (post) the local backup of each full run.
+func WithStoreSnapshots(m *snapshot.Manager, pre, post bool) RunnerOption {
+	return func(r *Runner) {
+		r.storeSnapshots = m
+		r.snapshotPre = pre
+		r.snapshotPost = post
+	}
+}
+
+// WithCrashDumpDir enables writing a crash dump file to dir when a run panics.
+func WithCrashDumpDir(dir string) RunnerOption {
+	return func(r *Runner) {
+		r.crashDumpDir = dir
+	}
+}
+
+// WithDestinationStatePath sets the file recording when removable backup
+// destinations were last seen, so unseen warnings survive restarts.
+func WithDestinationStatePath(path string) RunnerOption {
+	return func(r *Runner) {
+		r.destStatePath = path
+	}
+}
+
+// WithGameCountStatePath sets the file recording the games found by recent
+// full backups, so the rolling average survives restarts.
+func WithGameCountStatePath(path string) RunnerOption {
+	return func(r *Runner) {
+		r.gameCountPath = path
+	}
+}
+
+// WithQuarantineStatePath sets the file recording the games failing to back
+// up, so their quarantine survives restarts.
+func WithQuarantineStatePath(path string) RunnerOption {
+	return func(r *Runner) {
+		r.quarantinePath = path
+	}
+}
+
+// WithFailureStatePath sets the file recording how many runs in a row have
+// failed, so a failure streak survives restarts.
+func WithFailureStatePath(path string) RunnerOption {
+	return func(r *Runner) {
+		r.failurePath = path
+	}
+}
+
+// WithAckURL links failure notifications to GET <url>/ack/<token> on the
+// embedded HTTP server, which acknowledges the failure so it isn't notified
+// again until it recovers or fails differently.
+func WithAckURL(url string) RunnerOption {
+	return func(r *Runner) {
+		r.ackURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHistory keeps the result of every run in h.
+func WithHistory(h domain.RunHistory) RunnerOption {
+	return func(r *Runner) {
+		r.history = h
+	}
+}
+
+// WithMaintenancePath sets the state file turned on and off by the
+// maintenance command, which suppresses failure notifications and labels
+// metrics while on.
+func WithMaintenancePath(path string) RunnerOption {
+	return func(r *Runner) {
+		r.maintenancePath = path
+	}
+}
+
+// WithCloudTokens checks the cloud remote tokens from source after each full
+// run, warning before they expire.
+func WithCloudTokens(source domain.CloudTokenSource) RunnerOption {
+	return func(r *Runner) {
+		r.tokenSource = source
+	}
+}
+
+// WithNetworkProbe enables offline mode: probe is called before cloud
+// uploads, metrics pushes and notifications, and while it fails they are
+// skipped, with metrics and notifications delivered once it succeeds again.
+func WithNetworkProbe(probe func(ctx context.Context) error) RunnerOption {
+	return func(r *Runner) {
+		r.probe = probe
+	}
+}
+
+// WithOutbox enables the outbox: metrics pushes and notifications that fail
+// are kept in the file at path and retried on later runs, until they are
+// older than ttl. With an empty path they are kept in memory only.
+func WithOutbox(path string, ttl time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.outboxPath = path
+		r.outboxTTL = ttl
+		r.outboxRetry = true
+	}
+}
+
+// WithLudusaviWait delays runs while find returns other running ludusavi
+// instances, such as the GUI, for up to maxWait.
+func WithLudusaviWait(find func() ([]int, error), maxWait time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.findLudusavi = find
+		r.ludusaviWait = maxWait
+	}
+}
+
 // WithLogger sets the logger.
 func WithLogger(l *slog.Logger) RunnerOption {
 	return func(r *Runner) {
@@ -57,111 +355,438 @@ func NewRunner(cfg *config.Config, opts ...RunnerOption) *Runner {
 	hostname, _ := os.Hostname()
 
 	r := &Runner{
-		config:   cfg,
-		logger:   slog.Default(),
-		hostname: hostname,
-		notifier: &domain.NopNotifier{}, // Default to no-op
+		config:       cfg,
+		logger:       slog.Default(),
+		hostname:     hostname,
+		notifier:     &domain.NopNotifier{}, // Default to no-op
+		findVolume:   volume.Find,
+		ludusaviPoll: ludusaviPollInterval,
 	}
 
 	for _, opt := range opts {
 		opt(r)
 	}
 
+	// Pushes are counted as they reach the backend, not as they are held
+	if r.metricsPusher != nil {
+		r.metricsPusher = &countingPusher{MetricsPusher: r.metricsPusher, r: r}
+	}
+	r.selfTestPusher, r.selfTestNotifier = r.metricsPusher, r.notifier
+	// The notifier can be replaced on config reload; see SetNotifications
+	r.notifications = &swappableNotifier{notifier: r.notifier, level: cfg.Apprise.Notify}
+	r.notifier = r.notifications
+	if r.probe != nil || r.outboxRetry {
+		if r.metricsPusher != nil {
+			r.metricsPusher = &outboxPusher{MetricsPusher: r.metricsPusher, r: r}
+		}
+		r.outboxNotifier = &outboxNotifier{Notifier: r.notifier, r: r}
+		r.notifier = r.outboxNotifier
+	}
+	// Suppressed notifications are dropped rather than held back
+	if r.maintenancePath != "" {
+		r.notifier = &maintenanceNotifier{Notifier: r.notifier, r: r}
+	}
+
 	return r
 }
 
+// log returns the logger with the attributes carried by ctx, such as the ID
+// of the run in progress.
+func (r *Runner) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, r.logger)
+}
+
 // Run executes a single backup cycle.
 func (r *Runner) Run(ctx context.Context) (*domain.RunResult, error) {
 	result := domain.NewRunResult(r.config.DryRun)
+	ctx = logging.WithAttrs(ctx, logging.KeyRunID, result.ID)
+
+	ctx = tracing.ContextWithTracer(ctx, r.tracer)
+	ctx, span := tracing.Start(ctx, "backup run", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("host.name", r.hostname)
+	span.SetAttribute("run.id", result.ID)
+	span.SetAttribute("dry_run", r.config.DryRun)
 
-	r.logger.Info("starting backup run", "dry_run", r.config.DryRun)
+	r.log(ctx).Info("starting backup run", "dry_run", r.config.DryRun)
+	r.runHook(ctx, result, hooks.Event{Stage: hooks.Pre, Input: result})
 
-	// Execute cloud upload first
 	if r.executor != nil {
-		uploadResult, err := r.runCloudUpload(ctx)
+		r.waitForLudusavi(ctx)
+
+		// Pull the cloud backups down before anything is backed up or
+		// uploaded over them
+		if r.cloudDownloadDue() {
+			downloadResult, err := r.runOperation(ctx, result, domain.OperationCloudDownload, r.runCloudDownload)
+			if err != nil {
+				r.log(ctx).Error("cloud download failed", "error", err)
+				result.AddError(err)
+			}
+			result.CloudDownload = downloadResult
+			if downloadResult != nil && downloadResult.Success {
+				r.cloudDownloaded.Store(true)
+			}
+		}
+
+		// Execute cloud upload first
+		uploadResult, err := r.runOperation(ctx, result, domain.OperationCloudUpload, r.runCloudUpload)
 		if err != nil {
-			r.logger.Error("cloud upload failed", "error", err)
+			r.log(ctx).Error("cloud upload failed", "error", err)
 			result.AddError(err)
 		}
 		result.CloudUpload = uploadResult
 
+		// Keep the last good state of the backup store before writing to it
+		if r.storeSnapshots != nil && r.snapshotPre {
+			r.takeStoreSnapshot(ctx, result)
+		}
+
 		// Execute local backup
-		backupResult, err := r.runBackup(ctx)
+		backupResult, err := r.runOperation(ctx, result, domain.OperationBackup, func(ctx context.Context) (*domain.BackupResult, error) {
+			return r.runBackup(ctx, domain.OperationBackup, domain.BackupOptions{Force: true})
+		})
 		if err != nil {
-			r.logger.Error("backup failed", "error", err)
+			r.log(ctx).Error("backup failed", "error", err)
 			result.AddError(err)
 		}
 		result.Backup = backupResult
+		result.Preempted = backupResult != nil && backupResult.Preempted
+
+		// A preempted run leaves everything else to its next attempt, so
+		// the run that preempted it can start right away
+		if result.Preempted {
+			r.log(ctx).Info("backup run preempted, skipping the rest of the run")
+		} else {
+			r.runAfterBackup(ctx, result)
+		}
+	}
+
+	if r.tokenSource != nil {
+		r.checkCloudTokens(ctx)
 	}
 
+	r.checkBackupSize(ctx, result)
+	r.checkGameCount(ctx, result.Backup)
+	r.markOffline(ctx, result)
+	result.Maintenance = r.inMaintenance()
+
 	result.Complete()
+	r.runPostHook(ctx, result)
 
 	// Push metrics
 	if err := r.pushMetrics(ctx, result); err != nil {
-		r.logger.Error("failed to push metrics", "error", err)
+		r.log(ctx).Error("failed to push metrics", "error", err)
 		result.AddError(err)
 	}
 
 	// Send notifications based on result and config
 	if err := r.sendNotifications(ctx, result); err != nil {
-		r.logger.Error("failed to send notification", "error", err)
+		r.log(ctx).Error("failed to send notification", "error", err)
 	}
 
-	r.logger.Info("backup run completed",
+	r.publishResult(ctx, result)
+
+	r.log(ctx).Info("backup run completed",
 		"success", result.Success,
 		"duration", result.Duration,
 	)
+	r.recordRun(result)
+
+	span.SetSuccess(result.Success, strings.Join(result.Errors, "; "))
+
+	return result, nil
+}
+
+// runAfterBackup runs the operations of a full run that follow the local
+// backup: store snapshot, custom games, extras, additional destinations and
+// archive export.
+func (r *Runner) runAfterBackup(ctx context.Context, result *domain.RunResult) {
+	backupResult := result.Backup
+
+	if r.storeSnapshots != nil && r.snapshotPost && backupResult != nil && backupResult.Success {
+		r.takeStoreSnapshot(ctx, result)
+	}
+
+	// Custom games don't depend on ludusavi's backup succeeding
+	if r.custom != nil {
+		customResult, err := r.runOperation(ctx, result, domain.OperationCustom, func(ctx context.Context) (*domain.BackupResult, error) {
+			return r.runCustom(ctx, domain.OperationCustom, r.custom)
+		})
+		if err != nil {
+			r.log(ctx).Error("custom backup failed", "error", err)
+			result.AddError(err)
+		}
+		result.Custom = customResult
+	}
+	if r.extras != nil {
+		extrasResult, err := r.runOperation(ctx, result, domain.OperationExtras, func(ctx context.Context) (*domain.BackupResult, error) {
+			return r.runCustom(ctx, domain.OperationExtras, r.extras)
+		})
+		if err != nil {
+			r.log(ctx).Error("extras backup failed", "error", err)
+			result.AddError(err)
+		}
+		result.Extras = extrasResult
+	}
+
+	// Copies to additional destinations are independent of the main backup
+	r.runDestinationBackups(ctx, result)
+
+	// Export the backup directory once the local backup is up to date
+	if r.archiver != nil && backupResult != nil && backupResult.Success {
+		archiveResult, err := r.runOperation(ctx, result, domain.OperationArchive, r.runArchive)
+		if err != nil {
+			r.log(ctx).Error("archive failed", "error", err)
+			result.AddError(err)
+		}
+		result.Archive = archiveResult
+	}
+}
+
+// RunFast executes a fast backup cycle: only games whose saves changed since
+// the last backup are backed up, found by diffing a ludusavi preview. Cloud
+// upload and archive export are left to the next full cycle, and only
+// failures are notified.
+func (r *Runner) RunFast(ctx context.Context) (*domain.RunResult, error) {
+	return r.runPartial(ctx, domain.OperationFastBackup, domain.BackupOptions{Force: true, ChangedOnly: true})
+}
+
+// RunGames executes a backup cycle of only the named games, as after a game
+// session ended, skipping the scan for changes in the rest of the library.
+// Like a fast cycle, it leaves cloud upload and archive export to the next
+// full cycle and only notifies failures.
+func (r *Runner) RunGames(ctx context.Context, games []string) (*domain.RunResult, error) {
+	return r.runPartial(ctx, domain.OperationGameBackup, domain.BackupOptions{Force: true, Games: games})
+}
+
+// runPartial executes a backup cycle of part of the library, selected by
+// opts, as the operation op.
+func (r *Runner) runPartial(ctx context.Context, op domain.OperationType, opts domain.BackupOptions) (*domain.RunResult, error) {
+	name := strings.ReplaceAll(op.String(), "_", " ")
+	result := domain.NewRunResult(r.config.DryRun)
+	ctx = logging.WithAttrs(ctx, logging.KeyRunID, result.ID)
+
+	ctx = tracing.ContextWithTracer(ctx, r.tracer)
+	ctx, span := tracing.Start(ctx, name+" run", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("host.name", r.hostname)
+	span.SetAttribute("run.id", result.ID)
+	span.SetAttribute("dry_run", r.config.DryRun)
+	if len(opts.Games) > 0 {
+		ctx = logging.WithAttrs(ctx, logging.KeyGames, opts.Games)
+		span.SetAttribute("games", strings.Join(opts.Games, ", "))
+	}
+
+	r.log(ctx).Info("starting "+name+" run", "dry_run", r.config.DryRun)
+	r.runHook(ctx, result, hooks.Event{Stage: hooks.Pre, Input: result})
+
+	if r.executor != nil {
+		r.waitForLudusavi(ctx)
+		backupResult, err := r.runOperation(ctx, result, op, func(ctx context.Context) (*domain.BackupResult, error) {
+			return r.runBackup(ctx, op, opts)
+		})
+		if err != nil {
+			r.log(ctx).Error(name+" failed", "error", err)
+			result.AddError(err)
+		}
+		result.Backup = backupResult
+	}
+
+	r.checkBackupSize(ctx, result)
+	r.markOffline(ctx, result)
+	result.Maintenance = r.inMaintenance()
+
+	result.Complete()
+	r.runPostHook(ctx, result)
+
+	if err := r.pushMetrics(ctx, result); err != nil {
+		r.log(ctx).Error("failed to push metrics", "error", err)
+		result.AddError(err)
+	}
+
+	if !result.Success {
+		if err := r.sendNotifications(ctx, result); err != nil {
+			r.log(ctx).Error("failed to send notification", "error", err)
+		}
+	}
+
+	r.publishResult(ctx, result)
+
+	r.log(ctx).Info(name+" run completed",
+		"success", result.Success,
+		"duration", result.Duration,
+	)
+	r.recordRun(result)
+
+	span.SetSuccess(result.Success, strings.Join(result.Errors, "; "))
+
+	return result, nil
+}
+
+// ShutdownBackup runs a quick final backup while the service stops, to catch
+// saves from a session that ended right before shutdown. Cloud upload,
+// archive export, and notifications are skipped to keep it fast.
+func (r *Runner) ShutdownBackup(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+	if r.executor == nil {
+		return nil, nil
+	}
+
+	ctx = tracing.ContextWithTracer(ctx, r.tracer)
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, domain.OperationBackup.String())
+	ctx, span := tracing.Start(ctx, "shutdown backup", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("preview", opts.Preview)
+	span.SetAttribute("changed_only", opts.ChangedOnly)
+
+	r.log(ctx).Info("running shutdown backup", "preview", opts.Preview, "changed_only", opts.ChangedOnly)
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping shutdown backup")
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	result, err := r.executor.Backup(ctx, opts)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("shutdown backup error: %w", err)
+	}
+	recordResult(span, result)
+
+	if !result.Success {
+		r.log(ctx).Warn("shutdown backup failed", "error", result.Error)
+		return result, nil
+	}
+
+	if opts.Preview {
+		r.log(ctx).Info("shutdown backup preview completed",
+			"games_new", result.Stats.NewGames,
+			"games_changed", result.Stats.ChangedGames,
+			"duration", result.Duration,
+		)
+	} else {
+		r.log(ctx).Info("shutdown backup completed",
+			"games_processed", result.Stats.ProcessedGames,
+			"bytes_processed", result.Stats.ProcessedBytes,
+			"duration", result.Duration,
+		)
+	}
 
 	return result, nil
 }
 
+// cloudDownloadDue returns true if the full run should download from the
+// cloud before backing up. In the "startup" mode, runs keep trying until one
+// succeeds, so a first run while offline doesn't skip the download.
+func (r *Runner) cloudDownloadDue() bool {
+	switch r.config.CloudDownload {
+	case config.CloudDownloadAlways:
+		return true
+	case config.CloudDownloadStartup:
+		return !r.cloudDownloaded.Load()
+	default:
+		return false
+	}
+}
+
 // runCloudUpload executes the cloud upload operation.
 func (r *Runner) runCloudUpload(ctx context.Context) (*domain.BackupResult, error) {
-	r.logger.Debug("starting cloud upload")
+	return r.runCloudSync(ctx, domain.OperationCloudUpload, func(ctx context.Context) (*domain.BackupResult, error) {
+		return r.executor.CloudUpload(ctx, domain.UploadOptions{Force: true})
+	})
+}
+
+// runCloudDownload executes the cloud download operation.
+func (r *Runner) runCloudDownload(ctx context.Context) (*domain.BackupResult, error) {
+	return r.runCloudSync(ctx, domain.OperationCloudDownload, func(ctx context.Context) (*domain.BackupResult, error) {
+		return r.executor.CloudDownload(ctx, domain.DownloadOptions{Force: true})
+	})
+}
+
+// runCloudSync executes op, a transfer to or from the cloud, with sync.
+func (r *Runner) runCloudSync(ctx context.Context, op domain.OperationType, sync func(context.Context) (*domain.BackupResult, error)) (*domain.BackupResult, error) {
+	name := strings.ReplaceAll(op.String(), "_", " ")
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, op.String())
+	ctx, span := tracing.Start(ctx, name, tracing.SpanKindInternal)
+	defer span.End()
+
+	r.log(ctx).Debug("starting " + name)
 
 	if r.config.DryRun {
-		r.logger.Info("dry run: skipping cloud upload")
-		result := domain.NewBackupResult(domain.OperationCloudUpload)
+		r.log(ctx).Info("dry run: skipping " + name)
+		result := domain.NewBackupResult(op)
 		result.Complete(true, nil)
 		return result, nil
 	}
 
-	result, err := r.executor.CloudUpload(ctx, domain.UploadOptions{Force: true})
+	// Skipped right away rather than left to time out while offline
+	if err := r.networkDown(ctx); err != nil {
+		r.log(ctx).Warn(name + " skipped, network offline")
+		result := domain.NewBackupResult(op)
+		result.Offline = true
+		result.Complete(false, err)
+		recordResult(span, result)
+		return result, nil
+	}
+
+	result, err := sync(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("cloud upload error: %w", err)
+		span.RecordError(err)
+		return nil, fmt.Errorf("%s error: %w", name, err)
 	}
+	recordResult(span, result)
 
 	if result.Success {
-		r.logger.Info("cloud upload completed",
+		r.log(ctx).Info(name+" completed",
 			"games_processed", result.Stats.ProcessedGames,
 			"bytes_processed", result.Stats.ProcessedBytes,
 			"duration", result.Duration,
 		)
 	} else {
-		r.logger.Warn("cloud upload failed", "error", result.Error)
+		r.log(ctx).Warn(name+" failed", "error", result.Error)
 	}
 
 	return result, nil
 }
 
-// runBackup executes the local backup operation.
-func (r *Runner) runBackup(ctx context.Context) (*domain.BackupResult, error) {
-	r.logger.Debug("starting local backup")
+// runBackup executes a local backup operation, reported as op.
+func (r *Runner) runBackup(ctx context.Context, op domain.OperationType, opts domain.BackupOptions) (*domain.BackupResult, error) {
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, op.String())
+	ctx, span := tracing.Start(ctx, strings.ReplaceAll(op.String(), "_", " "), tracing.SpanKindInternal)
+	defer span.End()
+
+	r.log(ctx).Debug("starting local backup")
 
 	if r.config.DryRun {
-		r.logger.Info("dry run: skipping local backup")
-		result := domain.NewBackupResult(domain.OperationBackup)
+		r.log(ctx).Info("dry run: skipping local backup")
+		result := domain.NewBackupResult(op)
+		result.Games = opts.Games
 		result.Complete(true, nil)
 		return result, nil
 	}
 
-	result, err := r.executor.Backup(ctx, domain.BackupOptions{Force: true})
+	// Named games are backed up even in quarantine, which retries them
+	if len(opts.Games) == 0 {
+		opts.Exclude = r.quarantineExclude(true)
+	}
+
+	result, err := r.executor.Backup(ctx, opts)
 	if err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("backup error: %w", err)
 	}
+	result.Operation = op
+	result.Games = opts.Games
+	if r.trackQuarantine(ctx, result) && result.Code == domain.CodeGamesFailed {
+		// Only games in quarantine failed again
+		result.Error = ""
+		result.Complete(true, nil)
+	}
+	recordResult(span, result)
 
 	if result.Success {
-		r.logger.Info("local backup completed",
+		r.log(ctx).Info("local backup completed",
 			"games_total", result.Stats.TotalGames,
 			"games_processed", result.Stats.ProcessedGames,
 			"bytes_processed", result.Stats.ProcessedBytes,
@@ -170,7 +795,101 @@ func (r *Runner) runBackup(ctx context.Context) (*domain.BackupResult, error) {
 			"duration", result.Duration,
 		)
 	} else {
-		r.logger.Warn("local backup failed", "error", result.Error)
+		r.log(ctx).Warn("local backup failed", "error", result.Error)
+	}
+
+	return result, nil
+}
+
+// takeStoreSnapshot snapshots the backup store. A failed snapshot is
+// recorded as a run error but doesn't fail the backup itself.
+func (r *Runner) takeStoreSnapshot(ctx context.Context, result *domain.RunResult) {
+	ctx, span := tracing.Start(ctx, "store snapshot", tracing.SpanKindInternal)
+	defer span.End()
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping backup store snapshot")
+		return
+	}
+
+	name, err := r.storeSnapshots.Take(ctx)
+	if err != nil {
+		span.RecordError(err)
+		r.log(ctx).Error("backup store snapshot failed", "error", err)
+		result.AddError(domain.WithErrorCode(domain.CodeSnapshotFailed, err))
+		return
+	}
+	span.SetAttribute("snapshot.name", name)
+}
+
+// runArchive executes the archive export operation.
+func (r *Runner) runArchive(ctx context.Context) (*domain.BackupResult, error) {
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, domain.OperationArchive.String())
+	ctx, span := tracing.Start(ctx, "archive", tracing.SpanKindInternal)
+	defer span.End()
+
+	r.log(ctx).Debug("starting archive export")
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping archive export")
+		result := domain.NewBackupResult(domain.OperationArchive)
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	result, err := r.archiver.Archive(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("archive error: %w", err)
+	}
+	recordResult(span, result)
+
+	switch {
+	case result.Success:
+		r.log(ctx).Info("archive export completed",
+			"games_processed", result.Stats.ProcessedGames,
+			"bytes_processed", result.Stats.ProcessedBytes,
+			"duration", result.Duration,
+		)
+	case result.Offline:
+		r.log(ctx).Warn("archive export skipped, destination offline", "error", result.Error)
+	default:
+		r.log(ctx).Warn("archive export failed", "error", result.Error)
+	}
+
+	return result, nil
+}
+
+// runCustom executes op, the backup of custom games or of extras, with b.
+func (r *Runner) runCustom(ctx context.Context, op domain.OperationType, b domain.CustomBackuper) (*domain.BackupResult, error) {
+	ctx = logging.WithAttrs(ctx, logging.KeyOperation, op.String())
+	ctx, span := tracing.Start(ctx, op.String()+" backup", tracing.SpanKindInternal)
+	defer span.End()
+
+	r.log(ctx).Debug("starting " + op.String() + " backup")
+
+	if r.config.DryRun {
+		r.log(ctx).Info("dry run: skipping " + op.String() + " backup")
+		result := domain.NewBackupResult(op)
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	result, err := b.Backup(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("%s backup error: %w", op, err)
+	}
+	recordResult(span, result)
+
+	if result.Success {
+		r.log(ctx).Info(op.String()+" backup completed",
+			"games_processed", result.Stats.ProcessedGames,
+			"bytes_processed", result.Stats.ProcessedBytes,
+			"duration", result.Duration,
+		)
+	} else {
+		r.log(ctx).Warn(op.String()+" backup failed", "error", result.Error)
 	}
 
 	return result, nil
@@ -178,36 +897,212 @@ func (r *Runner) runBackup(ctx context.Context) (*domain.BackupResult, error) {
 
 // pushMetrics sends metrics to the metrics pusher.
 func (r *Runner) pushMetrics(ctx context.Context, result *domain.RunResult) error {
+	r.countFailures(result)
 	if r.metricsPusher == nil {
 		return nil
 	}
 
+	ctx, span := tracing.Start(ctx, "push metrics", tracing.SpanKindInternal)
+	defer span.End()
+
+	var results []*domain.BackupResult
+	for _, op := range result.Operations() {
+		// A skipped destination has nothing to report
+		if op.Destination == "" || !op.Skipped {
+			results = append(results, op)
+		}
+	}
+
+	// A push replaces every metric of the group, so the operations a fast
+	// or game backup doesn't do keep their latest results rather than
+	// disappear
+	r.pushedMu.Lock()
+	r.pushed = mergeResults(r.pushed, results)
+	r.pushedMu.Unlock()
+
+	metrics := r.newMetrics()
+	metrics.RunID = result.ID
+	metrics.Outcome = result.Outcome()
+
+	// The push gets its own deadline, so a run that took up most of its
+	// timeout still reports how it went
+	if timeout := r.config.Metrics.PushTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+	}
+
+	err := r.metricsPusher.Push(ctx, metrics)
+	span.RecordError(err)
+	return domain.WithErrorCode(domain.CodeMetricsPushFailed, err)
+}
+
+// countFailures counts the failures of result by code, for the failures
+// metric.
+func (r *Runner) countFailures(result *domain.RunResult) {
+	failures := result.Failures()
+	if len(failures) == 0 {
+		return
+	}
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if r.errorCodes == nil {
+		r.errorCodes = make(map[domain.ErrorCode]int64)
+	}
+	for _, f := range failures {
+		r.errorCodes[f.Code]++
+	}
+}
+
+// countingPusher counts the pushes that went out and failed, and the
+// reasons they were rejected for.
+type countingPusher struct {
+	domain.MetricsPusher
+	r *Runner
+}
+
+// Push pushes metrics and counts the outcome.
+func (p *countingPusher) Push(ctx context.Context, metrics *domain.Metrics) error {
+	err := p.MetricsPusher.Push(ctx, metrics)
+
+	r := p.r
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if err == nil {
+		r.pushes.Sent++
+		return nil
+	}
+	r.pushes.Failed++
+	var pushErr *domain.PushError
+	if errors.As(err, &pushErr) {
+		if r.pushErrors == nil {
+			r.pushErrors = make(map[string]int64)
+		}
+		r.pushErrors[pushErr.Reason]++
+	}
+	return err
+}
+
+// newMetrics creates metrics carrying the service-lifetime counters and the
+// latest result of each operation and destination pushed.
+func (r *Runner) newMetrics() *domain.Metrics {
 	metrics := domain.NewMetrics(r.hostname)
-	metrics.ServiceUp = true
+	metrics.Panics = r.panics.Load()
+	metrics.WatchdogRecoveries = r.WatchdogRecoveries()
+	r.statsMu.Lock()
+	metrics.PushErrors = maps.Clone(r.pushErrors)
+	metrics.Pushes = r.pushes
+	metrics.Failures = maps.Clone(r.errorCodes)
+	r.statsMu.Unlock()
+	if r.notificationCounts != nil {
+		metrics.Notifications = r.notificationCounts()
+	}
+	metrics.GameCount = r.gameCount()
+	metrics.Maintenance = r.inMaintenance()
+	metrics.Paused = r.paused.Load()
+	r.pushedMu.Lock()
+	metrics.Results = slices.Clone(r.pushed)
+	r.pushedMu.Unlock()
+	// The runner's own usage is left out where it can't be read
+	if stats, err := procstats.Self(); err == nil {
+		metrics.Process = stats
+	}
+	return metrics
+}
 
-	if result.CloudUpload != nil {
-		metrics.AddResult(result.CloudUpload)
+// setPaused records whether scheduled runs are paused and pushes metrics
+// right away, so dashboards show it without waiting for the next run.
+func (r *Runner) setPaused(paused bool) {
+	r.paused.Store(paused)
+	if r.metricsPusher == nil {
+		return
 	}
-	if result.Backup != nil {
-		metrics.AddResult(result.Backup)
+
+	timeout := r.config.Metrics.PushTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultMetricsPushTimeout
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := r.metricsPusher.Push(ctx, r.newMetrics()); err != nil {
+		r.logger.Warn("failed to push metrics after pausing or resuming", "error", err)
+	}
+}
+
+// publishResult publishes the result of a run, if a publisher is set.
+func (r *Runner) publishResult(ctx context.Context, result *domain.RunResult) {
+	if r.publisher == nil {
+		return
+	}
+	if err := r.networkDown(ctx); err != nil {
+		r.log(ctx).Warn("run result not published, network offline")
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "publish result", tracing.SpanKindInternal)
+	defer span.End()
 
-	return r.metricsPusher.Push(ctx, metrics)
+	err := r.publisher.Publish(ctx, result)
+	span.RecordError(err)
+	if err != nil {
+		r.log(ctx).Error("failed to publish run result", "error", err)
+	}
 }
 
 // sendNotifications sends notifications based on the result and config.
 func (r *Runner) sendNotifications(ctx context.Context, result *domain.RunResult) error {
+	failure := r.trackFailure(result)
 	if r.notifier == nil {
 		return nil
 	}
 
-	notifyLevel := r.config.Apprise.Notify
+	// Held notifications go out once they can be delivered, even if this
+	// run has nothing to notify
+	if r.outboxNotifier != nil {
+		if err := r.outboxNotifier.deliverHeld(ctx); err != nil {
+			return err
+		}
+	}
+
+	notifyLevel := r.notifyLevel()
 
 	// Determine if we should notify based on result and configured level
 	shouldNotify := false
 	var notification *domain.Notification
 
-	if !result.Success {
+	if result.DestinationOffline() {
+		// An offline destination is expected to recover on its own, so it is
+		// only a warning: notify if level is warning or always
+		if notifyLevel == config.NotifyWarning || notifyLevel == config.NotifyAlways {
+			shouldNotify = true
+			notification = domain.WarningNotification(
+				"Ludusavi Backup Destination Offline",
+				r.buildOfflineMessage(result),
+			)
+		}
+	} else if result.AuthRequired() {
+		// Backups keep failing until the user signs in again, so this is
+		// notified like a failure, but as a warning with what to do about it
+		if notifyLevel == config.NotifyError || notifyLevel == config.NotifyWarning || notifyLevel == config.NotifyAlways {
+			shouldNotify = true
+			notification = domain.WarningNotification(
+				"Ludusavi Cloud Sign-in Required",
+				r.buildAuthMessage(result),
+			)
+			notification.FailureStreak = failure.Streak
+		}
+	} else if result.PartialSuccess {
+		// Notified like a failure, but as a warning, as some backups were
+		// made
+		if notifyLevel == config.NotifyError || notifyLevel == config.NotifyWarning || notifyLevel == config.NotifyAlways {
+			shouldNotify = true
+			notification = domain.WarningNotification(
+				"Ludusavi Backup Partially Failed",
+				r.buildErrorMessage(result),
+			)
+			notification.FailureStreak = failure.Streak
+		}
+	} else if !result.Success {
 		// On failure, notify if level is error, warning, or always
 		if notifyLevel == config.NotifyError || notifyLevel == config.NotifyWarning || notifyLevel == config.NotifyAlways {
 			shouldNotify = true
@@ -215,6 +1110,7 @@ func (r *Runner) sendNotifications(ctx context.Context, result *domain.RunResult
 				"Ludusavi Backup Failed",
 				r.buildErrorMessage(result),
 			)
+			notification.FailureStreak = failure.Streak
 		}
 	} else {
 		// On success, only notify if level is "always"
@@ -230,24 +1126,151 @@ func (r *Runner) sendNotifications(ctx context.Context, result *domain.RunResult
 	if !shouldNotify || notification == nil {
 		return nil
 	}
+	if notification.FailureStreak > 0 && failure.Acked {
+		r.log(ctx).Info("failure notification suppressed, failure acknowledged", "failed_runs", failure.Streak)
+		return nil
+	}
+
+	notification.Body = strings.TrimRight(notification.Body, "\n")
+	if quarantined := r.Quarantined(); len(quarantined) > 0 {
+		notification.Body += "\n\n" + quarantineLines(quarantined, r.config.Quarantine.RetryInterval)
+	}
+	if notification.FailureStreak > 1 {
+		notification.Body += fmt.Sprintf("\n\n%d runs in a row have failed.", notification.FailureStreak)
+	}
+	if notification.FailureStreak > 0 && r.ackURL != "" {
+		notification.Body += "\n\nAcknowledge to stop repeats until it recovers: " + r.ackURL + "/ack/" + failure.Token
+	}
+	// Identifies the run in the service log and metrics
+	notification.Body += "\n\nRun ID: " + result.ID
+	notification.Result = result
 
-	return r.notifier.Notify(ctx, notification)
+	ctx, span := tracing.Start(ctx, "notify", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("notification.level", string(notification.Level))
+
+	err := r.notifier.Notify(ctx, notification)
+	span.RecordError(err)
+	return err
+}
+
+// recordResult records the outcome and statistics of an operation on its span.
+func recordResult(span *tracing.Span, result *domain.BackupResult) {
+	span.SetAttribute("games_processed", result.Stats.ProcessedGames)
+	span.SetAttribute("bytes_processed", result.Stats.ProcessedBytes)
+	span.SetSuccess(result.Success, result.Error)
 }
 
 // buildErrorMessage builds an error notification message.
 func (r *Runner) buildErrorMessage(result *domain.RunResult) string {
 	msg := fmt.Sprintf("Backup failed on %s.\n", r.hostname)
+	if result.PartialSuccess {
+		msg = fmt.Sprintf("Backup partially failed on %s: some backups were made, but not all.\n", r.hostname)
+	}
 
+	if result.CloudDownload != nil && !result.CloudDownload.Success {
+		msg += fmt.Sprintf("Cloud download error: %s [%s]\n", result.CloudDownload.Error, result.CloudDownload.ErrorCode())
+	}
 	if result.CloudUpload != nil && !result.CloudUpload.Success {
-		msg += fmt.Sprintf("Cloud upload error: %s\n", result.CloudUpload.Error)
+		msg += fmt.Sprintf("Cloud upload error: %s [%s]\n", result.CloudUpload.Error, result.CloudUpload.ErrorCode())
 	}
 	if result.Backup != nil && !result.Backup.Success {
-		msg += fmt.Sprintf("Backup error: %s\n", result.Backup.Error)
+		msg += fmt.Sprintf("Backup error: %s [%s]\n", result.Backup.Error, result.Backup.ErrorCode())
+	}
+	if result.Archive != nil && !result.Archive.Success {
+		msg += fmt.Sprintf("Archive error: %s [%s]\n", result.Archive.Error, result.Archive.ErrorCode())
+	}
+	if result.Custom != nil && !result.Custom.Success {
+		msg += fmt.Sprintf("Custom games error: %s [%s]\n", result.Custom.Error, result.Custom.ErrorCode())
+	}
+	if result.Extras != nil && !result.Extras.Success {
+		msg += fmt.Sprintf("Extras error: %s [%s]\n", result.Extras.Error, result.Extras.ErrorCode())
+	}
+	for _, dest := range result.Destinations {
+		if !dest.Success {
+			msg += fmt.Sprintf("Backup to %s error: %s [%s]\n", dest.Destination, dest.Error, dest.ErrorCode())
+		}
+	}
+
+	msg += errorLines(result)
+	msg += hintLines(result)
+
+	return msg
+}
+
+// errorLines describes the errors of result besides those of its
+// operations, one per line with its code.
+func errorLines(result *domain.RunResult) string {
+	var msg string
+	for _, f := range result.Failures() {
+		if f.Source == "" {
+			msg += fmt.Sprintf("Error: %s [%s]\n", f.Message, f.Code)
+		}
 	}
+	return msg
+}
 
-	for _, err := range result.Errors {
-		msg += fmt.Sprintf("Error: %s\n", err)
+// hintLines says what to do about each kind of failure of result, once per
+// error code.
+func hintLines(result *domain.RunResult) string {
+	var msg string
+	seen := make(map[domain.ErrorCode]bool)
+	for _, f := range result.Failures() {
+		if seen[f.Code] {
+			continue
+		}
+		seen[f.Code] = true
+		if msg == "" {
+			msg = "\nWhat to do:\n"
+		}
+		msg += fmt.Sprintf("%s: %s\n", f.Code, f.Code.Hint())
 	}
+	return msg
+}
+
+// quarantineLines lists the games in quarantine, retried every retry.
+func quarantineLines(games []QuarantinedGame, retry time.Duration) string {
+	msg := fmt.Sprintf("Quarantined games, left out of backups and retried every %s:", retry)
+	for _, game := range games {
+		msg += fmt.Sprintf("\n- %s: %s (failed %d times)", game.Title, game.Error, game.Failures)
+	}
+	return msg
+}
+
+// buildOfflineMessage builds a notification message for an unreachable destination.
+func (r *Runner) buildOfflineMessage(result *domain.RunResult) string {
+	msg := fmt.Sprintf("Backup completed on %s, but a destination was offline.\n", r.hostname)
+
+	if result.CloudDownload != nil && result.CloudDownload.Offline {
+		msg += fmt.Sprintf("Cloud download: %s [%s]\n", result.CloudDownload.Error, result.CloudDownload.ErrorCode())
+	}
+	if result.CloudUpload != nil && result.CloudUpload.Offline {
+		msg += fmt.Sprintf("Cloud upload: %s [%s]\n", result.CloudUpload.Error, result.CloudUpload.ErrorCode())
+	}
+	if result.Archive != nil && result.Archive.Offline {
+		msg += fmt.Sprintf("Archive: %s [%s]\n", result.Archive.Error, result.Archive.ErrorCode())
+	}
+
+	msg += "The next run will retry automatically."
+
+	return msg
+}
+
+// buildAuthMessage builds a notification message for an operation that
+// waited for the user to sign in.
+func (r *Runner) buildAuthMessage(result *domain.RunResult) string {
+	msg := fmt.Sprintf("Backup on %s stopped because ludusavi is waiting for you to sign in, "+
+		"most likely because the cloud remote's token expired.\n", r.hostname)
+
+	for _, op := range result.Operations() {
+		if op.AuthRequired {
+			msg += fmt.Sprintf("%s: %s [%s]\n", op.Operation, op.Error, op.ErrorCode())
+		}
+	}
+
+	msg += "To fix it, sign in to the cloud remote again on this machine, either in the ludusavi GUI " +
+		"(Other > Cloud > Remote) or with `rclone config reconnect <remote>:`. " +
+		"Uploads will keep failing until then."
 
 	return msg
 }
@@ -269,6 +1292,19 @@ func (r *Runner) buildSuccessMessage(result *domain.RunResult) string {
 		}
 	}
 
+	if result.Custom != nil {
+		msg += fmt.Sprintf("Custom games: %d total, %d processed\n",
+			result.Custom.Stats.TotalGames,
+			result.Custom.Stats.ProcessedGames,
+		)
+	}
+
+	for _, dest := range result.Destinations {
+		if dest.Skipped {
+			msg += fmt.Sprintf("Skipped %s: not mounted\n", dest.Destination)
+		}
+	}
+
 	msg += fmt.Sprintf("Duration: %s", result.Duration.Round(100000000)) // Round to 0.1s
 
 	return msg
@@ -1,16 +1,32 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sharkusmanch/ludusavi-runner/internal/archive"
 	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/customgame"
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/hooks"
 	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
 	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/rclone"
+	"github.com/sharkusmanch/ludusavi-runner/internal/snapshot"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -117,9 +133,53 @@ func TestRunner_Run_BackupFailure(t *testing.T) {
 	assert.False(t, result.Success)
 	assert.True(t, result.CloudUpload.Success)
 	assert.False(t, result.Backup.Success)
-	// Should send notification on failure
+	// Should send notification on failure, a warning as the upload went through
+	assert.True(t, result.PartialSuccess)
 	assert.Len(t, mockNotifier.Notifications, 1)
-	assert.Equal(t, domain.NotificationLevelError, mockNotifier.Notifications[0].Level)
+	assert.Equal(t, domain.NotificationLevelWarning, mockNotifier.Notifications[0].Level)
+	assert.Equal(t, "Ludusavi Backup Partially Failed", mockNotifier.Notifications[0].Title)
+}
+
+func TestRunner_Run_RunID(t *testing.T) {
+	var logs bytes.Buffer
+	mockExecutor := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(false, errors.New("backup failed"))
+			return result, nil
+		},
+	}
+	mockMetrics := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+
+	runner := NewRunner(testConfig(),
+		WithExecutor(mockExecutor),
+		WithMetricsPusher(mockMetrics),
+		WithNotifier(mockNotifier),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, result.ID)
+
+	// Every log line of the run carries its ID
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.Contains(t, line, "run_id="+result.ID)
+	}
+	assert.Contains(t, logs.String(), "run_id="+result.ID+" operation=backup")
+
+	require.Len(t, mockMetrics.PushedMetrics, 1)
+	assert.Equal(t, result.ID, mockMetrics.PushedMetrics[0].RunID)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.True(t, strings.HasSuffix(mockNotifier.Notifications[0].Body, "\n\nRun ID: "+result.ID))
+
+	// Each run gets its own ID
+	next, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, result.ID, next.ID)
 }
 
 func TestRunner_Run_DryRun(t *testing.T) {
@@ -156,6 +216,76 @@ func TestRunner_Run_DryRun(t *testing.T) {
 	assert.Len(t, mockMetrics.PushedMetrics, 1)
 }
 
+func TestRunner_Run_PartialSuccess(t *testing.T) {
+	backupOK := func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Complete(true, nil)
+		return result, nil
+	}
+	backupFailed := func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Complete(false, errors.New("ludusavi failed"))
+		return result, nil
+	}
+	uploadFailed := func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+		result := domain.NewBackupResult(domain.OperationCloudUpload)
+		result.Complete(false, errors.New("rclone failed"))
+		return result, nil
+	}
+
+	tests := []struct {
+		name     string
+		download config.CloudDownloadMode
+		backup   func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error)
+		outcome  domain.RunOutcome
+		level    domain.NotificationLevel
+		title    string
+	}{
+		{"an operation failed", "", backupOK, domain.OutcomePartial,
+			domain.NotificationLevelWarning, "Ludusavi Backup Partially Failed"},
+		{"only the cloud download succeeded", config.CloudDownloadAlways, backupFailed, domain.OutcomePartial,
+			domain.NotificationLevelWarning, "Ludusavi Backup Partially Failed"},
+		{"some games failed", "", func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.GameBytes = map[string]int64{"Hades": 10, "Celeste": 5}
+			result.FailedGames = map[string]string{"Celeste": "access denied"}
+			result.Complete(false, domain.WithErrorCode(domain.CodeGamesFailed, errors.New("1 games failed to back up")))
+			return result, nil
+		}, domain.OutcomePartial, domain.NotificationLevelWarning, "Ludusavi Backup Partially Failed"},
+		{"everything failed", "", backupFailed, domain.OutcomeFailure,
+			domain.NotificationLevelError, "Ludusavi Backup Failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.download != "" {
+				cfg.CloudDownload = tt.download
+			}
+			mockNotifier := &notify.MockNotifier{}
+			mockPusher := &metrics.MockPusher{}
+			runner := NewRunner(cfg,
+				WithExecutor(&executor.MockExecutor{BackupFunc: tt.backup, CloudUploadFunc: uploadFailed}),
+				WithNotifier(mockNotifier),
+				WithMetricsPusher(mockPusher),
+			)
+
+			result, err := runner.Run(context.Background())
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Equal(t, tt.outcome, result.Outcome())
+			assert.Equal(t, tt.outcome == domain.OutcomePartial, result.PartialSuccess)
+
+			require.Len(t, mockNotifier.Notifications, 1)
+			assert.Equal(t, tt.level, mockNotifier.Notifications[0].Level)
+			assert.Equal(t, tt.title, mockNotifier.Notifications[0].Title)
+
+			require.Len(t, mockPusher.PushedMetrics, 1)
+			assert.Equal(t, tt.outcome, mockPusher.PushedMetrics[0].Outcome)
+		})
+	}
+}
+
 func TestRunner_Run_NotifyAlways(t *testing.T) {
 	cfg := testConfig()
 	cfg.Apprise.Notify = config.NotifyAlways
@@ -177,6 +307,28 @@ func TestRunner_Run_NotifyAlways(t *testing.T) {
 	assert.Equal(t, domain.NotificationLevelInfo, mockNotifier.Notifications[0].Level)
 }
 
+func TestRunner_SetNotifications(t *testing.T) {
+	startup := &notify.MockNotifier{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithNotifier(startup),
+	)
+
+	// Successes aren't notified at the error level of testConfig
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, startup.Notifications)
+
+	// Once replaced, both the notifier and the level apply to the next run
+	reloaded := &notify.MockNotifier{}
+	runner.SetNotifications(config.NotifyAlways, reloaded)
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, startup.Notifications)
+	require.Len(t, reloaded.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelInfo, reloaded.Notifications[0].Level)
+}
+
 func TestRunner_Run_NoExecutor(t *testing.T) {
 	cfg := testConfig()
 
@@ -194,51 +346,1609 @@ func TestRunner_Run_NoExecutor(t *testing.T) {
 	assert.Nil(t, result.Backup)
 }
 
-func TestRunner_BuildSuccessMessage(t *testing.T) {
+func TestRunner_Run_Archive(t *testing.T) {
 	cfg := testConfig()
-	runner := NewRunner(cfg)
-	runner.hostname = "test-host"
 
-	result := &domain.RunResult{
-		Success:  true,
-		Duration: 5 * time.Second,
-		Backup: &domain.BackupResult{
-			Success: true,
-			Stats: domain.BackupStats{
-				TotalGames:     100,
-				ProcessedGames: 95,
-				NewGames:       5,
-				ChangedGames:   10,
+	mockArchiver := &archive.MockArchiver{}
+	mockMetrics := &metrics.MockPusher{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithArchiver(mockArchiver),
+		WithMetricsPusher(mockMetrics),
+	)
+
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.NotNil(t, result.Archive)
+	assert.True(t, result.Archive.Success)
+	assert.Equal(t, 1, mockArchiver.Calls)
+	require.Len(t, mockMetrics.PushedMetrics, 1)
+	assert.Len(t, mockMetrics.PushedMetrics[0].Results, 3)
+}
+
+func TestRunner_Run_Preempted(t *testing.T) {
+	mockArchiver := &archive.MockArchiver{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Preempted = true
+				result.Complete(true, nil)
+				return result, nil
 			},
+		}),
+		WithArchiver(mockArchiver),
+	)
+
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.True(t, result.Preempted)
+	// The rest of the run is left to its next attempt
+	assert.Nil(t, result.Archive)
+	assert.Zero(t, mockArchiver.Calls)
+}
+
+func TestRunner_Run_ArchiveFailure(t *testing.T) {
+	cfg := testConfig()
+
+	mockArchiver := &archive.MockArchiver{
+		ArchiveFunc: func(ctx context.Context) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationArchive)
+			result.Complete(false, errors.New("nas offline"))
+			return result, nil
 		},
 	}
+	mockNotifier := &notify.MockNotifier{}
 
-	msg := runner.buildSuccessMessage(result)
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithArchiver(mockArchiver),
+		WithNotifier(mockNotifier),
+	)
 
-	assert.Contains(t, msg, "test-host")
-	assert.Contains(t, msg, "100 total")
-	assert.Contains(t, msg, "95 processed")
-	assert.Contains(t, msg, "5 new")
-	assert.Contains(t, msg, "10 updated")
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Archive error: nas offline")
 }
 
-func TestRunner_BuildErrorMessage(t *testing.T) {
+func TestRunner_Run_ErrorCodes(t *testing.T) {
 	cfg := testConfig()
-	runner := NewRunner(cfg)
-	runner.hostname = "test-host"
 
-	result := &domain.RunResult{
-		Success: false,
-		Backup: &domain.BackupResult{
-			Success: false,
-			Error:   "disk full",
+	mockArchiver := &archive.MockArchiver{
+		ArchiveFunc: func(ctx context.Context) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationArchive)
+			result.Complete(false, errors.New("nas full"))
+			return result, nil
 		},
-		Errors: []string{"additional error"},
 	}
+	mockMetrics := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
 
-	msg := runner.buildErrorMessage(result)
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{
+			CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+				return nil, errors.New("rclone crashed")
+			},
+		}),
+		WithArchiver(mockArchiver),
+		WithMetricsPusher(mockMetrics),
+		WithNotifier(mockNotifier),
+	)
 
-	assert.Contains(t, msg, "test-host")
-	assert.Contains(t, msg, "disk full")
-	assert.Contains(t, msg, "additional error")
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, []domain.Failure{
+		{Code: domain.CodeArchiveFailed, Source: "archive", Message: "nas full"},
+		{Code: domain.CodeCloudSyncFailed, Message: result.Errors[0]},
+	}, result.Failures())
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	body := mockNotifier.Notifications[0].Body
+	assert.Contains(t, body, "Archive error: nas full [LR3002]")
+	assert.Contains(t, body, "rclone crashed [LR2002]")
+	assert.Contains(t, body, "What to do:\nLR3002: "+domain.CodeArchiveFailed.Hint())
+
+	require.Len(t, mockMetrics.PushedMetrics, 1)
+	assert.Equal(t, map[domain.ErrorCode]int64{domain.CodeArchiveFailed: 1, domain.CodeCloudSyncFailed: 1},
+		mockMetrics.PushedMetrics[0].Failures)
+
+	// Failures add up over the runs of a service
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), mockMetrics.PushedMetrics[1].Failures[domain.CodeArchiveFailed])
+}
+
+func TestRunner_Run_ArchiveDestinationOffline(t *testing.T) {
+	offlineArchive := func(ctx context.Context) (*domain.BackupResult, error) {
+		result := domain.NewBackupResult(domain.OperationArchive)
+		result.Offline = true
+		result.Complete(false, fmt.Errorf("nas: %w", domain.ErrDestinationOffline))
+		return result, nil
+	}
+
+	tests := []struct {
+		name        string
+		notifyLevel config.NotifyLevel
+		wantNotify  bool
+	}{
+		{"error level stays quiet", config.NotifyError, false},
+		{"warning level notifies", config.NotifyWarning, true},
+		{"always level notifies", config.NotifyAlways, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Apprise.Notify = tt.notifyLevel
+			mockNotifier := &notify.MockNotifier{}
+
+			runner := NewRunner(cfg,
+				WithExecutor(&executor.MockExecutor{}),
+				WithArchiver(&archive.MockArchiver{ArchiveFunc: offlineArchive}),
+				WithNotifier(mockNotifier),
+			)
+
+			result, err := runner.Run(context.Background())
+
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.True(t, result.DestinationOffline())
+			if !tt.wantNotify {
+				assert.Empty(t, mockNotifier.Notifications)
+				return
+			}
+			require.Len(t, mockNotifier.Notifications, 1)
+			assert.Equal(t, domain.NotificationLevelWarning, mockNotifier.Notifications[0].Level)
+			assert.Equal(t, "Ludusavi Backup Destination Offline", mockNotifier.Notifications[0].Title)
+			assert.Contains(t, mockNotifier.Notifications[0].Body, "destination offline")
+		})
+	}
+}
+
+func TestRunner_Run_NetworkOffline(t *testing.T) {
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyWarning
+	uploads := 0
+	mockExecutor := &executor.MockExecutor{
+		CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+			uploads++
+			result := domain.NewBackupResult(domain.OperationCloudUpload)
+			result.Complete(true, nil)
+			return result, nil
+		},
+	}
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+	probeErr := errors.New("dial tcp: network is unreachable")
+
+	runner := NewRunner(cfg,
+		WithExecutor(mockExecutor),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+		WithNetworkProbe(func(ctx context.Context) error { return probeErr }),
+	)
+
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, result.Offline)
+	assert.True(t, result.DestinationOffline())
+	assert.True(t, result.CloudUpload.Offline)
+	assert.Contains(t, result.CloudUpload.Error, domain.ErrNetworkOffline.Error())
+	assert.Zero(t, uploads)
+	assert.Empty(t, mockPusher.PushedMetrics)
+	assert.Empty(t, mockNotifier.Notifications)
+
+	// Back online, the held metrics and notification go out before the new ones
+	probeErr = nil
+	runner.probedAt = time.Time{}
+
+	result, err = runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Offline)
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, uploads)
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Equal(t, result.ID, mockPusher.PushedMetrics[0].RunID)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, "Ludusavi Backup Destination Offline", mockNotifier.Notifications[0].Title)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Delayed: could not be sent at")
+}
+
+func TestRunner_Run_NetworkOffline_SkipsPublish(t *testing.T) {
+	publisher := &webhook.MockPublisher{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithRunPublisher(publisher),
+		WithNetworkProbe(func(ctx context.Context) error { return errors.New("unreachable") }),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Offline)
+	assert.Empty(t, publisher.Published)
+}
+
+func TestRunner_DeliveryCounts(t *testing.T) {
+	rejected := &domain.PushError{Reason: "inconsistent", StatusCode: 400, Message: "inconsistent"}
+	fail := true
+	mockPusher := &metrics.MockPusher{PushFunc: func(ctx context.Context, m *domain.Metrics) error {
+		if fail {
+			return fmt.Errorf("push failed: %w", rejected)
+		}
+		return nil
+	}}
+	counts := map[string]domain.DeliveryCounts{"apprise": {Sent: 1, Failed: 2}}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithMetricsPusher(mockPusher),
+		WithNotificationCounts(func() map[string]domain.DeliveryCounts { return counts }),
+	)
+
+	for range 2 {
+		_, err := runner.Run(context.Background())
+		require.NoError(t, err)
+	}
+
+	// Rejected pushes are counted in the next one that goes through
+	fail = false
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockPusher.PushedMetrics, 3)
+	assert.Empty(t, mockPusher.PushedMetrics[0].PushErrors)
+	assert.Equal(t, map[string]int64{"inconsistent": 2}, mockPusher.PushedMetrics[2].PushErrors)
+	assert.Equal(t, domain.DeliveryCounts{Failed: 2}, mockPusher.PushedMetrics[2].Pushes)
+	assert.Equal(t, counts, mockPusher.PushedMetrics[2].Notifications)
+}
+
+func TestRunner_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	out := filepath.Join(t.TempDir(), "hooks.txt")
+	record := []string{"sh", "-c", `echo "$LUDUSAVI_RUNNER_HOOK $LUDUSAVI_RUNNER_OPERATION $LUDUSAVI_RUNNER_SUCCESS" >> "$OUT"`}
+	h := hooks.NewRunner(hooks.Commands{Pre: record, Post: record},
+		hooks.WithOperations(map[domain.OperationType]hooks.Commands{
+			domain.OperationBackup:      {Pre: record},
+			domain.OperationCloudUpload: {Post: []string{"sh", "-c", "echo 'sync client not running' >&2; exit 1"}},
+		}),
+		hooks.WithEnv(map[string]string{"OUT": out}, nil),
+	)
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithNotifier(mockNotifier),
+		WithHooks(h),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	// A failed hook fails the run, and is notified like a failed operation
+	assert.False(t, result.Success)
+	assert.True(t, result.Backup.Success)
+	assert.Equal(t, []string{"operations.cloud_upload.post"}, result.FailedHooks)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "operations.cloud_upload.post hook failed: sync client not running")
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelWarning, mockNotifier.Notifications[0].Level)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "sync client not running")
+
+	// The post_backup hook runs last, knowing the outcome of the run
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "pre  \npre backup \npost  false\n", string(data))
+
+	// Hooks don't run in dry runs
+	require.NoError(t, os.Remove(out))
+	cfg := testConfig()
+	cfg.DryRun = true
+	result, err = NewRunner(cfg, WithExecutor(&executor.MockExecutor{}), WithHooks(h)).Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.NoFileExists(t, out)
+}
+
+func TestRunner_SelfTest(t *testing.T) {
+	downErr := errors.New("connection refused")
+	cfg := testConfig()
+	cfg.SelfTest.Critical = []config.SelfTestComponent{config.SelfTestLudusavi}
+
+	var previewed bool
+	mockExecutor := &executor.MockExecutor{BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+		previewed = opts.Preview
+		result := domain.NewBackupResult(domain.OperationBackup)
+		result.Stats.TotalGames = 12
+		result.Complete(true, nil)
+		return result, nil
+	}}
+	mockPusher := &metrics.MockPusher{PushFunc: func(ctx context.Context, m *domain.Metrics) error { return downErr }}
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(cfg,
+		WithExecutor(mockExecutor),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+		// Failures aren't held for later, as they would be in a run
+		WithOutbox(filepath.Join(t.TempDir(), "outbox.json"), time.Hour),
+	)
+
+	// A failed metrics push only degrades the service
+	checks, err := runner.SelfTest(context.Background())
+	require.NoError(t, err)
+	require.Len(t, checks, 3)
+	assert.True(t, previewed)
+	assert.Equal(t, config.SelfTestLudusavi, checks[0].Component)
+	assert.True(t, checks[0].Critical)
+	assert.Equal(t, "12 games found", checks[0].Detail)
+	assert.Equal(t, config.SelfTestMetrics, checks[1].Component)
+	assert.ErrorIs(t, checks[1].Err, downErr)
+	assert.False(t, checks[1].Critical)
+	assert.Equal(t, config.SelfTestNotify, checks[2].Component)
+	assert.NoError(t, checks[2].Err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelInfo, mockNotifier.Notifications[0].Level)
+
+	// A critical one keeps it from starting
+	mockExecutor.BackupFunc = func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+		return nil, errors.New("ludusavi not found")
+	}
+	_, err = runner.SelfTest(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ludusavi: backup preview failed: ludusavi not found")
+
+	// Components that aren't configured aren't checked
+	checks, err = NewRunner(cfg, WithExecutor(&executor.MockExecutor{})).SelfTest(context.Background())
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, config.SelfTestLudusavi, checks[0].Component)
+}
+
+func TestRunner_Outbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyAlways
+	downErr := errors.New("connection refused")
+
+	failing := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithMetricsPusher(&metrics.MockPusher{PushFunc: func(ctx context.Context, m *domain.Metrics) error { return downErr }}),
+		WithNotifier(&notify.MockNotifier{NotifyFunc: func(ctx context.Context, n *domain.Notification) error { return downErr }}),
+		WithOutbox(path, time.Hour),
+	)
+	_, err := failing.Run(context.Background())
+	require.NoError(t, err)
+	require.FileExists(t, path)
+
+	// A later runner, as after a restart, delivers what was held first
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+		WithOutbox(path, time.Hour),
+	)
+	first, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	// The held push goes out with the new one as a single push
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Equal(t, first.ID, mockPusher.PushedMetrics[0].RunID)
+	require.Len(t, mockNotifier.Notifications, 2)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Delayed: could not be sent at")
+	assert.NotContains(t, mockNotifier.Notifications[1].Body, "Delayed")
+
+	// Nothing is delivered twice
+	mockPusher.Reset()
+	mockNotifier.Reset()
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Len(t, mockNotifier.Notifications, 1)
+}
+
+func TestMergeMetrics(t *testing.T) {
+	result := func(op domain.OperationType, destination string, games int) *domain.BackupResult {
+		r := domain.NewBackupResult(op)
+		r.Destination = destination
+		r.Stats.TotalGames = games
+		return r
+	}
+	older := &domain.Metrics{RunID: "older", Panics: 1, Results: []*domain.BackupResult{
+		result(domain.OperationBackup, "", 1),
+		result(domain.OperationArchive, "", 1),
+		result(domain.OperationBackup, "usb", 1),
+	}}
+	newer := &domain.Metrics{RunID: "newer", Panics: 2, Results: []*domain.BackupResult{
+		result(domain.OperationBackup, "", 2),
+	}}
+	latest := &domain.Metrics{RunID: "latest", Panics: 3, Results: []*domain.BackupResult{
+		result(domain.OperationBackup, "usb", 3),
+		result(domain.OperationFastBackup, "", 3),
+	}}
+
+	merged := mergeMetrics([]*heldMetrics{{Metrics: older}, {Metrics: newer}}, latest)
+
+	assert.Equal(t, "latest", merged.RunID)
+	assert.Equal(t, int64(3), merged.Panics)
+	games := make(map[string]int)
+	for _, r := range merged.Results {
+		games[r.Operation.String()+"/"+r.Destination] = r.Stats.TotalGames
+	}
+	assert.Equal(t, map[string]int{"backup/": 2, "archive/": 1, "backup/usb": 3, "fast_backup/": 3}, games)
+	assert.Len(t, latest.Results, 2, "latest must not be modified")
+}
+
+func TestRunner_Outbox_TTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	stale := fmt.Sprintf(`{"metrics":[{"held_at":%q,"metrics":{"RunID":"stale"}}],`+
+		`"notifications":[{"held_at":%q,"notification":{"title":"stale"}}]}`,
+		time.Now().Add(-2*time.Hour).Format(time.RFC3339), time.Now().Add(-2*time.Hour).Format(time.RFC3339))
+	require.NoError(t, os.WriteFile(path, []byte(stale), 0600))
+
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyAlways
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+		WithOutbox(path, time.Hour),
+	)
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Equal(t, result.ID, mockPusher.PushedMetrics[0].RunID)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, "Ludusavi Backup Completed", mockNotifier.Notifications[0].Title)
+}
+
+func TestRunner_Run_PublishesResult(t *testing.T) {
+	publisher := &webhook.MockPublisher{
+		PublishFunc: func(ctx context.Context, result *domain.RunResult) error {
+			return errors.New("webhook down")
+		},
+	}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithRunPublisher(publisher),
+	)
+
+	// A failing webhook doesn't fail the run
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, publisher.Published, 1)
+	assert.Same(t, result, publisher.Published[0])
+
+	fast, err := runner.RunFast(context.Background())
+	require.NoError(t, err)
+	require.Len(t, publisher.Published, 2)
+	assert.Same(t, fast, publisher.Published[1])
+}
+
+func TestRunner_Run_AuthRequired(t *testing.T) {
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyError
+	mockNotifier := &notify.MockNotifier{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{
+			CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationCloudUpload)
+				result.AuthRequired = true
+				result.Complete(false, fmt.Errorf("%w: rclone is waiting for a browser sign-in", domain.ErrAuthRequired))
+				return result, nil
+			},
+		}),
+		WithNotifier(mockNotifier),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.True(t, result.AuthRequired())
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	n := mockNotifier.Notifications[0]
+	assert.Equal(t, domain.NotificationLevelWarning, n.Level)
+	assert.Equal(t, "Ludusavi Cloud Sign-in Required", n.Title)
+	assert.Contains(t, n.Body, "cloud_upload: interactive auth required: rclone is waiting for a browser sign-in")
+	assert.Contains(t, n.Body, "rclone config reconnect")
+}
+
+func TestRunner_Run_Custom(t *testing.T) {
+	cfg := testConfig()
+	mockNotifier := &notify.MockNotifier{}
+	mockPusher := &metrics.MockPusher{}
+
+	custom := &customgame.MockBackuper{
+		BackupFunc: func(ctx context.Context) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationCustom)
+			result.Stats = domain.BackupStats{TotalGames: 2, ProcessedGames: 1}
+			result.Complete(false, errors.New("Mods: backup command failed: exit status 1"))
+			return result, nil
+		},
+	}
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithCustomBackuper(custom),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, custom.Calls)
+	require.NotNil(t, result.Custom)
+	assert.False(t, result.Success)
+
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	var ops []domain.OperationType
+	for _, r := range mockPusher.PushedMetrics[0].Results {
+		ops = append(ops, r.Operation)
+	}
+	assert.Contains(t, ops, domain.OperationCustom)
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Custom games error: Mods: backup command failed")
+}
+
+func TestRunner_Run_CloudDownload(t *testing.T) {
+	newRunner := func(mode config.CloudDownloadMode, calls *[]domain.OperationType, downloadErr error) (*Runner, *metrics.MockPusher) {
+		cfg := testConfig()
+		cfg.CloudDownload = mode
+		pusher := &metrics.MockPusher{}
+		exec := &executor.MockExecutor{
+			CloudDownloadFunc: func(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error) {
+				*calls = append(*calls, domain.OperationCloudDownload)
+				assert.True(t, opts.Force)
+				result := domain.NewBackupResult(domain.OperationCloudDownload)
+				result.Complete(downloadErr == nil, downloadErr)
+				return result, nil
+			},
+			CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+				*calls = append(*calls, domain.OperationCloudUpload)
+				result := domain.NewBackupResult(domain.OperationCloudUpload)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}
+		return NewRunner(cfg, WithExecutor(exec), WithMetricsPusher(pusher)), pusher
+	}
+
+	t.Run("off", func(t *testing.T) {
+		var calls []domain.OperationType
+		runner, _ := newRunner(config.CloudDownloadOff, &calls, nil)
+		result, err := runner.Run(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, result.CloudDownload)
+		assert.Equal(t, []domain.OperationType{domain.OperationCloudUpload}, calls)
+	})
+
+	t.Run("startup downloads once, before the upload", func(t *testing.T) {
+		var calls []domain.OperationType
+		runner, pusher := newRunner(config.CloudDownloadStartup, &calls, nil)
+		result, err := runner.Run(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, result.CloudDownload)
+		assert.True(t, result.Success)
+		assert.Equal(t, []domain.OperationType{domain.OperationCloudDownload, domain.OperationCloudUpload}, calls)
+
+		require.Len(t, pusher.PushedMetrics, 1)
+		var ops []domain.OperationType
+		for _, r := range pusher.PushedMetrics[0].Results {
+			ops = append(ops, r.Operation)
+		}
+		assert.Contains(t, ops, domain.OperationCloudDownload)
+
+		result, err = runner.Run(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, result.CloudDownload)
+		assert.Len(t, calls, 3)
+	})
+
+	t.Run("startup retries a failed download", func(t *testing.T) {
+		var calls []domain.OperationType
+		runner, _ := newRunner(config.CloudDownloadStartup, &calls, errors.New("remote unreachable"))
+		result, err := runner.Run(context.Background())
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+
+		result, err = runner.Run(context.Background())
+		require.NoError(t, err)
+		assert.NotNil(t, result.CloudDownload)
+	})
+
+	t.Run("always", func(t *testing.T) {
+		var calls []domain.OperationType
+		runner, _ := newRunner(config.CloudDownloadAlways, &calls, nil)
+		for range 2 {
+			result, err := runner.Run(context.Background())
+			require.NoError(t, err)
+			assert.NotNil(t, result.CloudDownload)
+		}
+	})
+}
+
+func TestRunner_Run_Extras(t *testing.T) {
+	cfg := testConfig()
+	mockPusher := &metrics.MockPusher{}
+	extras := &customgame.MockBackuper{
+		BackupFunc: func(ctx context.Context) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationExtras)
+			result.Complete(true, nil)
+			return result, nil
+		},
+	}
+
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithExtrasBackuper(extras),
+		WithMetricsPusher(mockPusher),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, extras.Calls)
+	require.NotNil(t, result.Extras)
+	assert.Nil(t, result.Custom)
+
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Equal(t, domain.OperationExtras, mockPusher.PushedMetrics[0].Results[len(mockPusher.PushedMetrics[0].Results)-1].Operation)
+}
+
+func TestRunner_Run_ArchiveSkippedOnBackupFailure(t *testing.T) {
+	cfg := testConfig()
+
+	mockExecutor := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(false, errors.New("backup failed"))
+			return result, nil
+		},
+	}
+	mockArchiver := &archive.MockArchiver{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(mockExecutor),
+		WithArchiver(mockArchiver),
+	)
+
+	result, err := runner.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Nil(t, result.Archive)
+	assert.Equal(t, 0, mockArchiver.Calls)
+}
+
+func TestRunner_Run_Tracing(t *testing.T) {
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyAlways
+
+	exporter := &tracing.MockExporter{}
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithArchiver(&archive.MockArchiver{}),
+		WithMetricsPusher(&metrics.MockPusher{}),
+		WithNotifier(&notify.MockNotifier{}),
+		WithTracer(tracing.NewTracer(exporter)),
+	)
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	// The whole run is exported as a single trace once it completes
+	require.Len(t, exporter.Exported, 1)
+	spans := exporter.Spans()
+
+	var root *tracing.Span
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+		if span.ParentID == "" {
+			root = span
+		}
+	}
+	assert.ElementsMatch(t, []string{"cloud upload", "backup", "archive", "push metrics", "notify", "backup run"}, names)
+
+	require.NotNil(t, root)
+	assert.Equal(t, "backup run", root.Name)
+	assert.Equal(t, tracing.StatusOK, root.Status)
+	for _, span := range spans {
+		assert.Equal(t, root.TraceID, span.TraceID)
+		if span != root {
+			assert.Equal(t, root.SpanID, span.ParentID)
+		}
+	}
+}
+
+func TestRunner_RunFast(t *testing.T) {
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyAlways
+
+	var gotOpts domain.BackupOptions
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			gotOpts = opts
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Stats = domain.BackupStats{TotalGames: 2, ProcessedGames: 2, ChangedGames: 2}
+			result.Complete(true, nil)
+			return result, nil
+		},
+		CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+			t.Fatal("fast cycles should not upload to the cloud")
+			return nil, nil
+		},
+	}
+	mockArchiver := &archive.MockArchiver{}
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(mockExec),
+		WithArchiver(mockArchiver),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+	)
+
+	result, err := runner.RunFast(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+
+	assert.Equal(t, domain.BackupOptions{Force: true, ChangedOnly: true}, gotOpts)
+	require.NotNil(t, result.Backup)
+	assert.Equal(t, domain.OperationFastBackup, result.Backup.Operation)
+	assert.Nil(t, result.CloudUpload)
+	assert.Nil(t, result.Archive)
+
+	// Metrics are reported under their own operation
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	require.Len(t, mockPusher.PushedMetrics[0].Results, 1)
+	assert.Equal(t, domain.OperationFastBackup, mockPusher.PushedMetrics[0].Results[0].Operation)
+
+	// Successful fast cycles are never notified, even with notify = "always"
+	assert.Empty(t, mockNotifier.Notifications)
+}
+
+func TestRunner_RunFast_KeepsFullRunMetrics(t *testing.T) {
+	mockPusher := &metrics.MockPusher{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithArchiver(&archive.MockArchiver{}),
+		WithMetricsPusher(mockPusher),
+	)
+
+	full, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	fast, err := runner.RunFast(context.Background())
+	require.NoError(t, err)
+
+	// A push replaces the whole group, so the fast push carries the latest
+	// results of the full run too
+	require.Len(t, mockPusher.PushedMetrics, 2)
+	pushed := mockPusher.PushedMetrics[1]
+	assert.Equal(t, fast.ID, pushed.RunID)
+	byOperation := make(map[domain.OperationType]*domain.BackupResult)
+	for _, result := range pushed.Results {
+		byOperation[result.Operation] = result
+	}
+	assert.Same(t, full.Backup, byOperation[domain.OperationBackup])
+	assert.Same(t, full.CloudUpload, byOperation[domain.OperationCloudUpload])
+	assert.Same(t, full.Archive, byOperation[domain.OperationArchive])
+	assert.Same(t, fast.Backup, byOperation[domain.OperationFastBackup])
+
+	// as do pushes outside runs, such as on pausing
+	runner.setPaused(true)
+	require.Len(t, mockPusher.PushedMetrics, 3)
+	assert.Len(t, mockPusher.PushedMetrics[2].Results, len(pushed.Results))
+}
+
+func TestRunner_RunFast_Failure(t *testing.T) {
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(false, errors.New("preview failed"))
+			return result, nil
+		},
+	}
+	mockNotifier := &notify.MockNotifier{}
+
+	result, err := NewRunner(testConfig(), WithExecutor(mockExec), WithNotifier(mockNotifier)).
+		RunFast(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelError, mockNotifier.Notifications[0].Level)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "preview failed")
+}
+
+func TestRunner_RunGames(t *testing.T) {
+	var gotOpts domain.BackupOptions
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			gotOpts = opts
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Stats = domain.BackupStats{TotalGames: 1, ProcessedGames: 1, ChangedGames: 1}
+			result.Complete(true, nil)
+			return result, nil
+		},
+		CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+			t.Fatal("game backups should not upload to the cloud")
+			return nil, nil
+		},
+	}
+	mockNotifier := &notify.MockNotifier{}
+
+	result, err := NewRunner(testConfig(), WithExecutor(mockExec), WithNotifier(mockNotifier)).
+		RunGames(context.Background(), []string{"Hades"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+
+	assert.Equal(t, domain.BackupOptions{Force: true, Games: []string{"Hades"}}, gotOpts)
+	require.NotNil(t, result.Backup)
+	assert.Equal(t, domain.OperationGameBackup, result.Backup.Operation)
+	assert.Equal(t, []string{"Hades"}, result.Backup.Games)
+	assert.Equal(t, 1, result.Backup.Stats.ProcessedGames)
+	assert.Nil(t, result.CloudUpload)
+	assert.Empty(t, mockNotifier.Notifications)
+}
+
+func TestRunner_Restore(t *testing.T) {
+	var gotOpts []domain.RestoreOptions
+	mockExec := &executor.MockExecutor{
+		RestoreFunc: func(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+			gotOpts = append(gotOpts, opts)
+			result := domain.NewBackupResult(domain.OperationRestore)
+			if opts.Preview {
+				result.Stats = domain.BackupStats{TotalGames: 1, ChangedGames: 1}
+				result.Complete(true, nil)
+			} else {
+				result.Complete(false, errors.New("access denied"))
+			}
+			return result, nil
+		},
+	}
+	mockNotifier := &notify.MockNotifier{}
+	mockPusher := &metrics.MockPusher{}
+	mockHistory := &history.MockHistory{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(mockExec),
+		WithNotifier(mockNotifier),
+		WithMetricsPusher(mockPusher),
+		WithHistory(mockHistory),
+	)
+
+	// A preview is neither pushed, notified nor recorded
+	opts := domain.RestoreOptions{Preview: true, Games: []string{"Hades"}}
+	result, err := runner.Restore(context.Background(), opts)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.NotNil(t, result.Restore)
+	assert.Equal(t, 1, result.Restore.Stats.ChangedGames)
+	assert.Empty(t, mockPusher.PushedMetrics)
+	assert.Empty(t, mockNotifier.Notifications)
+	assert.Empty(t, mockHistory.Recorded)
+
+	result, err = runner.Restore(context.Background(), domain.RestoreOptions{Force: true})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, []domain.RestoreOptions{opts, {Force: true}}, gotOpts)
+
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	require.Len(t, mockPusher.PushedMetrics[0].Results, 1)
+	assert.Equal(t, domain.OperationRestore, mockPusher.PushedMetrics[0].Results[0].Operation)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, "Ludusavi Restore Failed", mockNotifier.Notifications[0].Title)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Restore error: access denied")
+	require.Len(t, mockHistory.Recorded, 1)
+	assert.Equal(t, history.KindRestore, history.NewRecord(mockHistory.Recorded[0]).Kind)
+
+	// A failed restore isn't a failed backup
+	runner.failureMu.Lock()
+	assert.Zero(t, runner.loadFailureState().Streak)
+	runner.failureMu.Unlock()
+}
+
+func TestRunner_Run_BackupDestinations(t *testing.T) {
+	cfg := testConfig()
+	cfg.BackupDestinations = []config.BackupDestinationConfig{
+		{Name: "internal", Path: t.TempDir()},
+		{Name: "usb", Path: filepath.Join(t.TempDir(), "unplugged"), Removable: true},
+		{Name: "nas", Path: t.TempDir()},
+	}
+	nasPath := cfg.BackupDestinations[2].Path
+
+	var paths []string
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			paths = append(paths, opts.Path)
+			result := domain.NewBackupResult(domain.OperationBackup)
+			if opts.Path == nasPath {
+				result.Complete(false, errors.New("disk full"))
+			} else {
+				result.Complete(true, nil)
+			}
+			return result, nil
+		},
+	}
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(mockExec),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+	)
+
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	// The unmounted removable destination is never backed up
+	assert.Equal(t, []string{"", cfg.BackupDestinations[0].Path, nasPath}, paths)
+
+	require.Len(t, result.Destinations, 3)
+	assert.Equal(t, "internal", result.Destinations[0].Destination)
+	assert.True(t, result.Destinations[0].Success)
+	assert.Equal(t, "usb", result.Destinations[1].Destination)
+	assert.True(t, result.Destinations[1].Success)
+	assert.True(t, result.Destinations[1].Skipped)
+	assert.Equal(t, "nas", result.Destinations[2].Destination)
+	assert.False(t, result.Destinations[2].Success)
+
+	// The main backup succeeded, but the failed destination fails the run
+	assert.True(t, result.Backup.Success)
+	assert.False(t, result.Success)
+
+	// Skipped destinations have no metrics
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	var destinations []string
+	for _, r := range mockPusher.PushedMetrics[0].Results {
+		destinations = append(destinations, r.Destination)
+	}
+	assert.Equal(t, []string{"", "", "internal", "nas"}, destinations)
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Backup to nas error: disk full")
+}
+
+func TestRunner_Run_StoreSnapshots(t *testing.T) {
+	backupOK := true
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(backupOK, nil)
+			return result, nil
+		},
+	}
+	mockSnapshotter := &snapshot.MockSnapshotter{}
+	manager := snapshot.NewManager(mockSnapshotter, "ludusavi-runner")
+
+	runner := NewRunner(testConfig(), WithExecutor(mockExec), WithStoreSnapshots(manager, true, true))
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockSnapshotter.Snapshots, 2)
+
+	// No snapshot of a failed backup
+	backupOK = false
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockSnapshotter.Snapshots, 3)
+}
+
+func TestRunner_Run_Badge(t *testing.T) {
+	backupOK := true
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(backupOK, nil)
+			return result, nil
+		},
+	}
+	cfg := testConfig()
+	cfg.Badge.Path = filepath.Join(t.TempDir(), "www", "badge.svg")
+	runner := NewRunner(cfg, WithExecutor(mockExec))
+
+	assert.Equal(t, "no backups yet", runner.Badge().Message)
+	assert.Equal(t, config.DefaultBadgeLabel, runner.Badge().Label)
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "backed up <1m ago ✓", runner.Badge().Message)
+
+	data, err := os.ReadFile(cfg.Badge.Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "backed up &lt;1m ago ✓")
+
+	backupOK = false
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "backup failed, last ok <1m ago ✗", runner.Badge().Message)
+}
+
+func TestRunner_ShutdownBackup_DryRun(t *testing.T) {
+	cfg := testConfig()
+	cfg.DryRun = true
+
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			t.Fatal("executor should not be called in dry run")
+			return nil, nil
+		},
+	}
+
+	result, err := NewRunner(cfg, WithExecutor(mockExec)).
+		ShutdownBackup(context.Background(), domain.BackupOptions{ChangedOnly: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestRunner_BuildSuccessMessage(t *testing.T) {
+	cfg := testConfig()
+	runner := NewRunner(cfg)
+	runner.hostname = "test-host"
+
+	result := &domain.RunResult{
+		Success:  true,
+		Duration: 5 * time.Second,
+		Backup: &domain.BackupResult{
+			Success: true,
+			Stats: domain.BackupStats{
+				TotalGames:     100,
+				ProcessedGames: 95,
+				NewGames:       5,
+				ChangedGames:   10,
+			},
+		},
+	}
+
+	msg := runner.buildSuccessMessage(result)
+
+	assert.Contains(t, msg, "test-host")
+	assert.Contains(t, msg, "100 total")
+	assert.Contains(t, msg, "95 processed")
+	assert.Contains(t, msg, "5 new")
+	assert.Contains(t, msg, "10 updated")
+}
+
+func TestRunner_BuildErrorMessage(t *testing.T) {
+	cfg := testConfig()
+	runner := NewRunner(cfg)
+	runner.hostname = "test-host"
+
+	result := &domain.RunResult{
+		Success: false,
+		Backup: &domain.BackupResult{
+			Success: false,
+			Error:   "disk full",
+		},
+		Errors: []string{"additional error"},
+	}
+
+	msg := runner.buildErrorMessage(result)
+
+	assert.Contains(t, msg, "test-host")
+	assert.Contains(t, msg, "disk full")
+	assert.Contains(t, msg, "additional error")
+}
+
+func TestRunner_Run_CloudTokens(t *testing.T) {
+	cfg := testConfig()
+	cfg.CloudToken = config.CloudTokenConfig{Enabled: true, WarnDays: 7}
+	mockNotifier := &notify.MockNotifier{}
+
+	expiring := time.Now().Add(3 * 24 * time.Hour)
+	tokens := []domain.CloudToken{
+		{Remote: "box", Type: "box", Deadline: expiring},
+		{Remote: "gdrive", Type: "drive"},
+		{Remote: "onedrive", Type: "onedrive", Deadline: time.Now().Add(30 * 24 * time.Hour)},
+	}
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{}),
+		WithNotifier(mockNotifier),
+		WithCloudTokens(&rclone.MockTokenSource{
+			TokensFunc: func(ctx context.Context) ([]domain.CloudToken, error) {
+				return tokens, nil
+			},
+		}),
+	)
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	n := mockNotifier.Notifications[0]
+	assert.Equal(t, domain.NotificationLevelWarning, n.Level)
+	assert.Equal(t, "Ludusavi Cloud Token Expiring", n.Title)
+	assert.Contains(t, n.Body, "cloud remote box (box)")
+	assert.Contains(t, n.Body, "expires in 3 days")
+	assert.Contains(t, n.Body, "rclone config reconnect box:")
+
+	// The same deadline is only warned about once
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 1)
+
+	// Signing in again and letting it near expiry warns again
+	tokens[0].Deadline = expiring.Add(-time.Hour)
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 2)
+}
+
+func TestRunner_BuildTokenMessage_Expired(t *testing.T) {
+	runner := NewRunner(testConfig())
+	now := time.Now()
+
+	msg := runner.buildTokenMessage(domain.CloudToken{Remote: "box", Type: "box", Deadline: now.Add(-time.Hour)}, now)
+	assert.Contains(t, msg, "expired on")
+}
+
+func TestRunner_Run_SizeGuard(t *testing.T) {
+	cfg := testConfig()
+	cfg.SizeGuard = config.SizeGuardConfig{MaxRunGB: 5, MaxGameGB: 2}
+	mockNotifier := &notify.MockNotifier{}
+
+	gameBytes := map[string]int64{"Small Game": 1e6, "Replay Hoarder": 3e9}
+	processed := int64(4e9)
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Stats.ProcessedBytes = processed
+				result.GameBytes = gameBytes
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+		WithNotifier(mockNotifier),
+	)
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	n := mockNotifier.Notifications[0]
+	assert.Equal(t, domain.NotificationLevelWarning, n.Level)
+	assert.Equal(t, "Ludusavi Backup Size Warning", n.Title)
+	assert.Contains(t, n.Body, "- Replay Hoarder: 3.0 GB")
+	assert.NotContains(t, n.Body, "Small Game")
+	assert.NotContains(t, n.Body, "processed")
+
+	// A game over the limit is only warned about once
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 1)
+
+	// A run over the limit warns every time
+	processed = 6e9
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 2)
+	assert.Contains(t, mockNotifier.Notifications[1].Body, "processed 6.0 GB of saves, more than the 5.0 GB limit")
+	assert.NotContains(t, mockNotifier.Notifications[1].Body, "Replay Hoarder")
+
+	// Shrinking below the limit and growing past it again warns again
+	processed = 4e9
+	gameBytes["Replay Hoarder"] = 1e9
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	gameBytes["Replay Hoarder"] = 3e9
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 3)
+}
+
+func TestRunner_Run_GameCount(t *testing.T) {
+	cfg := testConfig()
+	cfg.GameCount = config.GameCountConfig{Enabled: true, DropPercent: 25, Window: 3}
+	cfg.Metrics.Enabled = true
+	statePath := filepath.Join(t.TempDir(), "game-counts.json")
+	mockNotifier := &notify.MockNotifier{}
+	mockPusher := &metrics.MockPusher{}
+
+	games := 100
+	newRunner := func() *Runner {
+		return NewRunner(cfg,
+			WithExecutor(&executor.MockExecutor{
+				BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+					result := domain.NewBackupResult(domain.OperationBackup)
+					result.Stats.TotalGames = games
+					result.Complete(true, nil)
+					return result, nil
+				},
+			}),
+			WithNotifier(mockNotifier),
+			WithMetricsPusher(mockPusher),
+			WithGameCountStatePath(statePath),
+		)
+	}
+
+	runner := newRunner()
+	for range 3 {
+		_, err := runner.Run(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Empty(t, mockNotifier.Notifications)
+
+	// A drop within the limit is fine
+	games = 80
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, mockNotifier.Notifications)
+
+	// The history survives restarts
+	games = 50
+	runner = newRunner()
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	n := mockNotifier.Notifications[0]
+	assert.Equal(t, domain.NotificationLevelWarning, n.Level)
+	assert.Equal(t, "Ludusavi Game Count Dropped", n.Title)
+	assert.Contains(t, n.Body, "found 50 games")
+	assert.Contains(t, n.Body, "average of 93.3 over the last 3 backups")
+
+	pushed := mockPusher.PushedMetrics[len(mockPusher.PushedMetrics)-1]
+	require.NotNil(t, pushed.GameCount)
+	assert.True(t, pushed.GameCount.Regressed)
+	assert.InDelta(t, 93.3, pushed.GameCount.Average, 0.1)
+
+	// Warned once per regression
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 1)
+
+	// Recovering clears the regression
+	games = 100
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	pushed = mockPusher.PushedMetrics[len(mockPusher.PushedMetrics)-1]
+	assert.False(t, pushed.GameCount.Regressed)
+}
+
+func TestRunner_Run_Quarantine(t *testing.T) {
+	cfg := testConfig()
+	cfg.Quarantine = config.QuarantineConfig{Enabled: true, After: 2, RetryInterval: time.Hour}
+	statePath := filepath.Join(t.TempDir(), "quarantine.json")
+	mockNotifier := &notify.MockNotifier{}
+
+	broken := true
+	var excluded []string
+	newRunner := func() *Runner {
+		return NewRunner(cfg,
+			WithExecutor(&executor.MockExecutor{
+				BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+					excluded = opts.Exclude
+					result := domain.NewBackupResult(domain.OperationBackup)
+					result.GameBytes = map[string]int64{"Hades": 10}
+					if slices.Contains(opts.Exclude, "Celeste") {
+						result.Complete(true, nil)
+						return result, nil
+					}
+					result.GameBytes["Celeste"] = 5
+					if !broken {
+						result.Complete(true, nil)
+						return result, nil
+					}
+					result.FailedGames = map[string]string{"Celeste": "access denied"}
+					result.Complete(false, domain.WithErrorCode(domain.CodeGamesFailed, errors.New("1 games failed to back up")))
+					return result, nil
+				},
+			}),
+			WithNotifier(mockNotifier),
+			WithQuarantineStatePath(statePath),
+		)
+	}
+
+	// Failing once isn't enough
+	runner := newRunner()
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, runner.Quarantined())
+
+	// Failing again quarantines the game, but fails the run
+	result, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	quarantined := runner.Quarantined()
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, "Celeste", quarantined[0].Title)
+	assert.Equal(t, 2, quarantined[0].Failures)
+	assert.Equal(t, "access denied", quarantined[0].Error)
+	n := mockNotifier.Notifications[len(mockNotifier.Notifications)-1]
+	assert.Contains(t, n.Body, "Quarantined games, left out of backups and retried every 1h0m0s:\n- Celeste: access denied (failed 2 times)")
+
+	// Quarantined games are left out, and the quarantine survives restarts
+	runner = newRunner()
+	result, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"Celeste"}, excluded)
+
+	// Named games are tried even in quarantine
+	_, err = runner.RunGames(context.Background(), []string{"Celeste"})
+	require.NoError(t, err)
+	assert.Empty(t, excluded)
+
+	// A retry failing again doesn't fail the run
+	cfg.Quarantine.RetryInterval = 0
+	result, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, excluded)
+	assert.True(t, result.Success, result.Errors)
+	assert.True(t, result.Backup.Success)
+	assert.Len(t, runner.Quarantined(), 1)
+
+	// Backing up releases the game
+	broken = false
+	result, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Empty(t, runner.Quarantined())
+}
+
+func TestRunner_Run_FailureStreak(t *testing.T) {
+	cfg := testConfig()
+	statePath := filepath.Join(t.TempDir(), "failures.json")
+	mockNotifier := &notify.MockNotifier{}
+
+	fail := true
+	newRunner := func() *Runner {
+		return NewRunner(cfg,
+			WithExecutor(&executor.MockExecutor{
+				BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+					op := domain.OperationBackup
+					if opts.ChangedOnly {
+						op = domain.OperationFastBackup
+					}
+					result := domain.NewBackupResult(op)
+					if fail {
+						result.Complete(false, errors.New("disk full"))
+					} else {
+						result.Complete(true, nil)
+					}
+					return result, nil
+				},
+			}),
+			WithNotifier(mockNotifier),
+			WithFailureStatePath(statePath),
+		)
+	}
+	lastStreak := func() int {
+		require.NotEmpty(t, mockNotifier.Notifications)
+		return mockNotifier.Notifications[len(mockNotifier.Notifications)-1].FailureStreak
+	}
+
+	runner := newRunner()
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, lastStreak())
+	assert.NotContains(t, mockNotifier.Notifications[0].Body, "in a row")
+
+	// Fast runs count towards the streak
+	_, err = runner.RunFast(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, lastStreak())
+
+	// The streak survives restarts
+	runner = newRunner()
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, lastStreak())
+	assert.Contains(t, mockNotifier.Notifications[2].Body, "3 runs in a row have failed.")
+
+	// A successful fast run doesn't end it
+	fail = false
+	_, err = runner.RunFast(context.Background())
+	require.NoError(t, err)
+	fail = true
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, lastStreak())
+
+	// A successful full run does
+	fail = false
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	fail = true
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, lastStreak())
+}
+
+func TestRunner_Run_Maintenance(t *testing.T) {
+	cfg := testConfig()
+	cfg.Apprise.Notify = config.NotifyAlways
+	path := filepath.Join(t.TempDir(), "maintenance.json")
+	mockNotifier := &notify.MockNotifier{}
+	mockPusher := &metrics.MockPusher{}
+
+	fail := true
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				if fail {
+					result.Complete(false, errors.New("drive unplugged"))
+				} else {
+					result.Complete(true, nil)
+				}
+				return result, nil
+			},
+		}),
+		WithNotifier(mockNotifier),
+		WithMetricsPusher(mockPusher),
+		WithMaintenancePath(path),
+	)
+
+	_, err := StartMaintenance(path, time.Time{})
+	require.NoError(t, err)
+
+	// Failures aren't notified, but metrics still go out
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.True(t, result.Maintenance)
+	assert.Empty(t, mockNotifier.Notifications)
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.True(t, mockPusher.PushedMetrics[0].Maintenance)
+
+	// Successes still are
+	fail = false
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelInfo, mockNotifier.Notifications[0].Level)
+
+	// Turned off, failures are notified again
+	fail = true
+	require.NoError(t, EndMaintenance(path))
+	result, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Maintenance)
+	require.Len(t, mockNotifier.Notifications, 2)
+	assert.Equal(t, "Ludusavi Backup Partially Failed", mockNotifier.Notifications[1].Title)
+	assert.False(t, mockPusher.PushedMetrics[2].Maintenance)
+
+	// Maintenance ends by itself when until passes
+	_, err = StartMaintenance(path, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 3)
+}
+
+func TestRunner_Acknowledge(t *testing.T) {
+	cfg := testConfig()
+	mockNotifier := &notify.MockNotifier{}
+
+	backupErr := "disk full"
+	runner := NewRunner(cfg,
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				if backupErr != "" {
+					result.Complete(false, errors.New(backupErr))
+				} else {
+					result.Complete(true, nil)
+				}
+				return result, nil
+			},
+		}),
+		WithNotifier(mockNotifier),
+		WithAckURL("http://backup-pc:9180/"),
+	)
+	ackToken := func(n *domain.Notification) string {
+		_, after, ok := strings.Cut(n.Body, "http://backup-pc:9180/ack/")
+		require.True(t, ok, "no ack link in %q", n.Body)
+		token, _, _ := strings.Cut(after, "\n")
+		return token
+	}
+
+	_, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 1)
+	token := ackToken(mockNotifier.Notifications[0])
+
+	_, err = runner.Acknowledge("wrong")
+	assert.ErrorIs(t, err, ErrAckNotFound)
+	ack, err := runner.Acknowledge(token)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ack.FailedRuns)
+
+	// The same failure isn't notified again
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 1)
+
+	// A different one is, with a new link
+	backupErr = "permission denied"
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, mockNotifier.Notifications, 2)
+	assert.NotEqual(t, token, ackToken(mockNotifier.Notifications[1]))
+	_, err = runner.Acknowledge(token)
+	assert.ErrorIs(t, err, ErrAckNotFound)
+
+	// Recovering ends the acknowledgment
+	_, err = runner.Acknowledge(ackToken(mockNotifier.Notifications[1]))
+	require.NoError(t, err)
+	backupErr = ""
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	backupErr = "permission denied"
+	_, err = runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mockNotifier.Notifications, 3)
+}
+
+func TestRunner_Run_History(t *testing.T) {
+	mockHistory := &history.MockHistory{RecordFunc: func(*domain.RunResult) error { return errors.New("disk full") }}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{}),
+		WithNotifier(&notify.MockNotifier{}),
+		WithMetricsPusher(&metrics.MockPusher{}),
+		WithHistory(mockHistory),
+	)
+
+	// Failing to record a run doesn't fail it
+	result, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, mockHistory.Recorded, 1)
+	assert.Same(t, result, mockHistory.Recorded[0])
+}
+
+func TestRunner_Run_WaitsForLudusavi(t *testing.T) {
+	t.Run("until it exits", func(t *testing.T) {
+		finds, findsAtBackup := 0, 0
+		find := func() ([]int, error) {
+			finds++
+			if finds < 3 {
+				return []int{42}, nil
+			}
+			return nil, nil
+		}
+		exec := &executor.MockExecutor{BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			findsAtBackup = finds
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Complete(true, nil)
+			return result, nil
+		}}
+		runner := NewRunner(testConfig(), WithExecutor(exec), WithLudusaviWait(find, time.Minute))
+		runner.ludusaviPoll = time.Millisecond
+
+		result, err := runner.RunFast(context.Background())
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, 3, findsAtBackup, "the backup waits for ludusavi to exit")
+	})
+
+	t.Run("up to the max wait", func(t *testing.T) {
+		find := func() ([]int, error) { return []int{42}, nil }
+		runner := NewRunner(testConfig(), WithExecutor(&executor.MockExecutor{}), WithLudusaviWait(find, 20*time.Millisecond))
+		runner.ludusaviPoll = time.Millisecond
+
+		result, err := runner.Run(context.Background())
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+	})
 }
@@ -2,24 +2,114 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 )
 
+// ErrSchedulerStopped is returned by Restore when the scheduler isn't
+// running, or stops before the restore ran.
+var ErrSchedulerStopped = errors.New("scheduler stopped")
+
 // Scheduler manages periodic execution of backup runs.
 type Scheduler struct {
 	runner          *Runner
 	interval        time.Duration
 	backupOnStartup bool
+	fastInterval    time.Duration
+	volumeInterval  time.Duration
 	logger          *slog.Logger
 
+	// cron, if set, schedules full runs instead of interval.
+	cron *cron.Schedule
+
+	// Watchdog settings; see watchdog.go.
+	watchdog   bool
+	runTimeout time.Duration
+	stallGrace time.Duration
+	heartbeat  atomic.Int64
+	abandon    chan struct{}
+
+	// minRunGap suppresses event-driven runs that would start sooner after
+	// the last run ended; see tooSoon.
+	minRunGap time.Duration
+
+	// shutdownBackup, if set, is run as a final quick backup on shutdown,
+	// bounded by shutdownBackupTimeout.
+	shutdownBackup        *domain.BackupOptions
+	shutdownBackupTimeout time.Duration
+
+	// shutdownGrace is how long a run in progress may take to finish once
+	// shutdown is requested.
+	shutdownGrace time.Duration
+
+	// events, if set, receives status changes and run results.
+	events *events.Broker
+
+	// queue holds the runs waiting for the worker; see queue.go.
+	queue *jobQueue
+
+	// triggerC requests a full run outside the schedule; see Trigger.
+	triggerC chan struct{}
+
+	// rescheduleC requests the loop to take up the schedule in reschedule,
+	// guarded by mu; see Reschedule.
+	rescheduleC chan struct{}
+	reschedule  *runSchedule
+
+	// Backups of games whose sessions ended, run once no more have ended
+	// for gameDebounce, or of games named manually; see games.go.
+	gameDebounce time.Duration
+	gamesC       chan struct{}
+	gameMu       sync.Mutex
+	gameTimer    *time.Timer
+	pendingGames map[string]bool
+	gamesManual  bool
+
+	// calendar, if set, skips scheduled backups and adds extra runs; it is
+	// checked for due runs every calendarInterval. See calendar.go.
+	calendar         *Calendar
+	calendarInterval time.Duration
+
+	// The wall clock is compared with the monotonic clock every
+	// clockInterval to resync the schedule after a sleep or clock change;
+	// see clock.go.
+	clockInterval time.Duration
+	wallClock     func() time.Time
+	epoch         time.Time
+
 	mu        sync.Mutex
 	running   bool
 	stopCh    chan struct{}
 	stoppedCh chan struct{}
+
+	// Guarded by mu; see Status.
+	state          SchedulerState
+	paused         bool
+	runStartedAt   time.Time
+	drainStartedAt time.Time
+	nextRunAt      time.Time
+	stallAfter     time.Duration
+	lastRunEndedAt time.Time
+	suppressed     int
+
+	// preempt, guarded by mu, is closed to stop the scheduled full run in
+	// progress at the next game boundary for a manual run; see runJob.
+	preempt chan struct{}
+
+	// ticks, guarded by mu, are the latest decisions about runs, oldest
+	// first; see ticks.go.
+	ticks []SchedulerTick
 }
 
 // SchedulerOption configures a Scheduler.
@@ -32,6 +122,14 @@ func WithInterval(d time.Duration) SchedulerOption {
 	}
 }
 
+// WithCronSchedule schedules full runs at the times of c rather than at an
+// interval.
+func WithCronSchedule(c *cron.Schedule) SchedulerOption {
+	return func(s *Scheduler) {
+		s.cron = c
+	}
+}
+
 // WithBackupOnStartup sets whether to run a backup immediately on start.
 func WithBackupOnStartup(b bool) SchedulerOption {
 	return func(s *Scheduler) {
@@ -39,6 +137,82 @@ func WithBackupOnStartup(b bool) SchedulerOption {
 	}
 }
 
+// WithWatchdog enables the watchdog. Runs are cancelled once they exceed
+// runTimeout (zero for no deadline), a worker stuck on a run that ignores
+// cancellation is recovered by abandoning the run, and a stalled scheduler
+// loop is reported.
+func WithWatchdog(runTimeout time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.watchdog = true
+		s.runTimeout = runTimeout
+	}
+}
+
+// WithShutdownGrace sets how long a run in progress may take to finish once
+// shutdown is requested before it is cancelled.
+func WithShutdownGrace(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.shutdownGrace = d
+	}
+}
+
+// WithShutdownBackup runs a final quick backup with opts when the scheduler
+// stops, cancelled after timeout so it can't hold up a system shutdown.
+func WithShutdownBackup(opts domain.BackupOptions, timeout time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.shutdownBackup = &opts
+		s.shutdownBackupTimeout = timeout
+	}
+}
+
+// WithFastInterval runs fast cycles, which back up only games with changed
+// saves, at this interval between full cycles. Zero disables fast cycles.
+func WithFastInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.fastInterval = d
+	}
+}
+
+// WithVolumeWatch checks for removable backup destinations at this interval
+// and backs up to each as soon as it is plugged in. Zero disables watching.
+func WithVolumeWatch(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.volumeInterval = d
+	}
+}
+
+// WithMinTimeBetweenRuns suppresses backups triggered by events, such as a
+// game session ending, that would start less than d after the last run
+// ended. Zero disables the limit.
+func WithMinTimeBetweenRuns(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.minRunGap = d
+	}
+}
+
+// WithCalendar skips scheduled backups and runs extra full backups as set
+// in c.
+func WithCalendar(c *Calendar) SchedulerOption {
+	return func(s *Scheduler) {
+		s.calendar = c
+	}
+}
+
+// WithGameDebounce sets how long a game backup requested by TriggerGame
+// waits for further requests, which it then covers too.
+func WithGameDebounce(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.gameDebounce = d
+	}
+}
+
+// WithEvents publishes scheduler status changes and run results to b.
+func WithEvents(b *events.Broker) SchedulerOption {
+	return func(s *Scheduler) {
+		s.events = b
+	}
+}
+
 // WithSchedulerLogger sets the logger.
 func WithSchedulerLogger(l *slog.Logger) SchedulerOption {
 	return func(s *Scheduler) {
@@ -49,10 +223,23 @@ func WithSchedulerLogger(l *slog.Logger) SchedulerOption {
 // NewScheduler creates a new Scheduler.
 func NewScheduler(runner *Runner, opts ...SchedulerOption) *Scheduler {
 	s := &Scheduler{
-		runner:          runner,
-		interval:        20 * time.Minute,
-		backupOnStartup: true,
-		logger:          slog.Default(),
+		runner:           runner,
+		interval:         20 * time.Minute,
+		backupOnStartup:  true,
+		logger:           slog.Default(),
+		stallGrace:       defaultStallGrace,
+		abandon:          make(chan struct{}, 1),
+		queue:            newJobQueue(),
+		triggerC:         make(chan struct{}, 1),
+		rescheduleC:      make(chan struct{}, 1),
+		gamesC:           make(chan struct{}, 1),
+		gameDebounce:     config.DefaultGameEventsDebounce,
+		shutdownGrace:    defaultShutdownGrace,
+		calendarInterval: defaultCalendarInterval,
+		clockInterval:    defaultClockInterval,
+		wallClock:        wallClock,
+		epoch:            time.Now(),
+		state:            SchedulerStateStopped,
 	}
 
 	for _, opt := range opts {
@@ -70,87 +257,522 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return nil
 	}
 	s.running = true
+	s.state = s.idleState()
 	s.stopCh = make(chan struct{})
 	s.stoppedCh = make(chan struct{})
 	s.mu.Unlock()
+	s.publishStatus()
 
 	defer func() {
 		s.mu.Lock()
 		s.running = false
+		s.state = SchedulerStateStopped
+		s.nextRunAt = time.Time{}
 		close(s.stoppedCh)
 		s.mu.Unlock()
+		s.publishStatus()
 	}()
 
+	var schedule string
+	if s.cron != nil {
+		schedule = s.cron.String()
+	}
 	s.logger.Info("scheduler started",
 		"interval", s.interval,
+		"schedule", schedule,
+		"fast_interval", s.fastInterval,
 		"backup_on_startup", s.backupOnStartup,
+		"watchdog", s.watchdog,
 	)
 
+	s.beat()
+	if s.watchdog {
+		s.mu.Lock()
+		s.stallAfter = s.stallThreshold()
+		s.mu.Unlock()
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go s.watch(watchCtx, max(s.interval/4, time.Second))
+	}
+
+	// Runs are queued for the worker, so triggers arriving during a run
+	// wait for it rather than being lost
+	workCtx, stopWork := context.WithCancel(ctx)
+	defer stopWork()
+	workDone := make(chan struct{})
+	go func() {
+		defer close(workDone)
+		s.work(workCtx)
+	}()
+	stop := func() {
+		stopWork()
+		<-workDone
+		if dropped := s.queue.clear(); len(dropped) > 0 {
+			s.logger.Info("dropping queued backups on shutdown", "queued", dropped)
+		}
+		s.runFinalBackup()
+	}
+
 	// Run backup on startup if configured
 	if s.backupOnStartup {
-		s.logger.Debug("running backup on startup")
-		s.runBackup(ctx)
+		s.logger.Debug("queueing backup on startup")
+		s.enqueue(&job{kind: jobFull, what: "startup backup"})
 	}
 
 	// Schedule periodic backups
-	ticker := time.NewTicker(s.interval)
+	ticker := time.NewTicker(s.setNextRun())
 	defer ticker.Stop()
 
+	// Fast cycles run between full cycles when configured
+	var fastTicker *time.Ticker
+	var fastC <-chan time.Time
+	if s.fastInterval > 0 {
+		fastTicker = time.NewTicker(s.fastInterval)
+		defer fastTicker.Stop()
+		fastC = fastTicker.C
+	}
+
+	// A full run starts the interval over and covers everything a fast
+	// cycle would. The times of a cron schedule don't move.
+	restartInterval := func() {
+		if s.cron == nil {
+			ticker.Reset(s.setNextRun())
+		}
+		if fastTicker != nil {
+			fastTicker.Reset(s.fastInterval)
+		}
+	}
+
+	// Removable destinations are backed up as soon as they are plugged in
+	var volumeC <-chan time.Time
+	if s.volumeInterval > 0 {
+		s.runner.CheckDestinations(ctx)
+		volumeTicker := time.NewTicker(s.volumeInterval)
+		defer volumeTicker.Stop()
+		volumeC = volumeTicker.C
+	}
+
+	// Extra runs from the calendar are due once their time has passed
+	var calendarC <-chan time.Time
+	calendarCheckedAt := time.Now()
+	if s.calendar != nil {
+		calendarTicker := time.NewTicker(s.calendarInterval)
+		defer calendarTicker.Stop()
+		calendarC = calendarTicker.C
+	}
+
+	// Sleep and clock changes are noticed by the wall clock moving apart
+	// from the monotonic clock the tickers run on
+	clock := newClockWatch(s.wallClock)
+	clockTicker := time.NewTicker(s.clockInterval)
+	defer clockTicker.Stop()
+
 	for {
+		s.beat()
 		select {
 		case <-ctx.Done():
 			s.logger.Info("scheduler stopping due to context cancellation")
-			s.runFinalBackup()
+			stop()
 			return ctx.Err()
 
 		case <-s.stopCh:
 			s.logger.Info("scheduler stopping due to stop signal")
-			s.runFinalBackup()
+			stop()
 			return nil
 
 		case <-ticker.C:
-			s.logger.Debug("interval triggered, running backup")
-			s.runBackup(ctx)
+			s.logger.Debug("interval triggered, queueing backup")
+			s.enqueue(&job{kind: jobFull, what: "scheduled backup"})
+			if s.cron != nil {
+				ticker.Reset(s.setNextRun())
+			}
+			restartInterval()
+
+		case <-s.rescheduleC:
+			s.applySchedule()
+			ticker.Reset(s.setNextRun())
+
+		case <-s.triggerC:
+			s.logger.Info("backup triggered manually")
+			s.enqueue(&job{kind: jobFull, what: "manual backup", manual: true})
+			restartInterval()
+
+		case <-s.gamesC:
+			games, manual := s.takeGames()
+			if len(games) == 0 {
+				continue
+			}
+			if manual {
+				s.logger.Info("game backup triggered manually", logging.KeyGames, games)
+			} else {
+				s.logger.Info("queueing backup of games whose sessions ended", logging.KeyGames, games)
+			}
+			s.enqueue(&job{kind: jobGames, what: "game backup", manual: manual, names: games})
+
+		case <-fastC:
+			s.logger.Debug("fast interval triggered, queueing fast backup")
+			s.enqueue(&job{kind: jobFast, what: "fast backup"})
+
+		case <-volumeC:
+			if s.IsPaused() {
+				continue
+			}
+			if appeared := s.runner.CheckDestinations(ctx); len(appeared) > 0 {
+				s.enqueue(&job{kind: jobDestinations, what: "destination backup", names: appeared})
+			}
+
+		case now := <-calendarC:
+			runs := s.calendar.Runs(calendarCheckedAt, now)
+			calendarCheckedAt = now
+			if len(runs) == 0 {
+				continue
+			}
+			s.logger.Info("queueing extra backup from calendar", "id", runs[0].ID, "reason", runs[0].Reason)
+			s.enqueue(&job{kind: jobFull, what: "extra backup"})
+			restartInterval()
+
+		case <-clockTicker.C:
+			jump := clock.jump()
+			if jump.Abs() < clockJumpTolerance || !s.resync(jump, ticker) {
+				continue
+			}
+
+			// One catch-up run covers everything missed meanwhile, extra
+			// runs from the calendar included, rather than a burst of them
+			calendarCheckedAt = time.Now()
+			s.logger.Info("queueing backup missed while the system was asleep")
+			s.enqueue(&job{kind: jobFull, what: "missed backup"})
+			if s.cron != nil {
+				ticker.Reset(s.setNextRun())
+			}
+			restartInterval()
 		}
 	}
 }
 
-// runBackup runs a backup with a separate context that allows graceful completion.
-// If shutdown is requested during a backup, the backup gets a 2 minute grace period.
-func (s *Scheduler) runBackup(ctx context.Context) {
+// enqueue queues j for the worker, publishing the status when it waits
+// behind other runs, and records the decision. A restore, or a manual game or destination backup,
+// preempts the scheduled full run in progress, if any, rather than wait for it.
+func (s *Scheduler) enqueue(j *job) {
+	s.mu.Lock()
+	busy := s.state == SchedulerStateRunning
+	s.mu.Unlock()
+
+	var reason string
+	if busy {
+		reason = "waiting for the backup in progress"
+	}
+	if s.queue.push(j) {
+		s.record(j, DecisionQueued, reason)
+		s.publishStatus()
+	} else {
+		s.record(j, DecisionMerged, reason)
+	}
+	if j.manual && j.kind != jobFull {
+		s.preemptRun(j)
+	}
+}
+
+// preemptRun asks the scheduled full run in progress, if any, to stop at the
+// next game boundary so j can run first.
+func (s *Scheduler) preemptRun(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.preempt == nil {
+		return
+	}
+	s.logger.Info("preempting scheduled backup", "for", j.String())
+	close(s.preempt)
+	s.preempt = nil
+}
+
+// work runs the queued jobs one at a time until ctx is cancelled.
+func (s *Scheduler) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.queue.ready:
+		}
+
+		for j := s.queue.pop(); j != nil; j = s.queue.pop() {
+			if ctx.Err() != nil {
+				return
+			}
+			s.runJob(ctx, j)
+		}
+	}
+}
+
+// runJob runs a queued job, unless it isn't manual and scheduled backups are
+// paused or skipped by the calendar.
+func (s *Scheduler) runJob(ctx context.Context, j *job) {
+	if !j.manual {
+		if s.IsPaused() {
+			s.logger.Debug(j.what + " skipped while paused")
+			s.record(j, DecisionSkipped, "paused")
+			return
+		}
+		if skip, ok := s.skipped(j.what); ok {
+			reason := "skipped by the calendar until " + skip.End.Format(time.DateTime)
+			if skip.Reason != "" {
+				reason += ": " + skip.Reason
+			}
+			s.record(j, DecisionSkipped, reason)
+			return
+		}
+		if s.tooSoon(j) {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if j.kind == jobRestore && j.caller.Err() != nil {
+		s.record(j, DecisionSkipped, "cancelled while queued")
+		return
+	}
+
+	var result *domain.RunResult
+	switch j.kind {
+	case jobFull:
+		if j.manual {
+			result = s.runBackup(ctx)
+			break
+		}
+		result = s.runPreemptible(ctx)
+	case jobFast:
+		result = s.runCycle(ctx, s.runner.RunFast)
+	case jobGames:
+		result = s.runCycle(ctx, func(ctx context.Context) (*domain.RunResult, error) {
+			return s.runner.RunGames(ctx, j.names)
+		})
+	case jobDestinations:
+		result = s.runCycle(ctx, func(ctx context.Context) (*domain.RunResult, error) {
+			return s.runner.RunDestinations(ctx, j.names)
+		})
+	case jobRestore:
+		result = s.runCycle(ctx, func(ctx context.Context) (*domain.RunResult, error) {
+			return s.runner.Restore(ctx, j.restore)
+		})
+		j.done <- result
+	}
+	s.recordRun(j, result)
+
+	if result != nil && result.Preempted && ctx.Err() == nil {
+		// Pick up where it stopped once the manual run is done
+		s.enqueue(j)
+	}
+}
+
+// tooSoon reports whether j is an event-driven run that would start less
+// than the minimum time between runs after the last run ended, counting and
+// logging it as suppressed if so. The next scheduled run covers it.
+func (s *Scheduler) tooSoon(j *job) bool {
+	if s.minRunGap <= 0 || !j.eventDriven() {
+		return false
+	}
+
+	s.mu.Lock()
+	since := time.Since(s.lastRunEndedAt)
+	soon := !s.lastRunEndedAt.IsZero() && since < s.minRunGap
+	if soon {
+		s.suppressed++
+	}
+	suppressed := s.suppressed
+	s.mu.Unlock()
+
+	if soon {
+		s.record(j, DecisionSkipped, fmt.Sprintf("too soon, %s after the last run", since.Round(time.Second)))
+		s.logger.Info(j.what+" suppressed, too soon after the last run",
+			"job", j.String(),
+			"since_last_run", since.Round(time.Second),
+			"min_time_between_runs", s.minRunGap,
+			"suppressed_total", suppressed,
+		)
+		s.publishStatus()
+	}
+	return soon
+}
+
+// skipped returns the calendar exception skipping backups now, if any,
+// logging that what was skipped.
+func (s *Scheduler) skipped(what string) (CalendarException, bool) {
+	if s.calendar == nil {
+		return CalendarException{}, false
+	}
+	skip, ok := s.calendar.Skip(time.Now())
+	if ok {
+		s.logger.Debug(what+" skipped by calendar", "id", skip.ID, "until", skip.End, "reason", skip.Reason)
+	}
+	return skip, ok
+}
+
+// cycle runs one backup cycle.
+type cycle func(ctx context.Context) (*domain.RunResult, error)
+
+// runBackup runs a full backup cycle, returning its result.
+func (s *Scheduler) runBackup(ctx context.Context) *domain.RunResult {
+	return s.runCycle(ctx, s.runner.Run)
+}
+
+// runPreemptible runs a scheduled full backup cycle that a manual run may
+// preempt, returning its result, which tells whether it was preempted.
+func (s *Scheduler) runPreemptible(ctx context.Context) *domain.RunResult {
+	preempt := make(chan struct{})
+	s.mu.Lock()
+	s.preempt = preempt
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.preempt = nil
+		s.mu.Unlock()
+	}()
+
+	return s.runCycle(ctx, func(ctx context.Context) (*domain.RunResult, error) {
+		return s.runner.Run(domain.WithPreempt(ctx, preempt))
+	})
+}
+
+// runCycle runs a cycle with a separate context that allows graceful completion.
+// If shutdown is requested during a backup, the backup gets a grace period to finish.
+// It returns the result of the run, or nil if it didn't run, panicked or was
+// abandoned.
+func (s *Scheduler) runCycle(ctx context.Context, run cycle) *domain.RunResult {
 	// Check if shutdown was already requested before starting
 	select {
 	case <-ctx.Done():
-		return
+		return nil
 	default:
 	}
 
 	// Create a backup context that allows graceful completion
 	backupCtx, cancel := context.WithCancel(context.Background())
+	if s.watchdog && s.runTimeout > 0 {
+		backupCtx, cancel = context.WithTimeout(context.Background(), s.runTimeout)
+	}
 	done := make(chan struct{})
 
+	s.mu.Lock()
+	stopCh := s.stopCh
+	s.state = SchedulerStateRunning
+	s.runStartedAt = time.Now()
+	s.mu.Unlock()
+	s.publishStatus()
+
+	defer func() {
+		s.mu.Lock()
+		if s.state == SchedulerStateRunning {
+			s.state = s.idleState()
+		}
+		s.runStartedAt = time.Time{}
+		s.lastRunEndedAt = time.Now()
+		s.mu.Unlock()
+		s.publishStatus()
+	}()
+
 	// Monitor for shutdown and give grace period
 	go func() {
 		select {
 		case <-done:
 			// Backup completed normally
 		case <-ctx.Done():
-			// Shutdown requested - give grace period then cancel
-			s.logger.Info("shutdown requested, allowing backup to complete (2m grace period)")
-			select {
-			case <-done:
-			case <-time.After(2 * time.Minute):
-				s.logger.Warn("backup grace period expired, cancelling")
-				cancel()
-			}
+			s.drain(done, cancel)
+		case <-stopCh:
+			s.drain(done, cancel)
 		}
 	}()
 
-	if _, err := s.runner.Run(backupCtx); err != nil {
-		s.logger.Error("backup failed", "error", err)
+	// Drain a stale abandon request left over from a run that finished on its own
+	select {
+	case <-s.abandon:
+	default:
+	}
+
+	var result *domain.RunResult
+	var abandoned bool
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		result = s.safeRun(backupCtx, run)
+	}()
+
+	select {
+	case <-runDone:
+		if errors.Is(backupCtx.Err(), context.DeadlineExceeded) {
+			s.runner.handleWatchdogRecovery(watchdogRunDeadline,
+				fmt.Sprintf("The backup run exceeded its %s deadline and was cancelled.", s.runTimeout))
+		}
+	case <-s.abandon:
+		// The watchdog found the worker stalled on a run that ignores
+		// cancellation; leave it behind so queued runs can carry on.
+		s.logger.Error("abandoning stalled backup run")
+		abandoned = true
 	}
 	close(done)
 	cancel()
+
+	if abandoned {
+		// Its result, if it ever has one, is left behind with it
+		return nil
+	}
+	return result
+}
+
+// drain gives the run in progress the shutdown grace period to finish, then
+// cancels it. Progress is logged periodically so a slow shutdown doesn't look
+// like a hang.
+func (s *Scheduler) drain(done <-chan struct{}, cancel context.CancelFunc) {
+	s.mu.Lock()
+	s.state = SchedulerStateDraining
+	s.drainStartedAt = time.Now()
+	s.mu.Unlock()
+	s.publishStatus()
+
+	s.logger.Info("shutdown requested, allowing backup to complete", "grace_period", s.shutdownGrace)
+
+	deadline := time.NewTimer(s.shutdownGrace)
+	defer deadline.Stop()
+	report := time.NewTicker(max(s.shutdownGrace/drainReports, time.Second))
+	defer report.Stop()
+
+	for {
+		select {
+		case <-done:
+			s.logger.Info("backup finished, continuing shutdown")
+			return
+		case <-report.C:
+			status := s.Status()
+			s.logger.Info(fmt.Sprintf("still finishing backup (%d%% of grace period)", status.DrainPercent),
+				"remaining", time.Duration(status.DrainRemainingSeconds)*time.Second,
+			)
+		case <-deadline.C:
+			s.logger.Warn("backup grace period expired, cancelling")
+			cancel()
+			return
+		}
+	}
+}
+
+// safeRun runs a backup, recovering from any panic so a single bad run can't
+// take the service down. It returns the result of the run, or nil if it
+// panicked.
+func (s *Scheduler) safeRun(ctx context.Context, run cycle) (result *domain.RunResult) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.runner.handlePanic(v, debug.Stack())
+		}
+	}()
+
+	result, err := run(ctx)
+	if err != nil {
+		s.logger.Error("backup failed", "error", err)
+	}
+	if result != nil && s.events != nil {
+		s.events.Publish(events.TypeRun, result)
+	}
+	return result
 }
 
 // Stop signals the scheduler to stop.
@@ -168,6 +790,191 @@ func (s *Scheduler) Stop() {
 	<-stoppedCh
 }
 
+// Trigger requests a full backup run now, even while paused. A run already in
+// progress finishes first; further requests made meanwhile are coalesced
+// into a single run.
+func (s *Scheduler) Trigger() {
+	select {
+	case s.triggerC <- struct{}{}:
+	default:
+	}
+}
+
+// Restore restores saves as the runner's Restore does, queued ahead of
+// backups: a scheduled full run in progress stops at its next game boundary
+// so ludusavi doesn't back up saves while they are restored, and runs again
+// afterwards. Like manual backups, it runs even while paused. Restore waits
+// for the result; if ctx is cancelled while the restore is still queued, it
+// doesn't run.
+func (s *Scheduler) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.RunResult, error) {
+	s.mu.Lock()
+	running, stopped := s.running, s.stoppedCh
+	s.mu.Unlock()
+	if !running {
+		return nil, ErrSchedulerStopped
+	}
+
+	j := &job{
+		kind:    jobRestore,
+		what:    "restore",
+		manual:  true,
+		names:   opts.Games,
+		restore: opts,
+		caller:  ctx,
+		done:    make(chan *domain.RunResult, 1),
+	}
+	if opts.Preview {
+		j.what = "restore preview"
+	}
+	s.logger.Info(j.what+" requested", logging.KeyGames, opts.Games)
+	s.enqueue(j)
+
+	select {
+	case result := <-j.done:
+		if result == nil {
+			return nil, errors.New("restore did not complete, see the service log")
+		}
+		return result, nil
+	case <-stopped:
+		return nil, ErrSchedulerStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runSchedule is when full runs are scheduled: at the times of cron if set,
+// or every interval.
+type runSchedule struct {
+	interval time.Duration
+	cron     *cron.Schedule
+}
+
+// Reschedule schedules full runs every interval, or at the times of c if
+// set, from now on, as when the config is reloaded. The next run is set
+// anew; a run in progress isn't affected.
+func (s *Scheduler) Reschedule(interval time.Duration, c *cron.Schedule) {
+	s.mu.Lock()
+	s.reschedule = &runSchedule{interval: interval, cron: c}
+	s.mu.Unlock()
+
+	select {
+	case s.rescheduleC <- struct{}{}:
+	default:
+	}
+}
+
+// applySchedule takes up the schedule requested by Reschedule, if any. It
+// runs on the scheduler loop, which reads the schedule without locking.
+func (s *Scheduler) applySchedule() {
+	s.mu.Lock()
+	next := s.reschedule
+	s.reschedule = nil
+	if next != nil {
+		s.interval, s.cron = next.interval, next.cron
+		s.nextRunAt = time.Time{}
+		s.stallAfter = s.stallThreshold()
+	}
+	s.mu.Unlock()
+
+	if next != nil {
+		var schedule string
+		if next.cron != nil {
+			schedule = next.cron.String()
+		}
+		s.logger.Info("schedule changed", "interval", next.interval, "schedule", schedule)
+	}
+}
+
+// Pause stops scheduled runs until Resume is called. A run in progress is
+// not interrupted, and manually triggered runs still happen.
+func (s *Scheduler) Pause() {
+	s.setPaused(true)
+}
+
+// Resume restarts scheduled runs after Pause.
+func (s *Scheduler) Resume() {
+	s.setPaused(false)
+}
+
+// IsPaused returns true if scheduled runs are paused.
+func (s *Scheduler) IsPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// setPaused pauses or resumes scheduled runs.
+func (s *Scheduler) setPaused(paused bool) {
+	s.mu.Lock()
+	if s.paused == paused {
+		s.mu.Unlock()
+		return
+	}
+	s.paused = paused
+	if s.state == SchedulerStateIdle || s.state == SchedulerStatePaused {
+		s.state = s.idleState()
+	}
+	s.mu.Unlock()
+
+	if paused {
+		s.logger.Info("scheduled backups paused")
+	} else {
+		s.logger.Info("scheduled backups resumed")
+	}
+	s.publishStatus()
+	if s.runner != nil {
+		s.runner.setPaused(paused)
+	}
+}
+
+// idleState returns the state between runs. The caller must hold mu.
+func (s *Scheduler) idleState() SchedulerState {
+	if s.paused {
+		return SchedulerStatePaused
+	}
+	return SchedulerStateIdle
+}
+
+// setNextRun records when the next scheduled full run is due, and returns
+// the time until then. With a cron schedule, that is its first time after
+// both now and the run just due, which the ticker may fire for a little
+// early.
+func (s *Scheduler) setNextRun() time.Duration {
+	now := s.wallClock()
+	s.mu.Lock()
+	next := now.Add(s.interval)
+	if s.cron != nil {
+		next = s.cron.Next(later(now, s.nextRunAt))
+		if next.IsZero() {
+			// Never due, which validation rules out
+			next = now.Add(s.interval)
+		}
+	}
+	s.nextRunAt = next
+	s.mu.Unlock()
+	s.publishStatus()
+
+	if s.cron != nil {
+		s.logger.Info("next scheduled backup", "at", next, "schedule", s.cron.String())
+	}
+	return max(next.Sub(now), time.Millisecond)
+}
+
+// later returns the later of a and b.
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// publishStatus publishes the current status, if events are enabled.
+func (s *Scheduler) publishStatus() {
+	if s.events != nil {
+		s.events.Publish(events.TypeStatus, s.Status())
+	}
+}
+
 // IsRunning returns true if the scheduler is currently running.
 func (s *Scheduler) IsRunning() bool {
 	s.mu.Lock()
@@ -175,17 +982,32 @@ func (s *Scheduler) IsRunning() bool {
 	return s.running
 }
 
-// runFinalBackup pushes a final metrics update before stopping.
+// runFinalBackup runs the shutdown backup, if configured, and pushes a final
+// metrics update before stopping.
 func (s *Scheduler) runFinalBackup() {
+	if s.shutdownBackup != nil {
+		if _, skip := s.skipped("shutdown backup"); !skip {
+			ctx, cancel := context.WithTimeout(context.Background(), s.shutdownBackupTimeout)
+			if _, err := s.runner.ShutdownBackup(ctx, *s.shutdownBackup); err != nil {
+				s.logger.Error("shutdown backup failed", "error", err)
+			}
+			cancel()
+		}
+	}
+
 	s.logger.Debug("pushing final metrics before shutdown")
 
 	// Create a context with timeout for the final push
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	timeout := s.runner.config.Metrics.PushTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultMetricsPushTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Push a final "service down" metric
 	if s.runner.metricsPusher != nil {
-		metrics := domain.NewMetrics(s.runner.hostname)
+		metrics := s.runner.newMetrics()
 		metrics.ServiceUp = false
 		if err := s.runner.metricsPusher.Push(ctx, metrics); err != nil {
 			s.logger.Warn("failed to push final metrics", "error", err)
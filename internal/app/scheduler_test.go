@@ -0,0 +1,858 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RecoversFromPanic(t *testing.T) {
+	cfg := testConfig()
+	dumpDir := filepath.Join(t.TempDir(), "crashes")
+
+	mockExec := &executor.MockExecutor{
+		BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			panic("unexpected nil stats")
+		},
+	}
+	mockPusher := &metrics.MockPusher{}
+	mockNotifier := &notify.MockNotifier{}
+
+	runner := NewRunner(cfg,
+		WithExecutor(mockExec),
+		WithMetricsPusher(mockPusher),
+		WithNotifier(mockNotifier),
+		WithCrashDumpDir(dumpDir),
+	)
+	scheduler := NewScheduler(runner)
+
+	require.NotPanics(t, func() { scheduler.safeRun(context.Background(), runner.Run) })
+	assert.Equal(t, int64(1), runner.Panics())
+
+	// Panic counter is pushed
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.Equal(t, int64(1), mockPusher.PushedMetrics[0].Panics)
+	assert.True(t, mockPusher.PushedMetrics[0].ServiceUp)
+
+	// Error notification is sent
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.Equal(t, domain.NotificationLevelError, mockNotifier.Notifications[0].Level)
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Panic: unexpected nil stats")
+	assert.Contains(t, mockNotifier.Notifications[0].Body, "Crash dump: "+dumpDir)
+
+	// Crash dump is written with the stack trace
+	entries, err := os.ReadDir(dumpDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	dump, err := os.ReadFile(filepath.Join(dumpDir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), "panic: unexpected nil stats")
+	assert.Contains(t, string(dump), "scheduler_test.go")
+	assert.Contains(t, string(dump), "All goroutines:")
+
+	// Later runs keep working and keep counting
+	mockExec.BackupFunc = nil
+	mockPusher.Reset()
+	scheduler.safeRun(context.Background(), runner.Run)
+	require.NotEmpty(t, mockPusher.PushedMetrics)
+	assert.Equal(t, int64(1), mockPusher.PushedMetrics[0].Panics)
+}
+
+func TestScheduler_RecoversFromPanic_NoCrashDump(t *testing.T) {
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			CloudUploadFunc: func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+				panic("boom")
+			},
+		}),
+		WithNotifier(mockNotifier),
+	)
+
+	require.NotPanics(t, func() { NewScheduler(runner).safeRun(context.Background(), runner.Run) })
+
+	require.Len(t, mockNotifier.Notifications, 1)
+	assert.NotContains(t, mockNotifier.Notifications[0].Body, "Crash dump")
+}
+
+func TestScheduler_DrainsRunOnShutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				close(started)
+				<-release
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner, WithShutdownGrace(time.Minute))
+	assert.Equal(t, SchedulerStateStopped, scheduler.Status().State)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = scheduler.Start(ctx)
+	}()
+
+	<-started
+	status := scheduler.Status()
+	assert.Equal(t, SchedulerStateRunning, status.State)
+	assert.NotNil(t, status.RunStartedAt)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return scheduler.Status().State == SchedulerStateDraining
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status = scheduler.Status()
+	assert.Equal(t, "stopping: draining (0% of grace period)", status.Message)
+	assert.InDelta(t, 60, status.DrainRemainingSeconds, 1)
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop after the run finished")
+	}
+	assert.Equal(t, SchedulerStateStopped, scheduler.Status().State)
+}
+
+func TestScheduler_DrainCancelsRunAfterGracePeriod(t *testing.T) {
+	started := make(chan struct{})
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner, WithShutdownGrace(50*time.Millisecond))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = scheduler.Start(context.Background())
+	}()
+
+	<-started
+	scheduler.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop after the grace period")
+	}
+	assert.Equal(t, SchedulerStateStopped, scheduler.Status().State)
+}
+
+func TestScheduler_ShutdownBackup(t *testing.T) {
+	var got []domain.BackupOptions
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				got = append(got, opts)
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithBackupOnStartup(false),
+		WithShutdownBackup(domain.BackupOptions{Force: true, ChangedOnly: true}, time.Minute),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, scheduler.Start(ctx), context.Canceled)
+
+	assert.Equal(t, []domain.BackupOptions{{Force: true, ChangedOnly: true}}, got)
+}
+
+func TestScheduler_ShutdownBackup_Timeout(t *testing.T) {
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithBackupOnStartup(false),
+		WithShutdownBackup(domain.BackupOptions{Preview: true}, 50*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_ = scheduler.Start(ctx)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestScheduler_FastInterval(t *testing.T) {
+	fastRuns := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				fastRuns <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithFastInterval(20*time.Millisecond),
+		WithBackupOnStartup(false),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	select {
+	case opts := <-fastRuns:
+		assert.True(t, opts.ChangedOnly)
+	case <-time.After(5 * time.Second):
+		t.Fatal("fast cycle did not run")
+	}
+}
+
+func TestScheduler_PauseMetrics(t *testing.T) {
+	mockPusher := &metrics.MockPusher{}
+	scheduler := NewScheduler(NewRunner(testConfig(), WithMetricsPusher(mockPusher)))
+
+	// Pausing and resuming push metrics right away, once per change
+	scheduler.Pause()
+	scheduler.Pause()
+	require.Len(t, mockPusher.PushedMetrics, 1)
+	assert.True(t, mockPusher.PushedMetrics[0].Paused)
+
+	scheduler.Resume()
+	require.Len(t, mockPusher.PushedMetrics, 2)
+	assert.False(t, mockPusher.PushedMetrics[1].Paused)
+}
+
+func TestScheduler_TriggerAndPause(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	broker := events.NewBroker()
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithFastInterval(20*time.Millisecond),
+		WithBackupOnStartup(false),
+		WithEvents(broker),
+	)
+	scheduler.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return scheduler.Status().State == SchedulerStatePaused
+	}, 5*time.Second, 10*time.Millisecond)
+	status := scheduler.Status()
+	assert.True(t, status.Paused)
+	require.NotNil(t, status.NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *status.NextRunAt, time.Minute)
+
+	// Fast cycles don't run while paused
+	select {
+	case <-runs:
+		t.Fatal("scheduled run while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A manual trigger runs a full backup even while paused
+	scheduler.Trigger()
+	select {
+	case opts := <-runs:
+		assert.False(t, opts.ChangedOnly)
+	case <-time.After(5 * time.Second):
+		t.Fatal("triggered run did not happen")
+	}
+
+	scheduler.Resume()
+	select {
+	case opts := <-runs:
+		assert.True(t, opts.ChangedOnly)
+	case <-time.After(5 * time.Second):
+		t.Fatal("fast cycle did not run after resume")
+	}
+
+	// Status changes and the run result were published
+	stream, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+	seen := map[events.Type]int{}
+	for len(stream) > 0 {
+		seen[(<-stream).Type]++
+	}
+	assert.Equal(t, 1, seen[events.TypeStatus])
+	assert.GreaterOrEqual(t, seen[events.TypeRun], 1)
+}
+
+func TestScheduler_TriggerGame(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(false),
+		WithGameDebounce(50*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	// Events within the debounce period lead to a single backup
+	scheduler.TriggerGame("Hades")
+	scheduler.TriggerGame("Celeste")
+	scheduler.TriggerGame("Hades")
+	select {
+	case opts := <-runs:
+		assert.Equal(t, []string{"Celeste", "Hades"}, opts.Games)
+		assert.False(t, opts.ChangedOnly)
+	case <-time.After(5 * time.Second):
+		t.Fatal("game backup did not run")
+	}
+	select {
+	case opts := <-runs:
+		t.Fatalf("unexpected second run: %+v", opts)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// Game backups are skipped while paused
+	scheduler.Pause()
+	scheduler.TriggerGame("Balatro")
+	select {
+	case opts := <-runs:
+		t.Fatalf("game backup while paused: %+v", opts)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// unless triggered manually, which also covers pending games
+	scheduler.TriggerGame("Balatro")
+	scheduler.TriggerGames("Celeste")
+	select {
+	case opts := <-runs:
+		assert.Equal(t, []string{"Balatro", "Celeste"}, opts.Games)
+	case <-time.After(5 * time.Second):
+		t.Fatal("manual game backup did not run")
+	}
+}
+
+func TestScheduler_QueuesTriggersDuringRun(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				started <- struct{}{}
+				<-release
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(false),
+		WithGameDebounce(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	scheduler.TriggerGames("Hades")
+	<-started
+
+	// Triggers during the run wait for it, coalesced
+	scheduler.TriggerGame("Celeste")
+	scheduler.TriggerGame("Balatro")
+	scheduler.TriggerGames("Hades")
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"game backup (Balatro, Celeste, Hades)"}, scheduler.Status().Queued)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(release)
+	assert.Equal(t, []string{"Hades"}, (<-runs).Games)
+	select {
+	case opts := <-runs:
+		assert.Equal(t, []string{"Balatro", "Celeste", "Hades"}, opts.Games)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued game backup did not run")
+	}
+	select {
+	case opts := <-runs:
+		t.Fatalf("unexpected third run: %+v", opts)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Empty(t, scheduler.Status().Queued)
+}
+
+func TestScheduler_PreemptsScheduledRun(t *testing.T) {
+	started := make(chan struct{}, 10)
+	runs := make(chan domain.BackupOptions, 10)
+	var fullRuns atomic.Int32
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				if len(opts.Games) == 0 && fullRuns.Add(1) == 1 {
+					// The first full run goes on until preempted
+					started <- struct{}{}
+					for !domain.Preempted(ctx) {
+						time.Sleep(time.Millisecond)
+					}
+					result.Preempted = true
+				}
+				runs <- opts
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(true),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	<-started
+	scheduler.TriggerGames("Hades")
+
+	next := func() domain.BackupOptions {
+		t.Helper()
+		select {
+		case opts := <-runs:
+			return opts
+		case <-time.After(5 * time.Second):
+			t.Fatal("backup did not run")
+			return domain.BackupOptions{}
+		}
+	}
+
+	// The scheduled run stops for the manual one, then runs again
+	assert.Empty(t, next().Games)
+	assert.Equal(t, []string{"Hades"}, next().Games)
+	assert.Empty(t, next().Games)
+	assert.Equal(t, int32(2), fullRuns.Load())
+}
+
+func TestScheduler_RestorePreemptsScheduledRun(t *testing.T) {
+	started := make(chan struct{}, 10)
+	var order []string
+	var mu sync.Mutex
+	ran := func(what string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, what)
+	}
+	var fullRuns atomic.Int32
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				result := domain.NewBackupResult(domain.OperationBackup)
+				if fullRuns.Add(1) == 1 {
+					// The first full run goes on until preempted
+					started <- struct{}{}
+					for !domain.Preempted(ctx) {
+						time.Sleep(time.Millisecond)
+					}
+					result.Preempted = true
+					ran("preempted backup")
+				} else {
+					ran("backup")
+				}
+				result.Complete(true, nil)
+				return result, nil
+			},
+			RestoreFunc: func(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+				ran("restore")
+				result := domain.NewBackupResult(domain.OperationRestore)
+				result.Games = opts.Games
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(true),
+	)
+
+	_, err := scheduler.Restore(context.Background(), domain.RestoreOptions{})
+	require.ErrorIs(t, err, ErrSchedulerStopped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	<-started
+	restoreCtx, cancelRestore := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelRestore()
+	result, err := scheduler.Restore(restoreCtx, domain.RestoreOptions{Games: []string{"Hades"}})
+	require.NoError(t, err)
+	require.NotNil(t, result.Restore)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"Hades"}, result.Restore.Games)
+
+	// The scheduled run stopped for the restore, then runs again
+	assert.Eventually(t, func() bool { return fullRuns.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"preempted backup", "restore", "backup"}, order)
+	mu.Unlock()
+}
+
+func TestScheduler_MinTimeBetweenRuns(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(true),
+		WithGameDebounce(time.Millisecond),
+		WithMinTimeBetweenRuns(time.Hour),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	next := func() domain.BackupOptions {
+		t.Helper()
+		select {
+		case opts := <-runs:
+			return opts
+		case <-time.After(5 * time.Second):
+			t.Fatal("backup did not run")
+			return domain.BackupOptions{}
+		}
+	}
+	assert.Empty(t, next().Games)
+	require.Eventually(t, func() bool { return scheduler.Status().State == SchedulerStateIdle }, 5*time.Second, 10*time.Millisecond)
+
+	// A game event right after the startup backup is suppressed
+	scheduler.TriggerGame("Hades")
+	require.Eventually(t, func() bool { return scheduler.Status().SuppressedTriggers == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// but a manual game backup isn't
+	scheduler.TriggerGames("Celeste")
+	assert.Equal(t, []string{"Celeste"}, next().Games)
+	assert.Equal(t, 1, scheduler.Status().SuppressedTriggers)
+}
+
+func TestScheduler_Calendar(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	now := time.Now()
+	calendar := NewCalendar([]CalendarException{
+		{Kind: CalendarSkip, Start: now.Add(-time.Hour), End: now.Add(500 * time.Millisecond), Reason: "LAN party"},
+		{Kind: CalendarRun, Start: now.Add(time.Second), Reason: "after the LAN party"},
+	})
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithFastInterval(20*time.Millisecond),
+		WithCalendar(calendar),
+	)
+	scheduler.calendarInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	// Neither the startup backup nor fast cycles run during a skip
+	require.Eventually(t, func() bool {
+		return scheduler.Status().State == SchedulerStateIdle
+	}, 5*time.Second, 10*time.Millisecond)
+	status := scheduler.Status()
+	require.NotNil(t, status.Skip)
+	assert.Equal(t, "LAN party", status.Skip.Reason)
+	assert.Contains(t, status.Message, "scheduled backups skipped until")
+	select {
+	case <-runs:
+		t.Fatal("backup ran during a calendar skip")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// The extra run is a full backup
+	require.Eventually(t, func() bool {
+		select {
+		case opts := <-runs:
+			return !opts.ChangedOnly
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_ClockJump(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	now := time.Now()
+	calendar := NewCalendar([]CalendarException{
+		{Kind: CalendarRun, Start: now.Add(30 * time.Minute), Reason: "slept through"},
+	})
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(false),
+		WithCalendar(calendar),
+	)
+	var offset atomic.Int64
+	scheduler.wallClock = func() time.Time {
+		return time.Now().Round(0).Add(time.Duration(offset.Load()))
+	}
+	scheduler.clockInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		return scheduler.Status().NextRunAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Setting the clock back doesn't postpone the next run past an interval
+	offset.Store(int64(-2 * time.Hour))
+	require.Eventually(t, func() bool {
+		return scheduler.untilNextRun() <= time.Hour
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Waking up after six hours runs the missed backup once, covering the
+	// calendar run missed meanwhile
+	offset.Store(int64(4 * time.Hour))
+	select {
+	case opts := <-runs:
+		assert.False(t, opts.ChangedOnly)
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed backup did not run after waking up")
+	}
+	select {
+	case <-runs:
+		t.Fatal("more than one backup ran after waking up")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.InDelta(t, time.Hour.Seconds(), scheduler.untilNextRun().Seconds(), 60)
+}
+
+func TestScheduler_CronSchedule(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	schedule, err := cron.Parse("* * * * *")
+	require.NoError(t, err)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithCronSchedule(schedule),
+		WithBackupOnStartup(false),
+	)
+	// The wall clock is 100ms before the next minute
+	now := time.Now()
+	offset := now.Truncate(time.Minute).Add(time.Minute - 100*time.Millisecond).Sub(now)
+	scheduler.wallClock = func() time.Time {
+		return time.Now().Round(0).Add(offset)
+	}
+	due := now.Add(offset).Truncate(time.Minute).Add(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		return scheduler.Status().NextRunAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, scheduler.Status().NextRunAt.Equal(due))
+
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled backup did not run")
+	}
+	require.Eventually(t, func() bool {
+		return scheduler.Status().NextRunAt.Equal(due.Add(time.Minute))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A manual run doesn't move the schedule
+	scheduler.Trigger()
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("manual backup did not run")
+	}
+	assert.True(t, scheduler.Status().NextRunAt.Equal(due.Add(time.Minute)))
+}
+
+func TestScheduler_Reschedule(t *testing.T) {
+	runs := make(chan domain.BackupOptions, 10)
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				runs <- opts
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(time.Hour),
+		WithBackupOnStartup(false),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		next := scheduler.Status().NextRunAt
+		return next != nil && time.Until(*next) > 30*time.Minute
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The next run is set from the new interval rather than the old one
+	scheduler.Reschedule(20*time.Millisecond, nil)
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("backup did not run at the new interval")
+	}
+
+	// Back to a long interval, nothing more runs
+	scheduler.Reschedule(time.Hour, nil)
+	require.Eventually(t, func() bool {
+		next := scheduler.Status().NextRunAt
+		return next != nil && time.Until(*next) > 30*time.Minute
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	for len(runs) > 0 {
+		<-runs
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, runs)
+}
+
+func TestScheduler_Ticks(t *testing.T) {
+	runner := NewRunner(testConfig(), WithExecutor(&executor.MockExecutor{}))
+	scheduler := NewScheduler(runner)
+	ctx := context.Background()
+	scheduled := &job{kind: jobFull, what: "scheduled backup"}
+
+	// A run coming due while another is queued merges into it
+	scheduler.enqueue(scheduled)
+	scheduler.enqueue(&job{kind: jobFull, what: "scheduled backup"})
+	require.Equal(t, scheduled, scheduler.queue.pop())
+
+	// Skipped while paused, and run once resumed
+	scheduler.Pause()
+	scheduler.runJob(ctx, scheduled)
+	scheduler.Resume()
+	scheduler.runJob(ctx, scheduled)
+
+	ticks := scheduler.Status().Ticks
+	require.Len(t, ticks, 4)
+	for _, tick := range ticks {
+		assert.Equal(t, "scheduled backup", tick.Job)
+		assert.False(t, tick.At.IsZero())
+	}
+	assert.Equal(t, DecisionQueued, ticks[0].Decision)
+	assert.Equal(t, DecisionMerged, ticks[1].Decision)
+	assert.Equal(t, DecisionSkipped, ticks[2].Decision)
+	assert.Equal(t, "paused", ticks[2].Reason)
+	assert.Equal(t, DecisionRan, ticks[3].Decision)
+	assert.Equal(t, domain.OutcomeSuccess, ticks[3].Outcome)
+	assert.Empty(t, ticks[3].Reason)
+
+	// Only the latest are kept
+	for i := range tickHistory {
+		scheduler.record(&job{kind: jobFast, what: fmt.Sprintf("fast backup %d", i)}, DecisionQueued, "")
+	}
+	ticks = scheduler.Status().Ticks
+	require.Len(t, ticks, tickHistory)
+	assert.Equal(t, "fast backup 0", ticks[0].Job)
+	assert.Equal(t, fmt.Sprintf("fast backup %d", tickHistory-1), ticks[tickHistory-1].Job)
+}
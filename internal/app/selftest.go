@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// SelfTestCheck is the outcome of exercising one component in the startup
+// self-test.
+type SelfTestCheck struct {
+	Component config.SelfTestComponent
+	// Critical is set if a failure of the component keeps the service from
+	// starting, as listed in selftest.critical.
+	Critical bool
+	Duration time.Duration
+	// Detail describes what the check found, such as the games a preview
+	// would back up.
+	Detail string
+	Err    error
+}
+
+// SelfTest exercises every configured integration the way a run would: it
+// previews a backup with ludusavi, pushes the metrics without the results
+// of a run and sends an info notification. Unlike a run, pushes and
+// notifications that fail aren't held in the outbox, and maintenance mode
+// doesn't apply. It returns the checks of the configured components, and an
+// error if a critical one failed.
+func (r *Runner) SelfTest(ctx context.Context) ([]SelfTestCheck, error) {
+	var checks []SelfTestCheck
+	check := func(component config.SelfTestComponent, fn func(ctx context.Context) (string, error)) {
+		start := time.Now()
+		detail, err := fn(ctx)
+		checks = append(checks, SelfTestCheck{
+			Component: component,
+			Critical:  slices.Contains(r.config.SelfTest.Critical, component),
+			Duration:  time.Since(start),
+			Detail:    detail,
+			Err:       err,
+		})
+	}
+
+	if r.executor != nil {
+		check(config.SelfTestLudusavi, r.selfTestLudusavi)
+	}
+	if r.selfTestPusher != nil {
+		check(config.SelfTestMetrics, r.selfTestMetrics)
+	}
+	if _, nop := r.selfTestNotifier.(*domain.NopNotifier); !nop {
+		check(config.SelfTestNotify, r.selfTestNotify)
+	}
+
+	var errs []error
+	for _, c := range checks {
+		if c.Err != nil && c.Critical {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Component, c.Err))
+		}
+	}
+	return checks, errors.Join(errs...)
+}
+
+// selfTestLudusavi previews a backup, which finds the games and their saves
+// without writing anything.
+func (r *Runner) selfTestLudusavi(ctx context.Context) (string, error) {
+	result, err := r.executor.Backup(ctx, domain.BackupOptions{Preview: true})
+	if err != nil {
+		return "", fmt.Errorf("backup preview failed: %w", err)
+	}
+	if !result.Success {
+		return "", fmt.Errorf("backup preview failed: %s", result.Error)
+	}
+	return fmt.Sprintf("%d games found", result.Stats.TotalGames), nil
+}
+
+// selfTestMetrics pushes the service metrics, such as runner_up, which the
+// next run pushes again anyway.
+func (r *Runner) selfTestMetrics(ctx context.Context) (string, error) {
+	if timeout := r.config.Metrics.PushTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := r.selfTestPusher.Push(ctx, r.newMetrics()); err != nil {
+		return "", fmt.Errorf("metrics push failed: %w", err)
+	}
+	return "pushed", nil
+}
+
+// selfTestNotify sends an info notification, whatever the notification
+// level, so the targets are known to be reachable.
+func (r *Runner) selfTestNotify(ctx context.Context) (string, error) {
+	notification := domain.InfoNotification(
+		"Ludusavi Runner Started",
+		fmt.Sprintf("The ludusavi runner on %s started and is sending notifications here. "+
+			"This is a test sent by its startup self-test.", r.hostname),
+	)
+	if err := r.selfTestNotifier.Notify(ctx, notification); err != nil {
+		return "", fmt.Errorf("test notification failed: %w", err)
+	}
+	return "sent", nil
+}
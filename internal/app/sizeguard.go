@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// bytesPerGB is the size of a gigabyte in the size guard limits.
+const bytesPerGB = 1e9
+
+// oversizedGame is a game whose saves exceed size_guard.max_game_gb.
+type oversizedGame struct {
+	title string
+	bytes int64
+}
+
+// checkBackupSize warns when the saves processed by the run, or a single
+// game's saves, exceed the size guard limits. Games are warned about once
+// until they shrink below the limit again.
+func (r *Runner) checkBackupSize(ctx context.Context, result *domain.RunResult) {
+	guard := r.config.SizeGuard
+	if guard.MaxRunGB <= 0 && guard.MaxGameGB <= 0 {
+		return
+	}
+
+	var processed int64
+	var games []oversizedGame
+	for _, op := range []*domain.BackupResult{result.Backup, result.Custom} {
+		if op == nil {
+			continue
+		}
+		processed += op.Stats.ProcessedBytes
+		if guard.MaxGameGB > 0 {
+			games = append(games, r.oversizedGames(op.GameBytes, int64(guard.MaxGameGB*bytesPerGB))...)
+		}
+	}
+
+	runOver := guard.MaxRunGB > 0 && processed > int64(guard.MaxRunGB*bytesPerGB)
+	if !runOver && len(games) == 0 {
+		return
+	}
+
+	msg := ""
+	if runOver {
+		r.log(ctx).Warn("backup size over limit", "bytes_processed", processed, "max_run_gb", guard.MaxRunGB)
+		msg += fmt.Sprintf("The backup on %s processed %s of saves, more than the %s limit.\n",
+			r.hostname, formatGB(processed), formatGB(int64(guard.MaxRunGB*bytesPerGB)))
+	}
+	if len(games) > 0 {
+		msg += fmt.Sprintf("Games on %s with saves over the %s limit:\n", r.hostname, formatGB(int64(guard.MaxGameGB*bytesPerGB)))
+		for _, game := range games {
+			r.log(ctx).Warn("game saves over size limit", "game", game.title, "bytes", game.bytes, "max_game_gb", guard.MaxGameGB)
+			msg += fmt.Sprintf("- %s: %s\n", game.title, formatGB(game.bytes))
+		}
+	}
+	msg += "Check the save folders for replays, logs or other files that don't belong in backups, " +
+		"and exclude them in ludusavi."
+
+	notification := domain.WarningNotification("Ludusavi Backup Size Warning", msg)
+	if err := r.notifier.Notify(ctx, notification); err != nil {
+		r.log(ctx).Error("failed to send notification", "error", err)
+	}
+}
+
+// oversizedGames returns the games in gameBytes over limit that weren't
+// warned about yet, largest first, and forgets the warnings of games back
+// under it.
+func (r *Runner) oversizedGames(gameBytes map[string]int64, limit int64) []oversizedGame {
+	r.sizeMu.Lock()
+	defer r.sizeMu.Unlock()
+
+	if r.sizeWarned == nil {
+		r.sizeWarned = make(map[string]bool)
+	}
+
+	var games []oversizedGame
+	for title, bytes := range gameBytes {
+		if bytes <= limit {
+			delete(r.sizeWarned, title)
+			continue
+		}
+		if !r.sizeWarned[title] {
+			r.sizeWarned[title] = true
+			games = append(games, oversizedGame{title: title, bytes: bytes})
+		}
+	}
+	slices.SortFunc(games, func(a, b oversizedGame) int {
+		if a.bytes != b.bytes {
+			return int(b.bytes - a.bytes)
+		}
+		return strings.Compare(a.title, b.title)
+	})
+	return games
+}
+
+// formatGB formats n bytes in gigabytes.
+func formatGB(n int64) string {
+	return fmt.Sprintf("%.1f GB", float64(n)/bytesPerGB)
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// defaultShutdownGrace is how long a run in progress may take to finish once
+// shutdown is requested.
+const defaultShutdownGrace = 2 * time.Minute
+
+// defaultCalendarInterval is how often the calendar is checked for extra
+// runs that are due.
+const defaultCalendarInterval = 30 * time.Second
+
+// defaultClockInterval is how often the wall clock is checked for jumps from
+// a sleep or clock change.
+const defaultClockInterval = 10 * time.Second
+
+// drainReports is how many progress lines are logged over the grace period.
+const drainReports = 8
+
+// SchedulerState describes what the scheduler is doing.
+type SchedulerState string
+
+const (
+	// SchedulerStateStopped indicates the scheduler loop is not running.
+	SchedulerStateStopped SchedulerState = "stopped"
+	// SchedulerStateIdle indicates the scheduler is waiting for the next run.
+	SchedulerStateIdle SchedulerState = "idle"
+	// SchedulerStatePaused indicates scheduled runs are paused.
+	SchedulerStatePaused SchedulerState = "paused"
+	// SchedulerStateRunning indicates a backup run is in progress.
+	SchedulerStateRunning SchedulerState = "running"
+	// SchedulerStateDraining indicates shutdown was requested and the run in
+	// progress is being given its grace period to finish.
+	SchedulerStateDraining SchedulerState = "draining"
+)
+
+// SchedulerStatus is a snapshot of the scheduler's state.
+type SchedulerStatus struct {
+	// State is the current scheduler state.
+	State SchedulerState `json:"state"`
+
+	// Message is a human-readable description of the state.
+	Message string `json:"message"`
+
+	// Paused is set while scheduled runs are paused, also during a run.
+	Paused bool `json:"paused,omitempty"`
+
+	// RunStartedAt is when the run in progress started, if any.
+	RunStartedAt *time.Time `json:"run_started_at,omitempty"`
+
+	// NextRunAt is when the next scheduled full run is due, if any.
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+
+	// Queued describes the runs waiting for the one in progress, in order.
+	Queued []string `json:"queued,omitempty"`
+
+	// SuppressedTriggers counts the event-driven runs suppressed since start
+	// for coming too soon after the last run.
+	SuppressedTriggers int `json:"suppressed_triggers,omitempty"`
+
+	// Skip is the calendar exception skipping scheduled backups now, if any.
+	Skip *CalendarException `json:"skip,omitempty"`
+
+	// DrainPercent is how much of the shutdown grace period has been used
+	// while draining.
+	DrainPercent int `json:"drain_percent,omitempty"`
+
+	// DrainRemainingSeconds is how much of the shutdown grace period is left
+	// while draining.
+	DrainRemainingSeconds int `json:"drain_remaining_seconds,omitempty"`
+
+	// Quarantined are the games left out of backups for failing too often.
+	Quarantined []QuarantinedGame `json:"quarantined,omitempty"`
+
+	// Ticks are the latest decisions about runs, oldest first: when each
+	// was queued, skipped or ran, and why.
+	Ticks []SchedulerTick `json:"ticks,omitempty"`
+}
+
+// Status returns the scheduler's current state.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := SchedulerStatus{
+		State:              s.state,
+		Paused:             s.paused,
+		Queued:             s.queue.list(),
+		SuppressedTriggers: s.suppressed,
+		Ticks:              slices.Clone(s.ticks),
+	}
+	if !s.runStartedAt.IsZero() {
+		started := s.runStartedAt
+		status.RunStartedAt = &started
+	}
+	if !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+
+	if s.runner != nil {
+		status.Quarantined = s.runner.Quarantined()
+	}
+
+	if s.calendar != nil {
+		if skip, ok := s.calendar.Skip(time.Now()); ok {
+			status.Skip = &skip
+		}
+	}
+
+	switch {
+	case s.state == SchedulerStateIdle && status.Skip != nil:
+		status.Message = "scheduled backups skipped until " + status.Skip.End.Format("Jan 2 15:04")
+		if status.Skip.Reason != "" {
+			status.Message += ": " + status.Skip.Reason
+		}
+	case s.state == SchedulerStateIdle:
+		status.Message = "waiting for next backup"
+	case s.state == SchedulerStatePaused:
+		status.Message = "scheduled backups paused"
+	case s.state == SchedulerStateRunning:
+		status.Message = "backup in progress"
+	case s.state == SchedulerStateDraining:
+		elapsed := time.Since(s.drainStartedAt)
+		status.DrainRemainingSeconds = int(max(s.shutdownGrace-elapsed, 0).Seconds())
+		if s.shutdownGrace > 0 {
+			status.DrainPercent = min(int(elapsed*100/s.shutdownGrace), 100)
+		}
+		status.Message = fmt.Sprintf("stopping: draining (%d%% of grace period)", status.DrainPercent)
+	default:
+		status.Message = "stopped"
+	}
+
+	return status
+}
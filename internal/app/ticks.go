@@ -0,0 +1,77 @@
+package app
+
+import (
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// tickHistory is how many of the scheduler's latest decisions are kept for
+// the status.
+const tickHistory = 32
+
+// SchedulerDecision is what the scheduler did with a run.
+type SchedulerDecision string
+
+const (
+	// DecisionQueued is a run that came due, or was triggered, and was
+	// queued.
+	DecisionQueued SchedulerDecision = "queued"
+	// DecisionMerged is a run merged into one already queued, behind the
+	// run in progress.
+	DecisionMerged SchedulerDecision = "merged"
+	// DecisionSkipped is a queued run that didn't run, for Reason.
+	DecisionSkipped SchedulerDecision = "skipped"
+	// DecisionRan is a run that ran.
+	DecisionRan SchedulerDecision = "ran"
+)
+
+// SchedulerTick is a decision of the scheduler about a run, kept so the
+// status can tell why a backup did or didn't run at some time.
+type SchedulerTick struct {
+	At time.Time `json:"at"`
+	// Job describes the run, e.g. "scheduled backup".
+	Job      string            `json:"job"`
+	Decision SchedulerDecision `json:"decision"`
+	// Reason says why a run was skipped, or what it waited for.
+	Reason string `json:"reason,omitempty"`
+	// Outcome and DurationSeconds are those of a run that ran; a run that
+	// panicked or was abandoned by the watchdog has no outcome.
+	Outcome         domain.RunOutcome `json:"outcome,omitempty"`
+	DurationSeconds float64           `json:"duration_seconds,omitempty"`
+}
+
+// record keeps a decision about j.
+func (s *Scheduler) record(j *job, decision SchedulerDecision, reason string) {
+	s.recordTick(SchedulerTick{Job: j.String(), Decision: decision, Reason: reason})
+}
+
+// recordRun keeps the outcome of j, which ran with result, or nil if it
+// panicked or was abandoned.
+func (s *Scheduler) recordRun(j *job, result *domain.RunResult) {
+	tick := SchedulerTick{Job: j.String(), Decision: DecisionRan}
+	switch {
+	case result == nil:
+		tick.Reason = "no result, the run panicked or was abandoned"
+	case result.Preempted:
+		tick.Reason = "preempted by a manual backup, queued again"
+	}
+	if result != nil {
+		tick.Outcome = result.Outcome()
+		tick.DurationSeconds = result.Duration.Seconds()
+	}
+	s.recordTick(tick)
+}
+
+// recordTick keeps tick, dropping the oldest beyond tickHistory.
+func (s *Scheduler) recordTick(tick SchedulerTick) {
+	tick.At = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticks := append(s.ticks, tick)
+	if len(ticks) > tickHistory {
+		ticks = ticks[len(ticks)-tickHistory:]
+	}
+	s.ticks = ticks
+}
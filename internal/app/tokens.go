@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// checkCloudTokens warns about cloud remote tokens that stop working within
+// cloud_token.warn_days, once per token. Tokens without a known deadline are
+// left to the sign-in detection of the upload itself.
+func (r *Runner) checkCloudTokens(ctx context.Context) {
+	tokens, err := r.tokenSource.Tokens(ctx)
+	if err != nil {
+		r.log(ctx).Warn("failed to check cloud tokens", "error", err)
+		return
+	}
+
+	now := time.Now()
+	warnWithin := time.Duration(r.config.CloudToken.WarnDays) * 24 * time.Hour
+	for _, token := range tokens {
+		if token.Deadline.IsZero() {
+			r.log(ctx).Debug("cloud token expiry unknown", "remote", token.Remote, "type", token.Type)
+			continue
+		}
+		if token.Deadline.Sub(now) > warnWithin || !r.markTokenWarned(token) {
+			continue
+		}
+
+		r.log(ctx).Warn("cloud token expiring", "remote", token.Remote, "deadline", token.Deadline)
+		// Uploads fail once the token lapses, so this is notified at the
+		// same levels as a failure
+		if notifyLevel := r.notifyLevel(); notifyLevel != config.NotifyError && notifyLevel != config.NotifyWarning && notifyLevel != config.NotifyAlways {
+			continue
+		}
+		notification := domain.WarningNotification(
+			"Ludusavi Cloud Token Expiring",
+			r.buildTokenMessage(token, now),
+		)
+		if err := r.notifier.Notify(ctx, notification); err != nil {
+			r.log(ctx).Error("failed to send notification", "error", err)
+		}
+	}
+}
+
+// markTokenWarned records a warning about token and reports whether it is
+// the first for its deadline. Signing in again moves the deadline, so a
+// renewed token is warned about again before it expires.
+func (r *Runner) markTokenWarned(token domain.CloudToken) bool {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+
+	if r.tokenWarned == nil {
+		r.tokenWarned = make(map[string]time.Time)
+	}
+	if r.tokenWarned[token.Remote].Equal(token.Deadline) {
+		return false
+	}
+	r.tokenWarned[token.Remote] = token.Deadline
+	return true
+}
+
+// buildTokenMessage builds a notification message for an expiring token.
+func (r *Runner) buildTokenMessage(token domain.CloudToken, now time.Time) string {
+	var when string
+	if left := token.Deadline.Sub(now); left > 0 {
+		when = fmt.Sprintf("expires in %d days, on %s", int(math.Ceil(left.Hours()/24)), token.Deadline.Format(time.RFC1123))
+	} else {
+		when = fmt.Sprintf("expired on %s", token.Deadline.Format(time.RFC1123))
+	}
+
+	return fmt.Sprintf("The sign-in of cloud remote %s (%s) on %s %s.\n", token.Remote, token.Type, r.hostname, when) +
+		"Sign in again in the ludusavi GUI (Other > Cloud > Remote) or with " +
+		fmt.Sprintf("`rclone config reconnect %s:`, or cloud uploads will start failing.", token.Remote)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Watchdog recovery reasons, used as the reason label of
+// ludusavi_runner_watchdog_recoveries_total.
+const (
+	watchdogRunDeadline = "run_deadline"
+	watchdogLoopStall   = "loop_stall"
+)
+
+// defaultStallGrace is how long a run may overrun its deadline before it is
+// abandoned, giving a cancelled run time to clean up.
+const defaultStallGrace = time.Minute
+
+// beat records that the scheduler loop is making progress: it beats on every
+// event it handles, and its tickers fire at least every interval, however
+// long a run takes. The heartbeat is kept on the monotonic clock, so a sleep
+// or clock change isn't mistaken for a stall.
+func (s *Scheduler) beat() {
+	s.heartbeat.Store(int64(time.Since(s.epoch)))
+}
+
+// sinceBeat returns how long ago the scheduler last made progress.
+func (s *Scheduler) sinceBeat() time.Duration {
+	return time.Since(s.epoch) - time.Duration(s.heartbeat.Load())
+}
+
+// stallThreshold is how long the scheduler loop may go without a beat before
+// it is considered stalled: two intervals, or two of the longest gaps
+// between the times of a cron schedule, as its ticker only fires at those.
+func (s *Scheduler) stallThreshold() time.Duration {
+	if s.cron != nil {
+		if gap := s.cron.LongestGap(time.Now()); gap > 0 {
+			return 2 * gap
+		}
+	}
+	return 2 * s.interval
+}
+
+// watch checks the scheduler every poll until ctx is cancelled. A run that
+// ignores the cancellation at its deadline is abandoned once it has overrun
+// it by the grace period, so queued runs can carry on; without a deadline, a
+// run may take as long as it needs. A loop that has gone its stall threshold
+// without a beat is reported.
+func (s *Scheduler) watch(ctx context.Context, poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	var abandoned time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			started := s.runStartedAt
+			threshold := s.stallAfter
+			s.mu.Unlock()
+
+			if s.runTimeout > 0 && !started.IsZero() && !started.Equal(abandoned) {
+				if overrun := time.Since(started) - s.runTimeout; overrun > s.stallGrace {
+					// Report each abandoned run only once
+					abandoned = started
+					select {
+					case s.abandon <- struct{}{}:
+					default:
+					}
+					s.runner.handleWatchdogRecovery(watchdogRunDeadline,
+						fmt.Sprintf("The backup run ignored its %s deadline for %s and was abandoned.",
+							s.runTimeout, overrun.Round(time.Second)))
+				}
+			}
+
+			if since := s.sinceBeat(); since > threshold {
+				// Reset the heartbeat so a single stall is only reported once
+				s.beat()
+				s.runner.handleWatchdogRecovery(watchdogLoopStall,
+					fmt.Sprintf("The scheduler loop made no progress for %s.", since.Round(time.Second)))
+			}
+		}
+	}
+}
+
+// WatchdogRecoveries returns the number of watchdog recoveries by reason.
+func (r *Runner) WatchdogRecoveries() map[string]int64 {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	return maps.Clone(r.watchdogRecoveries)
+}
+
+// handleWatchdogRecovery reports a watchdog recovery: it logs it, pushes the
+// recovery counter, and sends an error notification.
+func (r *Runner) handleWatchdogRecovery(reason, detail string) {
+	r.statsMu.Lock()
+	if r.watchdogRecoveries == nil {
+		r.watchdogRecoveries = make(map[string]int64)
+	}
+	r.watchdogRecoveries[reason]++
+	r.statsMu.Unlock()
+
+	r.logger.Error("watchdog recovered the scheduler", "reason", reason, "detail", detail)
+
+	ctx, cancel := context.WithTimeout(context.Background(), panicReportTimeout)
+	defer cancel()
+
+	if r.metricsPusher != nil {
+		if err := r.metricsPusher.Push(ctx, r.newMetrics()); err != nil {
+			r.logger.Warn("failed to push watchdog metrics", "error", err)
+		}
+	}
+
+	if r.notifier != nil {
+		notification := domain.ErrorNotification("Ludusavi Runner Watchdog",
+			fmt.Sprintf("%s\nHost: %s", detail, r.hostname))
+		if err := r.notifier.Notify(ctx, notification); err != nil {
+			r.logger.Warn("failed to send watchdog notification", "error", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Watchdog_RunDeadline(t *testing.T) {
+	mockNotifier := &notify.MockNotifier{}
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}),
+		WithNotifier(mockNotifier),
+	)
+	scheduler := NewScheduler(runner, WithWatchdog(50*time.Millisecond))
+
+	scheduler.runBackup(context.Background())
+
+	assert.Equal(t, map[string]int64{watchdogRunDeadline: 1}, runner.WatchdogRecoveries())
+
+	var watchdog *domain.Notification
+	for _, n := range mockNotifier.Notifications {
+		if n.Title == "Ludusavi Runner Watchdog" {
+			watchdog = n
+		}
+	}
+	require.NotNil(t, watchdog)
+	assert.Equal(t, domain.NotificationLevelError, watchdog.Level)
+	assert.Contains(t, watchdog.Body, "exceeded its 50ms deadline")
+}
+
+func TestScheduler_Watchdog_AbandonsOverdueRun(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var backups atomic.Int32
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				// The first run hangs and ignores cancellation
+				if backups.Add(1) == 1 {
+					<-release
+				}
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(40*time.Millisecond),
+		WithBackupOnStartup(true),
+		WithWatchdog(50*time.Millisecond),
+	)
+	scheduler.stallGrace = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = scheduler.Start(ctx)
+	}()
+
+	// The stuck run is abandoned and the worker carries on with new runs
+	require.Eventually(t, func() bool {
+		return runner.WatchdogRecoveries()[watchdogRunDeadline] >= 1 && backups.Load() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func TestScheduler_Watchdog_LongRunWithoutDeadline(t *testing.T) {
+	var backups atomic.Int32
+	runner := NewRunner(testConfig(),
+		WithExecutor(&executor.MockExecutor{
+			BackupFunc: func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+				// The first run takes many intervals, but is healthy
+				if backups.Add(1) == 1 {
+					time.Sleep(1500 * time.Millisecond)
+				}
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}),
+	)
+	scheduler := NewScheduler(runner,
+		WithInterval(40*time.Millisecond),
+		WithBackupOnStartup(true),
+		WithWatchdog(0),
+	)
+	scheduler.stallGrace = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = scheduler.Start(ctx)
+	}()
+
+	// The loop keeps ticking during the run, so nothing is recovered
+	require.Eventually(t, func() bool {
+		return backups.Load() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, runner.WatchdogRecoveries())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func TestScheduler_Watchdog_LoopStall(t *testing.T) {
+	runner := NewRunner(testConfig(), WithExecutor(&executor.MockExecutor{}))
+	scheduler := NewScheduler(runner, WithInterval(10*time.Millisecond), WithWatchdog(0))
+	scheduler.stallAfter = scheduler.stallThreshold()
+
+	// Without a loop to beat, the watchdog finds it stalled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.watch(ctx, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return runner.WatchdogRecoveries()[watchdogLoopStall] >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_StallThreshold(t *testing.T) {
+	s := NewScheduler(nil, WithInterval(20*time.Minute), WithWatchdog(2*time.Hour))
+	assert.Equal(t, 40*time.Minute, s.stallThreshold())
+
+	// A daily schedule only ticks once a day
+	daily, err := cron.Parse("0 3 * * *")
+	require.NoError(t, err)
+	s = NewScheduler(nil, WithInterval(20*time.Minute), WithCronSchedule(daily.In(time.UTC)), WithWatchdog(0))
+	assert.Equal(t, 48*time.Hour, s.stallThreshold())
+
+	// and is recomputed when the schedule changes
+	s.Reschedule(time.Hour, nil)
+	s.applySchedule()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Equal(t, 2*time.Hour, s.stallAfter)
+}
@@ -0,0 +1,447 @@
+// Package archive provides archive exports of the ludusavi backup directory
+// and implementations of the ArchiveDestination interface.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/bandwidth"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+)
+
+const (
+	defaultPrefix   = "ludusavi"
+	archiveExt      = ".tar.gz"
+	timestampFormat = "20060102T150405Z"
+)
+
+// Archiver packs the ludusavi backup directory into a tar.gz archive
+// and uploads it to one or more destinations.
+type Archiver struct {
+	source       string
+	prefix       string
+	tempDir      string
+	destinations []domain.ArchiveDestination
+	resume       bool
+	progress     ProgressFunc
+	limiter      *bandwidth.Limiter
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// ArchiverOption configures an Archiver.
+type ArchiverOption func(*Archiver)
+
+// WithDestinations sets the archive destinations.
+func WithDestinations(dests ...domain.ArchiveDestination) ArchiverOption {
+	return func(a *Archiver) {
+		a.destinations = append(a.destinations, dests...)
+	}
+}
+
+// WithPrefix sets the archive file name prefix.
+func WithPrefix(prefix string) ArchiverOption {
+	return func(a *Archiver) {
+		if prefix != "" {
+			a.prefix = prefix
+		}
+	}
+}
+
+// WithTempDir sets the directory used to stage archives before upload.
+func WithTempDir(dir string) ArchiverOption {
+	return func(a *Archiver) {
+		a.tempDir = dir
+	}
+}
+
+// WithResume keeps the staged archive when an upload fails, so the next run
+// finishes it, alongside its new archive, instead of starting over. The temp
+// dir must be persistent.
+func WithResume(resume bool) ArchiverOption {
+	return func(a *Archiver) {
+		a.resume = resume
+	}
+}
+
+// WithProgress sets a callback invoked as archives are uploaded.
+func WithProgress(fn ProgressFunc) ArchiverOption {
+	return func(a *Archiver) {
+		a.progress = fn
+	}
+}
+
+// WithBandwidthLimiter throttles uploads to every destination.
+// Concurrent uploads share the limit.
+func WithBandwidthLimiter(limiter *bandwidth.Limiter) ArchiverOption {
+	return func(a *Archiver) {
+		a.limiter = limiter
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) ArchiverOption {
+	return func(a *Archiver) {
+		a.logger = logger
+	}
+}
+
+// NewArchiver creates a new Archiver for the given source directory.
+func NewArchiver(source string, opts ...ArchiverOption) *Archiver {
+	a := &Archiver{
+		source: source,
+		prefix: defaultPrefix,
+		logger: slog.Default(),
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Archive creates an archive of the source directory and uploads it to all destinations.
+// Every destination is attempted; the result fails if any upload fails, and is
+// marked Offline if every failure was an unreachable destination.
+//
+// When resume is enabled and an earlier export was interrupted, that archive is
+// first uploaded to the destinations it is still missing from, once: the new
+// archive supersedes it, so a destination that stays unreachable doesn't hold
+// back later exports.
+func (a *Archiver) Archive(ctx context.Context) (*domain.BackupResult, error) {
+	log := logging.FromContext(ctx, a.logger)
+	result := domain.NewBackupResult(domain.OperationArchive)
+
+	var previous *pendingArchive
+	if a.resume {
+		var err error
+		if previous, err = a.loadPending(); err != nil {
+			log.Warn("discarding unreadable pending archive", "error", err)
+		}
+	}
+
+	pending, err := a.stage(ctx)
+	if err != nil {
+		// The interrupted export, if any, is left for the next run
+		result.Complete(false, err)
+		return result, nil
+	}
+	result.Stats = pending.Stats
+
+	if a.limiter != nil {
+		ctx = contextWithLimiter(ctx, a.limiter)
+	}
+	if previous != nil {
+		a.finish(ctx, previous)
+	}
+	a.upload(ctx, result, pending)
+	return result, nil
+}
+
+// stage writes a new archive of the source directory to the staging
+// directory, to be uploaded to every destination.
+func (a *Archiver) stage(ctx context.Context) (*pendingArchive, error) {
+	log := logging.FromContext(ctx, a.logger)
+	name := fmt.Sprintf("%s-%s%s", a.prefix, a.now().UTC().Format(timestampFormat), archiveExt)
+
+	if a.tempDir != "" {
+		if err := os.MkdirAll(a.tempDir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create staging directory: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(a.tempDir, a.prefix+"-*"+archiveExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary archive: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	stats, err := a.writeArchive(ctx, tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	stats.ProcessedBytes = info.Size()
+
+	log.Debug("archive created",
+		"name", name,
+		"source_bytes", stats.TotalBytes,
+		"archive_bytes", stats.ProcessedBytes,
+	)
+
+	pending := &pendingArchive{
+		Name:  name,
+		Path:  tmpPath,
+		Stats: *stats,
+	}
+	for _, dest := range a.destinations {
+		pending.Remaining = append(pending.Remaining, dest.Name())
+	}
+	return pending, nil
+}
+
+// finish uploads the archive of an interrupted export to the destinations it
+// is still missing from, then drops it: the new archive supersedes it, and
+// becomes the one resumed next time if it is interrupted too.
+func (a *Archiver) finish(ctx context.Context, previous *pendingArchive) {
+	log := logging.FromContext(ctx, a.logger)
+	log.Info("resuming interrupted archive export",
+		"name", previous.Name,
+		"destinations", previous.Remaining,
+	)
+	if errs, _ := a.send(ctx, previous); len(errs) > 0 {
+		log.Warn("interrupted archive export failed again, dropping it for the new archive",
+			"name", previous.Name,
+			"error", errors.Join(errs...),
+		)
+	}
+	a.discard(ctx, previous)
+}
+
+// upload sends the staged archive to every destination it is still missing from
+// and completes the result. The staged archive is removed once every destination
+// has it; otherwise it is kept for the next run if resume is enabled.
+func (a *Archiver) upload(ctx context.Context, result *domain.BackupResult, pending *pendingArchive) {
+	log := logging.FromContext(ctx, a.logger)
+	if a.resume {
+		if err := a.savePending(pending); err != nil {
+			log.Warn("failed to record pending archive, it will not be resumed", "error", err)
+		}
+	}
+
+	errs, offline := a.send(ctx, pending)
+	if len(errs) > 0 {
+		if !a.resume {
+			a.discard(ctx, pending)
+		}
+		// Only report the export as offline if nothing else went wrong
+		result.Offline = offline
+		result.Complete(false, errors.Join(errs...))
+		return
+	}
+
+	a.removeStaged(pending)
+	result.Complete(true, nil)
+}
+
+// send uploads the staged archive to every destination it is still missing
+// from, recording each upload if resume is enabled. It returns the failed
+// uploads, and whether they all failed for an unreachable destination.
+func (a *Archiver) send(ctx context.Context, pending *pendingArchive) ([]error, bool) {
+	log := logging.FromContext(ctx, a.logger)
+	var errs []error
+	offline := true
+	for _, dest := range a.destinations {
+		if !pending.remaining(dest.Name()) {
+			continue
+		}
+
+		log.Debug("uploading archive", "destination", dest.Name(), "name", pending.Name)
+		if err := a.uploadTo(ctx, dest, pending); err != nil {
+			if errors.Is(err, domain.ErrDestinationOffline) {
+				log.Warn("archive destination offline", "destination", dest.Name(), "error", err)
+			} else {
+				log.Warn("archive upload failed", "destination", dest.Name(), "error", err)
+				offline = false
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", dest.Name(), err))
+			continue
+		}
+		log.Info("archive uploaded", "destination", dest.Name(), "name", pending.Name)
+
+		pending.done(dest.Name())
+		if a.resume {
+			if err := a.savePending(pending); err != nil {
+				log.Warn("failed to record archive upload progress", "error", err)
+			}
+		}
+	}
+	return errs, offline
+}
+
+// uploadTo uploads the staged archive to a single destination.
+func (a *Archiver) uploadTo(ctx context.Context, dest domain.ArchiveDestination, pending *pendingArchive) error {
+	ctx, span := tracing.Start(ctx, "archive upload", tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("destination", dest.Name())
+	span.SetAttribute("archive.name", pending.Name)
+	span.SetAttribute("archive.bytes", pending.Stats.ProcessedBytes)
+
+	err := dest.Upload(contextWithProgress(ctx, a.progressFor(dest.Name())), pending.Path, pending.Name)
+	span.RecordError(err)
+	return err
+}
+
+// discard releases resumable state that failed uploads left on destinations.
+func (a *Archiver) discard(ctx context.Context, pending *pendingArchive) {
+	for _, dest := range a.destinations {
+		d, ok := dest.(Discarder)
+		if !ok || !pending.remaining(dest.Name()) {
+			continue
+		}
+		if err := d.Discard(ctx, pending.Path, pending.Name); err != nil {
+			logging.FromContext(ctx, a.logger).Debug("failed to discard partial upload", "destination", dest.Name(), "error", err)
+		}
+	}
+	a.removeStaged(pending)
+}
+
+// progressFor returns a progress callback for uploads to the named destination.
+// Progress is logged every 10 percent and forwarded to the configured ProgressFunc.
+func (a *Archiver) progressFor(destination string) func(uploaded, total int64) {
+	var lastDecile atomic.Int64
+	return func(uploaded, total int64) {
+		if a.progress != nil {
+			a.progress(destination, uploaded, total)
+		}
+		if total <= 0 {
+			return
+		}
+		decile := uploaded * 10 / total
+		if last := lastDecile.Load(); decile > last && lastDecile.CompareAndSwap(last, decile) {
+			a.logger.Info("archive upload progress",
+				"destination", destination,
+				"percent", decile*10,
+				"bytes_uploaded", uploaded,
+				"bytes_total", total,
+			)
+		}
+	}
+}
+
+// Validate checks that the source directory exists and all destinations are usable.
+func (a *Archiver) Validate(ctx context.Context) error {
+	info, err := os.Stat(a.source)
+	if err != nil {
+		return fmt.Errorf("archive source not found: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("archive source is not a directory: %s", a.source)
+	}
+
+	if len(a.destinations) == 0 {
+		return fmt.Errorf("no archive destinations configured")
+	}
+
+	var errs []error
+	for _, dest := range a.destinations {
+		if err := dest.Validate(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dest.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Destinations returns the configured destinations.
+func (a *Archiver) Destinations() []domain.ArchiveDestination {
+	return a.destinations
+}
+
+// writeArchive writes a gzip-compressed tarball of the source directory to w.
+// Each top-level directory is counted as one game, matching ludusavi's backup layout.
+func (a *Archiver) writeArchive(ctx context.Context, w io.Writer) (*domain.BackupStats, error) {
+	stats := &domain.BackupStats{}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(a.source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(a.source, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && filepath.Dir(rel) == "." {
+			stats.TotalGames++
+			stats.ProcessedGames++
+		}
+
+		// Skip anything that is not a regular file or directory (sockets, symlinks, ...)
+		if !d.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path) // #nosec G304 -- path comes from walking the configured source directory
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(tw, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+		stats.TotalBytes += n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Ensure Archiver implements domain.Archiver.
+var _ domain.Archiver = (*Archiver)(nil)
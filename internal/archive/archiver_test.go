@@ -0,0 +1,374 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBackupTree creates a fake ludusavi backup directory with two games.
+func writeBackupTree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"Hades/mapping.yaml":        "name: Hades",
+		"Hades/drive-C/save.sav":    "hades save data",
+		"Celeste/mapping.yaml":      "name: Celeste",
+		"Celeste/drive-C/0.celeste": "celeste",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	return dir
+}
+
+// readArchive returns the regular file names contained in a tar.gz archive.
+func readArchive(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			names = append(names, header.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestArchiver_Archive_Success(t *testing.T) {
+	source := writeBackupTree(t)
+
+	var archived []string
+	dest := &MockDestination{
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			archived = readArchive(t, localPath)
+			return nil
+		},
+	}
+
+	archiver := NewArchiver(source, WithDestinations(dest), WithPrefix("saves"))
+	archiver.now = func() time.Time {
+		return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	result, err := archiver.Archive(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, domain.OperationArchive, result.Operation)
+	assert.Equal(t, 2, result.Stats.TotalGames)
+	assert.Equal(t, int64(len("name: Hades")+len("hades save data")+len("name: Celeste")+len("celeste")), result.Stats.TotalBytes)
+	assert.Greater(t, result.Stats.ProcessedBytes, int64(0))
+	assert.Equal(t, []string{"saves-20260102T030405Z.tar.gz"}, dest.Uploaded)
+	assert.Equal(t, []string{
+		"Celeste/drive-C/0.celeste",
+		"Celeste/mapping.yaml",
+		"Hades/drive-C/save.sav",
+		"Hades/mapping.yaml",
+	}, archived)
+}
+
+func TestArchiver_Archive_RemovesTemporaryFile(t *testing.T) {
+	source := writeBackupTree(t)
+	tempDir := t.TempDir()
+
+	var stagedPath string
+	dest := &MockDestination{
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			stagedPath = localPath
+			return nil
+		},
+	}
+
+	archiver := NewArchiver(source, WithDestinations(dest), WithTempDir(tempDir))
+	_, err := archiver.Archive(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, tempDir, filepath.Dir(stagedPath))
+	_, err = os.Stat(stagedPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestArchiver_Archive_DestinationFailure(t *testing.T) {
+	source := writeBackupTree(t)
+
+	failing := &MockDestination{
+		NameValue: "failing",
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			return errors.New("connection refused")
+		},
+	}
+	working := &MockDestination{NameValue: "working"}
+
+	archiver := NewArchiver(source, WithDestinations(failing, working))
+	result, err := archiver.Archive(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "failing: connection refused")
+	// Remaining destinations are still attempted
+	assert.Len(t, working.Uploaded, 1)
+	// Without resume, partial uploads are discarded
+	assert.Len(t, failing.Discarded, 1)
+	assert.Empty(t, working.Discarded)
+}
+
+func TestArchiver_Archive_Resume(t *testing.T) {
+	source := writeBackupTree(t)
+	stagingDir := t.TempDir()
+
+	var stagedPaths []string
+	fail := true
+	flaky := &MockDestination{
+		NameValue: "flaky",
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			stagedPaths = append(stagedPaths, localPath)
+			if fail {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+	}
+	working := &MockDestination{NameValue: "working"}
+
+	archiver := NewArchiver(source,
+		WithDestinations(flaky, working),
+		WithTempDir(stagingDir),
+		WithResume(true),
+	)
+
+	// First run: one destination fails, the staged archive is kept
+	result, err := archiver.Archive(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, flaky.Discarded)
+	assert.FileExists(t, stagedPaths[0])
+	assert.FileExists(t, filepath.Join(stagingDir, defaultPrefix+pendingFileSuffix))
+
+	// Second run: the same archive is finished where it is missing, then a new
+	// one is uploaded everywhere
+	fail = false
+	result, err = archiver.Archive(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.Stats.TotalGames)
+
+	require.Len(t, flaky.Uploaded, 2)
+	assert.Equal(t, stagedPaths[0], stagedPaths[1])
+	assert.NotEqual(t, stagedPaths[1], stagedPaths[2])
+	assert.Len(t, working.Uploaded, 2)
+
+	// Everything is cleaned up once every destination has the archive
+	entries, err := os.ReadDir(stagingDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestArchiver_Archive_ResumeUnreachableDestination(t *testing.T) {
+	source := writeBackupTree(t)
+	stagingDir := t.TempDir()
+
+	var attempted []string
+	unreachable := &MockDestination{
+		NameValue: "unreachable",
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			attempted = append(attempted, remoteName)
+			return errors.New("connection refused")
+		},
+	}
+	working := &MockDestination{NameValue: "working"}
+
+	archiver := NewArchiver(source,
+		WithDestinations(unreachable, working),
+		WithTempDir(stagingDir),
+		WithResume(true),
+	)
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	archiver.now = func() time.Time { return now }
+
+	for range 3 {
+		result, err := archiver.Archive(context.Background())
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		now = now.Add(time.Hour)
+	}
+
+	// Every run exports a new archive rather than retrying the first one
+	assert.Equal(t, []string{
+		"ludusavi-20240115T100000Z.tar.gz",
+		"ludusavi-20240115T110000Z.tar.gz",
+		"ludusavi-20240115T120000Z.tar.gz",
+	}, working.Uploaded)
+
+	// The interrupted export is retried once alongside the next, then dropped
+	assert.Equal(t, []string{
+		"ludusavi-20240115T100000Z.tar.gz",
+		"ludusavi-20240115T100000Z.tar.gz",
+		"ludusavi-20240115T110000Z.tar.gz",
+		"ludusavi-20240115T110000Z.tar.gz",
+		"ludusavi-20240115T120000Z.tar.gz",
+	}, attempted)
+	assert.Equal(t, []string{
+		"ludusavi-20240115T100000Z.tar.gz",
+		"ludusavi-20240115T110000Z.tar.gz",
+	}, unreachable.Discarded)
+
+	// Only the latest archive is left staged
+	entries, err := os.ReadDir(stagingDir)
+	require.NoError(t, err)
+	var staged []string
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".gz" {
+			staged = append(staged, entry.Name())
+		}
+	}
+	assert.Len(t, staged, 1)
+}
+
+func TestArchiver_Archive_ResumeRemovesStaleArchives(t *testing.T) {
+	source := writeBackupTree(t)
+	stagingDir := t.TempDir()
+
+	// Left behind by an export that was interrupted while being written
+	stale := filepath.Join(stagingDir, defaultPrefix+"-123"+archiveExt)
+	require.NoError(t, os.WriteFile(stale, []byte("truncated"), 0600))
+
+	dest := &MockDestination{}
+	archiver := NewArchiver(source, WithDestinations(dest), WithTempDir(stagingDir), WithResume(true))
+
+	result, err := archiver.Archive(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.NoFileExists(t, stale)
+}
+
+func TestArchiver_Archive_Progress(t *testing.T) {
+	source := writeBackupTree(t)
+
+	var reported []int64
+	dest := &MockDestination{
+		NameValue: "nas",
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			info, err := os.Stat(localPath)
+			require.NoError(t, err)
+			prog := newProgress(ctx, info.Size(), 0)
+			prog.add(info.Size())
+			return nil
+		},
+	}
+
+	archiver := NewArchiver(source,
+		WithDestinations(dest),
+		WithProgress(func(destination string, uploaded, total int64) {
+			assert.Equal(t, "nas", destination)
+			assert.Equal(t, uploaded, total)
+			reported = append(reported, uploaded)
+		}),
+	)
+
+	result, err := archiver.Archive(context.Background())
+	require.NoError(t, err)
+	require.Len(t, reported, 1)
+	assert.Equal(t, result.Stats.ProcessedBytes, reported[0])
+}
+
+func TestArchiver_Archive_DestinationOffline(t *testing.T) {
+	source := writeBackupTree(t)
+
+	offline := &MockDestination{
+		NameValue: "nas",
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			return fmt.Errorf("%w: share not mounted", domain.ErrDestinationOffline)
+		},
+	}
+
+	t.Run("only offline destinations failed", func(t *testing.T) {
+		result, err := NewArchiver(source, WithDestinations(offline, &MockDestination{})).Archive(context.Background())
+
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.True(t, result.Offline)
+	})
+
+	t.Run("another destination failed", func(t *testing.T) {
+		failing := &MockDestination{
+			UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+				return errors.New("permission denied")
+			},
+		}
+		result, err := NewArchiver(source, WithDestinations(offline, failing)).Archive(context.Background())
+
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.False(t, result.Offline)
+	})
+}
+
+func TestArchiver_Archive_MissingSource(t *testing.T) {
+	dest := &MockDestination{}
+	archiver := NewArchiver(filepath.Join(t.TempDir(), "missing"), WithDestinations(dest))
+
+	result, err := archiver.Archive(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "failed to create archive")
+	assert.Empty(t, dest.Uploaded)
+}
+
+func TestArchiver_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		archiver := NewArchiver(t.TempDir(), WithDestinations(&MockDestination{}))
+		assert.NoError(t, archiver.Validate(context.Background()))
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		archiver := NewArchiver(filepath.Join(t.TempDir(), "missing"), WithDestinations(&MockDestination{}))
+		assert.ErrorContains(t, archiver.Validate(context.Background()), "archive source not found")
+	})
+
+	t.Run("no destinations", func(t *testing.T) {
+		archiver := NewArchiver(t.TempDir())
+		assert.ErrorContains(t, archiver.Validate(context.Background()), "no archive destinations configured")
+	})
+
+	t.Run("destination failure", func(t *testing.T) {
+		dest := &MockDestination{
+			NameValue: "nas",
+			ValidateFunc: func(ctx context.Context) error {
+				return errors.New("unreachable")
+			},
+		}
+		archiver := NewArchiver(t.TempDir(), WithDestinations(dest))
+		assert.ErrorContains(t, archiver.Validate(context.Background()), "nas: unreachable")
+	})
+}
@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+const (
+	defaultAvailabilityAttempts = 4
+	defaultAvailabilityDelay    = 15 * time.Second
+	defaultAvailabilityMaxDelay = 2 * time.Minute
+)
+
+// Prober is implemented by destinations that can cheaply check whether they are reachable.
+type Prober interface {
+	Probe(ctx context.Context) error
+}
+
+// AvailabilityDestination wraps a destination that may be temporarily
+// unreachable, such as a NAS share that is asleep or a network drive that is
+// not mapped yet. Before each upload it probes the destination, waiting with
+// exponential backoff for it to come online. If it never does, the upload
+// fails with domain.ErrDestinationOffline instead of a generic error.
+type AvailabilityDestination struct {
+	dest     domain.ArchiveDestination
+	probe    func(ctx context.Context) error
+	attempts int
+	delay    time.Duration
+	maxDelay time.Duration
+	logger   *slog.Logger
+}
+
+// AvailabilityOption configures an AvailabilityDestination.
+type AvailabilityOption func(*AvailabilityDestination)
+
+// WithAvailabilityRetry sets how many times the destination is probed and the
+// backoff between probes. Zero values keep the defaults.
+func WithAvailabilityRetry(attempts int, delay, maxDelay time.Duration) AvailabilityOption {
+	return func(a *AvailabilityDestination) {
+		if attempts > 0 {
+			a.attempts = attempts
+		}
+		if delay > 0 {
+			a.delay = delay
+		}
+		if maxDelay > 0 {
+			a.maxDelay = maxDelay
+		}
+	}
+}
+
+// WithAvailabilityLogger sets the logger.
+func WithAvailabilityLogger(logger *slog.Logger) AvailabilityOption {
+	return func(a *AvailabilityDestination) {
+		a.logger = logger
+	}
+}
+
+// NewAvailabilityDestination wraps dest with availability handling.
+// Destinations implementing Prober are probed with it; others with Validate.
+func NewAvailabilityDestination(dest domain.ArchiveDestination, opts ...AvailabilityOption) *AvailabilityDestination {
+	probe := dest.Validate
+	if p, ok := dest.(Prober); ok {
+		probe = p.Probe
+	}
+
+	a := &AvailabilityDestination{
+		dest:     dest,
+		probe:    probe,
+		attempts: defaultAvailabilityAttempts,
+		delay:    defaultAvailabilityDelay,
+		maxDelay: defaultAvailabilityMaxDelay,
+		logger:   slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Name returns the wrapped destination's identifier.
+func (a *AvailabilityDestination) Name() string {
+	return a.dest.Name()
+}
+
+// Upload waits for the destination to become reachable, then uploads to it.
+func (a *AvailabilityDestination) Upload(ctx context.Context, localPath, remoteName string) error {
+	if err := a.waitOnline(ctx); err != nil {
+		return err
+	}
+	return a.dest.Upload(ctx, localPath, remoteName)
+}
+
+// Validate validates the wrapped destination without waiting for it,
+// reporting domain.ErrDestinationOffline if it is unreachable.
+func (a *AvailabilityDestination) Validate(ctx context.Context) error {
+	err := a.dest.Validate(ctx)
+	if err != nil && a.probe(ctx) != nil {
+		return fmt.Errorf("%w: %v", domain.ErrDestinationOffline, err)
+	}
+	return err
+}
+
+// waitOnline probes the destination until it responds or attempts run out.
+func (a *AvailabilityDestination) waitOnline(ctx context.Context) error {
+	log := logging.FromContext(ctx, a.logger)
+	delay := a.delay
+
+	var err error
+	for attempt := 1; attempt <= a.attempts; attempt++ {
+		if err = a.probe(ctx); err == nil {
+			if attempt > 1 {
+				log.Info("archive destination back online", "destination", a.Name(), "attempt", attempt)
+			}
+			return nil
+		}
+
+		if attempt == a.attempts {
+			break
+		}
+
+		log.Warn("archive destination unavailable, waiting",
+			"destination", a.Name(),
+			"attempt", attempt,
+			"retry_in", delay,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > a.maxDelay {
+			delay = a.maxDelay
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts: %v", domain.ErrDestinationOffline, a.attempts, err)
+}
+
+// Ensure AvailabilityDestination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*AvailabilityDestination)(nil)
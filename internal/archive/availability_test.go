@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailabilityDestination_Upload_WaitsForDestination(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "share")
+	local := NewLocalDestination(dir)
+
+	probes := 0
+	inner := &probingDestination{
+		LocalDestination: local,
+		probe: func(ctx context.Context) error {
+			probes++
+			// The share comes online on the third probe
+			if probes == 3 {
+				require.NoError(t, os.Mkdir(dir, 0750))
+			}
+			return local.Probe(ctx)
+		},
+	}
+
+	dest := NewAvailabilityDestination(inner, WithAvailabilityRetry(5, time.Millisecond, time.Millisecond))
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "data"), "ludusavi-1.tar.gz")
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, probes)
+	assert.FileExists(t, filepath.Join(dir, "ludusavi-1.tar.gz"))
+}
+
+func TestAvailabilityDestination_Upload_Offline(t *testing.T) {
+	dest := NewAvailabilityDestination(
+		NewLocalDestination(filepath.Join(t.TempDir(), "missing")),
+		WithAvailabilityRetry(2, time.Millisecond, time.Millisecond),
+	)
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "data"), "ludusavi-1.tar.gz")
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, domain.ErrDestinationOffline))
+	assert.Contains(t, err.Error(), "after 2 attempts")
+}
+
+func TestAvailabilityDestination_Upload_FailureIsNotOffline(t *testing.T) {
+	inner := &MockDestination{
+		UploadFunc: func(ctx context.Context, localPath, remoteName string) error {
+			return errors.New("disk full")
+		},
+	}
+
+	dest := NewAvailabilityDestination(inner, WithAvailabilityRetry(2, time.Millisecond, time.Millisecond))
+	err := dest.Upload(context.Background(), "archive.tar.gz", "ludusavi-1.tar.gz")
+
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, domain.ErrDestinationOffline))
+}
+
+func TestAvailabilityDestination_Upload_ContextCancelled(t *testing.T) {
+	dest := NewAvailabilityDestination(
+		NewLocalDestination(filepath.Join(t.TempDir(), "missing")),
+		WithAvailabilityRetry(3, time.Hour, time.Hour),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := dest.Upload(ctx, "archive.tar.gz", "ludusavi-1.tar.gz")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAvailabilityDestination_Validate(t *testing.T) {
+	online := NewAvailabilityDestination(NewLocalDestination(t.TempDir()))
+	assert.NoError(t, online.Validate(context.Background()))
+
+	offline := NewAvailabilityDestination(NewLocalDestination(filepath.Join(t.TempDir(), "missing")))
+	assert.ErrorIs(t, offline.Validate(context.Background()), domain.ErrDestinationOffline)
+}
+
+// probingDestination overrides the probe of a LocalDestination.
+type probingDestination struct {
+	*LocalDestination
+	probe func(ctx context.Context) error
+}
+
+func (p *probingDestination) Probe(ctx context.Context) error {
+	return p.probe(ctx)
+}
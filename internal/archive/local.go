@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// LocalDestination copies archives into a directory on a local disk, mapped
+// network drive, or UNC path (\\server\share\dir).
+//
+// The directory must already exist: an absent directory usually means a share
+// or removable drive is not mounted, and creating it would silently write the
+// archive to the wrong disk.
+type LocalDestination struct {
+	path string
+}
+
+// NewLocalDestination creates a new LocalDestination for the given directory.
+func NewLocalDestination(path string) *LocalDestination {
+	return &LocalDestination{path: path}
+}
+
+// Name returns the destination identifier.
+func (l *LocalDestination) Name() string {
+	return l.path
+}
+
+// Upload copies the local file into the directory, writing to a temporary
+// name first so a partially copied archive is never left under the final name.
+func (l *LocalDestination) Upload(ctx context.Context, localPath, remoteName string) error {
+	if err := l.Probe(ctx); err != nil {
+		return err
+	}
+
+	src, err := os.Open(localPath) // #nosec G304 -- path is the archive staged by the archiver
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer src.Close()
+
+	finalPath := filepath.Join(l.path, remoteName)
+	partialPath := finalPath + partialUploadSuffix
+
+	dst, err := os.Create(partialPath) // #nosec G304 -- path is built from the configured destination directory
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+
+	info, err := src.Stat()
+	if err != nil {
+		_ = dst.Close()
+		_ = os.Remove(partialPath)
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	prog := newProgress(ctx, info.Size(), 0)
+	if _, err := io.Copy(dst, prog.reader(&contextReader{ctx: ctx, r: src})); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(partialPath)
+		return fmt.Errorf("failed to copy archive: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(partialPath)
+		return fmt.Errorf("failed to finalize destination file: %w", err)
+	}
+
+	if err := os.Rename(partialPath, finalPath); err != nil {
+		_ = os.Remove(partialPath)
+		return fmt.Errorf("failed to rename copied archive: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that the directory exists.
+func (l *LocalDestination) Validate(ctx context.Context) error {
+	return l.Probe(ctx)
+}
+
+// Probe reports whether the directory is currently reachable.
+func (l *LocalDestination) Probe(_ context.Context) error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("directory not reachable: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", l.path)
+	}
+	return nil
+}
+
+// Ensure LocalDestination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*LocalDestination)(nil)
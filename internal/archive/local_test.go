@@ -0,0 +1,38 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalDestination_Upload(t *testing.T) {
+	dir := t.TempDir()
+	dest := NewLocalDestination(dir)
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "archive contents"), "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "ludusavi-1.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "archive contents", string(data))
+
+	_, err = os.Stat(filepath.Join(dir, "ludusavi-1.tar.gz"+partialUploadSuffix))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalDestination_Upload_MissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "unmounted")
+	dest := NewLocalDestination(dir)
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "data"), "ludusavi-1.tar.gz")
+
+	assert.ErrorContains(t, err, "directory not reachable")
+	// The directory must not be created on the wrong disk
+	_, statErr := os.Stat(dir)
+	assert.True(t, os.IsNotExist(statErr))
+}
@@ -0,0 +1,87 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockDestination is a mock implementation of domain.ArchiveDestination for testing.
+type MockDestination struct {
+	NameValue    string
+	UploadFunc   func(ctx context.Context, localPath, remoteName string) error
+	ValidateFunc func(ctx context.Context) error
+
+	// Uploaded stores the remote names of all uploaded archives.
+	Uploaded []string
+
+	// Discarded stores the remote names of all discarded uploads.
+	Discarded []string
+}
+
+// Name returns the configured name, or "mock".
+func (m *MockDestination) Name() string {
+	if m.NameValue != "" {
+		return m.NameValue
+	}
+	return "mock"
+}
+
+// Upload calls the mock UploadFunc and stores the remote name.
+func (m *MockDestination) Upload(ctx context.Context, localPath, remoteName string) error {
+	if m.UploadFunc != nil {
+		if err := m.UploadFunc(ctx, localPath, remoteName); err != nil {
+			return err
+		}
+	}
+	m.Uploaded = append(m.Uploaded, remoteName)
+	return nil
+}
+
+// Validate calls the mock ValidateFunc.
+func (m *MockDestination) Validate(ctx context.Context) error {
+	if m.ValidateFunc != nil {
+		return m.ValidateFunc(ctx)
+	}
+	return nil
+}
+
+// Discard stores the remote name.
+func (m *MockDestination) Discard(_ context.Context, _, remoteName string) error {
+	m.Discarded = append(m.Discarded, remoteName)
+	return nil
+}
+
+// Ensure MockDestination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*MockDestination)(nil)
+
+// MockArchiver is a mock implementation of domain.Archiver for testing.
+type MockArchiver struct {
+	ArchiveFunc  func(ctx context.Context) (*domain.BackupResult, error)
+	ValidateFunc func(ctx context.Context) error
+
+	// Calls counts how many times Archive has been called.
+	Calls int
+}
+
+// Archive calls the mock ArchiveFunc.
+func (m *MockArchiver) Archive(ctx context.Context) (*domain.BackupResult, error) {
+	m.Calls++
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(ctx)
+	}
+	result := domain.NewBackupResult(domain.OperationArchive)
+	result.Complete(true, nil)
+	return result, nil
+}
+
+// Validate calls the mock ValidateFunc.
+func (m *MockArchiver) Validate(ctx context.Context) error {
+	if m.ValidateFunc != nil {
+		return m.ValidateFunc(ctx)
+	}
+	return nil
+}
+
+// Ensure MockArchiver implements domain.Archiver.
+var _ domain.Archiver = (*MockArchiver)(nil)
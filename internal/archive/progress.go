@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/bandwidth"
+)
+
+// ProgressFunc is called as an archive is uploaded to a destination.
+type ProgressFunc func(destination string, uploaded, total int64)
+
+type (
+	progressKey struct{}
+	limiterKey  struct{}
+)
+
+// contextWithProgress returns a context carrying a progress callback for one upload.
+func contextWithProgress(ctx context.Context, fn func(uploaded, total int64)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// contextWithLimiter returns a context carrying the bandwidth limiter for uploads.
+func contextWithLimiter(ctx context.Context, limiter *bandwidth.Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, limiter)
+}
+
+// progress tracks the bytes uploaded to a destination and reports them to the
+// callback carried by the context, if any. Readers it wraps are also throttled
+// by the context's bandwidth limiter. It is safe for concurrent use.
+type progress struct {
+	ctx      context.Context
+	fn       func(uploaded, total int64)
+	limiter  *bandwidth.Limiter
+	total    int64
+	uploaded atomic.Int64
+}
+
+// newProgress creates a progress tracker for an upload of total bytes.
+// alreadyUploaded accounts for bytes transferred by an earlier, resumed attempt.
+func newProgress(ctx context.Context, total, alreadyUploaded int64) *progress {
+	fn, _ := ctx.Value(progressKey{}).(func(uploaded, total int64))
+	limiter, _ := ctx.Value(limiterKey{}).(*bandwidth.Limiter)
+	p := &progress{ctx: ctx, fn: fn, limiter: limiter, total: total}
+	p.add(alreadyUploaded)
+	return p
+}
+
+// add records n more uploaded bytes.
+func (p *progress) add(n int64) {
+	uploaded := p.uploaded.Add(n)
+	if p.fn != nil && n > 0 {
+		p.fn(uploaded, p.total)
+	}
+}
+
+// reader wraps r so bytes read from it are throttled and reported as uploaded.
+func (p *progress) reader(r io.Reader) io.Reader {
+	return &progressReader{r: p.limiter.Reader(p.ctx, r), p: p}
+}
+
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.add(int64(n))
+	return n, err
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+const pendingFileSuffix = ".pending.json"
+
+// Discarder is implemented by destinations that keep state for resuming an
+// interrupted upload (multipart uploads, partial files), so that state can be
+// released when the archive is abandoned instead of resumed.
+type Discarder interface {
+	Discard(ctx context.Context, localPath, remoteName string) error
+}
+
+// pendingArchive records a staged archive that has not yet reached every destination.
+type pendingArchive struct {
+	Name      string             `json:"name"`
+	Path      string             `json:"path"`
+	Stats     domain.BackupStats `json:"stats"`
+	Remaining []string           `json:"remaining"`
+}
+
+// remaining reports whether the archive still has to be uploaded to the named destination.
+func (p *pendingArchive) remaining(destination string) bool {
+	return slices.Contains(p.Remaining, destination)
+}
+
+// done marks the named destination as uploaded.
+func (p *pendingArchive) done(destination string) {
+	p.Remaining = slices.DeleteFunc(p.Remaining, func(name string) bool {
+		return name == destination
+	})
+}
+
+// stagingDir returns the directory archives are staged in.
+func (a *Archiver) stagingDir() string {
+	if a.tempDir != "" {
+		return a.tempDir
+	}
+	return os.TempDir()
+}
+
+// pendingPath returns the path of the pending archive manifest.
+func (a *Archiver) pendingPath() string {
+	return filepath.Join(a.stagingDir(), a.prefix+pendingFileSuffix)
+}
+
+// loadPending returns the interrupted export to resume, or nil if there is none.
+// Staged files left behind by exports that can't be resumed are removed.
+func (a *Archiver) loadPending() (*pendingArchive, error) {
+	data, err := os.ReadFile(a.pendingPath())
+	if os.IsNotExist(err) {
+		a.removeStale("")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending archive: %w", err)
+	}
+
+	var pending pendingArchive
+	if err := json.Unmarshal(data, &pending); err != nil {
+		_ = os.Remove(a.pendingPath())
+		a.removeStale("")
+		return nil, fmt.Errorf("failed to parse pending archive: %w", err)
+	}
+
+	// Destinations removed from the config since the export was interrupted are dropped
+	configured := make([]string, 0, len(a.destinations))
+	for _, dest := range a.destinations {
+		configured = append(configured, dest.Name())
+	}
+	pending.Remaining = slices.DeleteFunc(pending.Remaining, func(name string) bool {
+		return !slices.Contains(configured, name)
+	})
+
+	if _, err := os.Stat(pending.Path); err != nil || len(pending.Remaining) == 0 {
+		a.removeStaged(&pending)
+		a.removeStale("")
+		return nil, nil
+	}
+
+	a.removeStale(pending.Path)
+	return &pending, nil
+}
+
+// savePending writes the pending archive manifest.
+func (a *Archiver) savePending(pending *pendingArchive) error {
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := a.pendingPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.pendingPath())
+}
+
+// removeStaged removes a staged archive, the resume state destinations kept
+// next to it, and the pending manifest.
+func (a *Archiver) removeStaged(pending *pendingArchive) {
+	_ = os.Remove(pending.Path)
+
+	dir, base := filepath.Split(pending.Path)
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), base+".") {
+				_ = os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	if a.resume {
+		_ = os.Remove(a.pendingPath())
+	}
+}
+
+// removeStale removes staged archives (and their resume state) other than keep,
+// left behind by exports that were interrupted before they could be recorded.
+func (a *Archiver) removeStale(keep string) {
+	entries, err := os.ReadDir(a.stagingDir())
+	if err != nil {
+		return
+	}
+
+	keepBase := filepath.Base(keep)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, a.prefix+"-") || !strings.Contains(name, archiveExt) {
+			continue
+		}
+		if keep != "" && (name == keepBase || strings.HasPrefix(name, keepBase+".")) {
+			continue
+		}
+		a.logger.Debug("removing stale staged archive", "name", name)
+		_ = os.Remove(filepath.Join(a.stagingDir(), name))
+	}
+}
@@ -0,0 +1,375 @@
+package archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+const (
+	defaultS3Region      = "us-east-1"
+	defaultS3Concurrency = 4
+	defaultS3PartSize    = 64 * 1024 * 1024
+	s3Service            = "s3"
+	s3DateFormat         = "20060102T150405Z"
+	s3DayFormat          = "20060102"
+	emptyPayloadSHA      = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Config holds connection settings for an S3-compatible destination.
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API (e.g. https://s3.eu-central-003.backblazeb2.com).
+	Endpoint string
+
+	// Region is the signing region (defaults to us-east-1, which MinIO accepts).
+	Region string
+
+	// Bucket is the target bucket.
+	Bucket string
+
+	// Prefix is prepended to object keys.
+	Prefix string
+
+	// AccessKeyID and SecretAccessKey are the credentials used for signing.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// StorageClass sets the x-amz-storage-class of uploaded objects (optional).
+	StorageClass string
+
+	// Tags are applied to uploaded objects, so bucket lifecycle rules can match them.
+	Tags map[string]string
+
+	// VirtualHostStyle addresses the bucket as a subdomain instead of a path segment.
+	VirtualHostStyle bool
+
+	// PartSize switches files larger than this many bytes to resumable multipart
+	// uploads (defaults to 64 MiB). A negative value always uses a single PUT.
+	PartSize int64
+
+	// Concurrency is the number of parts uploaded in parallel (defaults to 4).
+	Concurrency int
+}
+
+// S3Destination uploads archives to an S3-compatible object store
+// (AWS S3, MinIO, Backblaze B2, Wasabi, ...), signing requests with AWS Signature V4.
+type S3Destination struct {
+	cfg        S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// S3Option configures an S3Destination.
+type S3Option func(*S3Destination)
+
+// WithS3HTTPClient sets the HTTP client used for requests.
+func WithS3HTTPClient(client *http.Client) S3Option {
+	return func(s *S3Destination) {
+		s.httpClient = client
+	}
+}
+
+// NewS3Destination creates a new S3Destination.
+// Credentials fall back to AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY when not set.
+func NewS3Destination(cfg S3Config, opts ...S3Option) *S3Destination {
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = defaultS3PartSize
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = defaultS3Concurrency
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretAccessKey == "" {
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	s := &S3Destination{
+		cfg:        cfg,
+		httpClient: newHTTPClient(httpDialTimeout, httpResponseHeaderTimeout),
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Name returns the destination identifier.
+func (s *S3Destination) Name() string {
+	return fmt.Sprintf("s3://%s/%s", s.cfg.Bucket, s.cfg.Prefix)
+}
+
+// Upload uploads the local file as a single object, or as a resumable multipart
+// upload if it is larger than the configured part size.
+func (s *S3Destination) Upload(ctx context.Context, localPath, remoteName string) error {
+	f, err := os.Open(localPath) // #nosec G304 -- path is the archive staged by the archiver
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+	if s.cfg.PartSize > 0 && info.Size() > s.cfg.PartSize {
+		return s.uploadMultipart(ctx, f, info.Size(), localPath, remoteName)
+	}
+
+	payloadHash, size, err := hashReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to hash archive: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	body := newProgress(ctx, size, 0).reader(f)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(remoteName), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	s.setObjectHeaders(req)
+
+	resp, err := s.do(req, payloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return s3Error(resp)
+	}
+
+	return nil
+}
+
+// Validate checks that the bucket exists and the credentials can access it.
+func (s *S3Destination) Validate(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.bucketURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.do(req, emptyPayloadSHA)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("bucket %s not accessible: status %d", s.cfg.Bucket, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// setObjectHeaders sets the headers describing a new object.
+func (s *S3Destination) setObjectHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/gzip")
+	if s.cfg.StorageClass != "" {
+		req.Header.Set("X-Amz-Storage-Class", s.cfg.StorageClass)
+	}
+	if len(s.cfg.Tags) > 0 {
+		req.Header.Set("X-Amz-Tagging", encodeTags(s.cfg.Tags))
+	}
+}
+
+// do signs and sends the request.
+func (s *S3Destination) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// bucketURL returns the URL of the bucket.
+func (s *S3Destination) bucketURL() string {
+	if s.cfg.VirtualHostStyle {
+		u, err := url.Parse(s.cfg.Endpoint)
+		if err == nil {
+			u.Host = s.cfg.Bucket + "." + u.Host
+			return u.String() + "/"
+		}
+	}
+	return s.cfg.Endpoint + "/" + s.cfg.Bucket
+}
+
+// objectURL returns the URL of the object with the given name.
+func (s *S3Destination) objectURL(name string) string {
+	key := name
+	if s.cfg.Prefix != "" {
+		key = path.Join(s.cfg.Prefix, name)
+	}
+	return strings.TrimSuffix(s.bucketURL(), "/") + "/" + escapePath(key)
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (s *S3Destination) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(s3DateFormat)
+	day := now.Format(s3DayFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Canonical headers: host plus every header we set that affects the request.
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{day, s.cfg.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalURI returns the URI-encoded path of the request.
+func canonicalURI(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// canonicalQuery returns the sorted, encoded query string of the request.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath URI-encodes each segment of an object key.
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape percent-encodes everything except unreserved characters, as SigV4 requires.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// encodeTags encodes object tags as a URL query string for the x-amz-tagging header.
+func encodeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, awsEscape(k)+"="+awsEscape(tags[k]))
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Error builds an error from a non-2xx S3 response.
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("s3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// hashReader returns the hex SHA-256 and length of everything read from r.
+func hashReader(r io.Reader) (string, int64, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Ensure S3Destination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*S3Destination)(nil)
@@ -0,0 +1,321 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// s3UploadState records an in-progress multipart upload so it can be resumed
+// after the process is interrupted. It is stored next to the staged archive.
+type s3UploadState struct {
+	Key      string         `json:"key"`
+	UploadID string         `json:"upload_id"`
+	PartSize int64          `json:"part_size"`
+	Parts    map[int]string `json:"parts"`
+}
+
+type s3InitiateResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteUpload struct {
+	XMLName xml.Name         `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletePart `xml:"Part"`
+}
+
+// errNoSuchUpload indicates the multipart upload being resumed no longer exists
+// (it was aborted or expired by a lifecycle rule).
+var errNoSuchUpload = errors.New("multipart upload no longer exists")
+
+// uploadMultipart uploads the file in parts, several at a time. Completed parts
+// are recorded so an interrupted upload picks up where it left off.
+func (s *S3Destination) uploadMultipart(ctx context.Context, f *os.File, size int64, localPath, remoteName string) error {
+	statePath := s.statePath(localPath)
+	key := s.objectURL(remoteName)
+
+	state := s.loadState(statePath, key)
+	if state == nil {
+		uploadID, err := s.createMultipart(ctx, key)
+		if err != nil {
+			return err
+		}
+		state = &s3UploadState{Key: key, UploadID: uploadID, PartSize: s.cfg.PartSize, Parts: map[int]string{}}
+		if err := saveState(statePath, state); err != nil {
+			return fmt.Errorf("failed to save upload state: %w", err)
+		}
+	}
+
+	numParts := int((size + state.PartSize - 1) / state.PartSize)
+	partLength := func(n int) int64 {
+		return min(state.PartSize, size-int64(n-1)*state.PartSize)
+	}
+
+	var alreadyUploaded int64
+	var todo []int
+	for n := 1; n <= numParts; n++ {
+		if _, ok := state.Parts[n]; ok {
+			alreadyUploaded += partLength(n)
+		} else {
+			todo = append(todo, n)
+		}
+	}
+	prog := newProgress(ctx, size, alreadyUploaded)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	parts := make(chan int)
+
+	for range min(s.cfg.Concurrency, len(todo)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range parts {
+				offset := int64(n-1) * state.PartSize
+				etag, err := s.uploadPart(ctx, io.NewSectionReader(f, offset, partLength(n)), state, n, prog)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					state.Parts[n] = etag
+					if err := saveState(statePath, state); err != nil && firstErr == nil {
+						firstErr = fmt.Errorf("failed to save upload state: %w", err)
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, n := range todo {
+		select {
+		case parts <- n:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(parts)
+	wg.Wait()
+
+	if firstErr != nil {
+		if errors.Is(firstErr, errNoSuchUpload) {
+			// Start from scratch next time
+			_ = os.Remove(statePath)
+		}
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.completeMultipart(ctx, state); err != nil {
+		if errors.Is(err, errNoSuchUpload) {
+			_ = os.Remove(statePath)
+		}
+		return err
+	}
+
+	_ = os.Remove(statePath)
+	return nil
+}
+
+// Discard aborts an interrupted multipart upload of the given archive, if any.
+func (s *S3Destination) Discard(ctx context.Context, localPath, remoteName string) error {
+	statePath := s.statePath(localPath)
+	state := s.loadState(statePath, s.objectURL(remoteName))
+	if state == nil {
+		return nil
+	}
+	defer os.Remove(statePath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uploadURL(state.Key, state.UploadID, 0), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.do(req, emptyPayloadSHA)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// createMultipart starts a multipart upload and returns its upload ID.
+func (s *S3Destination) createMultipart(ctx context.Context, key string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, key+"?uploads", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	s.setObjectHeaders(req)
+
+	resp, err := s.do(req, emptyPayloadSHA)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", s3Error(resp)
+	}
+
+	var result s3InitiateResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse multipart upload response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("s3 returned no upload id")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart uploads a single part and returns its ETag.
+func (s *S3Destination) uploadPart(ctx context.Context, part *io.SectionReader, state *s3UploadState, n int, prog *progress) (string, error) {
+	payloadHash, size, err := hashReader(part)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash part %d: %w", n, err)
+	}
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind part %d: %w", n, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL(state.Key, state.UploadID, n), prog.reader(part))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+
+	resp, err := s.do(req, payloadHash)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errNoSuchUpload
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to upload part %d: %w", n, s3Error(resp))
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// completeMultipart assembles the uploaded parts into the final object.
+func (s *S3Destination) completeMultipart(ctx context.Context, state *s3UploadState) error {
+	complete := s3CompleteUpload{}
+	for n, etag := range state.Parts {
+		complete.Parts = append(complete.Parts, s3CompletePart{PartNumber: n, ETag: etag})
+	}
+	sort.Slice(complete.Parts, func(i, j int) bool {
+		return complete.Parts[i].PartNumber < complete.Parts[j].PartNumber
+	})
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return fmt.Errorf("failed to encode part list: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL(state.Key, state.UploadID, 0), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := s.do(req, hexSHA256(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNoSuchUpload
+	}
+
+	// S3 can report a failure with 200 OK once it has started streaming the response
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK || bytes.Contains(respBody, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete multipart upload: s3 returned status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// statePath returns where the multipart upload state for localPath is kept.
+// The name is specific to this destination so several S3 destinations can
+// upload the same archive independently.
+func (s *S3Destination) statePath(localPath string) string {
+	return localPath + ".s3-" + hexSHA256([]byte(s.Name()))[:12] + ".json"
+}
+
+// loadState returns the saved upload state for key, or nil if there is none
+// or it was made with different settings.
+func (s *S3Destination) loadState(path, key string) *s3UploadState {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is derived from the staged archive
+	if err != nil {
+		return nil
+	}
+	var state s3UploadState
+	if err := json.Unmarshal(data, &state); err != nil || state.Key != key || state.UploadID == "" || state.PartSize <= 0 {
+		return nil
+	}
+	if state.Parts == nil {
+		state.Parts = map[int]string{}
+	}
+	return &state
+}
+
+// saveState writes the upload state atomically.
+func saveState(path string, state *s3UploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// uploadURL returns the URL for a multipart upload request. Part 0 addresses the upload itself.
+func uploadURL(key, uploadID string, part int) string {
+	query := url.Values{"uploadId": {uploadID}}
+	if part > 0 {
+		query.Set("partNumber", fmt.Sprint(part))
+	}
+	return key + "?" + query.Encode()
+}
+
+// Ensure S3Destination implements Discarder.
+var _ Discarder = (*S3Destination)(nil)
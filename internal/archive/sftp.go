@@ -0,0 +1,266 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSFTPPort     = 22
+	sftpDialTimeout     = 30 * time.Second
+	partialUploadSuffix = ".partial"
+)
+
+// SFTPConfig holds connection settings for an SFTP destination.
+type SFTPConfig struct {
+	// Host is the SSH server hostname or IP address.
+	Host string
+
+	// Port is the SSH server port (defaults to 22).
+	Port int
+
+	// User is the SSH username.
+	User string
+
+	// KeyFile is the path to the private key used for authentication.
+	KeyFile string
+
+	// KnownHostsFile is the path to the known_hosts file used to verify the server.
+	// Defaults to ~/.ssh/known_hosts.
+	KnownHostsFile string
+
+	// Path is the remote directory archives are uploaded to.
+	Path string
+}
+
+// SFTPDestination uploads archives to a remote directory over SFTP.
+// Uploads are written to a temporary name and atomically renamed into place,
+// so readers never observe a partially transferred archive. An interrupted
+// upload is resumed from the end of the partial file.
+type SFTPDestination struct {
+	cfg SFTPConfig
+}
+
+// NewSFTPDestination creates a new SFTPDestination.
+func NewSFTPDestination(cfg SFTPConfig) *SFTPDestination {
+	if cfg.Port == 0 {
+		cfg.Port = defaultSFTPPort
+	}
+	return &SFTPDestination{cfg: cfg}
+}
+
+// Name returns the destination identifier.
+func (s *SFTPDestination) Name() string {
+	return fmt.Sprintf("sftp://%s@%s%s", s.cfg.User, net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)), s.cfg.Path)
+}
+
+// Upload uploads the local file to the remote directory.
+func (s *SFTPDestination) Upload(ctx context.Context, localPath, remoteName string) error {
+	client, closeFn, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	return s.upload(ctx, client, localPath, remoteName)
+}
+
+// Validate checks that the server is reachable and the remote directory can be created.
+func (s *SFTPDestination) Validate(ctx context.Context) error {
+	client, closeFn, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := client.MkdirAll(s.cfg.Path); err != nil {
+		return fmt.Errorf("failed to create remote directory %s: %w", s.cfg.Path, err)
+	}
+
+	return nil
+}
+
+// upload copies the local file to the remote directory using the given client.
+func (s *SFTPDestination) upload(ctx context.Context, client *sftp.Client, localPath, remoteName string) error {
+	src, err := os.Open(localPath) // #nosec G304 -- path is the archive staged by the archiver
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer src.Close()
+
+	if err := client.MkdirAll(s.cfg.Path); err != nil {
+		return fmt.Errorf("failed to create remote directory %s: %w", s.cfg.Path, err)
+	}
+
+	finalPath := path.Join(s.cfg.Path, remoteName)
+	partialPath := finalPath + partialUploadSuffix
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	dst, offset, err := openPartial(client, partialPath, info.Size())
+	if err != nil {
+		return err
+	}
+	if _, err := src.Seek(offset, io.SeekStart); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("failed to seek archive: %w", err)
+	}
+
+	prog := newProgress(ctx, info.Size(), offset)
+	if _, err := io.Copy(dst, prog.reader(&contextReader{ctx: ctx, r: src})); err != nil {
+		// Keep the partial file so the next attempt can resume it
+		_ = dst.Close()
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = client.Remove(partialPath)
+		return fmt.Errorf("failed to finalize remote file: %w", err)
+	}
+
+	// Prefer the atomic posix-rename extension; fall back to a plain rename
+	// for servers that don't support it.
+	if err := client.PosixRename(partialPath, finalPath); err != nil {
+		if err := client.Rename(partialPath, finalPath); err != nil {
+			_ = client.Remove(partialPath)
+			return fmt.Errorf("failed to rename uploaded archive: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Discard removes the partial upload of the given archive, if any.
+func (s *SFTPDestination) Discard(ctx context.Context, _, remoteName string) error {
+	client, closeFn, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	err = client.Remove(path.Join(s.cfg.Path, remoteName) + partialUploadSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove partial upload: %w", err)
+	}
+	return nil
+}
+
+// openPartial opens the partial upload file, resuming after the bytes already
+// transferred by an interrupted attempt. It returns the offset to continue from.
+func openPartial(client *sftp.Client, partialPath string, size int64) (*sftp.File, int64, error) {
+	if info, err := client.Stat(partialPath); err == nil && info.Size() > 0 && info.Size() <= size {
+		f, err := client.OpenFile(partialPath, os.O_WRONLY)
+		if err == nil {
+			if _, err := f.Seek(info.Size(), io.SeekStart); err == nil {
+				return f, info.Size(), nil
+			}
+			_ = f.Close()
+		}
+	}
+
+	f, err := client.Create(partialPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create remote file: %w", err)
+	}
+	return f, 0, nil
+}
+
+// connect opens an SSH connection and SFTP session.
+func (s *SFTPDestination) connect(ctx context.Context) (*sftp.Client, func(), error) {
+	sshConfig, err := s.clientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{Timeout: sftpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("ssh handshake failed: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, nil, fmt.Errorf("failed to start sftp session: %w", err)
+	}
+
+	closeFn := func() {
+		_ = client.Close()
+		_ = sshClient.Close()
+	}
+	return client, closeFn, nil
+}
+
+// clientConfig builds the SSH client configuration using key authentication.
+func (s *SFTPDestination) clientConfig() (*ssh.ClientConfig, error) {
+	key, err := os.ReadFile(s.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	knownHostsFile := s.cfg.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine known_hosts path: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	return &ssh.ClientConfig{
+		User:            s.cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	}, nil
+}
+
+// contextReader aborts reads once its context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Ensure SFTPDestination implements Discarder.
+var _ Discarder = (*SFTPDestination)(nil)
+
+// Ensure SFTPDestination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*SFTPDestination)(nil)
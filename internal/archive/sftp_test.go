@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInMemorySFTPClient returns an SFTP client connected to an in-memory server.
+func newInMemorySFTPClient(t *testing.T) *sftp.Client {
+	t.Helper()
+
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverRead, serverWrite}, sftp.InMemHandler())
+	go func() {
+		_ = server.Serve()
+	}()
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestSFTPDestination_Upload(t *testing.T) {
+	client := newInMemorySFTPClient(t)
+
+	localPath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(localPath, []byte("archive contents"), 0600))
+
+	dest := NewSFTPDestination(SFTPConfig{Host: "nas", User: "backup", Path: "/backups/ludusavi"})
+	err := dest.upload(context.Background(), client, localPath, "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+
+	f, err := client.Open("/backups/ludusavi/ludusavi-1.tar.gz")
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "archive contents", string(data))
+
+	// The partial file must not be left behind
+	_, err = client.Stat("/backups/ludusavi/ludusavi-1.tar.gz" + partialUploadSuffix)
+	assert.Error(t, err)
+}
+
+func TestSFTPDestination_Upload_ContextCancelled(t *testing.T) {
+	client := newInMemorySFTPClient(t)
+
+	localPath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(localPath, []byte("archive contents"), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dest := NewSFTPDestination(SFTPConfig{Host: "nas", User: "backup", Path: "/backups"})
+	err := dest.upload(ctx, client, localPath, "ludusavi-1.tar.gz")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = client.Stat("/backups/ludusavi-1.tar.gz")
+	assert.Error(t, err)
+}
+
+func TestSFTPDestination_Upload_Resume(t *testing.T) {
+	client := newInMemorySFTPClient(t)
+
+	localPath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(localPath, []byte("archive contents"), 0600))
+
+	// An earlier attempt was interrupted after transferring the first 8 bytes
+	require.NoError(t, client.MkdirAll("/backups"))
+	partial, err := client.Create("/backups/ludusavi-1.tar.gz" + partialUploadSuffix)
+	require.NoError(t, err)
+	_, err = partial.Write([]byte("archive "))
+	require.NoError(t, err)
+	require.NoError(t, partial.Close())
+
+	var uploaded []int64
+	ctx := contextWithProgress(context.Background(), func(n, total int64) {
+		uploaded = append(uploaded, n)
+	})
+
+	dest := NewSFTPDestination(SFTPConfig{Host: "nas", User: "backup", Path: "/backups"})
+	require.NoError(t, dest.upload(ctx, client, localPath, "ludusavi-1.tar.gz"))
+
+	f, err := client.Open("/backups/ludusavi-1.tar.gz")
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "archive contents", string(data))
+
+	// Progress starts from the resumed offset
+	require.NotEmpty(t, uploaded)
+	assert.Equal(t, int64(8), uploaded[0])
+	assert.Equal(t, int64(16), uploaded[len(uploaded)-1])
+}
+
+func TestSFTPDestination_Name(t *testing.T) {
+	dest := NewSFTPDestination(SFTPConfig{Host: "nas.local", User: "backup", Path: "/volume1/saves"})
+	assert.Equal(t, "sftp://backup@nas.local:22/volume1/saves", dest.Name())
+}
+
+func TestSFTPDestination_Validate_MissingKey(t *testing.T) {
+	dest := NewSFTPDestination(SFTPConfig{
+		Host:    "127.0.0.1",
+		User:    "backup",
+		KeyFile: filepath.Join(t.TempDir(), "missing"),
+		Path:    "/backups",
+	})
+	assert.ErrorContains(t, dest.Validate(context.Background()), "failed to read key file")
+}
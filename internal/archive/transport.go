@@ -0,0 +1,32 @@
+package archive
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// httpDialTimeout bounds connecting to an HTTP destination.
+	httpDialTimeout = 30 * time.Second
+
+	// httpResponseHeaderTimeout bounds the wait for a response once a
+	// request is sent. It is generous, as a server may assemble a large
+	// multipart or chunked upload before it answers.
+	httpResponseHeaderTimeout = 5 * time.Minute
+)
+
+// newHTTPClient returns the client of HTTP destinations, connecting within
+// dialTimeout and waiting up to headerTimeout for each response. It has no
+// overall timeout, which would cut off the upload of a large archive, but a
+// server that can't be reached or stops answering fails the upload rather
+// than hanging it.
+func newHTTPClient(dialTimeout, headerTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = headerTimeout
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,34 @@
+package archive
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient_ResponseHeaderTimeout(t *testing.T) {
+	// Takes the upload, then never answers
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	dest := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav"},
+		WithWebDAVHTTPClient(newHTTPClient(time.Second, 50*time.Millisecond)))
+
+	start := time.Now()
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "0123456789"), "ludusavi-1.tar.gz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout awaiting response headers")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,378 @@
+package archive
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+const (
+	methodMkcol    = "MKCOL"
+	methodMove     = "MOVE"
+	methodPropfind = "PROPFIND"
+
+	// nextcloudFilesPath and nextcloudUploadsPath are the Nextcloud/ownCloud
+	// DAV roots for regular files and chunked upload sessions.
+	nextcloudFilesPath   = "/remote.php/dav/files/"
+	nextcloudUploadsPath = "/remote.php/dav/uploads/"
+
+	// webdavAbortTimeout bounds deleting an unfinished upload session,
+	// which is done even once the upload is cancelled.
+	webdavAbortTimeout = 30 * time.Second
+)
+
+// WebDAVConfig holds connection settings for a WebDAV destination.
+type WebDAVConfig struct {
+	// URL is the collection archives are uploaded to
+	// (e.g. https://cloud.example.com/remote.php/dav/files/alice/Backups).
+	URL string
+
+	// Username and Password are used for basic authentication.
+	Username string
+	Password string
+
+	// ChunkSize enables Nextcloud chunked uploads for files larger than this
+	// many bytes. Zero disables chunking.
+	ChunkSize int64
+}
+
+// WebDAVDestination uploads archives to a WebDAV collection (Nextcloud, ownCloud, ...).
+// Overwrites are guarded with If-Match/If-None-Match so a file changed by
+// someone else between the existence check and the upload is never clobbered.
+type WebDAVDestination struct {
+	cfg        WebDAVConfig
+	httpClient *http.Client
+}
+
+// WebDAVOption configures a WebDAVDestination.
+type WebDAVOption func(*WebDAVDestination)
+
+// WithWebDAVHTTPClient sets the HTTP client used for requests.
+func WithWebDAVHTTPClient(client *http.Client) WebDAVOption {
+	return func(w *WebDAVDestination) {
+		w.httpClient = client
+	}
+}
+
+// NewWebDAVDestination creates a new WebDAVDestination.
+func NewWebDAVDestination(cfg WebDAVConfig, opts ...WebDAVOption) *WebDAVDestination {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	w := &WebDAVDestination{
+		cfg:        cfg,
+		httpClient: newHTTPClient(httpDialTimeout, httpResponseHeaderTimeout),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Name returns the destination identifier.
+func (w *WebDAVDestination) Name() string {
+	return w.cfg.URL
+}
+
+// Upload uploads the local file to the collection, using chunked uploads for large files
+// when the server supports them.
+func (w *WebDAVDestination) Upload(ctx context.Context, localPath, remoteName string) error {
+	f, err := os.Open(localPath) // #nosec G304 -- path is the archive staged by the archiver
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	if err := w.ensureCollection(ctx); err != nil {
+		return err
+	}
+
+	target := w.cfg.URL + "/" + url.PathEscape(remoteName)
+
+	etag, err := w.etag(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	uploadsURL, chunked := w.uploadsURL()
+	if chunked && w.cfg.ChunkSize > 0 && info.Size() > w.cfg.ChunkSize {
+		return w.uploadChunked(ctx, f, info.Size(), uploadsURL, target, etag)
+	}
+
+	req, err := w.newRequest(ctx, http.MethodPut, target, newProgress(ctx, info.Size(), 0).reader(f))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/gzip")
+	setPrecondition(req, etag)
+
+	return w.expect(req, "upload archive", http.StatusCreated, http.StatusNoContent, http.StatusOK)
+}
+
+// Validate checks that the collection is reachable with the configured
+// credentials. A missing collection is created on first upload, so it is
+// enough for its parent to be reachable.
+func (w *WebDAVDestination) Validate(ctx context.Context) error {
+	status, err := w.propfind(ctx, w.cfg.URL)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNotFound {
+		return checkPropfindStatus(status)
+	}
+
+	u, err := url.Parse(w.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webdav url: %w", err)
+	}
+	parent := *u
+	parent.Path = path.Dir(strings.TrimSuffix(u.Path, "/")) + "/"
+	parent.RawPath = ""
+
+	status, err = w.propfind(ctx, parent.String())
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("webdav collection %s and its parent don't exist", u.Path)
+	}
+	return checkPropfindStatus(status)
+}
+
+// propfind returns the status of a Depth 0 PROPFIND of target.
+func (w *WebDAVDestination) propfind(ctx context.Context, target string) (int, error) {
+	req, err := w.newRequest(ctx, methodPropfind, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Depth", "0")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webdav request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// checkPropfindStatus returns an error unless a PROPFIND found its target.
+func checkPropfindStatus(status int) error {
+	switch status {
+	case http.StatusMultiStatus, http.StatusOK:
+		return nil
+	default:
+		return fmt.Errorf("webdav server returned status %d", status)
+	}
+}
+
+// uploadChunked uploads the file using the Nextcloud chunked upload protocol (v2):
+// create an upload session, PUT each chunk, then MOVE the assembled file into place.
+func (w *WebDAVDestination) uploadChunked(ctx context.Context, f *os.File, size int64, uploadsURL, target, etag string) (err error) {
+	sessionURL := uploadsURL + "/" + newTransferID()
+
+	req, err := w.newRequest(ctx, methodMkcol, sessionURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Destination", target)
+	if err := w.expect(req, "create upload session", http.StatusCreated); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			w.abortSession(ctx, sessionURL)
+		}
+	}()
+
+	prog := newProgress(ctx, size, 0)
+	for chunk, offset := 1, int64(0); offset < size; chunk, offset = chunk+1, offset+w.cfg.ChunkSize {
+		length := min(w.cfg.ChunkSize, size-offset)
+
+		req, err := w.newRequest(ctx, http.MethodPut, fmt.Sprintf("%s/%05d", sessionURL, chunk),
+			prog.reader(io.NewSectionReader(f, offset, length)))
+		if err != nil {
+			return err
+		}
+		req.ContentLength = length
+		req.Header.Set("Destination", target)
+		req.Header.Set("OC-Total-Length", fmt.Sprintf("%d", size))
+
+		if err := w.expect(req, fmt.Sprintf("upload chunk %d", chunk), http.StatusCreated, http.StatusNoContent); err != nil {
+			return err
+		}
+	}
+
+	req, err = w.newRequest(ctx, methodMove, sessionURL+"/.file", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Destination", target)
+	req.Header.Set("OC-Total-Length", fmt.Sprintf("%d", size))
+	setPrecondition(req, etag)
+
+	return w.expect(req, "assemble chunks", http.StatusCreated, http.StatusNoContent)
+}
+
+// abortSession deletes an unfinished chunked upload session, also once ctx
+// is cancelled, for at most webdavAbortTimeout.
+func (w *WebDAVDestination) abortSession(ctx context.Context, sessionURL string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webdavAbortTimeout)
+	defer cancel()
+
+	req, err := w.newRequest(ctx, http.MethodDelete, sessionURL, nil)
+	if err != nil {
+		return
+	}
+	if resp, err := w.httpClient.Do(req); err == nil {
+		_ = resp.Body.Close()
+	}
+}
+
+// ensureCollection creates the destination collection and its parents.
+func (w *WebDAVDestination) ensureCollection(ctx context.Context) error {
+	u, err := url.Parse(w.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webdav url: %w", err)
+	}
+
+	// Only create segments below the Nextcloud files root; the root itself always exists.
+	root := ""
+	rest := u.Path
+	if idx := strings.Index(u.Path, nextcloudFilesPath); idx >= 0 {
+		after := u.Path[idx+len(nextcloudFilesPath):]
+		user, remainder, _ := strings.Cut(after, "/")
+		root = u.Path[:idx+len(nextcloudFilesPath)] + user
+		rest = remainder
+	}
+
+	current := root
+	for _, segment := range strings.Split(strings.Trim(rest, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		current += "/" + segment
+
+		collection := *u
+		collection.Path = current
+		collection.RawPath = ""
+
+		req, err := w.newRequest(ctx, methodMkcol, collection.String(), nil)
+		if err != nil {
+			return err
+		}
+		// 405 Method Not Allowed means the collection already exists.
+		if err := w.expect(req, "create collection "+current, http.StatusCreated, http.StatusMethodNotAllowed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// etag returns the current ETag of target, or an empty string if it doesn't exist.
+func (w *WebDAVDestination) etag(ctx context.Context, target string) (string, error) {
+	req, err := w.newRequest(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("webdav request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.Header.Get("ETag"), nil
+	default:
+		return "", fmt.Errorf("failed to check existing file: status %d", resp.StatusCode)
+	}
+}
+
+// uploadsURL derives the Nextcloud chunked upload root from the files URL.
+// It returns false if the URL isn't a Nextcloud/ownCloud files URL.
+func (w *WebDAVDestination) uploadsURL() (string, bool) {
+	idx := strings.Index(w.cfg.URL, nextcloudFilesPath)
+	if idx < 0 {
+		return "", false
+	}
+	user, _, _ := strings.Cut(w.cfg.URL[idx+len(nextcloudFilesPath):], "/")
+	if user == "" {
+		return "", false
+	}
+	return w.cfg.URL[:idx] + nextcloudUploadsPath + user, true
+}
+
+// newRequest creates an authenticated request.
+func (w *WebDAVDestination) newRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if w.cfg.Username != "" {
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+	}
+	return req, nil
+}
+
+// expect sends the request and returns an error unless the status is one of codes.
+func (w *WebDAVDestination) expect(req *http.Request, action string, codes ...int) error {
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("failed to %s: remote file was modified concurrently", action)
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("failed to %s: webdav returned status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// setPrecondition guards an overwrite: only replace the file we saw, or only
+// create it if it didn't exist.
+func setPrecondition(req *http.Request, etag string) {
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+}
+
+// newTransferID returns a random identifier for a chunked upload session.
+func newTransferID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "ludusavi-runner-" + hex.EncodeToString(b)
+}
+
+// Ensure WebDAVDestination implements domain.ArchiveDestination.
+var _ domain.ArchiveDestination = (*WebDAVDestination)(nil)
@@ -0,0 +1,286 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDAV is a minimal in-memory WebDAV server supporting the requests
+// WebDAVDestination makes, including Nextcloud chunked uploads.
+type fakeDAV struct {
+	mu          sync.Mutex
+	files       map[string]string
+	collections map[string]bool
+	requests    []string
+	preconds    map[string]string
+}
+
+func newFakeDAV() *fakeDAV {
+	return &fakeDAV{
+		files:       make(map[string]string),
+		collections: make(map[string]bool),
+		preconds:    make(map[string]string),
+	}
+}
+
+func (f *fakeDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case "MKCOL":
+		if f.collections[r.URL.Path] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		f.collections[r.URL.Path] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		if _, ok := f.files[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPut:
+		if !f.checkPrecondition(w, r, r.URL.Path) {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.files[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case "MOVE":
+		dest := strings.TrimPrefix(r.Header.Get("Destination"), "http://"+r.Host)
+		if !f.checkPrecondition(w, r, dest) {
+			return
+		}
+		session := strings.TrimSuffix(r.URL.Path, "/.file")
+		var chunks []string
+		for name := range f.files {
+			if strings.HasPrefix(name, session+"/") {
+				chunks = append(chunks, name)
+			}
+		}
+		sort.Strings(chunks)
+		var assembled strings.Builder
+		for _, name := range chunks {
+			assembled.WriteString(f.files[name])
+			delete(f.files, name)
+		}
+		f.files[dest] = assembled.String()
+		w.WriteHeader(http.StatusCreated)
+	case "PROPFIND":
+		w.WriteHeader(http.StatusMultiStatus)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeDAV) checkPrecondition(w http.ResponseWriter, r *http.Request, path string) bool {
+	_, exists := f.files[path]
+	if match := r.Header.Get("If-Match"); match != "" {
+		f.preconds[path] = "If-Match: " + match
+		if !exists || match != `"v1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return false
+		}
+	}
+	if r.Header.Get("If-None-Match") == "*" {
+		f.preconds[path] = "If-None-Match: *"
+		if exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return false
+		}
+	}
+	return true
+}
+
+func writeArchiveFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestWebDAVDestination_Upload_New(t *testing.T) {
+	dav := newFakeDAV()
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	dest := NewWebDAVDestination(WebDAVConfig{
+		URL:      server.URL + "/remote.php/dav/files/alice/Backups/ludusavi/",
+		Username: "alice",
+		Password: "secret",
+	})
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "archive contents"), "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+
+	target := "/remote.php/dav/files/alice/Backups/ludusavi/ludusavi-1.tar.gz"
+	assert.Equal(t, "archive contents", dav.files[target])
+	assert.Equal(t, "If-None-Match: *", dav.preconds[target])
+	// Collections below the user root are created, the root itself is not
+	assert.True(t, dav.collections["/remote.php/dav/files/alice/Backups"])
+	assert.True(t, dav.collections["/remote.php/dav/files/alice/Backups/ludusavi"])
+	assert.False(t, dav.collections["/remote.php/dav/files/alice"])
+}
+
+func TestWebDAVDestination_Upload_OverwriteUsesIfMatch(t *testing.T) {
+	dav := newFakeDAV()
+	dav.files["/dav/ludusavi-1.tar.gz"] = "old"
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	dest := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav", Username: "alice", Password: "secret"})
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "new"), "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+
+	assert.Equal(t, "new", dav.files["/dav/ludusavi-1.tar.gz"])
+	assert.Equal(t, `If-Match: "v1"`, dav.preconds["/dav/ludusavi-1.tar.gz"])
+}
+
+func TestWebDAVDestination_Upload_ConcurrentModification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	dest := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav"})
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "data"), "ludusavi-1.tar.gz")
+
+	assert.ErrorContains(t, err, "remote file was modified concurrently")
+}
+
+func TestWebDAVDestination_Upload_Chunked(t *testing.T) {
+	dav := newFakeDAV()
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	dest := NewWebDAVDestination(WebDAVConfig{
+		URL:       server.URL + "/remote.php/dav/files/alice/Backups",
+		Username:  "alice",
+		Password:  "secret",
+		ChunkSize: 4,
+	})
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "0123456789"), "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+
+	target := "/remote.php/dav/files/alice/Backups/ludusavi-1.tar.gz"
+	assert.Equal(t, "0123456789", dav.files[target])
+	assert.Equal(t, "If-None-Match: *", dav.preconds[target])
+
+	var chunkPuts int
+	for _, req := range dav.requests {
+		if strings.HasPrefix(req, "PUT /remote.php/dav/uploads/alice/") {
+			chunkPuts++
+		}
+	}
+	assert.Equal(t, 3, chunkPuts)
+}
+
+func TestWebDAVDestination_Upload_ChunkedCancelledAbortsSession(t *testing.T) {
+	dav := newFakeDAV()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The upload is cancelled during its first chunk
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/remote.php/dav/uploads/") {
+			cancel()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	dest := NewWebDAVDestination(WebDAVConfig{
+		URL:       server.URL + "/remote.php/dav/files/alice/Backups",
+		Username:  "alice",
+		Password:  "secret",
+		ChunkSize: 4,
+	})
+
+	err := dest.Upload(ctx, writeArchiveFile(t, "0123456789"), "ludusavi-1.tar.gz")
+	require.Error(t, err)
+
+	dav.mu.Lock()
+	defer dav.mu.Unlock()
+	var deleted bool
+	for _, req := range dav.requests {
+		if strings.HasPrefix(req, "DELETE /remote.php/dav/uploads/alice/") {
+			deleted = true
+		}
+	}
+	assert.True(t, deleted, "the upload session is deleted although the upload was cancelled")
+}
+
+func TestWebDAVDestination_Upload_ChunkingRequiresNextcloud(t *testing.T) {
+	dav := newFakeDAV()
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	// A generic WebDAV server falls back to a single PUT.
+	dest := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav", Username: "alice", Password: "secret", ChunkSize: 4})
+
+	err := dest.Upload(context.Background(), writeArchiveFile(t, "0123456789"), "ludusavi-1.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", dav.files["/dav/ludusavi-1.tar.gz"])
+}
+
+func TestWebDAVDestination_Validate(t *testing.T) {
+	dav := newFakeDAV()
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	ok := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav", Username: "alice", Password: "secret"})
+	assert.NoError(t, ok.Validate(context.Background()))
+
+	unauthorized := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav", Username: "alice", Password: "wrong"})
+	assert.ErrorContains(t, unauthorized.Validate(context.Background()), "status 401")
+}
+
+func TestWebDAVDestination_Validate_MissingCollection(t *testing.T) {
+	// Only /dav/ and its children exist.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, methodPropfind, r.Method)
+		if r.URL.Path == "/dav/" {
+			w.WriteHeader(http.StatusMultiStatus)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	missing := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/dav/saves"})
+	assert.NoError(t, missing.Validate(context.Background()))
+
+	orphaned := NewWebDAVDestination(WebDAVConfig{URL: server.URL + "/nope/saves/"})
+	assert.ErrorContains(t, orphaned.Validate(context.Background()), "/nope/saves and its parent don't exist")
+}
@@ -0,0 +1,115 @@
+// Package badge renders shields.io-style status badges for embedding in
+// dashboards such as Homepage or Heimdall.
+package badge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Badge colors, matching shields.io.
+const (
+	ColorGreen  = "#4c1"
+	ColorYellow = "#dfb317"
+	ColorRed    = "#e05d44"
+	ColorGrey   = "#9f9f9f"
+)
+
+// Badge is a two-part badge: a grey label and a colored message.
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// ForRun returns the badge for the latest run and the end of the latest
+// successful one. Backups older than stale are shown in yellow.
+func ForRun(label string, last *domain.RunResult, lastSuccess, now time.Time, stale time.Duration) Badge {
+	b := Badge{Label: label}
+	switch {
+	case last == nil:
+		b.Message, b.Color = "no backups yet", ColorGrey
+	case !last.Success && lastSuccess.IsZero():
+		b.Message, b.Color = "backup failed ✗", ColorRed
+	case !last.Success:
+		b.Message = fmt.Sprintf("backup failed, last ok %s ago ✗", age(now.Sub(lastSuccess)))
+		b.Color = ColorRed
+	default:
+		b.Message = fmt.Sprintf("backed up %s ago ✓", age(now.Sub(lastSuccess)))
+		b.Color = ColorGreen
+		if stale > 0 && now.Sub(lastSuccess) > stale {
+			b.Color = ColorYellow
+		}
+	}
+	return b
+}
+
+// age formats d in its largest whole unit, e.g. "12m" or "3d".
+func age(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// SVG renders the badge in the flat shields.io style.
+func (b Badge) SVG() []byte {
+	labelWidth := textWidth(b.Label) + 10
+	messageWidth := textWidth(b.Message) + 10
+	width := labelWidth + messageWidth
+	label, message := escape(b.Label), escape(b.Message)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(&buf, `<title>%s: %s</title>`, label, message)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&buf, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&buf, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, escape(b.Color), width)
+	buf.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, text := range []struct {
+		x int
+		s string
+	}{{labelWidth / 2, label}, {labelWidth + messageWidth/2, message}} {
+		fmt.Fprintf(&buf, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`,
+			text.x, text.s, text.x, text.s)
+	}
+	buf.WriteString(`</g></svg>`)
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// textWidth estimates the width in pixels of s in 11px Verdana.
+func textWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch r {
+		case 'i', 'j', 'l', 'I', '.', ',', ':', ';', '\'', '!', '|':
+			width += 4
+		case 'f', 'r', 't', ' ', '(', ')', '-':
+			width += 5
+		case 'm', 'w', 'M', 'W':
+			width += 11
+		default:
+			width += 7
+		}
+	}
+	return width
+}
+
+// escape escapes s for use in XML text and attributes.
+func escape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,60 @@
+package badge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForRun(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ok := &domain.RunResult{Success: true}
+	failed := &domain.RunResult{Success: false}
+
+	tests := []struct {
+		name        string
+		last        *domain.RunResult
+		lastSuccess time.Time
+		message     string
+		color       string
+	}{
+		{"no runs", nil, time.Time{}, "no backups yet", ColorGrey},
+		{"recent success", ok, now.Add(-12 * time.Minute), "backed up 12m ago ✓", ColorGreen},
+		{"just now", ok, now.Add(-10 * time.Second), "backed up <1m ago ✓", ColorGreen},
+		{"stale success", ok, now.Add(-3 * 24 * time.Hour), "backed up 3d ago ✓", ColorYellow},
+		{"failed", failed, now.Add(-5 * time.Hour), "backup failed, last ok 5h ago ✗", ColorRed},
+		{"never succeeded", failed, time.Time{}, "backup failed ✗", ColorRed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := ForRun("saves", tt.last, tt.lastSuccess, now, time.Hour)
+			assert.Equal(t, "saves", b.Label)
+			assert.Equal(t, tt.message, b.Message)
+			assert.Equal(t, tt.color, b.Color)
+		})
+	}
+}
+
+func TestBadge_SVG(t *testing.T) {
+	b := Badge{Label: "saves", Message: "backed up 12m ago ✓", Color: ColorGreen}
+	svg := string(b.SVG())
+
+	assert.Contains(t, svg, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(t, svg, `<title>saves: backed up 12m ago ✓</title>`)
+	assert.Contains(t, svg, `fill="#4c1"`)
+
+	// Text is escaped
+	b.Label = `<a & "b">`
+	svg = string(b.SVG())
+	assert.Contains(t, svg, "&lt;a &amp; &#34;b&#34;&gt;")
+	assert.NotContains(t, svg, "<a &")
+
+	// Longer text makes a wider badge
+	short := Badge{Label: "saves", Message: "ok"}.SVG()
+	long := Badge{Label: "saves", Message: "backup failed, last ok 5h ago ✗"}.SVG()
+	assert.Greater(t, len(long), len(short))
+	assert.Greater(t, textWidth("backup failed, last ok 5h ago ✗"), textWidth("ok"))
+}
@@ -0,0 +1,96 @@
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxChunk caps how much is read at once, so throttled transfers stay smooth.
+const maxChunk = 32 * 1024
+
+// Limiter throttles readers to the rate given by a Schedule. The rate is
+// re-evaluated continuously, so a transfer speeds up or slows down when it
+// crosses a rule boundary. A single Limiter can be shared by concurrent
+// transfers, which then share the limit.
+type Limiter struct {
+	schedule *Schedule
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a Limiter following the given schedule.
+func NewLimiter(schedule *Schedule) *Limiter {
+	return &Limiter{
+		schedule: schedule,
+		now:      time.Now,
+	}
+}
+
+// Reader wraps r so reads are throttled to the current limit.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil || l.schedule.Unlimited() {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, l: l}
+}
+
+// wait blocks until n bytes may be transferred.
+func (l *Limiter) wait(ctx context.Context, n int) error {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		rate := float64(l.schedule.LimitAt(now))
+		if rate <= 0 {
+			l.tokens = 0
+			l.last = time.Time{}
+			l.mu.Unlock()
+			return nil
+		}
+
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * rate
+		}
+		l.last = now
+		// Allow bursts of up to one second of traffic
+		l.tokens = min(l.tokens, rate)
+
+		// Reads larger than the burst go into debt rather than waiting forever
+		if need := min(float64(n), rate); l.tokens >= need {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((min(float64(n), rate) - l.tokens) / rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > maxChunk {
+		p = p[:maxChunk]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.l.wait(lr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
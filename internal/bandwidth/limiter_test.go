@@ -0,0 +1,54 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Reader_Throttles(t *testing.T) {
+	limiter := NewLimiter(NewSchedule(64 * 1024))
+	data := bytes.Repeat([]byte("x"), 96*1024)
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, limiter.Reader(context.Background(), bytes.NewReader(data)))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(len(data)), n)
+	// 96 KiB at 64 KiB/s with no initial burst takes about 1.5s
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestLimiter_Reader_Unlimited(t *testing.T) {
+	limiter := NewLimiter(NewSchedule(0))
+	r := strings.NewReader("data")
+
+	// Unlimited schedules don't wrap the reader at all
+	assert.Same(t, r, limiter.Reader(context.Background(), r))
+}
+
+func TestLimiter_Reader_FollowsSchedule(t *testing.T) {
+	limiter := NewLimiter(NewSchedule(0, Rule{Start: 8 * time.Hour, End: 23 * time.Hour, Limit: 1}))
+
+	// Outside the rule's window reads are not throttled
+	limiter.now = func() time.Time { return at(3, 0) }
+	data, err := io.ReadAll(limiter.Reader(context.Background(), strings.NewReader("overnight")))
+	require.NoError(t, err)
+	assert.Equal(t, "overnight", string(data))
+}
+
+func TestLimiter_Reader_ContextCancelled(t *testing.T) {
+	limiter := NewLimiter(NewSchedule(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := io.ReadAll(limiter.Reader(ctx, strings.NewReader("data")))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,165 @@
+// Package bandwidth provides time-of-day bandwidth limits for uploads.
+package bandwidth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// Rule limits bandwidth during a time-of-day window.
+// A window whose end is before its start wraps past midnight (e.g. 22:00-06:00).
+type Rule struct {
+	// Start and End are offsets from midnight in the schedule's time zone.
+	Start time.Duration
+	End   time.Duration
+
+	// Limit is the maximum rate in bytes per second. Zero means unlimited.
+	Limit int64
+}
+
+// contains reports whether the time-of-day offset falls within the rule's window.
+func (r Rule) contains(offset time.Duration) bool {
+	if r.Start <= r.End {
+		return offset >= r.Start && offset < r.End
+	}
+	return offset >= r.Start || offset < r.End
+}
+
+// Schedule maps times of day to bandwidth limits.
+type Schedule struct {
+	defaultLimit int64
+	rules        []Rule
+	// loc is the time zone of the rules; nil for local time.
+	loc *time.Location
+}
+
+// NewSchedule creates a schedule that applies the first matching rule, or
+// defaultLimit (bytes per second, zero for unlimited) outside of every rule.
+func NewSchedule(defaultLimit int64, rules ...Rule) *Schedule {
+	return &Schedule{
+		defaultLimit: defaultLimit,
+		rules:        rules,
+	}
+}
+
+// In returns a copy of the schedule with its rules in the time zone loc
+// rather than local time.
+func (s *Schedule) In(loc *time.Location) *Schedule {
+	c := *s
+	c.loc = loc
+	return &c
+}
+
+// LimitAt returns the limit in bytes per second at t. Zero means unlimited.
+func (s *Schedule) LimitAt(t time.Time) int64 {
+	if s == nil {
+		return 0
+	}
+	if s.loc != nil {
+		t = t.In(s.loc)
+	}
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	for _, rule := range s.rules {
+		if rule.contains(offset) {
+			return rule.Limit
+		}
+	}
+	return s.defaultLimit
+}
+
+// Unlimited reports whether the schedule never limits bandwidth.
+func (s *Schedule) Unlimited() bool {
+	if s == nil {
+		return true
+	}
+	if s.defaultLimit > 0 {
+		return false
+	}
+	for _, rule := range s.rules {
+		if rule.Limit > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RcloneTimetable renders the schedule in rclone's --bwlimit timetable format
+// (e.g. "08:00,512k 23:00,off"), so cloud uploads ludusavi performs through
+// rclone follow the same limits. rclone reads the timetable in local time, so
+// rules in another time zone are converted with today's offsets.
+func (s *Schedule) RcloneTimetable() string {
+	return s.rcloneTimetable(time.Local, time.Now())
+}
+
+// rcloneTimetable renders the schedule for an rclone running in the time zone
+// local on the day of now.
+func (s *Schedule) rcloneTimetable(local *time.Location, now time.Time) string {
+	if s.Unlimited() {
+		return ""
+	}
+	if len(s.rules) == 0 {
+		return rcloneRate(s.defaultLimit)
+	}
+
+	// The limit can only change at a rule boundary
+	boundaries := map[time.Duration]bool{}
+	for _, rule := range s.rules {
+		boundaries[rule.Start%day] = true
+		boundaries[rule.End%day] = true
+	}
+	times := make([]time.Duration, 0, len(boundaries))
+	for t := range boundaries {
+		times = append(times, t)
+	}
+
+	type entry struct {
+		at    time.Duration
+		limit int64
+	}
+	entries := make([]entry, 0, len(times))
+	if s.loc == nil {
+		midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+		for _, t := range times {
+			entries = append(entries, entry{t, s.LimitAt(midnight.Add(t))})
+		}
+	} else {
+		year, month, day := now.In(s.loc).Date()
+		for _, t := range times {
+			instant := time.Date(year, month, day, int(t.Hours()), int(t.Minutes())%60, 0, 0, s.loc)
+			at := instant.In(local)
+			entries = append(entries, entry{
+				time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute,
+				s.LimitAt(instant),
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].at < entries[j].at })
+
+	parts := make([]string, 0, len(entries))
+	for _, e := range entries {
+		parts = append(parts, fmt.Sprintf("%02d:%02d,%s", int(e.at.Hours()), int(e.at.Minutes())%60, rcloneRate(e.limit)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// rcloneRate formats a rate in bytes per second for rclone.
+func rcloneRate(limit int64) string {
+	if limit <= 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%dk", max(limit/1024, 1))
+}
+
+// ParseTimeOfDay parses an "HH:MM" time of day into an offset from midnight.
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,107 @@
+package bandwidth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 3, 1, hour, minute, 0, 0, time.Local)
+}
+
+func TestSchedule_LimitAt(t *testing.T) {
+	schedule := NewSchedule(0,
+		Rule{Start: 8 * time.Hour, End: 23 * time.Hour, Limit: 512 * 1024},
+		Rule{Start: 23 * time.Hour, End: 2 * time.Hour, Limit: 4096 * 1024},
+	)
+
+	tests := []struct {
+		name string
+		time time.Time
+		want int64
+	}{
+		{"before daytime window", at(7, 59), 0},
+		{"daytime window start", at(8, 0), 512 * 1024},
+		{"daytime window", at(15, 30), 512 * 1024},
+		{"overnight window", at(23, 30), 4096 * 1024},
+		{"overnight window after midnight", at(1, 0), 4096 * 1024},
+		{"after overnight window", at(2, 0), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schedule.LimitAt(tt.time))
+		})
+	}
+}
+
+func TestSchedule_DefaultLimit(t *testing.T) {
+	schedule := NewSchedule(1024, Rule{Start: 0, End: 6 * time.Hour, Limit: 0})
+
+	assert.Equal(t, int64(0), schedule.LimitAt(at(3, 0)))
+	assert.Equal(t, int64(1024), schedule.LimitAt(at(12, 0)))
+	assert.False(t, schedule.Unlimited())
+}
+
+func TestSchedule_Unlimited(t *testing.T) {
+	var nilSchedule *Schedule
+	assert.True(t, nilSchedule.Unlimited())
+	assert.True(t, NewSchedule(0).Unlimited())
+	assert.True(t, NewSchedule(0, Rule{Start: 0, End: time.Hour}).Unlimited())
+	assert.False(t, NewSchedule(0, Rule{Start: 0, End: time.Hour, Limit: 1}).Unlimited())
+}
+
+func TestSchedule_RcloneTimetable(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		want     string
+	}{
+		{"unlimited", NewSchedule(0), ""},
+		{"constant limit", NewSchedule(2048 * 1024), "2048k"},
+		{
+			"daytime throttle",
+			NewSchedule(0, Rule{Start: 8 * time.Hour, End: 23 * time.Hour, Limit: 512 * 1024}),
+			"08:00,512k 23:00,off",
+		},
+		{
+			"overnight full speed",
+			NewSchedule(256*1024, Rule{Start: 22*time.Hour + 30*time.Minute, End: 6 * time.Hour}),
+			"06:00,256k 22:30,off",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schedule.RcloneTimetable())
+		})
+	}
+}
+
+func TestSchedule_In(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	schedule := NewSchedule(0, Rule{Start: 8 * time.Hour, End: 23 * time.Hour, Limit: 512 * 1024}).In(berlin)
+
+	// 08:30 in Berlin is 07:30 UTC
+	assert.Equal(t, int64(512*1024), schedule.LimitAt(time.Date(2026, 1, 15, 7, 30, 0, 0, time.UTC)))
+	assert.Zero(t, schedule.LimitAt(time.Date(2026, 1, 15, 6, 30, 0, 0, time.UTC)))
+
+	// rclone on a machine kept on UTC gets the rules in UTC
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "07:00,512k 22:00,off", schedule.rcloneTimetable(time.UTC, now))
+	assert.Equal(t, "08:00,512k 23:00,off", schedule.rcloneTimetable(berlin, now))
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	offset, err := ParseTimeOfDay("08:30")
+	require.NoError(t, err)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, offset)
+
+	_, err = ParseTimeOfDay("25:00")
+	assert.Error(t, err)
+	_, err = ParseTimeOfDay("8am")
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+// Package bundle packs the config file and state of the runner into a
+// portable archive, for moving them to another machine.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version is the version of the bundle format.
+const Version = 1
+
+const (
+	// manifestName is the name of the manifest in the archive.
+	manifestName = "manifest.json"
+	// stateDir is the directory of the state files in the archive.
+	stateDir = "state/"
+	// maxFileSize bounds the size of a file read from a bundle.
+	maxFileSize = 1 << 30
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Hostname string    `json:"hostname"`
+	// Config is the name of the config file, if the bundle has one.
+	Config string `json:"config,omitempty"`
+	// State lists the names of the state files.
+	State []string `json:"state"`
+	// Redacted lists the config keys left out as credentials.
+	Redacted []string `json:"redacted,omitempty"`
+}
+
+// Bundle is the config file and state files of a runner.
+type Bundle struct {
+	Manifest
+	// Config is the content of the config file named Manifest.Config.
+	Config []byte
+	// State holds the content of the state files by name.
+	State map[string][]byte
+}
+
+// file is a file in the archive.
+type file struct {
+	name string
+	data []byte
+}
+
+// Write writes the bundle to w as a gzipped tar archive.
+func (b *Bundle) Write(w io.Writer) error {
+	b.Version = Version
+	b.Manifest.State = slices.Sorted(maps.Keys(b.State))
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	files := []file{{manifestName, manifest}}
+	if b.Manifest.Config != "" {
+		files = append(files, file{b.Manifest.Config, b.Config})
+	}
+	for _, name := range b.Manifest.State {
+		files = append(files, file{stateDir + name, b.State[name]})
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.data)), ModTime: b.Created}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// Read reads a bundle written by Write. Files not listed in its manifest
+// are rejected, and so are names that would leave the directory they are
+// restored to.
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %q in bundle", header.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if len(data) > maxFileSize {
+			return nil, fmt.Errorf("%s in bundle is too large", header.Name)
+		}
+		files[header.Name] = data
+	}
+
+	data, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("not a ludusavi-runner state bundle: no %s", manifestName)
+	}
+	b := &Bundle{State: make(map[string][]byte)}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if b.Version > Version {
+		return nil, fmt.Errorf("bundle version %d is newer than this version of ludusavi-runner supports", b.Version)
+	}
+	delete(files, manifestName)
+
+	if b.Manifest.Config != "" {
+		if !plainName(b.Manifest.Config) {
+			return nil, fmt.Errorf("invalid config file name %q in bundle", b.Manifest.Config)
+		}
+		if b.Config, ok = files[b.Manifest.Config]; !ok {
+			return nil, fmt.Errorf("config file %s missing from bundle", b.Manifest.Config)
+		}
+		delete(files, b.Manifest.Config)
+	}
+	for _, name := range b.Manifest.State {
+		if !plainName(name) {
+			return nil, fmt.Errorf("invalid state file name %q in bundle", name)
+		}
+		if b.State[name], ok = files[stateDir+name]; !ok {
+			return nil, fmt.Errorf("state file %s missing from bundle", name)
+		}
+		delete(files, stateDir+name)
+	}
+	for name := range files {
+		return nil, fmt.Errorf("unexpected entry %q in bundle", name)
+	}
+	return b, nil
+}
+
+// plainName returns true if name is a file name without a directory.
+func plainName(name string) bool {
+	return name != "" && name != "." && name != ".." && path.Base(name) == name && !strings.ContainsAny(name, `/\:`)
+}
@@ -0,0 +1,88 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle_RoundTrip(t *testing.T) {
+	b := &Bundle{
+		Manifest: Manifest{
+			Created:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Hostname: "old-pc",
+			Config:   "config.toml",
+			Redacted: []string{"home_assistant.token"},
+		},
+		Config: []byte(`interval = "1h"`),
+		State: map[string][]byte{
+			"history.jsonl":    []byte(`{"id":"1"}` + "\n"),
+			"game-counts.json": []byte(`[]`),
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, Version, read.Version)
+	assert.Equal(t, "old-pc", read.Hostname)
+	assert.True(t, b.Created.Equal(read.Created))
+	assert.Equal(t, []string{"game-counts.json", "history.jsonl"}, read.Manifest.State)
+	assert.Equal(t, []string{"home_assistant.token"}, read.Redacted)
+	assert.Equal(t, b.Config, read.Config)
+	assert.Equal(t, b.State, read.State)
+
+	// Without a config file
+	b = &Bundle{State: map[string][]byte{"failures.json": []byte(`{}`)}}
+	buf.Reset()
+	require.NoError(t, b.Write(&buf))
+	read, err = Read(&buf)
+	require.NoError(t, err)
+	assert.Nil(t, read.Config)
+	assert.Len(t, read.State, 1)
+}
+
+// archive returns a gzipped tar archive of files.
+func archive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, err := Read(bytes.NewBufferString("not an archive"))
+	assert.ErrorContains(t, err, "failed to read bundle")
+
+	_, err = Read(archive(t, map[string]string{"config.toml": ""}))
+	assert.ErrorContains(t, err, "not a ludusavi-runner state bundle")
+
+	_, err = Read(archive(t, map[string]string{"manifest.json": `{"version": 2}`}))
+	assert.ErrorContains(t, err, "bundle version 2 is newer")
+
+	_, err = Read(archive(t, map[string]string{
+		"manifest.json": `{"version": 1, "state": ["../../.bashrc"]}`,
+		"../../.bashrc": "",
+	}))
+	assert.ErrorContains(t, err, `invalid state file name "../../.bashrc"`)
+
+	_, err = Read(archive(t, map[string]string{"manifest.json": `{"version": 1, "state": ["history.jsonl"]}`}))
+	assert.ErrorContains(t, err, "state file history.jsonl missing")
+
+	_, err = Read(archive(t, map[string]string{"manifest.json": `{"version": 1}`, "state/extra": ""}))
+	assert.ErrorContains(t, err, `unexpected entry "state/extra"`)
+}
@@ -0,0 +1,50 @@
+package cli
+
+// Exit codes of the run command, so schedulers such as Windows Task
+// Scheduler, which shows them as the task's last run result, can tell how a
+// backup went.
+const (
+	exitOK                 = 0
+	exitError              = 1 // the backup couldn't run, e.g. an invalid config
+	exitBackupFailed       = 2
+	exitDestinationOffline = 3
+	exitAuthRequired       = 4
+	exitPartial            = 5 // some backups were made, others failed
+)
+
+// exitCodeError is an error that exits with a specific code.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+// withExitCode returns err exiting with code.
+func withExitCode(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// exitStatus names an exit code in the run result file.
+func exitStatus(code int) string {
+	switch code {
+	case exitOK:
+		return "success"
+	case exitBackupFailed:
+		return "failed"
+	case exitDestinationOffline:
+		return "destination_offline"
+	case exitAuthRequired:
+		return "auth_required"
+	case exitPartial:
+		return "partial"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/grafana"
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grafanaOutput     string
+	grafanaDatasource string
+	grafanaTitle      string
+	grafanaUID        string
+	grafanaPrefix     string
+)
+
+// NewGrafanaCmd creates the grafana command.
+func NewGrafanaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grafana",
+		Short: "Grafana integration",
+	}
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Print a Grafana dashboard for the pushed metrics",
+		Long: `Print a ready-to-import Grafana dashboard for the metrics pushed to
+Pushgateway: time since the last backup, run status and duration per
+operation, games and save size, backup destinations, and panics and watchdog
+recoveries.
+
+The dashboard is generated from the metrics this version pushes, so
+re-export it after upgrading. Without --datasource, Grafana asks for the
+Prometheus datasource on import. Pass --metric-prefix if metrics.prefix is
+set in the config.`,
+		Args: cobra.NoArgs,
+		RunE: runGrafanaExport,
+	}
+	export.Flags().StringVarP(&grafanaOutput, "output", "o", "", "write the dashboard to a file instead of stdout")
+	export.Flags().StringVar(&grafanaDatasource, "datasource", "", "UID of the Prometheus datasource")
+	export.Flags().StringVar(&grafanaTitle, "title", grafana.DefaultTitle, "dashboard title")
+	export.Flags().StringVar(&grafanaUID, "uid", grafana.DefaultUID, "dashboard UID")
+	export.Flags().StringVar(&grafanaPrefix, "metric-prefix", metrics.DefaultPrefix, "prefix of the pushed metric names")
+
+	cmd.AddCommand(export)
+	return cmd
+}
+
+func runGrafanaExport(cmd *cobra.Command, args []string) error {
+	opts := []grafana.Option{
+		grafana.WithTitle(grafanaTitle),
+		grafana.WithUID(grafanaUID),
+		grafana.WithMetricPrefix(grafanaPrefix),
+	}
+	if grafanaDatasource != "" {
+		opts = append(opts, grafana.WithDatasource(grafanaDatasource))
+	}
+
+	data, err := grafana.NewDashboard(opts...).JSON()
+	if err != nil {
+		return err
+	}
+
+	if grafanaOutput == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	// #nosec G306 -- dashboards contain no secrets
+	if err := os.WriteFile(grafanaOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dashboard: %w", err)
+	}
+	return nil
+}
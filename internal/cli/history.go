@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit int
+	historyJSON  bool
+)
+
+// NewHistoryCmd creates the history command.
+func NewHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List past runs from the run history",
+		Long: `List the most recent runs from the run history, newest first: when each
+started, its kind, whether it succeeded and how long it took, followed by the
+games and bytes of each of its operations and their errors.
+
+This answers when the saves were last backed up successfully:
+
+  ludusavi-runner history --limit 5
+
+With --json, the records are printed as kept in the history, for scripts.
+Runs are only recorded while history.enabled is set.`,
+		Args: cobra.NoArgs,
+		RunE: runHistory,
+	}
+	cmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "number of runs to list")
+	cmd.Flags().BoolVar(&historyJSON, "json", false, "output in JSON format")
+	return cmd
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyLimit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	path, err := config.DefaultHistoryPath()
+	if err != nil {
+		return fmt.Errorf("failed to determine history path: %w", err)
+	}
+
+	records, err := history.NewStore(path).Recent(historyLimit)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if historyJSON {
+		if records == nil {
+			records = []history.Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintln(out, "No runs recorded yet.")
+		if !cfg.History.Enabled {
+			fmt.Fprintln(out, "Set history.enabled to record them.")
+		}
+		return nil
+	}
+	return writeHistory(out, records, cfg.Location())
+}
+
+// writeHistory lists records as text, with times in loc.
+func writeHistory(out io.Writer, records []history.Record, loc *time.Location) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, rec := range records {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		dryRun := ""
+		if rec.DryRun {
+			dryRun = " (dry run)"
+		}
+		fmt.Fprintf(w, "%s  %s run %s in %s%s\n",
+			rec.Start.In(loc).Format("2006-01-02 15:04:05"), rec.Kind,
+			recordOutcome(rec), rec.Duration.Round(time.Second), dryRun)
+
+		// Errors go below the operations, so their columns stay aligned
+		var errs []string
+		for _, op := range rec.Operations {
+			name := string(op.Operation)
+			if op.Destination != "" {
+				name += " (" + op.Destination + ")"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%d/%d games\t%s/%s\t%s\n",
+				name, outcome(op.Success, op.Skipped), op.ProcessedGames, op.TotalGames,
+				units.FormatBytes(op.ProcessedBytes), units.FormatBytes(op.TotalBytes), op.Duration.Round(time.Second))
+			if op.Error != "" {
+				errs = append(errs, name+": "+withCode(op.Error, op.Code))
+			}
+		}
+		if len(rec.Operations) == 0 {
+			for i, msg := range rec.Errors {
+				if i < len(rec.ErrorCodes) {
+					msg = withCode(msg, rec.ErrorCodes[i])
+				}
+				errs = append(errs, msg)
+			}
+		}
+		for _, msg := range errs {
+			fmt.Fprintf(w, "  error: %s\n", msg)
+		}
+	}
+	return w.Flush()
+}
+
+// withCode returns msg followed by its error code, if known; runs recorded
+// before error codes have none.
+func withCode(msg string, code domain.ErrorCode) string {
+	if code == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s [%s]", msg, code)
+}
+
+// recordOutcome describes the outcome of a run.
+func recordOutcome(rec history.Record) string {
+	if rec.PartialSuccess {
+		return "PARTIALLY FAILED"
+	}
+	return outcome(rec.Success, false)
+}
+
+// outcome describes the outcome of a run or operation.
+func outcome(success, skipped bool) string {
+	switch {
+	case skipped:
+		return "skipped"
+	case success:
+		return "succeeded"
+	default:
+		return "FAILED"
+	}
+}
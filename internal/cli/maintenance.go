@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var maintenanceUntil string
+
+// NewMaintenanceCmd creates the maintenance command.
+func NewMaintenanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Show, turn on or turn off maintenance mode",
+		Long: `Show whether maintenance mode is on.
+
+Turn maintenance mode on while you deliberately break backups, such as when
+reorganizing drives. Unlike pausing the schedule, backups keep running, but
+failures and warnings aren't notified and every pushed metric is labeled
+maintenance="true", so dashboards and alerts can tell them apart.
+
+Maintenance mode applies from the service's next run, without restarting it.`,
+		Args: cobra.NoArgs,
+		RunE: runMaintenanceStatus,
+	}
+
+	on := &cobra.Command{
+		Use:   "on",
+		Short: "Turn maintenance mode on",
+		Long: `Turn maintenance mode on, until turned off or until the time given with
+--until: a duration such as 4h, or a time such as "2026-07-01 18:00" in the
+config's timezone.`,
+		Args: cobra.NoArgs,
+		RunE: runMaintenanceOn,
+	}
+	on.Flags().StringVar(&maintenanceUntil, "until", "", "end maintenance mode by itself after a duration or at a time")
+
+	off := &cobra.Command{
+		Use:   "off",
+		Short: "Turn maintenance mode off",
+		Args:  cobra.NoArgs,
+		RunE:  runMaintenanceOff,
+	}
+
+	cmd.AddCommand(on, off)
+	return cmd
+}
+
+func runMaintenanceStatus(cmd *cobra.Command, args []string) error {
+	path, err := config.DefaultMaintenancePath()
+	if err != nil {
+		return fmt.Errorf("failed to determine maintenance state path: %w", err)
+	}
+	m, err := app.ReadMaintenance(path)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !m.Active(time.Now()):
+		fmt.Println("Maintenance mode is off")
+	case m.Until.IsZero():
+		fmt.Printf("Maintenance mode is on since %s\n", m.Since.Format(time.DateTime))
+	default:
+		fmt.Printf("Maintenance mode is on since %s, until %s\n", m.Since.Format(time.DateTime), m.Until.Format(time.DateTime))
+	}
+	return nil
+}
+
+func runMaintenanceOn(cmd *cobra.Command, args []string) error {
+	var until time.Time
+	if maintenanceUntil != "" {
+		var err error
+		if until, err = parseUntil(maintenanceUntil); err != nil {
+			return err
+		}
+		if !until.After(time.Now()) {
+			return fmt.Errorf("--until must be in the future")
+		}
+	}
+
+	path, err := config.DefaultMaintenancePath()
+	if err != nil {
+		return fmt.Errorf("failed to determine maintenance state path: %w", err)
+	}
+	if _, err := app.StartMaintenance(path, until); err != nil {
+		return err
+	}
+
+	if until.IsZero() {
+		fmt.Println("Maintenance mode on until turned off")
+	} else {
+		fmt.Printf("Maintenance mode on until %s\n", until.Format(time.DateTime))
+	}
+	return nil
+}
+
+func runMaintenanceOff(cmd *cobra.Command, args []string) error {
+	path, err := config.DefaultMaintenancePath()
+	if err != nil {
+		return fmt.Errorf("failed to determine maintenance state path: %w", err)
+	}
+	if err := app.EndMaintenance(path); err != nil {
+		return err
+	}
+	fmt.Println("Maintenance mode off")
+	return nil
+}
+
+// parseUntil parses --until as a duration from now or, like calendar
+// exceptions, a time in the config's timezone.
+func parseUntil(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(d), nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	until, err := config.CalendarRunConfig{At: s}.Time(cfg.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --until: %w", err)
+	}
+	return until, nil
+}
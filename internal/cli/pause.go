@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// NewPauseCmd creates the pause command.
+func NewPauseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "Pause the running service's scheduled backups",
+		Long: `Pause the scheduled backups of the running service, as for a maintenance
+window such as moving the backup disk, without stopping the service. The
+request goes through the service's control channel (control.enabled), like
+trigger.
+
+While paused, the service keeps running: backups started with trigger or by
+game events still run, and a backup already in progress finishes. Scheduled
+backups start again with resume. The pause doesn't survive a restart of the
+service.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setPaused(cmd, "/pause")
+		},
+	}
+}
+
+// NewResumeCmd creates the resume command.
+func NewResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the running service's scheduled backups after pause",
+		Long: `Resume the scheduled backups of the running service after pause. The next
+scheduled backup runs when it is due, rather than right away; use trigger
+to back up now.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setPaused(cmd, "/resume")
+		},
+	}
+}
+
+// setPaused pauses or resumes scheduled backups through the control
+// channel, with path "/pause" or "/resume".
+func setPaused(cmd *cobra.Command, path string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	status, err := callControl(cmd.Context(), cfg, http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+
+	writePaused(cmd.OutOrStdout(), status)
+	return nil
+}
+
+// writePaused reports whether scheduled backups are paused, with the status
+// of the scheduler the service answered with.
+func writePaused(w io.Writer, status *app.SchedulerStatus) {
+	if status.Paused {
+		fmt.Fprintln(w, "Scheduled backups paused.")
+	} else {
+		fmt.Fprintln(w, "Scheduled backups resumed.")
+	}
+	if status.State == app.SchedulerStateRunning {
+		fmt.Fprintln(w, "A backup is in progress; it isn't interrupted.")
+	}
+	if !status.Paused && status.NextRunAt != nil {
+		fmt.Fprintf(w, "Next backup: %s\n", status.NextRunAt.Local().Format("Jan 2 15:04"))
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/alerting"
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesOutput          string
+	rulesInterval        time.Duration
+	rulesStaleIntervals  int
+	rulesFailureStreak   int
+	rulesSizeDropPercent int
+	rulesPrefix          string
+)
+
+// NewPrometheusCmd creates the prometheus command.
+func NewPrometheusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prometheus",
+		Short: "Prometheus integration",
+	}
+
+	rules := &cobra.Command{
+		Use:   "rules",
+		Short: "Print recommended Prometheus alerting rules",
+		Long: `Print recommended Prometheus alerting rules for the pushed metrics, scaled
+to the backup interval from the config, or the longest gap between runs of
+its cron schedule:
+
+  LudusaviBackupStale         no backup for --stale-intervals intervals
+  LudusaviBackupFailing       every run of an operation failed for
+                              --failure-streak intervals
+  LudusaviDestinationFailing  the same for additional backup destinations
+  LudusaviBackupSizeDropped   the total size of the saves fell more than
+                              --size-drop percent below its weekly maximum
+
+Add the output to rule_files in prometheus.yml. Regenerate it when you change
+the interval or schedule. Pass --metric-prefix if metrics.prefix is set in
+the config.`,
+		Args: cobra.NoArgs,
+		RunE: runPrometheusRules,
+	}
+	rules.Flags().StringVarP(&rulesOutput, "output", "o", "", "write the rules to a file instead of stdout")
+	rules.Flags().DurationVar(&rulesInterval, "interval", 0, "backup interval (default: interval from the config)")
+	rules.Flags().IntVar(&rulesStaleIntervals, "stale-intervals", alerting.DefaultStaleIntervals, "intervals without a backup before alerting")
+	rules.Flags().IntVar(&rulesFailureStreak, "failure-streak", alerting.DefaultFailureStreak, "intervals of failed runs before alerting")
+	rules.Flags().IntVar(&rulesSizeDropPercent, "size-drop", alerting.DefaultSizeDropPercent, "percent drop in save size that raises an alert")
+	rules.Flags().StringVar(&rulesPrefix, "metric-prefix", metrics.DefaultPrefix, "prefix of the pushed metric names")
+
+	cmd.AddCommand(rules)
+	return cmd
+}
+
+func runPrometheusRules(cmd *cobra.Command, args []string) error {
+	if rulesStaleIntervals < 1 || rulesFailureStreak < 1 {
+		return fmt.Errorf("--stale-intervals and --failure-streak must be at least 1")
+	}
+	if rulesSizeDropPercent < 1 || rulesSizeDropPercent > 99 {
+		return fmt.Errorf("--size-drop must be between 1 and 99")
+	}
+
+	interval := rulesInterval
+	if interval == 0 {
+		cfg, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		interval = cfg.Interval
+		if cfg.Schedule != "" {
+			// A cron schedule is as stale as its longest gap between runs
+			schedule, _ := cron.Parse(cfg.Schedule)
+			interval = schedule.In(cfg.Location()).LongestGap(time.Now())
+		}
+	}
+
+	data, err := alerting.NewRules(interval,
+		alerting.WithStaleIntervals(rulesStaleIntervals),
+		alerting.WithFailureStreak(rulesFailureStreak),
+		alerting.WithSizeDropPercent(rulesSizeDropPercent),
+		alerting.WithMetricPrefix(rulesPrefix),
+	).YAML()
+	if err != nil {
+		return err
+	}
+
+	if rulesOutput == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	// #nosec G306 -- alerting rules contain no secrets
+	if err := os.WriteFile(rulesOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+)
+
+// configReloadDebounce is how long the config file must go unwritten before
+// it is reloaded, so an editor saving in several steps reloads it once.
+const configReloadDebounce = 500 * time.Millisecond
+
+// reloadableKeys are the config keys, or tables of them, whose changes a
+// config reload applies to the running service. Others need a restart.
+var reloadableKeys = []string{"interval", "schedule", "apprise", "notify_webhook", "log.level"}
+
+// configReloader applies changes to the config file to the running service.
+type configReloader struct {
+	// active is the config the service runs with, replaced on each reload.
+	active    *config.Config
+	runner    *app.Runner
+	scheduler *app.Scheduler
+	counter   *notify.Counter
+	logger    *slog.Logger
+}
+
+// watch reloads the config each time its file is written, until ctx is
+// cancelled. It returns a channel closed once it stopped. Like the HTTP
+// server, a failure to watch never takes the backup service down with it.
+func (r *configReloader) watch(ctx context.Context) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.logger.Debug("watching config file for changes", "path", r.active.File)
+		if err := config.Watch(ctx, r.active.File, configReloadDebounce, r.reload); err != nil {
+			r.logger.Error("config file watch stopped, changes need a restart", "error", err)
+		}
+	}()
+	return done
+}
+
+// reload loads the config file again and applies the settings that changed,
+// logging each of them. An invalid config is rejected as a whole, keeping
+// the active config.
+func (r *configReloader) reload() {
+	cfg, err := loadConfig()
+	if err != nil {
+		r.logger.Error("config file changed but failed to load, keeping the active config", "error", err)
+		return
+	}
+
+	changes := config.Diff(r.active, cfg)
+	if len(changes) == 0 {
+		r.logger.Debug("config file changed, but no settings did")
+		return
+	}
+
+	var restart []string
+	for _, change := range changes {
+		attrs := []any{"key", change.Key}
+		if !change.Hidden {
+			attrs = append(attrs, "old", change.Old, "new", change.New)
+		}
+		if reloadable(change.Key) {
+			r.logger.Info("config setting changed", attrs...)
+		} else {
+			r.logger.Info("config setting changed, takes effect after a restart", attrs...)
+			restart = append(restart, change.Key)
+		}
+	}
+
+	if changed(changes, "log.level") {
+		logLevelVar.Set(configLogLevel(cfg))
+	}
+	if changed(changes, "interval", "schedule") {
+		r.scheduler.Reschedule(cfg.Interval, cronSchedule(cfg))
+	}
+	if changed(changes, "apprise", "notify_webhook") {
+		var notifier domain.Notifier = &domain.NopNotifier{}
+		if cfg.NotificationsEnabled() {
+			notifier = newNotifier(cfg, newHTTPClient(cfg, r.logger), r.logger, r.counter)
+		}
+		r.runner.SetNotifications(cfg.Apprise.Notify, notifier)
+	}
+
+	r.active = cfg
+	if len(restart) > 0 {
+		r.logger.Warn("config reloaded, some changes need a restart", "changes", len(changes), "restart", restart)
+	} else {
+		r.logger.Info("config reloaded", "changes", len(changes))
+	}
+}
+
+// reloadable returns true if a config reload applies changes to the key.
+func reloadable(key string) bool {
+	return slices.ContainsFunc(reloadableKeys, func(k string) bool { return under(key, k) })
+}
+
+// changed returns true if any of changes is to one of keys or within it.
+func changed(changes []config.Change, keys ...string) bool {
+	return slices.ContainsFunc(changes, func(c config.Change) bool {
+		return slices.ContainsFunc(keys, func(k string) bool { return under(c.Key, k) })
+	})
+}
+
+// under returns true if key is parent or one of its keys.
+func under(key, parent string) bool {
+	return key == parent || strings.HasPrefix(key, parent+".")
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportDays   int
+	reportFormat string
+	reportOutput string
+)
+
+// NewReportCmd creates the report command.
+func NewReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Print a report on the recent backups",
+		Long: `Print a report on the backups of the past days from the run history: runs
+by kind and failure rate, the most frequent errors, the size of the saves by
+day and the games whose saves grew the most.
+
+This is the report the service makes each week when report.enabled is set,
+for any period and on demand.`,
+		Args: cobra.NoArgs,
+		RunE: runReport,
+	}
+	cmd.Flags().IntVar(&reportDays, "days", 7, "number of days the report covers")
+	cmd.Flags().StringVar(&reportFormat, "format", "markdown", "report format: markdown or html")
+	cmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write the report to a file instead of stdout")
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	if reportDays < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	if reportFormat != "markdown" && reportFormat != "html" {
+		return fmt.Errorf("--format must be markdown or html")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	path, err := config.DefaultHistoryPath()
+	if err != nil {
+		return fmt.Errorf("failed to determine history path: %w", err)
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -reportDays)
+	records, err := history.NewStore(path).Since(from)
+	if err != nil {
+		return err
+	}
+	hostname, _ := os.Hostname()
+	rep := report.New(hostname, records, from, to, cfg.Location())
+
+	out := rep.Markdown()
+	if reportFormat == "html" {
+		if out, err = rep.HTML(); err != nil {
+			return err
+		}
+	}
+
+	if reportOutput == "" {
+		fmt.Print(out)
+		return nil
+	}
+	// #nosec G306 -- reports contain no secrets
+	if err := os.WriteFile(reportOutput, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
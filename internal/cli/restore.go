@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/ipc"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreGames   []string
+	restorePreview bool
+	restoreYes     bool
+)
+
+// NewRestoreCmd creates the restore command.
+func NewRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore saves from the backups",
+		Long: `Restore saves from the backups with ludusavi, overwriting the current saves.
+
+With --game, only the named games are restored; titles are game names as
+ludusavi knows them. With --preview, the games that would be restored are
+listed without writing anything:
+
+  ludusavi-runner restore --game "Hades" --preview
+  ludusavi-runner restore --game "Hades"
+
+A restore asks for confirmation unless --yes is given. Its outcome is pushed
+as metrics with operation="restore", notified like a backup run and kept in
+the run history. It exits with 2 if the restore failed.
+
+So that saves aren't restored while the service backs them up, a restore is
+handed to the running service through its control channel (control.enabled)
+and waited for: the service runs it ahead of queued backups, stopping a
+scheduled backup in progress at the next game boundary. Without a service to
+hand it to, the restore runs here, and refuses to start while the service is
+found in the middle of a backup through its HTTP server (server.enabled). A
+preview writes nothing, so it always runs here.`,
+		Args: cobra.NoArgs,
+		RunE: runRestore,
+	}
+
+	cmd.Flags().StringArrayVar(&restoreGames, "game", nil, "restore only the game with this title (repeatable)")
+	cmd.Flags().BoolVar(&restorePreview, "preview", false, "list what would be restored without writing anything")
+	cmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "don't ask for confirmation before restoring")
+
+	return cmd
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	opts := domain.RestoreOptions{Preview: restorePreview}
+	if cmd.Flags().Changed("game") {
+		var err error
+		if opts.Games, err = gameTitles(restoreGames); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	logger, err := setupLogging(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to setup logging: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if !opts.Preview {
+		what := "all games"
+		if len(opts.Games) > 0 {
+			what = fmt.Sprintf("%d game(s)", len(opts.Games))
+		}
+		if !restoreYes && !confirm(out, fmt.Sprintf("Restore the saves of %s from the backups, overwriting the current saves?", what)) {
+			return errors.New("restore cancelled")
+		}
+		// Confirmed here, as ludusavi can't ask with --api
+		opts.Force = true
+	}
+
+	var restored *restoreResponse
+	if !opts.Preview && serviceReachable(cmd.Context(), cfg) {
+		fmt.Fprintln(out, "Restoring through the running service...")
+		if restored, err = delegateRestore(cmd.Context(), cfg, opts); err != nil {
+			return fmt.Errorf("restore failed: %w", err)
+		}
+	} else {
+		if !opts.Preview {
+			if err := checkServiceConflict(cmd.Context(), cfg, logger, false,
+				"enable control so the restore is handed to the service, or try again once it is done"); err != nil {
+				return err
+			}
+		}
+		result, err := newRunner(cfg, logger, nil, notify.NewCounter()).Restore(cmd.Context(), opts)
+		if err != nil {
+			return fmt.Errorf("restore failed: %w", err)
+		}
+		restored = newRestoreResponse(result)
+	}
+
+	result := restored.Result
+	if restore := result.Restore; restore != nil {
+		titles := restored.Games
+		if opts.Preview {
+			fmt.Fprintf(out, "%d game(s) would be restored, %d with saves different from the backup:\n",
+				restore.Stats.ProcessedGames, restore.Stats.ChangedGames)
+		} else if restore.Success {
+			fmt.Fprintf(out, "Restored %d game(s):\n", restore.Stats.ProcessedGames)
+		}
+		if restore.Success {
+			for _, title := range titles {
+				fmt.Fprintf(out, "  %s\n", title)
+			}
+		}
+	}
+
+	if !result.Success {
+		writeFailures(cmd.ErrOrStderr(), result)
+		return withExitCode(exitBackupFailed, errors.New("restore completed with errors"))
+	}
+	return nil
+}
+
+// restoreRequest is the body of the control channel's restore endpoint.
+type restoreRequest struct {
+	Games []string `json:"games,omitempty"`
+}
+
+// restoreResponse is the answer of the control channel's restore endpoint:
+// the restore run, and the titles of the games restored, which the run's
+// JSON leaves out.
+type restoreResponse struct {
+	Result *domain.RunResult `json:"result"`
+	Games  []string          `json:"games,omitempty"`
+}
+
+// newRestoreResponse returns the answer describing the restore run result.
+func newRestoreResponse(result *domain.RunResult) *restoreResponse {
+	resp := &restoreResponse{Result: result}
+	if result.Restore != nil {
+		resp.Games = slices.Sorted(maps.Keys(result.Restore.GameBytes))
+	}
+	return resp
+}
+
+// serviceReachable returns true if a service answers on the control
+// channel.
+func serviceReachable(ctx context.Context, cfg *config.Config) bool {
+	if !cfg.Control.Enabled {
+		return false
+	}
+	_, err := callControl(ctx, cfg, http.MethodGet, "/status", nil)
+	return err == nil
+}
+
+// delegateRestore hands the restore to the running service through its
+// control channel and waits for it to finish.
+func delegateRestore(ctx context.Context, cfg *config.Config, opts domain.RestoreOptions) (*restoreResponse, error) {
+	socket, err := cfg.ControlPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine control channel path: %w", err)
+	}
+	data, err := json.Marshal(restoreRequest{Games: opts.Games})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ipc.URL("/restore"), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// No timeout: the restore may wait for a backup to stop, and take long
+	resp, err := ipc.NewClient(socket).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the service at %s: %w", socket, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("the service refused the restore: %s", strings.TrimSpace(string(msg)))
+	}
+	var restored restoreResponse
+	if err := json.NewDecoder(resp.Body).Decode(&restored); err != nil {
+		return nil, fmt.Errorf("failed to read the service's answer: %w", err)
+	}
+	if restored.Result == nil {
+		return nil, errors.New("the service answered without a result")
+	}
+	return &restored, nil
+}
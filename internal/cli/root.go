@@ -2,6 +2,8 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -9,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -19,8 +22,14 @@ var (
 	cfgFile  string
 	dryRun   bool
 	logLevel string
+	portable bool
+	strict   bool
 )
 
+// logLevelVar is the level of the logger set up by setupLogging, which a
+// config reload changes.
+var logLevelVar slog.LevelVar
+
 // NewRootCmd creates the root command.
 func NewRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
@@ -41,6 +50,8 @@ It can run as a one-shot backup, a foreground service, or as a system service.`,
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "simulate operations without running ludusavi")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&portable, "portable", false, "keep config, logs and state next to the executable")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "refuse to run or install a ludusavi binary failing signature or checksum verification")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("dry_run", rootCmd.PersistentFlags().Lookup("dry-run"))
@@ -48,6 +59,10 @@ It can run as a one-shot backup, a foreground service, or as a system service.`,
 
 	// Add subcommands
 	rootCmd.AddCommand(NewRunCmd())
+	rootCmd.AddCommand(NewTriggerCmd())
+	rootCmd.AddCommand(NewPauseCmd())
+	rootCmd.AddCommand(NewResumeCmd())
+	rootCmd.AddCommand(NewRestoreCmd())
 	rootCmd.AddCommand(NewServeCmd())
 	rootCmd.AddCommand(NewValidateCmd())
 	rootCmd.AddCommand(NewVersionCmd())
@@ -56,6 +71,13 @@ It can run as a one-shot backup, a foreground service, or as a system service.`,
 	rootCmd.AddCommand(NewStartCmd())
 	rootCmd.AddCommand(NewStopCmd())
 	rootCmd.AddCommand(NewStatusCmd())
+	rootCmd.AddCommand(NewTUICmd())
+	rootCmd.AddCommand(NewMaintenanceCmd())
+	rootCmd.AddCommand(NewReportCmd())
+	rootCmd.AddCommand(NewHistoryCmd())
+	rootCmd.AddCommand(NewStateCmd())
+	rootCmd.AddCommand(NewGrafanaCmd())
+	rootCmd.AddCommand(NewPrometheusCmd())
 
 	return rootCmd
 }
@@ -63,7 +85,11 @@ It can run as a one-shot backup, a foreground service, or as a system service.`,
 // Execute runs the root command.
 func Execute() {
 	if err := NewRootCmd().Execute(); err != nil {
-		os.Exit(1)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(exitError)
 	}
 }
 
@@ -88,33 +114,43 @@ func initConfig() error {
 	})
 	slog.SetDefault(slog.New(handler))
 
-	return nil
+	return initPortable()
 }
 
-// setupLogging configures logging based on the loaded config.
-func setupLogging(cfg *config.Config) (*slog.Logger, error) {
-	// Determine log level
-	level := slog.LevelInfo
-	switch strings.ToLower(cfg.Log.Level) {
-	case "debug":
-		level = slog.LevelDebug
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
+// initPortable turns on portable mode when requested with --portable or by a
+// portable.flag file next to the executable. The working directory changes to
+// the executable's directory, so relative paths in the config resolve against
+// the drive it runs from, whatever drive letter that has on this machine.
+func initPortable() error {
+	dir, err := config.ExecutableDir()
+	if err != nil {
+		if portable {
+			return fmt.Errorf("failed to determine executable directory: %w", err)
+		}
+		return nil
+	}
+	if !portable && !config.HasPortableFlag(dir) {
+		return nil
 	}
 
-	// CLI flag overrides config
-	if logLevel != "" {
-		switch strings.ToLower(logLevel) {
-		case "debug":
-			level = slog.LevelDebug
-		case "warn":
-			level = slog.LevelWarn
-		case "error":
-			level = slog.LevelError
+	if cfgFile != "" {
+		if cfgFile, err = filepath.Abs(cfgFile); err != nil {
+			return fmt.Errorf("failed to resolve config path: %w", err)
 		}
 	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("failed to change to executable directory: %w", err)
+	}
+
+	config.SetPortableDir(dir)
+	slog.Debug("running in portable mode", "dir", dir)
+	return nil
+}
+
+// setupLogging configures logging based on the loaded config. Log lines are
+// also written to each of tee.
+func setupLogging(cfg *config.Config, tee ...io.Writer) (*slog.Logger, error) {
+	logLevelVar.Set(configLogLevel(cfg))
 
 	// Determine output destination
 	var output io.Writer = os.Stderr
@@ -135,15 +171,49 @@ func setupLogging(cfg *config.Config) (*slog.Logger, error) {
 		}
 	}
 
-	handler := slog.NewTextHandler(output, &slog.HandlerOptions{
-		Level: level,
+	if len(tee) > 0 {
+		output = io.MultiWriter(append([]io.Writer{output}, tee...)...)
+	}
+
+	var handler slog.Handler = slog.NewTextHandler(output, &slog.HandlerOptions{
+		Level: &logLevelVar,
 	})
+	if cfg.Log.Burst > 0 {
+		handler = logging.NewSamplingHandler(handler, cfg.Log.Burst, cfg.Log.BurstWindow)
+	}
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
 
 	return logger, nil
 }
 
+// configLogLevel returns the log level set in the config, or with the
+// --log-level flag, which overrides it.
+func configLogLevel(cfg *config.Config) slog.Level {
+	level := slog.LevelInfo
+	switch strings.ToLower(cfg.Log.Level) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	// CLI flag overrides config
+	if logLevel != "" {
+		switch strings.ToLower(logLevel) {
+		case "debug":
+			level = slog.LevelDebug
+		case "warn":
+			level = slog.LevelWarn
+		case "error":
+			level = slog.LevelError
+		}
+	}
+	return level
+}
+
 // loadConfig loads the application configuration.
 func loadConfig() (*config.Config, error) {
 	loader := config.NewLoader()
@@ -159,6 +229,10 @@ func loadConfig() (*config.Config, error) {
 	if logLevel != "" {
 		loader.Set("log.level", logLevel)
 	}
+	if strict {
+		loader.Set("verify.enabled", true)
+		loader.Set("verify.strict", true)
+	}
 
 	return loader.Load()
 }
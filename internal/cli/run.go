@@ -1,16 +1,37 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/app"
-	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
-	"github.com/sharkusmanch/ludusavi-runner/internal/http"
-	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
 	"github.com/spf13/cobra"
 )
 
+var (
+	runResultFile     string
+	runGames          []string
+	runViaService     bool
+	runWaitForService bool
+)
+
+// servicePollInterval is how often the service is asked whether its backup
+// finished, with --wait-for-service.
+const servicePollInterval = 5 * time.Second
+
 // NewRunCmd creates the run command.
 func NewRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -18,14 +39,51 @@ func NewRunCmd() *cobra.Command {
 		Short: "Run a single backup cycle and exit",
 		Long: `Run a single backup cycle (cloud upload + local backup) and exit.
 
-This is useful for testing or one-off backups.`,
+This is useful for testing or one-off backups, or for running backups from
+a scheduled task instead of the service. The exit code tells how it went:
+
+  0  success, or offline-degraded with network operations skipped
+  1  the backup could not run, e.g. an invalid config
+  2  the backup failed
+  3  a backup destination was offline
+  4  ludusavi's cloud sign-in expired
+
+The outcome is also written as JSON to --result-file, by default
+last-run.json in the state directory.
+
+With --game, only the named games are backed up, without scanning the rest
+of the library; cloud upload and archive export are left to the next full
+run. Titles are game names as ludusavi knows them:
+
+  ludusavi-runner run --game "Hades" --game "Celeste"
+
+A backup runs ludusavi on the same saves and backups as the service, so run
+refuses to start while the service is in the middle of a backup, as found
+through its HTTP server (server.enabled). With --via-service, the backup is
+handed to the service instead, through the control channel or else the HTTP
+server with server.secret set, which queues it and returns right away; with
+--wait-for-service, it starts once the service's backup is done.`,
 		RunE: runRun,
 	}
 
+	cmd.Flags().StringVar(&runResultFile, "result-file", "", "file to write the run outcome to (default: last-run.json in the state directory)")
+	cmd.Flags().StringArrayVar(&runGames, "game", nil, "back up only the game with this title (repeatable)")
+	cmd.Flags().BoolVar(&runViaService, "via-service", false, "have the running service do the backup instead")
+	cmd.Flags().BoolVar(&runWaitForService, "wait-for-service", false, "wait for a backup of the running service to finish first")
+	cmd.MarkFlagsMutuallyExclusive("via-service", "wait-for-service")
+
 	return cmd
 }
 
 func runRun(cmd *cobra.Command, args []string) error {
+	var games []string
+	if cmd.Flags().Changed("game") {
+		var err error
+		if games, err = gameTitles(runGames); err != nil {
+			return err
+		}
+	}
+
 	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -36,70 +94,235 @@ func runRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to setup logging: %w", err)
 	}
 
-	// Create HTTP client with retry config
-	httpClient := http.NewClient(
-		http.WithRetryConfig(http.RetryConfig{
-			MaxAttempts:  cfg.Retry.MaxAttempts,
-			InitialDelay: cfg.Retry.InitialDelay,
-			MaxDelay:     cfg.Retry.MaxDelay,
-		}),
-		http.WithLogger(logger),
-	)
-
-	// Create executor
-	execOpts := []executor.LudusaviOption{
-		executor.WithLogger(logger),
+	if runViaService {
+		return delegateRun(cmd, cfg, games)
 	}
-	if cfg.LudusaviPath != "" {
-		execOpts = append(execOpts, executor.WithBinaryPath(cfg.LudusaviPath))
+	if err := checkServiceConflict(cmd.Context(), cfg, logger, runWaitForService,
+		"use --via-service to have it do this backup, or --wait-for-service to start once it is done"); err != nil {
+		return err
 	}
-	if len(cfg.Env) > 0 {
-		execOpts = append(execOpts, executor.WithEnv(cfg.Env))
-	}
-	exec := executor.NewLudusaviExecutor(execOpts...)
 
-	// Create runner
-	runnerOpts := []app.RunnerOption{
-		app.WithExecutor(exec),
-		app.WithLogger(logger),
+	runner := newRunner(cfg, logger, nil, notify.NewCounter())
+
+	// Run backup
+	var result *domain.RunResult
+	if len(games) > 0 {
+		result, err = runner.RunGames(cmd.Context(), games)
+	} else {
+		result, err = runner.Run(cmd.Context())
+	}
+	if err != nil {
+		err = fmt.Errorf("backup failed: %w", err)
+		writeRunResult(logger, nil, exitError, err)
+		return err
 	}
 
-	// Create metrics pusher if enabled
-	if cfg.Metrics.Enabled {
-		metricsPusher := metrics.NewPushgatewayClient(
-			cfg.Metrics.PushgatewayURL,
-			metrics.WithHTTPClient(httpClient),
-			metrics.WithLogger(logger),
+	code, err := runExitCode(result)
+	writeRunResult(logger, result, code, err)
+
+	switch {
+	case err != nil:
+		writeFailures(cmd.ErrOrStderr(), result)
+		return withExitCode(code, err)
+	case result.Offline:
+		// Offline, a skipped upload is expected rather than an error
+		logger.Warn("backup completed offline-degraded, network operations skipped",
+			"duration", result.Duration,
+		)
+	default:
+		logger.Info("backup completed successfully",
+			"duration", result.Duration,
 		)
-		runnerOpts = append(runnerOpts, app.WithMetricsPusher(metricsPusher))
 	}
 
-	// Create notifier if enabled
-	if cfg.Apprise.Enabled {
-		notifier := notify.NewAppriseClient(
-			cfg.Apprise.URL,
-			cfg.Apprise.Key,
-			notify.WithHTTPClient(httpClient),
-			notify.WithLogger(logger),
-		)
-		runnerOpts = append(runnerOpts, app.WithNotifier(notifier))
+	return nil
+}
+
+// checkServiceConflict looks for a service in the middle of a backup, which
+// a backup or restore here would run ludusavi alongside, possibly as another
+// user or with another config. It fails if one is found, suggesting hint, or
+// with wait waits for its backup to finish.
+func checkServiceConflict(ctx context.Context, cfg *config.Config, logger *slog.Logger, wait bool, hint string) error {
+	// Without the server, a running service is all that can be found
+	if !cfg.Server.Enabled {
+		if wait {
+			return errors.New("--wait-for-service requires server.enabled, to ask the service how its backup is going")
+		}
+		if serviceRunning(ctx) {
+			logger.Warn("the service is running; set server.enabled to check that it isn't in the middle of a backup")
+		}
+		return nil
 	}
 
-	runner := app.NewRunner(cfg, runnerOpts...)
+	for {
+		status, err := querySchedulerStatus(ctx, cfg)
+		if err != nil {
+			// No service to conflict with
+			logger.Debug("service not reachable", "error", err)
+			return nil
+		}
+		if status.State != app.SchedulerStateRunning && status.State != app.SchedulerStateDraining {
+			return nil
+		}
+		if !wait {
+			return fmt.Errorf("the service is in the middle of a backup (%s); %s", status.Message, hint)
+		}
 
-	// Run backup
-	result, err := runner.Run(cmd.Context())
-	if err != nil {
-		return fmt.Errorf("backup failed: %w", err)
+		logger.Info("waiting for the service's backup to finish", "status", status.Message)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(servicePollInterval):
+		}
 	}
+}
 
-	if !result.Success {
-		return fmt.Errorf("backup completed with errors")
+// serviceRunning returns true if the installed service is running.
+func serviceRunning(ctx context.Context) bool {
+	mgr := platform.NewServiceManager()
+	if !mgr.IsSupported() {
+		return false
 	}
+	status, err := mgr.Status(ctx)
+	return err == nil && status.State == platform.ServiceStateRunning
+}
 
-	logger.Info("backup completed successfully",
-		"duration", result.Duration,
-	)
+// delegateRun hands the backup of games, or a full backup, to the running
+// service through its control channel or else its HTTP server, which needs
+// the server's secret to accept it.
+func delegateRun(cmd *cobra.Command, cfg *config.Config, games []string) error {
+	path, body := "/run", any(nil)
+	if len(games) > 0 {
+		path, body = "/run/games", map[string][]string{"games": games}
+	}
 
+	var status *app.SchedulerStatus
+	var err error
+	switch {
+	case cfg.Control.Enabled:
+		status, err = callControl(cmd.Context(), cfg, http.MethodPost, path, body)
+		if err != nil {
+			return err
+		}
+	case cfg.Server.Enabled && cfg.Server.Secret != "":
+		status, err = callScheduler(cmd.Context(), cfg.Server, http.MethodPost, path, body)
+		if err != nil {
+			return fmt.Errorf("failed to reach the service at %s: %w", cfg.Server.ListenAddress, err)
+		}
+	default:
+		return errors.New("--via-service requires control.enabled, or server.enabled with server.secret, to reach the service")
+	}
+
+	writeHandedOver(cmd.OutOrStdout(), status)
 	return nil
 }
+
+// writeHandedOver reports a backup handed to the service, with the status
+// of its scheduler the service answered with.
+func writeHandedOver(w io.Writer, status *app.SchedulerStatus) {
+	fmt.Fprintf(w, "Backup handed to the service: %s\n", status.Message)
+	if len(status.Queued) > 0 {
+		fmt.Fprintf(w, "Queued: %s\n", strings.Join(status.Queued, ", "))
+	}
+	for _, game := range status.Quarantined {
+		fmt.Fprintf(w, "Quarantined: %s, retried at %s\n", game.Title, game.RetryAt.Local().Format("Jan 2 15:04"))
+	}
+}
+
+// gameTitles returns the game titles given to back up, trimmed, or an error
+// if there are none or one is empty.
+func gameTitles(titles []string) ([]string, error) {
+	if len(titles) == 0 {
+		return nil, errors.New("at least one game title is required")
+	}
+	games := make([]string, len(titles))
+	for i, title := range titles {
+		games[i] = strings.TrimSpace(title)
+		if games[i] == "" {
+			return nil, errors.New("game titles must not be empty")
+		}
+	}
+	return games, nil
+}
+
+// runExitCode returns the exit code for the outcome of a run, and the error
+// reported for it if it didn't succeed.
+func runExitCode(result *domain.RunResult) (int, error) {
+	switch {
+	case result.Success:
+		return exitOK, nil
+	case result.Offline && result.DestinationOffline():
+		return exitOK, nil
+	case result.DestinationOffline():
+		return exitDestinationOffline, errors.New("backup destination offline")
+	case result.AuthRequired():
+		return exitAuthRequired, errors.New("ludusavi cloud sign-in required")
+	case result.PartialSuccess:
+		return exitPartial, errors.New("backup partially failed, some backups were made")
+	default:
+		return exitBackupFailed, errors.New("backup completed with errors")
+	}
+}
+
+// writeFailures lists the failures of result, each with its error code and
+// what to do about it.
+func writeFailures(w io.Writer, result *domain.RunResult) {
+	for _, f := range result.Failures() {
+		what := f.Message
+		if f.Source != "" {
+			what = f.Source + ": " + what
+		}
+		fmt.Fprintf(w, "%s %s\n  %s\n", f.Code, what, f.Code.Hint())
+	}
+}
+
+// runOutcome is the outcome of a run written for schedulers and scripts.
+type runOutcome struct {
+	ExitCode int       `json:"exit_code"`
+	Status   string    `json:"status"`
+	RunID    string    `json:"run_id,omitempty"`
+	Offline  bool      `json:"offline,omitempty"`
+	Start    time.Time `json:"start_time,omitzero"`
+	End      time.Time `json:"end_time"`
+	Duration string    `json:"duration,omitempty"`
+	Errors   []string  `json:"errors,omitempty"`
+	// Failures are the failures of the run with their error codes.
+	Failures []domain.Failure `json:"failures,omitempty"`
+}
+
+// writeRunResult writes the outcome of a run to the result file. result is
+// nil when the run couldn't complete.
+func writeRunResult(logger *slog.Logger, result *domain.RunResult, code int, runErr error) {
+	path := runResultFile
+	if path == "" {
+		var err error
+		if path, err = config.DefaultRunResultPath(); err != nil {
+			logger.Warn("failed to determine run result path", "error", err)
+			return
+		}
+	}
+
+	out := runOutcome{ExitCode: code, Status: exitStatus(code), End: time.Now()}
+	if result != nil {
+		out.RunID = result.ID
+		out.Offline = result.Offline
+		out.Start, out.End = result.StartTime, result.EndTime
+		out.Duration = result.Duration.Round(time.Millisecond).String()
+		out.Errors = result.Errors
+		out.Failures = result.Failures()
+	}
+	if runErr != nil && len(out.Errors) == 0 {
+		out.Errors = []string{runErr.Error()}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0600)
+	}
+	if err != nil {
+		logger.Warn("failed to write run result", "path", path, "error", err)
+	}
+}
@@ -2,19 +2,49 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/app"
-	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
-	"github.com/sharkusmanch/ludusavi-runner/internal/http"
-	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/ipc"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+	"github.com/sharkusmanch/ludusavi-runner/internal/server"
 	"github.com/spf13/cobra"
 )
 
+// volumePollInterval is how often removable backup destinations are looked for.
+const volumePollInterval = 30 * time.Second
+
+// Bounds of the growth leaderboard's query parameters.
+const (
+	defaultGrowthDays  = 30
+	maxGrowthDays      = 365
+	defaultGrowthLimit = 10
+	maxGrowthLimit     = 100
+)
+
+// badgeRefreshInterval is how often the badge file is rewritten, so its age
+// stays current between runs.
+const badgeRefreshInterval = time.Minute
+
+var serveSelfTest bool
+
 // NewServeCmd creates the serve command.
 func NewServeCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -22,104 +52,462 @@ func NewServeCmd() *cobra.Command {
 		Short: "Run the service in foreground",
 		Long: `Run the backup service in foreground mode.
 
-This runs the scheduler loop, executing backups at the configured interval.
-Use Ctrl+C to stop.
+This runs the scheduler loop, executing backups at the configured interval
+or cron schedule. Use Ctrl+C to stop.
+
+This is useful for debugging or running in a container.
 
-This is useful for debugging or running in a container.`,
+With --selftest, or selftest.enabled set, every configured integration is
+exercised before the first run: a backup preview with ludusavi, a metrics
+push and a test notification. The service refuses to start if a component
+listed in selftest.critical fails, and starts with warnings otherwise.`,
 		RunE: runServe,
 	}
 
+	cmd.Flags().BoolVar(&serveSelfTest, "selftest", false, "exercise every configured integration before the first run")
+
 	return cmd
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
+	// Installed services are started as "serve --config <path>", so the
+	// service control manager hands us the same command line as the CLI.
+	if platform.IsRunningAsService() {
+		return platform.RunAsService(serve)
+	}
+
+	// Set up signal handling
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigCh
+		slog.Info("received signal, shutting down", "signal", sig)
+		cancel()
+	}()
+
+	return serve(ctx)
+}
+
+// serve loads the configuration and runs the scheduler until ctx is cancelled.
+func serve(ctx context.Context) error {
 	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	logger, err := setupLogging(cfg)
+	// Live events for the TUI are streamed by the HTTP server
+	var broker *events.Broker
+	var tee []io.Writer
+	if cfg.Server.Enabled {
+		broker = events.NewBroker()
+		tee = append(tee, events.LogWriter(broker))
+	}
+
+	logger, err := setupLogging(cfg, tee...)
 	if err != nil {
 		return fmt.Errorf("failed to setup logging: %w", err)
 	}
 	logger.Info("starting ludusavi-runner in foreground mode")
 
-	// Create HTTP client with retry config
-	httpClient := http.NewClient(
-		http.WithRetryConfig(http.RetryConfig{
-			MaxAttempts:  cfg.Retry.MaxAttempts,
-			InitialDelay: cfg.Retry.InitialDelay,
-			MaxDelay:     cfg.Retry.MaxDelay,
-		}),
-		http.WithLogger(logger),
-	)
+	exporter := newMetricsExporter(cfg)
+	counter := notify.NewCounter()
+	runner := newRunner(cfg, logger, exporter, counter)
 
-	// Create executor
-	execOpts := []executor.LudusaviOption{
-		executor.WithLogger(logger),
-	}
-	if cfg.LudusaviPath != "" {
-		execOpts = append(execOpts, executor.WithBinaryPath(cfg.LudusaviPath))
+	if path, warnings, err := lintLudusaviConfig(cfg, logger); err != nil {
+		logger.Debug("skipping ludusavi config check", "error", err)
+	} else {
+		for _, warning := range warnings {
+			logger.Warn("ludusavi config conflicts with the runner's", "path", path, "warning", warning)
+		}
 	}
-	if len(cfg.Env) > 0 {
-		execOpts = append(execOpts, executor.WithEnv(cfg.Env))
+
+	if serveSelfTest || cfg.SelfTest.Enabled {
+		if err := selfTest(ctx, runner, logger); err != nil {
+			return err
+		}
 	}
-	exec := executor.NewLudusaviExecutor(execOpts...)
 
-	// Create runner
-	runnerOpts := []app.RunnerOption{
-		app.WithExecutor(exec),
-		app.WithLogger(logger),
+	// Create scheduler
+	schedulerOpts := []app.SchedulerOption{
+		app.WithInterval(cfg.Interval),
+		app.WithFastInterval(cfg.FastInterval),
+		app.WithMinTimeBetweenRuns(cfg.MinTimeBetweenRuns),
+		app.WithBackupOnStartup(cfg.BackupOnStartup),
+		app.WithSchedulerLogger(logging.Component(logger, logging.ComponentScheduler)),
+	}
+	if broker != nil {
+		schedulerOpts = append(schedulerOpts, app.WithEvents(broker))
+	}
+	switch cfg.BackupOnShutdown {
+	case config.ShutdownBackupPreview:
+		schedulerOpts = append(schedulerOpts, app.WithShutdownBackup(
+			domain.BackupOptions{Preview: true}, cfg.ShutdownBackupTimeout))
+	case config.ShutdownBackupChanged:
+		schedulerOpts = append(schedulerOpts, app.WithShutdownBackup(
+			domain.BackupOptions{Force: true, ChangedOnly: true}, cfg.ShutdownBackupTimeout))
+	}
+	if cfg.Watchdog.Enabled {
+		schedulerOpts = append(schedulerOpts, app.WithWatchdog(cfg.Watchdog.RunTimeout))
 	}
+	if cfg.GameEvents.Enabled {
+		schedulerOpts = append(schedulerOpts, app.WithGameDebounce(cfg.GameEvents.Debounce))
+	}
+	for _, dest := range cfg.BackupDestinations {
+		if dest.HasVolume() {
+			schedulerOpts = append(schedulerOpts, app.WithVolumeWatch(volumePollInterval))
+			break
+		}
+	}
+	if schedule := cronSchedule(cfg); schedule != nil {
+		schedulerOpts = append(schedulerOpts, app.WithCronSchedule(schedule))
+	}
+	calendar := newCalendar(cfg, logging.Component(logger, logging.ComponentScheduler))
+	schedulerOpts = append(schedulerOpts, app.WithCalendar(calendar))
+	scheduler := app.NewScheduler(runner, schedulerOpts...)
 
-	// Create metrics pusher if enabled
-	if cfg.Metrics.Enabled {
-		metricsPusher := metrics.NewPushgatewayClient(
-			cfg.Metrics.PushgatewayURL,
-			metrics.WithHTTPClient(httpClient),
-			metrics.WithLogger(logger),
+	// Start the embedded HTTP server alongside the scheduler. A server
+	// failure is logged but never takes the backup service down with it.
+	serverCtx, stopServer := context.WithCancel(ctx)
+	defer stopServer()
+
+	var serverDone chan struct{}
+	if cfg.Server.Enabled {
+		srv := server.New(cfg.Server.ListenAddress,
+			server.WithDebug(cfg.Server.Debug),
+			server.WithLogger(logging.Component(logger, logging.ComponentServer)),
 		)
-		runnerOpts = append(runnerOpts, app.WithMetricsPusher(metricsPusher))
+		handleRuns(srv, scheduler, requireSecret(cfg.Server.Secret))
+		srv.Handle("GET /events", broker)
+		handleCalendar(srv, calendar, cfg.Location(), requireSecret(cfg.Server.Secret))
+		srv.Handle("GET /badge.svg", server.SVG(func() []byte { return runner.Badge().SVG() }))
+		// A GET, so the link in a notification acknowledges when opened
+		srv.Handle("GET /ack/{token}", server.Request(func(r *http.Request) (any, error) {
+			ack, err := runner.Acknowledge(r.PathValue("token"))
+			if errors.Is(err, app.ErrAckNotFound) {
+				return nil, server.NotFound(err)
+			}
+			return ack, err
+		}))
+		if cfg.HomeAssistant.Enabled && cfg.HomeAssistant.WebhookID != "" {
+			srv.Handle("POST /api/webhook/"+cfg.HomeAssistant.WebhookID,
+				server.Action(func() any { scheduler.Trigger(); return scheduler.Status() }))
+		}
+		if cfg.GameEvents.Enabled {
+			handleGameEvents(srv, scheduler, cfg.GameEvents.Secret)
+		}
+		if store := newHistory(cfg, logger); store != nil {
+			handleGrowth(srv, store)
+		}
+		serverDone = make(chan struct{})
+		go func() {
+			defer close(serverDone)
+			if err := srv.Start(serverCtx); err != nil {
+				logger.Error("http server error", "error", err)
+			}
+		}()
 	}
 
-	// Create notifier if enabled
-	if cfg.Apprise.Enabled {
-		notifier := notify.NewAppriseClient(
-			cfg.Apprise.URL,
-			cfg.Apprise.Key,
-			notify.WithHTTPClient(httpClient),
-			notify.WithLogger(logger),
-		)
-		runnerOpts = append(runnerOpts, app.WithNotifier(notifier))
+	var controlDone chan struct{}
+	if cfg.Control.Enabled {
+		controlDone = startControl(serverCtx, cfg, scheduler, logging.Component(logger, logging.ComponentControl))
 	}
 
-	runner := app.NewRunner(cfg, runnerOpts...)
+	var reloadDone chan struct{}
+	if cfg.WatchConfig && cfg.File != "" {
+		reloader := &configReloader{
+			active:    cfg,
+			runner:    runner,
+			scheduler: scheduler,
+			counter:   counter,
+			logger:    logging.Component(logger, logging.ComponentConfig),
+		}
+		reloadDone = reloader.watch(serverCtx)
+	}
 
-	// Create scheduler
-	scheduler := app.NewScheduler(runner,
-		app.WithInterval(cfg.Interval),
-		app.WithBackupOnStartup(cfg.BackupOnStartup),
-		app.WithSchedulerLogger(logger),
-	)
+	var metricsDone chan struct{}
+	if exporter != nil {
+		srv := server.New(cfg.Metrics.ListenAddress,
+			server.WithLogger(logging.Component(logger, logging.ComponentMetrics)),
+		)
+		srv.Handle("GET /metrics", exporter)
+		metricsDone = make(chan struct{})
+		go func() {
+			defer close(metricsDone)
+			if err := srv.Start(serverCtx); err != nil {
+				logger.Error("metrics server error", "error", err)
+			}
+		}()
+	}
 
-	// Set up signal handling
-	ctx, cancel := context.WithCancel(cmd.Context())
-	defer cancel()
+	if cfg.Badge.Path != "" {
+		go refreshBadge(serverCtx, runner, logger)
+	}
+	if reporter := newReporter(cfg, logger); reporter != nil {
+		go reporter.Run(serverCtx)
+	}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	// Start scheduler
+	err = scheduler.Start(ctx)
 
-	go func() {
-		sig := <-sigCh
-		logger.Info("received signal, shutting down", "signal", sig)
-		cancel()
-	}()
+	stopServer()
+	if serverDone != nil {
+		<-serverDone
+	}
+	if metricsDone != nil {
+		<-metricsDone
+	}
+	if controlDone != nil {
+		<-controlDone
+	}
+	if reloadDone != nil {
+		<-reloadDone
+	}
 
-	// Start scheduler
-	if err := scheduler.Start(ctx); err != nil && err != context.Canceled {
+	if err != nil && err != context.Canceled {
 		return fmt.Errorf("scheduler error: %w", err)
 	}
 
 	logger.Info("ludusavi-runner stopped")
 	return nil
 }
+
+// cronSchedule returns the cron schedule of full runs in the config's time
+// zone, or nil if they run at an interval. The schedule was checked by config
+// validation.
+func cronSchedule(cfg *config.Config) *cron.Schedule {
+	if cfg.Schedule == "" {
+		return nil
+	}
+	schedule, _ := cron.Parse(cfg.Schedule)
+	return schedule.In(cfg.Location())
+}
+
+// guard wraps the handlers of endpoints that change the service's state.
+type guard func(http.Handler) http.Handler
+
+// local passes every request on, for the control channel, which only the
+// same machine can reach.
+func local(next http.Handler) http.Handler {
+	return next
+}
+
+// requireSecret returns the guard of the HTTP server, which other machines
+// may reach: requests must be authenticated with secret, and without one
+// they are refused, leaving them to the control channel.
+func requireSecret(secret string) guard {
+	return func(next http.Handler) http.Handler {
+		if secret == "" {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "set server.secret to use this endpoint, or use the control channel", http.StatusForbidden)
+			})
+		}
+		return server.Authenticated(secret, next)
+	}
+}
+
+// handleRuns serves the scheduler status and the endpoints starting a
+// backup now and pausing scheduled ones, on the HTTP server and the control
+// channel alike. The latter are wrapped in guarded, as fits the server.
+func handleRuns(srv *server.Server, scheduler *app.Scheduler, guarded guard) {
+	srv.Handle("GET /status", server.JSON(func() any { return scheduler.Status() }))
+	srv.Handle("POST /pause", guarded(server.Action(func() any { scheduler.Pause(); return scheduler.Status() })))
+	srv.Handle("POST /resume", guarded(server.Action(func() any { scheduler.Resume(); return scheduler.Status() })))
+	srv.Handle("POST /run", guarded(server.Action(func() any { scheduler.Trigger(); return scheduler.Status() })))
+	srv.Handle("POST /run/games", guarded(server.Request(func(r *http.Request) (any, error) {
+		var run struct {
+			Games []string `json:"games"`
+		}
+		if err := server.DecodeJSON(r, &run); err != nil {
+			return nil, err
+		}
+		games, err := gameTitles(run.Games)
+		if err != nil {
+			return nil, err
+		}
+		scheduler.TriggerGames(games...)
+		return scheduler.Status(), nil
+	})))
+}
+
+// handleRestore registers the endpoint the restore command hands restores
+// to, answering once the restore is done. It is only served on the control
+// channel, which other machines can't reach.
+func handleRestore(srv *server.Server, scheduler *app.Scheduler) {
+	srv.Handle("POST /restore", server.Request(func(r *http.Request) (any, error) {
+		var restore restoreRequest
+		if err := server.DecodeJSON(r, &restore); err != nil {
+			return nil, err
+		}
+		// Confirmed by the restore command, as ludusavi can't ask with --api
+		result, err := scheduler.Restore(r.Context(), domain.RestoreOptions{Games: restore.Games, Force: true})
+		if err != nil {
+			return nil, err
+		}
+		return newRestoreResponse(result), nil
+	}))
+}
+
+// startControl opens the control channel, for the trigger command, and
+// serves it until ctx is cancelled. It returns a channel closed once it
+// stopped, or nil if it couldn't be opened: like the HTTP server, the
+// control channel never takes the backup service down with it.
+func startControl(ctx context.Context, cfg *config.Config, scheduler *app.Scheduler, logger *slog.Logger) chan struct{} {
+	path, err := cfg.ControlPath()
+	if err != nil {
+		logger.Error("failed to determine control channel path", "error", err)
+		return nil
+	}
+	listener, err := ipc.Listen(path)
+	if err != nil {
+		logger.Error("failed to open control channel", "error", err)
+		return nil
+	}
+
+	srv := server.New(path, server.WithLogger(logger))
+	handleRuns(srv, scheduler, local)
+	handleRestore(srv, scheduler)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := srv.Serve(ctx, listener); err != nil {
+			logger.Error("control channel error", "error", err)
+		}
+	}()
+	return done
+}
+
+// selfTest runs the startup self-test and logs its checks. It returns an
+// error if a critical component failed.
+func selfTest(ctx context.Context, runner *app.Runner, logger *slog.Logger) error {
+	logger.Info("running startup self-test")
+	checks, err := runner.SelfTest(ctx)
+	for _, check := range checks {
+		attrs := []any{"component", check.Component, "duration", check.Duration.Round(time.Millisecond)}
+		switch {
+		case check.Err == nil:
+			logger.Info("self-test passed", append(attrs, "detail", check.Detail)...)
+		case check.Critical:
+			logger.Error("self-test failed", append(attrs, "error", check.Err)...)
+		default:
+			logger.Warn("self-test failed, starting degraded", append(attrs, "error", check.Err)...)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("startup self-test failed: %w", err)
+	}
+	return nil
+}
+
+// handleCalendar registers the control API endpoints listing, adding and
+// removing calendar exceptions, the latter two wrapped in guarded. Times are
+// read in loc, as in the config file.
+func handleCalendar(srv *server.Server, calendar *app.Calendar, loc *time.Location, guarded guard) {
+	srv.Handle("GET /calendar", server.JSON(func() any { return calendar.List() }))
+	srv.Handle("POST /calendar/skip", guarded(server.Request(func(r *http.Request) (any, error) {
+		var skip config.CalendarSkipConfig
+		if err := server.DecodeJSON(r, &skip); err != nil {
+			return nil, err
+		}
+		start, end, err := skip.Window(loc)
+		if err != nil {
+			return nil, err
+		}
+		return calendar.Add(app.CalendarException{Kind: app.CalendarSkip, Start: start, End: end, Reason: skip.Reason})
+	})))
+	srv.Handle("POST /calendar/runs", guarded(server.Request(func(r *http.Request) (any, error) {
+		var run config.CalendarRunConfig
+		if err := server.DecodeJSON(r, &run); err != nil {
+			return nil, err
+		}
+		at, err := run.Time(loc)
+		if err != nil {
+			return nil, err
+		}
+		return calendar.Add(app.CalendarException{Kind: app.CalendarRun, Start: at, Reason: run.Reason})
+	})))
+	srv.Handle("DELETE /calendar/{id}", guarded(server.Request(func(r *http.Request) (any, error) {
+		if err := calendar.Remove(r.PathValue("id")); err != nil {
+			if errors.Is(err, app.ErrCalendarNotFound) {
+				return nil, server.NotFound(err)
+			}
+			return nil, err
+		}
+		return calendar.List(), nil
+	})))
+}
+
+// handleGrowth registers the endpoint ranking the games whose saves grew the
+// most over the last days, e.g. GET /games/growth?days=30&limit=10.
+func handleGrowth(srv *server.Server, store *history.Store) {
+	srv.Handle("GET /games/growth", server.Request(func(r *http.Request) (any, error) {
+		days, err := queryInt(r, "days", defaultGrowthDays, maxGrowthDays)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := queryInt(r, "limit", defaultGrowthLimit, maxGrowthLimit)
+		if err != nil {
+			return nil, err
+		}
+		board, err := store.Leaderboard(time.Now().AddDate(0, 0, -days), limit)
+		if err != nil {
+			return nil, server.Internal(err)
+		}
+		// An empty list rather than null when no saves grew
+		return append([]history.Growth{}, board...), nil
+	}))
+}
+
+// queryInt reads the integer query parameter name, from 1 to upTo, or def if
+// it isn't set.
+func queryInt(r *http.Request, name string, def, upTo int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > upTo {
+		return 0, fmt.Errorf("%s must be a number from 1 to %d", name, upTo)
+	}
+	return n, nil
+}
+
+// handleGameEvents registers the endpoints game launchers report sessions
+// to, authenticated with secret.
+func handleGameEvents(srv *server.Server, scheduler *app.Scheduler, secret string) {
+	srv.Handle("POST /api/v1/events/game-stopped", server.Authenticated(secret, server.Request(func(r *http.Request) (any, error) {
+		var event struct {
+			Title string `json:"title"`
+		}
+		if err := server.DecodeJSON(r, &event); err != nil {
+			return nil, err
+		}
+		title := strings.TrimSpace(event.Title)
+		if title == "" {
+			return nil, errors.New("title is required")
+		}
+		scheduler.TriggerGame(title)
+		return scheduler.Status(), nil
+	})))
+}
+
+// refreshBadge rewrites the badge file until ctx is cancelled.
+func refreshBadge(ctx context.Context, runner *app.Runner, logger *slog.Logger) {
+	ticker := time.NewTicker(badgeRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := runner.WriteBadge(); err != nil {
+			logger.Warn("failed to write status badge", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,9 +1,23 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
 	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +25,9 @@ import (
 var (
 	installUsername string
 	installPassword string
+	installUpdate   bool
+	statusVerbose   bool
+	serviceScope    string
 )
 
 // NewInstallCmd creates the install command.
@@ -21,18 +38,40 @@ func NewInstallCmd() *cobra.Command {
 		Long: `Install ludusavi-runner as a system service.
 
 On Windows, this installs a Windows Service.
-On Linux, this would install a systemd unit (not yet implemented).
-On macOS, this would install a launchd plist (not yet implemented).`,
+On Linux, this installs a systemd unit.
+On macOS, this installs a launchd job.
+
+By default (--scope user), the service runs as the current user, or the one
+given with --username, so it sees their game saves, ludusavi config and
+network drives; on Windows this needs the account's --password. On Linux,
+this is a unit of your user's systemd instance, which runs while you are
+logged in unless lingering is enabled with 'loginctl enable-linger'; another
+--username gets a system unit running as that user, which needs root. On
+macOS, it is a LaunchAgent, which runs while you are logged in, and another
+--username gets a LaunchDaemon running as that user. With --scope system, it
+runs as the system account (LocalSystem on Windows, root on Linux and macOS)
+instead.
+
+With --update, the installed service is pointed at this executable and the
+config file given with --config (or the default one) instead, keeping its
+account unless --username is given. Restart the service to apply it.`,
 		RunE: runInstall,
 	}
 
-	cmd.Flags().StringVar(&installUsername, "username", "", "username to run the service as (Windows)")
+	cmd.Flags().StringVar(&installUsername, "username", "", "username to run the service as")
 	cmd.Flags().StringVar(&installPassword, "password", "", "password for the service account (Windows)")
+	cmd.Flags().BoolVar(&installUpdate, "update", false, "rewrite the command line of the installed service")
+	addScopeFlag(cmd)
 
 	return cmd
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
+	scope, err := parseScope()
+	if err != nil {
+		return err
+	}
+
 	mgr := platform.NewServiceManager()
 
 	if !mgr.IsSupported() {
@@ -40,44 +79,178 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate: if username is specified, password is required
-	if installUsername != "" && installPassword == "" {
+	if runtime.GOOS == "windows" && installUsername != "" && installPassword == "" {
 		return fmt.Errorf("--password is required when --username is specified")
 	}
 
 	// Resolve config path - if not specified, use the default path for the current user.
 	// This is important because services may run as a different user (e.g., LocalSystem)
 	// which would have a different default config path.
-	configPath := cfgFile
-	if configPath == "" {
-		var err error
-		configPath, err = config.DefaultConfigPath()
-		if err != nil {
-			return fmt.Errorf("failed to determine default config path: %w", err)
-		}
+	configPath, err := resolveConfigPath()
+	if err != nil {
+		return err
 	}
 
 	opts := platform.InstallOptions{
+		Scope:      scope,
 		Username:   installUsername,
 		Password:   installPassword,
 		ConfigPath: configPath,
+		Portable:   portable,
 		AutoStart:  true,
 	}
 
+	if installUpdate {
+		// The service keeps its account unless asked to change it
+		if !cmd.Flags().Changed("scope") && installUsername == "" {
+			opts.Scope = ""
+		}
+		if err := mgr.Update(cmd.Context(), opts); err != nil {
+			return fmt.Errorf("failed to update service: %w", err)
+		}
+		fmt.Println("Service updated successfully.")
+		fmt.Printf("Config file: %s\n", configPath)
+		fmt.Println("Use 'ludusavi-runner stop' and 'ludusavi-runner start' to apply it.")
+		return nil
+	}
+
 	if err := mgr.Install(cmd.Context(), opts); err != nil {
 		return fmt.Errorf("failed to install service: %w", err)
 	}
 
 	fmt.Println("Service installed successfully.")
 	fmt.Printf("Config file: %s\n", configPath)
-	if installUsername != "" {
+	switch {
+	case scope == platform.ServiceScopeSystem:
+		fmt.Printf("Service will run as: %s\n", systemAccount())
+	case installUsername != "":
 		fmt.Printf("Service will run as: %s\n", installUsername)
-	} else {
-		fmt.Println("Service will run as: LocalSystem")
+	default:
+		fmt.Println("Service will run as: the current user")
 	}
 	fmt.Println("Use 'ludusavi-runner start' to start the service.")
 	return nil
 }
 
+// printServiceDetails prints how the installed service is started, next to
+// how this command runs where they differ.
+func printServiceDetails(status *platform.ServiceStatus) {
+	if status.BinaryPath == "" {
+		return
+	}
+
+	fmt.Printf("Binary: %s\n", status.BinaryPath)
+	if exe, err := os.Executable(); err == nil && !platform.SamePath(exe, status.BinaryPath) {
+		fmt.Printf("  (this command runs %s)\n", exe)
+	}
+	if _, err := os.Stat(status.BinaryPath); err != nil {
+		fmt.Printf("  Warning: %v\n", err)
+	}
+	fmt.Printf("Arguments: %s\n", strings.Join(status.Args, " "))
+
+	account := status.Account
+	if account == "" || strings.EqualFold(account, "LocalSystem") {
+		account = "LocalSystem (its user profile, environment and network drives differ from yours)"
+	}
+	fmt.Printf("Runs as: %s\n", account)
+	if status.StartType != "" {
+		fmt.Printf("Start type: %s\n", status.StartType)
+	}
+
+	if status.ConfigPath == "" {
+		fmt.Println("Config file: the default one of the service account")
+	} else if _, err := os.Stat(status.ConfigPath); err != nil {
+		fmt.Printf("Warning: config file: %v\n", err)
+	}
+}
+
+// systemAccount returns the name of the account services run as in the
+// system scope.
+func systemAccount() string {
+	if runtime.GOOS == "windows" {
+		return "LocalSystem"
+	}
+	return "root"
+}
+
+// parseScope returns the service scope selected with --scope.
+func parseScope() (platform.ServiceScope, error) {
+	scope, err := domain.ParseServiceScope(serviceScope)
+	if err != nil {
+		return "", fmt.Errorf("--scope: %w", err)
+	}
+	return scope, nil
+}
+
+// addScopeFlag adds the --scope flag to a service command.
+func addScopeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&serviceScope, "scope", string(platform.ServiceScopeUser),
+		"run the service as the current user (user) or as the system account (system)")
+}
+
+// checkServiceScope returns an error if --scope was given and the installed
+// service is in another scope, so a command doesn't act on a service other
+// than the one meant.
+func checkServiceScope(cmd *cobra.Command, mgr platform.ServiceManager) error {
+	scope, err := parseScope()
+	if err != nil || !cmd.Flags().Changed("scope") {
+		return err
+	}
+	status, err := mgr.Status(cmd.Context())
+	if err != nil || status.Scope == "" || status.Scope == scope {
+		return nil
+	}
+	return fmt.Errorf("the installed service is in the %s scope, not %s", status.Scope, scope)
+}
+
+// resolveConfigPath returns the absolute path of the config file the CLI
+// uses: the one given with --config, or the default one.
+func resolveConfigPath() (string, error) {
+	configPath := cfgFile
+	if configPath == "" {
+		var err error
+		configPath, err = config.DefaultConfigPath()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine default config path: %w", err)
+		}
+	}
+	// The service doesn't start in the current directory
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	return configPath, nil
+}
+
+// serviceConfigMismatch returns a warning if the installed service uses a
+// different config file than this command, whose changes the service then
+// won't see, or "" if it doesn't or that can't be told.
+func serviceConfigMismatch(status *platform.ServiceStatus) string {
+	if status == nil || status.ConfigPath == "" {
+		return ""
+	}
+	configPath, err := resolveConfigPath()
+	if err != nil || platform.SamePath(configPath, status.ConfigPath) {
+		return ""
+	}
+	return fmt.Sprintf("the installed service uses %s, not %s; "+
+		"run 'ludusavi-runner install --update' to point it at this config", status.ConfigPath, configPath)
+}
+
+// installedServiceConfigMismatch returns a warning if the installed service
+// uses a different config file than this command, or "" if it doesn't.
+func installedServiceConfigMismatch(ctx context.Context) string {
+	mgr := platform.NewServiceManager()
+	if !mgr.IsSupported() {
+		return ""
+	}
+	status, err := mgr.Status(ctx)
+	if err != nil {
+		return ""
+	}
+	return serviceConfigMismatch(status)
+}
+
 // NewUninstallCmd creates the uninstall command.
 func NewUninstallCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -86,6 +259,7 @@ func NewUninstallCmd() *cobra.Command {
 		Long:  `Remove the ludusavi-runner system service.`,
 		RunE:  runUninstall,
 	}
+	addScopeFlag(cmd)
 
 	return cmd
 }
@@ -97,6 +271,10 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("service management is not supported on this platform")
 	}
 
+	if err := checkServiceScope(cmd, mgr); err != nil {
+		return err
+	}
+
 	if err := mgr.Uninstall(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to uninstall service: %w", err)
 	}
@@ -113,6 +291,7 @@ func NewStartCmd() *cobra.Command {
 		Long:  `Start the ludusavi-runner system service.`,
 		RunE:  runStart,
 	}
+	addScopeFlag(cmd)
 
 	return cmd
 }
@@ -124,6 +303,14 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("service management is not supported on this platform")
 	}
 
+	if err := checkServiceScope(cmd, mgr); err != nil {
+		return err
+	}
+
+	if warning := installedServiceConfigMismatch(cmd.Context()); warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
 	if err := mgr.Start(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
@@ -140,6 +327,7 @@ func NewStopCmd() *cobra.Command {
 		Long:  `Stop the ludusavi-runner system service.`,
 		RunE:  runStop,
 	}
+	addScopeFlag(cmd)
 
 	return cmd
 }
@@ -151,6 +339,10 @@ func runStop(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("service management is not supported on this platform")
 	}
 
+	if err := checkServiceScope(cmd, mgr); err != nil {
+		return err
+	}
+
 	if err := mgr.Stop(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to stop service: %w", err)
 	}
@@ -164,14 +356,28 @@ func NewStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show service status",
-		Long:  `Display the current status of the ludusavi-runner system service.`,
-		RunE:  runStatus,
+		Long: `Display the current status of the ludusavi-runner system service.
+
+With --verbose, also show the command line the service is started with: the
+binary, its arguments, the config file and the account it runs as, to tell
+why something that works from a shell fails as a service, and the
+scheduler's latest decisions about runs: when each was queued, skipped and
+why, or ran, to tell why a backup didn't run at some time.`,
+		RunE: runStatus,
 	}
 
+	cmd.Flags().BoolVarP(&statusVerbose, "verbose", "v", false, "show the service's command line, account and config file, and recent scheduler decisions")
+	addScopeFlag(cmd)
+
 	return cmd
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	scope, err := parseScope()
+	if err != nil {
+		return err
+	}
+
 	mgr := platform.NewServiceManager()
 
 	if !mgr.IsSupported() {
@@ -184,6 +390,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Service Status: %s\n", status.State)
+	if status.Scope != "" {
+		fmt.Printf("Scope: %s\n", status.Scope)
+		if cmd.Flags().Changed("scope") && status.Scope != scope {
+			fmt.Printf("Warning: the installed service is in the %s scope, not %s\n", status.Scope, scope)
+		}
+	}
 	if status.PID > 0 {
 		fmt.Printf("PID: %d\n", status.PID)
 	}
@@ -193,6 +405,125 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if status.Message != "" {
 		fmt.Printf("Message: %s\n", status.Message)
 	}
+	if status.ConfigPath != "" {
+		fmt.Printf("Config file: %s\n", status.ConfigPath)
+	}
+	if status.Portable {
+		fmt.Println("Portable: yes")
+	}
+	if statusVerbose {
+		printServiceDetails(status)
+	}
+	if warning := serviceConfigMismatch(status); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	// The scheduler state is only available from the service itself
+	if status.State == platform.ServiceStateRunning || status.State == platform.ServiceStateStopping {
+		if cfg, err := loadConfig(); err == nil {
+			if scheduler, err := querySchedulerStatus(cmd.Context(), cfg); err == nil {
+				fmt.Printf("Scheduler: %s\n", scheduler.Message)
+				// The message is of the run in progress, if any
+				if scheduler.Paused && scheduler.State != app.SchedulerStatePaused {
+					fmt.Println("Scheduled backups: paused")
+				}
+				if statusVerbose {
+					printTicks(scheduler.Ticks)
+				}
+			}
+		}
+	}
 
 	return nil
 }
+
+// printTicks prints the scheduler's latest decisions about runs, oldest
+// first, in local time.
+func printTicks(ticks []app.SchedulerTick) {
+	if len(ticks) == 0 {
+		return
+	}
+	fmt.Println("Recent scheduler decisions:")
+	for _, tick := range ticks {
+		decision := string(tick.Decision)
+		if tick.Decision == app.DecisionRan && tick.Outcome != "" {
+			duration := time.Duration(tick.DurationSeconds * float64(time.Second)).Round(time.Second)
+			decision = fmt.Sprintf("ran in %s, %s", duration, tick.Outcome)
+		}
+		if tick.Reason != "" {
+			decision += " (" + tick.Reason + ")"
+		}
+		fmt.Printf("  %s  %s: %s\n", tick.At.Local().Format("2006-01-02 15:04:05"), tick.Job, decision)
+	}
+}
+
+// querySchedulerStatus fetches the scheduler status from the service,
+// through the control channel or else the embedded server.
+func querySchedulerStatus(ctx context.Context, cfg *config.Config) (*app.SchedulerStatus, error) {
+	if cfg.Control.Enabled {
+		if status, err := callControl(ctx, cfg, http.MethodGet, "/status", nil); err == nil {
+			return status, nil
+		}
+	}
+	if !cfg.Server.Enabled {
+		return nil, errors.New("neither the control channel nor the server is enabled")
+	}
+	return callScheduler(ctx, cfg.Server, http.MethodGet, "/status", nil)
+}
+
+// callScheduler calls an endpoint of the embedded server answering with the
+// scheduler status, sending body as JSON if not nil, authenticated with the
+// server's secret if set.
+func callScheduler(ctx context.Context, server config.ServerConfig, method, path string, body any) (*app.SchedulerStatus, error) {
+	host, port, err := net.SplitHostPort(server.ListenAddress)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return sendScheduler(ctx, http.DefaultClient, server.Secret, method, "http://"+net.JoinHostPort(host, port)+path, body)
+}
+
+// sendScheduler sends a request to url, an endpoint answering with the
+// scheduler status, with client, sending body as JSON if not nil and secret
+// as a bearer token if set.
+func sendScheduler(ctx context.Context, client *http.Client, secret, method, url string, body any) (*app.SchedulerStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s endpoint returned %d", strings.TrimPrefix(req.URL.Path, "/"), resp.StatusCode)
+	}
+
+	var status app.SchedulerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
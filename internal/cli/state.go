@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/bundle"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stateOutput string
+	stateForce  bool
+)
+
+// portableStatePaths are the state files moved by state export: the run
+// history and the baselines checks compare runs against. Caches and files
+// describing this machine, such as the scan cache and the outbox, are left
+// out.
+var portableStatePaths = []func() (string, error){
+	config.DefaultHistoryPath,
+	config.DefaultGameCountStatePath,
+	config.DefaultFailureStatePath,
+	config.DefaultDestinationStatePath,
+	config.DefaultCalendarStatePath,
+	config.DefaultReportStatePath,
+	config.DefaultMaintenancePath,
+}
+
+// NewStateCmd creates the state command.
+func NewStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Export or import the config and state for moving to another machine",
+	}
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Bundle the config and state into a portable archive",
+		Long: `Bundle the config file and state into a portable archive: the run history,
+the game count baseline, failure, calendar and report state, so a new
+machine carries on with the same history and baselines.
+
+Credentials are left out of the config file: tokens, passwords, secrets,
+request headers and credentials in URLs. Set them again after importing.
+The config file is re-encoded, so its comments are not kept.`,
+		Args: cobra.NoArgs,
+		RunE: runStateExport,
+	}
+	export.Flags().StringVarP(&stateOutput, "output", "o", "", "path of the archive (default: ludusavi-runner-<host>-<date>.tar.gz)")
+
+	imp := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Restore the config and state from an archive",
+		Long: `Restore the config file and state from an archive made by state export.
+
+Stop the service first. Existing files are not overwritten unless --force is
+given. Credentials left out by the export must be set again in the config.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runStateImport,
+	}
+	imp.Flags().BoolVar(&stateForce, "force", false, "overwrite the existing config file and state")
+
+	cmd.AddCommand(export, imp)
+	return cmd
+}
+
+func runStateExport(cmd *cobra.Command, args []string) error {
+	hostname, _ := os.Hostname()
+	b := &bundle.Bundle{
+		Manifest: bundle.Manifest{Created: time.Now().UTC().Round(time.Second), Hostname: hostname},
+		State:    make(map[string][]byte),
+	}
+
+	configPath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		b.Config, b.Redacted, err = config.Redact(configPath)
+		if err != nil {
+			return err
+		}
+		b.Manifest.Config = filepath.Base(configPath)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	paths, err := statePaths()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read state: %w", err)
+		}
+		b.State[filepath.Base(path)] = data
+	}
+
+	output := stateOutput
+	if output == "" {
+		output = fmt.Sprintf("%s-%s-%s.tar.gz", config.AppName, hostname, b.Created.Format(time.DateOnly))
+	}
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	if err := b.Write(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(output)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	fmt.Printf("Exported the config file and %d state files to %s\n", len(b.State), output)
+	if len(b.Redacted) > 0 {
+		fmt.Printf("Credentials left out, set them again after importing: %s\n", strings.Join(b.Redacted, ", "))
+	}
+	return nil
+}
+
+func runStateImport(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+	b, err := bundle.Read(f)
+	if err != nil {
+		return err
+	}
+
+	// Where each file of the bundle goes
+	targets := make(map[string][]byte)
+	if b.Manifest.Config != "" {
+		configPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(b.Manifest.Config); !strings.EqualFold(ext, filepath.Ext(configPath)) {
+			return fmt.Errorf("the bundled config file is %s, pass --config with a path ending in %s", b.Manifest.Config, ext)
+		}
+		targets[configPath] = b.Config
+	}
+	paths, err := statePaths()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if data, ok := b.State[filepath.Base(path)]; ok {
+			targets[path] = data
+		}
+	}
+
+	if !stateForce {
+		var existing []string
+		for path := range targets {
+			if _, err := os.Stat(path); err == nil {
+				existing = append(existing, path)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to overwrite %s, use --force to overwrite", strings.Join(existing, ", "))
+		}
+	}
+
+	var errs []error
+	for path, data := range targets {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create directory: %w", err))
+			continue
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %s: %w", path, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d files exported from %s on %s\n", len(targets), b.Hostname, b.Created.Local().Format(time.DateTime))
+	if len(b.Redacted) > 0 {
+		fmt.Printf("Set these credentials again in the config file: %s\n", strings.Join(b.Redacted, ", "))
+	}
+	return nil
+}
+
+// configFilePath returns the path of the config file, from --config or the
+// default location.
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	path, err := config.DefaultConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine config path: %w", err)
+	}
+	return path, nil
+}
+
+// statePaths returns the paths of the state files moved by state export.
+func statePaths() ([]string, error) {
+	var paths []string
+	for _, statePath := range portableStatePaths {
+		path, err := statePath()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine state directory: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
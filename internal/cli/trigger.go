@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/ipc"
+	"github.com/spf13/cobra"
+)
+
+var triggerGames []string
+
+// NewTriggerCmd creates the trigger command.
+func NewTriggerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trigger",
+		Short: "Ask the running service to start a backup now",
+		Long: `Ask the running service to start a backup now, instead of waiting for the
+next interval. The request goes through the service's control channel, a Unix
+socket or a named pipe on Windows (control.enabled), so it works without the
+HTTP server. The backup runs in the service like a scheduled one, and the
+command returns once the service has it.
+
+With --game, only the named games are backed up:
+
+  ludusavi-runner trigger --game "Hades"
+
+A backup already running isn't interrupted: the new one waits in the
+service's queue, which is printed.`,
+		Args: cobra.NoArgs,
+		RunE: runTrigger,
+	}
+
+	cmd.Flags().StringArrayVar(&triggerGames, "game", nil, "back up only the game with this title (repeatable)")
+
+	return cmd
+}
+
+func runTrigger(cmd *cobra.Command, args []string) error {
+	var games []string
+	if cmd.Flags().Changed("game") {
+		var err error
+		if games, err = gameTitles(triggerGames); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var status *app.SchedulerStatus
+	if len(games) > 0 {
+		status, err = callControl(cmd.Context(), cfg, http.MethodPost, "/run/games", map[string][]string{"games": games})
+	} else {
+		status, err = callControl(cmd.Context(), cfg, http.MethodPost, "/run", nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	writeHandedOver(cmd.OutOrStdout(), status)
+	return nil
+}
+
+// callControl sends a request to path on the service's control channel,
+// sending body as JSON if not nil, and returns the scheduler status the
+// service answered with.
+func callControl(ctx context.Context, cfg *config.Config, method, path string, body any) (*app.SchedulerStatus, error) {
+	socket, err := cfg.ControlPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine control channel path: %w", err)
+	}
+	status, err := sendScheduler(ctx, ipc.NewClient(socket), "", method, ipc.URL(path), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the service at %s (is it running, with control.enabled?): %w", socket, err)
+	}
+	return status, nil
+}
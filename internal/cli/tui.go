@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var tuiAddress string
+
+// NewTUICmd creates the tui command.
+func NewTUICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Show a live dashboard of the running service",
+		Long: `Show a live dashboard of the running service: scheduler status, the
+latest result of each operation, the games whose saves grew the most over
+the last 30 days, and recent log lines.
+
+The dashboard connects to the service's HTTP server, which must be enabled
+with [server] in the config, and reconnects when the service restarts.
+Running a backup or pausing needs server.secret to be set in the config.
+
+Keys:
+  r  run a full backup now
+  p  pause or resume scheduled backups
+  q  quit`,
+		RunE: runTUI,
+	}
+
+	cmd.Flags().StringVar(&tuiAddress, "address", "", "address of the service's HTTP server (default: server.listen_address from the config)")
+
+	return cmd
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	address := tuiAddress
+	if address == "" {
+		address = cfg.Server.ListenAddress
+	}
+
+	return tui.Run(cmd.Context(), "http://"+address, cfg.Server.Secret)
+}
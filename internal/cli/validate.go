@@ -1,18 +1,37 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/config"
 	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
 	"github.com/sharkusmanch/ludusavi-runner/internal/http"
-	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
-	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
 	"github.com/spf13/cobra"
 )
 
+var (
+	validateFix     bool
+	validateYes     bool
+	validateJSON    bool
+	validateTimeout time.Duration
+)
+
 // NewValidateCmd creates the validate command.
 func NewValidateCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -23,113 +42,543 @@ func NewValidateCmd() *cobra.Command {
 This checks:
 - Config file syntax
 - Ludusavi binary availability
+- Ludusavi's own config, for settings conflicting with the runner's, such
+  as ludusavi syncing to the cloud itself (reported as warnings)
 - Pushgateway connectivity
-- Apprise server connectivity (if enabled)`,
+- Apprise server connectivity (if enabled)
+- Notification webhook template and connectivity (if enabled)
+- Archive destination connectivity (if enabled)
+
+The checks run in parallel, each failing once --timeout passes.
+
+With --fix, common problems are fixed and the checks run again:
+- A missing config file is created from the example config
+- Obviously wrong URL schemes, such as "htp://" or none at all, are corrected
+- A missing ludusavi binary is downloaded from GitHub, after confirmation
+
+The exit code is non-zero when any check fails. With --json, a report of
+each check and its status is printed instead, for provisioning scripts.`,
 		RunE: runValidate,
 	}
+	cmd.Flags().BoolVar(&validateFix, "fix", false, "fix common problems and re-run the checks")
+	cmd.Flags().BoolVarP(&validateYes, "yes", "y", false, "don't ask for confirmation before downloading ludusavi")
+	cmd.Flags().BoolVar(&validateJSON, "json", false, "output a report in JSON format")
+	cmd.Flags().DurationVar(&validateTimeout, "timeout", 15*time.Second, "timeout of each check")
 
 	return cmd
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	// With --json, only the report goes to stdout
+	var text, fixOut io.Writer = os.Stdout, os.Stdout
+	if validateJSON {
+		text, fixOut = io.Discard, os.Stderr
+	}
+
+	report := validate(cmd.Context(), text)
+	if validateFix {
+		fmt.Fprintln(fixOut)
+		fmt.Fprintln(fixOut, "Fixes:")
+		if fixed := fixProblems(cmd.Context(), fixOut); fixed == 0 {
+			fmt.Fprintln(fixOut, "  Nothing to fix.")
+		} else {
+			fmt.Fprintln(fixOut)
+			fmt.Fprintln(fixOut, "Re-running checks...")
+			fmt.Fprintln(text)
+			report = validate(cmd.Context(), text)
+		}
+	}
+
+	if validateJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal validation report: %w", err)
+		}
+		fmt.Println(string(data))
+	}
+	return report.err()
+}
+
+// Statuses of validate checks.
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+	// checkWarning is a problem that doesn't fail validation.
+	checkWarning = "warning"
+)
+
+// validateCheck is the result of a single validate check.
+type validateCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// validateReport collects the results of the validate checks, printing each
+// to out as it is added.
+type validateReport struct {
+	ConfigFile string          `json:"config_file"`
+	Valid      bool            `json:"valid"`
+	Checks     []validateCheck `json:"checks"`
+
+	out io.Writer
+}
+
+// add records the result of a check.
+func (r *validateReport) add(c validateCheck) {
+	r.Checks = append(r.Checks, c)
+	switch c.Status {
+	case checkOK:
+		fmt.Fprintf(r.out, "  ✓ %s: %s\n", c.Name, c.Detail)
+	case checkWarning:
+		fmt.Fprintf(r.out, "  ! %s: %s\n", c.Name, c.Detail)
+	default:
+		r.Valid = false
+		fmt.Fprintf(r.out, "  ✗ %s: %s\n", c.Name, c.Detail)
+	}
+}
+
+// pass records a passed check.
+func (r *validateReport) pass(name, detail string) {
+	r.add(validateCheck{Name: name, Status: checkOK, Detail: detail})
+}
+
+// warn records a check that found a problem without failing.
+func (r *validateReport) warn(name, detail string) {
+	r.add(validateCheck{Name: name, Status: checkWarning, Detail: detail})
+}
+
+// fail records a failed check.
+func (r *validateReport) fail(name string, err error) {
+	r.add(validateCheck{Name: name, Status: checkFailed, Detail: err.Error()})
+}
+
+// check records a check that passed with detail unless err is set.
+func (r *validateReport) check(name string, err error, detail string) {
+	if err != nil {
+		r.fail(name, err)
+	} else {
+		r.pass(name, detail)
+	}
+}
+
+// err returns an error if any check failed.
+func (r *validateReport) err() error {
+	failed := 0
+	for _, c := range r.Checks {
+		if c.Status == checkFailed {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d checks failed", failed, len(r.Checks))
+}
+
+// validateTask is a named group of checks run by validate, recording its
+// results in r.
+type validateTask struct {
+	name string
+	run  func(ctx context.Context, r *validateReport)
+}
+
+// runTasks runs tasks concurrently, each with its own timeout so a slow
+// endpoint can't hold up the rest, and adds their results to r in order.
+func (r *validateReport) runTasks(ctx context.Context, tasks []validateTask) {
+	results := make([][]validateCheck, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runTask(ctx, task)
+		}()
+	}
+	wg.Wait()
+
+	for _, checks := range results {
+		for _, c := range checks {
+			r.add(c)
+		}
+	}
+}
+
+// runTask runs a task, failing it once its timeout passes even if the
+// check ignores its context.
+func runTask(ctx context.Context, task validateTask) []validateCheck {
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
 	defer cancel()
 
+	done := make(chan []validateCheck, 1)
+	go func() {
+		r := &validateReport{out: io.Discard}
+		task.run(ctx, r)
+		done <- r.Checks
+	}()
+
+	select {
+	case checks := <-done:
+		return checks
+	case <-ctx.Done():
+		return []validateCheck{{Name: task.name, Status: checkFailed, Detail: fmt.Sprintf("timed out after %s", validateTimeout)}}
+	}
+}
+
+// validate checks the config and connectivity, printing the results to out.
+func validate(ctx context.Context, out io.Writer) *validateReport {
+	configPath, _ := config.DefaultConfigPath()
+	if cfgFile != "" {
+		configPath = cfgFile
+	}
+	report := &validateReport{ConfigFile: configPath, Valid: true, Checks: []validateCheck{}, out: out}
+
 	// Load config
-	fmt.Println("Configuration:")
+	fmt.Fprintln(out, "Configuration:")
 	cfg, err := loadConfig()
+	report.check("Config file", err, "syntax valid")
 	if err != nil {
-		fmt.Printf("  ✗ Config file: %v\n", err)
-		return err
+		return report
 	}
-	fmt.Printf("  ✓ Config file syntax valid\n")
 
 	// Display config values
-	configPath, _ := config.DefaultConfigPath()
-	if cfgFile != "" {
-		configPath = cfgFile
+	fmt.Fprintf(out, "  Config file: %s\n", configPath)
+	if cfg.Schedule != "" {
+		fmt.Fprintf(out, "  Schedule: %s\n", cfg.Schedule)
+	} else {
+		fmt.Fprintf(out, "  Interval: %s\n", cfg.Interval)
 	}
-	fmt.Printf("  Config file: %s\n", configPath)
-	fmt.Printf("  Interval: %s\n", cfg.Interval)
-	fmt.Printf("  Backup on startup: %t\n", cfg.BackupOnStartup)
+	fmt.Fprintf(out, "  Backup on startup: %t\n", cfg.BackupOnStartup)
 	if cfg.Metrics.Enabled {
-		fmt.Printf("  Metrics: enabled\n")
-		fmt.Printf("  Pushgateway URL: %s\n", cfg.Metrics.PushgatewayURL)
+		fmt.Fprintf(out, "  Metrics: enabled\n")
+		switch cfg.Metrics.Backend {
+		case config.MetricsBackendRemoteWrite:
+			fmt.Fprintf(out, "  Remote write URL: %s\n", cfg.Metrics.RemoteWriteURL)
+		case config.MetricsBackendVictoriaMetrics:
+			fmt.Fprintf(out, "  VictoriaMetrics URL: %s\n", cfg.Metrics.VictoriaMetricsURL)
+		default:
+			fmt.Fprintf(out, "  Pushgateway URL: %s\n", cfg.Metrics.PushgatewayURL)
+		}
 	} else {
-		fmt.Printf("  Metrics: disabled\n")
+		fmt.Fprintf(out, "  Metrics: disabled\n")
 	}
-	if cfg.Apprise.Enabled {
-		fmt.Printf("  Notifications: enabled\n")
-		fmt.Printf("  Apprise URL: %s\n", cfg.Apprise.URL)
-		fmt.Printf("  Notification level: %s\n", cfg.Apprise.Notify)
+	if cfg.NotificationsEnabled() {
+		fmt.Fprintf(out, "  Notifications: enabled\n")
+		if cfg.Apprise.Enabled {
+			fmt.Fprintf(out, "  Apprise URL: %s\n", cfg.Apprise.URL)
+		}
+		if cfg.NotifyWebhook.Enabled {
+			fmt.Fprintf(out, "  Notification webhook URL: %s\n", cfg.NotifyWebhook.URL)
+		}
+		fmt.Fprintf(out, "  Notification level: %s\n", cfg.Apprise.Notify)
 	} else {
-		fmt.Printf("  Notifications: disabled\n")
+		fmt.Fprintf(out, "  Notifications: disabled\n")
 	}
-	fmt.Println()
-
-	// Check ludusavi
-	fmt.Println("Checks:")
-	logger, _ := setupLogging(cfg)
-	execOpts := []executor.LudusaviOption{
-		executor.WithLogger(logger),
+	if cfg.HomeAssistant.Enabled {
+		fmt.Fprintf(out, "  Home Assistant: enabled\n")
+		fmt.Fprintf(out, "  Home Assistant URL: %s\n", cfg.HomeAssistant.URL)
+	} else {
+		fmt.Fprintf(out, "  Home Assistant: disabled\n")
 	}
-	if cfg.LudusaviPath != "" {
-		execOpts = append(execOpts, executor.WithBinaryPath(cfg.LudusaviPath))
+	if cfg.Archive.Enabled {
+		fmt.Fprintf(out, "  Archive: enabled\n")
+		fmt.Fprintf(out, "  Archive source: %s\n", cfg.Archive.Source)
+	} else {
+		fmt.Fprintf(out, "  Archive: disabled\n")
 	}
 	if len(cfg.Env) > 0 {
-		execOpts = append(execOpts, executor.WithEnv(cfg.Env))
+		// Values may be secrets, so only the names are shown
+		fmt.Fprintf(out, "  Ludusavi environment: %s\n", strings.Join(slices.Sorted(maps.Keys(cfg.Env)), ", "))
 	}
-	exec := executor.NewLudusaviExecutor(execOpts...)
-
-	if err := exec.Validate(ctx); err != nil {
-		fmt.Printf("  ✗ Ludusavi binary: %v\n", err)
-	} else {
-		version, _ := exec.Version(ctx)
-		fmt.Printf("  ✓ Ludusavi binary found: %s\n", version)
+	if warning := installedServiceConfigMismatch(ctx); warning != "" {
+		fmt.Fprintf(out, "  Warning: %s\n", warning)
 	}
+	fmt.Fprintln(out)
 
-	// Create HTTP client
+	fmt.Fprintln(out, "Checks:")
+	report.runTasks(ctx, validateTasks(cfg))
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Validation complete.")
+	return report
+}
+
+// validateTasks returns the checks of the ludusavi binary and the services
+// enabled in cfg.
+func validateTasks(cfg *config.Config) []validateTask {
+	logger, _ := setupLogging(cfg)
 	httpClient := http.NewClient(
 		http.WithRetryConfig(http.RetryConfig{
 			MaxAttempts:  1, // No retries for validation
 			InitialDelay: time.Second,
 			MaxDelay:     time.Second,
 		}),
-		http.WithLogger(logger),
+		http.WithLogger(logging.Component(logger, logging.ComponentHTTP)),
+		http.WithDialer(http.NewDialer(cfg.Network.DialConfig())),
 	)
 
-	// Check pushgateway if enabled
-	if cfg.Metrics.Enabled {
-		pushgatewayClient := metrics.NewPushgatewayClient(
-			cfg.Metrics.PushgatewayURL,
-			metrics.WithHTTPClient(httpClient),
-			metrics.WithLogger(logger),
-		)
-
-		if err := pushgatewayClient.Validate(ctx); err != nil {
-			fmt.Printf("  ✗ Pushgateway: %v\n", err)
-		} else {
-			fmt.Printf("  ✓ Pushgateway reachable\n")
+	tasks := []validateTask{{"Ludusavi binary", func(ctx context.Context, r *validateReport) {
+		exec := newExecutor(cfg, logger)
+		if err := exec.Validate(ctx); err != nil {
+			r.fail("Ludusavi binary", err)
+			return
+		}
+		version, _ := exec.Version(ctx)
+		r.pass("Ludusavi binary", "found "+version)
+	}}, {"Ludusavi config", func(ctx context.Context, r *validateReport) {
+		path, warnings, err := lintLudusaviConfig(cfg, logger)
+		switch {
+		case err != nil:
+			r.warn("Ludusavi config", err.Error())
+		case len(warnings) == 0:
+			r.pass("Ludusavi config", "no conflicts in "+path)
+		}
+		for _, warning := range warnings {
+			r.warn("Ludusavi config", warning)
 		}
+	}}}
+
+	// The scrape backend has nothing to reach: Prometheus comes to it
+	if cfg.Metrics.Enabled && cfg.Metrics.Backend != config.MetricsBackendScrape {
+		name := "Pushgateway"
+		switch cfg.Metrics.Backend {
+		case config.MetricsBackendRemoteWrite:
+			name = "Remote write"
+		case config.MetricsBackendVictoriaMetrics:
+			name = "VictoriaMetrics"
+		}
+		tasks = append(tasks, validateTask{name, func(ctx context.Context, r *validateReport) {
+			pusher, err := newMetricsPusher(cfg, httpClient, logger)
+			if err == nil {
+				err = pusher.Validate(ctx)
+			}
+			r.check(name, err, "reachable")
+		}})
+	}
+
+	if cfg.Network.IPFamily != config.IPFamilyAny || len(cfg.Network.DNSServers) > 0 {
+		tasks = append(tasks, validateTask{"Network", func(ctx context.Context, r *validateReport) {
+			checkNetwork(ctx, r, cfg)
+		}})
 	}
 
-	// Check apprise if enabled
 	if cfg.Apprise.Enabled {
-		appriseClient := notify.NewAppriseClient(
-			cfg.Apprise.URL,
-			cfg.Apprise.Key,
-			notify.WithHTTPClient(httpClient),
-			notify.WithLogger(logger),
-		)
-
-		if err := appriseClient.Validate(ctx); err != nil {
-			fmt.Printf("  ✗ Apprise server: %v\n", err)
-		} else {
-			fmt.Printf("  ✓ Apprise server reachable\n")
+		tasks = append(tasks, validateTask{"Apprise server", func(ctx context.Context, r *validateReport) {
+			r.check("Apprise server", newAppriseNotifier(cfg, httpClient, logger, nil).Validate(ctx), "reachable")
+		}})
+	}
+
+	if cfg.NotifyWebhook.Enabled {
+		tasks = append(tasks, validateTask{"Notification webhook", func(ctx context.Context, r *validateReport) {
+			webhook, err := newWebhookNotifier(cfg, httpClient, logger)
+			if err == nil {
+				err = webhook.Validate(ctx)
+			}
+			r.check("Notification webhook", err, "reachable")
+		}})
+	}
+
+	if cfg.HomeAssistant.Enabled {
+		tasks = append(tasks, validateTask{"Home Assistant", func(ctx context.Context, r *validateReport) {
+			r.check("Home Assistant", newHomeAssistant(cfg, httpClient, logger).Validate(ctx), "reachable")
+		}})
+	}
+
+	if cfg.CloudToken.Enabled {
+		tasks = append(tasks, validateTask{"Cloud tokens", func(ctx context.Context, r *validateReport) {
+			tokens, err := newTokenSource(cfg).Tokens(ctx)
+			if err != nil {
+				r.fail("Cloud tokens", err)
+			}
+			for _, token := range tokens {
+				name := "Cloud token " + token.Remote
+				switch {
+				case token.Deadline.IsZero():
+					r.pass(name, "refreshed automatically")
+				case time.Until(token.Deadline) <= time.Duration(cfg.CloudToken.WarnDays)*24*time.Hour:
+					r.fail(name, fmt.Errorf("expires %s", token.Deadline.Format(time.RFC1123)))
+				default:
+					r.pass(name, "valid until "+token.Deadline.Format(time.RFC1123))
+				}
+			}
+		}})
+	}
+
+	if cfg.Custom.Enabled {
+		tasks = append(tasks, validateTask{"Custom games", func(ctx context.Context, r *validateReport) {
+			r.check("Custom games", newCustomBackuper(cfg, logger).Validate(ctx),
+				fmt.Sprintf("%d configured, %d presets", len(cfg.Custom.Games), len(cfg.Custom.Presets)))
+		}})
+	}
+
+	if cfg.Extras.Enabled {
+		tasks = append(tasks, validateTask{"Extras", func(ctx context.Context, r *validateReport) {
+			r.check("Extras", newExtrasBackuper(cfg, logger).Validate(ctx), "backed up to "+cfg.Extras.Path)
+		}})
+	}
+
+	// Each archive destination gets a timeout of its own
+	if cfg.Archive.Enabled {
+		for _, dest := range newArchiver(cfg, logger).Destinations() {
+			name := "Archive destination " + dest.Name()
+			tasks = append(tasks, validateTask{name, func(ctx context.Context, r *validateReport) {
+				r.check(name, dest.Validate(ctx), "reachable")
+			}})
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("Validation complete.")
-	return nil
+	return tasks
+}
+
+// checkNetwork resolves and connects to the host of each HTTP service as
+// configured under [network], reporting the addresses it resolves to and
+// the one connected to, e.g. to tell whether IPv6 or IPv4 won the happy
+// eyeballs race.
+func checkNetwork(ctx context.Context, r *validateReport, cfg *config.Config) {
+	dialer := http.NewDialer(cfg.Network.DialConfig())
+	seen := make(map[string]bool)
+	for _, endpoint := range httpEndpoints(cfg) {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Hostname() == "" || seen[u.Host] {
+			continue
+		}
+		seen[u.Host] = true
+		name := "Network " + u.Host
+
+		ips, err := dialer.LookupIP(ctx, u.Hostname())
+		if err != nil {
+			r.fail(name, err)
+			continue
+		}
+		addrs := make([]string, len(ips))
+		for i, ip := range ips {
+			addrs[i] = ip.String()
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if err != nil {
+			r.fail(name, fmt.Errorf("resolves to %s, but failed to connect: %w", strings.Join(addrs, ", "), err))
+			continue
+		}
+		_ = conn.Close()
+		r.pass(name, fmt.Sprintf("resolves to %s, connected to %s", strings.Join(addrs, ", "), conn.RemoteAddr()))
+	}
+}
+
+// fixProblems fixes common problems validate finds, printing what it did to
+// out, and returns how many it fixed.
+func fixProblems(ctx context.Context, out io.Writer) int {
+	fixed := 0
+
+	configPath, err := config.DefaultConfigPath()
+	if cfgFile != "" {
+		configPath, err = cfgFile, nil
+	}
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ Config file: %v\n", err)
+		return fixed
+	}
+
+	// Write the example config if there is no config file
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := config.WriteExampleConfig(configPath); err != nil {
+			fmt.Fprintf(out, "  ✗ Config file: %v\n", err)
+			return fixed
+		}
+		fmt.Fprintf(out, "  ✓ Wrote example config to %s\n", configPath)
+		fixed++
+	} else if err == nil {
+		fixes, err := config.FixURLSchemes(configPath)
+		if err != nil {
+			fmt.Fprintf(out, "  ✗ URL schemes: %v\n", err)
+		}
+		for _, fix := range fixes {
+			fmt.Fprintf(out, "  ✓ Corrected %s: %s -> %s\n", fix.Key, fix.From, fix.To)
+			fixed++
+		}
+	}
+
+	// Download ludusavi if it can't be found
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ Config file: %v\n", err)
+		return fixed
+	}
+	logger, _ := setupLogging(cfg)
+	if cfg.LudusaviPath != "" {
+		return fixed
+	}
+	if _, err := newExecutor(cfg, logger).BinaryPath(); err == nil {
+		return fixed
+	}
+	if installLudusavi(ctx, out, newVerifier(cfg, logger)) {
+		fixed++
+	}
+	return fixed
+}
+
+// installLudusavi downloads the latest ludusavi release into the default
+// install directory, after confirmation, and reports whether it did. The
+// download is verified with v, if set.
+func installLudusavi(ctx context.Context, out io.Writer, v *verify.Verifier) bool {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	dir, err := executor.DefaultInstallDir()
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ Ludusavi binary: %v\n", err)
+		return false
+	}
+	opts := []executor.InstallerOption{executor.WithInstallerHTTPClient(http.NewClient(
+		http.WithHTTPClient(&nethttp.Client{Timeout: 5 * time.Minute}),
+	))}
+	if v != nil {
+		opts = append(opts, executor.WithInstallerVerifier(v))
+	}
+	installer := executor.NewInstaller(opts...)
+	release, err := installer.Latest(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ Ludusavi binary: %v\n", err)
+		return false
+	}
+
+	if !validateYes && !confirm(out, fmt.Sprintf("  Download ludusavi %s (%s) to %s?", release.Version, release.Asset, dir)) {
+		fmt.Fprintf(out, "  - Ludusavi binary: download skipped\n")
+		return false
+	}
+	path, err := installer.Install(ctx, release, dir)
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ Ludusavi binary: %v\n", err)
+		return false
+	}
+	fmt.Fprintf(out, "  ✓ Installed ludusavi %s to %s\n", release.Version, path)
+	if !onPath(dir) {
+		fmt.Fprintf(out, "    %s is not in PATH; ludusavi-runner finds it there, but your shell won't\n", dir)
+	}
+	return true
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no.
+func confirm(out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// onPath reports whether dir is in PATH.
+func onPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,758 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/archive"
+	"github.com/sharkusmanch/ludusavi-runner/internal/bandwidth"
+	"github.com/sharkusmanch/ludusavi-runner/internal/config"
+	"github.com/sharkusmanch/ludusavi-runner/internal/customgame"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/executor"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/homeassistant"
+	"github.com/sharkusmanch/ludusavi-runner/internal/hooks"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+	"github.com/sharkusmanch/ludusavi-runner/internal/rclone"
+	"github.com/sharkusmanch/ludusavi-runner/internal/snapshot"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/vss"
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
+)
+
+// rcloneBandwidthEnv is the environment variable rclone reads its --bwlimit from.
+const rcloneBandwidthEnv = "RCLONE_BWLIMIT"
+
+// newHTTPClient creates the HTTP client shared by metrics and notifications,
+// with opts applied on top of the configured retries.
+func newHTTPClient(cfg *config.Config, logger *slog.Logger, opts ...http.ClientOption) *http.Client {
+	return http.NewClient(append([]http.ClientOption{
+		http.WithRetryConfig(http.RetryConfig{
+			MaxAttempts:  cfg.Retry.MaxAttempts,
+			InitialDelay: cfg.Retry.InitialDelay,
+			MaxDelay:     cfg.Retry.MaxDelay,
+		}),
+		http.WithLogger(logging.Component(logger, logging.ComponentHTTP)),
+		http.WithDialer(http.NewDialer(cfg.Network.DialConfig())),
+		http.WithTransportConfig(cfg.Network.TransportConfig()),
+	}, opts...)...)
+}
+
+// httpEndpoints returns the URLs of the enabled HTTP services: the metrics
+// backend, Apprise, the notification webhook and Home Assistant.
+func httpEndpoints(cfg *config.Config) []string {
+	var endpoints []string
+	if cfg.Metrics.Enabled {
+		switch cfg.Metrics.Backend {
+		case config.MetricsBackendRemoteWrite:
+			endpoints = append(endpoints, cfg.Metrics.RemoteWriteURL)
+		case config.MetricsBackendVictoriaMetrics:
+			endpoints = append(endpoints, cfg.Metrics.VictoriaMetricsURL)
+		default:
+			endpoints = append(endpoints, cfg.Metrics.PushgatewayURL)
+		}
+	}
+	if cfg.Apprise.Enabled {
+		endpoints = append(endpoints, cfg.Apprise.URL)
+	}
+	if cfg.NotifyWebhook.Enabled {
+		endpoints = append(endpoints, cfg.NotifyWebhook.URL)
+	}
+	if cfg.HomeAssistant.Enabled {
+		endpoints = append(endpoints, cfg.HomeAssistant.URL)
+	}
+	return endpoints
+}
+
+// newExecutor creates the ludusavi executor.
+func newExecutor(cfg *config.Config, logger *slog.Logger) *executor.LudusaviExecutor {
+	execOpts := []executor.LudusaviOption{
+		executor.WithLogger(logging.Component(logger, logging.ComponentExecutor)),
+	}
+	if cfg.LudusaviPath != "" {
+		execOpts = append(execOpts, executor.WithBinaryPath(cfg.LudusaviPath))
+	}
+	if env := executorEnv(cfg); len(env) > 0 {
+		execOpts = append(execOpts, executor.WithEnv(env), executor.WithEnvVars(cfg.EnvVars()))
+	}
+	if len(cfg.Games.Include) > 0 || len(cfg.Games.Exclude) > 0 {
+		execOpts = append(execOpts, executor.WithGameFilter(cfg.Games.Include, cfg.Games.Exclude))
+	}
+	if cfg.Throttle.Enabled {
+		execOpts = append(execOpts, executor.WithBatches(cfg.Throttle.BatchSize, cfg.Throttle.BatchPause))
+	}
+	if cfg.Sandbox.BinarySHA256 != "" {
+		execOpts = append(execOpts, executor.WithBinaryHash(cfg.Sandbox.BinarySHA256))
+	}
+	if v := newVerifier(cfg, logger); v != nil {
+		execOpts = append(execOpts, executor.WithVerifier(v, cfg.Verify.Checksums))
+	}
+	execOpts = append(execOpts,
+		executor.WithSandbox(cfg.Sandbox.Enabled),
+		executor.WithRestrictedEnv(cfg.Sandbox.RestrictEnv),
+	)
+	return executor.NewLudusaviExecutor(execOpts...)
+}
+
+// newVerifier creates the verifier of the ludusavi binary and downloads, or
+// returns nil if verification is disabled.
+func newVerifier(cfg *config.Config, logger *slog.Logger) *verify.Verifier {
+	if !cfg.Verify.Enabled {
+		return nil
+	}
+	opts := []verify.Option{
+		verify.WithStrict(cfg.Verify.Strict),
+		verify.WithLogger(logging.Component(logger, logging.ComponentExecutor)),
+	}
+	if cfg.Verify.PublicKey != "" {
+		// Validated with the config
+		key, _ := verify.ParsePublicKey(cfg.Verify.PublicKey)
+		opts = append(opts, verify.WithPublicKey(key))
+	}
+	return verify.NewVerifier(opts...)
+}
+
+// newRunnerExecutor creates the executor used for backup runs, wrapped in
+// shadow copies and the scan cache if enabled.
+func newRunnerExecutor(cfg *config.Config, logger *slog.Logger) domain.Executor {
+	var exec domain.Executor = newExecutor(cfg, logger)
+	if cfg.VSS.Enabled {
+		exec = newSnapshotExecutor(cfg, exec, logger)
+	}
+	if !cfg.ScanCache.Enabled {
+		return exec
+	}
+
+	path, err := config.DefaultScanCachePath()
+	if err != nil {
+		logger.Warn("failed to determine scan cache path, scan cache disabled", "error", err)
+		return exec
+	}
+	return executor.NewScanCacheExecutor(exec, path,
+		executor.WithScanCacheMaxAge(cfg.ScanCache.MaxAge),
+		executor.WithScanCacheLogger(logging.Component(logger, logging.ComponentExecutor)),
+	)
+}
+
+// newSnapshotExecutor wraps exec in shadow copies of the configured volumes.
+// Shadow copies only exist on Windows; elsewhere exec is returned as is.
+func newSnapshotExecutor(cfg *config.Config, exec domain.Executor, logger *slog.Logger) domain.Executor {
+	if runtime.GOOS != "windows" {
+		logger.Warn("vss is only supported on Windows, ignoring")
+		return exec
+	}
+
+	linkDir, err := config.DefaultVSSLinkDir()
+	if err != nil {
+		logger.Warn("failed to determine shadow copy directory, vss disabled", "error", err)
+		return exec
+	}
+	return executor.NewSnapshotExecutor(exec,
+		vss.NewManager(linkDir, vss.WithLogger(logging.Component(logger, logging.ComponentVSS))),
+		cfg.VSS.Volumes,
+		executor.WithSnapshotLogger(logging.Component(logger, logging.ComponentExecutor)),
+	)
+}
+
+// executorEnv returns the environment passed to ludusavi. Bandwidth limits are
+// passed to rclone, which ludusavi uses for cloud uploads, unless the user
+// already set RCLONE_BWLIMIT themselves.
+func executorEnv(cfg *config.Config) map[string]string {
+	timetable := newBandwidthSchedule(cfg).RcloneTimetable()
+	if _, ok := cfg.Env[rcloneBandwidthEnv]; ok || timetable == "" {
+		return cfg.Env
+	}
+
+	env := make(map[string]string, len(cfg.Env)+1)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	env[rcloneBandwidthEnv] = timetable
+	return env
+}
+
+// newBandwidthSchedule creates the upload bandwidth schedule. The config has
+// already been validated, so rule times parse.
+func newBandwidthSchedule(cfg *config.Config) *bandwidth.Schedule {
+	rules := make([]bandwidth.Rule, 0, len(cfg.Bandwidth.Rules))
+	for _, r := range cfg.Bandwidth.Rules {
+		start, _ := bandwidth.ParseTimeOfDay(r.Start)
+		end, _ := bandwidth.ParseTimeOfDay(r.End)
+		rules = append(rules, bandwidth.Rule{
+			Start: start,
+			End:   end,
+			Limit: int64(r.LimitKBps) * 1024,
+		})
+	}
+	return bandwidth.NewSchedule(int64(cfg.Bandwidth.DefaultLimitKBps)*1024, rules...).In(cfg.Location())
+}
+
+// newArchiver creates the archiver and its destinations.
+func newArchiver(cfg *config.Config, logger *slog.Logger) *archive.Archiver {
+	logger = logging.Component(logger, logging.ComponentArchive)
+	dests := make([]domain.ArchiveDestination, 0, len(cfg.Archive.Destinations))
+	for _, d := range cfg.Archive.Destinations {
+		switch d.Type {
+		case config.ArchiveDestinationSFTP:
+			dests = append(dests, archive.NewSFTPDestination(archive.SFTPConfig{
+				Host:           d.Host,
+				Port:           d.Port,
+				User:           d.User,
+				KeyFile:        d.KeyFile,
+				KnownHostsFile: d.KnownHostsFile,
+				Path:           d.Path,
+			}))
+		case config.ArchiveDestinationS3:
+			dests = append(dests, archive.NewS3Destination(archive.S3Config{
+				Endpoint:         d.Endpoint,
+				Region:           d.Region,
+				Bucket:           d.Bucket,
+				Prefix:           d.Prefix,
+				AccessKeyID:      d.AccessKeyID,
+				SecretAccessKey:  d.SecretAccessKey,
+				StorageClass:     d.StorageClass,
+				Tags:             d.Tags,
+				VirtualHostStyle: d.VirtualHostStyle,
+				PartSize:         int64(d.PartSizeMB) * 1024 * 1024,
+				Concurrency:      d.Concurrency,
+			}))
+		case config.ArchiveDestinationWebDAV:
+			dests = append(dests, archive.NewWebDAVDestination(archive.WebDAVConfig{
+				URL:       d.URL,
+				Username:  d.User,
+				Password:  d.Password,
+				ChunkSize: int64(d.ChunkSizeMB) * 1024 * 1024,
+			}))
+		case config.ArchiveDestinationLocal:
+			// Network shares may be asleep or not yet mapped, so wait for them
+			// and report them as offline instead of failing outright.
+			dests = append(dests, archive.NewAvailabilityDestination(
+				archive.NewLocalDestination(d.Path),
+				archive.WithAvailabilityRetry(d.OfflineRetries, d.OfflineRetryDelay, 0),
+				archive.WithAvailabilityLogger(logger),
+			))
+		}
+	}
+
+	opts := []archive.ArchiverOption{
+		archive.WithPrefix(cfg.Archive.Prefix),
+		archive.WithDestinations(dests...),
+		archive.WithLogger(logger),
+	}
+
+	if schedule := newBandwidthSchedule(cfg); !schedule.Unlimited() {
+		opts = append(opts, archive.WithBandwidthLimiter(bandwidth.NewLimiter(schedule)))
+	}
+
+	// Resumed exports need the staged archive to survive a reboot, so it
+	// can't live in the system temp directory.
+	stagingDir := cfg.Archive.StagingDir
+	if stagingDir == "" && cfg.Archive.Resume {
+		dir, err := config.DefaultStagingDir()
+		if err != nil {
+			logger.Warn("failed to determine staging directory, archives will not be resumed", "error", err)
+		} else {
+			stagingDir = dir
+		}
+	}
+	if stagingDir != "" {
+		opts = append(opts, archive.WithTempDir(stagingDir))
+		opts = append(opts, archive.WithResume(cfg.Archive.Resume))
+	}
+
+	return archive.NewArchiver(cfg.Archive.Source, opts...)
+}
+
+// newStoreSnapshots creates the backup store snapshot manager.
+func newStoreSnapshots(cfg *config.Config, logger *slog.Logger) *snapshot.Manager {
+	var snapshotter domain.StoreSnapshotter
+	switch cfg.StoreSnapshot.Type {
+	case config.StoreSnapshotZFS:
+		snapshotter = snapshot.NewZFSSnapshotter(cfg.StoreSnapshot.Source)
+	default:
+		snapshotter = snapshot.NewBtrfsSnapshotter(cfg.StoreSnapshot.Source, cfg.StoreSnapshot.Dir)
+	}
+	return snapshot.NewManager(snapshotter, cfg.StoreSnapshot.Prefix,
+		snapshot.WithKeep(cfg.StoreSnapshot.Keep),
+		snapshot.WithLogger(logging.Component(logger, logging.ComponentSnapshot)),
+	)
+}
+
+// newMetricsPusher creates the client of the configured metrics backend.
+// With metrics.tls set, it pushes with a client of its own presenting the
+// client certificate, rather than httpClient; if that can't be loaded, it
+// returns an error rather than push without it.
+func newMetricsPusher(cfg *config.Config, httpClient *http.Client, logger *slog.Logger) (domain.MetricsPusher, error) {
+	if cfg.Metrics.TLS.IsSet() {
+		tlsCfg, err := http.NewTLSConfig(cfg.Metrics.TLS.Files())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metrics TLS configuration: %w", err)
+		}
+		httpClient = newHTTPClient(cfg, logger, http.WithTLSConfig(tlsCfg))
+	}
+	logger = logging.Component(logger, logging.ComponentMetrics)
+	switch cfg.Metrics.Backend {
+	case config.MetricsBackendRemoteWrite:
+		return metrics.NewRemoteWriteClient(
+			cfg.Metrics.RemoteWriteURL,
+			metrics.WithRemoteWritePrefix(cfg.Metrics.Prefix),
+			metrics.WithRemoteWriteJobName(cfg.Metrics.JobName),
+			metrics.WithRemoteWriteHeaders(cfg.Metrics.Headers),
+			metrics.WithRemoteWriteHTTPClient(httpClient),
+			metrics.WithRemoteWriteLogger(logger),
+		), nil
+	case config.MetricsBackendVictoriaMetrics:
+		return metrics.NewVictoriaMetricsClient(
+			cfg.Metrics.VictoriaMetricsURL,
+			metrics.WithVictoriaMetricsPrefix(cfg.Metrics.Prefix),
+			metrics.WithVictoriaMetricsJobName(cfg.Metrics.JobName),
+			metrics.WithVictoriaMetricsHeaders(cfg.Metrics.Headers),
+			metrics.WithVictoriaMetricsHTTPClient(httpClient),
+			metrics.WithVictoriaMetricsLogger(logger),
+		), nil
+	default:
+		return metrics.NewPushgatewayClient(
+			cfg.Metrics.PushgatewayURL,
+			metrics.WithPrefix(cfg.Metrics.Prefix),
+			metrics.WithJobName(cfg.Metrics.JobName),
+			metrics.WithHTTPClient(httpClient),
+			metrics.WithLogger(logger),
+		), nil
+	}
+}
+
+// newMetricsExporter creates the exporter of the scrape metrics backend, or
+// returns nil if metrics are pushed instead.
+func newMetricsExporter(cfg *config.Config) *metrics.Exporter {
+	if !cfg.Metrics.Enabled || cfg.Metrics.Backend != config.MetricsBackendScrape {
+		return nil
+	}
+	return metrics.NewExporter(metrics.WithExporterPrefix(cfg.Metrics.Prefix))
+}
+
+// newHomeAssistant creates the Home Assistant client.
+func newHomeAssistant(cfg *config.Config, httpClient *http.Client, logger *slog.Logger) *homeassistant.Client {
+	return homeassistant.NewClient(
+		cfg.HomeAssistant.URL,
+		cfg.HomeAssistant.Token,
+		cfg.HomeAssistant.EntityPrefix,
+		homeassistant.WithHTTPClient(httpClient),
+		homeassistant.WithLogger(logging.Component(logger, logging.ComponentHomeAssistant)),
+	)
+}
+
+// newTokenSource creates the rclone client that reads cloud remote tokens.
+func newTokenSource(cfg *config.Config) *rclone.Client {
+	opts := []rclone.Option{
+		rclone.WithEnv(cfg.Env),
+		rclone.WithEnvVars(cfg.EnvVars()),
+		rclone.WithRemotes(cfg.CloudToken.Remotes...),
+	}
+	if cfg.CloudToken.RclonePath != "" {
+		opts = append(opts, rclone.WithBinaryPath(cfg.CloudToken.RclonePath))
+	}
+	return rclone.NewClient(opts...)
+}
+
+// newHooks creates the runner of hook commands.
+func newHooks(cfg *config.Config, logger *slog.Logger) *hooks.Runner {
+	operations := make(map[domain.OperationType]hooks.Commands, len(cfg.Hooks.Operations))
+	for op, commands := range cfg.Hooks.Operations {
+		operations[domain.OperationType(op)] = hooks.Commands{Pre: commands.Pre, Post: commands.Post}
+	}
+	return hooks.NewRunner(hooks.Commands{Pre: cfg.Hooks.PreBackup, Post: cfg.Hooks.PostBackup},
+		hooks.WithOperations(operations),
+		hooks.WithTimeout(cfg.Hooks.Timeout),
+		hooks.WithEnv(cfg.Env, cfg.EnvVars()),
+		hooks.WithLogger(logging.Component(logger, logging.ComponentHooks)),
+	)
+}
+
+// newCustomBackuper creates the backuper of custom games.
+func newCustomBackuper(cfg *config.Config, logger *slog.Logger) *customgame.Backuper {
+	games := make([]customgame.Game, 0, len(cfg.Custom.Games)+len(cfg.Custom.Presets))
+	for _, g := range cfg.Custom.Games {
+		games = append(games, customgame.Game{Title: g.Title, Paths: g.Paths, Command: g.Command})
+	}
+	for _, name := range cfg.Custom.Presets {
+		if game, ok := customgame.Preset(name.String()); ok {
+			games = append(games, game)
+		}
+	}
+	return customgame.NewBackuper(cfg.Custom.Path, games,
+		customgame.WithTimeout(cfg.Custom.Timeout),
+		customgame.WithEnv(cfg.Env, cfg.EnvVars()),
+		customgame.WithLogger(logging.Component(logger, logging.ComponentCustom)),
+	)
+}
+
+// newExtrasBackuper creates the backuper of screenshots and game config
+// files, which are copied like custom games, one game per kind.
+func newExtrasBackuper(cfg *config.Config, logger *slog.Logger) *customgame.Backuper {
+	var games []customgame.Game
+	if len(cfg.Extras.Screenshots) > 0 {
+		games = append(games, customgame.Game{Title: "Screenshots", Paths: cfg.Extras.Screenshots, Optional: true, KeepRemoved: true})
+	}
+	if len(cfg.Extras.Configs) > 0 {
+		games = append(games, customgame.Game{Title: "Configs", Paths: cfg.Extras.Configs, Optional: true})
+	}
+	return customgame.NewBackuper(cfg.Extras.Path, games,
+		customgame.WithOperation(domain.OperationExtras),
+		customgame.WithLogger(logging.Component(logger, logging.ComponentExtras)),
+	)
+}
+
+// newCalendar creates the calendar of schedule exceptions from the config,
+// keeping those added at runtime in the state directory.
+func newCalendar(cfg *config.Config, logger *slog.Logger) *app.Calendar {
+	var exceptions []app.CalendarException
+	// Entries were checked by config validation
+	for _, skip := range cfg.Calendar.Skip {
+		start, end, _ := skip.Window(cfg.Location())
+		exceptions = append(exceptions, app.CalendarException{Kind: app.CalendarSkip, Start: start, End: end, Reason: skip.Reason})
+	}
+	for _, run := range cfg.Calendar.Runs {
+		at, _ := run.Time(cfg.Location())
+		exceptions = append(exceptions, app.CalendarException{Kind: app.CalendarRun, Start: at, Reason: run.Reason})
+	}
+
+	opts := []app.CalendarOption{app.WithCalendarLogger(logger)}
+	if path, err := config.DefaultCalendarStatePath(); err != nil {
+		logger.Warn("failed to determine calendar state path, exceptions added at runtime will not persist", "error", err)
+	} else {
+		opts = append(opts, app.WithCalendarStatePath(path))
+	}
+	return app.NewCalendar(exceptions, opts...)
+}
+
+// newRunner creates the runner of backup runs. With the scrape metrics
+// backend, metrics go to exporter, if set, for serve to expose; one-off
+// runs pass nil, as there is nothing to scrape them from. Notifications are
+// counted by target with counter.
+func newRunner(cfg *config.Config, logger *slog.Logger, exporter *metrics.Exporter, counter *notify.Counter) *app.Runner {
+	httpClient := newHTTPClient(cfg, logger)
+
+	runnerOpts := []app.RunnerOption{
+		app.WithExecutor(newRunnerExecutor(cfg, logger)),
+		app.WithLogger(logging.Component(logger, logging.ComponentRunner)),
+	}
+
+	if cfg.CrashDump {
+		dir, err := config.DefaultCrashDumpDir()
+		if err != nil {
+			logger.Warn("failed to determine crash dump directory, crash dumps disabled", "error", err)
+		} else {
+			runnerOpts = append(runnerOpts, app.WithCrashDumpDir(dir))
+		}
+	}
+
+	if len(cfg.BackupDestinations) > 0 {
+		path, err := config.DefaultDestinationStatePath()
+		if err != nil {
+			logger.Warn("failed to determine destination state path, last seen times will not persist", "error", err)
+		} else {
+			runnerOpts = append(runnerOpts, app.WithDestinationStatePath(path))
+		}
+	}
+
+	if cfg.GameCount.Enabled {
+		path, err := config.DefaultGameCountStatePath()
+		if err != nil {
+			logger.Warn("failed to determine game count state path, game counts will not persist", "error", err)
+		} else {
+			runnerOpts = append(runnerOpts, app.WithGameCountStatePath(path))
+		}
+	}
+
+	if cfg.Quarantine.Enabled {
+		path, err := config.DefaultQuarantineStatePath()
+		if err != nil {
+			logger.Warn("failed to determine quarantine state path, quarantine will not persist", "error", err)
+		} else {
+			runnerOpts = append(runnerOpts, app.WithQuarantineStatePath(path))
+		}
+	}
+
+	if store := newHistory(cfg, logger); store != nil {
+		runnerOpts = append(runnerOpts, app.WithHistory(store))
+	}
+
+	if cfg.Server.Enabled && cfg.Server.PublicURL != "" {
+		runnerOpts = append(runnerOpts, app.WithAckURL(cfg.Server.PublicURL))
+	}
+
+	if path, err := config.DefaultMaintenancePath(); err != nil {
+		logger.Warn("failed to determine maintenance state path, maintenance mode unavailable", "error", err)
+	} else {
+		runnerOpts = append(runnerOpts, app.WithMaintenancePath(path))
+	}
+
+	if cfg.Offline.Enabled {
+		runnerOpts = append(runnerOpts, app.WithNetworkProbe(newNetworkProbe(cfg)))
+	}
+
+	if cfg.GUIWait.Enabled {
+		binary := cfg.LudusaviPath
+		if binary == "" {
+			binary = "ludusavi"
+		}
+		find := func() ([]int, error) { return platform.FindProcesses(binary) }
+		runnerOpts = append(runnerOpts, app.WithLudusaviWait(find, cfg.GUIWait.MaxWait))
+	}
+
+	if cfg.Outbox.Enabled {
+		path, err := config.DefaultOutboxPath()
+		if err != nil {
+			logger.Warn("failed to determine outbox path, undelivered items will not persist", "error", err)
+			path = ""
+		}
+		runnerOpts = append(runnerOpts, app.WithOutbox(path, cfg.Outbox.TTL))
+	}
+
+	if cfg.StoreSnapshot.Enabled {
+		runnerOpts = append(runnerOpts, app.WithStoreSnapshots(
+			newStoreSnapshots(cfg, logger),
+			cfg.StoreSnapshot.PreBackup,
+			cfg.StoreSnapshot.PostBackup,
+		))
+	}
+
+	if cfg.CloudToken.Enabled {
+		runnerOpts = append(runnerOpts, app.WithCloudTokens(newTokenSource(cfg)))
+	}
+
+	if cfg.Custom.Enabled {
+		runnerOpts = append(runnerOpts, app.WithCustomBackuper(newCustomBackuper(cfg, logger)))
+	}
+	if cfg.Hooks.Enabled() {
+		runnerOpts = append(runnerOpts, app.WithHooks(newHooks(cfg, logger)))
+	}
+	if cfg.Extras.Enabled {
+		runnerOpts = append(runnerOpts, app.WithExtrasBackuper(newExtrasBackuper(cfg, logger)))
+	}
+
+	// Create archiver if enabled
+	if cfg.Archive.Enabled {
+		runnerOpts = append(runnerOpts, app.WithArchiver(newArchiver(cfg, logger)))
+	}
+
+	// Create metrics pushers if enabled
+	var pushers []domain.MetricsPusher
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Backend != config.MetricsBackendScrape {
+			if pusher, err := newMetricsPusher(cfg, httpClient, logger); err != nil {
+				logger.Error("metrics push disabled", "error", err)
+			} else {
+				pushers = append(pushers, pusher)
+			}
+		} else if exporter != nil {
+			pushers = append(pushers, exporter)
+		}
+	}
+	if cfg.HomeAssistant.Enabled {
+		pushers = append(pushers, newHomeAssistant(cfg, httpClient, logger))
+	}
+	switch len(pushers) {
+	case 0:
+	case 1:
+		runnerOpts = append(runnerOpts, app.WithMetricsPusher(pushers[0]))
+	default:
+		runnerOpts = append(runnerOpts, app.WithMetricsPusher(metrics.NewMultiPusher(pushers...)))
+	}
+
+	// Create tracer if enabled
+	if cfg.Tracing.Enabled {
+		exporter := tracing.NewOTLPExporter(
+			cfg.Tracing.Endpoint,
+			tracing.WithServiceName(cfg.Tracing.ServiceName),
+			tracing.WithHeaders(cfg.Tracing.Headers),
+		)
+		runnerOpts = append(runnerOpts, app.WithTracer(tracing.NewTracer(exporter, tracing.WithLogger(logging.Component(logger, logging.ComponentTracing)))))
+	}
+
+	// Create notifier if enabled. Notifications are counted by counter,
+	// which also counts those of notifiers set on config reload.
+	runnerOpts = append(runnerOpts, app.WithNotificationCounts(counter.Counts))
+	if cfg.NotificationsEnabled() {
+		runnerOpts = append(runnerOpts, app.WithNotifier(newNotifier(cfg, httpClient, logger, counter)))
+
+		path, err := config.DefaultFailureStatePath()
+		if err != nil {
+			logger.Warn("failed to determine failure state path, failure streaks will not persist", "error", err)
+		} else {
+			runnerOpts = append(runnerOpts, app.WithFailureStatePath(path))
+		}
+	}
+
+	if cfg.OnComplete.WebhookURL != "" {
+		runnerOpts = append(runnerOpts, app.WithRunPublisher(webhook.NewClient(
+			cfg.OnComplete.WebhookURL,
+			webhook.WithSecret(cfg.OnComplete.WebhookSecret),
+			webhook.WithHTTPClient(httpClient),
+			webhook.WithLogger(logging.Component(logger, logging.ComponentWebhook)),
+		)))
+	}
+
+	return app.NewRunner(cfg, runnerOpts...)
+}
+
+// newNotifier creates the notifier sending to Apprise and the notification
+// webhook, whichever are enabled, escalating failures to the Apprise
+// escalation key's targets once apprise.escalate_after runs in a row failed.
+// With counter set, the notifications sent and failed are counted by target.
+func newNotifier(cfg *config.Config, httpClient *http.Client, logger *slog.Logger, counter *notify.Counter) domain.Notifier {
+	var notifiers []domain.Notifier
+	if cfg.Apprise.Enabled {
+		notifiers = append(notifiers, newAppriseNotifier(cfg, httpClient, logger, counter))
+	}
+	if cfg.NotifyWebhook.Enabled {
+		if webhook, err := newWebhookNotifier(cfg, httpClient, logger); err != nil {
+			logger.Error("notification webhook disabled", "error", err)
+		} else {
+			notifiers = append(notifiers, counter.Count("webhook", webhook))
+		}
+	}
+	if len(notifiers) == 1 {
+		return notifiers[0]
+	}
+	return notify.NewMultiNotifier(notifiers...)
+}
+
+// newAppriseNotifier creates the Apprise notifier, escalating failures to
+// the escalation key's targets once apprise.escalate_after runs in a row
+// failed.
+func newAppriseNotifier(cfg *config.Config, httpClient *http.Client, logger *slog.Logger, counter *notify.Counter) domain.Notifier {
+	logger = logging.Component(logger, logging.ComponentNotify)
+	notifier := counter.Count("apprise", notify.NewAppriseClient(
+		cfg.Apprise.URL,
+		cfg.Apprise.Key,
+		notify.WithHTTPClient(httpClient),
+		notify.WithLogger(logger),
+	))
+	if cfg.Apprise.EscalateAfter == 0 {
+		return notifier
+	}
+
+	var escalation []domain.Notifier
+	if cfg.Apprise.EscalateKey != "" {
+		escalation = append(escalation, counter.Count("apprise_escalation", notify.NewAppriseClient(
+			cfg.Apprise.URL,
+			cfg.Apprise.EscalateKey,
+			notify.WithHTTPClient(httpClient),
+			notify.WithLogger(logger),
+		)))
+	}
+	return notify.NewRouter(notifier,
+		notify.WithEscalation(cfg.Apprise.EscalateAfter, escalation...),
+		notify.WithRouterLogger(logger),
+	)
+}
+
+// newWebhookNotifier creates the notifier posting to the notification
+// webhook.
+func newWebhookNotifier(cfg *config.Config, httpClient *http.Client, logger *slog.Logger) (*notify.WebhookNotifier, error) {
+	text, err := cfg.NotifyWebhookTemplate()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := notify.ParseTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notify_webhook.template: %w", err)
+	}
+	return notify.NewWebhookNotifier(cfg.NotifyWebhook.URL, tmpl,
+		notify.WithWebhookHTTPClient(httpClient),
+		notify.WithWebhookLogger(logging.Component(logger, logging.ComponentNotify)),
+		notify.WithContentType(cfg.NotifyWebhook.ContentType),
+		notify.WithHeaders(cfg.NotifyWebhook.Headers),
+		notify.WithWebhookSecret(cfg.NotifyWebhook.Secret),
+	), nil
+}
+
+// newHistory creates the run history store, or returns nil if the history
+// is disabled or has nowhere to go.
+func newHistory(cfg *config.Config, logger *slog.Logger) *history.Store {
+	if !cfg.History.Enabled {
+		return nil
+	}
+	path, err := config.DefaultHistoryPath()
+	if err != nil {
+		logger.Warn("failed to determine history path, runs will not be recorded", "error", err)
+		return nil
+	}
+	return history.NewStore(path,
+		history.WithRetention(cfg.History.Retention),
+		history.WithLogger(logging.Component(logger, logging.ComponentRunner)),
+	)
+}
+
+// newReporter creates the weekly reporter, or returns nil if reports are
+// disabled or the history is unavailable.
+func newReporter(cfg *config.Config, logger *slog.Logger) *app.Reporter {
+	if !cfg.Report.Enabled {
+		return nil
+	}
+	store := newHistory(cfg, logger)
+	if store == nil {
+		return nil
+	}
+
+	logger = logging.Component(logger, logging.ComponentReport)
+	// Checked by config validation
+	weekday, at, _ := cfg.Report.Schedule()
+	opts := []app.ReporterOption{
+		app.WithReportSchedule(weekday, at, cfg.Location()),
+		app.WithReporterLogger(logger),
+	}
+	if cfg.Report.Dir != "" {
+		opts = append(opts, app.WithReportDir(cfg.Report.Dir))
+	}
+	if cfg.Report.Notify && cfg.NotificationsEnabled() {
+		opts = append(opts, app.WithReportNotifier(newNotifier(cfg, newHTTPClient(cfg, logger), logger, nil)))
+	}
+	if path, err := config.DefaultReportStatePath(); err != nil {
+		logger.Warn("failed to determine report state path, missed reports will not be caught up", "error", err)
+	} else {
+		opts = append(opts, app.WithReportStatePath(path))
+	}
+	return app.NewReporter(store, opts...)
+}
+
+// lintLudusaviConfig reads ludusavi's own config and returns its path and
+// the settings in it that conflict with cfg.
+func lintLudusaviConfig(cfg *config.Config, logger *slog.Logger) (string, []string, error) {
+	// Without the binary, the config is looked for in the default location
+	binary, _ := newExecutor(cfg, logger).BinaryPath()
+	path, err := config.LudusaviConfigPath(binary)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to locate ludusavi config: %w", err)
+	}
+	lc, err := config.ReadLudusaviConfig(path)
+	if err != nil {
+		return path, nil, err
+	}
+	return path, cfg.Lint(lc), nil
+}
+
+// newNetworkProbe returns a probe that dials the offline probe address to
+// tell whether the network is up, the way the HTTP clients would.
+func newNetworkProbe(cfg *config.Config) func(ctx context.Context) error {
+	dialer := http.NewDialer(cfg.Network.DialConfig())
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, cfg.Offline.ProbeTimeout)
+		defer cancel()
+		conn, err := dialer.DialContext(ctx, "tcp", cfg.Offline.ProbeAddress)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout is the format of a calendar date without a time.
+const dateLayout = "2006-01-02"
+
+// calendarLayouts are the accepted formats of calendar times with a time of
+// day. All but RFC 3339 are read in the schedule's time zone.
+var calendarLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// CalendarConfig holds one-off exceptions to the backup schedule.
+type CalendarConfig struct {
+	// Skip lists dates or time ranges without scheduled backups.
+	Skip []CalendarSkipConfig `mapstructure:"skip"`
+	// Runs lists extra full backups at set times.
+	Runs []CalendarRunConfig `mapstructure:"runs"`
+}
+
+// CalendarSkipConfig is a date, or a range from one time to another, during
+// which scheduled backups are skipped.
+type CalendarSkipConfig struct {
+	Date   string `mapstructure:"date" json:"date,omitempty"`
+	From   string `mapstructure:"from" json:"from,omitempty"`
+	To     string `mapstructure:"to" json:"to,omitempty"`
+	Reason string `mapstructure:"reason" json:"reason,omitempty"`
+}
+
+// CalendarRunConfig is an extra full backup at a set time.
+type CalendarRunConfig struct {
+	At     string `mapstructure:"at" json:"at"`
+	Reason string `mapstructure:"reason" json:"reason,omitempty"`
+}
+
+// Window returns the time range the skip covers in loc: the whole day for a
+// date, or from From to To, where a To without a time of day includes that
+// day.
+func (s CalendarSkipConfig) Window(loc *time.Location) (time.Time, time.Time, error) {
+	if s.Date != "" {
+		if s.From != "" || s.To != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("either date or from and to may be set, not both")
+		}
+		day, err := time.ParseInLocation(dateLayout, s.Date, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s.Date)
+		}
+		return day, day.AddDate(0, 0, 1), nil
+	}
+
+	if s.From == "" || s.To == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("date, or from and to, are required")
+	}
+	start, err := parseCalendarBound(s.From, loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseCalendarBound(s.To, loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+	return start, end, nil
+}
+
+// Time returns the time of the run in loc.
+func (r CalendarRunConfig) Time(loc *time.Location) (time.Time, error) {
+	if r.At == "" {
+		return time.Time{}, fmt.Errorf("at is required")
+	}
+	return parseCalendarBound(r.At, loc, false)
+}
+
+// parseCalendarBound parses a calendar date or time in loc. A date alone is
+// the start of that day, or with end the start of the next.
+func parseCalendarBound(s string, loc *time.Location, end bool) (time.Time, error) {
+	if day, err := time.ParseInLocation(dateLayout, s, loc); err == nil {
+		if end {
+			return day.AddDate(0, 0, 1), nil
+		}
+		return day, nil
+	}
+	for _, layout := range calendarLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD HH:MM", s)
+}
+
+// Validate checks if the calendar exceptions are valid in loc.
+func (c *CalendarConfig) Validate(loc *time.Location) error {
+	for i, s := range c.Skip {
+		if _, _, err := s.Window(loc); err != nil {
+			return fmt.Errorf("calendar.skip[%d]: %w", i, err)
+		}
+	}
+	for i, r := range c.Runs {
+		if _, err := r.Time(loc); err != nil {
+			return fmt.Errorf("calendar.runs[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -1,32 +1,170 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sharkusmanch/ludusavi-runner/internal/cron"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	Interval        time.Duration     `mapstructure:"interval"`
-	BackupOnStartup bool              `mapstructure:"backup_on_startup"`
-	LudusaviPath    string            `mapstructure:"ludusavi_path"`
-	DryRun          bool              `mapstructure:"dry_run"`
-	Env             map[string]string `mapstructure:"env"`
-	Retry           RetryConfig       `mapstructure:"retry"`
-	Metrics         MetricsConfig     `mapstructure:"metrics"`
-	Apprise         AppriseConfig     `mapstructure:"apprise"`
-	Log             LogConfig         `mapstructure:"log"`
+	Interval              time.Duration             `mapstructure:"interval"`
+	Schedule              string                    `mapstructure:"schedule"`
+	FastInterval          time.Duration             `mapstructure:"fast_interval"`
+	MinTimeBetweenRuns    time.Duration             `mapstructure:"min_time_between_runs"`
+	Timezone              string                    `mapstructure:"timezone"`
+	BackupOnStartup       bool                      `mapstructure:"backup_on_startup"`
+	BackupOnShutdown      ShutdownBackupMode        `mapstructure:"backup_on_shutdown"`
+	ShutdownBackupTimeout time.Duration             `mapstructure:"shutdown_backup_timeout"`
+	CloudDownload         CloudDownloadMode         `mapstructure:"cloud_download"`
+	LudusaviPath          string                    `mapstructure:"ludusavi_path"`
+	DryRun                bool                      `mapstructure:"dry_run"`
+	Env                   map[string]string         `mapstructure:"env"`
+	CrashDump             bool                      `mapstructure:"crash_dump"`
+	WatchConfig           bool                      `mapstructure:"watch_config"`
+	BackupDestinations    []BackupDestinationConfig `mapstructure:"backup_destinations"`
+	Games                 GamesConfig               `mapstructure:"games"`
+	Retry                 RetryConfig               `mapstructure:"retry"`
+	Metrics               MetricsConfig             `mapstructure:"metrics"`
+	Apprise               AppriseConfig             `mapstructure:"apprise"`
+	NotifyWebhook         NotifyWebhookConfig       `mapstructure:"notify_webhook"`
+	HomeAssistant         HomeAssistantConfig       `mapstructure:"home_assistant"`
+	Archive               ArchiveConfig             `mapstructure:"archive"`
+	Bandwidth             BandwidthConfig           `mapstructure:"bandwidth"`
+	Tracing               TracingConfig             `mapstructure:"tracing"`
+	Server                ServerConfig              `mapstructure:"server"`
+	Control               ControlConfig             `mapstructure:"control"`
+	Watchdog              WatchdogConfig            `mapstructure:"watchdog"`
+	SelfTest              SelfTestConfig            `mapstructure:"selftest"`
+	ScanCache             ScanCacheConfig           `mapstructure:"scan_cache"`
+	Throttle              ThrottleConfig            `mapstructure:"throttle"`
+	VSS                   VSSConfig                 `mapstructure:"vss"`
+	StoreSnapshot         StoreSnapshotConfig       `mapstructure:"store_snapshot"`
+	Badge                 BadgeConfig               `mapstructure:"badge"`
+	CloudToken            CloudTokenConfig          `mapstructure:"cloud_token"`
+	Custom                CustomConfig              `mapstructure:"custom"`
+	Hooks                 HooksConfig               `mapstructure:"hooks"`
+	Extras                ExtrasConfig              `mapstructure:"extras"`
+	SizeGuard             SizeGuardConfig           `mapstructure:"size_guard"`
+	GameCount             GameCountConfig           `mapstructure:"game_count"`
+	Quarantine            QuarantineConfig          `mapstructure:"quarantine"`
+	Calendar              CalendarConfig            `mapstructure:"calendar"`
+	Offline               OfflineConfig             `mapstructure:"offline"`
+	Network               NetworkConfig             `mapstructure:"network"`
+	GUIWait               GUIWaitConfig             `mapstructure:"gui_wait"`
+	Sandbox               SandboxConfig             `mapstructure:"sandbox"`
+	Verify                VerifyConfig              `mapstructure:"verify"`
+	Outbox                OutboxConfig              `mapstructure:"outbox"`
+	OnComplete            OnCompleteConfig          `mapstructure:"on_complete"`
+	GameEvents            GameEventsConfig          `mapstructure:"game_events"`
+	History               HistoryConfig             `mapstructure:"history"`
+	Report                ReportConfig              `mapstructure:"report"`
+	Log                   LogConfig                 `mapstructure:"log"`
+
+	// Dir is the directory of the config file, or the default config
+	// directory when there is none. It is set by Loader.Load.
+	Dir string `mapstructure:"-"`
+
+	// File is the path of the config file, or empty when there is none. It
+	// is set by Loader.Load.
+	File string `mapstructure:"-"`
+}
+
+// BackupDestinationConfig is an additional directory local backups are written
+// to, besides the backup directory configured in ludusavi.
+type BackupDestinationConfig struct {
+	Name string `mapstructure:"name"`
+	Path string `mapstructure:"path"`
+	// Removable destinations are skipped when not mounted instead of failing.
+	Removable bool `mapstructure:"removable"`
+	// VolumeLabel and VolumeUUID locate a removable destination's volume
+	// wherever it is mounted; Path is then relative to the volume root.
+	VolumeLabel string `mapstructure:"volume_label"`
+	VolumeUUID  string `mapstructure:"volume_uuid"`
+	// UnseenWarningDays sends a warning once a removable destination hasn't
+	// been mounted for this many days (0 disables).
+	UnseenWarningDays int `mapstructure:"unseen_warning_days"`
+}
+
+// HasVolume returns true if the destination is located by volume.
+func (d BackupDestinationConfig) HasVolume() bool {
+	return d.VolumeLabel != "" || d.VolumeUUID != ""
+}
+
+// GamesConfig limits which games are backed up, by their titles as ludusavi
+// names them.
+type GamesConfig struct {
+	// Include, if set, limits backups to these games.
+	Include []string `mapstructure:"include"`
+	// Exclude leaves these games out of backups.
+	Exclude []string `mapstructure:"exclude"`
 }
 
 // MetricsConfig holds Prometheus metrics configuration.
 type MetricsConfig struct {
-	Enabled        bool   `mapstructure:"enabled"`
-	PushgatewayURL string `mapstructure:"pushgateway_url"`
+	Enabled        bool           `mapstructure:"enabled"`
+	Backend        MetricsBackend `mapstructure:"backend"`
+	PushgatewayURL string         `mapstructure:"pushgateway_url"`
+	// RemoteWriteURL is the Prometheus remote write endpoint of the
+	// remote_write backend, and VictoriaMetricsURL the VictoriaMetrics of
+	// the victoriametrics backend. Both are sent Headers with each request.
+	RemoteWriteURL     string            `mapstructure:"remote_write_url"`
+	VictoriaMetricsURL string            `mapstructure:"victoriametrics_url"`
+	Headers            map[string]string `mapstructure:"headers"`
+	// ListenAddress is where serve exposes /metrics with the scrape backend.
+	ListenAddress string `mapstructure:"listen_address"`
+	// PushTimeout bounds each metrics push, retries included, apart from
+	// how long the run took.
+	PushTimeout time.Duration `mapstructure:"push_timeout"`
+	// Prefix replaces the "ludusavi_" prefix of the metric names, and
+	// JobName the job label they are pushed under.
+	Prefix  string `mapstructure:"prefix"`
+	JobName string `mapstructure:"job_name"`
+
+	// TLS is the client certificate pushes authenticate with, for backends
+	// behind an ingress that requires mutual TLS.
+	TLS MetricsTLSConfig `mapstructure:"tls"`
+}
+
+// MetricsTLSConfig holds the TLS configuration of metrics pushes.
+type MetricsTLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile, if set, replaces the system CAs the backend's certificate is
+	// verified with, and ServerName the name it is verified against.
+	CAFile     string `mapstructure:"ca_file"`
+	ServerName string `mapstructure:"server_name"`
+}
+
+// IsSet returns true if any of the TLS settings is set.
+func (t MetricsTLSConfig) IsSet() bool {
+	return t != MetricsTLSConfig{}
+}
+
+// Files returns the TLS settings as loaded by http.NewTLSConfig.
+func (t MetricsTLSConfig) Files() http.TLSFiles {
+	return http.TLSFiles{
+		CertFile:   t.CertFile,
+		KeyFile:    t.KeyFile,
+		CAFile:     t.CAFile,
+		ServerName: t.ServerName,
+	}
 }
 
 // RetryConfig holds HTTP retry configuration.
@@ -42,6 +180,524 @@ type AppriseConfig struct {
 	URL     string      `mapstructure:"url"`
 	Key     string      `mapstructure:"key"`
 	Notify  NotifyLevel `mapstructure:"notify"`
+	// EscalateAfter, if set, escalates failure notifications once this many
+	// runs in a row have failed: they are sent as errors, and also to
+	// EscalateKey if set.
+	EscalateAfter int `mapstructure:"escalate_after"`
+	// EscalateKey is the Apprise key of the targets escalated failures are
+	// sent to on top of those of Key, such as a phone push service.
+	EscalateKey string `mapstructure:"escalate_key"`
+}
+
+// NotifyWebhookConfig holds configuration for sending notifications to a
+// webhook, such as a Slack or Teams incoming webhook, with a payload
+// rendered from a Go template.
+type NotifyWebhookConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// Template is the text/template the payload is rendered from, executed
+	// with the notification. TemplateFile, if set, is the file it is read
+	// from instead, relative to the config file.
+	Template     string            `mapstructure:"template"`
+	TemplateFile string            `mapstructure:"template_file"`
+	ContentType  string            `mapstructure:"content_type"`
+	Headers      map[string]string `mapstructure:"headers"`
+	// Secret, if set, signs the payloads with HMAC-SHA256 over their
+	// timestamp and body, like the on_complete webhook.
+	Secret string `mapstructure:"secret"`
+}
+
+// NotificationsEnabled returns true if notifications are sent anywhere.
+func (c *Config) NotificationsEnabled() bool {
+	return c.Apprise.Enabled || c.NotifyWebhook.Enabled
+}
+
+// NotifyWebhookTemplate returns the template of notify_webhook payloads,
+// read from notify_webhook.template_file if set.
+func (c *Config) NotifyWebhookTemplate() (string, error) {
+	if c.NotifyWebhook.TemplateFile == "" {
+		return c.NotifyWebhook.Template, nil
+	}
+	path := c.NotifyWebhook.TemplateFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.Dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read notify_webhook.template_file: %w", err)
+	}
+	return string(data), nil
+}
+
+// HomeAssistantConfig holds Home Assistant REST API configuration.
+type HomeAssistantConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// Token is a long-lived access token.
+	Token        string `mapstructure:"token"`
+	EntityPrefix string `mapstructure:"entity_prefix"`
+	// WebhookID, if set, serves POST /api/webhook/<webhook_id> on the
+	// embedded HTTP server to trigger a run.
+	WebhookID string `mapstructure:"webhook_id"`
+}
+
+// ArchiveConfig holds archive export configuration.
+type ArchiveConfig struct {
+	Enabled      bool                       `mapstructure:"enabled"`
+	Source       string                     `mapstructure:"source"`
+	Prefix       string                     `mapstructure:"prefix"`
+	Resume       bool                       `mapstructure:"resume"`
+	StagingDir   string                     `mapstructure:"staging_dir"`
+	Destinations []ArchiveDestinationConfig `mapstructure:"destinations"`
+}
+
+// ArchiveDestinationConfig holds configuration for a single archive destination.
+// Which fields are used depends on Type.
+type ArchiveDestinationConfig struct {
+	Type ArchiveDestinationType `mapstructure:"type"`
+	Path string                 `mapstructure:"path"`
+
+	// SFTP settings
+	Host           string `mapstructure:"host"`
+	Port           int    `mapstructure:"port"`
+	User           string `mapstructure:"user"`
+	KeyFile        string `mapstructure:"key_file"`
+	KnownHostsFile string `mapstructure:"known_hosts_file"`
+
+	// S3 settings
+	Endpoint         string            `mapstructure:"endpoint"`
+	Region           string            `mapstructure:"region"`
+	Bucket           string            `mapstructure:"bucket"`
+	Prefix           string            `mapstructure:"prefix"`
+	AccessKeyID      string            `mapstructure:"access_key_id"`
+	SecretAccessKey  string            `mapstructure:"secret_access_key"`
+	StorageClass     string            `mapstructure:"storage_class"`
+	Tags             map[string]string `mapstructure:"tags"`
+	VirtualHostStyle bool              `mapstructure:"virtual_host_style"`
+	PartSizeMB       int               `mapstructure:"part_size_mb"`
+	Concurrency      int               `mapstructure:"concurrency"`
+
+	// WebDAV settings (user is shared with SFTP)
+	URL         string `mapstructure:"url"`
+	Password    string `mapstructure:"password"`
+	ChunkSizeMB int    `mapstructure:"chunk_size_mb"`
+
+	// Local settings (path is shared with SFTP)
+	OfflineRetries    int           `mapstructure:"offline_retries"`
+	OfflineRetryDelay time.Duration `mapstructure:"offline_retry_delay"`
+}
+
+// BandwidthConfig holds time-of-day upload bandwidth limits.
+type BandwidthConfig struct {
+	DefaultLimitKBps int                   `mapstructure:"default_limit_kbps"`
+	Rules            []BandwidthRuleConfig `mapstructure:"rules"`
+}
+
+// BandwidthRuleConfig limits bandwidth between two times of day (HH:MM, local time).
+type BandwidthRuleConfig struct {
+	Start     string `mapstructure:"start"`
+	End       string `mapstructure:"end"`
+	LimitKBps int    `mapstructure:"limit_kbps"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Endpoint    string            `mapstructure:"endpoint"`
+	ServiceName string            `mapstructure:"service_name"`
+	Headers     map[string]string `mapstructure:"headers"`
+}
+
+// ServerConfig holds the embedded HTTP server configuration (serve mode only).
+type ServerConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	ListenAddress string `mapstructure:"listen_address"`
+	Debug         bool   `mapstructure:"debug"`
+	// PublicURL, if set, is the server's URL as reached from where
+	// notifications are read; failure notifications link to it for
+	// acknowledging them.
+	PublicURL string `mapstructure:"public_url"`
+	// Secret authenticates the requests that start or pause backups, sent
+	// as a bearer token or used to sign them like the on_complete webhook.
+	// Without it, those are only served on the control channel.
+	Secret string `mapstructure:"secret"`
+}
+
+// ControlConfig holds the configuration of the control channel the trigger
+// command reaches the service through (serve mode only).
+type ControlConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Path is the Unix socket, or the named pipe on Windows, of the
+	// channel, or empty for DefaultControlPath.
+	Path string `mapstructure:"path"`
+}
+
+// ControlPath returns the path of the control channel.
+func (c *Config) ControlPath() (string, error) {
+	if c.Control.Path != "" {
+		return c.Control.Path, nil
+	}
+	return DefaultControlPath()
+}
+
+// WatchdogConfig holds scheduler watchdog configuration (serve mode only).
+type WatchdogConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	RunTimeout time.Duration `mapstructure:"run_timeout"`
+}
+
+// SelfTestConfig holds startup self-test configuration (serve mode only).
+type SelfTestConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Critical are the components whose failure keeps the service from
+	// starting; the failure of others is only logged.
+	Critical []SelfTestComponent `mapstructure:"critical"`
+}
+
+// ScanCacheConfig holds configuration for skipping backups when no save files
+// changed.
+type ScanCacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	MaxAge  time.Duration `mapstructure:"max_age"`
+}
+
+// ThrottleConfig holds configuration for spreading local backup writes out
+// over time.
+type ThrottleConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	BatchSize  int           `mapstructure:"batch_size"`
+	BatchPause time.Duration `mapstructure:"batch_pause"`
+}
+
+// VSSConfig holds Volume Shadow Copy configuration (Windows only).
+type VSSConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Volumes []string `mapstructure:"volumes"`
+}
+
+// StoreSnapshotConfig holds configuration for btrfs or ZFS snapshots of the
+// backup store.
+type StoreSnapshotConfig struct {
+	Enabled bool              `mapstructure:"enabled"`
+	Type    StoreSnapshotType `mapstructure:"type"`
+	// Source is the btrfs subvolume path or ZFS dataset name.
+	Source string `mapstructure:"source"`
+	// Dir is where btrfs snapshots are created (btrfs only).
+	Dir        string `mapstructure:"dir"`
+	Prefix     string `mapstructure:"prefix"`
+	Keep       int    `mapstructure:"keep"`
+	PreBackup  bool   `mapstructure:"pre_backup"`
+	PostBackup bool   `mapstructure:"post_backup"`
+}
+
+// BadgeConfig holds configuration for the status badge.
+type BadgeConfig struct {
+	// Path is a file the badge is written to, in addition to /badge.svg.
+	Path  string `mapstructure:"path"`
+	Label string `mapstructure:"label"`
+	// StaleAfter is how old the last successful backup may be before the
+	// badge turns yellow; 0 means three backup intervals.
+	StaleAfter time.Duration `mapstructure:"stale_after"`
+}
+
+// CloudTokenConfig holds configuration for the cloud token expiry check.
+type CloudTokenConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RclonePath is the rclone binary ludusavi uses; empty looks it up in PATH.
+	RclonePath string `mapstructure:"rclone_path"`
+	// Remotes limits the check to these remotes; empty checks all of them.
+	Remotes  []string `mapstructure:"remotes"`
+	WarnDays int      `mapstructure:"warn_days"`
+}
+
+// HookOperations are the operations of a run that can have hooks of their
+// own in hooks.operations.
+var HookOperations = []string{
+	"cloud_download", "cloud_upload", "backup", "fast_backup", "game_backup", "custom", "extras", "archive",
+}
+
+// HooksConfig holds the commands run before and after backup runs and
+// their operations. Each command is a program and its arguments.
+type HooksConfig struct {
+	// Timeout limits how long each hook command may run.
+	Timeout    time.Duration `mapstructure:"timeout"`
+	PreBackup  []string      `mapstructure:"pre_backup"`
+	PostBackup []string      `mapstructure:"post_backup"`
+	// Operations are the hooks of single operations, by operation.
+	Operations map[string]HookCommandsConfig `mapstructure:"operations"`
+}
+
+// HookCommandsConfig holds the commands run before and after an operation.
+type HookCommandsConfig struct {
+	Pre  []string `mapstructure:"pre"`
+	Post []string `mapstructure:"post"`
+}
+
+// Enabled returns true if any hook command is set.
+func (c *HooksConfig) Enabled() bool {
+	if len(c.PreBackup) > 0 || len(c.PostBackup) > 0 {
+		return true
+	}
+	for _, op := range c.Operations {
+		if len(op.Pre) > 0 || len(op.Post) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks if the hooks configuration is valid.
+func (c *HooksConfig) Validate() error {
+	if c.Enabled() && c.Timeout <= 0 {
+		return fmt.Errorf("hooks.timeout must be positive")
+	}
+	for op := range c.Operations {
+		if !slices.Contains(HookOperations, op) {
+			return fmt.Errorf("hooks.operations has unknown operation %q, must be one of: %s", op, strings.Join(HookOperations, ", "))
+		}
+	}
+	return nil
+}
+
+// CustomConfig holds configuration for backing up games that ludusavi's
+// manifest doesn't cover.
+type CustomConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Path is the directory custom games are backed up into, one
+	// directory per game.
+	Path string `mapstructure:"path"`
+	// Timeout limits how long each game's backup command may run.
+	Timeout time.Duration      `mapstructure:"timeout"`
+	Games   []CustomGameConfig `mapstructure:"games"`
+	// Presets adds built-in games for emulators; those not installed are
+	// skipped.
+	Presets []CustomPreset `mapstructure:"presets"`
+}
+
+// CustomGameConfig is a custom game, backed up by copying Paths or by running
+// Command.
+type CustomGameConfig struct {
+	Title   string   `mapstructure:"title"`
+	Paths   []string `mapstructure:"paths"`
+	Command []string `mapstructure:"command"`
+}
+
+// ExtrasConfig holds configuration for backing up screenshots and game config
+// files alongside the saves.
+type ExtrasConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Path is the directory extras are backed up into.
+	Path string `mapstructure:"path"`
+	// Screenshots are globs of screenshot files or directories. Copies are
+	// kept when the originals are removed.
+	Screenshots []string `mapstructure:"screenshots"`
+	// Configs are globs of game config files or directories, such as
+	// graphics settings in .ini files.
+	Configs []string `mapstructure:"configs"`
+}
+
+// SizeGuardConfig holds the limits above which a run or a single game's saves
+// trigger a warning, to catch games dumping replays or logs into their save
+// folder. Zero disables a limit.
+type SizeGuardConfig struct {
+	// MaxRunGB limits the saves processed by a run.
+	MaxRunGB float64 `mapstructure:"max_run_gb"`
+	// MaxGameGB limits the size of a single game's saves.
+	MaxGameGB float64 `mapstructure:"max_game_gb"`
+}
+
+// OfflineConfig holds configuration for offline mode, which probes the
+// network before cloud uploads, metrics pushes and notifications, and skips
+// them while it is down instead of letting each time out.
+type OfflineConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ProbeAddress is the host:port dialed to tell whether the network is up.
+	ProbeAddress string        `mapstructure:"probe_address"`
+	ProbeTimeout time.Duration `mapstructure:"probe_timeout"`
+}
+
+// NetworkConfig holds configuration for how the HTTP clients of metrics,
+// notifications and Home Assistant resolve and connect to servers.
+type NetworkConfig struct {
+	// IPFamily restricts connections to IPv4 or IPv6.
+	IPFamily IPFamily `mapstructure:"ip_family"`
+	// DNSServers, if set, are the DNS servers host names are resolved with
+	// instead of the system resolver, tried in order. The port defaults to
+	// 53.
+	DNSServers []string `mapstructure:"dns_servers"`
+	// HappyEyeballsDelay is how long a connection over IPv6 is given before
+	// one over IPv4 is raced against it; 0 disables the race.
+	HappyEyeballsDelay time.Duration `mapstructure:"happy_eyeballs_delay"`
+
+	// MaxIdleConns is how many idle connections are kept open to each
+	// server for later requests; 0 keeps Go's default of 2.
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+	// IdleConnTimeout is how long an idle connection is kept open; 0 keeps
+	// it open until the server closes it.
+	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
+	// DisableKeepAlives closes each connection after its request.
+	DisableKeepAlives bool `mapstructure:"disable_keep_alives"`
+}
+
+// DialConfig returns the network settings as used by http.NewDialer.
+func (n NetworkConfig) DialConfig() http.DialConfig {
+	cfg := http.DialConfig{
+		Network:       n.IPFamily.Network(),
+		FallbackDelay: n.HappyEyeballsDelay,
+	}
+	if cfg.FallbackDelay == 0 {
+		cfg.FallbackDelay = -1
+	}
+	for _, server := range n.DNSServers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		cfg.DNSServers = append(cfg.DNSServers, server)
+	}
+	return cfg
+}
+
+// TransportConfig returns the connection reuse settings as used by
+// http.WithTransportConfig.
+func (n NetworkConfig) TransportConfig() http.TransportConfig {
+	return http.TransportConfig{
+		MaxIdleConnsPerHost: n.MaxIdleConns,
+		IdleConnTimeout:     n.IdleConnTimeout,
+		DisableKeepAlives:   n.DisableKeepAlives,
+	}
+}
+
+// GUIWaitConfig holds configuration for waiting for another running ludusavi
+// instance, such as the GUI, to exit before a run invokes ludusavi.
+type GUIWaitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxWait is how long a run waits before backing up anyway.
+	MaxWait time.Duration `mapstructure:"max_wait"`
+}
+
+// SandboxConfig holds configuration for hardening the ludusavi process
+// against a tampered binary.
+type SandboxConfig struct {
+	// Enabled runs ludusavi with reduced privileges, on Linux and Windows.
+	Enabled bool `mapstructure:"enabled"`
+	// RestrictEnv passes ludusavi only the variables of the runner's
+	// environment it needs, besides the configured env.
+	RestrictEnv bool `mapstructure:"restrict_env"`
+	// BinarySHA256 is the hex SHA-256 digest the ludusavi binary must have,
+	// if set.
+	BinarySHA256 string `mapstructure:"binary_sha256"`
+}
+
+// VerifyConfig holds configuration for verifying the ludusavi binary, and
+// the release builds downloaded by validate --fix, against published minisign
+// signatures and checksums.
+type VerifyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Strict refuses binaries and downloads that fail verification, or have
+	// nothing to verify them against, rather than warn about them.
+	Strict bool `mapstructure:"strict"`
+	// PublicKey is the minisign public key signatures are checked with.
+	PublicKey string `mapstructure:"public_key"`
+	// Checksums is the path or URL of a checksums file listing the ludusavi
+	// binary.
+	Checksums string `mapstructure:"checksums"`
+}
+
+// OutboxConfig holds configuration for the outbox, which keeps metrics pushes
+// and notifications that could not be delivered on disk and retries them on
+// later runs.
+type OutboxConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTL is how long undelivered items are retried before being dropped.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// OnCompleteConfig holds configuration for what happens after each run, for
+// external systems rather than people.
+type OnCompleteConfig struct {
+	// WebhookURL, if set, is posted the result of each run as JSON.
+	WebhookURL string `mapstructure:"webhook_url"`
+	// WebhookSecret, if set, signs the posts with HMAC-SHA256 over their
+	// timestamp and body.
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// GameEventsConfig holds configuration for game events posted by launchers
+// such as Playnite to the embedded HTTP server (serve mode only), which back
+// up a game once its session ends.
+type GameEventsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Secret authenticates the events, sent as a bearer token or used to
+	// sign them like the on_complete webhook.
+	Secret string `mapstructure:"secret"`
+	// Debounce is how long a backup waits for further events, which it
+	// then covers too.
+	Debounce time.Duration `mapstructure:"debounce"`
+}
+
+// HistoryConfig holds configuration for the run history, which keeps the
+// results of finished runs for reports.
+type HistoryConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Retention is how long runs are kept.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// ReportConfig holds configuration for the weekly report on the backups
+// from the run history (serve mode only).
+type ReportConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Weekday and Time are when the report on the past week is made, in
+	// the configured timezone.
+	Weekday string `mapstructure:"weekday"`
+	Time    string `mapstructure:"time"`
+	// Dir, if set, is written the report as HTML and Markdown files.
+	Dir string `mapstructure:"dir"`
+	// Notify sends the report through apprise.
+	Notify bool `mapstructure:"notify"`
+}
+
+// Schedule returns the weekday and the offset from midnight the report is
+// made at.
+func (r ReportConfig) Schedule() (time.Weekday, time.Duration, error) {
+	weekday := -1
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(r.Weekday, day.String()) {
+			weekday = int(day)
+		}
+	}
+	if weekday < 0 {
+		return 0, 0, fmt.Errorf("report.weekday must be a day of the week, e.g. monday")
+	}
+	at, err := time.Parse(timeOfDayFormat, r.Time)
+	if err != nil {
+		return 0, 0, fmt.Errorf("report.time must be a time of day (HH:MM)")
+	}
+	return time.Weekday(weekday), time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// GameCountConfig holds configuration for the game count regression check,
+// which warns when ludusavi suddenly finds far fewer games than usual, as
+// after a broken manifest update or a moved Steam library.
+type GameCountConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DropPercent is how far, in percent, the number of games may fall below
+	// the rolling average before it is a regression.
+	DropPercent int `mapstructure:"drop_percent"`
+	// Window is the number of full backups in the rolling average.
+	Window int `mapstructure:"window"`
+}
+
+// QuarantineConfig holds configuration for quarantining games that fail to
+// back up run after run, such as a game with a save path that can't be read.
+// Quarantined games are left out of backups, so the rest of the library backs
+// up cleanly, and are tried again every RetryInterval until they succeed.
+type QuarantineConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// After is how many backups in a row a game must fail to be quarantined.
+	After int `mapstructure:"after"`
+	// RetryInterval is how often quarantined games are tried again.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
 }
 
 // LogConfig holds logging configuration.
@@ -49,6 +705,11 @@ type LogConfig struct {
 	Level     string `mapstructure:"level"`
 	Output    string `mapstructure:"output"`
 	MaxSizeMB int    `mapstructure:"max_size_mb"`
+	// Burst is how many warnings or errors with the same message are logged
+	// per BurstWindow before further repeats are summarized. Zero disables
+	// the limit.
+	Burst       int           `mapstructure:"burst"`
+	BurstWindow time.Duration `mapstructure:"burst_window"`
 }
 
 // Loader handles configuration loading from multiple sources.
@@ -85,6 +746,17 @@ func (l *Loader) Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := l.restoreEnvCase(&cfg); err != nil {
+		return nil, err
+	}
+
+	if path := l.v.ConfigFileUsed(); path != "" {
+		cfg.Dir = filepath.Dir(path)
+		cfg.File = path
+	} else if dir, err := DefaultConfigDir(); err == nil {
+		cfg.Dir = dir
+	}
+
 	// Set default log path if not specified.
 	// This is done after loading because the default path depends on the config directory.
 	if cfg.Log.Output == "" {
@@ -105,25 +777,184 @@ func (l *Loader) Load() (*Config, error) {
 // setDefaults sets default values for all configuration options.
 func (l *Loader) setDefaults() {
 	l.v.SetDefault("interval", DefaultInterval)
+	l.v.SetDefault("schedule", DefaultSchedule)
+	l.v.SetDefault("fast_interval", DefaultFastInterval)
+	l.v.SetDefault("min_time_between_runs", DefaultMinTimeBetweenRuns)
+	l.v.SetDefault("timezone", DefaultTimezone)
 	l.v.SetDefault("backup_on_startup", DefaultBackupOnStartup)
+	l.v.SetDefault("backup_on_shutdown", string(DefaultBackupOnShutdown))
+	l.v.SetDefault("shutdown_backup_timeout", DefaultShutdownBackupTimeout)
+	l.v.SetDefault("cloud_download", string(DefaultCloudDownload))
 	l.v.SetDefault("ludusavi_path", "")
 	l.v.SetDefault("dry_run", false)
+	l.v.SetDefault("crash_dump", DefaultCrashDump)
+	l.v.SetDefault("watch_config", DefaultWatchConfig)
 
 	l.v.SetDefault("retry.max_attempts", DefaultRetryMaxAttempts)
 	l.v.SetDefault("retry.initial_delay", DefaultRetryInitialDelay)
 	l.v.SetDefault("retry.max_delay", DefaultRetryMaxDelay)
 
 	l.v.SetDefault("metrics.enabled", DefaultMetricsEnabled)
+	l.v.SetDefault("metrics.backend", string(DefaultMetricsBackend))
 	l.v.SetDefault("metrics.pushgateway_url", DefaultMetricsPushgatewayURL)
+	l.v.SetDefault("metrics.remote_write_url", "")
+	l.v.SetDefault("metrics.victoriametrics_url", "")
+	l.v.SetDefault("metrics.push_timeout", DefaultMetricsPushTimeout)
+	l.v.SetDefault("metrics.listen_address", DefaultMetricsListenAddress)
+	l.v.SetDefault("metrics.prefix", DefaultMetricsPrefix)
+	l.v.SetDefault("metrics.job_name", DefaultMetricsJobName)
+	l.v.SetDefault("metrics.tls.cert_file", "")
+	l.v.SetDefault("metrics.tls.key_file", "")
+	l.v.SetDefault("metrics.tls.ca_file", "")
+	l.v.SetDefault("metrics.tls.server_name", "")
 
 	l.v.SetDefault("apprise.enabled", DefaultAppriseEnabled)
 	l.v.SetDefault("apprise.url", DefaultAppriseURL)
 	l.v.SetDefault("apprise.key", DefaultAppriseKey)
 	l.v.SetDefault("apprise.notify", string(DefaultAppriseNotify))
+	l.v.SetDefault("apprise.escalate_after", DefaultAppriseEscalateAfter)
+	l.v.SetDefault("apprise.escalate_key", "")
+
+	// Notification webhook defaults
+	l.v.SetDefault("notify_webhook.enabled", DefaultNotifyWebhookEnabled)
+	l.v.SetDefault("notify_webhook.url", "")
+	l.v.SetDefault("notify_webhook.template", DefaultNotifyWebhookTemplate)
+	l.v.SetDefault("notify_webhook.template_file", "")
+	l.v.SetDefault("notify_webhook.content_type", DefaultNotifyWebhookContentType)
+	l.v.SetDefault("notify_webhook.secret", "")
+
+	l.v.SetDefault("home_assistant.enabled", DefaultHomeAssistantEnabled)
+	l.v.SetDefault("home_assistant.url", DefaultHomeAssistantURL)
+	l.v.SetDefault("home_assistant.token", "")
+	l.v.SetDefault("home_assistant.entity_prefix", DefaultHomeAssistantEntityPrefix)
+	l.v.SetDefault("home_assistant.webhook_id", "")
+
+	l.v.SetDefault("archive.enabled", DefaultArchiveEnabled)
+	l.v.SetDefault("archive.source", "")
+	l.v.SetDefault("archive.prefix", DefaultArchivePrefix)
+	l.v.SetDefault("archive.resume", DefaultArchiveResume)
+	l.v.SetDefault("archive.staging_dir", "")
+
+	l.v.SetDefault("bandwidth.default_limit_kbps", DefaultBandwidthLimitKBps)
+
+	l.v.SetDefault("tracing.enabled", DefaultTracingEnabled)
+	l.v.SetDefault("tracing.endpoint", DefaultTracingEndpoint)
+	l.v.SetDefault("tracing.service_name", DefaultTracingServiceName)
+
+	l.v.SetDefault("server.enabled", DefaultServerEnabled)
+	l.v.SetDefault("server.listen_address", DefaultServerListenAddress)
+	l.v.SetDefault("server.debug", DefaultServerDebug)
+	l.v.SetDefault("server.public_url", "")
+	l.v.SetDefault("server.secret", "")
+
+	// Control channel defaults
+	l.v.SetDefault("control.enabled", DefaultControlEnabled)
+	l.v.SetDefault("control.path", "")
+
+	l.v.SetDefault("watchdog.enabled", DefaultWatchdogEnabled)
+	l.v.SetDefault("watchdog.run_timeout", DefaultWatchdogRunTimeout)
+
+	// Self-test defaults
+	l.v.SetDefault("selftest.enabled", DefaultSelfTestEnabled)
+	l.v.SetDefault("selftest.critical", []string{string(SelfTestLudusavi)})
+
+	l.v.SetDefault("scan_cache.enabled", DefaultScanCacheEnabled)
+	l.v.SetDefault("scan_cache.max_age", DefaultScanCacheMaxAge)
+
+	l.v.SetDefault("throttle.enabled", DefaultThrottleEnabled)
+	l.v.SetDefault("throttle.batch_size", DefaultThrottleBatchSize)
+	l.v.SetDefault("throttle.batch_pause", DefaultThrottleBatchPause)
+
+	l.v.SetDefault("vss.enabled", DefaultVSSEnabled)
+	l.v.SetDefault("vss.volumes", []string{DefaultVSSVolume})
+
+	l.v.SetDefault("store_snapshot.enabled", DefaultStoreSnapshotEnabled)
+	l.v.SetDefault("store_snapshot.prefix", DefaultStoreSnapshotPrefix)
+	l.v.SetDefault("store_snapshot.keep", DefaultStoreSnapshotKeep)
+	l.v.SetDefault("store_snapshot.pre_backup", DefaultStoreSnapshotPreBackup)
+	l.v.SetDefault("store_snapshot.post_backup", DefaultStoreSnapshotPostBackup)
+
+	l.v.SetDefault("badge.label", DefaultBadgeLabel)
+	l.v.SetDefault("badge.stale_after", DefaultBadgeStaleAfter)
+	l.v.SetDefault("cloud_token.enabled", DefaultCloudTokenEnabled)
+	l.v.SetDefault("cloud_token.rclone_path", "")
+	l.v.SetDefault("cloud_token.warn_days", DefaultCloudTokenWarnDays)
+	l.v.SetDefault("custom.enabled", DefaultCustomEnabled)
+	l.v.SetDefault("custom.path", "")
+	l.v.SetDefault("custom.timeout", DefaultCustomTimeout)
+	l.v.SetDefault("hooks.timeout", DefaultHooksTimeout)
+	l.v.SetDefault("extras.enabled", DefaultExtrasEnabled)
+	l.v.SetDefault("extras.path", "")
+	l.v.SetDefault("size_guard.max_run_gb", DefaultSizeGuardMaxRunGB)
+	l.v.SetDefault("size_guard.max_game_gb", DefaultSizeGuardMaxGameGB)
+	l.v.SetDefault("game_count.enabled", DefaultGameCountEnabled)
+	l.v.SetDefault("game_count.drop_percent", DefaultGameCountDropPercent)
+	l.v.SetDefault("game_count.window", DefaultGameCountWindow)
+	l.v.SetDefault("quarantine.enabled", DefaultQuarantineEnabled)
+	l.v.SetDefault("quarantine.after", DefaultQuarantineAfter)
+	l.v.SetDefault("quarantine.retry_interval", DefaultQuarantineRetryInterval)
+
+	// Offline defaults
+	l.v.SetDefault("offline.enabled", DefaultOfflineEnabled)
+	l.v.SetDefault("offline.probe_address", DefaultOfflineProbeAddress)
+	l.v.SetDefault("offline.probe_timeout", DefaultOfflineProbeTimeout)
+
+	// Games defaults
+	l.v.SetDefault("games.include", []string{})
+	l.v.SetDefault("games.exclude", []string{})
+
+	// Network defaults
+	l.v.SetDefault("network.ip_family", string(DefaultNetworkIPFamily))
+	l.v.SetDefault("network.dns_servers", []string{})
+	l.v.SetDefault("network.happy_eyeballs_delay", DefaultNetworkHappyEyeballsDelay)
+	l.v.SetDefault("network.max_idle_conns", DefaultNetworkMaxIdleConns)
+	l.v.SetDefault("network.idle_conn_timeout", DefaultNetworkIdleConnTimeout)
+	l.v.SetDefault("network.disable_keep_alives", DefaultNetworkDisableKeepAlives)
+
+	// GUI wait defaults
+	l.v.SetDefault("gui_wait.enabled", DefaultGUIWaitEnabled)
+	l.v.SetDefault("gui_wait.max_wait", DefaultGUIWaitMaxWait)
+
+	// Sandbox defaults
+	l.v.SetDefault("sandbox.enabled", DefaultSandboxEnabled)
+	l.v.SetDefault("sandbox.restrict_env", DefaultSandboxRestrictEnv)
+	l.v.SetDefault("sandbox.binary_sha256", "")
+
+	// Verify defaults
+	l.v.SetDefault("verify.enabled", DefaultVerifyEnabled)
+	l.v.SetDefault("verify.strict", DefaultVerifyStrict)
+	l.v.SetDefault("verify.public_key", "")
+	l.v.SetDefault("verify.checksums", "")
+
+	// Outbox defaults
+	l.v.SetDefault("outbox.enabled", DefaultOutboxEnabled)
+	l.v.SetDefault("outbox.ttl", DefaultOutboxTTL)
+
+	// On complete defaults
+	l.v.SetDefault("on_complete.webhook_url", "")
+	l.v.SetDefault("on_complete.webhook_secret", "")
+
+	// Game events defaults
+	l.v.SetDefault("game_events.enabled", DefaultGameEventsEnabled)
+	l.v.SetDefault("game_events.secret", "")
+	l.v.SetDefault("game_events.debounce", DefaultGameEventsDebounce)
+
+	// History defaults
+	l.v.SetDefault("history.enabled", DefaultHistoryEnabled)
+	l.v.SetDefault("history.retention", DefaultHistoryRetention)
+
+	// Report defaults
+	l.v.SetDefault("report.enabled", DefaultReportEnabled)
+	l.v.SetDefault("report.weekday", DefaultReportWeekday)
+	l.v.SetDefault("report.time", DefaultReportTime)
+	l.v.SetDefault("report.dir", "")
+	l.v.SetDefault("report.notify", DefaultReportNotify)
 
 	l.v.SetDefault("log.level", DefaultLogLevel)
 	l.v.SetDefault("log.output", "")
 	l.v.SetDefault("log.max_size_mb", DefaultLogMaxSizeMB)
+	l.v.SetDefault("log.burst", DefaultLogBurst)
+	l.v.SetDefault("log.burst_window", DefaultLogBurstWindow)
 }
 
 // setupEnvBindings configures environment variable bindings.
@@ -163,6 +994,45 @@ func (l *Loader) loadConfigFile() error {
 	return nil
 }
 
+// restoreEnvCase restores the case of the variable names in the env table,
+// which viper lowercases along with every other key. Windows ignores the case
+// of environment variables, but elsewhere RCLONE_CONFIG and rclone_config are
+// different variables.
+func (l *Loader) restoreEnvCase(cfg *Config) error {
+	path := l.v.ConfigFileUsed()
+	if len(cfg.Env) == 0 || path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file struct {
+		Env map[string]any `toml:"env" yaml:"env" json:"env"`
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	default:
+		err = toml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read env from config file: %w", err)
+	}
+
+	for name := range file.Env {
+		lower := strings.ToLower(name)
+		if value, ok := cfg.Env[lower]; ok && name != lower {
+			delete(cfg.Env, lower)
+			cfg.Env[name] = value
+		}
+	}
+	return nil
+}
+
 // Set sets a configuration value (for CLI flag overrides).
 func (l *Loader) Set(key string, value interface{}) {
 	l.v.Set(key, value)
@@ -173,21 +1043,132 @@ func (l *Loader) ConfigFileUsed() string {
 	return l.v.ConfigFileUsed()
 }
 
+// Location returns the time zone wall-clock times in the config are in: the
+// configured timezone, or the system time zone if unset or unknown.
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
 // Validate checks if the configuration is valid.
 func (c *Config) Validate() error {
 	if c.Interval < time.Minute {
 		return fmt.Errorf("interval must be at least 1 minute, got %s", c.Interval)
 	}
 
+	if c.FastInterval != 0 {
+		if c.FastInterval < time.Minute {
+			return fmt.Errorf("fast_interval must be at least 1 minute, got %s", c.FastInterval)
+		}
+		if c.FastInterval >= c.Interval && c.Schedule == "" {
+			return fmt.Errorf("fast_interval must be shorter than interval")
+		}
+	}
+	if c.Schedule != "" {
+		schedule, err := cron.Parse(c.Schedule)
+		if err != nil {
+			return fmt.Errorf("schedule: %w", err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("schedule: %q is never due", c.Schedule)
+		}
+	}
+	if c.MinTimeBetweenRuns < 0 {
+		return fmt.Errorf("min_time_between_runs must not be negative")
+	}
+
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("timezone: unknown time zone %q", c.Timezone)
+		}
+	}
+
+	if c.BackupOnShutdown != ShutdownBackupOff {
+		if !c.BackupOnShutdown.IsValid() {
+			return fmt.Errorf("backup_on_shutdown must be one of: preview, changed")
+		}
+		if c.ShutdownBackupTimeout <= 0 {
+			return fmt.Errorf("shutdown_backup_timeout must be positive when backup_on_shutdown is set")
+		}
+	}
+
+	if !c.CloudDownload.IsValid() {
+		return fmt.Errorf("cloud_download must be one of: startup, always, or empty to disable")
+	}
+
 	if c.LudusaviPath != "" {
 		if _, err := os.Stat(c.LudusaviPath); err != nil {
 			return fmt.Errorf("ludusavi_path does not exist: %s", c.LudusaviPath)
 		}
 	}
 
+	for name, value := range c.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("env: invalid variable name %q", name)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("env: %s contains a NUL character", name)
+		}
+	}
+
 	if c.Metrics.Enabled {
-		if c.Metrics.PushgatewayURL == "" {
-			return fmt.Errorf("metrics.pushgateway_url is required when metrics is enabled")
+		switch c.Metrics.Backend {
+		case MetricsBackendPushgateway:
+			if c.Metrics.PushgatewayURL == "" {
+				return fmt.Errorf("metrics.pushgateway_url is required when metrics is enabled")
+			}
+		case MetricsBackendRemoteWrite:
+			if c.Metrics.RemoteWriteURL == "" {
+				return fmt.Errorf("metrics.remote_write_url is required when metrics.backend is %q", c.Metrics.Backend)
+			}
+		case MetricsBackendVictoriaMetrics:
+			if c.Metrics.VictoriaMetricsURL == "" {
+				return fmt.Errorf("metrics.victoriametrics_url is required when metrics.backend is %q", c.Metrics.Backend)
+			}
+		case MetricsBackendScrape:
+			if _, _, err := net.SplitHostPort(c.Metrics.ListenAddress); err != nil {
+				return fmt.Errorf("metrics.listen_address must be host:port: %w", err)
+			}
+			if c.Server.Enabled && c.Metrics.ListenAddress == c.Server.ListenAddress {
+				return fmt.Errorf("metrics.listen_address must differ from server.listen_address")
+			}
+		default:
+			return fmt.Errorf("metrics.backend must be one of: pushgateway, remote_write, victoriametrics, scrape")
+		}
+		if c.Metrics.PushTimeout <= 0 {
+			return fmt.Errorf("metrics.push_timeout must be positive")
+		}
+		if !metricPrefixPattern.MatchString(c.Metrics.Prefix) {
+			return fmt.Errorf("metrics.prefix must be a valid Prometheus metric name prefix, got %q", c.Metrics.Prefix)
+		}
+		if c.Metrics.JobName == "" {
+			return fmt.Errorf("metrics.job_name is required when metrics is enabled")
+		}
+		if c.Metrics.TLS.IsSet() {
+			if _, err := http.NewTLSConfig(c.Metrics.TLS.Files()); err != nil {
+				return fmt.Errorf("metrics.tls: %w", err)
+			}
+		}
+	}
+
+	if c.HomeAssistant.Enabled {
+		if c.HomeAssistant.URL == "" {
+			return fmt.Errorf("home_assistant.url is required when home_assistant is enabled")
+		}
+		if c.HomeAssistant.Token == "" {
+			return fmt.Errorf("home_assistant.token is required when home_assistant is enabled")
+		}
+		if !entityPrefixPattern.MatchString(c.HomeAssistant.EntityPrefix) {
+			return fmt.Errorf("home_assistant.entity_prefix must contain only lowercase letters, digits and underscores")
+		}
+		if c.HomeAssistant.WebhookID != "" && !webhookIDPattern.MatchString(c.HomeAssistant.WebhookID) {
+			return fmt.Errorf("home_assistant.webhook_id must be at least 16 letters, digits, '-' or '_'")
 		}
 	}
 
@@ -203,6 +1184,47 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("retry.max_delay must be >= retry.initial_delay")
 	}
 
+	names := make(map[string]bool, len(c.BackupDestinations))
+	for i, d := range c.BackupDestinations {
+		if d.Name == "" {
+			return fmt.Errorf("backup_destinations[%d]: name is required", i)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("backup_destinations[%d]: duplicate name %q", i, d.Name)
+		}
+		names[d.Name] = true
+		if d.HasVolume() {
+			if !d.Removable {
+				return fmt.Errorf("backup_destinations[%d]: removable must be true when volume_label or volume_uuid is set", i)
+			}
+			if filepath.IsAbs(d.Path) || filepath.VolumeName(d.Path) != "" {
+				return fmt.Errorf("backup_destinations[%d]: path must be relative to the volume when volume_label or volume_uuid is set", i)
+			}
+		} else if d.Path == "" {
+			return fmt.Errorf("backup_destinations[%d]: path is required", i)
+		}
+		if d.UnseenWarningDays < 0 {
+			return fmt.Errorf("backup_destinations[%d]: unseen_warning_days cannot be negative", i)
+		}
+		if d.UnseenWarningDays > 0 && !d.Removable {
+			return fmt.Errorf("backup_destinations[%d]: unseen_warning_days requires removable = true", i)
+		}
+	}
+
+	for _, title := range c.Games.Include {
+		if strings.TrimSpace(title) == "" {
+			return fmt.Errorf("games.include cannot contain empty titles")
+		}
+	}
+	for _, title := range c.Games.Exclude {
+		if strings.TrimSpace(title) == "" {
+			return fmt.Errorf("games.exclude cannot contain empty titles")
+		}
+		if slices.Contains(c.Games.Include, title) {
+			return fmt.Errorf("games.exclude: %q is also in games.include", title)
+		}
+	}
+
 	if c.Apprise.Enabled {
 		if c.Apprise.URL == "" {
 			return fmt.Errorf("apprise.url is required when apprise is enabled")
@@ -210,8 +1232,247 @@ func (c *Config) Validate() error {
 		if c.Apprise.Key == "" {
 			return fmt.Errorf("apprise.key is required when apprise is enabled")
 		}
-		if !c.Apprise.Notify.IsValid() {
-			return fmt.Errorf("apprise.notify must be one of: error, warning, always")
+		if c.Apprise.EscalateAfter < 0 {
+			return fmt.Errorf("apprise.escalate_after must not be negative")
+		}
+		if c.Apprise.EscalateKey != "" && c.Apprise.EscalateAfter == 0 {
+			return fmt.Errorf("apprise.escalate_after is required when apprise.escalate_key is set")
+		}
+	}
+
+	if c.NotifyWebhook.Enabled {
+		if !strings.HasPrefix(c.NotifyWebhook.URL, "http://") && !strings.HasPrefix(c.NotifyWebhook.URL, "https://") {
+			return fmt.Errorf("notify_webhook.url must start with http:// or https://")
+		}
+		if c.NotifyWebhook.ContentType == "" {
+			return fmt.Errorf("notify_webhook.content_type is required when notify_webhook is enabled")
+		}
+		text, err := c.NotifyWebhookTemplate()
+		if err != nil {
+			return err
+		}
+		if _, err := notify.ParseTemplate(text); err != nil {
+			return fmt.Errorf("notify_webhook.template is invalid: %w", err)
+		}
+	}
+
+	// The level applies to every notifier
+	if c.NotificationsEnabled() && !c.Apprise.Notify.IsValid() {
+		return fmt.Errorf("apprise.notify must be one of: error, warning, always")
+	}
+
+	if c.Archive.Enabled {
+		if err := c.Archive.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Bandwidth.Validate(); err != nil {
+		return err
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+		}
+		if !strings.HasPrefix(c.Tracing.Endpoint, "http://") && !strings.HasPrefix(c.Tracing.Endpoint, "https://") {
+			return fmt.Errorf("tracing.endpoint must start with http:// or https://")
+		}
+	}
+
+	if c.Server.Enabled {
+		if c.Server.ListenAddress == "" {
+			return fmt.Errorf("server.listen_address is required when server is enabled")
+		}
+		if _, _, err := net.SplitHostPort(c.Server.ListenAddress); err != nil {
+			return fmt.Errorf("server.listen_address must be host:port: %w", err)
+		}
+		if c.Server.PublicURL != "" &&
+			!strings.HasPrefix(c.Server.PublicURL, "http://") && !strings.HasPrefix(c.Server.PublicURL, "https://") {
+			return fmt.Errorf("server.public_url must start with http:// or https://")
+		}
+	}
+
+	if c.Watchdog.Enabled && c.Watchdog.RunTimeout != 0 && c.Watchdog.RunTimeout < time.Minute {
+		return fmt.Errorf("watchdog.run_timeout must be at least 1 minute, or 0 for no deadline")
+	}
+
+	for _, component := range c.SelfTest.Critical {
+		if !component.IsValid() {
+			return fmt.Errorf("selftest.critical must only contain: ludusavi, metrics, notify")
+		}
+	}
+
+	if c.ScanCache.Enabled && c.ScanCache.MaxAge < time.Minute {
+		return fmt.Errorf("scan_cache.max_age must be at least 1 minute")
+	}
+
+	if c.Throttle.Enabled {
+		if c.Throttle.BatchSize < 1 {
+			return fmt.Errorf("throttle.batch_size must be at least 1")
+		}
+		if c.Throttle.BatchPause < 0 {
+			return fmt.Errorf("throttle.batch_pause cannot be negative")
+		}
+	}
+
+	if c.VSS.Enabled {
+		if len(c.VSS.Volumes) == 0 {
+			return fmt.Errorf("vss.volumes is required when vss is enabled")
+		}
+		for i, v := range c.VSS.Volumes {
+			if !driveLetterPattern.MatchString(v) {
+				return fmt.Errorf("vss.volumes[%d]: must be a drive letter such as \"C:\"", i)
+			}
+		}
+	}
+
+	if c.StoreSnapshot.Enabled {
+		if err := c.StoreSnapshot.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Badge.StaleAfter < 0 {
+		return fmt.Errorf("badge.stale_after cannot be negative")
+	}
+
+	if c.CloudToken.Enabled && c.CloudToken.WarnDays < 1 {
+		return fmt.Errorf("cloud_token.warn_days must be at least 1")
+	}
+
+	if c.Custom.Enabled {
+		if err := c.Custom.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Hooks.Validate(); err != nil {
+		return err
+	}
+
+	if c.Extras.Enabled {
+		if c.Extras.Path == "" {
+			return fmt.Errorf("extras.path is required when extras is enabled")
+		}
+		if len(c.Extras.Screenshots) == 0 && len(c.Extras.Configs) == 0 {
+			return fmt.Errorf("extras.screenshots or extras.configs must contain at least one path when extras is enabled")
+		}
+	}
+
+	if c.SizeGuard.MaxRunGB < 0 {
+		return fmt.Errorf("size_guard.max_run_gb cannot be negative")
+	}
+	if c.SizeGuard.MaxGameGB < 0 {
+		return fmt.Errorf("size_guard.max_game_gb cannot be negative")
+	}
+
+	if err := c.Calendar.Validate(c.Location()); err != nil {
+		return err
+	}
+
+	if c.GameCount.Enabled {
+		if c.GameCount.DropPercent < 1 || c.GameCount.DropPercent > 99 {
+			return fmt.Errorf("game_count.drop_percent must be between 1 and 99")
+		}
+		if c.GameCount.Window < 2 {
+			return fmt.Errorf("game_count.window must be at least 2")
+		}
+	}
+
+	if c.Quarantine.Enabled {
+		if c.Quarantine.After < 1 {
+			return fmt.Errorf("quarantine.after must be at least 1")
+		}
+		if c.Quarantine.RetryInterval <= 0 {
+			return fmt.Errorf("quarantine.retry_interval must be positive")
+		}
+	}
+
+	if c.Offline.Enabled {
+		if _, _, err := net.SplitHostPort(c.Offline.ProbeAddress); err != nil {
+			return fmt.Errorf("offline.probe_address must be host:port: %w", err)
+		}
+		if c.Offline.ProbeTimeout <= 0 {
+			return fmt.Errorf("offline.probe_timeout must be positive")
+		}
+	}
+
+	if !c.Network.IPFamily.IsValid() {
+		return fmt.Errorf("network.ip_family must be one of: ipv4, ipv6, or empty for either")
+	}
+	for _, server := range c.Network.DNSServers {
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("network.dns_servers must be IP addresses, optionally with a port, got %q", server)
+		}
+	}
+	if c.Network.HappyEyeballsDelay < 0 {
+		return fmt.Errorf("network.happy_eyeballs_delay cannot be negative")
+	}
+	if c.Network.MaxIdleConns < 0 {
+		return fmt.Errorf("network.max_idle_conns cannot be negative")
+	}
+	if c.Network.IdleConnTimeout < 0 {
+		return fmt.Errorf("network.idle_conn_timeout cannot be negative")
+	}
+
+	if c.GUIWait.Enabled && c.GUIWait.MaxWait <= 0 {
+		return fmt.Errorf("gui_wait.max_wait must be positive")
+	}
+
+	if c.Sandbox.Enabled && runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		return fmt.Errorf("sandbox.enabled is only supported on Linux and Windows")
+	}
+	if sum := c.Sandbox.BinarySHA256; sum != "" {
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("sandbox.binary_sha256 must be a hex SHA-256 digest")
+		}
+	}
+
+	if c.Verify.PublicKey != "" {
+		if _, err := verify.ParsePublicKey(c.Verify.PublicKey); err != nil {
+			return fmt.Errorf("verify.public_key must be a minisign public key: %w", err)
+		}
+	}
+
+	if c.Outbox.Enabled && c.Outbox.TTL <= 0 {
+		return fmt.Errorf("outbox.ttl must be positive")
+	}
+
+	if c.OnComplete.WebhookURL != "" &&
+		!strings.HasPrefix(c.OnComplete.WebhookURL, "http://") && !strings.HasPrefix(c.OnComplete.WebhookURL, "https://") {
+		return fmt.Errorf("on_complete.webhook_url must start with http:// or https://")
+	}
+
+	if c.GameEvents.Enabled {
+		if !c.Server.Enabled {
+			return fmt.Errorf("game_events requires server.enabled = true")
+		}
+		if c.GameEvents.Secret == "" {
+			return fmt.Errorf("game_events.secret is required when game_events is enabled")
+		}
+		if c.GameEvents.Debounce < 0 {
+			return fmt.Errorf("game_events.debounce must not be negative")
+		}
+	}
+
+	if c.History.Enabled && c.History.Retention <= 0 {
+		return fmt.Errorf("history.retention must be positive")
+	}
+
+	if c.Report.Enabled {
+		if !c.History.Enabled {
+			return fmt.Errorf("report requires history.enabled = true")
+		}
+		if _, _, err := c.Report.Schedule(); err != nil {
+			return err
+		}
+		if c.Report.Dir == "" && !(c.Report.Notify && c.NotificationsEnabled()) {
+			return fmt.Errorf("report.dir, or report.notify with notifications enabled, is required when report is enabled")
 		}
 	}
 
@@ -229,6 +1490,155 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("log.max_size_mb must be at least 1")
 	}
 
+	if c.Log.Burst < 0 {
+		return fmt.Errorf("log.burst cannot be negative")
+	}
+
+	if c.Log.Burst > 0 && c.Log.BurstWindow <= 0 {
+		return fmt.Errorf("log.burst_window must be positive")
+	}
+
+	return nil
+}
+
+// Validate checks if the custom games configuration is valid.
+func (c *CustomConfig) Validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("custom.path is required when custom is enabled")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("custom.timeout must be positive")
+	}
+	if len(c.Games) == 0 && len(c.Presets) == 0 {
+		return fmt.Errorf("custom.games or custom.presets must contain at least one game when custom is enabled")
+	}
+	for i, p := range c.Presets {
+		if !p.IsValid() {
+			return fmt.Errorf("custom.presets[%d] must be one of: retroarch, dolphin, pcsx2, yuzu, ryujinx, minecraft", i)
+		}
+	}
+
+	titles := make(map[string]bool, len(c.Games))
+	for i, g := range c.Games {
+		if g.Title == "" {
+			return fmt.Errorf("custom.games[%d]: title is required", i)
+		}
+		// Titles name the game directories, which may be case-insensitive
+		if titles[strings.ToLower(g.Title)] {
+			return fmt.Errorf("custom.games[%d]: duplicate title %q", i, g.Title)
+		}
+		titles[strings.ToLower(g.Title)] = true
+		if (len(g.Paths) == 0) == (len(g.Command) == 0) {
+			return fmt.Errorf("custom.games[%d]: exactly one of paths or command is required", i)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the archive configuration is valid.
+func (a *ArchiveConfig) Validate() error {
+	if a.Source == "" {
+		return fmt.Errorf("archive.source is required when archive is enabled")
+	}
+	if len(a.Destinations) == 0 {
+		return fmt.Errorf("archive.destinations must contain at least one destination when archive is enabled")
+	}
+
+	for i, d := range a.Destinations {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("archive.destinations[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks if the archive destination configuration is valid.
+func (d *ArchiveDestinationConfig) Validate() error {
+	switch d.Type {
+	case ArchiveDestinationSFTP:
+		if d.Host == "" {
+			return fmt.Errorf("host is required for sftp destinations")
+		}
+		if d.User == "" {
+			return fmt.Errorf("user is required for sftp destinations")
+		}
+		if d.KeyFile == "" {
+			return fmt.Errorf("key_file is required for sftp destinations")
+		}
+		if d.Path == "" {
+			return fmt.Errorf("path is required for sftp destinations")
+		}
+		if d.Port < 0 || d.Port > 65535 {
+			return fmt.Errorf("port must be between 0 and 65535")
+		}
+	case ArchiveDestinationS3:
+		if d.Endpoint == "" {
+			return fmt.Errorf("endpoint is required for s3 destinations")
+		}
+		if !strings.HasPrefix(d.Endpoint, "http://") && !strings.HasPrefix(d.Endpoint, "https://") {
+			return fmt.Errorf("endpoint must start with http:// or https://")
+		}
+		if d.Bucket == "" {
+			return fmt.Errorf("bucket is required for s3 destinations")
+		}
+		if d.PartSizeMB > 0 && d.PartSizeMB < MinS3PartSizeMB {
+			return fmt.Errorf("part_size_mb must be at least %d (or negative to disable multipart uploads)", MinS3PartSizeMB)
+		}
+		if d.Concurrency < 0 {
+			return fmt.Errorf("concurrency cannot be negative")
+		}
+	case ArchiveDestinationWebDAV:
+		if d.URL == "" {
+			return fmt.Errorf("url is required for webdav destinations")
+		}
+		if !strings.HasPrefix(d.URL, "http://") && !strings.HasPrefix(d.URL, "https://") {
+			return fmt.Errorf("url must start with http:// or https://")
+		}
+		if d.ChunkSizeMB < 0 {
+			return fmt.Errorf("chunk_size_mb cannot be negative")
+		}
+	case ArchiveDestinationLocal:
+		if d.Path == "" {
+			return fmt.Errorf("path is required for local destinations")
+		}
+		if d.OfflineRetries < 0 {
+			return fmt.Errorf("offline_retries cannot be negative")
+		}
+		if d.OfflineRetryDelay < 0 {
+			return fmt.Errorf("offline_retry_delay cannot be negative")
+		}
+	default:
+		return fmt.Errorf("type must be one of: sftp, s3, webdav, local")
+	}
+
+	return nil
+}
+
+// Validate checks if the bandwidth configuration is valid.
+func (b *BandwidthConfig) Validate() error {
+	if b.DefaultLimitKBps < 0 {
+		return fmt.Errorf("bandwidth.default_limit_kbps cannot be negative")
+	}
+
+	for i, rule := range b.Rules {
+		start, err := time.Parse(timeOfDayFormat, rule.Start)
+		if err != nil {
+			return fmt.Errorf("bandwidth.rules[%d]: start must be a time of day (HH:MM)", i)
+		}
+		end, err := time.Parse(timeOfDayFormat, rule.End)
+		if err != nil {
+			return fmt.Errorf("bandwidth.rules[%d]: end must be a time of day (HH:MM)", i)
+		}
+		if start.Equal(end) {
+			return fmt.Errorf("bandwidth.rules[%d]: start and end must differ", i)
+		}
+		if rule.LimitKBps < 0 {
+			return fmt.Errorf("bandwidth.rules[%d]: limit_kbps cannot be negative", i)
+		}
+	}
+
 	return nil
 }
 
@@ -244,6 +1654,29 @@ func EnsureConfigDir() (string, error) {
 	return dir, nil
 }
 
+// Validate checks the store snapshot configuration.
+func (c *StoreSnapshotConfig) Validate() error {
+	if !c.Type.IsValid() {
+		return fmt.Errorf("store_snapshot.type must be one of: btrfs, zfs")
+	}
+	if c.Source == "" {
+		return fmt.Errorf("store_snapshot.source is required when store_snapshot is enabled")
+	}
+	if c.Type == StoreSnapshotBtrfs && c.Dir == "" {
+		return fmt.Errorf("store_snapshot.dir is required for btrfs snapshots")
+	}
+	if c.Prefix == "" || strings.ContainsAny(c.Prefix, "/@ ") {
+		return fmt.Errorf("store_snapshot.prefix must be non-empty and contain no '/', '@' or spaces")
+	}
+	if c.Keep < 0 {
+		return fmt.Errorf("store_snapshot.keep cannot be negative")
+	}
+	if !c.PreBackup && !c.PostBackup {
+		return fmt.Errorf("store_snapshot requires pre_backup or post_backup")
+	}
+	return nil
+}
+
 // WriteExampleConfig writes an example config file to the given path.
 func WriteExampleConfig(path string) error {
 	content := `# Ludusavi Runner Configuration
@@ -251,17 +1684,66 @@ func WriteExampleConfig(path string) error {
 # Backup schedule interval
 interval = "20m"
 
+# Cron expression scheduling full runs instead of interval, e.g. "0 */2 * * *"
+# (empty = use interval)
+schedule = ""
+
+# Fast cycles back up only games with changed saves between full cycles (0 to disable)
+fast_interval = "0s"
+
+# Skip game event and drive backups due sooner than this after the last run (0 to disable)
+min_time_between_runs = "0s"
+
+# Time zone of the schedule, bandwidth rules and calendar exceptions, e.g.
+# "Europe/Berlin" (empty = the system time zone)
+timezone = ""
+
 # Run backup immediately on service start
 backup_on_startup = true
 
+# Final quick backup on service stop: "" (off), "preview", or "changed"
+backup_on_shutdown = ""
+shutdown_backup_timeout = "20s"
+
+# Pull cloud backups down before backing up: "" (off), "startup", or "always"
+cloud_download = ""
+
 # Path to ludusavi binary (auto-detected if empty)
 ludusavi_path = ""
 
-# Environment variables to pass to ludusavi (useful for rclone config when running as a service)
+# Write a crash dump file to the state directory if a backup run panics
+crash_dump = false
+
+# Apply changes to this file to the running service (serve mode): interval,
+# schedule, notification settings and log level change without a restart
+watch_config = true
+
+# Additional local backup destinations, each backed up separately after the
+# main backup. Removable destinations are skipped when not mounted; with a
+# volume label or UUID they are found wherever they are mounted and backed up
+# as soon as they are plugged in (serve mode).
+# [[backup_destinations]]
+# name = "usb"
+# removable = true
+# volume_label = "BACKUP"
+# path = "ludusavi"  # relative to the volume
+# unseen_warning_days = 14
+
+# Environment variables to pass to ludusavi and the rclone it runs, such as
+# the rclone config when running as a service under another account. Names
+# are passed as written; only names, not values, are logged. Values can
+# refer to ${config_dir} (the directory of this file), ${exe_dir}, ${state_dir},
+# ${log_dir}, other variables in this table and the runner's own environment,
+# expanded at each run; $${ is a literal ${.
 # [env]
 # RCLONE_CONFIG = "C:\\Users\\username\\AppData\\Roaming\\rclone\\rclone.conf"
 # RCLONE_PASSWORD_COMMAND = "powershell C:\\path\\to\\rclone_pass.ps1"
 
+# Games to back up, by their titles in ludusavi (empty = all games found)
+[games]
+include = []
+exclude = []
+
 # HTTP retry configuration
 [retry]
 max_attempts = 3
@@ -271,7 +1753,23 @@ max_delay = "30s"
 # Prometheus metrics (optional, disabled by default)
 [metrics]
 enabled = false
+# "pushgateway", "remote_write", "victoriametrics" or "scrape"
+backend = "pushgateway"
+# Or a Unix socket, as in "unix:///run/pushgateway.sock"
 pushgateway_url = "http://pushgateway:9091"
+# remote_write_url = "http://mimir:9009/api/v1/push"
+# victoriametrics_url = "http://victoriametrics:8428"
+# Where serve exposes /metrics for Prometheus with the scrape backend
+listen_address = "127.0.0.1:9181"
+push_timeout = "30s"
+# Metric name prefix and Pushgateway job, e.g. to share a Pushgateway
+prefix = "ludusavi_"
+job_name = "ludusavi"
+# Client certificate for a backend behind a mutual TLS ingress
+# [metrics.tls]
+# cert_file = "/etc/ludusavi-runner/metrics.crt"
+# key_file = "/etc/ludusavi-runner/metrics.key"
+# ca_file = "/etc/ludusavi-runner/ca.crt"
 
 # Apprise notifications (optional, disabled by default)
 [apprise]
@@ -280,6 +1778,327 @@ url = "http://localhost:8000"
 key = "ludusavi"
 # Notification level: "error", "warning", "always"
 notify = "error"
+# Escalate failure notifications once this many runs in a row have failed,
+# also sending them to the targets of escalate_key (0 disables)
+escalate_after = 0
+escalate_key = ""
+
+# Notification webhook (optional, disabled by default)
+# POSTs notifications, at the apprise.notify level, with a payload rendered
+# from a Go template of the notification (.Title, .Body, .Level, and .Result,
+# the run, if any). The json function quotes and escapes a value.
+[notify_webhook]
+enabled = false
+url = ""
+template = '{"title": {{json .Title}}, "body": {{json .Body}}, "level": {{json .Level}}}'
+# template_file = "webhook.tmpl"  # relative to the config file
+content_type = "application/json"
+# secret = ""  # signs payloads like the on_complete webhook
+
+# Home Assistant (optional, disabled by default)
+# Sets sensor.<entity_prefix>_<operation>_last_run/_last_success and
+# binary_sensor.<entity_prefix>_<operation>_problem after each run.
+# With webhook_id set, POST /api/webhook/<webhook_id> on the HTTP server
+# triggers a run.
+[home_assistant]
+enabled = false
+url = "http://homeassistant.local:8123"
+token = ""  # long-lived access token
+entity_prefix = "ludusavi_runner"
+webhook_id = ""
+
+# Archive exports (optional, disabled by default)
+# Packs the ludusavi backup directory into a .tar.gz after each backup
+# and uploads it to every configured destination.
+[archive]
+enabled = false
+# ludusavi backup directory to archive
+source = ""
+prefix = "ludusavi"
+# Resume interrupted uploads on the next run instead of starting over
+resume = false
+
+# [[archive.destinations]]
+# type = "sftp"
+# host = "nas.local"
+# port = 22
+# user = "backup"
+# key_file = "/home/user/.ssh/id_ed25519"
+# known_hosts_file = ""  # defaults to ~/.ssh/known_hosts
+# path = "/volume1/backups/ludusavi"
+
+# [[archive.destinations]]
+# type = "s3"
+# endpoint = "https://s3.us-west-004.backblazeb2.com"
+# bucket = "game-saves"
+# prefix = "ludusavi"
+# access_key_id = ""      # defaults to AWS_ACCESS_KEY_ID
+# secret_access_key = ""  # defaults to AWS_SECRET_ACCESS_KEY
+
+# [[archive.destinations]]
+# type = "webdav"
+# url = "https://cloud.example.com/remote.php/dav/files/alice/Backups/ludusavi"
+# user = "alice"
+# password = "app-password"
+# chunk_size_mb = 50
+
+# [[archive.destinations]]
+# type = "local"
+# path = '\\nas\backups\ludusavi'  # must exist; waited for if offline
+
+# Upload bandwidth limits (optional, unlimited by default)
+# Applies to archive uploads and, through rclone's RCLONE_BWLIMIT, to cloud
+# uploads. Rules are matched in order by time of day in timezone; windows may
+# wrap past midnight. Limits are in KiB/s, 0 means unlimited.
+[bandwidth]
+default_limit_kbps = 0
+# [[bandwidth.rules]]
+# start = "08:00"
+# end = "23:00"
+# limit_kbps = 512
+
+# OpenTelemetry tracing (optional, disabled by default)
+# Exports a trace of each run (cloud upload, backup, archive, metrics push,
+# notifications) over OTLP/HTTP to a collector, Jaeger, or Tempo.
+[tracing]
+enabled = false
+endpoint = "http://localhost:4318"
+service_name = "ludusavi-runner"
+
+# Embedded HTTP server (optional, serve mode only)
+# Serves /healthz, /status and /badge.svg, and the event stream and controls used by the tui command;
+# with debug enabled also pprof and runtime statistics under /debug/. Keep it bound to
+# localhost unless you need remote access.
+[server]
+enabled = false
+listen_address = "127.0.0.1:9180"
+debug = false
+# URL the server is reached at from where notifications are read, to add
+# acknowledgment links to failure notifications (optional)
+public_url = ""
+# Authenticates the requests that run or pause backups (optional); without
+# it, only the control channel accepts them
+secret = ""
+
+# Control channel (serve mode only): a Unix socket, or a named pipe on Windows,
+# that the trigger, pause and resume commands reach the service through
+[control]
+enabled = true
+# Socket or pipe path (default: control.sock in the state directory, or
+# \\.\pipe\ludusavi-runner on Windows)
+path = ""
+
+# Scheduler watchdog (optional, serve mode only)
+# Cancels runs that exceed run_timeout, abandons those that ignore it, and
+# reports a scheduler loop that stops making progress, with a notification
+# and metric for each recovery.
+[watchdog]
+enabled = false
+run_timeout = "2h"
+
+# Startup self-test (optional, serve mode only, also "serve --selftest")
+# Previews a backup, pushes the metrics and sends a test notification before
+# the first run. The service refuses to start if a critical component fails;
+# other failures are logged as warnings. Components: ludusavi, metrics, notify
+[selftest]
+enabled = false
+critical = ["ludusavi"]
+
+# Scan cache (optional, disabled by default)
+# Skips ludusavi entirely when none of the save files from the last backup
+# changed. A full scan still runs once max_age has passed.
+[scan_cache]
+enabled = false
+max_age = "6h"
+
+# Backup throttling (optional, disabled by default)
+# Backs up batch_size games per ludusavi run with batch_pause between runs, so
+# backups to the disk games run from don't cause stutter.
+[throttle]
+enabled = false
+batch_size = 10
+batch_pause = "5s"
+
+# Volume Shadow Copy (optional, Windows only, requires administrator rights)
+# Snapshots the volumes below for the duration of each backup, exposed under
+# the "vss" directory in the state directory, e.g. ...\vss\C. Custom games
+# in ludusavi can back up locked files from there.
+[vss]
+enabled = false
+volumes = ["C:"]
+
+# btrfs/ZFS snapshots of the backup store (optional, disabled by default)
+# Snapshots the subvolume or dataset holding the backups around each full run
+# and keeps the newest "keep" snapshots.
+[store_snapshot]
+enabled = false
+type = "btrfs"  # or "zfs"
+source = ""     # btrfs subvolume path or ZFS dataset name
+dir = ""        # btrfs only: where snapshots are created
+prefix = "ludusavi-runner"
+keep = 14
+pre_backup = false
+post_backup = true
+
+# Status badge, served at /badge.svg by the HTTP server
+# e.g. "saves | backed up 12m ago ✓"; path also writes it to a file.
+[badge]
+path = ""
+label = "saves"
+stale_after = "0s"  # 0 = three intervals
+
+# Cloud token expiry check, through rclone config dump
+# Warns warn_days before a cloud remote's sign-in expires, where the backend exposes it.
+[cloud_token]
+enabled = false
+rclone_path = ""  # empty = rclone in PATH
+# remotes = ["ludusavi-1646784769"]  # empty = all remotes
+warn_days = 7
+
+# Custom games, for saves ludusavi's manifest doesn't cover (emulators, mod
+# configs). Each game is backed up into its own directory below path, by
+# copying paths (globs allowed) or by running command, which gets the
+# directory in LUDUSAVI_RUNNER_BACKUP_DIR. Runs after the ludusavi backup as
+# the "custom" operation, with its own metrics.
+[custom]
+enabled = false
+path = ""
+timeout = "10m"  # per command
+# Built-in games; those not installed are skipped
+# presets = ["retroarch", "dolphin", "pcsx2", "yuzu", "ryujinx", "minecraft"]
+#
+# [[custom.games]]
+# title = "Dolphin (GameCube)"
+# paths = ['C:\Users\username\Documents\Dolphin Emulator\GC\*']
+#
+# [[custom.games]]
+# title = "Skyrim mod list"
+# command = ["powershell", "-File", 'C:\scripts\export-modlist.ps1']
+
+# Hooks: commands run before and after each backup run, and before and after
+# single operations ([hooks.operations.<operation>] pre/post). They get the
+# result as JSON on stdin and LUDUSAVI_RUNNER_HOOK, _OPERATION, _RUN_ID and
+# _SUCCESS in the environment; a failed hook fails the run.
+[hooks]
+timeout = "5m"  # per command
+# pre_backup = ["powershell", "-File", 'C:\scripts\pause-sync.ps1']
+# post_backup = ["powershell", "-File", 'C:\scripts\resume-sync.ps1']
+
+# Screenshots and game config files, backed up after the saves as the
+# "extras" operation. Globs may start with ~ for the home directory; copies
+# of screenshots are kept when the originals are removed.
+[extras]
+enabled = false
+path = ""
+# screenshots = ['C:\Program Files (x86)\Steam\userdata\*\760\remote\*\screenshots', "~/Pictures/Screenshots"]
+# configs = ["~/Documents/My Games/*/*.ini"]
+
+# Backup size guard: warn when a run processes more than max_run_gb of saves
+# or a single game's saves grow beyond max_game_gb (0 disables each)
+[size_guard]
+max_run_gb = 0
+max_game_gb = 0
+
+# Game count regression check: warn when a full backup finds more than
+# drop_percent fewer games than the average of the last window backups
+[game_count]
+enabled = false
+drop_percent = 25
+window = 10
+
+# Game quarantine: leave games out of backups once they fail after backups in
+# a row, retrying them every retry_interval
+[quarantine]
+enabled = false
+after = 3
+retry_interval = "24h"
+
+# Offline mode: probe the network before cloud uploads, metrics pushes and
+# notifications; while it is down, skip them quickly and deliver the metrics
+# and notifications once it is back
+[offline]
+enabled = false
+probe_address = "1.1.1.1:443"
+probe_timeout = "3s"
+
+# How metrics, notifications and Home Assistant connect: over "ipv4" or
+# "ipv6" only, and through other DNS servers than the system's
+[network]
+# ip_family = "ipv4"
+# dns_servers = ["192.168.1.1"]
+happy_eyeballs_delay = "300ms"
+# Keep connections open for reuse, so frequent pushes don't each take a port
+max_idle_conns = 2
+idle_conn_timeout = "90s"
+disable_keep_alives = false
+
+# Wait up to max_wait for a running ludusavi GUI to exit before each run, so
+# they don't write to the backup directory at once
+[gui_wait]
+enabled = true
+max_wait = "2m"
+
+# Harden the ludusavi process against a tampered binary: run it with reduced
+# privileges (Linux and Windows), pass it only the environment it needs, and
+# refuse to run it unless its SHA-256 is binary_sha256, if set
+[sandbox]
+enabled = false
+restrict_env = false
+binary_sha256 = ""
+
+# Verify the ludusavi binary, and builds downloaded by validate --fix, against
+# minisign signatures (ludusavi.minisig next to it) and checksums; strict (or
+# --strict) refuses what fails rather than warn
+[verify]
+enabled = false
+strict = false
+public_key = ""
+checksums = ""
+
+# Outbox: keep metrics pushes and notifications that could not be delivered
+# and retry them on later runs, for up to ttl
+[outbox]
+enabled = false
+ttl = "24h"
+
+# Post the result of each run as JSON to a webhook, for n8n, Zapier or
+# scripts; signed with webhook_secret if set, with a timestamp against replays
+[on_complete]
+webhook_url = ""
+webhook_secret = ""
+
+# Back up a game once a launcher such as Playnite reports its session ended,
+# with POST /api/v1/events/game-stopped {"title": "..."} on the HTTP server,
+# authenticated with secret; see the README
+[game_events]
+enabled = false
+secret = ""
+debounce = "30s"
+
+# Run history: keep the results of finished runs for reports
+[history]
+enabled = true
+retention = "2160h"
+
+# Weekly report on the backups (serve mode only): run counts, failure rate,
+# size trend and fastest growing games, sent through apprise and/or written
+# to dir as HTML and Markdown
+[report]
+enabled = false
+weekday = "monday"
+time = "09:00"
+dir = ""
+notify = true
+
+# One-off schedule exceptions, in timezone; more can be added at runtime
+# through the control API
+# [[calendar.skip]]
+# date = "2026-11-14"
+# reason = "LAN party"
+#
+# [[calendar.runs]]
+# at = "2026-11-13 23:00"
+# reason = "before the LAN party"
 
 # Logging configuration
 [log]
@@ -289,6 +2108,10 @@ level = "info"
 # output = ""
 # Max log file size before rotation (MB)
 max_size_mb = 10
+# Warnings and errors with the same message logged more than burst times per
+# burst_window are summarized as "message repeated N times" (0 to disable)
+burst = 10
+burst_window = "1m"
 `
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0750); err != nil {
@@ -1,29 +1,173 @@
 // Package config handles application configuration loading and validation.
 package config
 
-import "time"
+import (
+	"regexp"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/notify"
+)
 
 // Default configuration values.
 const (
-	DefaultInterval        = 20 * time.Minute
-	DefaultBackupOnStartup = true
+	DefaultInterval              = 20 * time.Minute
+	DefaultSchedule              = ""
+	DefaultFastInterval          = time.Duration(0)
+	DefaultMinTimeBetweenRuns    = time.Duration(0)
+	DefaultTimezone              = ""
+	DefaultBackupOnStartup       = true
+	DefaultBackupOnShutdown      = ShutdownBackupOff
+	DefaultShutdownBackupTimeout = 20 * time.Second
+	DefaultCloudDownload         = CloudDownloadOff
+	DefaultCrashDump             = false
+	DefaultWatchConfig           = true
 
 	DefaultMetricsEnabled        = false
+	DefaultMetricsBackend        = MetricsBackendPushgateway
 	DefaultMetricsPushgatewayURL = ""
+	DefaultMetricsPushTimeout    = 30 * time.Second
+	DefaultMetricsPrefix         = "ludusavi_"
+	DefaultMetricsJobName        = "ludusavi"
+	DefaultMetricsListenAddress  = "127.0.0.1:9181"
 
 	DefaultRetryMaxAttempts  = 3
 	DefaultRetryInitialDelay = 5 * time.Second
 	DefaultRetryMaxDelay     = 30 * time.Second
 
-	DefaultAppriseEnabled = false
-	DefaultAppriseURL     = ""
-	DefaultAppriseKey     = ""
-	DefaultAppriseNotify  = NotifyError
+	DefaultAppriseEnabled       = false
+	DefaultAppriseURL           = ""
+	DefaultAppriseKey           = ""
+	DefaultAppriseNotify        = NotifyError
+	DefaultAppriseEscalateAfter = 0
+
+	DefaultNotifyWebhookEnabled     = false
+	DefaultNotifyWebhookContentType = notify.DefaultWebhookContentType
+	// DefaultNotifyWebhookTemplate posts the notification as JSON
+	DefaultNotifyWebhookTemplate = `{"title": {{json .Title}}, "body": {{json .Body}}, "level": {{json .Level}}}`
+
+	DefaultHomeAssistantEnabled      = false
+	DefaultHomeAssistantURL          = ""
+	DefaultHomeAssistantEntityPrefix = "ludusavi_runner"
+
+	DefaultArchiveEnabled = false
+	DefaultArchivePrefix  = "ludusavi"
+	DefaultArchiveResume  = false
+
+	// MinS3PartSizeMB is the smallest part size S3 accepts for multipart uploads.
+	MinS3PartSizeMB = 5
+
+	DefaultBandwidthLimitKBps = 0
+
+	DefaultTracingEnabled     = false
+	DefaultTracingEndpoint    = "http://localhost:4318"
+	DefaultTracingServiceName = "ludusavi-runner"
+
+	DefaultServerEnabled       = false
+	DefaultServerListenAddress = "127.0.0.1:9180"
+	DefaultServerDebug         = false
+
+	// Control channel defaults
+	DefaultControlEnabled = true
+
+	DefaultWatchdogEnabled    = false
+	DefaultWatchdogRunTimeout = 2 * time.Hour
+
+	DefaultSelfTestEnabled = false
+
+	DefaultScanCacheEnabled = false
+	DefaultScanCacheMaxAge  = 6 * time.Hour
+
+	DefaultThrottleEnabled    = false
+	DefaultThrottleBatchSize  = 10
+	DefaultThrottleBatchPause = 5 * time.Second
+
+	DefaultVSSEnabled = false
+	DefaultVSSVolume  = "C:"
+
+	DefaultStoreSnapshotEnabled    = false
+	DefaultStoreSnapshotPrefix     = "ludusavi-runner"
+	DefaultStoreSnapshotKeep       = 14
+	DefaultStoreSnapshotPreBackup  = false
+	DefaultStoreSnapshotPostBackup = true
+
+	DefaultBadgeLabel      = "saves"
+	DefaultBadgeStaleAfter = time.Duration(0)
+
+	DefaultCloudTokenEnabled  = false
+	DefaultCloudTokenWarnDays = 7
+
+	DefaultCustomEnabled = false
+	DefaultCustomTimeout = 10 * time.Minute
+	DefaultHooksTimeout  = 5 * time.Minute
+
+	DefaultExtrasEnabled = false
+
+	DefaultSizeGuardMaxRunGB  = 0.0
+	DefaultSizeGuardMaxGameGB = 0.0
+
+	DefaultGameCountEnabled     = false
+	DefaultGameCountDropPercent = 25
+	DefaultGameCountWindow      = 10
+
+	DefaultQuarantineEnabled       = false
+	DefaultQuarantineAfter         = 3
+	DefaultQuarantineRetryInterval = 24 * time.Hour
+
+	DefaultOfflineEnabled      = false
+	DefaultOfflineProbeAddress = "1.1.1.1:443"
+	DefaultOfflineProbeTimeout = 3 * time.Second
+
+	DefaultNetworkIPFamily           = IPFamilyAny
+	DefaultNetworkHappyEyeballsDelay = 300 * time.Millisecond
+	DefaultNetworkMaxIdleConns       = 2
+	DefaultNetworkIdleConnTimeout    = 90 * time.Second
+	DefaultNetworkDisableKeepAlives  = false
+
+	DefaultGUIWaitEnabled = true
+	DefaultGUIWaitMaxWait = 2 * time.Minute
+
+	DefaultSandboxEnabled     = false
+	DefaultSandboxRestrictEnv = false
+
+	DefaultVerifyEnabled = false
+	DefaultVerifyStrict  = false
+
+	DefaultOutboxEnabled = false
+	DefaultOutboxTTL     = 24 * time.Hour
+
+	DefaultGameEventsEnabled  = false
+	DefaultGameEventsDebounce = 30 * time.Second
+
+	DefaultHistoryEnabled   = true
+	DefaultHistoryRetention = 90 * 24 * time.Hour
+
+	DefaultReportEnabled = false
+	DefaultReportWeekday = "monday"
+	DefaultReportTime    = "09:00"
+	DefaultReportNotify  = true
 
-	DefaultLogLevel     = "info"
-	DefaultLogMaxSizeMB = 10
+	DefaultLogLevel       = "info"
+	DefaultLogMaxSizeMB   = 10
+	DefaultLogBurst       = 10
+	DefaultLogBurstWindow = time.Minute
 )
 
+// entityPrefixPattern matches a valid Home Assistant object ID prefix.
+var entityPrefixPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// metricPrefixPattern matches a valid Prometheus metric name prefix.
+var metricPrefixPattern = regexp.MustCompile(`^[A-Za-z_:][A-Za-z0-9_:]*$`)
+
+// webhookIDPattern matches a webhook ID that is hard to guess and safe to use
+// in a URL path.
+var webhookIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+
+// driveLetterPattern matches a Windows drive letter, e.g. "C:" or "C:\".
+var driveLetterPattern = regexp.MustCompile(`^[A-Za-z]:\\?$`)
+
+// timeOfDayFormat is the layout of times of day in the config (HH:MM).
+const timeOfDayFormat = "15:04"
+
 // NotifyLevel represents when to send notifications.
 type NotifyLevel string
 
@@ -50,3 +194,227 @@ func (n NotifyLevel) IsValid() bool {
 func (n NotifyLevel) String() string {
 	return string(n)
 }
+
+// ShutdownBackupMode selects the final backup run when the service stops.
+type ShutdownBackupMode string
+
+const (
+	// ShutdownBackupOff disables the shutdown backup.
+	ShutdownBackupOff ShutdownBackupMode = ""
+	// ShutdownBackupPreview previews a backup and logs games with unsaved changes.
+	ShutdownBackupPreview ShutdownBackupMode = "preview"
+	// ShutdownBackupChanged backs up only games with new or changed saves.
+	ShutdownBackupChanged ShutdownBackupMode = "changed"
+)
+
+// IsValid returns true if the shutdown backup mode is valid.
+func (m ShutdownBackupMode) IsValid() bool {
+	switch m {
+	case ShutdownBackupOff, ShutdownBackupPreview, ShutdownBackupChanged:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the shutdown backup mode.
+func (m ShutdownBackupMode) String() string {
+	return string(m)
+}
+
+// SelfTestComponent names a component checked by the startup self-test.
+type SelfTestComponent string
+
+const (
+	// SelfTestLudusavi previews a backup with ludusavi.
+	SelfTestLudusavi SelfTestComponent = "ludusavi"
+	// SelfTestMetrics pushes the metrics, without the results of a run.
+	SelfTestMetrics SelfTestComponent = "metrics"
+	// SelfTestNotify sends a test notification.
+	SelfTestNotify SelfTestComponent = "notify"
+)
+
+// IsValid returns true if the self-test component is valid.
+func (c SelfTestComponent) IsValid() bool {
+	switch c {
+	case SelfTestLudusavi, SelfTestMetrics, SelfTestNotify:
+		return true
+	default:
+		return false
+	}
+}
+
+// CloudDownloadMode selects when full runs pull the cloud backups down
+// before backing up.
+type CloudDownloadMode string
+
+const (
+	// CloudDownloadOff never downloads from the cloud.
+	CloudDownloadOff CloudDownloadMode = ""
+	// CloudDownloadStartup downloads in the first full run that succeeds
+	// in doing so, as when setting up a new machine.
+	CloudDownloadStartup CloudDownloadMode = "startup"
+	// CloudDownloadAlways downloads in every full run, for machines taking
+	// turns on the same games.
+	CloudDownloadAlways CloudDownloadMode = "always"
+)
+
+// IsValid returns true if the cloud download mode is valid.
+func (m CloudDownloadMode) IsValid() bool {
+	switch m {
+	case CloudDownloadOff, CloudDownloadStartup, CloudDownloadAlways:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the cloud download mode.
+func (m CloudDownloadMode) String() string {
+	return string(m)
+}
+
+// IPFamily selects the IP version HTTP clients connect over.
+type IPFamily string
+
+const (
+	// IPFamilyAny connects over IPv6 or IPv4, racing them as happy eyeballs.
+	IPFamilyAny IPFamily = ""
+	// IPFamilyIPv4 connects over IPv4 only.
+	IPFamilyIPv4 IPFamily = "ipv4"
+	// IPFamilyIPv6 connects over IPv6 only.
+	IPFamilyIPv6 IPFamily = "ipv6"
+)
+
+// IsValid returns true if the IP family is valid.
+func (f IPFamily) IsValid() bool {
+	switch f {
+	case IPFamilyAny, IPFamilyIPv4, IPFamilyIPv6:
+		return true
+	default:
+		return false
+	}
+}
+
+// Network returns the network to dial for the IP family, as net.Dial.
+func (f IPFamily) Network() string {
+	switch f {
+	case IPFamilyIPv4:
+		return "tcp4"
+	case IPFamilyIPv6:
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// MetricsBackend selects where metrics are pushed to.
+type MetricsBackend string
+
+const (
+	// MetricsBackendPushgateway pushes to a Prometheus Pushgateway.
+	MetricsBackendPushgateway MetricsBackend = "pushgateway"
+	// MetricsBackendRemoteWrite writes timestamped samples to a Prometheus
+	// remote write endpoint.
+	MetricsBackendRemoteWrite MetricsBackend = "remote_write"
+	// MetricsBackendVictoriaMetrics imports timestamped samples into
+	// VictoriaMetrics in the Prometheus text format.
+	MetricsBackendVictoriaMetrics MetricsBackend = "victoriametrics"
+	// MetricsBackendScrape serves the metrics of the latest run for
+	// Prometheus to scrape, in serve mode.
+	MetricsBackendScrape MetricsBackend = "scrape"
+)
+
+// IsValid returns true if the metrics backend is valid.
+func (b MetricsBackend) IsValid() bool {
+	switch b {
+	case MetricsBackendPushgateway, MetricsBackendRemoteWrite, MetricsBackendVictoriaMetrics, MetricsBackendScrape:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the metrics backend.
+func (b MetricsBackend) String() string {
+	return string(b)
+}
+
+// StoreSnapshotType identifies the filesystem used for backup store snapshots.
+type StoreSnapshotType string
+
+const (
+	// StoreSnapshotBtrfs snapshots a btrfs subvolume.
+	StoreSnapshotBtrfs StoreSnapshotType = "btrfs"
+	// StoreSnapshotZFS snapshots a ZFS dataset.
+	StoreSnapshotZFS StoreSnapshotType = "zfs"
+)
+
+// IsValid returns true if the snapshot type is valid.
+func (t StoreSnapshotType) IsValid() bool {
+	switch t {
+	case StoreSnapshotBtrfs, StoreSnapshotZFS:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the snapshot type.
+func (t StoreSnapshotType) String() string {
+	return string(t)
+}
+
+// CustomPreset names a built-in custom game with the save paths of an
+// emulator or game ludusavi's manifest misses.
+type CustomPreset string
+
+const (
+	// CustomPresetRetroArch backs up RetroArch saves and save states.
+	CustomPresetRetroArch CustomPreset = "retroarch"
+	// CustomPresetDolphin backs up Dolphin GameCube memory cards and Wii saves.
+	CustomPresetDolphin CustomPreset = "dolphin"
+	// CustomPresetPCSX2 backs up PCSX2 memory cards and save states.
+	CustomPresetPCSX2 CustomPreset = "pcsx2"
+	// CustomPresetYuzu backs up yuzu Switch saves.
+	CustomPresetYuzu CustomPreset = "yuzu"
+	// CustomPresetRyujinx backs up Ryujinx Switch saves.
+	CustomPresetRyujinx CustomPreset = "ryujinx"
+	// CustomPresetMinecraft backs up Minecraft Java Edition worlds.
+	CustomPresetMinecraft CustomPreset = "minecraft"
+)
+
+// IsValid returns true if the preset exists.
+func (p CustomPreset) IsValid() bool {
+	switch p {
+	case CustomPresetRetroArch, CustomPresetDolphin, CustomPresetPCSX2,
+		CustomPresetYuzu, CustomPresetRyujinx, CustomPresetMinecraft:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the string representation of the preset.
+func (p CustomPreset) String() string {
+	return string(p)
+}
+
+// ArchiveDestinationType identifies an archive destination implementation.
+type ArchiveDestinationType string
+
+const (
+	// ArchiveDestinationSFTP uploads archives over SFTP.
+	ArchiveDestinationSFTP ArchiveDestinationType = "sftp"
+	// ArchiveDestinationS3 uploads archives to an S3-compatible object store.
+	ArchiveDestinationS3 ArchiveDestinationType = "s3"
+	// ArchiveDestinationWebDAV uploads archives to a WebDAV collection.
+	ArchiveDestinationWebDAV ArchiveDestinationType = "webdav"
+	// ArchiveDestinationLocal copies archives to a local directory or network share.
+	ArchiveDestinationLocal ArchiveDestinationType = "local"
+)
+
+// String returns the string representation of the destination type.
+func (t ArchiveDestinationType) String() string {
+	return string(t)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Change is a setting that differs between two configs.
+type Change struct {
+	// Key is the dotted key of the setting, such as "apprise.notify".
+	Key string
+	// Old and New are the values before and after, unless Hidden.
+	Old string
+	New string
+	// Hidden is set for credentials, and for tables and lists of tables,
+	// which may hold some: their values are left out.
+	Hidden bool
+}
+
+// String describes the change, such as `interval: "20m0s" -> "30m0s"`.
+func (c Change) String() string {
+	if c.Hidden {
+		return c.Key + " changed"
+	}
+	return fmt.Sprintf("%s: %q -> %q", c.Key, c.Old, c.New)
+}
+
+// Diff returns the settings that differ from old in cfg, in the order of
+// the config struct.
+func Diff(old, cfg *Config) []Change {
+	return diff(reflect.ValueOf(*old), reflect.ValueOf(*cfg), "")
+}
+
+// diff returns the settings that differ between a and b, values of the same
+// config struct whose keys start with prefix.
+func diff(a, b reflect.Value, prefix string) []Change {
+	var changes []Change
+	for i := range a.NumField() {
+		field := a.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+
+		x, y := a.Field(i), b.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			changes = append(changes, diff(x, y, key+".")...)
+			continue
+		}
+		if reflect.DeepEqual(x.Interface(), y.Interface()) {
+			continue
+		}
+
+		change := Change{Key: key, Hidden: !shown(key, field.Type)}
+		if !change.Hidden {
+			change.Old, change.New = display(key, x), display(key, y)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// shown returns true if the values of the key, of type t, can be logged:
+// plain values and lists of them, but no credentials.
+func shown(key string, t reflect.Type) bool {
+	if slices.Contains(secretKeys, key) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return true
+}
+
+// display formats the value of the key, without the credentials in service
+// URLs.
+func display(key string, v reflect.Value) string {
+	s := fmt.Sprint(v.Interface())
+	if slices.Contains(urlKeys, key) {
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			u.User = nil
+			s = u.String()
+		}
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// urlKeys are the config keys holding the URLs of HTTP services.
+var urlKeys = []string{
+	"metrics.pushgateway_url",
+	"metrics.remote_write_url",
+	"metrics.victoriametrics_url",
+	"apprise.url",
+	"home_assistant.url",
+	"tracing.endpoint",
+	"on_complete.webhook_url",
+	"server.public_url",
+}
+
+// schemeTypo matches a misspelled or malformed http or https scheme, such as
+// "htp://", "HTTPS:/" or "http//".
+var schemeTypo = regexp.MustCompile(`(?i)^h+t+p+(s?)(?::/*|/+)`)
+
+// URLFix is a URL corrected in the config file.
+type URLFix struct {
+	Key  string
+	From string
+	To   string
+}
+
+// FixURLSchemes corrects obviously wrong schemes of the HTTP service URLs in
+// the config file at path, such as a missing "http://" or a "htp://", and
+// returns the URLs it corrected. Values are replaced in place, keeping the
+// rest of the file as it is; a value that isn't quoted exactly once in the
+// file is left alone.
+func FixURLSchemes(path string) ([]URLFix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	file, err := parseFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	content := string(data)
+	var fixes []URLFix
+	for _, key := range urlKeys {
+		value, ok := lookup(file, key)
+		if !ok || value == "" {
+			continue
+		}
+		fixed, ok := fixURLScheme(value)
+		if !ok {
+			continue
+		}
+		quoted := strconv.Quote(value)
+		if strings.Count(content, quoted) != 1 {
+			quoted = "'" + value + "'"
+			if strings.Count(content, quoted) != 1 {
+				continue
+			}
+		}
+		content = strings.Replace(content, quoted, strconv.Quote(fixed), 1)
+		fixes = append(fixes, URLFix{Key: key, From: value, To: fixed})
+	}
+	if len(fixes) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return fixes, nil
+}
+
+// parseFile parses the data of the config file at path, in the format its
+// extension names.
+func parseFile(path string, data []byte) (map[string]any, error) {
+	var file map[string]any
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	default:
+		err = toml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return file, nil
+}
+
+// encodeFile encodes a parsed config file in the format the extension of
+// path names.
+func encodeFile(path string, file map[string]any) ([]byte, error) {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(file)
+	case ".json":
+		data, err = json.MarshalIndent(file, "", "  ")
+	default:
+		data, err = toml.Marshal(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config file: %w", err)
+	}
+	return data, nil
+}
+
+// lookup returns the string at the dotted key in a parsed config file.
+func lookup(file map[string]any, key string) (string, bool) {
+	section, name, _ := strings.Cut(key, ".")
+	table, ok := file[section].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	value, ok := table[name].(string)
+	return value, ok
+}
+
+// fixURLScheme returns u with a misspelled http or https scheme corrected, or
+// http:// added when it has no scheme at all, and whether it changed.
+func fixURLScheme(u string) (string, bool) {
+	fixed := u
+	if m := schemeTypo.FindStringSubmatch(u); m != nil {
+		scheme := "http"
+		if m[1] != "" {
+			scheme = "https"
+		}
+		fixed = scheme + "://" + u[len(m[0]):]
+	} else if !strings.Contains(u, "://") {
+		fixed = "http://" + u
+	}
+	return fixed, fixed != u
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LudusaviConfigFileName is the name of ludusavi's own config file.
+	LudusaviConfigFileName = "config.yaml"
+	// ludusaviPortableFileName is the file next to the ludusavi binary that
+	// makes it keep its config there.
+	ludusaviPortableFileName = "ludusavi.portable"
+)
+
+// LudusaviConfig holds the settings of ludusavi's own config file that
+// bear on how the runner drives it.
+type LudusaviConfig struct {
+	Backup struct {
+		Path string `yaml:"path"`
+	} `yaml:"backup"`
+	Cloud struct {
+		// Remote is the rclone remote, unset until one is configured.
+		Remote any    `yaml:"remote"`
+		Path   string `yaml:"path"`
+		// Synchronize makes ludusavi sync each backup to the cloud itself.
+		Synchronize bool `yaml:"synchronize"`
+	} `yaml:"cloud"`
+}
+
+// LudusaviConfigPath returns the path of ludusavi's config file for the
+// ludusavi binary at binaryPath: next to it in portable mode, otherwise in
+// the user's config directory.
+func LudusaviConfigPath(binaryPath string) (string, error) {
+	if binaryPath != "" {
+		dir := filepath.Dir(binaryPath)
+		if _, err := os.Stat(filepath.Join(dir, ludusaviPortableFileName)); err == nil {
+			return filepath.Join(dir, LudusaviConfigFileName), nil
+		}
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ludusavi", LudusaviConfigFileName), nil
+}
+
+// ReadLudusaviConfig reads ludusavi's config file at path.
+func ReadLudusaviConfig(path string) (*LudusaviConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ludusavi config: %w", err)
+	}
+	var lc LudusaviConfig
+	if err := yaml.Unmarshal(data, &lc); err != nil {
+		return nil, fmt.Errorf("failed to parse ludusavi config: %w", err)
+	}
+	return &lc, nil
+}
+
+// Lint returns warnings about settings of ludusavi's config lc that
+// conflict with c, such as ludusavi syncing to the cloud on its own while
+// the runner uploads too.
+func (c *Config) Lint(lc *LudusaviConfig) []string {
+	var warnings []string
+
+	// Each full run starts with a cloud upload
+	if lc.Cloud.Synchronize {
+		warnings = append(warnings, "ludusavi syncs each backup to the cloud itself (cloud.synchronize) "+
+			"and the runner uploads before each full backup too, so saves are synced twice; "+
+			`turn off "synchronize automatically" in ludusavi's cloud settings`)
+	}
+	if lc.Cloud.Remote == nil {
+		warnings = append(warnings, "no cloud remote is set up in ludusavi, so the cloud upload of each full backup fails; "+
+			"set one up in ludusavi's cloud settings")
+	}
+
+	backupPath := expandHome(lc.Backup.Path)
+	if backupPath == "" {
+		return warnings
+	}
+	for _, dest := range c.BackupDestinations {
+		// The path of a destination located by volume is relative to it
+		if dest.HasVolume() || dest.Path == "" {
+			continue
+		}
+		if overlaps(expandHome(dest.Path), backupPath) {
+			warnings = append(warnings, fmt.Sprintf("backup destination %q (%s) overlaps ludusavi's backup path %s, "+
+				"so backups are copied into each other", dest.Name, dest.Path, lc.Backup.Path))
+		}
+	}
+	if c.Archive.Enabled && c.Archive.Source != "" && !samePath(expandHome(c.Archive.Source), backupPath) {
+		warnings = append(warnings, fmt.Sprintf("archive.source %s is not ludusavi's backup path %s, "+
+			"so archives don't hold the backups the runner makes", c.Archive.Source, lc.Backup.Path))
+	}
+	return warnings
+}
+
+// expandHome expands a leading "~" in path to the home directory, as
+// ludusavi does.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// overlaps returns true if a and b are the same directory or one is inside
+// the other.
+func overlaps(a, b string) bool {
+	return within(a, b) || within(b, a)
+}
+
+// within returns true if path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(foldPath(dir), foldPath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// samePath returns true if a and b are the same path.
+func samePath(a, b string) bool {
+	return foldPath(a) == foldPath(b)
+}
+
+// foldPath cleans path, and lowercases it on Windows, whose paths are
+// case-insensitive.
+func foldPath(path string) string {
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	return path
+}
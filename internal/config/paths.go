@@ -15,10 +15,51 @@ const (
 	LogFileName = "ludusavi-runner.log"
 	// EnvPrefix is the prefix for environment variables.
 	EnvPrefix = "LUDUSAVI_RUNNER"
+	// PortableFlagFileName is the file next to the executable that turns on
+	// portable mode.
+	PortableFlagFileName = "portable.flag"
 )
 
-// DefaultConfigDir returns the default configuration directory for the current OS.
+// portableDir holds the config file, logs and state in portable mode.
+var portableDir string
+
+// SetPortableDir turns on portable mode, keeping the config file, logs and
+// state in dir instead of the per-user directories, for running off an
+// external drive across machines. An empty dir turns portable mode off.
+func SetPortableDir(dir string) {
+	portableDir = dir
+}
+
+// PortableDir returns the portable mode directory, or "" outside portable mode.
+func PortableDir() string {
+	return portableDir
+}
+
+// ExecutableDir returns the directory of the running executable.
+func ExecutableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// HasPortableFlag reports whether dir contains the portable mode flag file.
+func HasPortableFlag(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, PortableFlagFileName))
+	return err == nil
+}
+
+// DefaultConfigDir returns the default configuration directory for the current
+// OS, or the portable mode directory.
 func DefaultConfigDir() (string, error) {
+	if portableDir != "" {
+		return portableDir, nil
+	}
+
 	switch runtime.GOOS {
 	case "windows":
 		// %APPDATA%\ludusavi-runner
@@ -64,8 +105,13 @@ func DefaultConfigPath() (string, error) {
 }
 
 // DefaultLogPath returns the full path to the default log file.
-// The log file is stored in the same directory as the config file.
+// The log file is stored in the same directory as the config file, or in
+// portable mode in the logs directory.
 func DefaultLogPath() (string, error) {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "logs", LogFileName), nil
+	}
+
 	dir, err := DefaultConfigDir()
 	if err != nil {
 		return "", err
@@ -73,8 +119,201 @@ func DefaultLogPath() (string, error) {
 	return filepath.Join(dir, LogFileName), nil
 }
 
-// DefaultLogDir returns the default log directory for the current OS.
+// DefaultStateDir returns the default directory for runtime state for the
+// current OS, or the state directory in the portable mode directory.
+func DefaultStateDir() (string, error) {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "state"), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		// %LOCALAPPDATA%\ludusavi-runner
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			localAppData = filepath.Join(home, "AppData", "Local")
+		}
+		return filepath.Join(localAppData, AppName), nil
+
+	case "darwin":
+		// ~/Library/Application Support/ludusavi-runner
+		return DefaultConfigDir()
+
+	default:
+		// Linux: $XDG_STATE_HOME/ludusavi-runner or ~/.local/state/ludusavi-runner
+		if xdgState := os.Getenv("XDG_STATE_HOME"); xdgState != "" {
+			return filepath.Join(xdgState, AppName), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "state", AppName), nil
+	}
+}
+
+// DefaultControlPath returns the default path of the control channel of a
+// running service: a named pipe on Windows, the same for every user so the
+// CLI reaches a service running as another account, or a Unix socket in the
+// state directory elsewhere.
+func DefaultControlPath() (string, error) {
+	if runtime.GOOS == "windows" {
+		return `\\.\pipe\` + AppName, nil
+	}
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "control.sock"), nil
+}
+
+// DefaultStagingDir returns the default directory archives are staged in
+// when resumable exports are enabled.
+func DefaultStagingDir() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "staging"), nil
+}
+
+// DefaultCrashDumpDir returns the default directory crash dumps are written to.
+func DefaultCrashDumpDir() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "crashes"), nil
+}
+
+// DefaultScanCachePath returns the default path of the scan cache.
+func DefaultScanCachePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "scan-cache.json"), nil
+}
+
+// DefaultDestinationStatePath returns the default path of the file recording
+// when removable backup destinations were last seen.
+func DefaultDestinationStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "destinations.json"), nil
+}
+
+// DefaultGameCountStatePath returns the default path of the file recording
+// the games found by recent full backups.
+func DefaultGameCountStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "game-counts.json"), nil
+}
+
+// DefaultQuarantineStatePath returns the default path of the file recording
+// the games failing to back up and those in quarantine.
+func DefaultQuarantineStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quarantine.json"), nil
+}
+
+// DefaultFailureStatePath returns the default path of the file recording how
+// many runs in a row have failed.
+func DefaultFailureStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "failures.json"), nil
+}
+
+// DefaultMaintenancePath returns the default path of the file that turns
+// maintenance mode on.
+func DefaultMaintenancePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "maintenance.json"), nil
+}
+
+// DefaultOutboxPath returns the default path of the file holding the metrics
+// pushes and notifications waiting to be delivered.
+func DefaultOutboxPath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "outbox.json"), nil
+}
+
+// DefaultHistoryPath returns the default path of the run history.
+func DefaultHistoryPath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// DefaultReportStatePath returns the default path of the file recording when
+// the last weekly report was made.
+func DefaultReportStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "report.json"), nil
+}
+
+// DefaultRunResultPath returns the default path of the file the run command
+// writes the outcome of its backup to.
+func DefaultRunResultPath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "last-run.json"), nil
+}
+
+// DefaultCalendarStatePath returns the default path of the file holding the
+// calendar exceptions added at runtime.
+func DefaultCalendarStatePath() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "calendar.json"), nil
+}
+
+// DefaultVSSLinkDir returns the default directory shadow copies are exposed in.
+func DefaultVSSLinkDir() (string, error) {
+	dir, err := DefaultStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vss"), nil
+}
+
+// DefaultLogDir returns the default log directory for the current OS, or the
+// logs directory in the portable mode directory.
 func DefaultLogDir() (string, error) {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "logs"), nil
+	}
+
 	switch runtime.GOOS {
 	case "windows":
 		// %LOCALAPPDATA%\ludusavi-runner\logs
@@ -108,3 +347,24 @@ func DefaultLogDir() (string, error) {
 		return filepath.Join(home, ".local", "state", AppName), nil
 	}
 }
+
+// EnvVars returns the config variables values in the env table can refer to
+// as ${name}, so a portable setup can point ludusavi at files next to the
+// runner wherever it is mounted. Directories that can't be determined are
+// left out.
+func (c *Config) EnvVars() map[string]string {
+	vars := make(map[string]string, 4)
+	if c.Dir != "" {
+		vars["config_dir"] = c.Dir
+	}
+	if dir, err := ExecutableDir(); err == nil {
+		vars["exe_dir"] = dir
+	}
+	if dir, err := DefaultStateDir(); err == nil {
+		vars["state_dir"] = dir
+	}
+	if dir, err := DefaultLogDir(); err == nil {
+		vars["log_dir"] = dir
+	}
+	return vars
+}
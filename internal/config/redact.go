@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// secretKeys are the config keys holding credentials, as dotted paths where
+// "*" stands for each element of an array.
+var secretKeys = []string{
+	"home_assistant.token",
+	"home_assistant.webhook_id",
+	"archive.destinations.*.secret_access_key",
+	"archive.destinations.*.password",
+	"metrics.headers",
+	"tracing.headers",
+	"on_complete.webhook_secret",
+	"notify_webhook.secret",
+	"game_events.secret",
+	"server.secret",
+}
+
+// Redact returns the config file at path without the credentials in it, in
+// the same format, and the keys it removed. Credentials in the userinfo of
+// service URLs are removed from the URLs. Unlike FixURLSchemes, the file is
+// re-encoded, so its comments and layout are lost.
+func Redact(path string) ([]byte, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	file, err := parseFile(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var removed []string
+	for _, key := range secretKeys {
+		removed = append(removed, remove(file, strings.Split(key, "."), "")...)
+	}
+	for _, key := range urlKeys {
+		value, ok := lookup(file, key)
+		if !ok {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.User == nil {
+			continue
+		}
+		u.User = nil
+		section, name, _ := strings.Cut(key, ".")
+		file[section].(map[string]any)[name] = u.String()
+		removed = append(removed, key)
+	}
+
+	data, err = encodeFile(path, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, removed, nil
+}
+
+// remove removes the key at path from node, a table or array of a parsed
+// config file, and returns the keys it removed, prefixed with prefix.
+func remove(node any, path []string, prefix string) []string {
+	if path[0] == "*" {
+		elems, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+		var removed []string
+		for i, elem := range elems {
+			removed = append(removed, remove(elem, path[1:], fmt.Sprintf("%s[%d].", strings.TrimSuffix(prefix, "."), i))...)
+		}
+		return removed
+	}
+
+	table, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	value, ok := table[path[0]]
+	if !ok {
+		return nil
+	}
+	if len(path) > 1 {
+		return remove(value, path[1:], prefix+path[0]+".")
+	}
+	if empty(value) {
+		return nil
+	}
+	delete(table, path[0])
+	return []string{prefix + path[0]}
+}
+
+// empty returns true if value is an empty string or table, which holds no
+// credentials.
+func empty(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
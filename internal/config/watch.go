@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch calls changed each time the config file at path is written, until
+// ctx is cancelled. Writes within debounce of each other, such as an editor
+// saving in several steps, call it once. The file's directory is watched
+// rather than the file, so a file replaced by renaming a new one over it, as
+// many editors save, is still followed.
+func Watch(ctx context.Context, path string, debounce time.Duration, changed func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	defer watcher.Close()
+
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch config file: %w", err)
+		case <-timer.C:
+			changed()
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package cron parses cron expressions and computes when they are next due.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is the range and names of a field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// Sunday is both 0 and 7
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// macros are the shorthands accepted in place of the five fields.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxYears bounds the search for the next time a schedule is due, for
+// expressions such as "0 0 30 2 *" that never are.
+const maxYears = 5
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are set when the day of the month or week is "*".
+	// When both are restricted, a day matching either is due, as in cron.
+	domAny, dowAny bool
+	// loc is the time zone of the expression; nil for local time.
+	loc *time.Location
+}
+
+// Parse parses a standard five-field cron expression: minute, hour, day of
+// the month, month and day of the week. Fields take "*", numbers, ranges
+// ("1-5"), lists ("1,15") and steps ("*/15", "0-30/10"); months and days of
+// the week also take English names ("jan", "mon"). The shorthands @yearly,
+// @monthly, @weekly, @daily and @hourly are accepted too.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// In returns a copy of the schedule with the expression in the time zone loc
+// rather than local time.
+func (s *Schedule) In(loc *time.Location) *Schedule {
+	c := *s
+	c.loc = loc
+	return &c
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t the schedule is due, or the zero time
+// if it isn't due within the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(end) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			// An hour skipped by daylight saving time normalizes to the
+			// one after it
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			next := t.Add(time.Minute)
+			// An hour repeated by daylight saving time isn't run again
+			if next.Hour() == t.Hour() && next.Minute() < t.Minute() {
+				next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			}
+			t = next
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// LongestGap returns the longest time between two consecutive times the
+// schedule is due within the year after from, or zero if it never is.
+func (s *Schedule) LongestGap(from time.Time) time.Duration {
+	// Enough for a schedule due every minute to cover a few months, beyond
+	// which its gaps are no different
+	const maxRuns = 100000
+
+	var gap time.Duration
+	end := from.AddDate(1, 0, 0)
+	prev := s.Next(from)
+	for i := 0; i < maxRuns && !prev.IsZero() && prev.Before(end); i++ {
+		next := s.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		gap = max(gap, next.Sub(prev))
+		prev = next
+	}
+	return gap
+}
+
+// dayMatches reports whether the schedule is due on the day of t.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// has reports whether value is in the set bits.
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+// parse returns the set of values the field expression spec covers.
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepSpec, f.name)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeSpec == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeSpec, "-"):
+			loSpec, hiSpec, _ := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = f.value(loSpec); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiSpec); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeSpec, f.name)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangeSpec); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// "5/15" runs from 5 to the end of the range
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single number or name of the field.
+func (f field) value(spec string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(spec, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", spec, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
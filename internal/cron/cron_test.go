@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Thursday
+	from := time.Date(2026, 10, 15, 9, 41, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 15, 9, 42, 0, 0, time.UTC)},
+		{"0 */2 * * *", time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 15, 9, 45, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2026, 10, 16, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		// Either day matches when both are restricted
+		{"0 0 20 * sat", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.In(time.UTC).Next(from))
+		})
+	}
+}
+
+func TestSchedule_Next_Never(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestSchedule_Next_DaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	s, err := Parse("30 2 * * *")
+	require.NoError(t, err)
+	s = s.In(loc)
+
+	// 02:30 doesn't exist on the day clocks go forward, so that day is
+	// skipped, and happens twice on the day they go back, run once
+	next := s.Next(time.Date(2026, 3, 29, 1, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 3, 30, 2, 30, 0, 0, loc), next)
+
+	next = s.Next(time.Date(2026, 10, 25, 1, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, 10, 25, 2, 30, 0, 0, loc), next)
+	assert.Equal(t, time.Date(2026, 10, 26, 2, 30, 0, 0, loc), s.Next(next))
+
+	// 02:10 CEST, in the first of the repeated hours
+	next = s.Next(time.Date(2026, 10, 25, 0, 10, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC), next.UTC())
+	assert.Equal(t, time.Date(2026, 10, 26, 2, 30, 0, 0, loc), s.Next(next))
+}
+
+func TestSchedule_LongestGap(t *testing.T) {
+	from := time.Date(2026, 10, 15, 9, 41, 30, 0, time.UTC)
+	for expr, want := range map[string]time.Duration{
+		"0 */2 * * *":      2 * time.Hour,
+		"0 9,17 * * *":     16 * time.Hour,
+		"30 3 * * mon-fri": 72 * time.Hour,
+		"0 0 30 2 *":       0,
+	} {
+		s, err := Parse(expr)
+		require.NoError(t, err)
+		assert.Equal(t, want, s.In(time.UTC).LongestGap(from), expr)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for expr, want := range map[string]string{
+		"":             "expected 5 fields",
+		"0 0 * *":      "expected 5 fields",
+		"60 * * * *":   "minute 60 out of range 0-59",
+		"* 24 * * *":   "hour 24 out of range 0-23",
+		"* * 0 * *":    "day of month 0 out of range 1-31",
+		"* * * foo *":  `invalid value "foo" in month field`,
+		"*/0 * * * *":  `invalid step "0" in minute field`,
+		"* 5-1 * * *":  `invalid range "5-1" in hour field`,
+		"@fortnightly": "expected 5 fields",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorContains(t, err, want, expr)
+	}
+}
@@ -0,0 +1,467 @@
+// Package customgame backs up games that ludusavi's manifest doesn't cover,
+// such as emulator saves and mod configs, by copying their paths or running a
+// command that exports them, so they share the schedule, metrics and
+// notifications of ludusavi's backups.
+package customgame
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+)
+
+// Environment variables set for backup commands.
+const (
+	// EnvBackupDir is the directory a command writes its backup to. It is
+	// also the command's working directory.
+	EnvBackupDir = "LUDUSAVI_RUNNER_BACKUP_DIR"
+	// EnvGame is the title of the game being backed up.
+	EnvGame = "LUDUSAVI_RUNNER_GAME"
+)
+
+// DefaultTimeout is how long a backup command may run when no timeout is set.
+const DefaultTimeout = 10 * time.Minute
+
+// Game is a custom game, backed up by copying Paths or by running Command.
+type Game struct {
+	Title string
+	// Paths are files or directories to copy, which may contain glob
+	// patterns and start with ~ for the home directory.
+	Paths []string
+	// Command is a program and its arguments that writes the backup to
+	// EnvBackupDir.
+	Command []string
+	// Optional games skip paths that match nothing instead of failing, and
+	// are left out when none match, as for presets of software that isn't
+	// installed.
+	Optional bool
+	// KeepRemoved keeps copies of files removed from Paths, as for
+	// screenshots that are moved elsewhere or cleaned up.
+	KeepRemoved bool
+}
+
+// Backuper backs up custom games, each into its own directory named after
+// its title.
+type Backuper struct {
+	dir       string
+	games     []Game
+	operation domain.OperationType
+	timeout   time.Duration
+	env       map[string]string
+	envVars   map[string]string
+	logger    *slog.Logger
+}
+
+// Option configures a Backuper.
+type Option func(*Backuper)
+
+// WithOperation sets the operation results are reported as, instead of
+// domain.OperationCustom.
+func WithOperation(op domain.OperationType) Option {
+	return func(b *Backuper) {
+		b.operation = op
+	}
+}
+
+// WithTimeout sets how long a backup command may run.
+func WithTimeout(d time.Duration) Option {
+	return func(b *Backuper) {
+		b.timeout = d
+	}
+}
+
+// WithEnv sets environment variables to pass to backup commands, expanded
+// with vars like ludusavi's.
+func WithEnv(env, vars map[string]string) Option {
+	return func(b *Backuper) {
+		b.env = env
+		b.envVars = vars
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Backuper) {
+		b.logger = logger
+	}
+}
+
+// NewBackuper creates a new Backuper backing up games into dir.
+func NewBackuper(dir string, games []Game, opts ...Option) *Backuper {
+	b := &Backuper{
+		dir:       dir,
+		operation: domain.OperationCustom,
+		timeout:   DefaultTimeout,
+		logger:    slog.Default(),
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, game := range games {
+		game.Paths = slices.Clone(game.Paths)
+		for i, path := range game.Paths {
+			game.Paths[i] = expandHome(path, home)
+		}
+		b.games = append(b.games, game)
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// gameStats are the statistics of a single game's backup.
+type gameStats struct {
+	new       bool
+	changed   bool
+	bytes     int64
+	processed int64
+}
+
+// Backup backs up every game. A game failing doesn't stop the others; the
+// result fails with the errors of all failed games.
+func (b *Backuper) Backup(ctx context.Context) (*domain.BackupResult, error) {
+	result := domain.NewBackupResult(b.operation)
+
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create %s backup directory: %w", b.operation, err)
+	}
+
+	var errs []error
+	for _, game := range b.games {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		log := logging.FromContext(ctx, b.logger).With(logging.KeyGame, game.Title)
+		if game.Optional {
+			game.Paths = existing(game.Paths)
+			if len(game.Paths) == 0 {
+				log.Debug("no custom game saves found, skipping")
+				continue
+			}
+		}
+
+		stats, err := b.backupGame(ctx, game)
+		result.Stats.TotalGames++
+		result.Stats.TotalBytes += stats.bytes
+		if result.GameBytes == nil {
+			result.GameBytes = make(map[string]int64, len(b.games))
+		}
+		result.GameBytes[game.Title] = stats.bytes
+		if err != nil {
+			log.Warn("custom game backup failed", "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", game.Title, err))
+			continue
+		}
+
+		switch {
+		case stats.new:
+			result.Stats.NewGames++
+		case stats.changed:
+			result.Stats.ChangedGames++
+		default:
+			result.Stats.SameGames++
+		}
+		if stats.new || stats.changed {
+			result.Stats.ProcessedGames++
+			result.Stats.ProcessedBytes += stats.processed
+			log.Debug("custom game saves changed", "new", stats.new, "bytes", stats.processed)
+		}
+	}
+
+	err := errors.Join(errs...)
+	result.Complete(err == nil, err)
+	return result, nil
+}
+
+// backupGame backs up game into its directory.
+func (b *Backuper) backupGame(ctx context.Context, game Game) (gameStats, error) {
+	gameDir := filepath.Join(b.dir, DirName(game.Title))
+	before, err := snapshot(gameDir)
+	if err != nil {
+		return gameStats{}, err
+	}
+	if err := os.MkdirAll(gameDir, 0o750); err != nil {
+		return gameStats{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if len(game.Command) > 0 {
+		err = b.runCommand(ctx, game, gameDir)
+	} else {
+		err = copyPaths(ctx, game.Paths, gameDir, game.KeepRemoved)
+	}
+
+	after, snapErr := snapshot(gameDir)
+	if err == nil {
+		err = snapErr
+	}
+
+	stats := gameStats{new: len(before) == 0 && len(after) > 0}
+	for path, file := range after {
+		stats.bytes += file.size
+		if prev, ok := before[path]; !ok || prev != file {
+			stats.changed = true
+			stats.processed += file.size
+		}
+	}
+	if len(before) != len(after) {
+		stats.changed = true
+	}
+	return stats, err
+}
+
+// runCommand runs the backup command of game in gameDir.
+func (b *Backuper) runCommand(ctx context.Context, game Game, gameDir string) error {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	env := maps.Clone(b.env)
+	if env == nil {
+		env = make(map[string]string, 2)
+	}
+	env[EnvBackupDir] = gameDir
+	env[EnvGame] = game.Title
+
+	// #nosec G204 -- command is from config, not user input
+	cmd := exec.CommandContext(ctx, game.Command[0], game.Command[1:]...)
+	cmd.Dir = gameDir
+	cmd.Env = platform.Environ(env, b.envVars)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := platform.RunProcessGroup(cmd); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("backup command did not finish within %s", b.timeout)
+		}
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("backup command failed: %s: %w", platform.LastLine(msg), err)
+		}
+		return fmt.Errorf("backup command failed: %w", err)
+	}
+	return nil
+}
+
+// copyPaths copies the files matching paths into gameDir, at their absolute
+// path below it like ludusavi lays out backups (drive-C/Users/...), and
+// removes files no longer matched unless keepRemoved is set.
+func copyPaths(ctx context.Context, paths []string, gameDir string, keepRemoved bool) error {
+	keep := make(map[string]bool)
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid path %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no files found at %s", pattern)
+		}
+
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return err
+				}
+				target := filepath.Join(gameDir, MirrorPath(abs))
+				keep[target] = true
+				return copyFile(path, target)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to copy %s: %w", match, err)
+			}
+		}
+	}
+
+	if keepRemoved {
+		return nil
+	}
+	return filepath.WalkDir(gameDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || keep[path] {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale backup file: %w", err)
+		}
+		return nil
+	})
+}
+
+// copyFile copies src to dst unless dst already has the same size and
+// modification time, writing to a temporary name first so a partially copied
+// file is never left behind.
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if existing, err := os.Stat(dst); err == nil && existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	in, err := os.Open(src) // #nosec G304 -- path is from config
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	partial := dst + ".partial"
+	out, err := os.Create(partial) // #nosec G304 -- path is built from the backup directory
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(partial)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+	if err := os.Chtimes(partial, info.ModTime(), info.ModTime()); err != nil {
+		_ = os.Remove(partial)
+		return err
+	}
+	return os.Rename(partial, dst)
+}
+
+// fileState is what a backup file is compared by between runs.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot returns the files below dir, which may not exist yet.
+func snapshot(dir string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	return files, nil
+}
+
+// Validate checks that the backup directory can be created and that every
+// game's paths match something and its command exists.
+func (b *Backuper) Validate(ctx context.Context) error {
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s backup directory: %w", b.operation, err)
+	}
+	for _, game := range b.games {
+		if len(game.Command) > 0 {
+			if _, err := exec.LookPath(game.Command[0]); err != nil {
+				return fmt.Errorf("%s: backup command not found: %w", game.Title, err)
+			}
+			continue
+		}
+		if game.Optional {
+			continue
+		}
+		for _, pattern := range game.Paths {
+			if matches, err := filepath.Glob(pattern); err != nil || len(matches) == 0 {
+				return fmt.Errorf("%s: no files found at %s", game.Title, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// existing returns the paths that match something.
+func existing(paths []string) []string {
+	var found []string
+	for _, pattern := range paths {
+		if matches, err := filepath.Glob(pattern); err == nil && len(matches) > 0 {
+			found = append(found, pattern)
+		}
+	}
+	return found
+}
+
+// expandHome replaces a leading ~ in path with home, if known.
+func expandHome(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == "~" {
+		return home
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	if rest, ok := strings.CutPrefix(path, `~\`); ok {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+// DirName returns the name of the directory title is backed up in, with
+// characters that aren't allowed in file names replaced.
+func DirName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, title)
+	// Windows drops trailing dots and spaces
+	return strings.TrimRight(name, ". ")
+}
+
+// MirrorPath returns where the file at the absolute path abs is kept below a
+// game's directory: drive-C/Users/... for C:\Users\..., and drive-0/home/...
+// for /home/..., as ludusavi does.
+func MirrorPath(abs string) string {
+	vol := filepath.VolumeName(abs)
+	rest := filepath.ToSlash(strings.TrimPrefix(abs, vol))
+	drive := "drive-0"
+	if vol != "" {
+		drive = "drive-" + DirName(strings.TrimSuffix(strings.TrimLeft(filepath.ToSlash(vol), "/"), ":"))
+	}
+	return filepath.Join(drive, filepath.FromSlash(rest))
+}
+
+// Ensure Backuper implements domain.CustomBackuper.
+var _ domain.CustomBackuper = (*Backuper)(nil)
@@ -0,0 +1,196 @@
+package customgame
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackuper_Backup_Paths(t *testing.T) {
+	src := t.TempDir()
+	saves := filepath.Join(src, "GC")
+	require.NoError(t, os.MkdirAll(filepath.Join(saves, "USA"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(saves, "USA", "a.gci"), []byte("aaaa"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(saves, "b.gci"), []byte("bb"), 0o600))
+
+	dir := t.TempDir()
+	b := NewBackuper(dir, []Game{{Title: "Dolphin: GC", Paths: []string{filepath.Join(saves, "*")}}})
+
+	result, err := b.Backup(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, domain.OperationCustom, result.Operation)
+	assert.Equal(t, domain.BackupStats{
+		TotalGames: 1, ProcessedGames: 1, TotalBytes: 6, ProcessedBytes: 6, NewGames: 1,
+	}, result.Stats)
+
+	backup := filepath.Join(dir, "Dolphin_ GC", MirrorPath(filepath.Join(saves, "USA", "a.gci")))
+	data, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaa", string(data))
+
+	// Nothing changed
+	result, err = b.Backup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.BackupStats{TotalGames: 1, TotalBytes: 6, SameGames: 1}, result.Stats)
+
+	// A changed file is copied again and a deleted one removed from the backup
+	require.NoError(t, os.WriteFile(filepath.Join(saves, "USA", "a.gci"), []byte("aaaaaa"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(saves, "b.gci")))
+	result, err = b.Backup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, domain.BackupStats{
+		TotalGames: 1, ProcessedGames: 1, TotalBytes: 6, ProcessedBytes: 6, ChangedGames: 1,
+	}, result.Stats)
+	assert.NoFileExists(t, filepath.Join(dir, "Dolphin_ GC", MirrorPath(filepath.Join(saves, "b.gci"))))
+}
+
+func TestBackuper_Backup_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	dir := t.TempDir()
+	b := NewBackuper(dir, []Game{{
+		Title:   "Mods",
+		Command: []string{"sh", "-c", `printf "%s" "$LUDUSAVI_RUNNER_GAME $MOD_ROOT" > "$LUDUSAVI_RUNNER_BACKUP_DIR/modlist.txt"`},
+	}}, WithEnv(map[string]string{"MOD_ROOT": "${config_dir}/mods"}, map[string]string{"config_dir": "/cfg"}))
+
+	result, err := b.Backup(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, 1, result.Stats.NewGames)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Mods", "modlist.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Mods /cfg/mods", string(data))
+}
+
+func TestBackuper_Backup_Failures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "save.dat"), []byte("x"), 0o600))
+
+	b := NewBackuper(t.TempDir(), []Game{
+		{Title: "Missing", Paths: []string{filepath.Join(src, "missing", "*")}},
+		{Title: "Broken", Command: []string{"sh", "-c", "echo 'mod manager not running' >&2; exit 3"}},
+		{Title: "Slow", Command: []string{"sleep", "10"}},
+		{Title: "Fine", Paths: []string{filepath.Join(src, "save.dat")}},
+	}, WithTimeout(100*time.Millisecond))
+
+	result, err := b.Backup(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "Missing: no files found at")
+	assert.Contains(t, result.Error, "Broken: backup command failed: mod manager not running")
+	assert.Contains(t, result.Error, "Slow: backup command did not finish within 100ms")
+	assert.Equal(t, 4, result.Stats.TotalGames)
+	assert.Equal(t, 1, result.Stats.ProcessedGames)
+}
+
+func TestBackuper_Validate(t *testing.T) {
+	b := NewBackuper(t.TempDir(), []Game{{Title: "Missing", Paths: []string{filepath.Join(t.TempDir(), "*.sav")}}})
+	assert.ErrorContains(t, b.Validate(context.Background()), "Missing: no files found at")
+
+	b = NewBackuper(t.TempDir(), []Game{{Title: "Tool", Command: []string{"ludusavi-runner-no-such-command"}}})
+	assert.ErrorContains(t, b.Validate(context.Background()), "Tool: backup command not found")
+}
+
+func TestDirName(t *testing.T) {
+	assert.Equal(t, "Dolphin_ GameCube", DirName("Dolphin: GameCube"))
+	assert.Equal(t, "A_B_C", DirName("A/B\\C"))
+	assert.Equal(t, "Mods", DirName("Mods. "))
+}
+
+func TestMirrorPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, filepath.Join("drive-C", "Users", "me", "save.dat"), MirrorPath(`C:\Users\me\save.dat`))
+		assert.Equal(t, filepath.Join("drive-nas_saves", "emu", "a.sav"), MirrorPath(`\\nas\saves\emu\a.sav`))
+		return
+	}
+	assert.Equal(t, filepath.Join("drive-0", "home", "me", "save.dat"), MirrorPath("/home/me/save.dat"))
+}
+
+func TestBackuper_Backup_Optional(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "saves"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "saves", "game.srm"), []byte("srm"), 0o600))
+
+	b := NewBackuper(t.TempDir(), []Game{
+		{Title: "RetroArch", Optional: true, Paths: []string{filepath.Join(src, "saves"), filepath.Join(src, "states")}},
+		{Title: "Not installed", Optional: true, Paths: []string{filepath.Join(src, "missing")}},
+	})
+
+	result, err := b.Backup(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, 1, result.Stats.TotalGames)
+	assert.Equal(t, int64(3), result.Stats.TotalBytes)
+	assert.NoError(t, b.Validate(context.Background()))
+}
+
+func TestPreset(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("USERPROFILE", `C:\Users\user`)
+	t.Setenv("APPDATA", `C:\Users\user\AppData\Roaming`)
+
+	for name := range presets {
+		game, ok := Preset(name)
+		require.True(t, ok, name)
+		assert.True(t, game.Optional)
+		for _, path := range game.Paths {
+			assert.True(t, filepath.IsAbs(path), path)
+		}
+	}
+
+	if runtime.GOOS == "linux" {
+		game, _ := Preset("minecraft")
+		assert.Equal(t, []string{"/home/user/.minecraft/saves", "/home/user/.var/app/com.mojang.Minecraft/.minecraft/saves"}, game.Paths)
+	}
+
+	_, ok := Preset("n64")
+	assert.False(t, ok)
+}
+
+func TestBackuper_Backup_KeepRemoved(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	shots := filepath.Join(home, "Screenshots")
+	require.NoError(t, os.MkdirAll(shots, 0o750))
+	shot := filepath.Join(shots, "1.png")
+	require.NoError(t, os.WriteFile(shot, []byte("png"), 0o600))
+
+	dir := t.TempDir()
+	b := NewBackuper(dir, []Game{{Title: "Screenshots", Paths: []string{"~/Screenshots"}, KeepRemoved: true}},
+		WithOperation(domain.OperationExtras))
+
+	result, err := b.Backup(context.Background())
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, domain.OperationExtras, result.Operation)
+
+	require.NoError(t, os.Remove(shot))
+	require.NoError(t, os.WriteFile(filepath.Join(shots, "2.png"), []byte("png"), 0o600))
+	_, err = b.Backup(context.Background())
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "Screenshots", MirrorPath(shot)))
+}
+
+func TestExpandHome(t *testing.T) {
+	home := filepath.FromSlash("/home/user")
+	assert.Equal(t, filepath.Join(home, "Pictures"), expandHome("~/Pictures", home))
+	assert.Equal(t, home, expandHome("~", home))
+	assert.Equal(t, "~user/Pictures", expandHome("~user/Pictures", home))
+	assert.Equal(t, "~/Pictures", expandHome("~/Pictures", ""))
+}
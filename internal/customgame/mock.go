@@ -0,0 +1,38 @@
+package customgame
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockBackuper is a mock implementation of domain.CustomBackuper for testing.
+type MockBackuper struct {
+	BackupFunc   func(ctx context.Context) (*domain.BackupResult, error)
+	ValidateFunc func(ctx context.Context) error
+
+	// Calls counts the calls to Backup.
+	Calls int
+}
+
+// Backup calls the mock BackupFunc, or returns a successful result.
+func (m *MockBackuper) Backup(ctx context.Context) (*domain.BackupResult, error) {
+	m.Calls++
+	if m.BackupFunc != nil {
+		return m.BackupFunc(ctx)
+	}
+	result := domain.NewBackupResult(domain.OperationCustom)
+	result.Complete(true, nil)
+	return result, nil
+}
+
+// Validate calls the mock ValidateFunc.
+func (m *MockBackuper) Validate(ctx context.Context) error {
+	if m.ValidateFunc != nil {
+		return m.ValidateFunc(ctx)
+	}
+	return nil
+}
+
+// Ensure MockBackuper implements domain.CustomBackuper.
+var _ domain.CustomBackuper = (*MockBackuper)(nil)
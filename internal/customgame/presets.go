@@ -0,0 +1,108 @@
+package customgame
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// preset is a built-in game with its default save paths on each OS. Paths
+// start with ~ for the home directory or ${APPDATA} on Windows, and include
+// the Flatpak locations on Linux.
+type preset struct {
+	title string
+	paths map[string][]string
+}
+
+// presets are the built-in games by name, matching config.CustomPreset.
+var presets = map[string]preset{
+	"retroarch": {
+		title: "RetroArch",
+		paths: map[string][]string{
+			"windows": {"${APPDATA}/RetroArch/saves", "${APPDATA}/RetroArch/states"},
+			"darwin":  {"~/Library/Application Support/RetroArch/saves", "~/Library/Application Support/RetroArch/states"},
+			"linux": {
+				"~/.config/retroarch/saves", "~/.config/retroarch/states",
+				"~/.var/app/org.libretro.RetroArch/config/retroarch/saves",
+				"~/.var/app/org.libretro.RetroArch/config/retroarch/states",
+			},
+		},
+	},
+	"dolphin": {
+		title: "Dolphin",
+		paths: map[string][]string{
+			"windows": {"~/Documents/Dolphin Emulator/GC", "~/Documents/Dolphin Emulator/Wii/title"},
+			"darwin":  {"~/Library/Application Support/Dolphin/GC", "~/Library/Application Support/Dolphin/Wii/title"},
+			"linux": {
+				"~/.local/share/dolphin-emu/GC", "~/.local/share/dolphin-emu/Wii/title",
+				"~/.var/app/org.DolphinEmu.dolphin-emu/data/dolphin-emu/GC",
+				"~/.var/app/org.DolphinEmu.dolphin-emu/data/dolphin-emu/Wii/title",
+			},
+		},
+	},
+	"pcsx2": {
+		title: "PCSX2",
+		paths: map[string][]string{
+			"windows": {"~/Documents/PCSX2/memcards", "~/Documents/PCSX2/sstates"},
+			"darwin":  {"~/Library/Application Support/PCSX2/memcards", "~/Library/Application Support/PCSX2/sstates"},
+			"linux": {
+				"~/.config/PCSX2/memcards", "~/.config/PCSX2/sstates",
+				"~/.var/app/net.pcsx2.PCSX2/config/PCSX2/memcards",
+				"~/.var/app/net.pcsx2.PCSX2/config/PCSX2/sstates",
+			},
+		},
+	},
+	"yuzu": {
+		title: "yuzu",
+		paths: map[string][]string{
+			"windows": {"${APPDATA}/yuzu/nand/user/save"},
+			"linux": {
+				"~/.local/share/yuzu/nand/user/save",
+				"~/.var/app/org.yuzu_emu.yuzu/data/yuzu/nand/user/save",
+			},
+		},
+	},
+	"ryujinx": {
+		title: "Ryujinx",
+		paths: map[string][]string{
+			"windows": {"${APPDATA}/Ryujinx/bis/user/save"},
+			"darwin":  {"~/Library/Application Support/Ryujinx/bis/user/save"},
+			"linux": {
+				"~/.config/Ryujinx/bis/user/save",
+				"~/.var/app/org.ryujinx.Ryujinx/config/Ryujinx/bis/user/save",
+			},
+		},
+	},
+	"minecraft": {
+		title: "Minecraft",
+		paths: map[string][]string{
+			"windows": {"${APPDATA}/.minecraft/saves"},
+			"darwin":  {"~/Library/Application Support/minecraft/saves"},
+			"linux": {
+				"~/.minecraft/saves",
+				"~/.var/app/com.mojang.Minecraft/.minecraft/saves",
+			},
+		},
+	},
+}
+
+// Preset returns the built-in game name for the current OS. Its paths are
+// optional, as only some of them exist on any one machine.
+func Preset(name string) (Game, bool) {
+	p, ok := presets[name]
+	if !ok {
+		return Game{}, false
+	}
+
+	home, _ := os.UserHomeDir()
+	game := Game{Title: p.title, Optional: true}
+	for _, path := range p.paths[runtime.GOOS] {
+		path = os.ExpandEnv(expandHome(path, home))
+		// Unset variables would leave a relative path
+		if !filepath.IsAbs(path) {
+			continue
+		}
+		game.Paths = append(game.Paths, filepath.Clean(path))
+	}
+	return game, true
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ArchiveDestination defines the interface for storing archive exports.
+// Implementations upload a local archive file to a remote or local location.
+type ArchiveDestination interface {
+	// Name returns a short identifier for the destination (used in logs and errors).
+	Name() string
+
+	// Upload stores the local file at localPath under remoteName.
+	Upload(ctx context.Context, localPath, remoteName string) error
+
+	// Validate checks if the destination is reachable and properly configured.
+	Validate(ctx context.Context) error
+}
+
+// Archiver defines the interface for exporting the backup directory as an archive.
+type Archiver interface {
+	// Archive creates an archive of the backup directory and uploads it to all destinations.
+	Archive(ctx context.Context) (*BackupResult, error)
+
+	// Validate checks if the archiver and all of its destinations are properly configured.
+	Validate(ctx context.Context) error
+}
+
+// ErrDestinationOffline indicates an archive destination was unreachable
+// (e.g. a sleeping NAS or unmapped network drive), as opposed to an upload
+// that was attempted and failed.
+var ErrDestinationOffline = errors.New("destination offline")
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// CloudToken is the OAuth token of a cloud remote that uploads sign in with.
+type CloudToken struct {
+	// Remote is the name of the remote, e.g. "ludusavi-1646784769".
+	Remote string
+
+	// Type is the backend of the remote, e.g. "drive" or "box".
+	Type string
+
+	// Deadline is when the token stops working unless the user signs in
+	// again, or zero if the backend doesn't expose it.
+	Deadline time.Time
+}
+
+// CloudTokenSource defines the interface for reading the tokens of cloud
+// remotes, so they can be renewed before uploads start failing.
+type CloudTokenSource interface {
+	// Tokens returns the tokens of the cloud remotes.
+	Tokens(ctx context.Context) ([]CloudToken, error)
+}
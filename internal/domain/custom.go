@@ -0,0 +1,14 @@
+package domain
+
+import "context"
+
+// CustomBackuper defines the interface for backing up games that ludusavi's
+// manifest doesn't cover, such as emulator saves and mod configs.
+type CustomBackuper interface {
+	// Backup backs up every custom game, returning a single result for
+	// OperationCustom with one game counted per custom game.
+	Backup(ctx context.Context) (*BackupResult, error)
+
+	// Validate checks if the backup directory and games are properly configured.
+	Validate(ctx context.Context) error
+}
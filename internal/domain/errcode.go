@@ -0,0 +1,155 @@
+package domain
+
+import "errors"
+
+// ErrorCode identifies a kind of failure, such as "LR2003" for an expired
+// cloud sign-in. Codes are stable, so they can be looked up in the README,
+// searched for and aggregated across machines, while error messages change.
+type ErrorCode string
+
+// Error codes, grouped by what failed: 1xxx ludusavi itself, 2xxx the cloud,
+// 3xxx backup destinations and archives, 4xxx the runner's own operations.
+const (
+	CodeBinaryNotFound     ErrorCode = "LR1001"
+	CodeLudusaviFailed     ErrorCode = "LR1002"
+	CodeBinaryUnverified   ErrorCode = "LR1003"
+	CodeGamesFailed        ErrorCode = "LR1004"
+	CodeNetworkOffline     ErrorCode = "LR2001"
+	CodeCloudSyncFailed    ErrorCode = "LR2002"
+	CodeCloudAuthExpired   ErrorCode = "LR2003"
+	CodeDestinationOffline ErrorCode = "LR3001"
+	CodeArchiveFailed      ErrorCode = "LR3002"
+	CodeSnapshotFailed     ErrorCode = "LR3003"
+	CodeCustomFailed       ErrorCode = "LR4001"
+	CodeHookFailed         ErrorCode = "LR4002"
+	CodeMetricsPushFailed  ErrorCode = "LR4003"
+	CodeUnknown            ErrorCode = "LR9999"
+)
+
+// ErrorCodeInfo describes an error code.
+type ErrorCodeInfo struct {
+	Code ErrorCode
+	// Summary says what failed, in a few words.
+	Summary string
+	// Hint says what to do about it.
+	Hint string
+}
+
+// ErrorCatalog lists every error code.
+var ErrorCatalog = []ErrorCodeInfo{
+	{CodeBinaryNotFound, "ludusavi binary not found",
+		"Install ludusavi, or set ludusavi_path to its binary."},
+	{CodeLudusaviFailed, "ludusavi failed",
+		"Run the same ludusavi command by hand to see why; its output is in the log at debug level."},
+	{CodeBinaryUnverified, "ludusavi binary failed verification",
+		"The binary changed or its signature doesn't match; reinstall ludusavi or update the verify settings."},
+	{CodeGamesFailed, "some games failed to back up",
+		"The other games were backed up. Check that the failed games' save files can be read, e.g. that the game isn't running; with quarantine enabled, games failing repeatedly are set aside and retried less often."},
+	{CodeNetworkOffline, "network offline",
+		"The operation was skipped while the network was down; it runs again once the network is back."},
+	{CodeCloudSyncFailed, "cloud sync failed",
+		"Check the cloud remote in ludusavi (Other > Cloud) and rclone's error in the message."},
+	{CodeCloudAuthExpired, "cloud sign-in expired",
+		"Sign in to the cloud remote again, in the ludusavi GUI (Other > Cloud > Remote) or with `rclone config reconnect <remote>:`."},
+	{CodeDestinationOffline, "destination offline",
+		"The destination was unreachable; check that it is mounted or online. The next run retries automatically."},
+	{CodeArchiveFailed, "archive export failed",
+		"Check the archive destinations and their credentials, and that there is space left."},
+	{CodeSnapshotFailed, "backup store snapshot failed",
+		"Check the store_snapshot settings and that the snapshot tool works on the backup directory."},
+	{CodeCustomFailed, "custom files backup failed",
+		"Check that the paths under custom and extras exist and can be read."},
+	{CodeHookFailed, "hook command failed",
+		"Check the hook's output in the log, and that it finishes within hooks.timeout."},
+	{CodeMetricsPushFailed, "metrics push failed",
+		"Check that the metrics backend is up and that the metrics URL and headers are right."},
+	{CodeUnknown, "unexpected error",
+		"See the log around the error for details."},
+}
+
+// Info returns the catalog entry of the code, or that of CodeUnknown if the
+// code isn't in the catalog.
+func (c ErrorCode) Info() ErrorCodeInfo {
+	for _, info := range ErrorCatalog {
+		if info.Code == c {
+			return info
+		}
+	}
+	return ErrorCatalog[len(ErrorCatalog)-1]
+}
+
+// Hint returns what to do about a failure with the code.
+func (c ErrorCode) Hint() string {
+	return c.Info().Hint
+}
+
+// CodedError is an error with the code of the failure it describes.
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+// Error returns the message of the wrapped error.
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithErrorCode returns err with code, or nil if err is nil.
+func WithErrorCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorCodeOf returns the code of err: the code it was given with
+// WithErrorCode, the code of a known error it wraps, or CodeUnknown. It
+// returns an empty code if err is nil.
+func ErrorCodeOf(err error) ErrorCode {
+	var coded *CodedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, ErrAuthRequired):
+		return CodeCloudAuthExpired
+	case errors.Is(err, ErrNetworkOffline):
+		return CodeNetworkOffline
+	case errors.Is(err, ErrDestinationOffline):
+		return CodeDestinationOffline
+	case errors.Is(err, ErrBinaryNotFound):
+		return CodeBinaryNotFound
+	default:
+		return CodeUnknown
+	}
+}
+
+// FailureCode returns the code of a failure of the operation whose cause
+// isn't known more precisely.
+func (o OperationType) FailureCode() ErrorCode {
+	switch o {
+	case OperationCloudUpload, OperationCloudDownload:
+		return CodeCloudSyncFailed
+	case OperationArchive:
+		return CodeArchiveFailed
+	case OperationCustom, OperationExtras:
+		return CodeCustomFailed
+	default:
+		return CodeLudusaviFailed
+	}
+}
+
+// Failure is a failed operation or other error of a run, with its code.
+type Failure struct {
+	Code ErrorCode `json:"code"`
+	// Source names what failed, such as "cloud_upload" or "backup (nas)",
+	// or is empty for an error of the run itself.
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+}
@@ -1,11 +1,61 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrAuthRequired indicates ludusavi or rclone stopped to wait for the user,
+// typically to sign in to a cloud remote again after its token expired.
+var ErrAuthRequired = errors.New("interactive auth required")
+
+// ErrNetworkOffline indicates a network operation was skipped because the
+// network probe found the network down.
+var ErrNetworkOffline = errors.New("network offline")
+
+// ErrBinaryNotFound indicates the ludusavi binary isn't installed where it
+// was looked for.
+var ErrBinaryNotFound = errors.New("ludusavi binary not found")
 
 // BackupOptions contains options for a backup operation.
 type BackupOptions struct {
 	// Force skips confirmation prompts.
 	Force bool
+
+	// Preview reports what would be backed up without writing anything.
+	Preview bool
+
+	// ChangedOnly backs up only games whose saves are new or changed, which is
+	// much faster than a full backup when little has changed.
+	ChangedOnly bool
+
+	// Path overrides the backup directory configured in ludusavi.
+	Path string
+
+	// Games, if set, limits the backup to the games with these titles, as
+	// ludusavi names them.
+	Games []string
+
+	// Exclude leaves the games with these titles out of the backup, such as
+	// games in quarantine.
+	Exclude []string
+}
+
+// RestoreOptions contains options for a restore operation.
+type RestoreOptions struct {
+	// Force skips confirmation prompts.
+	Force bool
+
+	// Preview reports what would be restored without writing anything.
+	Preview bool
+
+	// Path overrides the backup directory configured in ludusavi to restore
+	// from.
+	Path string
+
+	// Games, if set, limits the restore to the games with these titles, as
+	// ludusavi names them.
+	Games []string
 }
 
 // UploadOptions contains options for a cloud upload operation.
@@ -14,15 +64,28 @@ type UploadOptions struct {
 	Force bool
 }
 
+// DownloadOptions contains options for a cloud download operation.
+type DownloadOptions struct {
+	// Force skips confirmation prompts.
+	Force bool
+}
+
 // Executor defines the interface for running backup operations.
 // This abstraction allows for different implementations (real ludusavi, mock, etc.).
 type Executor interface {
 	// Backup runs a local backup operation and returns the result.
 	Backup(ctx context.Context, opts BackupOptions) (*BackupResult, error)
 
+	// Restore restores saves from the backups and returns the result.
+	Restore(ctx context.Context, opts RestoreOptions) (*BackupResult, error)
+
 	// CloudUpload runs a cloud upload operation and returns the result.
 	CloudUpload(ctx context.Context, opts UploadOptions) (*BackupResult, error)
 
+	// CloudDownload runs a cloud download operation, replacing the local
+	// backups with the cloud's, and returns the result.
+	CloudDownload(ctx context.Context, opts DownloadOptions) (*BackupResult, error)
+
 	// Version returns the ludusavi version string.
 	Version(ctx context.Context) (string, error)
 
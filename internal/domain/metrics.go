@@ -16,14 +16,79 @@ type Metrics struct {
 	// ServiceUp indicates if the service is running.
 	ServiceUp bool
 
+	// Paused indicates scheduled runs are paused.
+	Paused bool
+
+	// Panics is the number of panics recovered since the service started.
+	Panics int64
+
+	// WatchdogRecoveries counts watchdog recoveries since the service started, by reason.
+	WatchdogRecoveries map[string]int64
+
+	// PushErrors counts pushes rejected by the metrics backend since the
+	// service started, by reason.
+	PushErrors map[string]int64
+
+	// Pushes counts the metrics pushes before this one since the service
+	// started, and Notifications the notifications by target, such as
+	// "apprise".
+	Pushes        DeliveryCounts
+	Notifications map[string]DeliveryCounts
+
+	// Failures counts the failures of runs since the service started, by
+	// code.
+	Failures map[ErrorCode]int64
+
 	// Version information.
 	Version   string
 	GoVersion string
 
+	// RunID is the ID of the run the results are from, if any.
+	RunID string
+
+	// Outcome is how the run the results are from went, if any.
+	Outcome RunOutcome
+
+	// Process is the resource usage of the runner itself, if known.
+	Process *ProcessStats
+
+	// GameCount is the outcome of the latest game count regression check,
+	// if any.
+	GameCount *GameCountStats
+
+	// Maintenance is set while maintenance mode is on, labeling every
+	// metric so dashboards and alerts can tell deliberate breakage apart.
+	Maintenance bool
+
 	// Results from backup operations.
 	Results []*BackupResult
 }
 
+// DeliveryCounts counts deliveries, such as metrics pushes or
+// notifications, that went out and that failed.
+type DeliveryCounts struct {
+	Sent   int64
+	Failed int64
+}
+
+// ProcessStats is the resource usage of the runner process.
+type ProcessStats struct {
+	CPUSeconds float64
+	// MemoryBytes is the resident memory, or zero if unknown.
+	MemoryBytes int64
+	// OpenFDs is the number of open file descriptors, or handles on
+	// Windows, or zero if unknown.
+	OpenFDs int
+}
+
+// GameCountStats is the outcome of a game count regression check.
+type GameCountStats struct {
+	// Average is the rolling average of the games found by full backups.
+	Average float64
+	// Regressed is set while the latest count is too far below Average.
+	Regressed bool
+}
+
 // NewMetrics creates a new Metrics instance.
 func NewMetrics(hostname string) *Metrics {
 	return &Metrics{
@@ -49,3 +114,20 @@ type MetricsPusher interface {
 	// Validate checks if the pusher is properly configured.
 	Validate(ctx context.Context) error
 }
+
+// PushError is a push of metrics the backend rejected, with what to do
+// about it.
+type PushError struct {
+	// Reason is the kind of rejection, such as "inconsistent", as counted
+	// by Metrics.PushErrors.
+	Reason string
+	// StatusCode is the HTTP status the backend answered with.
+	StatusCode int
+	// Message says why the push was rejected and how to fix it.
+	Message string
+}
+
+// Error returns the message.
+func (e *PushError) Error() string {
+	return e.Message
+}
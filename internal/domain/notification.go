@@ -24,6 +24,14 @@ type Notification struct {
 
 	// Level is the severity level.
 	Level NotificationLevel `json:"level"`
+
+	// FailureStreak is how many runs in a row have failed, including the
+	// one notified about, or 0 when it isn't about a failed run.
+	FailureStreak int `json:"failure_streak,omitempty"`
+
+	// Result is the run notified about, if any. It isn't kept with
+	// notifications held back for later delivery.
+	Result *RunResult `json:"-"`
 }
 
 // NewNotification creates a new notification.
@@ -0,0 +1,28 @@
+package domain
+
+import "context"
+
+type preemptKey struct{}
+
+// WithPreempt returns a context that asks the operations run with it to stop
+// at the next safe point once preempt is closed, so a more urgent run can go
+// first. Unlike cancellation, a preempted operation finishes cleanly with
+// the work it has done.
+func WithPreempt(ctx context.Context, preempt <-chan struct{}) context.Context {
+	return context.WithValue(ctx, preemptKey{}, preempt)
+}
+
+// Preempted reports whether the run ctx belongs to has been asked to stop
+// for a more urgent one.
+func Preempted(ctx context.Context) bool {
+	preempt, _ := ctx.Value(preemptKey{}).(<-chan struct{})
+	if preempt == nil {
+		return false
+	}
+	select {
+	case <-preempt:
+		return true
+	default:
+		return false
+	}
+}
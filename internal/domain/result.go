@@ -1,7 +1,12 @@
 // Package domain defines core business types and interfaces.
 package domain
 
-import "time"
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
 
 // OperationType represents the type of backup operation.
 type OperationType string
@@ -11,6 +16,22 @@ const (
 	OperationBackup OperationType = "backup"
 	// OperationCloudUpload represents a cloud upload operation.
 	OperationCloudUpload OperationType = "cloud_upload"
+	// OperationCloudDownload represents a cloud download operation.
+	OperationCloudDownload OperationType = "cloud_download"
+	// OperationArchive represents an archive export operation.
+	OperationArchive OperationType = "archive"
+	// OperationFastBackup represents a backup of only games with changed saves.
+	OperationFastBackup OperationType = "fast_backup"
+	// OperationGameBackup represents a backup of only some games, named
+	// rather than found by scanning the library.
+	OperationGameBackup OperationType = "game_backup"
+	// OperationCustom represents a backup of custom games outside ludusavi.
+	OperationCustom OperationType = "custom"
+	// OperationExtras represents a backup of screenshots and game config
+	// files alongside the saves.
+	OperationExtras OperationType = "extras"
+	// OperationRestore represents a restore of saves from the backups.
+	OperationRestore OperationType = "restore"
 )
 
 // String returns the string representation of the operation type.
@@ -29,6 +50,17 @@ type BackupStats struct {
 	SameGames      int   `json:"same_games"`
 }
 
+// Add adds other to the statistics, as when combining runs over separate games.
+func (s *BackupStats) Add(other BackupStats) {
+	s.TotalGames += other.TotalGames
+	s.ProcessedGames += other.ProcessedGames
+	s.TotalBytes += other.TotalBytes
+	s.ProcessedBytes += other.ProcessedBytes
+	s.NewGames += other.NewGames
+	s.ChangedGames += other.ChangedGames
+	s.SameGames += other.SameGames
+}
+
 // BackupResult contains the result of a backup operation.
 type BackupResult struct {
 	Operation OperationType `json:"operation"`
@@ -38,6 +70,61 @@ type BackupResult struct {
 	Duration  time.Duration `json:"duration"`
 	Stats     BackupStats   `json:"stats"`
 	Error     string        `json:"error,omitempty"`
+
+	// Code is the code of the failure of the operation, if it failed.
+	Code ErrorCode `json:"code,omitempty"`
+
+	// Offline is set when the operation failed only because its destination
+	// was unreachable, rather than because the operation itself failed.
+	Offline bool `json:"offline,omitempty"`
+
+	// AuthRequired is set when the operation failed because it waited for
+	// the user to sign in, which a service can't do.
+	AuthRequired bool `json:"auth_required,omitempty"`
+
+	// SaveFiles lists the save files ludusavi scanned, when known.
+	SaveFiles []string `json:"-"`
+
+	// GameBytes is the size of each game's saves by title, when known.
+	GameBytes map[string]int64 `json:"-"`
+
+	// FailedGames are the games that failed to back up, by title, with the
+	// error of each; the other games were backed up.
+	FailedGames map[string]string `json:"failed_games,omitempty"`
+
+	// Skipped is set when the operation was not run because nothing changed
+	// since the previous run.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Destination names the additional backup destination the operation
+	// wrote to, if any.
+	Destination string `json:"destination,omitempty"`
+
+	// Games lists the titles of the games the operation was limited to, if
+	// it was.
+	Games []string `json:"games,omitempty"`
+
+	// Preempted is set when the operation stopped early, at a game boundary,
+	// for a more urgent run; the games it got to are backed up.
+	Preempted bool `json:"preempted,omitempty"`
+
+	// Usage is the resource usage of the ludusavi processes the operation ran.
+	Usage ProcessUsage `json:"usage"`
+}
+
+// ProcessUsage is the resource usage of one or more child processes.
+type ProcessUsage struct {
+	CPUSeconds float64 `json:"cpu_seconds"`
+	// PeakMemoryBytes is the largest resident memory of any of the
+	// processes, or zero if the platform doesn't report it.
+	PeakMemoryBytes int64 `json:"peak_memory_bytes,omitempty"`
+}
+
+// Add adds the usage of another process: CPU time adds up, while peak memory
+// is the larger of the two.
+func (u *ProcessUsage) Add(other ProcessUsage) {
+	u.CPUSeconds += other.CPUSeconds
+	u.PeakMemoryBytes = max(u.PeakMemoryBytes, other.PeakMemoryBytes)
 }
 
 // NewBackupResult creates a new BackupResult with the given operation type.
@@ -56,10 +143,40 @@ func (r *BackupResult) Complete(success bool, err error) {
 	if err != nil {
 		r.Error = err.Error()
 	}
+	r.Code = ""
+	if !success {
+		r.Code = ErrorCodeOf(err)
+		if r.Code == "" || r.Code == CodeUnknown {
+			r.Code = r.Operation.FailureCode()
+		}
+	}
+}
+
+// ErrorCode returns the code of the failure of the operation, which is
+// that of any failure of the operation if it wasn't given one.
+func (r *BackupResult) ErrorCode() ErrorCode {
+	if r.Code == "" {
+		return r.Operation.FailureCode()
+	}
+	return r.Code
 }
 
+// RunOutcome is how a run went as a whole.
+type RunOutcome string
+
+const (
+	// OutcomeSuccess is a run that succeeded.
+	OutcomeSuccess RunOutcome = "success"
+	// OutcomePartial is a run that failed, but made some of its backups.
+	OutcomePartial RunOutcome = "partial"
+	// OutcomeFailure is a run that failed without making any backups.
+	OutcomeFailure RunOutcome = "failure"
+)
+
 // RunResult contains the results of a complete backup run (all operations).
 type RunResult struct {
+	// ID uniquely identifies the run in logs, metrics and notifications.
+	ID          string        `json:"id"`
 	StartTime   time.Time     `json:"start_time"`
 	EndTime     time.Time     `json:"end_time"`
 	Duration    time.Duration `json:"duration"`
@@ -67,36 +184,197 @@ type RunResult struct {
 	DryRun      bool          `json:"dry_run"`
 	Backup      *BackupResult `json:"backup,omitempty"`
 	CloudUpload *BackupResult `json:"cloud_upload,omitempty"`
+	Archive     *BackupResult `json:"archive,omitempty"`
+	Custom      *BackupResult `json:"custom,omitempty"`
+	Extras      *BackupResult `json:"extras,omitempty"`
+	Restore     *BackupResult `json:"restore,omitempty"`
 	Errors      []string      `json:"errors,omitempty"`
+	// ErrorCodes are the codes of Errors, in the same order.
+	ErrorCodes []ErrorCode `json:"error_codes,omitempty"`
+
+	// Offline is set when the network was down during the run, so network
+	// operations were skipped and metrics and notifications held back until
+	// it is back: the run is offline-degraded rather than failed.
+	Offline bool `json:"offline,omitempty"`
+
+	// Preempted is set when the backup stopped early for a more urgent run,
+	// which skips the rest of the run; the run is queued again after it.
+	Preempted bool `json:"preempted,omitempty"`
+
+	// Maintenance is set when the run happened with maintenance mode on, so
+	// its failures weren't notified.
+	Maintenance bool `json:"maintenance,omitempty"`
+
+	// Destinations are the backups to additional destinations, one per destination.
+	Destinations []*BackupResult `json:"destinations,omitempty"`
+
+	// CloudDownload is set when the cloud backups were pulled down before
+	// the backup.
+	CloudDownload *BackupResult `json:"cloud_download,omitempty"`
+
+	// FailedHooks names the hook commands that failed, such as
+	// "pre_backup", which fails the run; their errors are in Errors.
+	FailedHooks []string `json:"failed_hooks,omitempty"`
+
+	// PartialSuccess is set when the run failed, but still made some of its
+	// backups: an operation failed while another succeeded, or some games
+	// failed to back up while the others did.
+	PartialSuccess bool `json:"partial_success,omitempty"`
 }
 
 // NewRunResult creates a new RunResult.
 func NewRunResult(dryRun bool) *RunResult {
 	return &RunResult{
+		ID:        NewRunID(),
 		StartTime: time.Now(),
 		DryRun:    dryRun,
 		Errors:    make([]string, 0),
 	}
 }
 
+// NewRunID returns a random (version 4) UUID identifying a run.
+func NewRunID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // Complete marks the run as complete.
 func (r *RunResult) Complete() {
 	r.EndTime = time.Now()
 	r.Duration = r.EndTime.Sub(r.StartTime)
 
-	// Success if both operations succeeded (or were not run)
-	r.Success = true
-	if r.CloudUpload != nil && !r.CloudUpload.Success {
-		r.Success = false
+	// Success if all operations succeeded (or were not run)
+	r.Success = len(r.FailedHooks) == 0
+	for _, op := range r.Operations() {
+		if !op.Success {
+			r.Success = false
+		}
+	}
+
+	r.PartialSuccess = false
+	if !r.Success {
+		for _, op := range r.Operations() {
+			if op.madeBackups() {
+				r.PartialSuccess = true
+			}
+		}
+	}
+}
+
+// Operations returns the operations the run did, in the order they run,
+// with the backups to additional destinations last.
+func (r *RunResult) Operations() []*BackupResult {
+	var ops []*BackupResult
+	for _, op := range []*BackupResult{r.CloudDownload, r.CloudUpload, r.Backup, r.Archive, r.Custom, r.Extras, r.Restore} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return append(ops, r.Destinations...)
+}
+
+// madeBackups returns true if the operation backed up anything: it
+// succeeded without being skipped, or failed after backing up some games,
+// as when others failed or a later batch did.
+func (r *BackupResult) madeBackups() bool {
+	if r.Success {
+		return !r.Skipped
 	}
-	if r.Backup != nil && !r.Backup.Success {
-		r.Success = false
+	for title := range r.GameBytes {
+		if _, failed := r.FailedGames[title]; !failed {
+			return true
+		}
 	}
+	return false
+}
+
+// Outcome returns how the run went as a whole.
+func (r *RunResult) Outcome() RunOutcome {
+	switch {
+	case r.Success:
+		return OutcomeSuccess
+	case r.PartialSuccess:
+		return OutcomePartial
+	default:
+		return OutcomeFailure
+	}
+}
+
+// DestinationOffline returns true if the run failed only because a destination
+// was offline, i.e. every failed operation is marked Offline.
+func (r *RunResult) DestinationOffline() bool {
+	if r.Success || len(r.Errors) > 0 {
+		return false
+	}
+
+	offline := false
+	for _, op := range r.Operations() {
+		if op.Success {
+			continue
+		}
+		if !op.Offline {
+			return false
+		}
+		offline = true
+	}
+	return offline
+}
+
+// AuthRequired returns true if any operation of the run failed because it
+// waited for the user to sign in.
+func (r *RunResult) AuthRequired() bool {
+	for _, op := range r.Operations() {
+		if op.AuthRequired {
+			return true
+		}
+	}
+	return false
 }
 
 // AddError adds an error to the run result.
 func (r *RunResult) AddError(err error) {
 	if err != nil {
 		r.Errors = append(r.Errors, err.Error())
+		r.ErrorCodes = append(r.ErrorCodes, ErrorCodeOf(err))
 	}
 }
+
+// Failures returns the failed operations of the run and its other errors,
+// each with its code.
+func (r *RunResult) Failures() []Failure {
+	var failures []Failure
+	for _, op := range r.Operations() {
+		if op.Success {
+			continue
+		}
+		source := op.Operation.String()
+		if op.Destination != "" {
+			source += " (" + op.Destination + ")"
+		}
+		failures = append(failures, Failure{Code: op.ErrorCode(), Source: source, Message: op.Error})
+	}
+	for i, msg := range r.Errors {
+		code := CodeUnknown
+		if i < len(r.ErrorCodes) {
+			code = r.ErrorCodes[i]
+		}
+		failures = append(failures, Failure{Code: code, Message: msg})
+	}
+	return failures
+}
+
+// RunHistory defines the interface for keeping the results of finished runs.
+type RunHistory interface {
+	// Record adds the result of a finished run.
+	Record(result *RunResult) error
+}
+
+// RunPublisher defines the interface for publishing run results to external
+// systems, apart from the notifications meant for people.
+type RunPublisher interface {
+	// Publish sends the result of a finished run.
+	Publish(ctx context.Context, result *RunResult) error
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // ServiceState represents the state of a system service.
 type ServiceState string
@@ -25,6 +28,27 @@ func (s ServiceState) String() string {
 	return string(s)
 }
 
+// ServiceScope is whether a service runs for a user or for the whole system.
+type ServiceScope string
+
+const (
+	// ServiceScopeUser is a service running as the user, with their profile,
+	// as game saves and ludusavi's config usually need.
+	ServiceScopeUser ServiceScope = "user"
+	// ServiceScopeSystem is a service running as the system account.
+	ServiceScopeSystem ServiceScope = "system"
+)
+
+// ParseServiceScope parses a service scope name.
+func ParseServiceScope(s string) (ServiceScope, error) {
+	switch scope := ServiceScope(s); scope {
+	case ServiceScopeUser, ServiceScopeSystem:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("invalid scope %q: must be user or system", s)
+	}
+}
+
 // ServiceStatus contains information about the service status.
 type ServiceStatus struct {
 	// State is the current service state.
@@ -38,11 +62,38 @@ type ServiceStatus struct {
 
 	// Message provides additional status information.
 	Message string `json:"message,omitempty"`
+
+	// ConfigPath is the config file the installed service was set up with,
+	// if known.
+	ConfigPath string `json:"config_path,omitempty"`
+
+	// Portable is set if the installed service runs in portable mode.
+	Portable bool `json:"portable,omitempty"`
+
+	// BinaryPath and Args are the command line the installed service is
+	// started with, if known.
+	BinaryPath string   `json:"binary_path,omitempty"`
+	Args       []string `json:"args,omitempty"`
+
+	// Account is the user the installed service runs as, if known.
+	Account string `json:"account,omitempty"`
+
+	// Scope is the scope of the installed service, if known.
+	Scope ServiceScope `json:"scope,omitempty"`
+
+	// StartType is how the installed service is started, such as
+	// "automatic" or "manual", if known.
+	StartType string `json:"start_type,omitempty"`
 }
 
 // InstallOptions contains options for service installation.
 type InstallOptions struct {
-	// Username is the account to run the service as.
+	// Scope is whether the service runs as a user or as the system. When
+	// updating a service, empty keeps its account.
+	Scope ServiceScope
+
+	// Username is the account to run the service as, in the user scope;
+	// the current user if empty.
 	Username string
 
 	// Password is the password for the account.
@@ -51,6 +102,9 @@ type InstallOptions struct {
 	// ConfigPath is the path to the config file.
 	ConfigPath string
 
+	// Portable runs the service in portable mode.
+	Portable bool
+
 	// AutoStart enables automatic service start on boot.
 	AutoStart bool
 }
@@ -61,6 +115,10 @@ type ServiceManager interface {
 	// Install installs the service.
 	Install(ctx context.Context, opts InstallOptions) error
 
+	// Update rewrites the command line of the installed service for opts,
+	// and its account if opts has a username.
+	Update(ctx context.Context, opts InstallOptions) error
+
 	// Uninstall removes the service.
 	Uninstall(ctx context.Context) error
 
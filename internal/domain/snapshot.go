@@ -0,0 +1,38 @@
+package domain
+
+import "context"
+
+// Snapshot is a point-in-time copy of a volume.
+type Snapshot struct {
+	// ID identifies the snapshot to the snapshot provider.
+	ID string
+
+	// Volume is the volume the snapshot was taken of, e.g. "C:".
+	Volume string
+
+	// Path is where the snapshot's files can be read from.
+	Path string
+}
+
+// Snapshotter defines the interface for taking volume snapshots, so save
+// files locked by running games or launchers can still be read consistently.
+type Snapshotter interface {
+	// Create takes a snapshot of volume.
+	Create(ctx context.Context, volume string) (*Snapshot, error)
+
+	// Delete removes a snapshot created by Create.
+	Delete(ctx context.Context, snapshot *Snapshot) error
+}
+
+// StoreSnapshotter defines the interface for snapshotting the backup store
+// itself on a copy-on-write filesystem, for point-in-time rollback.
+type StoreSnapshotter interface {
+	// Create takes a read-only snapshot named name.
+	Create(ctx context.Context, name string) error
+
+	// List returns the names of existing snapshots.
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the snapshot named name.
+	Delete(ctx context.Context, name string) error
+}
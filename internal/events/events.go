@@ -0,0 +1,118 @@
+// Package events streams live service events, such as scheduler status
+// changes, run results and log lines, to clients like the TUI dashboard.
+package events
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of an event.
+type Type string
+
+const (
+	// TypeStatus events carry the scheduler status after it changed.
+	TypeStatus Type = "status"
+	// TypeRun events carry the result of a completed run.
+	TypeRun Type = "run"
+	// TypeLog events carry a formatted log line.
+	TypeLog Type = "log"
+)
+
+// history is how many of the latest events of each type are replayed to new
+// subscribers, so they start with the current state.
+var history = map[Type]int{
+	TypeStatus: 1,
+	TypeRun:    20,
+	TypeLog:    200,
+}
+
+// subscriberBuffer is how many events may queue up for a slow subscriber
+// before further events are dropped for it.
+const subscriberBuffer = 256
+
+// Event is a single service event.
+type Event struct {
+	Type Type            `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the event data into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Broker fans events out to subscribers. Publishing never blocks: events for
+// a subscriber that doesn't keep up are dropped.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	recent      map[Type][]Event
+}
+
+// NewBroker creates a new Broker.
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[chan Event]struct{}),
+		recent:      make(map[Type][]Event),
+	}
+}
+
+// Publish sends an event with data, marshalled as JSON, to all subscribers.
+func (b *Broker) Publish(typ Type, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		// Only our own types are published; they always marshal
+		return
+	}
+	event := Event{Type: typ, Time: time.Now(), Data: raw}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := history[typ]; n > 0 {
+		recent := append(b.recent[typ], event)
+		if len(recent) > n {
+			recent = recent[len(recent)-n:]
+		}
+		b.recent[typ] = recent
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the recent events, oldest first, and
+// then new events as they are published, and a function that unsubscribes.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	for _, events := range b.recent {
+		replay = append(replay, events...)
+	}
+	slices.SortStableFunc(replay, func(a, b Event) int { return a.Time.Compare(b.Time) })
+
+	ch := make(chan Event, max(subscriberBuffer, len(replay)))
+	for _, event := range replay {
+		ch <- event
+	}
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
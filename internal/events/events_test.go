@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive returns the next event from ch.
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestBroker_Subscribe(t *testing.T) {
+	b := NewBroker()
+	b.Publish(TypeStatus, map[string]string{"state": "idle"})
+	b.Publish(TypeStatus, map[string]string{"state": "running"})
+	b.Publish(TypeLog, "level=INFO msg=started")
+
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	// Only the latest status is replayed, in publishing order with the logs
+	var status map[string]string
+	event := receive(t, ch)
+	require.Equal(t, TypeStatus, event.Type)
+	require.NoError(t, event.Decode(&status))
+	assert.Equal(t, "running", status["state"])
+
+	var line string
+	event = receive(t, ch)
+	require.Equal(t, TypeLog, event.Type)
+	require.NoError(t, event.Decode(&line))
+	assert.Equal(t, "level=INFO msg=started", line)
+
+	b.Publish(TypeRun, map[string]bool{"success": true})
+	assert.Equal(t, TypeRun, receive(t, ch).Type)
+
+	unsubscribe()
+	b.Publish(TypeRun, map[string]bool{"success": true})
+	assert.Empty(t, ch)
+}
+
+func TestBroker_LogHistory(t *testing.T) {
+	b := NewBroker()
+	for i := range history[TypeLog] + 10 {
+		b.Publish(TypeLog, fmt.Sprintf("line %d", i))
+	}
+
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+	require.Len(t, ch, history[TypeLog])
+
+	var line string
+	require.NoError(t, receive(t, ch).Decode(&line))
+	assert.Equal(t, "line 10", line)
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	// Publishing never blocks on a subscriber that doesn't read
+	for range subscriberBuffer * 2 {
+		b.Publish(TypeRun, true)
+	}
+	assert.Len(t, ch, subscriberBuffer)
+}
+
+func TestLogWriter(t *testing.T) {
+	b := NewBroker()
+	w := LogWriter(b)
+
+	n, err := w.Write([]byte("level=INFO msg=one\nlevel=WARN msg=two\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 38, n)
+
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+	require.Len(t, ch, 2)
+}
+
+func TestListen(t *testing.T) {
+	b := NewBroker()
+	b.Publish(TypeStatus, map[string]string{"state": "idle"})
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan Event, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Listen(ctx, srv.Client(), srv.URL, func(e Event) { received <- e })
+	}()
+
+	assert.Equal(t, TypeStatus, receive(t, received).Type)
+
+	b.Publish(TypeLog, "level=ERROR msg=\"backup failed\"")
+	event := receive(t, received)
+	assert.Equal(t, TypeLog, event.Type)
+	var line string
+	require.NoError(t, event.Decode(&line))
+	assert.Equal(t, `level=ERROR msg="backup failed"`, line)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestListen_Error(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	err := Listen(context.Background(), srv.Client(), srv.URL, func(Event) {})
+	assert.ErrorContains(t, err, "event stream returned status 404")
+}
@@ -0,0 +1,28 @@
+package events
+
+import (
+	"io"
+	"strings"
+)
+
+// LogWriter returns a writer publishing each line written to it as a log
+// event, for teeing the service log into the event stream. slog handlers
+// write each record with a single call.
+func LogWriter(b *Broker) io.Writer {
+	return &logWriter{broker: b}
+}
+
+// logWriter publishes log lines; see LogWriter.
+type logWriter struct {
+	broker *Broker
+}
+
+// Write publishes the lines in p.
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" {
+			w.broker.Publish(TypeLog, line)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,282 @@
+package executor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
+)
+
+// LatestReleaseURL is the GitHub API URL of the latest ludusavi release.
+const LatestReleaseURL = "https://api.github.com/repos/mtkennerly/ludusavi/releases/latest"
+
+// Release is a ludusavi release build for this platform.
+type Release struct {
+	Version string
+	Asset   string
+	URL     string
+	// Signature and Checksums are the URLs of the build's minisign signature
+	// and of a checksums file listing it, if the release has them.
+	Signature string
+	Checksums string
+}
+
+// Installer downloads ludusavi release builds from GitHub.
+type Installer struct {
+	client     *http.Client
+	releaseURL string
+	goos       string
+	goarch     string
+	verifier   *verify.Verifier
+}
+
+// InstallerOption configures an Installer.
+type InstallerOption func(*Installer)
+
+// WithInstallerHTTPClient sets the HTTP client.
+func WithInstallerHTTPClient(c *http.Client) InstallerOption {
+	return func(i *Installer) {
+		i.client = c
+	}
+}
+
+// WithReleaseURL sets the URL the latest release is looked up at.
+func WithReleaseURL(url string) InstallerOption {
+	return func(i *Installer) {
+		i.releaseURL = url
+	}
+}
+
+// WithInstallerVerifier verifies downloaded builds with v against the
+// release's signatures and checksums before installing them.
+func WithInstallerVerifier(v *verify.Verifier) InstallerOption {
+	return func(i *Installer) {
+		i.verifier = v
+	}
+}
+
+// NewInstaller creates a new Installer.
+func NewInstaller(opts ...InstallerOption) *Installer {
+	i := &Installer{
+		client:     http.NewClient(),
+		releaseURL: LatestReleaseURL,
+		goos:       runtime.GOOS,
+		goarch:     runtime.GOARCH,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Latest looks up the build of the latest ludusavi release for this platform.
+func (i *Installer) Latest(ctx context.Context) (*Release, error) {
+	resp, err := i.client.Get(ctx, i.releaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the latest ludusavi release: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to look up the latest ludusavi release: HTTP %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(resp.Body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse ludusavi release: %w", err)
+	}
+
+	platform := i.platform()
+	var build *Release
+	for _, asset := range release.Assets {
+		if strings.Contains(asset.Name, "-"+platform+".") && !strings.Contains(asset.Name, "legacy") && !verificationAsset(asset.Name) {
+			build = &Release{Version: release.TagName, Asset: asset.Name, URL: asset.URL}
+			break
+		}
+	}
+	if build == nil {
+		return nil, fmt.Errorf("ludusavi %s has no build for %s/%s", release.TagName, i.goos, i.goarch)
+	}
+
+	// A checksum file of the build itself is preferred over one of the
+	// whole release
+	var buildChecksums string
+	for _, asset := range release.Assets {
+		switch {
+		case asset.Name == build.Asset+".minisig":
+			build.Signature = asset.URL
+		case asset.Name == build.Asset+".sha256" || asset.Name == build.Asset+".sha512":
+			buildChecksums = asset.URL
+		case releaseChecksums(asset.Name):
+			build.Checksums = asset.URL
+		}
+	}
+	if buildChecksums != "" {
+		build.Checksums = buildChecksums
+	}
+	return build, nil
+}
+
+// releaseChecksums reports whether the release asset name is a checksums
+// file of the whole release, such as SHA256SUMS or checksums.txt.
+func releaseChecksums(name string) bool {
+	switch strings.TrimSuffix(strings.ToLower(name), ".txt") {
+	case "sha256sums", "sha512sums", "checksums":
+		return true
+	}
+	return false
+}
+
+// verificationAsset reports whether the release asset name is a signature
+// or checksums file rather than a build.
+func verificationAsset(name string) bool {
+	for _, ext := range []string{".minisig", ".sha256", ".sha512"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return releaseChecksums(name)
+}
+
+// Install downloads release and extracts the ludusavi binary into dir,
+// returning its path.
+func (i *Installer) Install(ctx context.Context, release *Release, dir string) (string, error) {
+	resp, err := i.client.Get(ctx, release.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", release.Asset, err)
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("failed to download %s: HTTP %d", release.Asset, resp.StatusCode)
+	}
+	if i.verifier != nil {
+		src := verify.Sources{Signature: release.Signature, Checksums: release.Checksums}
+		if err := i.verifier.Verify(ctx, release.Asset, resp.Body, src); err != nil {
+			return "", err
+		}
+	}
+
+	name := "ludusavi"
+	if i.goos == "windows" {
+		name += ".exe"
+	}
+	var binary []byte
+	if strings.HasSuffix(release.Asset, ".zip") {
+		binary, err = extractZip(resp.Body, name)
+	} else {
+		binary, err = extractTarGz(resp.Body, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", release.Asset, err)
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	dest := filepath.Join(dir, name)
+	tmp := dest + ".download"
+	if err := os.WriteFile(tmp, binary, 0755); err != nil {
+		return "", fmt.Errorf("failed to write ludusavi binary: %w", err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("failed to install ludusavi binary: %w", err)
+	}
+	return dest, nil
+}
+
+// platform returns the platform name ludusavi release builds are named after.
+func (i *Installer) platform() string {
+	switch i.goos {
+	case "windows":
+		if i.goarch == "386" {
+			return "win32"
+		}
+		return "win64"
+	case "darwin":
+		return "mac"
+	default:
+		return i.goos
+	}
+}
+
+// DefaultInstallDir returns the directory ludusavi is installed to, one of
+// the common locations the executor looks for it in.
+func DefaultInstallDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			localAppData = filepath.Join(home, "AppData", "Local")
+		}
+		return filepath.Join(localAppData, "Programs", "ludusavi"), nil
+	}
+	return filepath.Join(home, ".local", "bin"), nil
+}
+
+// errBinaryNotFound is returned when a release archive has no ludusavi binary.
+var errBinaryNotFound = errors.New("no ludusavi binary in archive")
+
+// extractZip returns the file called name from a zip archive.
+func extractZip(data []byte, name string) ([]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if path.Base(f.Name) != name || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, errBinaryNotFound
+}
+
+// extractTarGz returns the file called name from a gzipped tar archive.
+func extractTarGz(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errBinaryNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
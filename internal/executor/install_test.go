@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstaller(t *testing.T) {
+	var tarGz bytes.Buffer
+	gz := gzip.NewWriter(&tarGz)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "ludusavi", Typeflag: tar.TypeReg, Mode: 0755, Size: 5}))
+	_, _ = tw.Write([]byte("linux"))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("ludusavi.exe")
+	require.NoError(t, err)
+	_, _ = w.Write([]byte("windows"))
+	require.NoError(t, zw.Close())
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			fmt.Fprintf(w, `{"tag_name": "v0.29.1", "assets": [
+				{"name": "ludusavi-v0.29.1-legacy-linux.tar.gz", "browser_download_url": "%[1]s/legacy"},
+				{"name": "ludusavi-v0.29.1-linux.tar.gz", "browser_download_url": "%[1]s/linux"},
+				{"name": "ludusavi-v0.29.1-win64.zip", "browser_download_url": "%[1]s/win64"}
+			]}`, srv.URL)
+		case "/linux":
+			_, _ = w.Write(tarGz.Bytes())
+		case "/win64":
+			_, _ = w.Write(zipped.Bytes())
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		goos, goarch string
+		asset        string
+		binary       string
+		content      string
+	}{
+		{"linux", "amd64", "ludusavi-v0.29.1-linux.tar.gz", "ludusavi", "linux"},
+		{"windows", "amd64", "ludusavi-v0.29.1-win64.zip", "ludusavi.exe", "windows"},
+	} {
+		t.Run(tt.goos, func(t *testing.T) {
+			installer := NewInstaller(WithReleaseURL(srv.URL + "/latest"))
+			installer.goos, installer.goarch = tt.goos, tt.goarch
+
+			release, err := installer.Latest(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "v0.29.1", release.Version)
+			assert.Equal(t, tt.asset, release.Asset)
+
+			dir := filepath.Join(t.TempDir(), "bin")
+			path, err := installer.Install(context.Background(), release, dir)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, tt.binary), path)
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, string(data))
+		})
+	}
+
+	t.Run("no build for the platform", func(t *testing.T) {
+		installer := NewInstaller(WithReleaseURL(srv.URL + "/latest"))
+		installer.goos, installer.goarch = "freebsd", "amd64"
+
+		_, err := installer.Latest(context.Background())
+		assert.ErrorContains(t, err, "ludusavi v0.29.1 has no build for freebsd/amd64")
+	})
+}
+
+func TestInstaller_Verify(t *testing.T) {
+	var tarGz bytes.Buffer
+	gz := gzip.NewWriter(&tarGz)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "ludusavi", Typeflag: tar.TypeReg, Mode: 0755, Size: 5}))
+	_, _ = tw.Write([]byte("linux"))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	sum := sha256.Sum256(tarGz.Bytes())
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/signed":
+			fmt.Fprintf(w, `{"tag_name": "v0.30.0", "assets": [
+				{"name": "ludusavi-v0.30.0-linux.tar.gz.sha256", "browser_download_url": "%[1]s/linux.sha256"},
+				{"name": "ludusavi-v0.30.0-linux.tar.gz", "browser_download_url": "%[1]s/linux"},
+				{"name": "SHA256SUMS", "browser_download_url": "%[1]s/SHA256SUMS"}
+			]}`, srv.URL)
+		case "/unsigned":
+			fmt.Fprintf(w, `{"tag_name": "v0.30.0", "assets": [
+				{"name": "ludusavi-v0.30.0-linux.tar.gz", "browser_download_url": "%[1]s/linux"}
+			]}`, srv.URL)
+		case "/linux":
+			_, _ = w.Write(tarGz.Bytes())
+		case "/linux.sha256":
+			_, _ = w.Write([]byte(hex.EncodeToString(sum[:]) + "\n"))
+		case "/SHA256SUMS":
+			_, _ = w.Write([]byte(strings.Repeat("0", 64) + "  ludusavi-v0.30.0-linux.tar.gz\n"))
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	strict := verify.NewVerifier(verify.WithStrict(true))
+	installer := NewInstaller(WithReleaseURL(srv.URL+"/signed"), WithInstallerVerifier(strict))
+	installer.goos, installer.goarch = "linux", "amd64"
+	release, err := installer.Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ludusavi-v0.30.0-linux.tar.gz", release.Asset)
+	assert.Equal(t, srv.URL+"/linux.sha256", release.Checksums, "the build's own checksum is preferred")
+	_, err = installer.Install(context.Background(), release, t.TempDir())
+	require.NoError(t, err)
+
+	release.Checksums = srv.URL + "/SHA256SUMS"
+	dir := t.TempDir()
+	_, err = installer.Install(context.Background(), release, dir)
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.NoFileExists(t, filepath.Join(dir, "ludusavi"))
+
+	installer = NewInstaller(WithReleaseURL(srv.URL+"/unsigned"), WithInstallerVerifier(strict))
+	installer.goos, installer.goarch = "linux", "amd64"
+	release, err = installer.Latest(context.Background())
+	require.NoError(t, err)
+	_, err = installer.Install(context.Background(), release, t.TempDir())
+	assert.ErrorIs(t, err, verify.ErrUnverified)
+}
@@ -5,21 +5,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+	"github.com/sharkusmanch/ludusavi-runner/internal/procstats"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
 )
 
 // LudusaviOutput represents the JSON output from ludusavi --api commands.
 type LudusaviOutput struct {
-	Overall LudusaviOverall `json:"overall"`
-	Errors  LudusaviErrors  `json:"errors,omitempty"`
+	Overall LudusaviOverall         `json:"overall"`
+	Errors  LudusaviErrors          `json:"errors,omitempty"`
+	Games   map[string]LudusaviGame `json:"games,omitempty"`
 }
 
 // LudusaviOverall contains the overall statistics from ludusavi.
@@ -38,16 +50,74 @@ type LudusaviChangedGames struct {
 	Same      int `json:"same"`
 }
 
+// LudusaviGame contains the result for a single game.
+type LudusaviGame struct {
+	Decision string                  `json:"decision"`
+	Change   string                  `json:"change"`
+	Files    map[string]LudusaviFile `json:"files,omitempty"`
+}
+
+// LudusaviFile contains the result for a single save file.
+type LudusaviFile struct {
+	Change string `json:"change"`
+	Bytes  int64  `json:"bytes"`
+	// Failed is set when the file couldn't be backed up, for Error.
+	Failed bool               `json:"failed,omitempty"`
+	Error  *LudusaviFileError `json:"error,omitempty"`
+}
+
+// LudusaviFileError describes why a save file couldn't be backed up.
+type LudusaviFileError struct {
+	Message string `json:"message"`
+}
+
+// Ludusavi change values for games that differ from the last backup.
+const (
+	ludusaviChangeNew       = "New"
+	ludusaviChangeDifferent = "Different"
+)
+
 // LudusaviErrors contains error information from ludusavi.
 type LudusaviErrors struct {
 	SomeGamesFailed bool `json:"someGamesFailed"`
 }
 
+// errSomeGamesFailed is returned with ludusavi's output when it backed up
+// some games but failed others, which the output lists.
+var errSomeGamesFailed = errors.New("some games failed to back up")
+
 // LudusaviExecutor implements Executor using the ludusavi CLI.
 type LudusaviExecutor struct {
 	binaryPath string
 	env        map[string]string
+	envVars    map[string]string
 	logger     *slog.Logger
+
+	// Backups are split into batches of batchSize games, with batchPause
+	// between them, when batchSize is positive.
+	batchSize  int
+	batchPause time.Duration
+
+	// Backups are limited to the include games, if any, and leave out the
+	// exclude games.
+	include []string
+	exclude []string
+
+	// authProbeAddr is watched for rclone's OAuth redirect server during
+	// each run, every authProbeInterval.
+	authProbeAddr     string
+	authProbeInterval time.Duration
+
+	// Hardening of the ludusavi process; see sandbox.go.
+	sandbox     bool
+	restrictEnv bool
+	pinnedHash  string
+
+	// verifier checks the ludusavi binary's signature and checksums; see
+	// verify.go.
+	verifier  *verify.Verifier
+	checksums string
+	verified  verifiedBinary
 }
 
 // LudusaviOption configures a LudusaviExecutor.
@@ -74,10 +144,42 @@ func WithEnv(env map[string]string) LudusaviOption {
 	}
 }
 
+// WithEnvVars sets the variables ${name} references in env values expand to,
+// besides other env values and the runner's own environment. Values are
+// expanded for each run.
+func WithEnvVars(vars map[string]string) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.envVars = vars
+	}
+}
+
+// WithBatches backs up size games per ludusavi invocation and pauses between
+// invocations, spreading the writes of a large backup out over time so it
+// doesn't starve games running from the same disk. Zero size disables batching.
+func WithBatches(size int, pause time.Duration) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.batchSize = size
+		e.batchPause = pause
+	}
+}
+
+// WithGameFilter limits backups to the games titled in include, if any, and
+// leaves out the games titled in exclude. Included games are named to
+// ludusavi, so it only looks for their saves; excluding games takes a
+// preview to find the others.
+func WithGameFilter(include, exclude []string) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.include = include
+		e.exclude = exclude
+	}
+}
+
 // NewLudusaviExecutor creates a new LudusaviExecutor.
 func NewLudusaviExecutor(opts ...LudusaviOption) *LudusaviExecutor {
 	e := &LudusaviExecutor{
-		logger: slog.Default(),
+		logger:            slog.Default(),
+		authProbeAddr:     rcloneAuthAddr,
+		authProbeInterval: authProbeInterval,
 	}
 
 	for _, opt := range opts {
@@ -92,23 +194,331 @@ func (e *LudusaviExecutor) Backup(ctx context.Context, opts domain.BackupOptions
 	result := domain.NewBackupResult(domain.OperationBackup)
 
 	args := []string{"backup", "--api"}
+	if opts.Path != "" {
+		args = append(args, "--path", opts.Path)
+	}
 	if opts.Force {
 		args = append(args, "--force")
 	}
 
-	output, err := e.run(ctx, args...)
+	if opts.Preview {
+		args = append(args, "--preview")
+	}
+
+	// Named games are backed up directly, without scanning for others
+	if len(opts.Games) > 0 {
+		games := e.filterGames(opts.Games)
+		games = slices.DeleteFunc(games, func(title string) bool {
+			return slices.Contains(opts.Exclude, title)
+		})
+		if len(games) == 0 {
+			logging.FromContext(ctx, e.logger).Debug("no games to back up", "filtered", len(opts.Games))
+			result.Complete(true, nil)
+			return result, nil
+		}
+		args = append(args, "--")
+		return e.backup(ctx, result, append(args, games...))
+	}
+	if len(e.exclude) == 0 && len(opts.Exclude) == 0 && (opts.Preview || !opts.ChangedOnly && e.batchSize <= 0) {
+		return e.backup(ctx, result, withGames(args, e.include))
+	}
+
+	games, stats, err := e.previewGames(ctx, &result.Usage, opts.ChangedOnly && !opts.Preview, opts.Path)
 	if err != nil {
-		result.Complete(false, err)
+		return fail(result, err)
+	}
+	games = slices.DeleteFunc(e.filterGames(games), func(title string) bool {
+		return slices.Contains(opts.Exclude, title)
+	})
+	if len(games) == 0 {
+		logging.FromContext(ctx, e.logger).Debug("no games to back up")
+		result.Stats = *stats
+		result.Stats.ProcessedGames = 0
+		result.Stats.ProcessedBytes = 0
+		result.Complete(true, nil)
+		return result, nil
+	}
+
+	if e.batchSize > 0 && !opts.Preview {
+		return e.backupBatches(ctx, result, args, games)
+	}
+
+	return e.backup(ctx, result, withGames(args, games))
+}
+
+// filterGames returns the titles in games that the game filter keeps.
+func (e *LudusaviExecutor) filterGames(games []string) []string {
+	return slices.DeleteFunc(slices.Clone(games), func(title string) bool {
+		return len(e.include) > 0 && !slices.Contains(e.include, title) || slices.Contains(e.exclude, title)
+	})
+}
+
+// withGames returns args naming games to ludusavi, or args as they are if
+// there are none.
+func withGames(args, games []string) []string {
+	if len(games) == 0 {
+		return args
+	}
+	return append(append(slices.Clone(args), "--"), games...)
+}
+
+// backup runs a single ludusavi backup and completes result with its output.
+// Games that failed to back up fail the result, but the others are backed up.
+func (e *LudusaviExecutor) backup(ctx context.Context, result *domain.BackupResult, args []string) (*domain.BackupResult, error) {
+	output, runErr := e.run(ctx, &result.Usage, args...)
+	if runErr != nil && !errors.Is(runErr, errSomeGamesFailed) {
+		return fail(result, runErr)
+	}
+
+	stats, err := e.parseOutput(output)
+	if err != nil {
+		result.Complete(false, fmt.Errorf("failed to parse output: %w", err))
+		return result, nil
+	}
+
+	e.logGames(ctx, output)
+	result.Stats = *stats
+	result.SaveFiles = saveFiles(output)
+	addGameBytes(result, output)
+	addFailedGames(result, output)
+	return completeGames(result, runErr)
+}
+
+// backupBatches backs up games a batch at a time, pausing between batches,
+// and completes result with the combined output. A preempted run stops
+// between batches, with the batches done so far backed up.
+func (e *LudusaviExecutor) backupBatches(ctx context.Context, result *domain.BackupResult, args []string, games []string) (*domain.BackupResult, error) {
+	batches := slices.Collect(slices.Chunk(games, e.batchSize))
+	var gamesErr error
+
+	for i, batch := range batches {
+		if i > 0 && e.batchPause > 0 {
+			select {
+			case <-ctx.Done():
+				result.Complete(false, ctx.Err())
+				return result, nil
+			case <-time.After(e.batchPause):
+			}
+		}
+		if i > 0 && domain.Preempted(ctx) {
+			logging.FromContext(ctx, e.logger).Info("backup preempted, stopping between batches",
+				"batches_done", i, "batches", len(batches))
+			result.Preempted = true
+			break
+		}
+
+		logging.FromContext(ctx, e.logger).Debug("backing up batch", "batch", i+1, "batches", len(batches), "games", len(batch))
+
+		batchArgs := append(slices.Clone(args), "--")
+		batchArgs = append(batchArgs, batch...)
+		output, err := e.run(ctx, &result.Usage, batchArgs...)
+		if errors.Is(err, errSomeGamesFailed) {
+			// The failed games are listed in the output, the rest of the
+			// batch is backed up
+			gamesErr = err
+		} else if err != nil {
+			return fail(result, fmt.Errorf("batch %d of %d: %w", i+1, len(batches), err))
+		}
+
+		stats, err := e.parseOutput(output)
+		if err != nil {
+			result.Complete(false, fmt.Errorf("failed to parse output of batch %d of %d: %w", i+1, len(batches), err))
+			return result, nil
+		}
+
+		e.logGames(ctx, output)
+		result.Stats.Add(*stats)
+		result.SaveFiles = append(result.SaveFiles, saveFiles(output)...)
+		addGameBytes(result, output)
+		addFailedGames(result, output)
+	}
+
+	slices.Sort(result.SaveFiles)
+	return completeGames(result, gamesErr)
+}
+
+// logGames logs each game in ludusavi's output whose saves are new or
+// changed, so the backups of a single game can be followed in the log.
+func (e *LudusaviExecutor) logGames(ctx context.Context, output []byte) {
+	log := logging.FromContext(ctx, e.logger)
+	if !log.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	var ludusaviOut LudusaviOutput
+	if err := json.Unmarshal(output, &ludusaviOut); err != nil {
+		return
+	}
+
+	for _, title := range slices.Sorted(maps.Keys(ludusaviOut.Games)) {
+		game := ludusaviOut.Games[title]
+		if game.Change == ludusaviChangeNew || game.Change == ludusaviChangeDifferent {
+			log.Debug("game saves changed", logging.KeyGame, title, "change", game.Change, "decision", game.Decision)
+		}
+	}
+}
+
+// fail completes result as failed with err, marking whether it failed
+// because ludusavi or rclone waited for the user to sign in.
+func fail(result *domain.BackupResult, err error) (*domain.BackupResult, error) {
+	result.AuthRequired = errors.Is(err, domain.ErrAuthRequired)
+	result.Complete(false, err)
+	return result, nil
+}
+
+// saveFiles returns the paths of the save files listed in ludusavi's output.
+func saveFiles(output []byte) []string {
+	var ludusaviOut LudusaviOutput
+	if err := json.Unmarshal(output, &ludusaviOut); err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, game := range ludusaviOut.Games {
+		for path := range game.Files {
+			files = append(files, path)
+		}
+	}
+	slices.Sort(files)
+	return files
+}
+
+// addGameBytes adds the size of each game's saves in ludusavi's output to
+// result.GameBytes.
+func addGameBytes(result *domain.BackupResult, output []byte) {
+	var ludusaviOut LudusaviOutput
+	if err := json.Unmarshal(output, &ludusaviOut); err != nil {
+		return
+	}
+
+	for title, game := range ludusaviOut.Games {
+		if result.GameBytes == nil {
+			result.GameBytes = make(map[string]int64, len(ludusaviOut.Games))
+		}
+		for _, file := range game.Files {
+			result.GameBytes[title] += file.Bytes
+		}
+	}
+}
+
+// addFailedGames adds the games in ludusavi's output with save files that
+// failed to back up to result.FailedGames, with the error of the first.
+func addFailedGames(result *domain.BackupResult, output []byte) {
+	var ludusaviOut LudusaviOutput
+	if err := json.Unmarshal(output, &ludusaviOut); err != nil {
+		return
+	}
+
+	for title, game := range ludusaviOut.Games {
+		for _, path := range slices.Sorted(maps.Keys(game.Files)) {
+			file := game.Files[path]
+			if !file.Failed {
+				continue
+			}
+			if result.FailedGames == nil {
+				result.FailedGames = make(map[string]string)
+			}
+			msg := "failed to back up " + path
+			if file.Error != nil && file.Error.Message != "" {
+				msg += ": " + file.Error.Message
+			}
+			result.FailedGames[title] = msg
+			break
+		}
+	}
+}
+
+// completeGames completes result, as failed if any games failed to back up.
+// err is the error ludusavi exited with after failing them, if any; it fails
+// result as well when the output doesn't say which games failed.
+func completeGames(result *domain.BackupResult, err error) (*domain.BackupResult, error) {
+	if len(result.FailedGames) == 0 {
+		if err != nil {
+			return fail(result, err)
+		}
+		result.Complete(true, nil)
 		return result, nil
 	}
 
+	titles := slices.Sorted(maps.Keys(result.FailedGames))
+	for i, title := range titles {
+		titles[i] = title + " (" + result.FailedGames[title] + ")"
+	}
+	result.Complete(false, domain.WithErrorCode(domain.CodeGamesFailed,
+		fmt.Errorf("%d games failed to back up: %s", len(titles), strings.Join(titles, ", "))))
+	return result, nil
+}
+
+// previewGames previews a backup and returns the titles of the games found,
+// or with changedOnly only of games whose saves are new or changed, along with
+// the preview statistics. Changes are relative to the backups in path, if set.
+// Only the included games are previewed, if any.
+func (e *LudusaviExecutor) previewGames(ctx context.Context, usage *domain.ProcessUsage, changedOnly bool, path string) ([]string, *domain.BackupStats, error) {
+	args := []string{"backup", "--api"}
+	if path != "" {
+		args = append(args, "--path", path)
+	}
+	output, err := e.run(ctx, usage, withGames(append(args, "--preview"), e.include)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to preview backup: %w", err)
+	}
+
+	var ludusaviOut LudusaviOutput
+	if err := json.Unmarshal(output, &ludusaviOut); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse preview output: %w", err)
+	}
+
+	var games []string
+	for title, game := range ludusaviOut.Games {
+		if !changedOnly || game.Change == ludusaviChangeNew || game.Change == ludusaviChangeDifferent {
+			games = append(games, title)
+		}
+	}
+	slices.Sort(games)
+
+	stats, err := e.parseOutput(output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse preview output: %w", err)
+	}
+
+	return games, stats, nil
+}
+
+// Restore restores saves from the backups with ludusavi restore.
+func (e *LudusaviExecutor) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+	result := domain.NewBackupResult(domain.OperationRestore)
+
+	args := []string{"restore", "--api"}
+	if opts.Path != "" {
+		args = append(args, "--path", opts.Path)
+	}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	if opts.Preview {
+		args = append(args, "--preview")
+	}
+	if len(opts.Games) > 0 {
+		args = append(args, "--")
+		args = append(args, opts.Games...)
+	}
+
+	output, err := e.run(ctx, &result.Usage, args...)
+	if err != nil {
+		return fail(result, err)
+	}
+
 	stats, err := e.parseOutput(output)
 	if err != nil {
 		result.Complete(false, fmt.Errorf("failed to parse output: %w", err))
 		return result, nil
 	}
 
+	e.logGames(ctx, output)
 	result.Stats = *stats
+	result.Games = opts.Games
+	result.SaveFiles = saveFiles(output)
+	addGameBytes(result, output)
 	result.Complete(true, nil)
 	return result, nil
 }
@@ -122,12 +532,37 @@ func (e *LudusaviExecutor) CloudUpload(ctx context.Context, opts domain.UploadOp
 		args = append(args, "--force")
 	}
 
-	output, err := e.run(ctx, args...)
+	output, err := e.run(ctx, &result.Usage, args...)
 	if err != nil {
-		result.Complete(false, err)
+		return fail(result, err)
+	}
+
+	stats, err := e.parseOutput(output)
+	if err != nil {
+		result.Complete(false, fmt.Errorf("failed to parse output: %w", err))
 		return result, nil
 	}
 
+	result.Stats = *stats
+	result.Complete(true, nil)
+	return result, nil
+}
+
+// CloudDownload runs a cloud download operation, replacing the local
+// backups with the ones in the cloud.
+func (e *LudusaviExecutor) CloudDownload(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error) {
+	result := domain.NewBackupResult(domain.OperationCloudDownload)
+
+	args := []string{"cloud", "download", "--api"}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+
+	output, err := e.run(ctx, &result.Usage, args...)
+	if err != nil {
+		return fail(result, err)
+	}
+
 	stats, err := e.parseOutput(output)
 	if err != nil {
 		result.Complete(false, fmt.Errorf("failed to parse output: %w", err))
@@ -141,7 +576,7 @@ func (e *LudusaviExecutor) CloudUpload(ctx context.Context, opts domain.UploadOp
 
 // Version returns the ludusavi version.
 func (e *LudusaviExecutor) Version(ctx context.Context) (string, error) {
-	output, err := e.run(ctx, "--version")
+	output, err := e.run(ctx, nil, "--version")
 	if err != nil {
 		return "", err
 	}
@@ -168,39 +603,87 @@ func (e *LudusaviExecutor) Validate(ctx context.Context) error {
 	return nil
 }
 
-// run executes ludusavi with the given arguments.
-func (e *LudusaviExecutor) run(ctx context.Context, args ...string) ([]byte, error) {
+// run executes ludusavi with the given arguments, adding the resource usage
+// of the process to usage if not nil.
+func (e *LudusaviExecutor) run(ctx context.Context, usage *domain.ProcessUsage, args ...string) ([]byte, error) {
 	path, err := e.getBinaryPath()
 	if err != nil {
 		return nil, err
 	}
+	if err := e.verifyBinary(ctx, path); err != nil {
+		return nil, domain.WithErrorCode(domain.CodeBinaryUnverified, err)
+	}
 
-	e.logger.Debug("executing ludusavi", "path", path, "args", args)
+	// Only the names of the configured variables are logged, as values such
+	// as RCLONE_CONFIG_PASS are secrets
+	logging.FromContext(ctx, e.logger).Debug("executing ludusavi", "path", path, "args", args, "env", slices.Sorted(maps.Keys(e.env)))
 
+	_, span := tracing.Start(ctx, "ludusavi "+args[0], tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("process.executable.path", path)
+	span.SetAttribute("process.command_args", strings.Join(args, " "))
+
+	// A service can't answer prompts, so ludusavi is stopped as soon as it
+	// or rclone waits for the user instead of hanging until the run times out
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go watchAuthServer(runCtx, e.authProbeAddr, e.authProbeInterval, cancel)
+
+	// CreateProcess doesn't handle long paths itself
 	// #nosec G204 -- path is from config or auto-detected, not user input
-	cmd := exec.CommandContext(ctx, path, args...)
-
-	// Set environment variables if configured
-	if len(e.env) > 0 {
-		// Start with current environment and add/override with configured vars
-		cmd.Env = os.Environ()
-		for k, v := range e.env {
-			cmd.Env = append(cmd.Env, k+"="+v)
+	cmd := exec.CommandContext(runCtx, platform.LongPath(path), args...)
+
+	// Start with current environment and add/override with configured vars
+	cmd.Env = platform.Environ(e.env, e.envVars)
+	if e.restrictEnv {
+		cmd.Env = platform.RestrictedEnviron(e.env, e.envVars)
+	}
+	if e.sandbox {
+		release, err := platform.Sandbox(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sandbox ludusavi: %w", err)
 		}
+		defer release()
 	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// rclone prompts end up on stderr; stdout is ludusavi's JSON
+	cmd.Stderr = io.MultiWriter(&stderr, &promptWatcher{cancel: cancel})
+
+	err = platform.RunProcessGroup(cmd)
+	span.SetAttribute("process.exit.code", cmd.ProcessState.ExitCode())
+	if usage != nil {
+		usage.Add(procstats.Child(cmd.ProcessState))
+	}
+	if err != nil {
+		span.RecordError(err)
 
-	if err := cmd.Run(); err != nil {
 		// Check if it's a context error
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		if cause := context.Cause(runCtx); errors.Is(cause, domain.ErrAuthRequired) {
+			return nil, cause
+		}
+		// A configured path that doesn't exist
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w at %s: %w", domain.ErrBinaryNotFound, path, err)
+		}
+
+		// Include stderr in error message. ludusavi writes UTF-8, but errors
+		// from Windows itself come in the console code page.
+		errMsg := strings.ToValidUTF8(strings.TrimSpace(stderr.String()), "\uFFFD")
+
+		// ludusavi fails when any game does, after backing up the rest
+		var ludusaviOut LudusaviOutput
+		if json.Unmarshal(stdout.Bytes(), &ludusaviOut) == nil && ludusaviOut.Errors.SomeGamesFailed {
+			if errMsg != "" {
+				return stdout.Bytes(), fmt.Errorf("%w: %s", errSomeGamesFailed, errMsg)
+			}
+			return stdout.Bytes(), errSomeGamesFailed
+		}
 
-		// Include stderr in error message
-		errMsg := strings.TrimSpace(stderr.String())
 		if errMsg != "" {
 			return nil, fmt.Errorf("ludusavi failed: %s: %w", errMsg, err)
 		}
@@ -233,6 +716,12 @@ func (e *LudusaviExecutor) parseOutput(output []byte) (*domain.BackupStats, erro
 	}, nil
 }
 
+// BinaryPath returns the path to the ludusavi binary: the configured one, or
+// else the one found in PATH or a common location.
+func (e *LudusaviExecutor) BinaryPath() (string, error) {
+	return e.getBinaryPath()
+}
+
 // getBinaryPath returns the path to the ludusavi binary.
 func (e *LudusaviExecutor) getBinaryPath() (string, error) {
 	// Use configured path if set
@@ -254,18 +743,22 @@ func (e *LudusaviExecutor) getBinaryPath() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("ludusavi not found in PATH or common locations")
+	return "", fmt.Errorf("%w in PATH or common locations", domain.ErrBinaryNotFound)
 }
 
 // getCommonPaths returns common installation paths for ludusavi.
 func (e *LudusaviExecutor) getCommonPaths() []string {
 	switch runtime.GOOS {
 	case "windows":
-		home := os.Getenv("USERPROFILE")
+		home, _ := os.UserHomeDir()
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			localAppData = filepath.Join(home, "AppData", "Local")
+		}
 		return []string{
 			filepath.Join(home, "scoop", "shims", "ludusavi.exe"),
 			filepath.Join(home, "scoop", "apps", "ludusavi", "current", "ludusavi.exe"),
-			filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "ludusavi", "ludusavi.exe"),
+			filepath.Join(localAppData, "Programs", "ludusavi", "ludusavi.exe"),
 			"C:\\Program Files\\ludusavi\\ludusavi.exe",
 		}
 	case "darwin":
@@ -1,12 +1,608 @@
 package executor
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fakeLudusaviEnv makes the test binary stand in for ludusavi; see TestMain.
+const fakeLudusaviEnv = "LUDUSAVI_RUNNER_FAKE_LUDUSAVI"
+
+// TestMain runs the test binary as a fake ludusavi when fakeLudusaviEnv is set
+// to a log file: each invocation appends its arguments to the log and prints
+// the preview or backup output from the environment. It then hangs if
+// FAKE_LUDUSAVI_HANG is set, as ludusavi does while waiting for a prompt, and
+// fails a backup if FAKE_LUDUSAVI_FAIL is set, as ludusavi does when a game
+// failed.
+func TestMain(m *testing.M) {
+	platform.HandleSandboxExec()
+	if logPath := os.Getenv(fakeLudusaviEnv); logPath != "" {
+		args := strings.Join(os.Args[1:], " ")
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 -- test log path
+		if err == nil {
+			_, _ = fmt.Fprintln(f, args)
+			_ = f.Close()
+		}
+		if strings.Contains(args, "--preview") {
+			fmt.Print(os.Getenv("FAKE_LUDUSAVI_PREVIEW"))
+		} else {
+			fmt.Print(os.Getenv("FAKE_LUDUSAVI_BACKUP"))
+		}
+		fmt.Fprint(os.Stderr, os.Getenv("FAKE_LUDUSAVI_STDERR"))
+		if os.Getenv("FAKE_LUDUSAVI_HANG") != "" {
+			time.Sleep(time.Minute)
+		}
+		if os.Getenv("FAKE_LUDUSAVI_FAIL") != "" && !strings.Contains(args, "--preview") {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newFakeLudusavi returns an executor running the fake ludusavi, and the path
+// of the log its invocations are recorded in.
+func newFakeLudusavi(t *testing.T, preview, backup string) (*LudusaviExecutor, string) {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "invocations.log")
+	return NewLudusaviExecutor(
+		WithBinaryPath(os.Args[0]),
+		WithEnv(map[string]string{
+			fakeLudusaviEnv:         logPath,
+			"FAKE_LUDUSAVI_PREVIEW": preview,
+			"FAKE_LUDUSAVI_BACKUP":  backup,
+		}),
+	), logPath
+}
+
+func TestLudusaviExecutor_Backup_ChangedOnly(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 3, "totalBytes": 300, "processedGames": 3, "processedBytes": 300,
+			"changedGames": {"new": 1, "different": 1, "same": 1}},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Different"},
+			"Celeste": {"decision": "Processed", "change": "Same"},
+			"Balatro": {"decision": "Processed", "change": "New"}
+		}
+	}`
+	backup := `{"overall": {"totalGames": 2, "totalBytes": 200, "processedGames": 2, "processedBytes": 200,
+		"changedGames": {"new": 1, "different": 1, "same": 0}}}`
+
+	executor, logPath := newFakeLudusavi(t, preview, backup)
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true, ChangedOnly: true})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, 2, result.Stats.ProcessedGames)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --preview\nbackup --api --force -- Balatro Hades\n", string(log))
+
+	// Usage covers both the preview and the backup process
+	if runtime.GOOS != "windows" {
+		assert.Positive(t, result.Usage.PeakMemoryBytes)
+	}
+}
+
+func TestLudusaviExecutor_Backup_Games(t *testing.T) {
+	backup := `{"overall": {"totalGames": 1, "totalBytes": 100, "processedGames": 1, "processedBytes": 100,
+		"changedGames": {"new": 0, "different": 1, "same": 0}}}`
+
+	executor, logPath := newFakeLudusavi(t, "", backup)
+	executor.batchSize = 1
+
+	// Named games skip the preview, even when only changed games are wanted
+	result, err := executor.Backup(context.Background(),
+		domain.BackupOptions{Force: true, ChangedOnly: true, Games: []string{"Hades II"}})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, 1, result.Stats.ProcessedGames)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --force -- Hades II\n", string(log))
+}
+
+func TestLudusaviExecutor_Backup_GameFilter(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 3, "totalBytes": 300, "processedGames": 3, "processedBytes": 300,
+			"changedGames": {"new": 1, "different": 1, "same": 1}},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Different"},
+			"Celeste": {"decision": "Processed", "change": "Same"},
+			"Balatro": {"decision": "Processed", "change": "New"}
+		}
+	}`
+	backup := `{"overall": {"totalGames": 2, "totalBytes": 200, "processedGames": 2, "processedBytes": 200,
+		"changedGames": {"new": 1, "different": 1, "same": 0}}}`
+	ctx := context.Background()
+
+	t.Run("include names the games", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithGameFilter([]string{"Hades", "Celeste"}, nil)(executor)
+
+		_, err := executor.Backup(ctx, domain.BackupOptions{Force: true})
+		require.NoError(t, err)
+		_, err = executor.Backup(ctx, domain.BackupOptions{Force: true, ChangedOnly: true})
+		require.NoError(t, err)
+		_, err = executor.Backup(ctx, domain.BackupOptions{Force: true, Games: []string{"Hades", "Balatro"}})
+		require.NoError(t, err)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --force -- Hades Celeste\n"+
+			"backup --api --preview -- Hades Celeste\n"+
+			"backup --api --force -- Hades\n"+
+			"backup --api --force -- Hades\n", string(log))
+	})
+
+	t.Run("exclude previews the others", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithGameFilter(nil, []string{"Hades"})(executor)
+
+		_, err := executor.Backup(ctx, domain.BackupOptions{Force: true})
+		require.NoError(t, err)
+		_, err = executor.Backup(ctx, domain.BackupOptions{Preview: true})
+		require.NoError(t, err)
+
+		// Nothing is left of named games that are all excluded
+		result, err := executor.Backup(ctx, domain.BackupOptions{Force: true, Games: []string{"Hades"}})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Zero(t, result.Stats.ProcessedGames)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --preview\n"+
+			"backup --api --force -- Balatro Celeste\n"+
+			"backup --api --preview\n"+
+			"backup --api --preview -- Balatro Celeste\n", string(log))
+	})
+}
+
+func TestLudusaviExecutor_Backup_ChangedOnly_NothingChanged(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 1, "totalBytes": 100, "processedGames": 1, "processedBytes": 100,
+			"changedGames": {"new": 0, "different": 0, "same": 1}},
+		"games": {"Celeste": {"decision": "Processed", "change": "Same"}}
+	}`
+
+	executor, logPath := newFakeLudusavi(t, preview, "")
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{ChangedOnly: true})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, 1, result.Stats.TotalGames)
+	assert.Equal(t, 0, result.Stats.ProcessedGames)
+
+	// No backup is run when the preview finds nothing to do
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --preview\n", string(log))
+}
+
+func TestLudusaviExecutor_Backup_LogsGames(t *testing.T) {
+	backup := `{
+		"overall": {"totalGames": 2, "totalBytes": 200, "processedGames": 2, "processedBytes": 200,
+			"changedGames": {"new": 1, "different": 0, "same": 1}},
+		"games": {
+			"Celeste": {"decision": "Processed", "change": "Same"},
+			"Balatro": {"decision": "Processed", "change": "New"}
+		}
+	}`
+
+	var buf bytes.Buffer
+	executor, _ := newFakeLudusavi(t, "", backup)
+	executor.logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ctx := logging.WithAttrs(context.Background(), logging.KeyOperation, "backup")
+	result, err := executor.Backup(ctx, domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+
+	assert.Contains(t, buf.String(), `msg="game saves changed" operation=backup game=Balatro change=New`)
+	assert.NotContains(t, buf.String(), "game=Celeste")
+}
+
+func TestLudusaviExecutor_Run_LogsEnvNames(t *testing.T) {
+	var buf bytes.Buffer
+	executor, _ := newFakeLudusavi(t, "", `{"overall": {}}`)
+	executor.env["RCLONE_CONFIG_PASS"] = "hunter2"
+	executor.logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "RCLONE_CONFIG_PASS")
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestLudusaviExecutor_Backup_BinaryHash(t *testing.T) {
+	backup := `{"overall": {"totalGames": 1, "processedGames": 1}, "games": {}}`
+	data, err := os.ReadFile(os.Args[0])
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+
+	executor, _ := newFakeLudusavi(t, "", backup)
+	WithBinaryHash(strings.ToUpper(hex.EncodeToString(sum[:])))(executor)
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.Error)
+
+	executor, logPath := newFakeLudusavi(t, "", backup)
+	WithBinaryHash(strings.Repeat("0", 64))(executor)
+	result, err = executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "not the pinned "+strings.Repeat("0", 64))
+	assert.Equal(t, domain.CodeBinaryUnverified, result.Code)
+	assert.NoFileExists(t, logPath, "a binary with another hash isn't run")
+}
+
+func TestLudusaviExecutor_VerifyBinary_SameSizeAndTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ludusavi")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0700))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("original"))
+
+	executor := NewLudusaviExecutor(WithBinaryPath(path), WithBinaryHash(hex.EncodeToString(sum[:])))
+	require.NoError(t, executor.verifyBinary(context.Background(), path))
+
+	// A binary replaced with one of the same size and modification time is
+	// still caught
+	require.NoError(t, os.WriteFile(path, []byte("tampered"), 0700))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	err = executor.verifyBinary(context.Background(), path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not the pinned")
+}
+
+func TestLudusaviExecutor_Backup_FailedGames(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 3, "totalBytes": 300, "processedGames": 3, "processedBytes": 300,
+			"changedGames": {"new": 0, "different": 3, "same": 0}},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Different"},
+			"Celeste": {"decision": "Processed", "change": "Different"},
+			"Balatro": {"decision": "Processed", "change": "Different"}
+		}
+	}`
+	backup := `{
+		"overall": {"totalGames": 2, "totalBytes": 200, "processedGames": 2, "processedBytes": 200,
+			"changedGames": {"new": 0, "different": 2, "same": 0}},
+		"errors": {"someGamesFailed": true},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Different",
+				"files": {"/saves/hades.sav": {"change": "Different", "bytes": 100}}},
+			"Celeste": {"decision": "Processed", "change": "Different",
+				"files": {"/saves/celeste.sav": {"change": "Different", "bytes": 100, "failed": true,
+					"error": {"message": "Access is denied."}}}}
+		}
+	}`
+
+	executor, logPath := newFakeLudusavi(t, preview, backup)
+	executor.env["FAKE_LUDUSAVI_FAIL"] = "1"
+
+	// The other games are backed up, but the failed ones fail the result
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true, Exclude: []string{"Balatro"}})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, domain.CodeGamesFailed, result.Code)
+	assert.Equal(t, "1 games failed to back up: Celeste (failed to back up /saves/celeste.sav: Access is denied.)", result.Error)
+	assert.Equal(t, map[string]string{"Celeste": "failed to back up /saves/celeste.sav: Access is denied."}, result.FailedGames)
+	assert.Equal(t, 2, result.Stats.ProcessedGames)
+	assert.Equal(t, int64(100), result.GameBytes["Hades"])
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --preview\nbackup --api --force -- Celeste Hades\n", string(log))
+
+	// Without games listed as failed, ludusavi's failure fails the result
+	executor.env["FAKE_LUDUSAVI_BACKUP"] = `{"overall": {}, "errors": {"someGamesFailed": true}}`
+	result, err = executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.FailedGames)
+	assert.Contains(t, result.Error, "some games failed to back up")
+}
+
+func TestLudusaviExecutor_Backup_BinaryNotFound(t *testing.T) {
+	executor := NewLudusaviExecutor(WithBinaryPath(filepath.Join(t.TempDir(), "ludusavi")))
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, domain.CodeBinaryNotFound, result.Code)
+}
+
+func TestLudusaviExecutor_Backup_Verifier(t *testing.T) {
+	backup := `{"overall": {"totalGames": 1, "processedGames": 1}, "games": {}}`
+	data, err := os.ReadFile(os.Args[0])
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	checksums := filepath.Join(t.TempDir(), "SHA256SUMS")
+	strict := verify.NewVerifier(verify.WithStrict(true))
+
+	require.NoError(t, os.WriteFile(checksums, []byte(hex.EncodeToString(sum[:])+"  "+filepath.Base(os.Args[0])+"\n"), 0600))
+	executor, _ := newFakeLudusavi(t, "", backup)
+	WithVerifier(strict, checksums)(executor)
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.Error)
+
+	// The binary is only verified again once it changes
+	require.NoError(t, os.WriteFile(checksums, nil, 0600))
+	result, err = executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.Error)
+
+	executor, logPath := newFakeLudusavi(t, "", backup)
+	WithVerifier(strict, checksums)(executor)
+	result, err = executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "is not listed in the checksums")
+	assert.NoFileExists(t, logPath, "a binary failing verification isn't run")
+}
+
+func TestLudusaviExecutor_VerifyBinary_VerifierSameSizeAndTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ludusavi")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0700))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("original"))
+	checksums := filepath.Join(dir, "SHA256SUMS")
+	require.NoError(t, os.WriteFile(checksums, []byte(hex.EncodeToString(sum[:])+"  ludusavi\n"), 0600))
+
+	executor := NewLudusaviExecutor(WithBinaryPath(path), WithVerifier(verify.NewVerifier(verify.WithStrict(true)), checksums))
+	require.NoError(t, executor.verifyBinary(context.Background(), path))
+
+	// A binary replaced with one of the same size and modification time is
+	// verified again
+	require.NoError(t, os.WriteFile(path, []byte("tampered"), 0700))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	assert.Error(t, executor.verifyBinary(context.Background(), path))
+}
+
+func TestLudusaviExecutor_Backup_Sandbox(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("sandboxing is only supported on Linux and Windows")
+	}
+	executor, logPath := newFakeLudusavi(t, "", `{"overall": {"totalGames": 1, "processedGames": 1}, "games": {}}`)
+	WithSandbox(true)(executor)
+	WithRestrictedEnv(true)(executor)
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.Error)
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --force\n", string(log))
+}
+
+func TestLudusaviExecutor_Backup_Batches(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 3, "totalBytes": 300, "processedGames": 3, "processedBytes": 300,
+			"changedGames": {"new": 1, "different": 1, "same": 1}},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Different"},
+			"Celeste": {"decision": "Processed", "change": "Same"},
+			"Balatro": {"decision": "Processed", "change": "New"}
+		}
+	}`
+	backup := `{"overall": {"totalGames": 2, "totalBytes": 200, "processedGames": 2, "processedBytes": 200,
+		"changedGames": {"new": 1, "different": 1, "same": 0}},
+		"games": {"Hades": {"decision": "Processed", "change": "Different", "files": {"/saves/hades.sav": {"bytes": 200}}}}}`
+
+	t.Run("full backup", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithBatches(2, time.Millisecond)(executor)
+
+		result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+		// The fake reports the same output for every batch
+		assert.Equal(t, 4, result.Stats.TotalGames)
+		assert.Equal(t, int64(400), result.Stats.ProcessedBytes)
+		assert.Equal(t, []string{"/saves/hades.sav", "/saves/hades.sav"}, result.SaveFiles)
+		assert.Equal(t, map[string]int64{"Hades": 400}, result.GameBytes)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --preview\n"+
+			"backup --api --force -- Balatro Celeste\n"+
+			"backup --api --force -- Hades\n", string(log))
+	})
+
+	t.Run("changed only", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithBatches(1, 0)(executor)
+
+		result, err := executor.Backup(context.Background(), domain.BackupOptions{ChangedOnly: true})
+		require.NoError(t, err)
+		require.True(t, result.Success, result.Error)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --preview\n"+
+			"backup --api -- Balatro\n"+
+			"backup --api -- Hades\n", string(log))
+	})
+
+	t.Run("cancelled between batches", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithBatches(2, time.Hour)(executor)
+
+		// Cancel once the first batch has run
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			for ctx.Err() == nil {
+				if log, _ := os.ReadFile(logPath); strings.Count(string(log), "\n") == 2 {
+					cancel()
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		result, err := executor.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --preview\n"+
+			"backup --api -- Balatro Celeste\n", string(log))
+	})
+
+	t.Run("preempted between batches", func(t *testing.T) {
+		executor, logPath := newFakeLudusavi(t, preview, backup)
+		WithBatches(2, 0)(executor)
+
+		preempt := make(chan struct{})
+		close(preempt)
+		ctx := domain.WithPreempt(context.Background(), preempt)
+
+		result, err := executor.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Success, result.Error)
+		assert.True(t, result.Preempted)
+		assert.Equal(t, 2, result.Stats.TotalGames)
+
+		log, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, "backup --api --preview\n"+
+			"backup --api -- Balatro Celeste\n", string(log))
+	})
+}
+
+func TestLudusaviExecutor_Backup_Preview(t *testing.T) {
+	preview := `{"overall": {"totalGames": 1, "processedGames": 1, "changedGames": {"new": 1}}}`
+
+	executor, logPath := newFakeLudusavi(t, preview, "")
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Preview: true})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, 1, result.Stats.NewGames)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --preview\n", string(log))
+}
+
+func TestLudusaviExecutor_Restore(t *testing.T) {
+	preview := `{
+		"overall": {"totalGames": 1, "totalBytes": 100, "processedGames": 1, "processedBytes": 100,
+			"changedGames": {"new": 0, "different": 1, "same": 0}},
+		"games": {"Hades": {"decision": "Processed", "change": "Different",
+			"files": {"/saves/hades/Profile1.sav": {"change": "Different", "bytes": 100}}}}
+	}`
+
+	executor, logPath := newFakeLudusavi(t, preview, preview)
+
+	result, err := executor.Restore(context.Background(), domain.RestoreOptions{Preview: true, Games: []string{"Hades"}})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, domain.OperationRestore, result.Operation)
+	assert.Equal(t, 1, result.Stats.ProcessedGames)
+	assert.Equal(t, 1, result.Stats.ChangedGames)
+	assert.Equal(t, []string{"/saves/hades/Profile1.sav"}, result.SaveFiles)
+	assert.Equal(t, map[string]int64{"Hades": 100}, result.GameBytes)
+
+	_, err = executor.Restore(context.Background(), domain.RestoreOptions{Force: true, Path: "/mnt/backups"})
+	require.NoError(t, err)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "restore --api --preview -- Hades\nrestore --api --path /mnt/backups --force\n", string(log))
+}
+
+func TestLudusaviExecutor_CloudDownload(t *testing.T) {
+	output := `{
+		"overall": {"totalGames": 2, "totalBytes": 300, "processedGames": 2, "processedBytes": 300,
+			"changedGames": {"new": 1, "different": 1, "same": 0}},
+		"games": {}
+	}`
+
+	executor, logPath := newFakeLudusavi(t, output, output)
+
+	result, err := executor.CloudDownload(context.Background(), domain.DownloadOptions{Force: true})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, domain.OperationCloudDownload, result.Operation)
+	assert.Equal(t, 2, result.Stats.ProcessedGames)
+	assert.Equal(t, int64(300), result.Stats.ProcessedBytes)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "cloud download --api --force\n", string(log))
+}
+
+func TestLudusaviExecutor_Backup_LongUnicodePaths(t *testing.T) {
+	// Install the fake ludusavi under a path beyond MAX_PATH with non-ASCII
+	// directory names, as under a Windows profile with a Japanese username
+	dir := filepath.Join(t.TempDir(), strings.Repeat("ユーザー名/", 10), strings.Repeat("Ludusavi Ünïcödé ", 8))
+	require.NoError(t, os.MkdirAll(dir, 0750))
+	binary := filepath.Join(dir, filepath.Base(os.Args[0]))
+	src, err := os.ReadFile(os.Args[0])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(binary, src, 0700)) // #nosec G306 -- the fake ludusavi must be executable
+
+	savePath := "C:/Users/ユーザー名/Documents/My Games/ファイナルファンタジーⅩ/セーブデータ/slot1.sav"
+	preview := `{
+		"overall": {"totalGames": 2, "processedGames": 2, "changedGames": {"new": 1, "same": 1}},
+		"games": {
+			"ファイナルファンタジーⅩ": {"decision": "Processed", "change": "New"},
+			"Ōkami": {"decision": "Processed", "change": "Same"}
+		}
+	}`
+	backup := `{
+		"overall": {"totalGames": 1, "processedGames": 1, "changedGames": {"new": 1}},
+		"games": {"ファイナルファンタジーⅩ": {"decision": "Processed", "change": "New", "files": {
+			"` + savePath + `": {"change": "New", "bytes": 10}
+		}}}
+	}`
+
+	executor, logPath := newFakeLudusavi(t, preview, backup)
+	executor.binaryPath = binary
+	require.Greater(t, len(binary), 260)
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{
+		Force: true, ChangedOnly: true, Path: "E:/バックアップ",
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, []string{savePath}, result.SaveFiles)
+
+	log, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "backup --api --path E:/バックアップ --preview\n"+
+		"backup --api --path E:/バックアップ --force -- ファイナルファンタジーⅩ\n", string(log))
+}
+
 func TestLudusaviExecutor_ParseOutput_Success(t *testing.T) {
 	executor := NewLudusaviExecutor()
 
@@ -37,6 +633,28 @@ func TestLudusaviExecutor_ParseOutput_Success(t *testing.T) {
 	assert.Equal(t, 167, stats.SameGames)
 }
 
+func TestSaveFiles(t *testing.T) {
+	output := []byte(`{
+		"overall": {"totalGames": 2},
+		"games": {
+			"Hades": {"decision": "Processed", "change": "Same", "files": {
+				"C:/Users/me/Saved Games/Hades/Profile1.sav": {"change": "Same", "bytes": 10},
+				"C:/Users/me/Saved Games/Hades/Profile1.sav.bak": {"change": "Same", "bytes": 10}
+			}},
+			"Celeste": {"decision": "Processed", "change": "Same", "files": {
+				"C:/Program Files (x86)/Steam/userdata/1/504230/remote/0.celeste": {"change": "Same", "bytes": 5}
+			}}
+		}
+	}`)
+
+	assert.Equal(t, []string{
+		"C:/Program Files (x86)/Steam/userdata/1/504230/remote/0.celeste",
+		"C:/Users/me/Saved Games/Hades/Profile1.sav",
+		"C:/Users/me/Saved Games/Hades/Profile1.sav.bak",
+	}, saveFiles(output))
+	assert.Nil(t, saveFiles([]byte("not json")))
+}
+
 func TestLudusaviExecutor_ParseOutput_Empty(t *testing.T) {
 	executor := NewLudusaviExecutor()
 
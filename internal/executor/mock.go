@@ -8,10 +8,12 @@ import (
 
 // MockExecutor is a mock implementation of domain.Executor for testing.
 type MockExecutor struct {
-	BackupFunc      func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error)
-	CloudUploadFunc func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error)
-	VersionFunc     func(ctx context.Context) (string, error)
-	ValidateFunc    func(ctx context.Context) error
+	BackupFunc        func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error)
+	RestoreFunc       func(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error)
+	CloudUploadFunc   func(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error)
+	CloudDownloadFunc func(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error)
+	VersionFunc       func(ctx context.Context) (string, error)
+	ValidateFunc      func(ctx context.Context) error
 }
 
 // Backup calls the mock BackupFunc.
@@ -24,6 +26,16 @@ func (m *MockExecutor) Backup(ctx context.Context, opts domain.BackupOptions) (*
 	return result, nil
 }
 
+// Restore calls the mock RestoreFunc.
+func (m *MockExecutor) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, opts)
+	}
+	result := domain.NewBackupResult(domain.OperationRestore)
+	result.Complete(true, nil)
+	return result, nil
+}
+
 // CloudUpload calls the mock CloudUploadFunc.
 func (m *MockExecutor) CloudUpload(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
 	if m.CloudUploadFunc != nil {
@@ -34,6 +46,16 @@ func (m *MockExecutor) CloudUpload(ctx context.Context, opts domain.UploadOption
 	return result, nil
 }
 
+// CloudDownload calls the mock CloudDownloadFunc.
+func (m *MockExecutor) CloudDownload(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error) {
+	if m.CloudDownloadFunc != nil {
+		return m.CloudDownloadFunc(ctx, opts)
+	}
+	result := domain.NewBackupResult(domain.OperationCloudDownload)
+	result.Complete(true, nil)
+	return result, nil
+}
+
 // Version calls the mock VersionFunc.
 func (m *MockExecutor) Version(ctx context.Context) (string, error) {
 	if m.VersionFunc != nil {
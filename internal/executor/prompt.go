@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+const (
+	// rcloneAuthAddr is where rclone serves the OAuth redirect while it waits
+	// for the user to sign in to a cloud remote in their browser.
+	rcloneAuthAddr = "127.0.0.1:53682"
+	// authProbeInterval is how often rcloneAuthAddr is checked during a run.
+	authProbeInterval = 2 * time.Second
+	// promptTailSize is how much output is kept to find prompts split
+	// across writes.
+	promptTailSize = 256
+)
+
+// promptPatterns are printed, in lower case, by ludusavi or rclone when they
+// wait for input that will never come from a service.
+var promptPatterns = [][]byte{
+	[]byte("waiting for code"),
+	[]byte("go to the following link"),
+	[]byte("enter verification code"),
+	[]byte("paste the following into your remote machine"),
+	[]byte("y/n>"),
+	[]byte("press enter"),
+}
+
+// promptWatcher is a writer that scans a command's output for prompts and
+// calls cancel with domain.ErrAuthRequired when it finds one.
+type promptWatcher struct {
+	mu     sync.Mutex
+	tail   []byte
+	cancel context.CancelCauseFunc
+}
+
+// Write scans p, along with the end of the previous writes, for prompts.
+func (w *promptWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tail = append(w.tail, bytes.ToLower(p)...)
+	for _, pattern := range promptPatterns {
+		if bytes.Contains(w.tail, pattern) {
+			w.cancel(fmt.Errorf("%w: ludusavi is waiting for input (%q)", domain.ErrAuthRequired, pattern))
+			break
+		}
+	}
+	if len(w.tail) > promptTailSize {
+		w.tail = append(w.tail[:0], w.tail[len(w.tail)-promptTailSize:]...)
+	}
+	return len(p), nil
+}
+
+// watchAuthServer calls cancel with domain.ErrAuthRequired if rclone starts
+// serving its OAuth redirect at addr before ctx is done. Nothing is watched
+// if addr is already in use, as something else owns it.
+func watchAuthServer(ctx context.Context, addr string, interval time.Duration, cancel context.CancelCauseFunc) {
+	if listening(addr) {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if listening(addr) {
+				cancel(fmt.Errorf("%w: rclone is waiting for a browser sign-in at http://%s", domain.ErrAuthRequired, addr))
+				return
+			}
+		}
+	}
+}
+
+// listening reports whether something accepts connections at addr.
+func listening(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
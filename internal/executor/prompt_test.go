@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	w := &promptWatcher{cancel: cancel}
+
+	_, _ = w.Write([]byte("NOTICE: Config file not found\n"))
+	assert.NoError(t, ctx.Err())
+
+	// Prompts split across writes are found
+	_, _ = w.Write([]byte("2024/01/01 NOTICE: Make sure your Redirect URL is set. Waiting for "))
+	_, _ = w.Write([]byte("code...\n"))
+	assert.ErrorIs(t, context.Cause(ctx), domain.ErrAuthRequired)
+}
+
+func TestLudusaviExecutor_CloudUpload_Prompt(t *testing.T) {
+	executor, _ := newFakeLudusavi(t, "", "")
+	executor.env["FAKE_LUDUSAVI_STDERR"] = "If your browser doesn't open automatically go to the following link: http://127.0.0.1:53682/auth?state=abc\n"
+	executor.env["FAKE_LUDUSAVI_HANG"] = "1"
+
+	start := time.Now()
+	result, err := executor.CloudUpload(context.Background(), domain.UploadOptions{Force: true})
+	require.NoError(t, err)
+
+	assert.False(t, result.Success)
+	assert.True(t, result.AuthRequired)
+	assert.Contains(t, result.Error, "interactive auth required")
+	assert.Less(t, time.Since(start), 30*time.Second)
+}
+
+func TestLudusaviExecutor_CloudUpload_AuthServer(t *testing.T) {
+	// Find a free port for the fake rclone auth server
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	executor, _ := newFakeLudusavi(t, "", "")
+	executor.env["FAKE_LUDUSAVI_HANG"] = "1"
+	executor.authProbeAddr = addr
+	executor.authProbeInterval = 10 * time.Millisecond
+
+	// The server only comes up once ludusavi is running
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { _ = l.Close() })
+	}()
+
+	result, err := executor.CloudUpload(context.Background(), domain.UploadOptions{Force: true})
+	require.NoError(t, err)
+
+	assert.True(t, result.AuthRequired)
+	assert.Contains(t, result.Error, "browser sign-in")
+}
+
+func TestLudusaviExecutor_Backup_NoPrompt(t *testing.T) {
+	executor, _ := newFakeLudusavi(t, "", `{"overall": {"totalGames": 1}}`)
+	executor.env["FAKE_LUDUSAVI_STDERR"] = "some warning\n"
+
+	result, err := executor.Backup(context.Background(), domain.BackupOptions{Force: true})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.Error)
+	assert.False(t, result.AuthRequired)
+	assert.False(t, errors.Is(errors.New(result.Error), domain.ErrAuthRequired))
+}
@@ -0,0 +1,29 @@
+package executor
+
+import "strings"
+
+// WithSandbox runs ludusavi with reduced privileges: without the ability to
+// gain privileges on Linux, and with a token stripped of its privileges on
+// Windows. See platform.Sandbox.
+func WithSandbox(enabled bool) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.sandbox = enabled
+	}
+}
+
+// WithRestrictedEnv passes ludusavi only the variables of the runner's
+// environment it needs, besides the configured ones, rather than all of
+// them.
+func WithRestrictedEnv(enabled bool) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.restrictEnv = enabled
+	}
+}
+
+// WithBinaryHash refuses to run a ludusavi binary whose SHA-256 digest is not
+// sum, given in hex, to catch a binary that was replaced.
+func WithBinaryHash(sum string) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.pinnedHash = strings.ToLower(sum)
+	}
+}
@@ -0,0 +1,300 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// defaultScanCacheMaxAge is how long a scan is trusted before ludusavi is run
+// regardless, so games installed since the last scan are picked up.
+const defaultScanCacheMaxAge = 6 * time.Hour
+
+// ScanCacheExecutor wraps an executor and skips local backups when none of the
+// save files found by the previous backup, nor the directories containing
+// them, have changed since. Most cycles on an idle machine become a handful
+// of stat calls instead of a full ludusavi scan.
+//
+// Only file saves are tracked: registry-based saves and new save locations
+// are picked up once the cache expires.
+type ScanCacheExecutor struct {
+	executor domain.Executor
+	path     string
+	maxAge   time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// ScanCacheOption configures a ScanCacheExecutor.
+type ScanCacheOption func(*ScanCacheExecutor)
+
+// WithScanCacheMaxAge sets how long a scan is trusted. Zero keeps the default.
+func WithScanCacheMaxAge(d time.Duration) ScanCacheOption {
+	return func(c *ScanCacheExecutor) {
+		if d > 0 {
+			c.maxAge = d
+		}
+	}
+}
+
+// WithScanCacheLogger sets the logger.
+func WithScanCacheLogger(logger *slog.Logger) ScanCacheOption {
+	return func(c *ScanCacheExecutor) {
+		c.logger = logger
+	}
+}
+
+// NewScanCacheExecutor wraps executor with a scan cache stored at path.
+func NewScanCacheExecutor(executor domain.Executor, path string, opts ...ScanCacheOption) *ScanCacheExecutor {
+	c := &ScanCacheExecutor{
+		executor: executor,
+		path:     path,
+		maxAge:   defaultScanCacheMaxAge,
+		logger:   slog.Default(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// scanCache is the state persisted between runs.
+type scanCache struct {
+	// ScannedAt is when ludusavi last scanned every game.
+	ScannedAt time.Time `json:"scanned_at"`
+
+	// Stats are the statistics of that scan.
+	Stats domain.BackupStats `json:"stats"`
+
+	// Entries are the save files and their directories, by path.
+	Entries map[string]fileState `json:"entries"`
+}
+
+// fileState is the last-known state of a save file or directory.
+type fileState struct {
+	Dir     bool      `json:"dir,omitempty"`
+	Missing bool      `json:"missing,omitempty"`
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Backup runs a local backup unless nothing changed since the previous one.
+// Previews and backups to other directories always run, since the cache only
+// describes the default backup directory, and so do backups of named games,
+// whose saves may not be in the cache yet.
+func (c *ScanCacheExecutor) Backup(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+	if opts.Preview || opts.Path != "" {
+		return c.executor.Backup(ctx, opts)
+	}
+	log := logging.FromContext(ctx, c.logger)
+
+	cache, err := c.load()
+	if err != nil {
+		log.Warn("ignoring unreadable scan cache", "error", err)
+	}
+
+	partial := opts.ChangedOnly || len(opts.Games) > 0
+	if cache != nil && len(opts.Games) == 0 && c.now().Sub(cache.ScannedAt) < c.maxAge {
+		changed := cache.changed()
+		if changed == "" {
+			log.Info("no save files changed since last scan, skipping ludusavi",
+				"files", len(cache.Entries),
+				"scanned_at", cache.ScannedAt,
+			)
+			return cache.skippedResult(), nil
+		}
+		log.Debug("save file changed since last scan", "path", changed)
+	}
+
+	result, err := c.executor.Backup(ctx, opts)
+	if err != nil || !result.Success || len(result.SaveFiles) == 0 {
+		c.remove()
+		return result, err
+	}
+
+	switch {
+	case !partial:
+		cache = &scanCache{
+			ScannedAt: result.StartTime,
+			Stats:     result.Stats,
+			Entries:   snapshot(result.SaveFiles, result.StartTime),
+		}
+	case cache != nil:
+		// A partial backup leaves the full scan's age and statistics alone,
+		// but the files it backed up must not count as changed next time.
+		paths := result.SaveFiles
+		for path, state := range cache.Entries {
+			if !state.Dir {
+				paths = append(paths, path)
+			}
+		}
+		cache.Entries = snapshot(paths, result.StartTime)
+	default:
+		// Without a full scan to build on there is nothing to cache yet
+		return result, nil
+	}
+
+	if err := c.save(cache); err != nil {
+		log.Warn("failed to save scan cache", "error", err)
+	}
+
+	return result, nil
+}
+
+// Restore runs a restore. One that writes saves drops the cache, so the next
+// backup scans them again rather than trusting what the cache recorded.
+func (c *ScanCacheExecutor) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+	if !opts.Preview {
+		c.remove()
+	}
+	return c.executor.Restore(ctx, opts)
+}
+
+// CloudUpload runs a cloud upload; it is never skipped, since the cloud may
+// have changed from another machine.
+func (c *ScanCacheExecutor) CloudUpload(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+	return c.executor.CloudUpload(ctx, opts)
+}
+
+// CloudDownload runs a cloud download. It drops the cache, as the backups
+// it pulled down may differ from the saves the cache found unchanged.
+func (c *ScanCacheExecutor) CloudDownload(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error) {
+	c.remove()
+	return c.executor.CloudDownload(ctx, opts)
+}
+
+// Version returns the ludusavi version.
+func (c *ScanCacheExecutor) Version(ctx context.Context) (string, error) {
+	return c.executor.Version(ctx)
+}
+
+// Validate checks the wrapped executor.
+func (c *ScanCacheExecutor) Validate(ctx context.Context) error {
+	return c.executor.Validate(ctx)
+}
+
+// skippedResult returns the result of a backup skipped because nothing changed.
+func (s *scanCache) skippedResult() *domain.BackupResult {
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Stats = domain.BackupStats{
+		TotalGames: s.Stats.TotalGames,
+		TotalBytes: s.Stats.TotalBytes,
+		SameGames:  s.Stats.TotalGames,
+	}
+	result.Skipped = true
+	result.Complete(true, nil)
+	return result
+}
+
+// changed returns the first entry whose state differs from the cache, or an
+// empty string if nothing changed.
+func (s *scanCache) changed() string {
+	paths := make([]string, 0, len(s.Entries))
+	for path := range s.Entries {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if !stat(path).equal(s.Entries[path]) {
+			return path
+		}
+	}
+	return ""
+}
+
+// snapshot records the current state of each file and its directory. Files
+// modified after notAfter may have changed while they were being backed up,
+// so they are recorded in a state that never matches, forcing a rescan.
+func snapshot(paths []string, notAfter time.Time) map[string]fileState {
+	entries := make(map[string]fileState, 2*len(paths))
+	for _, path := range paths {
+		for _, p := range []string{path, filepath.Dir(path)} {
+			if _, ok := entries[p]; ok {
+				continue
+			}
+			state := stat(p)
+			if state.ModTime.After(notAfter) {
+				// No real file has a negative size
+				state = fileState{Dir: state.Dir, Size: -1}
+			}
+			entries[p] = state
+		}
+	}
+	return entries
+}
+
+// equal reports whether two states are the same.
+func (f fileState) equal(other fileState) bool {
+	return f.Dir == other.Dir &&
+		f.Missing == other.Missing &&
+		f.Size == other.Size &&
+		f.ModTime.Equal(other.ModTime)
+}
+
+// stat returns the current state of path.
+func stat(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{Missing: true}
+	}
+	if info.IsDir() {
+		return fileState{Dir: true, ModTime: info.ModTime().UTC()}
+	}
+	return fileState{Size: info.Size(), ModTime: info.ModTime().UTC()}
+}
+
+// load reads the cache, returning nil if there is none.
+func (c *ScanCacheExecutor) load() (*scanCache, error) {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan cache: %w", err)
+	}
+
+	var cache scanCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		c.remove()
+		return nil, fmt.Errorf("failed to parse scan cache: %w", err)
+	}
+	return &cache, nil
+}
+
+// save writes the cache.
+func (c *ScanCacheExecutor) save(cache *scanCache) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0750); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// remove deletes the cache, so the next backup always runs ludusavi.
+func (c *ScanCacheExecutor) remove() {
+	_ = os.Remove(c.path)
+}
+
+// Ensure ScanCacheExecutor implements domain.Executor.
+var _ domain.Executor = (*ScanCacheExecutor)(nil)
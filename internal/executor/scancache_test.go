@@ -0,0 +1,307 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// newSaveFile writes a save file last modified an hour ago.
+func newSaveFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, past, past))
+	require.NoError(t, os.Chtimes(filepath.Dir(path), past, past))
+	return path
+}
+
+// countingExecutor returns a mock executor that reports files as scanned and
+// counts its backups.
+func countingExecutor(files []string, calls *int) *MockExecutor {
+	return &MockExecutor{
+		BackupFunc: func(_ context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			*calls++
+			result := domain.NewBackupResult(domain.OperationBackup)
+			result.Stats = domain.BackupStats{TotalGames: 2, TotalBytes: 100, ProcessedGames: 2}
+			if opts.ChangedOnly {
+				result.Stats.TotalGames = 1
+			}
+			result.SaveFiles = files
+			result.Complete(true, nil)
+			return result, nil
+		},
+	}
+}
+
+func TestScanCacheExecutor_Backup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skips when nothing changed", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		first, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.False(t, first.Skipped)
+
+		second, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.True(t, second.Skipped)
+		assert.True(t, second.Success)
+		assert.Equal(t, 2, second.Stats.TotalGames)
+		assert.Equal(t, 2, second.Stats.SameGames)
+		assert.Equal(t, int64(100), second.Stats.TotalBytes)
+	})
+
+	t.Run("runs when a save file changed", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(save, []byte("more progress"), 0600))
+
+		result, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.False(t, result.Skipped)
+	})
+
+	t.Run("runs when a file appears next to a save", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(save), "slot2.dat"), []byte("new"), 0600))
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("handles long unicode save paths", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), strings.Repeat("ドラゴンクエスト/", 12))
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		save := filepath.Join(dir, "セーブ.dat")
+		require.NoError(t, os.WriteFile(save, []byte("progress"), 0600))
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(save, past, past))
+		require.NoError(t, os.Chtimes(dir, past, past))
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{filepath.ToSlash(save)}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		result, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Skipped)
+
+		require.NoError(t, os.WriteFile(save, []byte("more progress"), 0600))
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("runs when a save file was removed", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, os.Remove(save))
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("runs when the cache expired", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"),
+			WithScanCacheMaxAge(time.Hour),
+		)
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		exec.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not cache files modified during the backup", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		mock := countingExecutor([]string{save}, &calls)
+		backup := mock.BackupFunc
+		mock.BackupFunc = func(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+			result, err := backup(ctx, opts)
+			future := result.StartTime.Add(time.Minute)
+			require.NoError(t, os.Chtimes(save, future, future))
+			return result, err
+		}
+		exec := NewScanCacheExecutor(mock, filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("failure clears the cache", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		calls := 0
+		mock := countingExecutor([]string{save}, &calls)
+		exec := NewScanCacheExecutor(mock, cachePath)
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		require.FileExists(t, cachePath)
+
+		// Expire the cache so the failing backup runs
+		exec.now = func() time.Time { return time.Now().Add(7 * time.Hour) }
+		mock.BackupFunc = func(_ context.Context, _ domain.BackupOptions) (*domain.BackupResult, error) {
+			return nil, errors.New("ludusavi failed")
+		}
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.Error(t, err)
+		assert.NoFileExists(t, cachePath)
+	})
+
+	t.Run("preview bypasses the cache", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), cachePath)
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{Preview: true})
+		require.NoError(t, err)
+		assert.NoFileExists(t, cachePath)
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		_, err = exec.Backup(ctx, domain.BackupOptions{Preview: true})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("changed-only backup keeps the full scan statistics", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(save, []byte("more progress"), 0600))
+		past := time.Now().Add(-time.Minute)
+		require.NoError(t, os.Chtimes(save, past, past))
+
+		_, err = exec.Backup(ctx, domain.BackupOptions{ChangedOnly: true})
+		require.NoError(t, err)
+
+		result, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.True(t, result.Skipped)
+		assert.Equal(t, 2, result.Stats.TotalGames)
+	})
+
+	t.Run("game backup bypasses the cache", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), filepath.Join(t.TempDir(), "cache.json"))
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		result, err := exec.Backup(ctx, domain.BackupOptions{Games: []string{"Hades"}})
+		require.NoError(t, err)
+		assert.False(t, result.Skipped)
+
+		// It doesn't replace the full scan the cache describes
+		result, err = exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.True(t, result.Skipped)
+		assert.Equal(t, 2, result.Stats.TotalGames)
+	})
+
+	t.Run("restore clears the cache", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), cachePath)
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+
+		// A preview writes nothing
+		_, err = exec.Restore(ctx, domain.RestoreOptions{Preview: true})
+		require.NoError(t, err)
+		require.FileExists(t, cachePath)
+
+		_, err = exec.Restore(ctx, domain.RestoreOptions{Force: true})
+		require.NoError(t, err)
+		assert.NoFileExists(t, cachePath)
+
+		result, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.Skipped)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("cloud download clears the cache", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), cachePath)
+
+		_, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		require.FileExists(t, cachePath)
+
+		_, err = exec.CloudDownload(ctx, domain.DownloadOptions{Force: true})
+		require.NoError(t, err)
+		assert.NoFileExists(t, cachePath)
+	})
+
+	t.Run("unreadable cache is ignored", func(t *testing.T) {
+		save := newSaveFile(t, "save.dat", "progress")
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		require.NoError(t, os.WriteFile(cachePath, []byte("{not json"), 0600))
+		calls := 0
+		exec := NewScanCacheExecutor(countingExecutor([]string{save}, &calls), cachePath)
+
+		result, err := exec.Backup(ctx, domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.Skipped)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// snapshotCleanupTimeout bounds deleting snapshots after a backup, which
+// must happen even if the backup was cancelled.
+const snapshotCleanupTimeout = time.Minute
+
+// SnapshotExecutor wraps an executor and takes snapshots of volumes for the
+// duration of each local backup, deleting them afterwards.
+//
+// Ludusavi reads saves from their usual locations, so the snapshots are only
+// read by custom games in ludusavi that point at the snapshot path. A failed
+// snapshot is logged and the backup runs without it.
+type SnapshotExecutor struct {
+	executor    domain.Executor
+	snapshotter domain.Snapshotter
+	volumes     []string
+	logger      *slog.Logger
+}
+
+// SnapshotOption configures a SnapshotExecutor.
+type SnapshotOption func(*SnapshotExecutor)
+
+// WithSnapshotLogger sets the logger.
+func WithSnapshotLogger(logger *slog.Logger) SnapshotOption {
+	return func(s *SnapshotExecutor) {
+		s.logger = logger
+	}
+}
+
+// NewSnapshotExecutor wraps executor, snapshotting volumes with snapshotter.
+func NewSnapshotExecutor(executor domain.Executor, snapshotter domain.Snapshotter, volumes []string, opts ...SnapshotOption) *SnapshotExecutor {
+	s := &SnapshotExecutor{
+		executor:    executor,
+		snapshotter: snapshotter,
+		volumes:     volumes,
+		logger:      slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Backup runs a local backup while snapshots of the volumes exist. Previews
+// read nothing, so they run without snapshots.
+func (s *SnapshotExecutor) Backup(ctx context.Context, opts domain.BackupOptions) (*domain.BackupResult, error) {
+	if opts.Preview {
+		return s.executor.Backup(ctx, opts)
+	}
+	log := logging.FromContext(ctx, s.logger)
+
+	snapshots := make([]*domain.Snapshot, 0, len(s.volumes))
+	for _, volume := range s.volumes {
+		snapshot, err := s.snapshotter.Create(ctx, volume)
+		if err != nil {
+			log.Warn("failed to create snapshot, backing up without it", "volume", volume, "error", err)
+			continue
+		}
+		log.Info("created snapshot", "volume", volume, "path", snapshot.Path)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	defer func() {
+		// Clean up even if the backup was cancelled
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), snapshotCleanupTimeout)
+		defer cancel()
+		for _, snapshot := range snapshots {
+			if err := s.snapshotter.Delete(cleanupCtx, snapshot); err != nil {
+				log.Warn("failed to delete snapshot", "volume", snapshot.Volume, "error", err)
+			}
+		}
+	}()
+
+	return s.executor.Backup(ctx, opts)
+}
+
+// Restore runs a restore, which writes to the live save locations rather
+// than reading them, so it runs without snapshots.
+func (s *SnapshotExecutor) Restore(ctx context.Context, opts domain.RestoreOptions) (*domain.BackupResult, error) {
+	return s.executor.Restore(ctx, opts)
+}
+
+// CloudUpload runs a cloud upload, which only reads the backup directory.
+func (s *SnapshotExecutor) CloudUpload(ctx context.Context, opts domain.UploadOptions) (*domain.BackupResult, error) {
+	return s.executor.CloudUpload(ctx, opts)
+}
+
+// CloudDownload runs a cloud download, which only writes to the backup
+// directory.
+func (s *SnapshotExecutor) CloudDownload(ctx context.Context, opts domain.DownloadOptions) (*domain.BackupResult, error) {
+	return s.executor.CloudDownload(ctx, opts)
+}
+
+// Version returns the ludusavi version.
+func (s *SnapshotExecutor) Version(ctx context.Context) (string, error) {
+	return s.executor.Version(ctx)
+}
+
+// Validate checks the wrapped executor.
+func (s *SnapshotExecutor) Validate(ctx context.Context) error {
+	return s.executor.Validate(ctx)
+}
+
+// Ensure SnapshotExecutor implements domain.Executor.
+var _ domain.Executor = (*SnapshotExecutor)(nil)
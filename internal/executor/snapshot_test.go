@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/vss"
+)
+
+func TestSnapshotExecutor_Backup(t *testing.T) {
+	t.Run("snapshots exist during the backup", func(t *testing.T) {
+		snapshotter := &vss.MockSnapshotter{}
+		mock := &MockExecutor{
+			BackupFunc: func(_ context.Context, _ domain.BackupOptions) (*domain.BackupResult, error) {
+				assert.Equal(t, []string{"C:", "D:"}, snapshotter.Created)
+				assert.Empty(t, snapshotter.Deleted)
+				result := domain.NewBackupResult(domain.OperationBackup)
+				result.Complete(true, nil)
+				return result, nil
+			},
+		}
+
+		result, err := NewSnapshotExecutor(mock, snapshotter, []string{"C:", "D:"}).
+			Backup(context.Background(), domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, []string{"C:", "D:"}, snapshotter.Deleted)
+	})
+
+	t.Run("failed snapshot does not fail the backup", func(t *testing.T) {
+		snapshotter := &vss.MockSnapshotter{
+			CreateFunc: func(_ context.Context, volume string) (*domain.Snapshot, error) {
+				if volume == "D:" {
+					return nil, errors.New("access denied")
+				}
+				return &domain.Snapshot{ID: "1", Volume: volume}, nil
+			},
+		}
+
+		result, err := NewSnapshotExecutor(&MockExecutor{}, snapshotter, []string{"C:", "D:"}).
+			Backup(context.Background(), domain.BackupOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, []string{"C:"}, snapshotter.Deleted)
+	})
+
+	t.Run("snapshots deleted after a cancelled backup", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		snapshotter := &vss.MockSnapshotter{
+			DeleteFunc: func(ctx context.Context, _ *domain.Snapshot) error {
+				return ctx.Err()
+			},
+		}
+		mock := &MockExecutor{
+			BackupFunc: func(ctx context.Context, _ domain.BackupOptions) (*domain.BackupResult, error) {
+				cancel()
+				return nil, ctx.Err()
+			},
+		}
+
+		_, err := NewSnapshotExecutor(mock, snapshotter, []string{"C:"}).Backup(ctx, domain.BackupOptions{})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []string{"C:"}, snapshotter.Deleted)
+	})
+
+	t.Run("preview runs without snapshots", func(t *testing.T) {
+		snapshotter := &vss.MockSnapshotter{}
+
+		_, err := NewSnapshotExecutor(&MockExecutor{}, snapshotter, []string{"C:"}).
+			Backup(context.Background(), domain.BackupOptions{Preview: true})
+		require.NoError(t, err)
+		assert.Empty(t, snapshotter.Created)
+	})
+}
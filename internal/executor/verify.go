@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/verify"
+)
+
+// WithVerifier verifies the ludusavi binary with v before it is run: against
+// its minisign signature next to it, such as ludusavi.minisig, and against
+// checksums, a path or URL of a checksums file listing it, if set. A binary
+// is verified again once its contents change.
+func WithVerifier(v *verify.Verifier, checksums string) LudusaviOption {
+	return func(e *LudusaviExecutor) {
+		e.verifier = v
+		e.checksums = checksums
+	}
+}
+
+// verifiedBinary is the SHA-256 digest of the ludusavi binary last verified
+// by the verifier.
+type verifiedBinary struct {
+	mu  sync.Mutex
+	sum string
+}
+
+// verifyBinary returns an error unless the binary at path has the pinned
+// SHA-256 digest, if one is configured, and passes the verifier, if set. The
+// binary is identified by its digest, taken before every run, as a replaced
+// one can keep the size and modification time of the original; only the
+// verifier's result is kept for a binary whose digest is unchanged.
+func (e *LudusaviExecutor) verifyBinary(ctx context.Context, path string) error {
+	if e.pinnedHash == "" && e.verifier == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- the configured or detected ludusavi binary
+	if err != nil {
+		return fmt.Errorf("failed to verify ludusavi binary: %w", err)
+	}
+	digest := sha256.Sum256(data)
+	sum := hex.EncodeToString(digest[:])
+
+	if e.pinnedHash != "" && sum != e.pinnedHash {
+		return fmt.Errorf("ludusavi binary %s has SHA-256 %s, not the pinned %s; "+
+			"update sandbox.binary_sha256 if ludusavi was updated", path, sum, e.pinnedHash)
+	}
+	if e.verifier == nil {
+		return nil
+	}
+
+	e.verified.mu.Lock()
+	defer e.verified.mu.Unlock()
+	if sum == e.verified.sum {
+		return nil
+	}
+	src := verify.Sources{Signature: path + ".minisig", Checksums: e.checksums}
+	if err := e.verifier.Verify(ctx, filepath.Base(path), data, src); err != nil {
+		return err
+	}
+	e.verified.sum = sum
+	return nil
+}
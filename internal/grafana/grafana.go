@@ -0,0 +1,477 @@
+// Package grafana generates a Grafana dashboard for the metrics the runner
+// pushes, built from the metric catalog so it stays in sync with the code.
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+)
+
+const (
+	// DefaultTitle is the dashboard title.
+	DefaultTitle = "Ludusavi Runner"
+	// DefaultUID is the dashboard UID, kept stable so re-imports replace the
+	// dashboard instead of duplicating it.
+	DefaultUID = "ludusavi-runner"
+
+	// datasourceInput is the import input Grafana asks for when no
+	// datasource UID is given.
+	datasourceInput = "DS_PROMETHEUS"
+	schemaVersion   = 39
+)
+
+// Dashboard is a Grafana dashboard in its JSON model.
+type Dashboard struct {
+	Inputs        []Input    `json:"__inputs,omitempty"`
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Timezone      string     `json:"timezone"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+// Input is a value Grafana asks for when the dashboard is imported.
+type Input struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	PluginID string `json:"pluginId"`
+}
+
+// TimeRange is the default time range of the dashboard.
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Templating holds the dashboard variables.
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable is a dashboard variable.
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Query      string      `json:"query"`
+	Multi      bool        `json:"multi"`
+	IncludeAll bool        `json:"includeAll"`
+	Refresh    int         `json:"refresh"`
+}
+
+// Datasource references a Grafana datasource.
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// GridPos is the position and size of a panel.
+type GridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Panel is a dashboard panel.
+type Panel struct {
+	ID          int            `json:"id"`
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	GridPos     GridPos        `json:"gridPos"`
+	Datasource  *Datasource    `json:"datasource"`
+	Targets     []Target       `json:"targets"`
+	FieldConfig map[string]any `json:"fieldConfig"`
+	Options     map[string]any `json:"options,omitempty"`
+}
+
+// Target is a PromQL query of a panel.
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+// Option configures the generated dashboard.
+type Option func(*Dashboard)
+
+// WithTitle sets the dashboard title.
+func WithTitle(title string) Option {
+	return func(d *Dashboard) {
+		d.Title = title
+	}
+}
+
+// WithUID sets the dashboard UID.
+func WithUID(uid string) Option {
+	return func(d *Dashboard) {
+		d.UID = uid
+	}
+}
+
+// WithDatasource sets the UID of the Prometheus datasource. Without it the
+// dashboard asks for the datasource on import.
+func WithDatasource(uid string) Option {
+	return func(d *Dashboard) {
+		d.Inputs = nil
+		setDatasource(d, &Datasource{Type: "prometheus", UID: uid})
+	}
+}
+
+// WithMetricPrefix queries the metrics by names pushed with prefix in
+// place of metrics.DefaultPrefix.
+func WithMetricPrefix(prefix string) Option {
+	return func(d *Dashboard) {
+		for i := range d.Panels {
+			for j := range d.Panels[i].Targets {
+				t := &d.Panels[i].Targets[j]
+				t.Expr = metrics.RenameQuery(t.Expr, prefix)
+			}
+		}
+		for i := range d.Templating.List {
+			v := &d.Templating.List[i]
+			v.Query = metrics.RenameQuery(v.Query, prefix)
+		}
+	}
+}
+
+// NewDashboard returns the dashboard for the runner's metrics.
+func NewDashboard(opts ...Option) *Dashboard {
+	d := &Dashboard{
+		Inputs: []Input{{
+			Name:     datasourceInput,
+			Label:    "Prometheus",
+			Type:     "datasource",
+			PluginID: "prometheus",
+		}},
+		UID:           DefaultUID,
+		Title:         DefaultTitle,
+		Tags:          []string{"ludusavi", "backup"},
+		Timezone:      "browser",
+		SchemaVersion: schemaVersion,
+		Refresh:       "1m",
+		Time:          TimeRange{From: "now-7d", To: "now"},
+		Templating: Templating{List: []Variable{{
+			Name:       "instance",
+			Label:      "Instance",
+			Type:       "query",
+			Query:      fmt.Sprintf("label_values(%s, instance)", metrics.MetricUp),
+			Multi:      true,
+			IncludeAll: true,
+			Refresh:    2,
+		}}},
+		Panels: panels(),
+	}
+	setDatasource(d, &Datasource{Type: "prometheus", UID: "${" + datasourceInput + "}"})
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// JSON returns the dashboard as indented JSON, ready to import.
+func (d *Dashboard) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dashboard: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Exprs returns the PromQL queries of all panels.
+func (d *Dashboard) Exprs() []string {
+	var exprs []string
+	for _, p := range d.Panels {
+		for _, t := range p.Targets {
+			exprs = append(exprs, t.Expr)
+		}
+	}
+	return exprs
+}
+
+// setDatasource points the panels and variables at ds.
+func setDatasource(d *Dashboard, ds *Datasource) {
+	for i := range d.Panels {
+		d.Panels[i].Datasource = ds
+	}
+	for i := range d.Templating.List {
+		d.Templating.List[i].Datasource = ds
+	}
+}
+
+// sel returns a selector for metric on the selected instances, with extra
+// label matchers.
+func sel(metric string, matchers ...string) string {
+	s := metric + `{instance=~"$instance"`
+	for _, m := range matchers {
+		s += "," + m
+	}
+	return s + "}"
+}
+
+// panels returns the dashboard panels.
+func panels() []Panel {
+	local := metrics.LabelDestination + `=""`
+	backup := metrics.LabelOperation + `="backup"`
+	// Failures during maintenance are deliberate, so the failure panels skip them
+	live := metrics.LabelMaintenance + `!="true"`
+	successMapping := []any{map[string]any{
+		"type": "value",
+		"options": map[string]any{
+			"0": map[string]any{"text": "Failed", "color": "red"},
+			"1": map[string]any{"text": "OK", "color": "green"},
+		},
+	}}
+	stat := func(unit string, mappings []any, steps ...any) map[string]any {
+		defaults := map[string]any{"unit": unit}
+		if mappings != nil {
+			defaults["mappings"] = mappings
+		}
+		if steps != nil {
+			defaults["thresholds"] = map[string]any{"mode": "absolute", "steps": steps}
+		}
+		return map[string]any{"defaults": defaults, "overrides": []any{}}
+	}
+	step := func(color string, value any) any {
+		return map[string]any{"color": color, "value": value}
+	}
+	series := func(unit string) map[string]any {
+		return map[string]any{"defaults": map[string]any{"unit": unit}, "overrides": []any{}}
+	}
+
+	ps := []Panel{
+		{
+			Type:        "stat",
+			Title:       "Time since last backup",
+			Description: "Time since the last full or fast backup ended.",
+			GridPos:     GridPos{X: 0, Y: 0, W: 6, H: 4},
+			Targets: []Target{{
+				Expr: fmt.Sprintf(`time() - max by (instance) (%s)`,
+					sel(metrics.MetricLastRunTimestamp, metrics.LabelOperation+`=~"backup|fast_backup"`, local)),
+				LegendFormat: "{{instance}}",
+			}},
+			FieldConfig: stat("s", nil, step("green", nil), step("yellow", 86400), step("red", 3*86400)),
+		},
+		{
+			Type:        "stat",
+			Title:       "Last run status",
+			Description: "Whether the last run of each operation succeeded.",
+			GridPos:     GridPos{X: 6, Y: 0, W: 4, H: 4},
+			Targets: []Target{{
+				Expr:         sel(metrics.MetricLastRunSuccess, local, live),
+				LegendFormat: "{{operation}}",
+			}},
+			FieldConfig: stat("none", successMapping, step("red", nil), step("green", 1)),
+			Options:     map[string]any{"colorMode": "background"},
+		},
+		{
+			Type:        "stat",
+			Title:       "Last run outcome",
+			Description: "Whether the last run succeeded, failed, or failed only in part, with some of its backups made.",
+			GridPos:     GridPos{X: 10, Y: 0, W: 2, H: 4},
+			Targets:     []Target{{Expr: sel(metrics.MetricLastRunOutcome, live) + " == 1", LegendFormat: "{{outcome}}"}},
+			FieldConfig: stat("none", nil),
+			Options:     map[string]any{"textMode": "name"},
+		},
+		{
+			Type:        "stat",
+			Title:       "Game count",
+			Description: "Whether the last full backup found far fewer games than usual, as after a broken manifest update or a moved Steam library.",
+			GridPos:     GridPos{X: 12, Y: 0, W: 4, H: 4},
+			Targets:     []Target{{Expr: sel(metrics.MetricGameCountRegressed), LegendFormat: "{{instance}}"}},
+			FieldConfig: stat("none", []any{map[string]any{"type": "value", "options": map[string]any{
+				"0": map[string]any{"text": "OK", "color": "green"},
+				"1": map[string]any{"text": "Dropped", "color": "orange"},
+			}}}),
+			Options: map[string]any{"colorMode": "background"},
+		},
+		{
+			Type:    "stat",
+			Title:   "Service",
+			GridPos: GridPos{X: 16, Y: 0, W: 2, H: 4},
+			Targets: []Target{{Expr: sel(metrics.MetricUp), LegendFormat: "{{instance}}"}},
+			FieldConfig: stat("none", []any{map[string]any{"type": "value", "options": map[string]any{
+				"0": map[string]any{"text": "Down", "color": "red"},
+				"1": map[string]any{"text": "Up", "color": "green"},
+			}}}),
+			Options: map[string]any{"colorMode": "background"},
+		},
+		{
+			Type:        "stat",
+			Title:       "Schedule",
+			Description: "Whether scheduled backups are paused, as during maintenance of the backup disk.",
+			GridPos:     GridPos{X: 18, Y: 0, W: 2, H: 4},
+			Targets:     []Target{{Expr: sel(metrics.MetricPaused), LegendFormat: "{{instance}}"}},
+			FieldConfig: stat("none", []any{map[string]any{"type": "value", "options": map[string]any{
+				"0": map[string]any{"text": "Active", "color": "green"},
+				"1": map[string]any{"text": "Paused", "color": "orange"},
+			}}}),
+			Options: map[string]any{"colorMode": "background"},
+		},
+		{
+			Type:        "stat",
+			Title:       "Version",
+			GridPos:     GridPos{X: 20, Y: 0, W: 4, H: 2},
+			Targets:     []Target{{Expr: sel(metrics.MetricInfo), LegendFormat: "{{version}}"}},
+			FieldConfig: stat("none", nil),
+			Options:     map[string]any{"textMode": "name"},
+		},
+		{
+			Type:        "stat",
+			Title:       "Last run ID",
+			Description: "ID of the latest run, to find it in the service log and notifications.",
+			GridPos:     GridPos{X: 20, Y: 2, W: 4, H: 2},
+			Targets:     []Target{{Expr: sel(metrics.MetricLastRunInfo), LegendFormat: "{{run_id}}"}},
+			FieldConfig: stat("none", nil),
+			Options:     map[string]any{"textMode": "name"},
+		},
+		{
+			Type:    "timeseries",
+			Title:   "Run duration",
+			GridPos: GridPos{X: 0, Y: 4, W: 12, H: 8},
+			Targets: []Target{{
+				Expr:         sel(metrics.MetricLastRunDuration),
+				LegendFormat: "{{instance}} {{operation}} {{destination}}",
+			}},
+			FieldConfig: series("s"),
+		},
+		{
+			Type:    "timeseries",
+			Title:   "Games",
+			GridPos: GridPos{X: 12, Y: 4, W: 12, H: 8},
+			Targets: []Target{
+				{Expr: sel(metrics.MetricGamesTotal, backup, local), LegendFormat: "{{instance}} total"},
+				{Expr: sel(metrics.MetricGamesAverage), LegendFormat: "{{instance}} average"},
+				{Expr: sel(metrics.MetricGamesProcessed, backup, local), LegendFormat: "{{instance}} processed"},
+				{Expr: sel(metrics.MetricGamesNew, backup, local), LegendFormat: "{{instance}} new"},
+				{Expr: sel(metrics.MetricGamesChanged, backup, local), LegendFormat: "{{instance}} changed"},
+			},
+			FieldConfig: series("none"),
+		},
+		{
+			Type:    "timeseries",
+			Title:   "Save size",
+			GridPos: GridPos{X: 0, Y: 12, W: 12, H: 8},
+			Targets: []Target{
+				{Expr: sel(metrics.MetricBytesTotal, backup, local), LegendFormat: "{{instance}} total"},
+				{Expr: sel(metrics.MetricBytesProcessed), LegendFormat: "{{instance}} {{operation}} {{destination}} processed"},
+			},
+			FieldConfig: series("bytes"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Backup destinations",
+			Description: "Whether the last backup to each additional destination succeeded.",
+			GridPos:     GridPos{X: 12, Y: 12, W: 12, H: 8},
+			Targets: []Target{{
+				Expr:         sel(metrics.MetricLastRunSuccess, metrics.LabelDestination+`!=""`, live),
+				LegendFormat: "{{instance}} {{destination}}",
+			}},
+			FieldConfig: series("none"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Recoveries and delivery failures",
+			Description: "Panics recovered from runs, runs or scheduler loops recovered by the watchdog, metrics pushes that failed or the Pushgateway rejected, and notifications that failed.",
+			GridPos:     GridPos{X: 0, Y: 20, W: 12, H: 6},
+			Targets: []Target{
+				{Expr: fmt.Sprintf("increase(%s[1h])", sel(metrics.MetricPanics)), LegendFormat: "{{instance}} panics"},
+				{
+					Expr:         fmt.Sprintf("sum by (instance, reason) (increase(%s[1h]))", sel(metrics.MetricWatchdogRecoveries)),
+					LegendFormat: "{{instance}} watchdog {{reason}}",
+				},
+				{
+					Expr:         fmt.Sprintf("sum by (instance, reason) (increase(%s[1h]))", sel(metrics.MetricPushErrors)),
+					LegendFormat: "{{instance}} push {{reason}}",
+				},
+				{
+					Expr:         fmt.Sprintf("increase(%s[1h])", sel(metrics.MetricPushes, metrics.LabelOutcome+`="`+metrics.OutcomeFailed+`"`)),
+					LegendFormat: "{{instance}} failed pushes",
+				},
+				{
+					Expr:         fmt.Sprintf("sum by (instance, target) (increase(%s[1h]))", sel(metrics.MetricNotifications, metrics.LabelOutcome+`="`+metrics.OutcomeFailed+`"`)),
+					LegendFormat: "{{instance}} failed notifications {{target}}",
+				},
+			},
+			FieldConfig: series("none"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Failures by error code",
+			Description: "Failed operations and other run errors by error code, such as LR2003 for an expired cloud sign-in, across all machines.",
+			GridPos:     GridPos{X: 12, Y: 20, W: 12, H: 6},
+			Targets: []Target{{
+				Expr:         fmt.Sprintf("sum by (code) (increase(%s[1h]))", sel(metrics.MetricFailures, live)),
+				LegendFormat: "{{code}}",
+			}},
+			FieldConfig: series("none"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Runner CPU",
+			Description: "CPU used by the runner process itself, outside of ludusavi.",
+			GridPos:     GridPos{X: 0, Y: 26, W: 8, H: 8},
+			Targets: []Target{{
+				Expr:         fmt.Sprintf("rate(%s[5m])", sel(metrics.MetricProcessCPU)),
+				LegendFormat: "{{instance}}",
+			}},
+			FieldConfig: series("percentunit"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Runner memory",
+			GridPos:     GridPos{X: 8, Y: 26, W: 8, H: 8},
+			Targets:     []Target{{Expr: sel(metrics.MetricProcessMemory), LegendFormat: "{{instance}}"}},
+			FieldConfig: series("bytes"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Runner open files",
+			Description: "Open file descriptors, or handles on Windows, of the runner process.",
+			GridPos:     GridPos{X: 16, Y: 26, W: 8, H: 8},
+			Targets:     []Target{{Expr: sel(metrics.MetricProcessOpenFDs), LegendFormat: "{{instance}}"}},
+			FieldConfig: series("none"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Ludusavi CPU time",
+			Description: "CPU time of the ludusavi processes of each operation in the last run.",
+			GridPos:     GridPos{X: 0, Y: 34, W: 12, H: 8},
+			Targets: []Target{{
+				Expr:         sel(metrics.MetricLastRunCPU),
+				LegendFormat: "{{instance}} {{operation}} {{destination}}",
+			}},
+			FieldConfig: series("s"),
+		},
+		{
+			Type:        "timeseries",
+			Title:       "Ludusavi peak memory",
+			Description: "Peak resident memory of the ludusavi processes of each operation in the last run. Not reported on Windows.",
+			GridPos:     GridPos{X: 12, Y: 34, W: 12, H: 8},
+			Targets: []Target{{
+				Expr:         sel(metrics.MetricLastRunPeakMemory),
+				LegendFormat: "{{instance}} {{operation}} {{destination}}",
+			}},
+			FieldConfig: series("bytes"),
+		},
+	}
+
+	for i := range ps {
+		ps[i].ID = i + 1
+		for j := range ps[i].Targets {
+			ps[i].Targets[j].RefID = string(rune('A' + j))
+		}
+	}
+	return ps
+}
@@ -0,0 +1,94 @@
+package grafana
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricPattern matches metric names in PromQL queries.
+var metricPattern = regexp.MustCompile(`\bludusavi_[a-z_]+`)
+
+func TestNewDashboard_MatchesMetrics(t *testing.T) {
+	used := make(map[string]bool)
+	for _, expr := range NewDashboard().Exprs() {
+		for _, name := range metricPattern.FindAllString(expr, -1) {
+			_, ok := metrics.Lookup(name)
+			assert.True(t, ok, "query uses unknown metric %s: %s", name, expr)
+			used[name] = true
+		}
+	}
+
+	for _, d := range metrics.Definitions {
+		assert.True(t, used[d.Name], "metric %s is not on the dashboard", d.Name)
+	}
+}
+
+func TestNewDashboard_MetricPrefix(t *testing.T) {
+	d := NewDashboard(WithMetricPrefix("saves_"))
+	for _, expr := range d.Exprs() {
+		assert.NotContains(t, expr, metrics.DefaultPrefix)
+	}
+	assert.Equal(t, "label_values(saves_runner_up, instance)", d.Templating.List[0].Query)
+}
+
+func TestNewDashboard_FailurePanelsSkipMaintenance(t *testing.T) {
+	failurePanels := map[string]bool{
+		"Last run status":        true,
+		"Last run outcome":       true,
+		"Backup destinations":    true,
+		"Failures by error code": true,
+	}
+	found := 0
+	for _, p := range NewDashboard().Panels {
+		if !failurePanels[p.Title] {
+			continue
+		}
+		found++
+		for _, target := range p.Targets {
+			assert.Contains(t, target.Expr, `maintenance!="true"`, p.Title)
+		}
+	}
+	assert.Equal(t, len(failurePanels), found)
+}
+
+func TestDashboard_JSON(t *testing.T) {
+	t.Run("import input", func(t *testing.T) {
+		data, err := NewDashboard().JSON()
+		require.NoError(t, err)
+
+		var d map[string]any
+		require.NoError(t, json.Unmarshal(data, &d))
+		assert.Equal(t, DefaultUID, d["uid"])
+		assert.Equal(t, DefaultTitle, d["title"])
+		assert.Contains(t, string(data), `"name": "DS_PROMETHEUS"`)
+		assert.Contains(t, string(data), `"uid": "${DS_PROMETHEUS}"`)
+	})
+
+	t.Run("datasource", func(t *testing.T) {
+		data, err := NewDashboard(WithDatasource("prom-1"), WithTitle("Saves")).JSON()
+		require.NoError(t, err)
+
+		var d Dashboard
+		require.NoError(t, json.Unmarshal(data, &d))
+		assert.Empty(t, d.Inputs)
+		assert.Equal(t, "Saves", d.Title)
+		for _, p := range d.Panels {
+			assert.Equal(t, "prom-1", p.Datasource.UID, p.Title)
+		}
+		assert.Equal(t, "prom-1", d.Templating.List[0].Datasource.UID)
+	})
+
+	t.Run("unique panel ids", func(t *testing.T) {
+		ids := make(map[int]bool)
+		for _, p := range NewDashboard().Panels {
+			assert.False(t, ids[p.ID], "duplicate panel id %d", p.ID)
+			ids[p.ID] = true
+			assert.NotEmpty(t, p.Targets, p.Title)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Growth is how much a game's saves grew between the first and last full
+// run that found them.
+type Growth struct {
+	Game  string    `json:"game"`
+	Since time.Time `json:"since"`
+	From  int64     `json:"from_bytes"`
+	To    int64     `json:"to_bytes"`
+	Delta int64     `json:"delta_bytes"`
+	// PerDay is the average growth per day since then.
+	PerDay int64 `json:"bytes_per_day"`
+}
+
+// Leaderboard ranks the games whose saves grew over the successful full runs
+// among records, largest growth first, and returns the top n. Games whose
+// saves shrank or stayed the same are left out.
+func Leaderboard(records []Record, n int) []Growth {
+	type span struct {
+		first, last   time.Time
+		firstN, lastN int64
+	}
+	spans := make(map[string]*span)
+	for _, rec := range records {
+		if rec.Kind != KindFull || !rec.Success || rec.DryRun {
+			continue
+		}
+		for game, bytes := range rec.GameBytes {
+			s, ok := spans[game]
+			if !ok {
+				spans[game] = &span{first: rec.Start, last: rec.Start, firstN: bytes, lastN: bytes}
+				continue
+			}
+			s.last, s.lastN = rec.Start, bytes
+		}
+	}
+
+	var board []Growth
+	for game, s := range spans {
+		delta := s.lastN - s.firstN
+		if delta <= 0 {
+			continue
+		}
+		days := max(s.last.Sub(s.first).Hours()/24, 1)
+		board = append(board, Growth{
+			Game:   game,
+			Since:  s.first,
+			From:   s.firstN,
+			To:     s.lastN,
+			Delta:  delta,
+			PerDay: int64(float64(delta) / days),
+		})
+	}
+	slices.SortFunc(board, func(a, b Growth) int {
+		return cmp.Or(cmp.Compare(b.Delta, a.Delta), strings.Compare(a.Game, b.Game))
+	})
+	return board[:min(len(board), n)]
+}
+
+// Leaderboard ranks the games whose saves grew the most over the full runs
+// started at or after since, and returns the top n.
+func (s *Store) Leaderboard(since time.Time, n int) ([]Growth, error) {
+	records, err := s.Since(since)
+	if err != nil {
+		return nil, err
+	}
+	return Leaderboard(records, n), nil
+}
@@ -0,0 +1,318 @@
+// Package history keeps the results of finished runs in a JSON Lines file,
+// for reports and trends across runs.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// DefaultRetention is how long runs are kept by default.
+const DefaultRetention = 90 * 24 * time.Hour
+
+// pruneInterval is how often records older than the retention are removed.
+const pruneInterval = 24 * time.Hour
+
+// maxLineSize bounds the size of a record, which lists the size of every
+// game's saves for full runs.
+const maxLineSize = 16 << 20
+
+// Kind is the kind of run a record is of.
+type Kind string
+
+const (
+	// KindFull is a full run: cloud upload, backup, archive and so on.
+	KindFull Kind = "full"
+	// KindFast is a fast run of the games with changed saves.
+	KindFast Kind = "fast"
+	// KindGame is a run of games whose sessions ended.
+	KindGame Kind = "game"
+	// KindDestination is a run of additional destinations only.
+	KindDestination Kind = "destination"
+	// KindRestore is a restore of saves from the backups.
+	KindRestore Kind = "restore"
+)
+
+// Record is a finished run.
+type Record struct {
+	ID         string        `json:"id"`
+	Kind       Kind          `json:"kind"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+	Success    bool          `json:"success"`
+	DryRun     bool          `json:"dry_run,omitempty"`
+	Operations []Operation   `json:"operations,omitempty"`
+	Errors     []string      `json:"errors,omitempty"`
+	// ErrorCodes are the codes of Errors, in the same order.
+	ErrorCodes []domain.ErrorCode `json:"error_codes,omitempty"`
+
+	// PartialSuccess is set when the run failed, but made some backups.
+	PartialSuccess bool `json:"partial_success,omitempty"`
+
+	// GameBytes is the size of each game's saves by title, recorded for
+	// full runs only.
+	GameBytes map[string]int64 `json:"game_bytes,omitempty"`
+}
+
+// Operation is an operation of a run.
+type Operation struct {
+	Operation      domain.OperationType `json:"operation"`
+	Destination    string               `json:"destination,omitempty"`
+	Success        bool                 `json:"success"`
+	Skipped        bool                 `json:"skipped,omitempty"`
+	Duration       time.Duration        `json:"duration"`
+	TotalGames     int                  `json:"total_games"`
+	ProcessedGames int                  `json:"processed_games"`
+	TotalBytes     int64                `json:"total_bytes"`
+	ProcessedBytes int64                `json:"processed_bytes"`
+	Error          string               `json:"error,omitempty"`
+	Code           domain.ErrorCode     `json:"code,omitempty"`
+}
+
+// NewRecord returns the record of a finished run.
+func NewRecord(result *domain.RunResult) Record {
+	rec := Record{
+		ID:             result.ID,
+		Kind:           KindDestination,
+		Start:          result.StartTime,
+		Duration:       result.Duration,
+		Success:        result.Success,
+		PartialSuccess: result.PartialSuccess,
+		DryRun:         result.DryRun,
+		Errors:         result.Errors,
+		ErrorCodes:     result.ErrorCodes,
+	}
+	for _, op := range result.Operations() {
+		rec.Operations = append(rec.Operations, Operation{
+			Operation:      op.Operation,
+			Destination:    op.Destination,
+			Success:        op.Success,
+			Skipped:        op.Skipped,
+			Duration:       op.Duration,
+			TotalGames:     op.Stats.TotalGames,
+			ProcessedGames: op.Stats.ProcessedGames,
+			TotalBytes:     op.Stats.TotalBytes,
+			ProcessedBytes: op.Stats.ProcessedBytes,
+			Error:          op.Error,
+			Code:           op.Code,
+		})
+	}
+
+	if result.Restore != nil {
+		rec.Kind = KindRestore
+	}
+	if result.Backup != nil {
+		switch result.Backup.Operation {
+		case domain.OperationBackup:
+			rec.Kind = KindFull
+			rec.GameBytes = result.Backup.GameBytes
+		case domain.OperationFastBackup:
+			rec.Kind = KindFast
+		case domain.OperationGameBackup:
+			rec.Kind = KindGame
+		}
+	}
+	return rec
+}
+
+// Backup returns the local backup operation of the run, if it had one.
+func (r *Record) Backup() *Operation {
+	for i, op := range r.Operations {
+		if op.Destination == "" && (op.Operation == domain.OperationBackup ||
+			op.Operation == domain.OperationFastBackup || op.Operation == domain.OperationGameBackup) {
+			return &r.Operations[i]
+		}
+	}
+	return nil
+}
+
+// Store keeps records in a JSON Lines file, oldest first.
+type Store struct {
+	path      string
+	retention time.Duration
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	prunedAt time.Time
+}
+
+// StoreOption configures a Store.
+type StoreOption func(*Store)
+
+// WithRetention sets how long records are kept.
+func WithRetention(d time.Duration) StoreOption {
+	return func(s *Store) {
+		s.retention = d
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) StoreOption {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// NewStore creates a new Store keeping records in the file at path.
+func NewStore(path string, opts ...StoreOption) *Store {
+	s := &Store{
+		path:      path,
+		retention: DefaultRetention,
+		logger:    slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Path returns the path of the history file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Record adds the result of a finished run, and once a day removes records
+// older than the retention.
+func (s *Store) Record(result *domain.RunResult) error {
+	line, err := json.Marshal(NewRecord(result))
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.prunedAt) > pruneInterval {
+		if err := s.prune(); err != nil {
+			s.logger.Warn("failed to prune run history", "error", err)
+		}
+		s.prunedAt = time.Now()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Close()
+}
+
+// Since returns the records of the runs started at or after since, oldest
+// first.
+func (s *Store) Since(since time.Time) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for i, rec := range records {
+		if !rec.Start.Before(since) {
+			return records[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+// Recent returns the records of the last n runs, newest first, or of all
+// runs if n is not positive.
+func (s *Store) Recent(n int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	slices.Reverse(records)
+	return records, nil
+}
+
+// read reads all records. Unreadable lines, such as one cut short by a
+// crash, are skipped. The caller must hold mu.
+func (s *Store) read() ([]Record, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			s.logger.Warn("skipping unreadable run history record", "error", err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return records, nil
+}
+
+// prune rewrites the history without the records older than the retention.
+// The caller must hold mu.
+func (s *Store) prune() error {
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-s.retention)
+	drop := 0
+	for drop < len(records) && records[drop].Start.Before(cutoff) {
+		drop++
+	}
+	if drop == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	for _, rec := range records[drop:] {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to encode history record: %w", err)
+		}
+		b.Write(append(line, '\n'))
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace history: %w", err)
+	}
+	s.logger.Debug("pruned run history", "removed", drop)
+	return nil
+}
+
+// Ensure Store implements domain.RunHistory.
+var _ domain.RunHistory = (*Store)(nil)
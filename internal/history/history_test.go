@@ -0,0 +1,122 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newResult returns the result of a finished run of op, started at start.
+func newResult(op domain.OperationType, start time.Time, err error) *domain.RunResult {
+	result := domain.NewRunResult(false)
+	result.StartTime = start
+	result.Backup = domain.NewBackupResult(op)
+	result.Backup.Stats.TotalBytes = 1000
+	result.Backup.GameBytes = map[string]int64{"Celeste": 600, "Hades": 400}
+	result.Backup.Complete(err == nil, err)
+	result.Complete()
+	return result
+}
+
+func TestNewRecord(t *testing.T) {
+	start := time.Now()
+
+	rec := NewRecord(newResult(domain.OperationBackup, start, nil))
+	assert.Equal(t, KindFull, rec.Kind)
+	assert.True(t, rec.Success)
+	assert.Equal(t, map[string]int64{"Celeste": 600, "Hades": 400}, rec.GameBytes)
+	require.NotNil(t, rec.Backup())
+	assert.Equal(t, int64(1000), rec.Backup().TotalBytes)
+
+	// Game sizes are kept for full runs only, which cover every game
+	rec = NewRecord(newResult(domain.OperationFastBackup, start, errors.New("disk full")))
+	assert.Equal(t, KindFast, rec.Kind)
+	assert.False(t, rec.Success)
+	assert.Nil(t, rec.GameBytes)
+	assert.Equal(t, "disk full", rec.Backup().Error)
+	assert.Equal(t, domain.CodeLudusaviFailed, rec.Backup().Code)
+
+	result := domain.NewRunResult(false)
+	result.Destinations = []*domain.BackupResult{domain.NewBackupResult(domain.OperationBackup)}
+	result.Destinations[0].Destination = "usb"
+	rec = NewRecord(result)
+	assert.Equal(t, KindDestination, rec.Kind)
+	assert.Nil(t, rec.Backup())
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.jsonl")
+	store := NewStore(path, WithRetention(48*time.Hour))
+	now := time.Now()
+
+	records, err := store.Since(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	for _, start := range []time.Time{now.Add(-72 * time.Hour), now.Add(-time.Hour), now} {
+		require.NoError(t, store.Record(newResult(domain.OperationBackup, start, nil)))
+	}
+	records, err = store.Since(now.Add(-2 * time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), records[0].Start.Unix())
+
+	records, err = store.Recent(2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, now.Unix(), records[0].Start.Unix(), "newest first")
+	assert.Equal(t, now.Add(-time.Hour).Unix(), records[1].Start.Unix())
+	records, err = store.Recent(0)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// A line cut short by a crash is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":"cut`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	records, err = store.Since(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// Records past the retention are pruned by the first record of a day
+	store = NewStore(path, WithRetention(48*time.Hour))
+	require.NoError(t, store.Record(newResult(domain.OperationBackup, now, nil)))
+	records, err = store.Since(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.False(t, records[0].Start.Before(now.Add(-48*time.Hour)))
+}
+
+func TestLeaderboard(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	full := func(days int, success bool, games map[string]int64) Record {
+		return Record{Kind: KindFull, Start: start.AddDate(0, 0, days), Success: success, GameBytes: games}
+	}
+	records := []Record{
+		full(0, true, map[string]int64{"Cyberpunk 2077": 100 << 20, "Celeste": 1 << 20, "Tunic": 5 << 20}),
+		full(5, false, map[string]int64{"Cyberpunk 2077": 900 << 20}),
+		{Kind: KindFast, Start: start.AddDate(0, 0, 6), Success: true, GameBytes: map[string]int64{"Celeste": 90 << 20}},
+		full(8, true, map[string]int64{"Cyberpunk 2077": 500 << 20, "Celeste": 2 << 20, "Tunic": 4 << 20, "Hades": 1 << 20}),
+		full(10, true, map[string]int64{"Cyberpunk 2077": 600 << 20, "Celeste": 3 << 20, "Hades": 1 << 20}),
+	}
+
+	// Failed and partial runs are left out, and so are shrinking, steady
+	// and new games
+	board := Leaderboard(records, 5)
+	require.Len(t, board, 2)
+	assert.Equal(t, Growth{
+		Game: "Cyberpunk 2077", Since: start, From: 100 << 20, To: 600 << 20, Delta: 500 << 20, PerDay: 50 << 20,
+	}, board[0])
+	assert.Equal(t, "Celeste", board[1].Game)
+	assert.Equal(t, int64(2<<20), board[1].Delta)
+
+	assert.Len(t, Leaderboard(records, 1), 1)
+}
@@ -0,0 +1,30 @@
+package history
+
+import (
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockHistory is a mock implementation of domain.RunHistory for testing.
+type MockHistory struct {
+	RecordFunc func(result *domain.RunResult) error
+
+	// Recorded stores all run results that have been recorded.
+	Recorded []*domain.RunResult
+}
+
+// Record calls the mock RecordFunc and stores the result.
+func (m *MockHistory) Record(result *domain.RunResult) error {
+	m.Recorded = append(m.Recorded, result)
+	if m.RecordFunc != nil {
+		return m.RecordFunc(result)
+	}
+	return nil
+}
+
+// Reset clears all stored run results.
+func (m *MockHistory) Reset() {
+	m.Recorded = nil
+}
+
+// Ensure MockHistory implements domain.RunHistory.
+var _ domain.RunHistory = (*MockHistory)(nil)
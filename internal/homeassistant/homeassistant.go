@@ -0,0 +1,217 @@
+// Package homeassistant publishes backup health to Home Assistant through its
+// REST API, so it can be used in automations without an MQTT broker.
+package homeassistant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// Client sets entity states in Home Assistant after each run.
+type Client struct {
+	url        string
+	token      string
+	prefix     string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets a custom HTTP client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient creates a new Client for the Home Assistant instance at url,
+// authenticating with a long-lived access token. Entity IDs start with prefix.
+func NewClient(url, token, prefix string, opts ...Option) *Client {
+	c := &Client{
+		url:        strings.TrimSuffix(url, "/"),
+		token:      token,
+		prefix:     prefix,
+		httpClient: http.NewClient(),
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// State is the state of a Home Assistant entity.
+type State struct {
+	EntityID   string         `json:"-"`
+	State      string         `json:"state"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// Push sets the entity states for the results in metrics. Metrics without
+// results, such as those sent after a recovered panic, change nothing.
+func (c *Client) Push(ctx context.Context, metrics *domain.Metrics) error {
+	for _, state := range c.States(metrics) {
+		if err := c.setState(ctx, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// States returns the entity states for the results in metrics. Each
+// operation gets a last run and last success timestamp and a problem binary
+// sensor; the backup operation also reports the number and size of saves.
+// Results for additional backup destinations are left out.
+func (c *Client) States(metrics *domain.Metrics) []State {
+	var states []State
+	for _, r := range metrics.Results {
+		if r.Destination != "" {
+			continue
+		}
+
+		op := r.Operation.String()
+		name := "Ludusavi " + strings.ReplaceAll(op, "_", " ")
+		lastRun := State{
+			EntityID: c.entityID("sensor", op, "last_run"),
+			State:    r.EndTime.UTC().Format(time.RFC3339),
+			Attributes: map[string]any{
+				"device_class":  "timestamp",
+				"friendly_name": name + " last run",
+				"duration":      r.Duration.Seconds(),
+			},
+		}
+		if metrics.RunID != "" {
+			lastRun.Attributes["run_id"] = metrics.RunID
+		}
+		states = append(states, lastRun)
+		if r.Success {
+			states = append(states, State{
+				EntityID: c.entityID("sensor", op, "last_success"),
+				State:    r.EndTime.UTC().Format(time.RFC3339),
+				Attributes: map[string]any{
+					"device_class":  "timestamp",
+					"friendly_name": name + " last success",
+				},
+			})
+		}
+
+		problem := State{
+			EntityID: c.entityID("binary_sensor", op, "problem"),
+			State:    "off",
+			Attributes: map[string]any{
+				"device_class":  "problem",
+				"friendly_name": name + " problem",
+			},
+		}
+		if !r.Success {
+			problem.State = "on"
+			if r.Error != "" {
+				problem.Attributes["error"] = r.Error
+			}
+		}
+		states = append(states, problem)
+
+		if r.Operation == domain.OperationBackup {
+			states = append(states,
+				State{
+					EntityID: c.entityID("sensor", op, "games"),
+					State:    fmt.Sprint(r.Stats.TotalGames),
+					Attributes: map[string]any{
+						"friendly_name": name + " games",
+						"state_class":   "measurement",
+						"new":           r.Stats.NewGames,
+						"changed":       r.Stats.ChangedGames,
+					},
+				},
+				State{
+					EntityID: c.entityID("sensor", op, "size"),
+					State:    fmt.Sprint(r.Stats.TotalBytes),
+					Attributes: map[string]any{
+						"device_class":        "data_size",
+						"unit_of_measurement": "B",
+						"state_class":         "measurement",
+						"friendly_name":       name + " size",
+					},
+				},
+			)
+		}
+	}
+	return states
+}
+
+// entityID returns the ID of an entity in domain, e.g.
+// "sensor.ludusavi_runner_backup_last_run".
+func (c *Client) entityID(entityDomain, op, suffix string) string {
+	return fmt.Sprintf("%s.%s_%s_%s", entityDomain, c.prefix, op, suffix)
+}
+
+// setState creates or updates an entity through POST /api/states/<entity_id>.
+func (c *Client) setState(ctx context.Context, state State) error {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	resp, err := c.do(ctx, nethttp.MethodPost, "/api/states/"+state.EntityID, body)
+	if err != nil {
+		return fmt.Errorf("failed to set home assistant state of %s: %w", state.EntityID, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("home assistant returned status %d for %s: %s", resp.StatusCode, state.EntityID, string(resp.Body))
+	}
+
+	logging.FromContext(ctx, c.logger).Debug("home assistant state set", "entity_id", state.EntityID, "state", state.State)
+	return nil
+}
+
+// Validate checks that Home Assistant is reachable and accepts the token.
+func (c *Client) Validate(ctx context.Context) error {
+	resp, err := c.do(ctx, nethttp.MethodGet, "/api/", nil, http.WithRequestTimeout(10*time.Second))
+	if err != nil {
+		return fmt.Errorf("home assistant not reachable at %s: %w", c.url, err)
+	}
+	switch {
+	case resp.StatusCode == nethttp.StatusUnauthorized:
+		return fmt.Errorf("home assistant rejected the access token")
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("home assistant returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// do sends an authenticated request to the Home Assistant API.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, opts ...http.RequestOption) (*http.Response, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(ctx, req, opts...)
+}
+
+// Ensure Client implements domain.MetricsPusher.
+var _ domain.MetricsPusher = (*Client)(nil)
@@ -0,0 +1,124 @@
+package homeassistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	internalhttp "github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noRetry is an HTTP client that fails on the first error.
+var noRetry = internalhttp.NewClient(internalhttp.WithRetryConfig(internalhttp.RetryConfig{
+	MaxAttempts:  1,
+	InitialDelay: time.Millisecond,
+	MaxDelay:     time.Millisecond,
+}))
+
+func TestClient_Push(t *testing.T) {
+	var mu sync.Mutex
+	states := make(map[string]map[string]any)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(r.Body)
+		var state map[string]any
+		assert.NoError(t, json.Unmarshal(body, &state))
+
+		mu.Lock()
+		states[r.URL.Path] = state
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	backup := domain.NewBackupResult(domain.OperationBackup)
+	backup.Stats = domain.BackupStats{TotalGames: 42, TotalBytes: 1024}
+	backup.Complete(true, nil)
+	upload := domain.NewBackupResult(domain.OperationCloudUpload)
+	upload.Complete(false, errors.New("rclone failed"))
+	dest := domain.NewBackupResult(domain.OperationBackup)
+	dest.Destination = "usb"
+	dest.Complete(true, nil)
+
+	metrics := domain.NewMetrics("test-host")
+	metrics.AddResult(backup)
+	metrics.AddResult(upload)
+	metrics.AddResult(dest)
+
+	client := NewClient(server.URL+"/", "secret", "ludusavi_runner", WithHTTPClient(noRetry))
+	require.NoError(t, client.Push(context.Background(), metrics))
+
+	lastSuccess := states["/api/states/sensor.ludusavi_runner_backup_last_success"]
+	require.NotNil(t, lastSuccess)
+	assert.Equal(t, backup.EndTime.UTC().Format(time.RFC3339), lastSuccess["state"])
+	assert.Equal(t, "timestamp", lastSuccess["attributes"].(map[string]any)["device_class"])
+
+	assert.Equal(t, "off", states["/api/states/binary_sensor.ludusavi_runner_backup_problem"]["state"])
+	assert.Equal(t, "42", states["/api/states/sensor.ludusavi_runner_backup_games"]["state"])
+	assert.Equal(t, "1024", states["/api/states/sensor.ludusavi_runner_backup_size"]["state"])
+
+	problem := states["/api/states/binary_sensor.ludusavi_runner_cloud_upload_problem"]
+	assert.Equal(t, "on", problem["state"])
+	assert.Equal(t, "rclone failed", problem["attributes"].(map[string]any)["error"])
+	assert.Contains(t, states, "/api/states/sensor.ludusavi_runner_cloud_upload_last_run")
+	assert.NotContains(t, states, "/api/states/sensor.ludusavi_runner_cloud_upload_last_success")
+
+	// Destinations don't get entities of their own
+	assert.Len(t, states, 7)
+}
+
+func TestClient_Push_NoResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "secret", "ludusavi_runner", WithHTTPClient(noRetry))
+	assert.NoError(t, client.Push(context.Background(), domain.NewMetrics("test-host")))
+}
+
+func TestClient_Push_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("bad state"))
+	}))
+	defer server.Close()
+
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Complete(true, nil)
+	metrics := domain.NewMetrics("test-host")
+	metrics.AddResult(result)
+
+	client := NewClient(server.URL, "secret", "ludusavi_runner", WithHTTPClient(noRetry))
+	err := client.Push(context.Background(), metrics)
+	assert.ErrorContains(t, err, "home assistant returned status 400 for sensor.ludusavi_runner_backup_last_run")
+}
+
+func TestClient_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"message": "API running."}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, NewClient(server.URL, "secret", "x", WithHTTPClient(noRetry)).Validate(context.Background()))
+	assert.ErrorContains(t, NewClient(server.URL, "wrong", "x", WithHTTPClient(noRetry)).Validate(context.Background()),
+		"home assistant rejected the access token")
+}
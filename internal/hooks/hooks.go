@@ -0,0 +1,207 @@
+// Package hooks runs user commands before and after backup runs and their
+// operations, such as to stop a sync client while saves are backed up or to
+// hand the outcome of a run to a script.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+)
+
+// Environment variables set for hook commands.
+const (
+	// EnvHook is the stage the hook runs at: "pre" or "post".
+	EnvHook = "LUDUSAVI_RUNNER_HOOK"
+	// EnvOperation is the operation hooked, such as "cloud_upload", or
+	// empty for the hooks of a whole run.
+	EnvOperation = "LUDUSAVI_RUNNER_OPERATION"
+	// EnvRunID is the ID of the run.
+	EnvRunID = "LUDUSAVI_RUNNER_RUN_ID"
+	// EnvSuccess is "true" or "false", the outcome of what was hooked, for
+	// post hooks only.
+	EnvSuccess = "LUDUSAVI_RUNNER_SUCCESS"
+)
+
+// DefaultTimeout is how long a hook command may run when no timeout is set.
+const DefaultTimeout = 5 * time.Minute
+
+// Stage is when a hook runs relative to what it hooks.
+type Stage string
+
+const (
+	// Pre hooks run before a run or operation.
+	Pre Stage = "pre"
+	// Post hooks run after a run or operation, knowing its outcome.
+	Post Stage = "post"
+)
+
+// Commands are the hook commands of a run or an operation: each a program
+// and its arguments, or empty for none.
+type Commands struct {
+	Pre  []string
+	Post []string
+}
+
+// command returns the command of stage.
+func (c Commands) command(stage Stage) []string {
+	if stage == Pre {
+		return c.Pre
+	}
+	return c.Post
+}
+
+// Event is a point in a run that hooks are run at.
+type Event struct {
+	Stage Stage
+	// Operation is the operation hooked, or empty for the whole run.
+	Operation domain.OperationType
+	RunID     string
+	// Success is the outcome of what was hooked, for post hooks.
+	Success bool
+	// Input is passed to the command as JSON on its standard input, such
+	// as the result of the run or operation.
+	Input any
+}
+
+// Name names the hook of the event as in the config file, such as
+// "pre_backup" or "operations.cloud_upload.post".
+func (e Event) Name() string {
+	if e.Operation == "" {
+		return string(e.Stage) + "_backup"
+	}
+	return "operations." + string(e.Operation) + "." + string(e.Stage)
+}
+
+// Runner runs the hook commands of runs and operations.
+type Runner struct {
+	run        Commands
+	operations map[domain.OperationType]Commands
+	timeout    time.Duration
+	env        map[string]string
+	envVars    map[string]string
+	logger     *slog.Logger
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithOperations sets the hook commands of single operations.
+func WithOperations(operations map[domain.OperationType]Commands) Option {
+	return func(h *Runner) {
+		h.operations = operations
+	}
+}
+
+// WithTimeout sets how long a hook command may run.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Runner) {
+		h.timeout = d
+	}
+}
+
+// WithEnv sets environment variables to pass to hook commands, expanded
+// with vars like ludusavi's.
+func WithEnv(env, vars map[string]string) Option {
+	return func(h *Runner) {
+		h.env = env
+		h.envVars = vars
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Runner) {
+		h.logger = logger
+	}
+}
+
+// NewRunner creates a new Runner running the run commands before and after
+// each backup run.
+func NewRunner(run Commands, opts ...Option) *Runner {
+	h := &Runner{
+		run:     run,
+		timeout: DefaultTimeout,
+		logger:  slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Run runs the hook of the event, if any. Its output is logged, and an
+// error returned if it fails or doesn't finish within the timeout.
+func (h *Runner) Run(ctx context.Context, event Event) error {
+	command := h.command(event)
+	if len(command) == 0 {
+		return nil
+	}
+	log := logging.FromContext(ctx, h.logger).With("hook", event.Name())
+
+	input, err := json.Marshal(event.Input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s hook input: %w", event.Name(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	env := maps.Clone(h.env)
+	if env == nil {
+		env = make(map[string]string, 4)
+	}
+	env[EnvHook] = string(event.Stage)
+	env[EnvOperation] = string(event.Operation)
+	env[EnvRunID] = event.RunID
+	if event.Stage == Post {
+		env[EnvSuccess] = strconv.FormatBool(event.Success)
+	}
+
+	// #nosec G204 -- command is from config, not user input
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = platform.Environ(env, h.envVars)
+	cmd.Stdin = bytes.NewReader(input)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	log.Debug("running hook", "command", command[0])
+	start := time.Now()
+	err = platform.RunProcessGroup(cmd)
+	if msg := strings.TrimSpace(output.String()); msg != "" {
+		log.Info("hook output", "output", msg)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s hook did not finish within %s", event.Name(), h.timeout)
+		}
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%s hook failed: %s: %w", event.Name(), platform.LastLine(msg), err)
+		}
+		return fmt.Errorf("%s hook failed: %w", event.Name(), err)
+	}
+	log.Debug("hook completed", "duration", time.Since(start))
+	return nil
+}
+
+// command returns the command of the event's hook.
+func (h *Runner) command(event Event) []string {
+	if event.Operation == "" {
+		return h.run.command(event.Stage)
+	}
+	return h.operations[event.Operation].command(event.Stage)
+}
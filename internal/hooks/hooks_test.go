@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	script := `printf "%s %s %s %s %s " "$LUDUSAVI_RUNNER_HOOK" "$LUDUSAVI_RUNNER_OPERATION" "$LUDUSAVI_RUNNER_RUN_ID" "$LUDUSAVI_RUNNER_SUCCESS" "$SYNC" >> "$OUT"; cat >> "$OUT"`
+	h := NewRunner(Commands{Post: []string{"sh", "-c", script}},
+		WithOperations(map[domain.OperationType]Commands{
+			domain.OperationCloudUpload: {Pre: []string{"sh", "-c", script}},
+		}),
+		WithEnv(map[string]string{"OUT": out, "SYNC": "${config_dir}/sync"}, map[string]string{"config_dir": "/cfg"}),
+	)
+
+	require.NoError(t, h.Run(context.Background(), Event{
+		Stage: Post, RunID: "run-1", Success: true, Input: map[string]bool{"success": true},
+	}))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `post  run-1 true /cfg/sync {"success":true}`, string(data))
+
+	require.NoError(t, os.Remove(out))
+	require.NoError(t, h.Run(context.Background(), Event{
+		Stage: Pre, Operation: domain.OperationCloudUpload, RunID: "run-2",
+	}))
+	data, err = os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "pre cloud_upload run-2  /cfg/sync null", string(data))
+
+	// Events without a hook do nothing
+	require.NoError(t, os.Remove(out))
+	require.NoError(t, h.Run(context.Background(), Event{Stage: Pre, RunID: "run-3"}))
+	require.NoError(t, h.Run(context.Background(), Event{Stage: Post, Operation: domain.OperationCloudUpload}))
+	assert.NoFileExists(t, out)
+}
+
+func TestRunner_Run_Failures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	h := NewRunner(Commands{
+		Pre:  []string{"sh", "-c", "echo stopping sync; echo 'sync client not running' >&2; exit 3"},
+		Post: []string{"sleep", "10"},
+	}, WithTimeout(100*time.Millisecond))
+
+	err := h.Run(context.Background(), Event{Stage: Pre})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pre_backup hook failed: sync client not running")
+
+	err = h.Run(context.Background(), Event{Stage: Post})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "post_backup hook did not finish within 100ms")
+
+	h = NewRunner(Commands{Pre: []string{"ludusavi-runner-no-such-command"}})
+	assert.Error(t, h.Run(context.Background(), Event{Stage: Pre}))
+}
+
+func TestEvent_Name(t *testing.T) {
+	assert.Equal(t, "pre_backup", Event{Stage: Pre}.Name())
+	assert.Equal(t, "post_backup", Event{Stage: Post}.Name())
+	assert.Equal(t, "operations.archive.post", Event{Stage: Post, Operation: domain.OperationArchive}.Name())
+}
@@ -9,7 +9,13 @@ import (
 	"log/slog"
 	"math"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/tracing"
 )
 
 // RetryConfig configures retry behavior for the HTTP client.
@@ -38,6 +44,14 @@ type Client struct {
 	httpClient *http.Client
 	retry      RetryConfig
 	logger     *slog.Logger
+	userAgent  string
+
+	// requests numbers the requests made, for their IDs.
+	requests atomic.Uint64
+
+	// unix sends requests to unix:// URLs; see unix.go.
+	unixOnce sync.Once
+	unix     *http.Client
 }
 
 // ClientOption configures a Client.
@@ -57,6 +71,14 @@ func WithHTTPClient(client *http.Client) ClientOption {
 	}
 }
 
+// WithUserAgent sets the User-Agent header sent with requests in place of
+// DefaultUserAgent.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
 // WithLogger sets the logger.
 func WithLogger(logger *slog.Logger) ClientOption {
 	return func(c *Client) {
@@ -70,8 +92,9 @@ func NewClient(opts ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		retry:  DefaultRetryConfig(),
-		logger: slog.Default(),
+		retry:     DefaultRetryConfig(),
+		logger:    slog.Default(),
+		userAgent: DefaultUserAgent(),
 	}
 
 	for _, opt := range opts {
@@ -89,7 +112,46 @@ type Response struct {
 }
 
 // Do performs an HTTP request with retry logic.
-func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
+func (c *Client) Do(ctx context.Context, req *http.Request, opts ...RequestOption) (*Response, error) {
+	o := requestOptions{maxAttempts: c.retry.MaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	for key, values := range o.header {
+		req.Header[key] = values
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, c.requestID(ctx))
+	}
+	ctx = logging.WithAttrs(ctx, logging.KeyRequestID, req.Header.Get(RequestIDHeader))
+
+	ctx, span := tracing.Start(ctx, "HTTP "+req.Method, tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("url.full", redactURL(req.URL))
+
+	resp, attempts, err := c.do(ctx, req, o.maxAttempts)
+	span.SetAttribute("http.request.attempts", attempts)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	span.SetSuccess(resp.StatusCode < 400, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	return resp, nil
+}
+
+// do performs the request with up to maxAttempts attempts, returning the response and the number of attempts made.
+func (c *Client) do(ctx context.Context, req *http.Request, maxAttempts int) (*Response, int, error) {
+	log := logging.FromContext(ctx, c.logger)
 	var lastErr error
 	var bodyBytes []byte
 
@@ -98,12 +160,12 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 		var err error
 		bodyBytes, err = io.ReadAll(req.Body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read request body: %w", err)
+			return nil, 0, fmt.Errorf("failed to read request body: %w", err)
 		}
 		_ = req.Body.Close()
 	}
 
-	for attempt := 1; attempt <= c.retry.MaxAttempts; attempt++ {
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		// Reset body for each attempt
 		if bodyBytes != nil {
 			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -112,30 +174,30 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 		// Create a new request with context for each attempt
 		attemptReq := req.Clone(ctx)
 
-		c.logger.Debug("HTTP request attempt",
+		log.Debug("HTTP request attempt",
 			"method", req.Method,
 			"url", req.URL.String(),
 			"attempt", attempt,
-			"max_attempts", c.retry.MaxAttempts,
+			"max_attempts", maxAttempts,
 		)
 
-		resp, err := c.httpClient.Do(attemptReq)
+		resp, err := c.send(attemptReq)
 		if err != nil {
 			lastErr = err
-			c.logger.Warn("HTTP request failed",
+			log.Warn("HTTP request failed",
 				"method", req.Method,
 				"url", req.URL.String(),
 				"attempt", attempt,
 				"error", err,
 			)
 
-			if attempt < c.retry.MaxAttempts {
+			if attempt < maxAttempts {
 				delay := c.calculateDelay(attempt)
-				c.logger.Debug("Retrying after delay", "delay", delay)
+				log.Debug("Retrying after delay", "delay", delay)
 
 				select {
 				case <-ctx.Done():
-					return nil, ctx.Err()
+					return nil, attempt, ctx.Err()
 				case <-time.After(delay):
 					continue
 				}
@@ -151,9 +213,9 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 		}
 
 		// Check for retryable status codes
-		if c.shouldRetry(resp.StatusCode) && attempt < c.retry.MaxAttempts {
+		if c.shouldRetry(resp.StatusCode) && attempt < maxAttempts {
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
-			c.logger.Warn("HTTP request returned retryable status",
+			log.Warn("HTTP request returned retryable status",
 				"status", resp.StatusCode,
 				"attempt", attempt,
 			)
@@ -161,7 +223,7 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 			delay := c.calculateDelay(attempt)
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				return nil, attempt, ctx.Err()
 			case <-time.After(delay):
 				continue
 			}
@@ -171,29 +233,52 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 			StatusCode: resp.StatusCode,
 			Body:       body,
 			Headers:    resp.Header,
-		}, nil
+		}, attempt, nil
 	}
 
-	return nil, fmt.Errorf("request failed after %d attempts: %w", c.retry.MaxAttempts, lastErr)
+	return nil, maxAttempts, fmt.Errorf("request failed after %d attempts: %w", maxAttempts, lastErr)
 }
 
 // Get performs a GET request.
-func (c *Client) Get(ctx context.Context, url string) (*Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Get(ctx context.Context, url string, opts ...RequestOption) (*Response, error) {
+	return c.request(ctx, http.MethodGet, url, nil, opts)
+}
+
+// Head performs a HEAD request.
+func (c *Client) Head(ctx context.Context, url string, opts ...RequestOption) (*Response, error) {
+	return c.request(ctx, http.MethodHead, url, nil, opts)
 }
 
 // Post performs a POST request.
-func (c *Client) Post(ctx context.Context, url string, contentType string, body []byte) (*Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+func (c *Client) Post(ctx context.Context, url string, contentType string, body []byte, opts ...RequestOption) (*Response, error) {
+	return c.request(ctx, http.MethodPost, url, body, append([]RequestOption{WithRequestHeader("Content-Type", contentType)}, opts...))
+}
+
+// Delete performs a DELETE request.
+func (c *Client) Delete(ctx context.Context, url string, opts ...RequestOption) (*Response, error) {
+	return c.request(ctx, http.MethodDelete, url, nil, opts)
+}
+
+// request performs a request with method to url, sending body if not nil.
+func (c *Client) request(ctx context.Context, method, url string, body []byte, opts []RequestOption) (*Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", contentType)
-	return c.Do(ctx, req)
+	return c.Do(ctx, req, opts...)
+}
+
+// redactURL returns the URL without credentials or query parameters, which may contain secrets.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	return redacted.String()
 }
 
 // calculateDelay calculates the delay for a given attempt using exponential backoff.
@@ -222,22 +307,14 @@ func (c *Client) shouldRetry(statusCode int) bool {
 	}
 }
 
-// CheckConnectivity performs a simple connectivity check to the given URL.
-func (c *Client) CheckConnectivity(ctx context.Context, url string) error {
-	// Use a shorter timeout for connectivity checks
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
+// CheckConnectivity performs a simple connectivity check to the given URL,
+// a single attempt bounded by connectivityTimeout unless opts say otherwise.
+func (c *Client) CheckConnectivity(ctx context.Context, url string, opts ...RequestOption) error {
+	opts = append([]RequestOption{WithRequestTimeout(connectivityTimeout), WithMaxAttempts(1)}, opts...)
+	resp, err := c.Get(ctx, url, opts...)
 	if err != nil {
 		return fmt.Errorf("connectivity check failed: %w", err)
 	}
-	_ = resp.Body.Close()
 
 	// Accept any 2xx or common endpoint responses
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +45,101 @@ func TestClient_Post_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestClient_HeadDelete(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	resp, err := client.Head(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = client.Delete(context.Background(), server.URL+"/metrics/job/ludusavi")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	assert.Equal(t, []string{http.MethodHead, http.MethodDelete}, methods)
+}
+
+func TestClient_RequestOptions(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithRetryConfig(RetryConfig{
+		MaxAttempts:  3,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}))
+	auth := WithRequestHeader("Authorization", "Bearer token")
+
+	resp, err := client.Get(context.Background(), server.URL, auth, WithMaxAttempts(1))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	// The timeout covers the retries too
+	start := time.Now()
+	_, err = client.Get(context.Background(), server.URL+"/slow", auth, WithRequestTimeout(50*time.Millisecond))
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestClient_Headers(t *testing.T) {
+	var mu sync.Mutex
+	var userAgents, requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		if len(requestIDs) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithRetryConfig(RetryConfig{
+		MaxAttempts:  2,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}))
+
+	// Requests of a run carry its ID, the same for each retry
+	ctx := logging.WithAttrs(context.Background(), logging.KeyRunID, "run-1")
+	_, err := client.Get(ctx, server.URL)
+	require.NoError(t, err)
+	_, err = client.Post(ctx, server.URL, "text/plain", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run-1-1", "run-1-1", "run-1-2"}, requestIDs)
+	assert.Equal(t, DefaultUserAgent(), userAgents[0])
+	assert.Regexp(t, `^ludusavi-runner/\S+ \(\w+; \w+\)$`, userAgents[0])
+
+	// Other requests get a random ID, and headers set by the caller are kept
+	client = NewClient(WithUserAgent("agent/1.0"))
+	_, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, err = client.Get(context.Background(), server.URL, WithRequestHeader(RequestIDHeader, "mine"), WithRequestHeader("User-Agent", "curl"))
+	require.NoError(t, err)
+	assert.Len(t, requestIDs[3], 36)
+	assert.Equal(t, []string{"agent/1.0", "curl"}, userAgents[3:])
+	assert.Equal(t, "mine", requestIDs[4])
+}
+
 func TestClient_Retry_Success(t *testing.T) {
 	var attempts int32
 
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DialConfig configures how a client resolves and connects to servers.
+type DialConfig struct {
+	// Network is "tcp4" or "tcp6" to connect over IPv4 or IPv6 only, or
+	// "tcp" (the default) for either.
+	Network string
+
+	// DNSServers are the host:port of the DNS servers host names are
+	// resolved with, tried in order, instead of the system resolver.
+	DNSServers []string
+
+	// FallbackDelay is how long a connection over IPv6 is given before one
+	// over IPv4 is raced against it ("happy eyeballs"). Zero uses Go's
+	// default of 300ms, a negative delay disables the race.
+	FallbackDelay time.Duration
+}
+
+// Dialer connects to servers as configured by a DialConfig.
+type Dialer struct {
+	network string
+	dialer  *net.Dialer
+}
+
+// NewDialer creates a Dialer for cfg.
+func NewDialer(cfg DialConfig) *Dialer {
+	d := &Dialer{
+		network: cfg.Network,
+		dialer: &net.Dialer{
+			Timeout:       30 * time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: cfg.FallbackDelay,
+		},
+	}
+	if d.network == "" {
+		d.network = "tcp"
+	}
+	if len(cfg.DNSServers) > 0 {
+		d.dialer.Resolver = newResolver(cfg.DNSServers)
+	}
+	return d
+}
+
+// DialContext connects to addr. A "tcp" network is narrowed to the
+// configured IP version.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if network == "tcp" {
+		network = d.network
+	}
+	return d.dialer.DialContext(ctx, network, addr)
+}
+
+// LookupIP returns the addresses of host of the configured IP version, in
+// the order they are dialed.
+func (d *Dialer) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	resolver := d.dialer.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	network := "ip"
+	switch d.network {
+	case "tcp4":
+		network = "ip4"
+	case "tcp6":
+		network = "ip6"
+	}
+	return resolver.LookupIP(ctx, network, host)
+}
+
+// WithDialer makes the client connect to servers with d. It must come after
+// WithHTTPClient, if both are given.
+func WithDialer(d *Dialer) ClientOption {
+	return WithDialContext(d.DialContext)
+}
+
+// transport returns the client's transport, replacing the default one with
+// a copy that options can change.
+func transport(c *Client) *http.Transport {
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		return t
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	c.httpClient.Transport = t
+	return t
+}
+
+// newResolver returns a resolver querying the first of servers that
+// answers. A server that fails is passed over, for this and later queries,
+// until the others have failed too.
+func newResolver(servers []string) *net.Resolver {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	var current atomic.Int64
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var errs []error
+			for range servers {
+				i := current.Load()
+				next := func() { current.CompareAndSwap(i, (i+1)%int64(len(servers))) }
+				conn, err := dialer.DialContext(ctx, network, servers[i])
+				if err == nil {
+					if udp, ok := conn.(*net.UDPConn); ok {
+						return &resolverConn{UDPConn: udp, failed: next}, nil
+					}
+					return conn, nil
+				}
+				errs = append(errs, err)
+				next()
+			}
+			return nil, errors.Join(errs...)
+		},
+	}
+}
+
+// resolverConn is a UDP connection to a DNS server, calling failed once a
+// query over it fails, as unlike over TCP, dialing doesn't tell whether the
+// server is up.
+type resolverConn struct {
+	*net.UDPConn
+	failed func()
+}
+
+func (c *resolverConn) Read(b []byte) (int, error) {
+	n, err := c.UDPConn.Read(b)
+	if err != nil {
+		c.failed()
+	}
+	return n, err
+}
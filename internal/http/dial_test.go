@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveDNS answers A queries for any name with 127.0.0.1, and other queries
+// with no records, over UDP. It returns the server's address.
+func serveDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// The question ends in its type and class, after the name
+			end := 12
+			for end < n && buf[end] != 0 {
+				end += int(buf[end]) + 1
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+			qtype := binary.BigEndian.Uint16(buf[end-4:])
+
+			resp := append([]byte{}, buf[:end]...)
+			resp[2], resp[3] = 0x81, 0x80 // Response, recursion available
+			binary.BigEndian.PutUint16(resp[6:], 0)
+			binary.BigEndian.PutUint16(resp[8:], 0)
+			binary.BigEndian.PutUint16(resp[10:], 0)
+			if qtype == 1 {
+				binary.BigEndian.PutUint16(resp[6:], 1)
+				resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+			}
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestClient_Dialer(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	target := "http://pushgateway.test:" + u.Port()
+
+	// A DNS server that fails is passed over
+	dead, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddr := dead.LocalAddr().String()
+	require.NoError(t, dead.Close())
+
+	dialer := NewDialer(DialConfig{Network: "tcp4", DNSServers: []string{deadAddr, serveDNS(t)}})
+	ips, err := dialer.LookupIP(ctx, "pushgateway.test")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", ips[0].String())
+
+	client := NewClient(WithDialer(dialer), WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+	resp, err := client.Get(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, "pushgateway.test:"+u.Port(), string(resp.Body))
+
+	// Over IPv6 only, the IPv4 address isn't used
+	dialer = NewDialer(DialConfig{Network: "tcp6", DNSServers: []string{serveDNS(t)}})
+	_, err = dialer.LookupIP(ctx, "pushgateway.test")
+	assert.Error(t, err)
+	client = NewClient(WithDialer(dialer), WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+	_, err = client.Get(ctx, target)
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
+)
+
+// connectivityTimeout bounds CheckConnectivity by default.
+const connectivityTimeout = 10 * time.Second
+
+// RequestIDHeader is the header identifying each request, for servers to
+// log and match against the runner's logs.
+const RequestIDHeader = "X-Request-ID"
+
+// DefaultUserAgent returns the User-Agent requests are sent with, naming
+// the runner, its version and the OS it runs on, such as
+// "ludusavi-runner/1.4.0 (windows; amd64)".
+func DefaultUserAgent() string {
+	info := version.Get()
+	return fmt.Sprintf("ludusavi-runner/%s (%s; %s)", info.Version, info.OS, info.Arch)
+}
+
+// requestID returns the ID of a new request. Requests made for a run get
+// the run's ID followed by their number, so a server's logs can be matched
+// to the run; others get a random ID.
+func (c *Client) requestID(ctx context.Context) string {
+	if runID, ok := logging.Value(ctx, logging.KeyRunID); ok {
+		return fmt.Sprintf("%v-%d", runID, c.requests.Add(1))
+	}
+	return domain.NewRunID()
+}
+
+// RequestOption configures a single request, overriding the client's
+// settings for it.
+type RequestOption func(*requestOptions)
+
+// requestOptions are the settings of a single request.
+type requestOptions struct {
+	timeout     time.Duration
+	maxAttempts int
+	header      http.Header
+}
+
+// WithRequestTimeout bounds the request, retries included, by d rather than
+// only by the caller's context.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxAttempts makes up to n attempts at the request in place of the
+// client's RetryConfig.MaxAttempts; 1 disables retries.
+func WithMaxAttempts(n int) RequestOption {
+	return func(o *requestOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// WithRequestHeader sets the header key to value on the request.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Set(key, value)
+	}
+}
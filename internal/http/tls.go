@@ -0,0 +1,99 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSFiles are the PEM files of a client certificate and its key, presented
+// for mutual TLS, and of the CA certificates servers are verified with.
+// Each is optional, but the certificate and key go together.
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// ServerName, if set, is the name server certificates are verified
+	// against instead of the host name in the URL.
+	ServerName string
+}
+
+// NewTLSConfig returns the TLS configuration of files. The client
+// certificate is read again once its file changes, so a renewed certificate
+// is picked up without a restart.
+func NewTLSConfig(files TLSFiles) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: files.ServerName,
+	}
+
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("a client certificate needs both a certificate and a key file")
+	}
+	if files.CertFile != "" {
+		cert := &clientCert{certFile: files.CertFile, keyFile: files.KeyFile}
+		if _, err := cert.get(nil); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = cert.get
+	}
+
+	if files.CAFile != "" {
+		pem, err := os.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", files.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// WithTLSConfig makes the client use cfg for HTTPS connections. It must come
+// after WithHTTPClient, if both are given.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		transport(c).TLSClientConfig = cfg
+	}
+}
+
+// clientCert is a client certificate loaded from files, reloaded once the
+// certificate file changes.
+type clientCert struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+// get returns the current certificate, as tls.Config.GetClientCertificate.
+// A certificate that can't be reloaded is kept until it can.
+func (c *clientCert) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.certFile)
+	if err == nil && c.cert != nil && info.ModTime().Equal(c.modTime) {
+		return c.cert, nil
+	}
+	if err == nil {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(c.certFile, c.keyFile); err == nil {
+			c.cert, c.modTime = &cert, info.ModTime()
+			return c.cert, nil
+		}
+	}
+	if c.cert != nil {
+		return c.cert, nil
+	}
+	return nil, fmt.Errorf("failed to load client certificate: %w", err)
+}
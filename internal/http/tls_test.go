@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a certificate for name, signed by parent with parentKey
+// or self-signed if parent is nil, and its key as PEM files in dir.
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return cert, key
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "client", ca, caKey)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := filepath.Join(dir, "server-ca.pem")
+	require.NoError(t, os.WriteFile(serverCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	cfg, err := NewTLSConfig(TLSFiles{
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client.key"),
+		CAFile:   serverCA,
+	})
+	require.NoError(t, err)
+	client := NewClient(WithTLSConfig(cfg), WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "client", string(resp.Body))
+
+	// A renewed certificate is picked up on the next connection
+	writeCert(t, dir, "renewed", ca, caKey)
+	later := time.Now().Add(time.Minute)
+	for _, ext := range []string{".pem", ".key"} {
+		data, err := os.ReadFile(filepath.Join(dir, "renewed"+ext))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "client"+ext), data, 0600))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "client"+ext), later, later))
+	}
+	client = NewClient(WithTLSConfig(cfg), WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "renewed", string(resp.Body))
+
+	// Without a client certificate, the server refuses the connection
+	cfg, err = NewTLSConfig(TLSFiles{CAFile: serverCA})
+	require.NoError(t, err)
+	client = NewClient(WithTLSConfig(cfg), WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+	_, err = client.Get(context.Background(), server.URL)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewTLSConfig(TLSFiles{CertFile: filepath.Join(dir, "client.pem")})
+	assert.ErrorContains(t, err, "needs both a certificate and a key file")
+
+	_, err = NewTLSConfig(TLSFiles{CertFile: filepath.Join(dir, "client.pem"), KeyFile: filepath.Join(dir, "client.key")})
+	assert.ErrorContains(t, err, "failed to load client certificate")
+
+	_, err = NewTLSConfig(TLSFiles{CAFile: filepath.Join(dir, "ca.pem")})
+	assert.ErrorContains(t, err, "failed to read CA file")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("not PEM"), 0600))
+	_, err = NewTLSConfig(TLSFiles{CAFile: filepath.Join(dir, "ca.pem")})
+	assert.ErrorContains(t, err, "no certificates in CA file")
+}
@@ -0,0 +1,32 @@
+package http
+
+import "time"
+
+// TransportConfig configures how a client keeps connections to servers open
+// for reuse. Zero values have the meaning they have in net/http.Transport.
+type TransportConfig struct {
+	// MaxIdleConnsPerHost is how many idle connections are kept open to
+	// each server; zero keeps Go's default of 2.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open before it
+	// is closed; zero keeps it open until the server closes it.
+	IdleConnTimeout time.Duration
+
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+}
+
+// WithTransportConfig makes the client keep connections open as cfg says.
+// It must come after WithHTTPClient, if both are given.
+func WithTransportConfig(cfg TransportConfig) ClientOption {
+	return func(c *Client) {
+		t := transport(c)
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		if t.MaxIdleConns != 0 && t.MaxIdleConns < cfg.MaxIdleConnsPerHost {
+			t.MaxIdleConns = cfg.MaxIdleConnsPerHost
+		}
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+		t.DisableKeepAlives = cfg.DisableKeepAlives
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_TransportConfig(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	push := func(client *Client) {
+		t.Helper()
+		for range 3 {
+			_, err := client.Post(context.Background(), server.URL+"/metrics", "text/plain", []byte("up 1"))
+			require.NoError(t, err)
+		}
+	}
+
+	// Connections are reused
+	push(NewClient(WithTransportConfig(TransportConfig{MaxIdleConnsPerHost: 4, IdleConnTimeout: time.Minute})))
+	assert.EqualValues(t, 1, conns.Load())
+
+	conns.Store(0)
+	push(NewClient(WithTransportConfig(TransportConfig{DisableKeepAlives: true})))
+	assert.EqualValues(t, 3, conns.Load())
+
+	client := NewClient(WithTransportConfig(TransportConfig{MaxIdleConnsPerHost: 200, IdleConnTimeout: time.Minute}))
+	tr := client.httpClient.Transport.(*http.Transport)
+	assert.Equal(t, 200, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 200, tr.MaxIdleConns, "the total limit is raised to the one per server")
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// unixScheme is the scheme of URLs of servers listening on a Unix socket,
+// such as unix:///run/pushgateway.sock/metrics. The socket is the part of
+// the path that isn't an existing directory, the rest is the request path.
+const unixScheme = "unix"
+
+// DialFunc connects to addr over network, as net.Dialer.DialContext does.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialContext makes the client connect to servers with dial. It must
+// come after WithHTTPClient, if both are given.
+func WithDialContext(dial DialFunc) ClientOption {
+	return func(c *Client) {
+		transport(c).DialContext = dial
+	}
+}
+
+// send sends req, over the Unix socket its URL names if it is a unix:// one.
+// req is modified for that, so it must be a copy.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != unixScheme {
+		return c.httpClient.Do(req)
+	}
+
+	socket, path := splitUnixPath(req.URL.Path)
+	u := *req.URL
+	// The socket is carried in the host, so connections to different
+	// sockets aren't pooled together
+	u.Scheme, u.Host = "http", hex.EncodeToString([]byte(socket))
+	u.Path, u.RawPath = path, ""
+	req.URL = &u
+	req.Host = "localhost"
+	return c.unixClient().Do(req)
+}
+
+// unixClient returns the client sending requests over Unix sockets, created
+// on first use.
+func (c *Client) unixClient() *http.Client {
+	c.unixOnce.Do(func() {
+		t := http.DefaultTransport.(*http.Transport)
+		// Connections are kept open as to other servers
+		if own, ok := c.httpClient.Transport.(*http.Transport); ok {
+			t = own
+		}
+		t = t.Clone()
+		t.Proxy = nil
+		dialer := &net.Dialer{}
+		t.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			socket, err := hex.DecodeString(host)
+			if err != nil {
+				return nil, fmt.Errorf("invalid unix socket address %q: %w", host, err)
+			}
+			return dialer.DialContext(ctx, "unix", string(socket))
+		}
+		c.unix = &http.Client{
+			Transport:     t,
+			Timeout:       c.httpClient.Timeout,
+			CheckRedirect: c.httpClient.CheckRedirect,
+		}
+	})
+	return c.unix
+}
+
+// splitUnixPath splits the path of a unix:// URL into the socket, the
+// first prefix that isn't a directory, and the request path after it.
+func splitUnixPath(p string) (socket, path string) {
+	// unix:///C:/sockets/pushgateway.sock on Windows
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	for i := 1; i <= len(p); i++ {
+		if i < len(p) && p[i] != '/' {
+			continue
+		}
+		if info, err := os.Stat(filepath.FromSlash(p[:i])); err != nil || !info.IsDir() {
+			socket, path = p[:i], p[i:]
+			break
+		}
+	}
+	if socket == "" {
+		socket = p
+	}
+	if path == "" {
+		path = "/"
+	}
+	return filepath.FromSlash(socket), path
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "pushgateway.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.Host + " " + r.URL.RequestURI()))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	base := "unix:///" + strings.TrimPrefix(filepath.ToSlash(socket), "/")
+	client := NewClient(WithRetryConfig(RetryConfig{MaxAttempts: 1}))
+
+	resp, err := client.Post(context.Background(), base+"/metrics/job/ludusavi?x=1", "text/plain", []byte("up 1"))
+	require.NoError(t, err)
+	assert.Equal(t, "POST localhost /metrics/job/ludusavi?x=1", string(resp.Body))
+
+	resp, err = client.Get(context.Background(), base)
+	require.NoError(t, err)
+	assert.Equal(t, "GET localhost /", string(resp.Body))
+
+	require.NoError(t, client.CheckConnectivity(context.Background(), base+"/-/ready"))
+
+	// A socket that doesn't exist fails to connect
+	_, err = client.Get(context.Background(), base+".missing/-/ready")
+	assert.Error(t, err)
+}
+
+func TestClient_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Every connection goes to the test server, whatever the host
+	var dialed []string
+	client := NewClient(
+		WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		}),
+		WithRetryConfig(RetryConfig{MaxAttempts: 1}),
+	)
+
+	resp, err := client.Get(context.Background(), "http://apprise.test:8000/status")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.Equal(t, []string{"apprise.test:8000"}, dialed)
+}
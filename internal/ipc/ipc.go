@@ -0,0 +1,35 @@
+// Package ipc provides the control channel of a running service: a Unix
+// socket, or a named pipe on Windows, that other processes on the same
+// machine use to ask the scheduler to start a backup now. Unlike the
+// embedded HTTP server, it needs no port and isn't reachable from the
+// network.
+package ipc
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// baseURL is the URL requests over the control channel are made to. The
+// host is ignored, as the connection is dialed to the channel.
+const baseURL = "http://ludusavi-runner"
+
+// NewClient returns an HTTP client whose requests to URLs made with URL go
+// over the control channel at path.
+func NewClient(path string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return Dial(ctx, path)
+			},
+			// A request is rare enough that connections needn't be kept
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+// URL returns the URL of the request path p over the control channel.
+func URL(p string) string {
+	return baseURL + p
+}
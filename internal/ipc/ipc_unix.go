@@ -0,0 +1,54 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleProbeTimeout bounds the check for a service still listening on a
+// socket left behind.
+const staleProbeTimeout = time.Second
+
+// Listen listens on the Unix socket at path, which only the current user can
+// connect to. A socket left behind by a service that didn't stop cleanly is
+// replaced, but not one another service is still listening on.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, staleProbeTimeout); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("another service is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+	return listener, nil
+}
+
+// Dial connects to the Unix socket at path.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
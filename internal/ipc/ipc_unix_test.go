@@ -0,0 +1,73 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen(t *testing.T) {
+	// Short, as socket paths are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "ipc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "state", "control.sock")
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.Path))
+		}))
+	}()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	resp, err := NewClient(path).Post(URL("/run"), "application/json", nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "POST /run", string(body))
+
+	// A second service doesn't take over the socket
+	_, err = Listen(path)
+	assert.ErrorContains(t, err, "another service is listening")
+
+	require.NoError(t, listener.Close())
+	_, err = NewClient(path).Get(URL("/status"))
+	assert.Error(t, err)
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ipc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "control.sock")
+
+	// A socket left behind by a service that was killed
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	require.FileExists(t, path)
+
+	listener, err := Listen(path)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	// Anything else at the path is left alone
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	_, err = Listen(path)
+	assert.ErrorContains(t, err, "is not a socket")
+}
@@ -0,0 +1,203 @@
+//go:build windows
+
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeSDDL lets the service account, administrators and any signed-in
+// user, such as the one running the CLI, read and write the pipe.
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GRGW;;;AU)"
+
+// pipeBufferSize is the size of the pipe's buffers each way.
+const pipeBufferSize = 4096
+
+// busyRetryDelay is how long Dial waits before trying again while every
+// instance of the pipe is busy.
+const busyRetryDelay = 10 * time.Millisecond
+
+// Listen listens on the named pipe at path, such as
+// \\.\pipe\ludusavi-runner. Clients on other machines are rejected.
+func Listen(path string) (net.Listener, error) {
+	sd, err := windows.SecurityDescriptorFromString(pipeSDDL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control pipe security descriptor: %w", err)
+	}
+	l := &pipeListener{
+		path: path,
+		sa: &windows.SecurityAttributes{
+			Length:             uint32(unsafe.Sizeof(windows.SecurityAttributes{})),
+			SecurityDescriptor: sd,
+		},
+	}
+
+	// The first instance fails if another service has the pipe already
+	l.next, err = l.create(windows.FILE_FLAG_FIRST_PIPE_INSTANCE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Dial connects to the named pipe at path, waiting while every instance of
+// it is busy.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0, nil, windows.OPEN_EXISTING, windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+		if err == nil {
+			return newPipeConn(h, path, false), nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) {
+			return nil, &os.PathError{Op: "dial", Path: path, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(busyRetryDelay):
+		}
+	}
+}
+
+// pipeListener accepts connections to a named pipe, one instance of the
+// pipe per connection.
+type pipeListener struct {
+	path string
+	sa   *windows.SecurityAttributes
+
+	mu     sync.Mutex
+	next   windows.Handle // the instance waiting for the next client
+	closed bool
+}
+
+// create creates an instance of the pipe.
+func (l *pipeListener) create(flags uint32) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.path)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	return windows.CreateNamedPipe(name,
+		windows.PIPE_ACCESS_DUPLEX|flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, pipeBufferSize, pipeBufferSize, 0, l.sa)
+}
+
+// Accept waits for a client to connect to the pipe.
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	h := l.next
+	l.mu.Unlock()
+
+	err := windows.ConnectNamedPipe(h, nil)
+	if err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		// Woken up by Close
+		_ = windows.CloseHandle(h)
+		return nil, net.ErrClosed
+	}
+	// The next client gets an instance of its own
+	if l.next, err = l.create(0); err != nil {
+		l.closed = true
+		_ = windows.CloseHandle(h)
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+	return newPipeConn(h, l.path, true), nil
+}
+
+// Close stops listening. An Accept waiting for a client is woken up by
+// connecting to the pipe.
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if conn, err := Dial(ctx, l.path); err == nil {
+		_ = conn.Close()
+	}
+	return nil
+}
+
+// Addr returns the path of the pipe.
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// pipeConn is a connection over an instance of a named pipe.
+type pipeConn struct {
+	*os.File
+	handle windows.Handle
+	server bool
+}
+
+// newPipeConn returns a connection over the pipe instance h.
+func newPipeConn(h windows.Handle, path string, server bool) *pipeConn {
+	return &pipeConn{File: os.NewFile(uintptr(h), path), handle: h, server: server}
+}
+
+// Close closes the connection. On the server end, the client is
+// disconnected first, which fails a read still waiting for it.
+func (c *pipeConn) Close() error {
+	if c.server {
+		_ = windows.FlushFileBuffers(c.handle)
+		_ = windows.DisconnectNamedPipe(c.handle)
+	}
+	return c.File.Close()
+}
+
+// LocalAddr returns the path of the pipe.
+func (c *pipeConn) LocalAddr() net.Addr {
+	return pipeAddr(c.Name())
+}
+
+// RemoteAddr returns the path of the pipe.
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return pipeAddr(c.Name())
+}
+
+// SetDeadline does nothing: the pipe is opened for synchronous I/O, which
+// has no deadlines. Requests over it are bounded by their context instead.
+func (c *pipeConn) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline does nothing; see SetDeadline.
+func (c *pipeConn) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline does nothing; see SetDeadline.
+func (c *pipeConn) SetWriteDeadline(time.Time) error { return nil }
+
+// pipeAddr is the address of a named pipe, its path.
+type pipeAddr string
+
+// Network returns "pipe".
+func (a pipeAddr) Network() string { return "pipe" }
+
+// String returns the path of the pipe.
+func (a pipeAddr) String() string { return string(a) }
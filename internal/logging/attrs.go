@@ -0,0 +1,49 @@
+package logging
+
+import "log/slog"
+
+// Attribute keys set consistently across components, so logs can be
+// filtered on them with `logs --level` or in Loki.
+const (
+	// KeyComponent names the component that logged, e.g. "executor".
+	KeyComponent = "component"
+	// KeyOperation is the operation in progress, e.g. "backup".
+	KeyOperation = "operation"
+	// KeyRunID is the ID of the run in progress.
+	KeyRunID = "run_id"
+	// KeyGame is the title of the game a line is about.
+	KeyGame = "game"
+	// KeyGames are the titles of the games a run is limited to.
+	KeyGames = "games"
+	// KeyRequestID is the ID of an outbound HTTP request, as sent in its
+	// X-Request-ID header.
+	KeyRequestID = "request_id"
+)
+
+// Component names.
+const (
+	ComponentRunner        = "runner"
+	ComponentScheduler     = "scheduler"
+	ComponentExecutor      = "executor"
+	ComponentHTTP          = "http"
+	ComponentServer        = "server"
+	ComponentControl       = "control"
+	ComponentArchive       = "archive"
+	ComponentCustom        = "custom"
+	ComponentExtras        = "extras"
+	ComponentHooks         = "hooks"
+	ComponentMetrics       = "metrics"
+	ComponentNotify        = "notify"
+	ComponentWebhook       = "webhook"
+	ComponentHomeAssistant = "homeassistant"
+	ComponentTracing       = "tracing"
+	ComponentSnapshot      = "snapshot"
+	ComponentVSS           = "vss"
+	ComponentReport        = "report"
+	ComponentConfig        = "config"
+)
+
+// Component returns l with every line attributed to the component name.
+func Component(l *slog.Logger, name string) *slog.Logger {
+	return l.With(KeyComponent, name)
+}
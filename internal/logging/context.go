@@ -0,0 +1,55 @@
+// Package logging carries log attributes, such as the ID of the run in
+// progress, through a context to every component that logs on its behalf.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// attrsKey is the context key for log attributes.
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx carrying args, as key-value pairs or
+// slog.Attr values, in addition to any attributes ctx already carries.
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	prev, _ := ctx.Value(attrsKey{}).([]any)
+	return context.WithValue(ctx, attrsKey{}, append(slices.Clip(prev), args...))
+}
+
+// FromContext returns l with the attributes carried by ctx.
+func FromContext(ctx context.Context, l *slog.Logger) *slog.Logger {
+	if args, _ := ctx.Value(attrsKey{}).([]any); len(args) > 0 {
+		return l.With(args...)
+	}
+	return l
+}
+
+// Value returns the value of the attribute key carried by ctx, the one
+// added last if there are several.
+func Value(ctx context.Context, key string) (any, bool) {
+	args, _ := ctx.Value(attrsKey{}).([]any)
+	var value any
+	found := false
+	for len(args) > 0 {
+		switch arg := args[0].(type) {
+		case slog.Attr:
+			if arg.Key == key {
+				value, found = arg.Value.Any(), true
+			}
+			args = args[1:]
+		case string:
+			if len(args) < 2 {
+				return value, found
+			}
+			if arg == key {
+				value, found = args[1], true
+			}
+			args = args[2:]
+		default:
+			args = args[1:]
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil)).With("component", "runner")
+
+	FromContext(context.Background(), logger).Info("no attrs")
+	assert.Contains(t, buf.String(), `msg="no attrs" component=runner`)
+
+	buf.Reset()
+	ctx := WithAttrs(context.Background(), "run_id", "abc")
+	child := WithAttrs(ctx, "operation", "backup")
+	FromContext(child, logger).Info("child")
+	assert.Contains(t, buf.String(), "component=runner run_id=abc operation=backup")
+
+	// The parent context is unaffected by its children
+	buf.Reset()
+	_ = WithAttrs(ctx, "operation", "archive")
+	FromContext(ctx, logger).Info("parent")
+	assert.Contains(t, buf.String(), "run_id=abc\n")
+}
+
+func TestValue(t *testing.T) {
+	_, ok := Value(context.Background(), KeyRunID)
+	assert.False(t, ok)
+
+	ctx := WithAttrs(context.Background(), KeyRunID, "abc", slog.String(KeyOperation, "backup"))
+	value, ok := Value(ctx, KeyRunID)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", value)
+	value, _ = Value(ctx, KeyOperation)
+	assert.Equal(t, "backup", value)
+
+	// The attribute added last wins
+	value, _ = Value(WithAttrs(ctx, KeyRunID, "def"), KeyRunID)
+	assert.Equal(t, "def", value)
+	_, ok = Value(ctx, KeyGame)
+	assert.False(t, ok)
+}
+
+func TestComponent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := Component(slog.New(slog.NewTextHandler(&buf, nil)), ComponentExecutor)
+
+	ctx := WithAttrs(context.Background(), KeyRunID, "abc", KeyOperation, "backup")
+	FromContext(ctx, logger).Info("game changed", KeyGame, "Celeste")
+	assert.Contains(t, buf.String(), `component=executor run_id=abc operation=backup game=Celeste`)
+}
@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingHandler is a slog.Handler that limits repeated warnings and errors,
+// such as HTTP retries failing throughout an outage. Once a message has been
+// logged burst times within a window, further records with the same level
+// and message are dropped until the window ends, when a single "message
+// repeated N times" record summarizes them. The summary is logged along with
+// the next record after the window ends. Records below warn level always pass
+// through.
+type SamplingHandler struct {
+	next  slog.Handler
+	state *samplingState
+}
+
+// samplingState is shared by a SamplingHandler and the handlers derived from
+// it with WithAttrs and WithGroup, so repeats are counted across them.
+type samplingState struct {
+	mu      sync.Mutex
+	burst   int
+	window  time.Duration
+	now     func() time.Time
+	entries map[samplingKey]*samplingEntry
+}
+
+// samplingKey identifies repeats of a record.
+type samplingKey struct {
+	level slog.Level
+	msg   string
+}
+
+// samplingEntry counts the repeats of a record in the current window.
+type samplingEntry struct {
+	start      time.Time
+	count      int
+	suppressed int
+	// handler is the handler of the last suppressed record, which its
+	// summary is logged through so it keeps the record's attributes.
+	handler slog.Handler
+}
+
+// summary is a pending "message repeated N times" record.
+type summary struct {
+	handler slog.Handler
+	key     samplingKey
+	entry   samplingEntry
+	at      time.Time
+}
+
+// NewSamplingHandler returns a handler that passes at most burst warnings or
+// errors with the same message per window to next.
+func NewSamplingHandler(next slog.Handler, burst int, window time.Duration) *SamplingHandler {
+	return &SamplingHandler{
+		next: next,
+		state: &samplingState{
+			burst:   burst,
+			window:  window,
+			now:     time.Now,
+			entries: make(map[samplingKey]*samplingEntry),
+		},
+	}
+}
+
+// Enabled reports whether next handles records at level.
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes r to the next handler unless it repeats too often, after
+// logging summaries of records suppressed in windows that have ended.
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	pass, summaries := h.state.sample(h.next, r)
+	for _, s := range summaries {
+		if err := s.handler.Handle(ctx, s.record()); err != nil {
+			return err
+		}
+	}
+	if !pass {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler with attrs that shares the repeat counts of h.
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+// WithGroup returns a handler with a group that shares the repeat counts of h.
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// sample counts r and reports whether it should be logged, along with the
+// summaries of windows that have ended.
+func (s *samplingState) sample(handler slog.Handler, r slog.Record) (bool, []summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var summaries []summary
+	for key, e := range s.entries {
+		if now.Sub(e.start) < s.window {
+			continue
+		}
+		if e.suppressed > 0 {
+			summaries = append(summaries, summary{handler: e.handler, key: key, entry: *e, at: now})
+		}
+		delete(s.entries, key)
+	}
+
+	if r.Level < slog.LevelWarn {
+		return true, summaries
+	}
+
+	key := samplingKey{level: r.Level, msg: r.Message}
+	e, ok := s.entries[key]
+	if !ok {
+		s.entries[key] = &samplingEntry{start: now, count: 1}
+		return true, summaries
+	}
+
+	e.count++
+	if e.count <= s.burst {
+		return true, summaries
+	}
+	e.suppressed++
+	e.handler = handler
+	return false, summaries
+}
+
+// record returns the summary as a log record.
+func (s summary) record() slog.Record {
+	r := slog.NewRecord(s.at, s.key.level, fmt.Sprintf("message repeated %d times: %s", s.entry.suppressed, s.key.msg), 0)
+	r.AddAttrs(slog.Int("suppressed", s.entry.suppressed), slog.Time("since", s.entry.start))
+	return r
+}
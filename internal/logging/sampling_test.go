@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	handler := NewSamplingHandler(slog.NewTextHandler(&buf, nil), 2, time.Minute)
+	handler.state.now = func() time.Time { return now }
+	logger := slog.New(handler).With("component", "http")
+
+	for range 5 {
+		logger.Warn("request failed, retrying")
+		logger.Info("retrying")
+	}
+	logger.Error("request failed, retrying")
+
+	out := buf.String()
+	assert.Equal(t, 2, strings.Count(out, `level=WARN msg="request failed, retrying"`))
+	assert.Equal(t, 5, strings.Count(out, `msg=retrying`))
+	// The same message at another level is counted separately
+	assert.Equal(t, 1, strings.Count(out, `level=ERROR msg="request failed, retrying"`))
+	assert.NotContains(t, out, "message repeated")
+
+	// The suppressed records are summarized with the next record after the
+	// window ends, keeping their attributes
+	buf.Reset()
+	now = now.Add(time.Minute)
+	logger.Info("backup completed")
+	out = buf.String()
+	assert.Contains(t, out, `level=WARN msg="message repeated 3 times: request failed, retrying" component=http suppressed=3`)
+	assert.Contains(t, out, `msg="backup completed"`)
+
+	// A new window starts with a fresh burst
+	buf.Reset()
+	logger.Warn("request failed, retrying")
+	assert.Contains(t, buf.String(), `msg="request failed, retrying"`)
+}
+
+func TestSamplingHandler_Enabled(t *testing.T) {
+	handler := NewSamplingHandler(slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn}), 1, time.Minute)
+
+	assert.False(t, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelError))
+}
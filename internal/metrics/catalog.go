@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultPrefix is the prefix of the metric names below. Metrics can be
+// pushed with another prefix instead; see Rename.
+const DefaultPrefix = "ludusavi_"
+
+// DefaultJobName is the job label metrics are pushed under.
+const DefaultJobName = "ludusavi"
+
+// Metric names pushed by the runner. Dashboards and alert rules are
+// generated from these, so they stay in sync with what is pushed.
+const (
+	MetricUp                 = "ludusavi_runner_up"
+	MetricPaused             = "ludusavi_runner_paused"
+	MetricInfo               = "ludusavi_runner_info"
+	MetricPanics             = "ludusavi_runner_panics_total"
+	MetricWatchdogRecoveries = "ludusavi_runner_watchdog_recoveries_total"
+	MetricPushErrors         = "ludusavi_runner_push_errors_total"
+	MetricPushes             = "ludusavi_runner_pushes_total"
+	MetricNotifications      = "ludusavi_runner_notifications_total"
+	MetricFailures           = "ludusavi_runner_failures_total"
+	MetricProcessCPU         = "ludusavi_runner_process_cpu_seconds_total"
+	MetricProcessMemory      = "ludusavi_runner_process_resident_memory_bytes"
+	MetricProcessOpenFDs     = "ludusavi_runner_process_open_fds"
+	MetricLastRunInfo        = "ludusavi_last_run_info"
+	MetricLastRunOutcome     = "ludusavi_last_run_outcome"
+	MetricLastRunTimestamp   = "ludusavi_last_run_timestamp_seconds"
+	MetricLastRunSuccess     = "ludusavi_last_run_success"
+	MetricLastRunDuration    = "ludusavi_last_run_duration_seconds"
+	MetricGamesTotal         = "ludusavi_games_total"
+	MetricGamesProcessed     = "ludusavi_games_processed"
+	MetricBytesTotal         = "ludusavi_bytes_total"
+	MetricBytesProcessed     = "ludusavi_bytes_processed"
+	MetricGamesNew           = "ludusavi_games_new"
+	MetricGamesChanged       = "ludusavi_games_changed"
+	MetricLastRunCPU         = "ludusavi_last_run_cpu_seconds"
+	MetricLastRunPeakMemory  = "ludusavi_last_run_peak_memory_bytes"
+	MetricGamesAverage       = "ludusavi_games_average"
+	MetricGameCountRegressed = "ludusavi_game_count_regression"
+)
+
+// Labels set on metrics besides the job and instance labels added by the
+// Pushgateway.
+const (
+	LabelOperation   = "operation"
+	LabelDestination = "destination"
+	LabelReason      = "reason"
+	LabelRunID       = "run_id"
+	LabelTarget      = "target"
+	LabelOutcome     = "outcome"
+	// LabelCode is the error code of a failure, such as "LR2003".
+	LabelCode = "code"
+	// LabelMaintenance is set to "true" on every metric while maintenance
+	// mode is on.
+	LabelMaintenance = "maintenance"
+)
+
+// Values of the outcome label of delivery counters. The outcome label of
+// MetricLastRunOutcome is a domain.RunOutcome instead.
+const (
+	OutcomeSent   = "sent"
+	OutcomeFailed = "failed"
+)
+
+// Metric types.
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+// Definition describes a metric pushed by the runner.
+type Definition struct {
+	Name   string
+	Type   string
+	Help   string
+	Labels []string
+}
+
+// Definitions lists every metric pushed by the runner.
+var Definitions = []Definition{
+	{MetricUp, TypeGauge, "Service is running", nil},
+	{MetricPaused, TypeGauge, "Scheduled backups are paused", nil},
+	{MetricInfo, TypeGauge, "Build information", []string{"version", "go_version"}},
+	{MetricPanics, TypeCounter, "Panics recovered from backup runs since the service started", nil},
+	{MetricWatchdogRecoveries, TypeCounter, "Stalled or overdue runs recovered by the watchdog", []string{LabelReason}},
+	{MetricPushErrors, TypeCounter, "Metrics pushes rejected by the backend since the service started", []string{LabelReason}},
+	{MetricPushes, TypeCounter, "Metrics pushes sent and failed before this one since the service started", []string{LabelOutcome}},
+	{MetricNotifications, TypeCounter, "Notifications sent and failed since the service started", []string{LabelTarget, LabelOutcome}},
+	{MetricFailures, TypeCounter, "Failures of runs since the service started, by error code", []string{LabelCode}},
+	{MetricProcessCPU, TypeCounter, "CPU time used by the runner process", nil},
+	{MetricProcessMemory, TypeGauge, "Resident memory of the runner process", nil},
+	{MetricProcessOpenFDs, TypeGauge, "Open file descriptors, or handles on Windows, of the runner process", nil},
+	{MetricLastRunInfo, TypeGauge, "ID of the run the pushed results are from", []string{LabelRunID}},
+	{MetricLastRunOutcome, TypeGauge, "Outcome of the last run: 1 for success, partial or failure, 0 for the others", []string{LabelOutcome}},
+	{MetricLastRunTimestamp, TypeGauge, "Unix timestamp of last run", resultLabels},
+	{MetricLastRunSuccess, TypeGauge, "Whether the last run succeeded", resultLabels},
+	{MetricLastRunDuration, TypeGauge, "Duration of last run", resultLabels},
+	{MetricGamesTotal, TypeGauge, "Total games detected", resultLabels},
+	{MetricGamesProcessed, TypeGauge, "Games processed in last run", resultLabels},
+	{MetricBytesTotal, TypeGauge, "Total bytes across all saves", resultLabels},
+	{MetricBytesProcessed, TypeGauge, "Bytes processed in last run", resultLabels},
+	{MetricGamesNew, TypeGauge, "New games backed up", resultLabels},
+	{MetricGamesChanged, TypeGauge, "Games with changes", resultLabels},
+	{MetricLastRunCPU, TypeGauge, "CPU time used by ludusavi in last run", resultLabels},
+	{MetricLastRunPeakMemory, TypeGauge, "Peak resident memory of ludusavi in last run", resultLabels},
+	{MetricGamesAverage, TypeGauge, "Rolling average of the games found by full backups", nil},
+	{MetricGameCountRegressed, TypeGauge, "Whether the last full backup found far fewer games than the rolling average", nil},
+}
+
+// resultLabels are the labels of per-operation result metrics. The
+// destination label is only set for additional backup destinations.
+var resultLabels = []string{LabelOperation, LabelDestination}
+
+// Lookup returns the definition of the metric called name.
+func Lookup(name string) (Definition, bool) {
+	for _, d := range Definitions {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Rename returns the metric called name with prefix in place of
+// DefaultPrefix.
+func Rename(name, prefix string) string {
+	return prefix + strings.TrimPrefix(name, DefaultPrefix)
+}
+
+// metricName matches what may be a metric name in a PromQL query.
+var metricName = regexp.MustCompile(`\b` + DefaultPrefix + `[a-zA-Z0-9_:]+`)
+
+// RenameQuery returns query, such as a PromQL expression, with the metrics
+// in it renamed to prefix in place of DefaultPrefix.
+func RenameQuery(query, prefix string) string {
+	return metricName.ReplaceAllStringFunc(query, func(name string) string {
+		if _, ok := Lookup(name); !ok {
+			return name
+		}
+		return Rename(name, prefix)
+	})
+}
@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameQuery(t *testing.T) {
+	assert.Equal(t, "saves_runner_up", Rename(MetricUp, "saves_"))
+	assert.Equal(t,
+		`max_over_time(saves_last_run_success{destination=""}[1h]) == 0 and ludusavi_other`,
+		RenameQuery(`max_over_time(ludusavi_last_run_success{destination=""}[1h]) == 0 and ludusavi_other`, "saves_"))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	nethttp "net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Exporter keeps the metrics of the latest run for Prometheus to scrape from
+// its /metrics endpoint, so no Pushgateway is needed. Like a push to the
+// Pushgateway, each run replaces the metrics of the one before; the job and
+// instance labels come from the scrape configuration.
+type Exporter struct {
+	prefix string
+
+	mu      sync.Mutex
+	metrics *domain.Metrics
+}
+
+// ExporterOption configures an Exporter.
+type ExporterOption func(*Exporter)
+
+// WithExporterPrefix sets the prefix metric names are exported with in place
+// of DefaultPrefix.
+func WithExporterPrefix(prefix string) ExporterOption {
+	return func(e *Exporter) {
+		e.prefix = prefix
+	}
+}
+
+// NewExporter creates a new Exporter.
+func NewExporter(opts ...ExporterOption) *Exporter {
+	e := &Exporter{prefix: DefaultPrefix}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Push keeps metrics to be scraped, replacing the previous ones.
+func (e *Exporter) Push(ctx context.Context, metrics *domain.Metrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = metrics
+	return nil
+}
+
+// Validate does nothing: there is nothing to reach until Prometheus scrapes.
+func (e *Exporter) Validate(ctx context.Context) error {
+	return nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format, or nothing
+// before the first run. Samples carry no timestamp, so they take the time
+// of the scrape, as pushed ones do on the Pushgateway.
+func (e *Exporter) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	e.mu.Lock()
+	metrics := e.metrics
+	e.mu.Unlock()
+
+	var b strings.Builder
+	if metrics != nil {
+		writeText(&b, families(metrics), e.prefix, time.Time{})
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// Ensure Exporter implements domain.MetricsPusher.
+var _ domain.MetricsPusher = (*Exporter)(nil)
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporter(t *testing.T) {
+	exporter := NewExporter(WithExporterPrefix("saves_"))
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+		return rec.Body.String()
+	}
+
+	// Nothing to scrape before the first run
+	assert.Empty(t, scrape())
+	require.NoError(t, exporter.Validate(context.Background()))
+
+	metrics := domain.NewMetrics("test-host")
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Stats = domain.BackupStats{TotalGames: 10, ProcessedGames: 2}
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+	require.NoError(t, exporter.Push(context.Background(), metrics))
+
+	body := scrape()
+	assert.Contains(t, body, "saves_runner_up 1\n")
+	assert.Contains(t, body, `saves_games_total{operation="backup"} 10`)
+	assert.NotContains(t, body, "job=")
+
+	// The next run replaces the metrics
+	metrics = domain.NewMetrics("test-host")
+	require.NoError(t, exporter.Push(context.Background(), metrics))
+	body = scrape()
+	assert.Contains(t, body, "saves_runner_up 1\n")
+	assert.NotContains(t, body, "saves_games_total")
+}
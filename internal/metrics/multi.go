@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MultiPusher pushes metrics to multiple pushers.
+type MultiPusher struct {
+	pushers []domain.MetricsPusher
+}
+
+// NewMultiPusher creates a new MultiPusher.
+func NewMultiPusher(pushers ...domain.MetricsPusher) *MultiPusher {
+	return &MultiPusher{pushers: pushers}
+}
+
+// Push sends metrics to all configured pushers.
+// Returns an error if any pusher fails, but attempts all pushers.
+func (m *MultiPusher) Push(ctx context.Context, metrics *domain.Metrics) error {
+	var errs []error
+	for _, pusher := range m.pushers {
+		if err := pusher.Push(ctx, metrics); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate validates all configured pushers.
+func (m *MultiPusher) Validate(ctx context.Context) error {
+	var errs []error
+	for _, pusher := range m.pushers {
+		if err := pusher.Validate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ensure MultiPusher implements domain.MetricsPusher.
+var _ domain.MetricsPusher = (*MultiPusher)(nil)
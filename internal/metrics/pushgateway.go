@@ -5,22 +5,22 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"runtime"
+	neturl "net/url"
 	"strings"
+	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 	"github.com/sharkusmanch/ludusavi-runner/internal/http"
-	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 )
 
-const (
-	metricsJobName = "ludusavi"
-	contentType    = "text/plain; charset=utf-8"
-)
+const contentType = "text/plain; charset=utf-8"
 
 // PushgatewayClient pushes metrics to a Prometheus Pushgateway.
 type PushgatewayClient struct {
 	url        string
+	prefix     string
+	jobName    string
 	httpClient *http.Client
 	logger     *slog.Logger
 }
@@ -42,10 +42,27 @@ func WithLogger(logger *slog.Logger) PushgatewayOption {
 	}
 }
 
+// WithPrefix sets the prefix metric names are pushed with in place of
+// DefaultPrefix.
+func WithPrefix(prefix string) PushgatewayOption {
+	return func(p *PushgatewayClient) {
+		p.prefix = prefix
+	}
+}
+
+// WithJobName sets the job label metrics are pushed under.
+func WithJobName(name string) PushgatewayOption {
+	return func(p *PushgatewayClient) {
+		p.jobName = name
+	}
+}
+
 // NewPushgatewayClient creates a new PushgatewayClient.
 func NewPushgatewayClient(url string, opts ...PushgatewayOption) *PushgatewayClient {
 	p := &PushgatewayClient{
 		url:        strings.TrimSuffix(url, "/"),
+		prefix:     DefaultPrefix,
+		jobName:    DefaultJobName,
 		httpClient: http.NewClient(),
 		logger:     slog.Default(),
 	}
@@ -59,11 +76,12 @@ func NewPushgatewayClient(url string, opts ...PushgatewayOption) *PushgatewayCli
 
 // Push sends metrics to the Pushgateway.
 func (p *PushgatewayClient) Push(ctx context.Context, metrics *domain.Metrics) error {
+	log := logging.FromContext(ctx, p.logger)
 	body := p.buildMetrics(metrics)
 
-	pushURL := fmt.Sprintf("%s/metrics/job/%s/instance/%s", p.url, metricsJobName, metrics.Hostname)
+	pushURL := fmt.Sprintf("%s/metrics/job/%s/instance/%s", p.url, neturl.PathEscape(p.jobName), metrics.Hostname)
 
-	p.logger.Debug("pushing metrics to pushgateway",
+	log.Debug("pushing metrics to pushgateway",
 		"url", pushURL,
 		"metrics_count", len(metrics.Results),
 	)
@@ -74,13 +92,65 @@ func (p *PushgatewayClient) Push(ctx context.Context, metrics *domain.Metrics) e
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("pushgateway returned status %d: %s", resp.StatusCode, string(resp.Body))
+		return pushError(resp.StatusCode, string(resp.Body), pushURL)
 	}
 
-	p.logger.Debug("metrics pushed successfully")
+	log.Debug("metrics pushed successfully")
 	return nil
 }
 
+// Reasons the Pushgateway rejects a push for, as counted by the push
+// errors metric.
+const (
+	PushErrorInvalid       = "invalid"
+	PushErrorInconsistent  = "inconsistent"
+	PushErrorLabelConflict = "label_conflict"
+	PushErrorNotFound      = "not_found"
+	PushErrorUnauthorized  = "unauthorized"
+	PushErrorTooLarge      = "too_large"
+	PushErrorUnavailable   = "unavailable"
+	PushErrorRejected      = "rejected"
+)
+
+// pushError returns the error for a push to pushURL the Pushgateway
+// answered with statusCode and body, saying what to do about it where the
+// cause is known.
+func pushError(statusCode int, body, pushURL string) *domain.PushError {
+	body = strings.TrimSpace(body)
+	err := &domain.PushError{StatusCode: statusCode}
+	switch {
+	case statusCode == 400 && strings.Contains(body, "grouping label"):
+		err.Reason = PushErrorLabelConflict
+		err.Message = fmt.Sprintf("pushgateway rejected the metrics: a metric has a label that is also in the push URL, such as job or instance (%s)", body)
+	case statusCode == 400 && strings.Contains(body, "inconsistent with existing metrics"):
+		err.Reason = PushErrorInconsistent
+		err.Message = fmt.Sprintf("pushgateway rejected the metrics as inconsistent with those it already has for this group, "+
+			"such as ones pushed by another version or with another type or help text; "+
+			"delete the group in the Pushgateway's web UI or with curl -X DELETE %s (%s)", pushURL, body)
+	case statusCode == 400:
+		err.Reason = PushErrorInvalid
+		err.Message = fmt.Sprintf("pushgateway rejected the metrics as malformed; a proxy in front of it may have altered the request (%s)", body)
+	case statusCode == 404 || statusCode == 405 || statusCode == 410:
+		err.Reason = PushErrorNotFound
+		err.Message = fmt.Sprintf("pushgateway returned status %d for %s: check metrics.url points at the Pushgateway, "+
+			"including any path it is served under with --web.route-prefix", statusCode, pushURL)
+	case statusCode == 401 || statusCode == 403:
+		err.Reason = PushErrorUnauthorized
+		err.Message = fmt.Sprintf("pushgateway returned status %d: the Pushgateway or a proxy in front of it requires credentials "+
+			"or a TLS client certificate the runner didn't present or that were refused", statusCode)
+	case statusCode == 413:
+		err.Reason = PushErrorTooLarge
+		err.Message = "pushgateway returned status 413: the metrics are larger than the Pushgateway or a proxy in front of it accepts; raise its request size limit"
+	case statusCode >= 500:
+		err.Reason = PushErrorUnavailable
+		err.Message = fmt.Sprintf("pushgateway returned status %d, after retries: it may be restarting or out of disk space (%s)", statusCode, body)
+	default:
+		err.Reason = PushErrorRejected
+		err.Message = fmt.Sprintf("pushgateway returned status %d: %s", statusCode, body)
+	}
+	return err
+}
+
 // Validate checks if the Pushgateway is reachable.
 func (p *PushgatewayClient) Validate(ctx context.Context) error {
 	// Pushgateway typically has a /-/ready endpoint
@@ -99,75 +169,9 @@ func (p *PushgatewayClient) Validate(ctx context.Context) error {
 // buildMetrics constructs the Prometheus text format metrics.
 func (p *PushgatewayClient) buildMetrics(m *domain.Metrics) string {
 	var b strings.Builder
-
-	// Service up metric
-	b.WriteString("# HELP ludusavi_runner_up Service is running\n")
-	b.WriteString("# TYPE ludusavi_runner_up gauge\n")
-	if m.ServiceUp {
-		b.WriteString("ludusavi_runner_up 1\n")
-	} else {
-		b.WriteString("ludusavi_runner_up 0\n")
-	}
-	b.WriteString("\n")
-
-	// Info metric
-	versionInfo := version.Get()
-	b.WriteString("# HELP ludusavi_runner_info Build information\n")
-	b.WriteString("# TYPE ludusavi_runner_info gauge\n")
-	b.WriteString(fmt.Sprintf("ludusavi_runner_info{version=%q,go_version=%q} 1\n",
-		versionInfo.Version, runtime.Version()))
-	b.WriteString("\n")
-
-	// Write HELP/TYPE declarations once for result metrics
-	if len(m.Results) > 0 {
-		b.WriteString("# HELP ludusavi_last_run_timestamp_seconds Unix timestamp of last run\n")
-		b.WriteString("# TYPE ludusavi_last_run_timestamp_seconds gauge\n")
-		b.WriteString("# HELP ludusavi_last_run_success Whether the last run succeeded\n")
-		b.WriteString("# TYPE ludusavi_last_run_success gauge\n")
-		b.WriteString("# HELP ludusavi_last_run_duration_seconds Duration of last run\n")
-		b.WriteString("# TYPE ludusavi_last_run_duration_seconds gauge\n")
-		b.WriteString("# HELP ludusavi_games_total Total games detected\n")
-		b.WriteString("# TYPE ludusavi_games_total gauge\n")
-		b.WriteString("# HELP ludusavi_games_processed Games processed in last run\n")
-		b.WriteString("# TYPE ludusavi_games_processed gauge\n")
-		b.WriteString("# HELP ludusavi_bytes_total Total bytes across all saves\n")
-		b.WriteString("# TYPE ludusavi_bytes_total gauge\n")
-		b.WriteString("# HELP ludusavi_bytes_processed Bytes processed in last run\n")
-		b.WriteString("# TYPE ludusavi_bytes_processed gauge\n")
-		b.WriteString("# HELP ludusavi_games_new New games backed up\n")
-		b.WriteString("# TYPE ludusavi_games_new gauge\n")
-		b.WriteString("# HELP ludusavi_games_changed Games with changes\n")
-		b.WriteString("# TYPE ludusavi_games_changed gauge\n")
-		b.WriteString("\n")
-
-		// Write metric values for each result
-		for _, result := range m.Results {
-			p.writeResultMetrics(&b, result)
-		}
-	}
-
+	writeText(&b, families(m), p.prefix, time.Time{})
 	return b.String()
 }
 
-// writeResultMetrics writes metric values for a single backup result.
-func (p *PushgatewayClient) writeResultMetrics(b *strings.Builder, r *domain.BackupResult) {
-	op := r.Operation.String()
-
-	success := 0
-	if r.Success {
-		success = 1
-	}
-
-	b.WriteString(fmt.Sprintf("ludusavi_last_run_timestamp_seconds{operation=%q} %d\n", op, r.EndTime.Unix()))
-	b.WriteString(fmt.Sprintf("ludusavi_last_run_success{operation=%q} %d\n", op, success))
-	b.WriteString(fmt.Sprintf("ludusavi_last_run_duration_seconds{operation=%q} %.3f\n", op, r.Duration.Seconds()))
-	b.WriteString(fmt.Sprintf("ludusavi_games_total{operation=%q} %d\n", op, r.Stats.TotalGames))
-	b.WriteString(fmt.Sprintf("ludusavi_games_processed{operation=%q} %d\n", op, r.Stats.ProcessedGames))
-	b.WriteString(fmt.Sprintf("ludusavi_bytes_total{operation=%q} %d\n", op, r.Stats.TotalBytes))
-	b.WriteString(fmt.Sprintf("ludusavi_bytes_processed{operation=%q} %d\n", op, r.Stats.ProcessedBytes))
-	b.WriteString(fmt.Sprintf("ludusavi_games_new{operation=%q} %d\n", op, r.Stats.NewGames))
-	b.WriteString(fmt.Sprintf("ludusavi_games_changed{operation=%q} %d\n", op, r.Stats.ChangedGames))
-}
-
 // Ensure PushgatewayClient implements domain.MetricsPusher.
 var _ domain.MetricsPusher = (*PushgatewayClient)(nil)
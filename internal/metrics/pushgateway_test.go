@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	ihttp "github.com/sharkusmanch/ludusavi-runner/internal/http"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +53,36 @@ func TestPushgatewayClient_Push_Success(t *testing.T) {
 	assert.Contains(t, receivedBody, `operation="backup"`)
 }
 
+func TestPushgatewayClient_Push_PrefixAndJob(t *testing.T) {
+	var receivedBody string
+	var receivedPath string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		receivedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewPushgatewayClient(server.URL, WithPrefix("homelab_saves_"), WithJobName("game-saves"))
+
+	metrics := domain.NewMetrics("test-host")
+	metrics.ServiceUp = true
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+
+	err := client.Push(context.Background(), metrics)
+
+	require.NoError(t, err)
+	assert.Equal(t, "/metrics/job/game-saves/instance/test-host", receivedPath)
+	assert.Contains(t, receivedBody, "# TYPE homelab_saves_runner_up gauge\nhomelab_saves_runner_up 1\n")
+	assert.Contains(t, receivedBody, `homelab_saves_games_total{operation="backup"} 0`)
+	assert.NotContains(t, receivedBody, "ludusavi_")
+}
+
 func TestPushgatewayClient_Push_Failure(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -68,6 +99,48 @@ func TestPushgatewayClient_Push_Failure(t *testing.T) {
 	assert.Contains(t, err.Error(), "500")
 }
 
+func TestPushgatewayClient_Push_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		reason   string
+		contains string
+	}{
+		{"inconsistent", http.StatusBadRequest,
+			"pushed metrics are invalid or inconsistent with existing metrics: collected metric ludusavi_games_total has help \"x\" but should have \"y\"\n",
+			PushErrorInconsistent, "curl -X DELETE"},
+		{"grouping label", http.StatusBadRequest,
+			"pushed metrics are invalid or inconsistent with existing metrics: pushed metric ludusavi_runner_up already contains grouping label instance",
+			PushErrorLabelConflict, "such as job or instance"},
+		{"parse error", http.StatusBadRequest, "text format parsing error in line 3", PushErrorInvalid, "line 3"},
+		{"deleted group", http.StatusGone, "", PushErrorNotFound, "--web.route-prefix"},
+		{"wrong path", http.StatusNotFound, "404 page not found", PushErrorNotFound, "/metrics/job/ludusavi/instance/test-host"},
+		{"forbidden", http.StatusForbidden, "", PushErrorUnauthorized, "credentials"},
+		{"too large", http.StatusRequestEntityTooLarge, "", PushErrorTooLarge, "request size limit"},
+		{"server error", http.StatusInternalServerError, "disk full", PushErrorUnavailable, "disk full"},
+		{"other", http.StatusTeapot, "short and stout", PushErrorRejected, "status 418: short and stout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewPushgatewayClient(server.URL, WithHTTPClient(ihttp.NewClient(ihttp.WithRetryConfig(ihttp.RetryConfig{MaxAttempts: 1}))))
+			err := client.Push(context.Background(), domain.NewMetrics("test-host"))
+
+			var pushErr *domain.PushError
+			require.ErrorAs(t, err, &pushErr)
+			assert.Equal(t, tt.reason, pushErr.Reason)
+			assert.Equal(t, tt.status, pushErr.StatusCode)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
 func TestPushgatewayClient_Validate_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -137,6 +210,12 @@ func TestPushgatewayClient_BuildMetrics(t *testing.T) {
 	assert.Contains(t, body, "ludusavi_last_run_duration_seconds")
 	assert.Contains(t, body, "ludusavi_games_total")
 	assert.Contains(t, body, "ludusavi_bytes_total")
+	assert.NotContains(t, body, "ludusavi_game_count_regression")
+
+	metrics.GameCount = &domain.GameCountStats{Average: 175.5, Regressed: true}
+	body = client.buildMetrics(metrics)
+	assert.Contains(t, body, "ludusavi_games_average 175.5\n")
+	assert.Contains(t, body, "ludusavi_game_count_regression 1\n")
 
 	// Verify valid Prometheus format (no syntax errors)
 	lines := strings.Split(body, "\n")
@@ -161,3 +240,157 @@ func TestPushgatewayClient_BuildMetrics_ServiceDown(t *testing.T) {
 
 	assert.Contains(t, body, "ludusavi_runner_up 0")
 }
+
+func TestPushgatewayClient_BuildMetrics_Outcome(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_last_run_outcome")
+
+	metrics.Outcome = domain.OutcomePartial
+	assert.Contains(t, client.buildMetrics(metrics), `ludusavi_last_run_outcome{outcome="success"} 0`+"\n"+
+		`ludusavi_last_run_outcome{outcome="partial"} 1`+"\n"+
+		`ludusavi_last_run_outcome{outcome="failure"} 0`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Paused(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.Contains(t, client.buildMetrics(metrics), "ludusavi_runner_paused 0")
+
+	metrics.Paused = true
+	assert.Contains(t, client.buildMetrics(metrics), "ludusavi_runner_paused 1")
+}
+
+func TestPushgatewayClient_BuildMetrics_Panics(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.Contains(t, client.buildMetrics(metrics), "ludusavi_runner_panics_total 0")
+
+	metrics.Panics = 3
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "# TYPE ludusavi_runner_panics_total counter")
+	assert.Contains(t, body, "ludusavi_runner_panics_total 3")
+}
+
+func TestPushgatewayClient_BuildMetrics_WatchdogRecoveries(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_runner_watchdog_recoveries_total")
+
+	metrics.WatchdogRecoveries = map[string]int64{"run_deadline": 2, "loop_stall": 1}
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "# TYPE ludusavi_runner_watchdog_recoveries_total counter")
+	assert.Contains(t, body, `ludusavi_runner_watchdog_recoveries_total{reason="loop_stall"} 1`+"\n"+
+		`ludusavi_runner_watchdog_recoveries_total{reason="run_deadline"} 2`)
+}
+
+func TestPushgatewayClient_BuildMetrics_PushErrors(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_runner_push_errors_total")
+
+	metrics.PushErrors = map[string]int64{PushErrorInconsistent: 2}
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "# TYPE ludusavi_runner_push_errors_total counter")
+	assert.Contains(t, body, `ludusavi_runner_push_errors_total{reason="inconsistent"} 2`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Failures(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_runner_failures_total")
+
+	metrics.Failures = map[domain.ErrorCode]int64{domain.CodeCloudAuthExpired: 3, domain.CodeBinaryNotFound: 1}
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "# TYPE ludusavi_runner_failures_total counter")
+	assert.Contains(t, body, `ludusavi_runner_failures_total{code="LR1001"} 1`+"\n"+
+		`ludusavi_runner_failures_total{code="LR2003"} 3`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Deliveries(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, `ludusavi_runner_pushes_total{outcome="sent"} 0`+"\n"+
+		`ludusavi_runner_pushes_total{outcome="failed"} 0`)
+	assert.NotContains(t, body, "ludusavi_runner_notifications_total")
+
+	metrics.Pushes = domain.DeliveryCounts{Sent: 5, Failed: 1}
+	metrics.Notifications = map[string]domain.DeliveryCounts{"apprise": {Sent: 3}, "apprise_escalation": {Failed: 2}}
+	body = client.buildMetrics(metrics)
+	assert.Contains(t, body, `ludusavi_runner_pushes_total{outcome="sent"} 5`)
+	assert.Contains(t, body, "# TYPE ludusavi_runner_notifications_total counter")
+	assert.Contains(t, body, `ludusavi_runner_notifications_total{target="apprise",outcome="sent"} 3`+"\n"+
+		`ludusavi_runner_notifications_total{target="apprise",outcome="failed"} 0`+"\n"+
+		`ludusavi_runner_notifications_total{target="apprise_escalation",outcome="sent"} 0`+"\n"+
+		`ludusavi_runner_notifications_total{target="apprise_escalation",outcome="failed"} 2`)
+}
+
+func TestPushgatewayClient_BuildMetrics_RunID(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_last_run_info")
+
+	metrics.RunID = "0b7f4c9e-3a1d-4e2b-9c8f-5d6e7f8a9b0c"
+	assert.Contains(t, client.buildMetrics(metrics),
+		`ludusavi_last_run_info{run_id="0b7f4c9e-3a1d-4e2b-9c8f-5d6e7f8a9b0c"} 1`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Process(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	assert.NotContains(t, client.buildMetrics(metrics), "ludusavi_runner_process_")
+
+	metrics.Process = &domain.ProcessStats{CPUSeconds: 1.5, MemoryBytes: 20 << 20}
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Usage = domain.ProcessUsage{CPUSeconds: 12.25, PeakMemoryBytes: 150 << 20}
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "ludusavi_runner_process_cpu_seconds_total 1.500\n")
+	assert.Contains(t, body, "ludusavi_runner_process_resident_memory_bytes 20971520\n")
+	// Unknown values are left out
+	assert.NotContains(t, body, "ludusavi_runner_process_open_fds")
+	assert.Contains(t, body, `ludusavi_last_run_cpu_seconds{operation="backup"} 12.250`)
+	assert.Contains(t, body, `ludusavi_last_run_peak_memory_bytes{operation="backup"} 157286400`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Destination(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Destination = "usb"
+	result.Stats = domain.BackupStats{TotalGames: 7}
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, `ludusavi_last_run_success{operation="backup",destination="usb"} 1`)
+	assert.Contains(t, body, `ludusavi_games_total{operation="backup",destination="usb"} 7`)
+}
+
+func TestPushgatewayClient_BuildMetrics_Maintenance(t *testing.T) {
+	client := NewPushgatewayClient("http://localhost:9091")
+
+	metrics := domain.NewMetrics("test-host")
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Complete(false, nil)
+	metrics.AddResult(result)
+	assert.NotContains(t, client.buildMetrics(metrics), "maintenance")
+
+	metrics.Maintenance = true
+	body := client.buildMetrics(metrics)
+	assert.Contains(t, body, "ludusavi_runner_up{maintenance=\"true\"} 1\n")
+	assert.Contains(t, body, `ludusavi_last_run_success{operation="backup",maintenance="true"} 0`)
+}
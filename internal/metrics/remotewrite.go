@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	nethttp "net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+const (
+	// remoteWriteVersion is the version of the remote write protocol spoken.
+	remoteWriteVersion = "0.1.0"
+	// validateTimeout bounds the reachability check of Validate.
+	validateTimeout = 10 * time.Second
+)
+
+// RemoteWriteClient pushes metrics to a Prometheus remote write endpoint,
+// such as Mimir, Thanos Receive or VictoriaMetrics. Unlike with the
+// Pushgateway, every sample carries the time it was collected, so a series
+// simply ends when runs stop instead of going stale with its last value.
+type RemoteWriteClient struct {
+	url        string
+	prefix     string
+	jobName    string
+	headers    map[string]string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// RemoteWriteOption configures a RemoteWriteClient.
+type RemoteWriteOption func(*RemoteWriteClient)
+
+// WithRemoteWriteHTTPClient sets a custom HTTP client.
+func WithRemoteWriteHTTPClient(client *http.Client) RemoteWriteOption {
+	return func(c *RemoteWriteClient) {
+		c.httpClient = client
+	}
+}
+
+// WithRemoteWriteLogger sets the logger.
+func WithRemoteWriteLogger(logger *slog.Logger) RemoteWriteOption {
+	return func(c *RemoteWriteClient) {
+		c.logger = logger
+	}
+}
+
+// WithRemoteWritePrefix sets the prefix metric names are written with in
+// place of DefaultPrefix.
+func WithRemoteWritePrefix(prefix string) RemoteWriteOption {
+	return func(c *RemoteWriteClient) {
+		c.prefix = prefix
+	}
+}
+
+// WithRemoteWriteJobName sets the job label of the written series.
+func WithRemoteWriteJobName(name string) RemoteWriteOption {
+	return func(c *RemoteWriteClient) {
+		c.jobName = name
+	}
+}
+
+// WithRemoteWriteHeaders sets extra headers sent with each request, such as
+// an Authorization or X-Scope-OrgID header.
+func WithRemoteWriteHeaders(headers map[string]string) RemoteWriteOption {
+	return func(c *RemoteWriteClient) {
+		c.headers = headers
+	}
+}
+
+// NewRemoteWriteClient creates a new RemoteWriteClient writing to url, e.g.
+// "http://mimir:9009/api/v1/push".
+func NewRemoteWriteClient(url string, opts ...RemoteWriteOption) *RemoteWriteClient {
+	c := &RemoteWriteClient{
+		url:        url,
+		prefix:     DefaultPrefix,
+		jobName:    DefaultJobName,
+		httpClient: http.NewClient(),
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Push writes metrics to the remote write endpoint, stamped with the time
+// they were collected.
+func (c *RemoteWriteClient) Push(ctx context.Context, metrics *domain.Metrics) error {
+	log := logging.FromContext(ctx, c.logger)
+	fams := families(metrics)
+	body := snappyEncode(c.writeRequest(metrics, fams))
+
+	log.Debug("writing metrics to remote write endpoint",
+		"url", c.url,
+		"series", countSeries(fams),
+	)
+
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+
+	resp, err := c.httpClient.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("remote write endpoint returned status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	log.Debug("metrics written successfully")
+	return nil
+}
+
+// Validate checks if the remote write endpoint is reachable. It only
+// accepts POST requests, so any HTTP response will do.
+func (c *RemoteWriteClient) Validate(ctx context.Context) error {
+	if _, err := c.httpClient.Get(ctx, c.url, http.WithRequestTimeout(validateTimeout)); err != nil {
+		return fmt.Errorf("remote write endpoint not reachable at %s: %w", c.url, err)
+	}
+	return nil
+}
+
+// countSeries returns the number of series in fams.
+func countSeries(fams []family) int {
+	n := 0
+	for _, f := range fams {
+		n += len(f.samples)
+	}
+	return n
+}
+
+// writeRequest encodes fams as a remote write WriteRequest protobuf message,
+// with a series for each sample and the metadata of each metric.
+func (c *RemoteWriteClient) writeRequest(m *domain.Metrics, fams []family) []byte {
+	var req []byte
+	at := m.Timestamp.UnixMilli()
+
+	for _, f := range fams {
+		name := Rename(f.name, c.prefix)
+		for _, s := range f.samples {
+			labels := append([]label{
+				{"__name__", name},
+				{"instance", m.Hostname},
+				{"job", c.jobName},
+			}, s.labels...)
+			// Receivers require the labels of a series sorted by name
+			slices.SortFunc(labels, func(a, b label) int {
+				return strings.Compare(a.name, b.name)
+			})
+
+			var series []byte
+			for _, l := range labels {
+				var lb []byte
+				lb = appendString(lb, 1, l.name)
+				lb = appendString(lb, 2, l.value)
+				series = appendBytes(series, 1, lb)
+			}
+			var sb []byte
+			sb = appendDouble(sb, 1, s.value)
+			sb = appendVarint(sb, 2, uint64(at))
+			series = appendBytes(series, 2, sb)
+
+			req = appendBytes(req, 1, series)
+		}
+	}
+
+	for _, f := range fams {
+		d, _ := Lookup(f.name)
+		var md []byte
+		md = appendVarint(md, 1, metadataType(d.Type))
+		md = appendString(md, 2, Rename(f.name, c.prefix))
+		md = appendString(md, 4, d.Help)
+		req = appendBytes(req, 3, md)
+	}
+
+	return req
+}
+
+// metadataType returns the remote write MetricMetadata.MetricType of a
+// metric type.
+func metadataType(t string) uint64 {
+	switch t {
+	case TypeCounter:
+		return 1
+	case TypeGauge:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// appendVarint appends a varint field.
+func appendVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendDouble appends a double field.
+func appendDouble(b []byte, field int, v float64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireFixed64)
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+}
+
+// appendBytes appends a length-delimited field, such as an embedded message.
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendString appends a string field.
+func appendString(b []byte, field int, v string) []byte {
+	return appendBytes(b, field, []byte(v))
+}
+
+// Ensure RemoteWriteClient implements domain.MetricsPusher.
+var _ domain.MetricsPusher = (*RemoteWriteClient)(nil)
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writtenSeries is a series decoded from a remote write request.
+type writtenSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+func TestRemoteWriteClient_Push(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewRemoteWriteClient(server.URL+"/api/v1/push",
+		WithRemoteWriteJobName("saves"),
+		WithRemoteWriteHeaders(map[string]string{"X-Scope-OrgID": "homelab"}),
+	)
+
+	metrics := domain.NewMetrics("test-host")
+	metrics.Timestamp = time.UnixMilli(1700000000123)
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Destination = "usb"
+	result.Stats = domain.BackupStats{TotalGames: 42}
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+
+	require.NoError(t, client.Push(context.Background(), metrics))
+
+	assert.Equal(t, "application/x-protobuf", header.Get("Content-Type"))
+	assert.Equal(t, "snappy", header.Get("Content-Encoding"))
+	assert.Equal(t, "0.1.0", header.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "homelab", header.Get("X-Scope-OrgID"))
+
+	series := decodeWriteRequest(t, snappyDecode(t, body))
+	games := findSeries(series, "ludusavi_games_total")
+	require.NotNil(t, games)
+	assert.Equal(t, map[string]string{
+		"__name__":    "ludusavi_games_total",
+		"instance":    "test-host",
+		"job":         "saves",
+		"operation":   "backup",
+		"destination": "usb",
+	}, games.labels)
+	assert.Equal(t, 42.0, games.value)
+	assert.Equal(t, int64(1700000000123), games.timestamp)
+
+	up := findSeries(series, "ludusavi_runner_up")
+	require.NotNil(t, up)
+	assert.Equal(t, 1.0, up.value)
+}
+
+func TestRemoteWriteClient_Push_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("out of order sample"))
+	}))
+	defer server.Close()
+
+	client := NewRemoteWriteClient(server.URL)
+	err := client.Push(context.Background(), domain.NewMetrics("test-host"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Contains(t, err.Error(), "out of order sample")
+}
+
+func TestSnappyEncode(t *testing.T) {
+	for _, n := range []int{0, 1, 60, 61, 256, 257, 70000} {
+		src := []byte(strings.Repeat("x", n))
+		assert.Equal(t, src, snappyDecode(t, snappyEncode(src)), "length %d", n)
+	}
+}
+
+// findSeries returns the series of the metric called name, or nil.
+func findSeries(series []writtenSeries, name string) *writtenSeries {
+	for i := range series {
+		if series[i].labels["__name__"] == name {
+			return &series[i]
+		}
+	}
+	return nil
+}
+
+// snappyDecode decodes a snappy block of literals, as snappyEncode writes.
+func snappyDecode(t *testing.T, src []byte) []byte {
+	t.Helper()
+	n, k := binary.Uvarint(src)
+	require.Positive(t, k)
+	src = src[k:]
+
+	dst := []byte{}
+	for len(src) > 0 {
+		tag := src[0]
+		require.Zero(t, tag&3, "not a literal")
+		length := int(tag >> 2)
+		src = src[1:]
+		switch length {
+		case 60:
+			length = int(src[0])
+			src = src[1:]
+		case 61:
+			length = int(binary.LittleEndian.Uint16(src))
+			src = src[2:]
+		}
+		length++
+		dst = append(dst, src[:length]...)
+		src = src[length:]
+	}
+	require.Len(t, dst, int(n))
+	return dst
+}
+
+// decodeWriteRequest decodes the series of a WriteRequest message.
+func decodeWriteRequest(t *testing.T, b []byte) []writtenSeries {
+	t.Helper()
+	var series []writtenSeries
+	for _, ts := range fields(t, b)[1] {
+		s := writtenSeries{labels: make(map[string]string)}
+		f := fields(t, ts)
+		for _, l := range f[1] {
+			lf := fields(t, l)
+			s.labels[string(lf[1][0])] = string(lf[2][0])
+		}
+		sf := fields(t, f[2][0])
+		s.value = math.Float64frombits(binary.LittleEndian.Uint64(sf[1][0]))
+		ts, _ := binary.Uvarint(sf[2][0])
+		s.timestamp = int64(ts)
+		series = append(series, s)
+	}
+	return series
+}
+
+// fields splits a protobuf message into its fields by number: the raw bytes
+// of fixed64 and length-delimited fields, and the encoded varint of varints.
+func fields(t *testing.T, b []byte) map[int][][]byte {
+	t.Helper()
+	out := make(map[int][][]byte)
+	for len(b) > 0 {
+		key, k := binary.Uvarint(b)
+		require.Positive(t, k)
+		b = b[k:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			_, k := binary.Uvarint(b)
+			out[field] = append(out[field], b[:k])
+			b = b[k:]
+		case wireFixed64:
+			out[field] = append(out[field], b[:8])
+			b = b[8:]
+		case wireBytes:
+			n, k := binary.Uvarint(b)
+			b = b[k:]
+			out[field] = append(out[field], b[:n])
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return out
+}
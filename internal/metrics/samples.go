@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
+)
+
+// family is a metric and the samples pushed for it.
+type family struct {
+	// name is the metric's name in the catalog, with DefaultPrefix.
+	name    string
+	samples []sample
+}
+
+// sample is one value of a metric.
+type sample struct {
+	labels []label
+	value  float64
+	// precision is the number of decimals written in the text format.
+	precision int
+}
+
+// label is a label of a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// families returns the metrics pushed for m, in the order they are written.
+func families(m *domain.Metrics) []family {
+	var fams []family
+	add := func(name string, samples ...sample) {
+		fams = append(fams, family{name: name, samples: samples})
+	}
+
+	up := 0.0
+	if m.ServiceUp {
+		up = 1
+	}
+	add(MetricUp, sample{value: up})
+
+	paused := 0.0
+	if m.Paused {
+		paused = 1
+	}
+	add(MetricPaused, sample{value: paused})
+
+	versionInfo := version.Get()
+	add(MetricInfo, sample{
+		labels: []label{{"version", versionInfo.Version}, {"go_version", runtime.Version()}},
+		value:  1,
+	})
+
+	// Panics recovered since the service started
+	add(MetricPanics, sample{value: float64(m.Panics)})
+
+	// Watchdog recoveries since the service started
+	if len(m.WatchdogRecoveries) > 0 {
+		var samples []sample
+		for _, reason := range slices.Sorted(maps.Keys(m.WatchdogRecoveries)) {
+			samples = append(samples, sample{
+				labels: []label{{LabelReason, reason}},
+				value:  float64(m.WatchdogRecoveries[reason]),
+			})
+		}
+		add(MetricWatchdogRecoveries, samples...)
+	}
+
+	// Rejected pushes since the service started, reported by the next
+	// push that goes through
+	if len(m.PushErrors) > 0 {
+		var samples []sample
+		for _, reason := range slices.Sorted(maps.Keys(m.PushErrors)) {
+			samples = append(samples, sample{
+				labels: []label{{LabelReason, reason}},
+				value:  float64(m.PushErrors[reason]),
+			})
+		}
+		add(MetricPushErrors, samples...)
+	}
+
+	// Deliveries since the service started, so a broken alerting path shows
+	// up once metrics get through again
+	add(MetricPushes, deliverySamples(m.Pushes)...)
+	if len(m.Notifications) > 0 {
+		var samples []sample
+		for _, target := range slices.Sorted(maps.Keys(m.Notifications)) {
+			for _, s := range deliverySamples(m.Notifications[target]) {
+				s.labels = append([]label{{LabelTarget, target}}, s.labels...)
+				samples = append(samples, s)
+			}
+		}
+		add(MetricNotifications, samples...)
+	}
+
+	// Failures since the service started by error code, so the same failure
+	// can be counted across machines
+	if len(m.Failures) > 0 {
+		var samples []sample
+		for _, code := range slices.Sorted(maps.Keys(m.Failures)) {
+			samples = append(samples, sample{
+				labels: []label{{LabelCode, string(code)}},
+				value:  float64(m.Failures[code]),
+			})
+		}
+		add(MetricFailures, samples...)
+	}
+
+	// Overhead of the runner itself
+	if m.Process != nil {
+		add(MetricProcessCPU, sample{value: m.Process.CPUSeconds, precision: 3})
+		if m.Process.MemoryBytes > 0 {
+			add(MetricProcessMemory, sample{value: float64(m.Process.MemoryBytes)})
+		}
+		if m.Process.OpenFDs > 0 {
+			add(MetricProcessOpenFDs, sample{value: float64(m.Process.OpenFDs)})
+		}
+	}
+
+	// Game count regression check of the latest full backup
+	if m.GameCount != nil {
+		regressed := 0.0
+		if m.GameCount.Regressed {
+			regressed = 1
+		}
+		add(MetricGamesAverage, sample{value: m.GameCount.Average, precision: 1})
+		add(MetricGameCountRegressed, sample{value: regressed})
+	}
+
+	// Identifies the run in the service log and notifications
+	if m.RunID != "" {
+		add(MetricLastRunInfo, sample{labels: []label{{LabelRunID, m.RunID}}, value: 1})
+	}
+
+	// One sample per outcome, so a query for any of them has a value
+	if m.Outcome != "" {
+		var samples []sample
+		for _, outcome := range []domain.RunOutcome{domain.OutcomeSuccess, domain.OutcomePartial, domain.OutcomeFailure} {
+			value := 0.0
+			if m.Outcome == outcome {
+				value = 1
+			}
+			samples = append(samples, sample{labels: []label{{LabelOutcome, string(outcome)}}, value: value})
+		}
+		add(MetricLastRunOutcome, samples...)
+	}
+
+	if len(m.Results) > 0 {
+		for _, rm := range resultMetrics {
+			var samples []sample
+			for _, r := range m.Results {
+				labels := []label{{LabelOperation, r.Operation.String()}}
+				if r.Destination != "" {
+					labels = append(labels, label{LabelDestination, r.Destination})
+				}
+				samples = append(samples, sample{labels: labels, value: rm.value(r), precision: rm.precision})
+			}
+			add(rm.name, samples...)
+		}
+	}
+
+	// Failures during maintenance are deliberate, which dashboards and
+	// alerts can tell from the label
+	if m.Maintenance {
+		for _, f := range fams {
+			for i := range f.samples {
+				f.samples[i].labels = append(f.samples[i].labels, label{LabelMaintenance, "true"})
+			}
+		}
+	}
+
+	return fams
+}
+
+// resultMetric is a metric pushed for each backup result.
+type resultMetric struct {
+	name      string
+	value     func(r *domain.BackupResult) float64
+	precision int
+}
+
+// resultMetrics are the metrics pushed for each backup result, in order.
+var resultMetrics = []resultMetric{
+	{MetricLastRunTimestamp, func(r *domain.BackupResult) float64 { return float64(r.EndTime.Unix()) }, 0},
+	{MetricLastRunSuccess, func(r *domain.BackupResult) float64 {
+		if r.Success {
+			return 1
+		}
+		return 0
+	}, 0},
+	{MetricLastRunDuration, func(r *domain.BackupResult) float64 { return r.Duration.Seconds() }, 3},
+	{MetricGamesTotal, func(r *domain.BackupResult) float64 { return float64(r.Stats.TotalGames) }, 0},
+	{MetricGamesProcessed, func(r *domain.BackupResult) float64 { return float64(r.Stats.ProcessedGames) }, 0},
+	{MetricBytesTotal, func(r *domain.BackupResult) float64 { return float64(r.Stats.TotalBytes) }, 0},
+	{MetricBytesProcessed, func(r *domain.BackupResult) float64 { return float64(r.Stats.ProcessedBytes) }, 0},
+	{MetricGamesNew, func(r *domain.BackupResult) float64 { return float64(r.Stats.NewGames) }, 0},
+	{MetricGamesChanged, func(r *domain.BackupResult) float64 { return float64(r.Stats.ChangedGames) }, 0},
+	{MetricLastRunCPU, func(r *domain.BackupResult) float64 { return r.Usage.CPUSeconds }, 3},
+	{MetricLastRunPeakMemory, func(r *domain.BackupResult) float64 { return float64(r.Usage.PeakMemoryBytes) }, 0},
+}
+
+// deliverySamples returns the samples of a delivery counter, by outcome.
+func deliverySamples(counts domain.DeliveryCounts) []sample {
+	return []sample{
+		{labels: []label{{LabelOutcome, OutcomeSent}}, value: float64(counts.Sent)},
+		{labels: []label{{LabelOutcome, OutcomeFailed}}, value: float64(counts.Failed)},
+	}
+}
+
+// labelEscaper escapes label values in the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeText writes fams in the Prometheus text format with the metric names
+// given prefix. Unless at is zero, each sample is stamped with it.
+func writeText(b *strings.Builder, fams []family, prefix string, at time.Time) {
+	for i, f := range fams {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		d, _ := Lookup(f.name)
+		name := Rename(f.name, prefix)
+		b.WriteString("# HELP " + name + " " + d.Help + "\n")
+		b.WriteString("# TYPE " + name + " " + d.Type + "\n")
+
+		for _, s := range f.samples {
+			b.WriteString(name)
+			if len(s.labels) > 0 {
+				b.WriteString("{")
+				for j, l := range s.labels {
+					if j > 0 {
+						b.WriteString(",")
+					}
+					b.WriteString(l.name + `="` + labelEscaper.Replace(l.value) + `"`)
+				}
+				b.WriteString("}")
+			}
+			b.WriteString(" " + strconv.FormatFloat(s.value, 'f', s.precision, 64))
+			if !at.IsZero() {
+				b.WriteString(" " + strconv.FormatInt(at.UnixMilli(), 10))
+			}
+			b.WriteString("\n")
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import "encoding/binary"
+
+// maxSnappyLiteral is the longest literal snappyEncode writes at once.
+const maxSnappyLiteral = 1 << 16
+
+// snappyEncode encodes src in the snappy block format remote write requires.
+// It writes src as literals without looking for repeats: the requests are a
+// few kilobytes, so compressing them isn't worth a dependency, and any
+// snappy decoder reads literal-only blocks.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), maxSnappyLiteral)
+		switch {
+		case n <= 60:
+			dst = append(dst, byte(n-1)<<2)
+		case n <= 1<<8:
+			dst = append(dst, 60<<2, byte(n-1))
+		default:
+			dst = append(dst, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// victoriaMetricsImportPath is the VictoriaMetrics endpoint importing the
+// Prometheus text format.
+const victoriaMetricsImportPath = "/api/v1/import/prometheus"
+
+// VictoriaMetricsClient imports metrics into VictoriaMetrics in the
+// Prometheus text format, each sample stamped with the time it was
+// collected, so no Pushgateway is needed in between.
+type VictoriaMetricsClient struct {
+	url        string
+	prefix     string
+	jobName    string
+	headers    map[string]string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// VictoriaMetricsOption configures a VictoriaMetricsClient.
+type VictoriaMetricsOption func(*VictoriaMetricsClient)
+
+// WithVictoriaMetricsHTTPClient sets a custom HTTP client.
+func WithVictoriaMetricsHTTPClient(client *http.Client) VictoriaMetricsOption {
+	return func(c *VictoriaMetricsClient) {
+		c.httpClient = client
+	}
+}
+
+// WithVictoriaMetricsLogger sets the logger.
+func WithVictoriaMetricsLogger(logger *slog.Logger) VictoriaMetricsOption {
+	return func(c *VictoriaMetricsClient) {
+		c.logger = logger
+	}
+}
+
+// WithVictoriaMetricsPrefix sets the prefix metric names are imported with
+// in place of DefaultPrefix.
+func WithVictoriaMetricsPrefix(prefix string) VictoriaMetricsOption {
+	return func(c *VictoriaMetricsClient) {
+		c.prefix = prefix
+	}
+}
+
+// WithVictoriaMetricsJobName sets the job label of the imported series.
+func WithVictoriaMetricsJobName(name string) VictoriaMetricsOption {
+	return func(c *VictoriaMetricsClient) {
+		c.jobName = name
+	}
+}
+
+// WithVictoriaMetricsHeaders sets extra headers sent with each request, such
+// as an Authorization header for vmauth.
+func WithVictoriaMetricsHeaders(headers map[string]string) VictoriaMetricsOption {
+	return func(c *VictoriaMetricsClient) {
+		c.headers = headers
+	}
+}
+
+// NewVictoriaMetricsClient creates a new VictoriaMetricsClient for the
+// VictoriaMetrics at url, e.g. "http://victoriametrics:8428".
+func NewVictoriaMetricsClient(url string, opts ...VictoriaMetricsOption) *VictoriaMetricsClient {
+	c := &VictoriaMetricsClient{
+		url:        strings.TrimSuffix(url, "/"),
+		prefix:     DefaultPrefix,
+		jobName:    DefaultJobName,
+		httpClient: http.NewClient(),
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Push imports metrics into VictoriaMetrics, stamped with the time they
+// were collected. The job and instance labels the Pushgateway would add are
+// added as extra labels.
+func (c *VictoriaMetricsClient) Push(ctx context.Context, metrics *domain.Metrics) error {
+	log := logging.FromContext(ctx, c.logger)
+
+	var b strings.Builder
+	writeText(&b, families(metrics), c.prefix, metrics.Timestamp)
+
+	query := neturl.Values{"extra_label": {
+		"job=" + c.jobName,
+		"instance=" + metrics.Hostname,
+	}}
+	importURL := c.url + victoriaMetricsImportPath + "?" + query.Encode()
+
+	log.Debug("importing metrics into victoriametrics",
+		"url", importURL,
+		"metrics_count", len(metrics.Results),
+	)
+
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodPost, importURL, strings.NewReader(b.String()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.httpClient.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to import metrics: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("victoriametrics returned status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	log.Debug("metrics imported successfully")
+	return nil
+}
+
+// Validate checks if VictoriaMetrics is reachable.
+func (c *VictoriaMetricsClient) Validate(ctx context.Context) error {
+	if err := c.httpClient.CheckConnectivity(ctx, c.url+"/health"); err != nil {
+		return fmt.Errorf("victoriametrics not reachable at %s: %w", c.url, err)
+	}
+	return nil
+}
+
+// Ensure VictoriaMetricsClient implements domain.MetricsPusher.
+var _ domain.MetricsPusher = (*VictoriaMetricsClient)(nil)
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVictoriaMetricsClient_Push(t *testing.T) {
+	var request *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewVictoriaMetricsClient(server.URL+"/", WithVictoriaMetricsPrefix("saves_"))
+
+	metrics := domain.NewMetrics("test-host")
+	metrics.Timestamp = time.UnixMilli(1700000000123)
+	result := domain.NewBackupResult(domain.OperationBackup)
+	result.Stats = domain.BackupStats{TotalGames: 42}
+	result.Complete(true, nil)
+	metrics.AddResult(result)
+
+	require.NoError(t, client.Push(context.Background(), metrics))
+
+	assert.Equal(t, "/api/v1/import/prometheus", request.URL.Path)
+	assert.Equal(t, []string{"job=ludusavi", "instance=test-host"}, request.URL.Query()["extra_label"])
+	assert.Contains(t, body, "saves_runner_up 1 1700000000123\n")
+	assert.Contains(t, body, `saves_games_total{operation="backup"} 42 1700000000123`+"\n")
+}
+
+func TestVictoriaMetricsClient_Push_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("cannot parse"))
+	}))
+	defer server.Close()
+
+	client := NewVictoriaMetricsClient(server.URL)
+	err := client.Push(context.Background(), domain.NewMetrics("test-host"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestVictoriaMetricsClient_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, NewVictoriaMetricsClient(server.URL).Validate(context.Background()))
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 )
 
 const (
@@ -66,6 +67,7 @@ type appriseRequest struct {
 
 // Notify sends a notification via Apprise.
 func (a *AppriseClient) Notify(ctx context.Context, notification *domain.Notification) error {
+	log := logging.FromContext(ctx, a.logger)
 	body := notification.Body
 	if len(body) > maxBodyLength {
 		body = body[:maxBodyLength-3] + "..."
@@ -84,7 +86,7 @@ func (a *AppriseClient) Notify(ctx context.Context, notification *domain.Notific
 
 	notifyURL := fmt.Sprintf("%s/notify/%s", a.url, a.key)
 
-	a.logger.Debug("sending notification via apprise",
+	log.Debug("sending notification via apprise",
 		"url", notifyURL,
 		"title", notification.Title,
 		"level", notification.Level,
@@ -99,7 +101,7 @@ func (a *AppriseClient) Notify(ctx context.Context, notification *domain.Notific
 		return fmt.Errorf("apprise returned status %d: %s", resp.StatusCode, string(resp.Body))
 	}
 
-	a.logger.Debug("notification sent successfully")
+	log.Debug("notification sent successfully")
 	return nil
 }
 
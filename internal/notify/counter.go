@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Counter counts the notifications sent and failed since it was created,
+// by target, so a broken alerting path shows up in the metrics.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[string]domain.DeliveryCounts
+}
+
+// NewCounter creates a new Counter.
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[string]domain.DeliveryCounts)}
+}
+
+// Count returns notifier with its notifications counted under target. A nil
+// Counter returns notifier as it is.
+func (c *Counter) Count(target string, notifier domain.Notifier) domain.Notifier {
+	if c == nil {
+		return notifier
+	}
+	return &countedNotifier{Notifier: notifier, target: target, counter: c}
+}
+
+// Counts returns the notifications sent and failed by target.
+func (c *Counter) Counts() map[string]domain.DeliveryCounts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
+
+// record counts a notification to target that failed with err, if not nil.
+func (c *Counter) record(target string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.counts[target]
+	if err != nil {
+		counts.Failed++
+	} else {
+		counts.Sent++
+	}
+	c.counts[target] = counts
+}
+
+// countedNotifier counts the notifications of a target.
+type countedNotifier struct {
+	domain.Notifier
+	target  string
+	counter *Counter
+}
+
+// Notify sends the notification and counts whether it went out.
+func (n *countedNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	err := n.Notifier.Notify(ctx, notification)
+	n.counter.record(n.target, err)
+	return err
+}
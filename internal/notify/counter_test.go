@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	counter := NewCounter()
+	failing := &MockNotifier{NotifyFunc: func(ctx context.Context, n *domain.Notification) error {
+		return errors.New("connection refused")
+	}}
+	notifier := NewRouter(counter.Count("apprise", &MockNotifier{}),
+		WithEscalation(1, counter.Count("apprise_escalation", failing)),
+	)
+
+	notification := domain.ErrorNotification("Backup failed", "disk full")
+	require.NoError(t, notifier.Notify(context.Background(), notification))
+	notification.FailureStreak = 1
+	require.Error(t, notifier.Notify(context.Background(), notification))
+
+	assert.Equal(t, map[string]domain.DeliveryCounts{
+		"apprise":            {Sent: 2},
+		"apprise_escalation": {Failed: 1},
+	}, counter.Counts())
+
+	// Without a counter, nothing is counted
+	var none *Counter
+	plain := &MockNotifier{}
+	assert.Same(t, plain, none.Count("apprise", plain))
+}
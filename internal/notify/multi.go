@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
 )
 
 // MultiNotifier sends notifications to multiple notifiers.
@@ -29,7 +30,7 @@ func (m *MultiNotifier) Notify(ctx context.Context, notification *domain.Notific
 
 	for _, notifier := range m.notifiers {
 		if err := notifier.Notify(ctx, notification); err != nil {
-			m.logger.Warn("notifier failed", "error", err)
+			logging.FromContext(ctx, m.logger).Warn("notifier failed", "error", err)
 			errs = append(errs, err)
 		}
 	}
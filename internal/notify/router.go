@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// Router sends notifications to a notifier and escalates failures that keep
+// happening: once enough runs in a row have failed, their notifications are
+// raised to error level and also sent to the escalation notifiers, such as
+// a phone push on top of the usual chat message.
+type Router struct {
+	notifier      domain.Notifier
+	escalateAfter int
+	escalation    []domain.Notifier
+	logger        *slog.Logger
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithEscalation escalates notifications of failures once after runs in a
+// row have failed, sending them to notifiers as well. Without notifiers,
+// escalation only raises the level.
+func WithEscalation(after int, notifiers ...domain.Notifier) RouterOption {
+	return func(r *Router) {
+		r.escalateAfter = after
+		r.escalation = notifiers
+	}
+}
+
+// WithRouterLogger sets the logger.
+func WithRouterLogger(logger *slog.Logger) RouterOption {
+	return func(r *Router) {
+		r.logger = logger
+	}
+}
+
+// NewRouter creates a new Router sending notifications to notifier.
+func NewRouter(notifier domain.Notifier, opts ...RouterOption) *Router {
+	r := &Router{
+		notifier: notifier,
+		logger:   slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Notify sends a notification, escalating it if its failure streak reached
+// the escalation threshold. Returns an error if any notifier fails, but
+// attempts all notifiers.
+func (r *Router) Notify(ctx context.Context, notification *domain.Notification) error {
+	if !r.escalates(notification) {
+		return r.notifier.Notify(ctx, notification)
+	}
+
+	logging.FromContext(ctx, r.logger).Info("escalating failure notification",
+		"failed_runs", notification.FailureStreak,
+		"notifiers", len(r.escalation),
+	)
+	escalated := *notification
+	escalated.Level = domain.NotificationLevelError
+	if notification.FailureStreak > 1 {
+		escalated.Title = fmt.Sprintf("%s (%d failures in a row)", notification.Title, notification.FailureStreak)
+	}
+
+	var errs []error
+	for _, notifier := range append([]domain.Notifier{r.notifier}, r.escalation...) {
+		if err := notifier.Notify(ctx, &escalated); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// escalates returns true if notification is escalated.
+func (r *Router) escalates(notification *domain.Notification) bool {
+	return r.escalateAfter > 0 && notification.FailureStreak >= r.escalateAfter
+}
+
+// Validate validates the notifier and the escalation notifiers.
+func (r *Router) Validate(ctx context.Context) error {
+	var errs []error
+
+	for _, notifier := range append([]domain.Notifier{r.notifier}, r.escalation...) {
+		if err := notifier.Validate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Ensure Router implements domain.Notifier.
+var _ domain.Notifier = (*Router)(nil)
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Notify(t *testing.T) {
+	failure := func(streak int) *domain.Notification {
+		n := domain.ErrorNotification("Ludusavi Backup Failed", "disk full")
+		n.FailureStreak = streak
+		return n
+	}
+
+	t.Run("sends to the notifier below the threshold", func(t *testing.T) {
+		base, phone := &MockNotifier{}, &MockNotifier{}
+		router := NewRouter(base, WithEscalation(3, phone))
+
+		require.NoError(t, router.Notify(context.Background(), failure(2)))
+		require.Len(t, base.Notifications, 1)
+		assert.Equal(t, "Ludusavi Backup Failed", base.Notifications[0].Title)
+		assert.Empty(t, phone.Notifications)
+	})
+
+	t.Run("escalates from the threshold", func(t *testing.T) {
+		base, phone := &MockNotifier{}, &MockNotifier{}
+		router := NewRouter(base, WithEscalation(3, phone))
+
+		auth := domain.WarningNotification("Ludusavi Cloud Sign-in Required", "sign in")
+		auth.FailureStreak = 3
+		require.NoError(t, router.Notify(context.Background(), auth))
+
+		for _, m := range []*MockNotifier{base, phone} {
+			require.Len(t, m.Notifications, 1)
+			assert.Equal(t, "Ludusavi Cloud Sign-in Required (3 failures in a row)", m.Notifications[0].Title)
+			assert.Equal(t, domain.NotificationLevelError, m.Notifications[0].Level)
+		}
+		// The original notification is left as it was
+		assert.Equal(t, domain.NotificationLevelWarning, auth.Level)
+	})
+
+	t.Run("never escalates other notifications", func(t *testing.T) {
+		base, phone := &MockNotifier{}, &MockNotifier{}
+		router := NewRouter(base, WithEscalation(1, phone))
+
+		require.NoError(t, router.Notify(context.Background(), domain.InfoNotification("Ludusavi Backup Completed", "ok")))
+		assert.Len(t, base.Notifications, 1)
+		assert.Empty(t, phone.Notifications)
+	})
+
+	t.Run("escalation without notifiers raises the level", func(t *testing.T) {
+		base := &MockNotifier{}
+		router := NewRouter(base, WithEscalation(1))
+
+		require.NoError(t, router.Notify(context.Background(), failure(1)))
+		require.Len(t, base.Notifications, 1)
+		assert.Equal(t, "Ludusavi Backup Failed", base.Notifications[0].Title)
+		assert.Equal(t, domain.NotificationLevelError, base.Notifications[0].Level)
+	})
+
+	t.Run("attempts every notifier", func(t *testing.T) {
+		base := &MockNotifier{NotifyFunc: func(ctx context.Context, n *domain.Notification) error {
+			return errors.New("discord down")
+		}}
+		phone := &MockNotifier{}
+		router := NewRouter(base, WithEscalation(1, phone))
+
+		assert.ErrorContains(t, router.Notify(context.Background(), failure(1)), "discord down")
+		assert.Len(t, phone.Notifications, 1)
+	})
+}
+
+func TestRouter_Validate(t *testing.T) {
+	base := &MockNotifier{}
+	phone := &MockNotifier{ValidateFunc: func(ctx context.Context) error {
+		return errors.New("unreachable")
+	}}
+
+	assert.NoError(t, NewRouter(base).Validate(context.Background()))
+	assert.ErrorContains(t, NewRouter(base, WithEscalation(3, phone)).Validate(context.Background()), "unreachable")
+}
@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
+)
+
+// DefaultWebhookContentType is the content type webhook payloads are sent
+// with by default.
+const DefaultWebhookContentType = "application/json"
+
+// webhookValidateTimeout bounds the reachability check of Validate.
+const webhookValidateTimeout = 10 * time.Second
+
+// templateFuncs are the functions webhook templates can use besides the
+// built-in ones.
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, such as a string with its quotes and
+	// escapes, for use inside a JSON payload
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseTemplate parses the template of webhook payloads. It is executed
+// with the domain.Notification, whose Result is the run notified about, if
+// any.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Parse(text)
+}
+
+// WebhookNotifier sends notifications as an HTTP POST with a payload
+// rendered from a template, for services Apprise doesn't cover or that want
+// a payload of their own, such as a Slack or Teams incoming webhook.
+type WebhookNotifier struct {
+	url         string
+	tmpl        *template.Template
+	contentType string
+	headers     map[string]string
+	secret      string
+	httpClient  *http.Client
+	logger      *slog.Logger
+}
+
+// WebhookOption configures a WebhookNotifier.
+type WebhookOption func(*WebhookNotifier)
+
+// WithWebhookHTTPClient sets a custom HTTP client.
+func WithWebhookHTTPClient(client *http.Client) WebhookOption {
+	return func(w *WebhookNotifier) {
+		w.httpClient = client
+	}
+}
+
+// WithWebhookLogger sets the logger.
+func WithWebhookLogger(logger *slog.Logger) WebhookOption {
+	return func(w *WebhookNotifier) {
+		w.logger = logger
+	}
+}
+
+// WithContentType sets the content type payloads are sent with in place
+// of DefaultWebhookContentType.
+func WithContentType(contentType string) WebhookOption {
+	return func(w *WebhookNotifier) {
+		w.contentType = contentType
+	}
+}
+
+// WithHeaders sets extra headers sent with each payload, such as for
+// authentication.
+func WithHeaders(headers map[string]string) WebhookOption {
+	return func(w *WebhookNotifier) {
+		w.headers = headers
+	}
+}
+
+// WithWebhookSecret signs each payload with secret, as the on_complete
+// webhook does; see webhook.SetSignature.
+func WithWebhookSecret(secret string) WebhookOption {
+	return func(w *WebhookNotifier) {
+		w.secret = secret
+	}
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier posting payloads
+// rendered from tmpl, as parsed by ParseTemplate, to url.
+func NewWebhookNotifier(url string, tmpl *template.Template, opts ...WebhookOption) *WebhookNotifier {
+	w := &WebhookNotifier{
+		url:         url,
+		tmpl:        tmpl,
+		contentType: DefaultWebhookContentType,
+		httpClient:  http.NewClient(),
+		logger:      slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Notify posts the payload rendered for notification.
+func (w *WebhookNotifier) Notify(ctx context.Context, notification *domain.Notification) error {
+	log := logging.FromContext(ctx, w.logger)
+
+	body, err := w.render(notification)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("sending notification to webhook",
+		"title", notification.Title,
+		"level", notification.Level,
+	)
+
+	opts := w.requestOptions()
+	if w.secret != "" {
+		header := make(nethttp.Header)
+		webhook.SetSignature(header, w.secret, body, time.Now())
+		for key := range header {
+			opts = append(opts, http.WithRequestHeader(key, header.Get(key)))
+		}
+	}
+
+	resp, err := w.httpClient.Post(ctx, w.url, w.contentType, body, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook notification: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	log.Debug("notification sent to webhook")
+	return nil
+}
+
+// Validate renders the template for a sample notification and checks that
+// the webhook is reachable. Any HTTP response will do, as webhooks often
+// only accept the POST of a payload.
+func (w *WebhookNotifier) Validate(ctx context.Context) error {
+	sample := domain.ErrorNotification("Ludusavi Backup Failed", "Backup failed on validate.\n\nRun ID: validate")
+	sample.FailureStreak = 1
+	sample.Result = domain.NewRunResult(true)
+	if _, err := w.render(sample); err != nil {
+		return err
+	}
+
+	opts := append(w.requestOptions(), http.WithRequestTimeout(webhookValidateTimeout), http.WithMaxAttempts(1))
+	if _, err := w.httpClient.Head(ctx, w.url, opts...); err != nil {
+		return fmt.Errorf("webhook not reachable at %s: %w", w.url, err)
+	}
+	return nil
+}
+
+// render renders the payload for notification. A JSON payload must be
+// valid JSON, so a broken template fails here rather than at the service.
+func (w *WebhookNotifier) render(notification *domain.Notification) ([]byte, error) {
+	var b bytes.Buffer
+	if err := w.tmpl.Execute(&b, notification); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	if strings.HasPrefix(w.contentType, DefaultWebhookContentType) && !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("webhook template rendered invalid JSON: %s", b.String())
+	}
+	return b.Bytes(), nil
+}
+
+// requestOptions returns the options setting the configured headers.
+func (w *WebhookNotifier) requestOptions() []http.RequestOption {
+	var opts []http.RequestOption
+	for key, value := range w.headers {
+		opts = append(opts, http.WithRequestHeader(key, value))
+	}
+	return opts
+}
+
+// Ensure WebhookNotifier implements domain.Notifier.
+var _ domain.Notifier = (*WebhookNotifier)(nil)
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received map[string]any
+	var contentType, auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json .Title}}, "body": {{json .Body}}{{with .Result}}, "success": {{.Success}}{{end}}}`)
+	require.NoError(t, err)
+	notifier := NewWebhookNotifier(server.URL, tmpl, WithHeaders(map[string]string{"Authorization": "Bearer token"}))
+
+	notification := domain.ErrorNotification("Backup \"failed\"", "line one\nline two")
+	notification.Result = domain.NewRunResult(false)
+	require.NoError(t, notifier.Notify(context.Background(), notification))
+
+	assert.Equal(t, DefaultWebhookContentType, contentType)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, "Backup \"failed\"", received["text"])
+	assert.Equal(t, "line one\nline two", received["body"])
+	assert.Equal(t, false, received["success"])
+}
+
+func TestWebhookNotifier_Notify_Signed(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json .Title}}}`)
+	require.NoError(t, err)
+
+	notifier := NewWebhookNotifier(server.URL, tmpl, WithWebhookSecret("s3cret"))
+	require.NoError(t, notifier.Notify(context.Background(), domain.InfoNotification("Report", "")))
+	require.NoError(t, webhook.Verify(header, "s3cret", body, webhook.DefaultTolerance, time.Now()))
+	assert.ErrorIs(t, webhook.Verify(header, "guess", body, webhook.DefaultTolerance, time.Now()), webhook.ErrInvalidSignature)
+
+	notifier = NewWebhookNotifier(server.URL, tmpl)
+	require.NoError(t, notifier.Notify(context.Background(), domain.InfoNotification("Report", "")))
+	assert.Empty(t, header.Get(webhook.SignatureHeader))
+}
+
+func TestWebhookNotifier_Notify_WithoutResult(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json .Title}}{{with .Result}}, "success": {{.Success}}{{end}}}`)
+	require.NoError(t, err)
+	notifier := NewWebhookNotifier(server.URL, tmpl)
+
+	require.NoError(t, notifier.Notify(context.Background(), domain.InfoNotification("Report", "")))
+	assert.JSONEq(t, `{"text": "Report"}`, string(body))
+}
+
+func TestWebhookNotifier_Notify_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid_payload"))
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json .Title}}}`)
+	require.NoError(t, err)
+	notifier := NewWebhookNotifier(server.URL, tmpl)
+
+	err = notifier.Notify(context.Background(), domain.ErrorNotification("Title", "Body"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Contains(t, err.Error(), "invalid_payload")
+}
+
+func TestWebhookNotifier_Notify_InvalidJSON(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// The title isn't quoted or escaped without json
+	tmpl, err := ParseTemplate(`{"text": {{.Title}}}`)
+	require.NoError(t, err)
+
+	err = NewWebhookNotifier(server.URL, tmpl).Notify(context.Background(), domain.ErrorNotification("Title", "Body"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid JSON")
+	assert.False(t, called)
+
+	// Other content types are sent as rendered
+	notifier := NewWebhookNotifier(server.URL, tmpl, WithContentType("text/plain"))
+	require.NoError(t, notifier.Notify(context.Background(), domain.ErrorNotification("Title", "Body")))
+	assert.True(t, called)
+}
+
+func TestWebhookNotifier_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Webhooks often only accept a POST
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json .Title}}, "errors": {{len .Result.Errors}}}`)
+	require.NoError(t, err)
+	require.NoError(t, NewWebhookNotifier(server.URL, tmpl).Validate(context.Background()))
+
+	// A template failing to render is reported
+	tmpl, err = ParseTemplate(`{"text": {{.Missing}}}`)
+	require.NoError(t, err)
+	assert.Error(t, NewWebhookNotifier(server.URL, tmpl).Validate(context.Background()))
+
+	// An unreachable webhook is reported
+	server.Close()
+	tmpl, err = ParseTemplate(`{}`)
+	require.NoError(t, err)
+	assert.Error(t, NewWebhookNotifier(server.URL, tmpl).Validate(context.Background()))
+}
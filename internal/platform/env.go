@@ -0,0 +1,61 @@
+package platform
+
+import (
+	"os"
+	"regexp"
+)
+
+// envReference matches ${name} references in env values, and $${name}, which
+// escapes them.
+var envReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv returns env with ${name} references in its values replaced by
+// vars[name], the expanded value of the variable name in env, or the variable
+// name of the current process, in that order, and by nothing if none is set.
+// $${name} is kept as a literal ${name}. Only the braced form is expanded, so
+// values such as PowerShell commands with $env:NAME pass through unchanged.
+func ExpandEnv(env, vars map[string]string) map[string]string {
+	expanded := make(map[string]string, len(env))
+	var expand func(name string, seen map[string]bool) string
+	expand = func(name string, seen map[string]bool) string {
+		if value, ok := expanded[name]; ok {
+			return value
+		}
+		seen[name] = true
+		value := envReference.ReplaceAllStringFunc(env[name], func(ref string) string {
+			if ref[1] == '$' {
+				return ref[1:]
+			}
+			ref = ref[2 : len(ref)-1]
+			if value, ok := vars[ref]; ok {
+				return value
+			}
+			if _, ok := env[ref]; ok && !seen[ref] {
+				return expand(ref, seen)
+			}
+			return os.Getenv(ref)
+		})
+		delete(seen, name)
+		expanded[name] = value
+		return value
+	}
+
+	for name := range env {
+		expand(name, make(map[string]bool))
+	}
+	return expanded
+}
+
+// Environ returns the environment of the current process with env, expanded
+// with vars by ExpandEnv, added or overridden, for exec.Cmd.Env. It returns
+// nil, which runs commands with the current environment, if env is empty.
+func Environ(env, vars map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	environ := os.Environ()
+	for name, value := range ExpandEnv(env, vars) {
+		environ = append(environ, name+"="+value)
+	}
+	return environ
+}
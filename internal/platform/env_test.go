@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("LUDUSAVI_RUNNER_TEST_HOME", "/home/user")
+
+	env := map[string]string{
+		"RCLONE_CONFIG":           "${config_dir}/rclone.conf",
+		"RCLONE_CACHE_DIR":        "${CACHE_ROOT}/rclone",
+		"CACHE_ROOT":              "${LUDUSAVI_RUNNER_TEST_HOME}/.cache",
+		"RCLONE_PASSWORD_COMMAND": "powershell -c $env:PASS",
+		"LITERAL":                 "$${config_dir}",
+		"UNSET":                   "a${LUDUSAVI_RUNNER_TEST_UNSET}b",
+		"LOOP_A":                  "${LOOP_B}",
+		"LOOP_B":                  "${LOOP_A}",
+	}
+	vars := map[string]string{"config_dir": "/mnt/usb/runner"}
+
+	expanded := ExpandEnv(env, vars)
+
+	assert.Equal(t, "/mnt/usb/runner/rclone.conf", expanded["RCLONE_CONFIG"])
+	assert.Equal(t, "/home/user/.cache/rclone", expanded["RCLONE_CACHE_DIR"])
+	assert.Equal(t, "/home/user/.cache", expanded["CACHE_ROOT"])
+	assert.Equal(t, "powershell -c $env:PASS", expanded["RCLONE_PASSWORD_COMMAND"])
+	assert.Equal(t, "${config_dir}", expanded["LITERAL"])
+	assert.Equal(t, "ab", expanded["UNSET"])
+	assert.Empty(t, expanded["LOOP_A"])
+	assert.Empty(t, expanded["LOOP_B"])
+}
+
+func TestExpandEnv_SelfReference(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+
+	expanded := ExpandEnv(map[string]string{"PATH": "/opt/rclone:${PATH}"}, nil)
+
+	assert.Equal(t, "/opt/rclone:/usr/bin", expanded["PATH"])
+}
+
+func TestEnviron_Empty(t *testing.T) {
+	assert.Nil(t, Environ(nil, map[string]string{"config_dir": "/etc"}))
+}
+
+func TestRestrictedEnviron(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "/home/me/.config")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	environ := RestrictedEnviron(map[string]string{"RCLONE_CONFIG": "${HOME}/rclone.conf"}, nil)
+	assert.Contains(t, environ, "HOME=/home/me")
+	assert.Contains(t, environ, "XDG_CONFIG_HOME=/home/me/.config")
+	assert.Contains(t, environ, "RCLONE_CONFIG=/home/me/rclone.conf")
+	assert.NotContains(t, environ, "AWS_SECRET_ACCESS_KEY=secret")
+
+	assert.NotNil(t, RestrictedEnviron(nil, nil), "an empty environment is not the runner's")
+}
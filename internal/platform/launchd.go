@@ -0,0 +1,178 @@
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// launchdJob is the launchd job the service is installed as: the keys of
+// its property list the runner sets.
+type launchdJob struct {
+	Label            string
+	ProgramArguments []string
+	// UserName is the user a daemon runs as, root if empty.
+	UserName string
+	// RunAtLoad starts the job when it is loaded, at login for an agent or
+	// at boot for a daemon.
+	RunAtLoad bool
+}
+
+// plistHeader starts a property list file.
+const plistHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+`
+
+// marshal returns the property list of the job. It is restarted a minute
+// after failing, like the Windows service, and given time to finish a
+// backup in progress when stopped.
+func (j *launchdJob) marshal() []byte {
+	var b bytes.Buffer
+	b.WriteString(plistHeader)
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	writePlistKey(&b, "Label")
+	writePlistString(&b, "\t", j.Label)
+	writePlistKey(&b, "ProgramArguments")
+	b.WriteString("\t<array>\n")
+	for _, arg := range j.ProgramArguments {
+		writePlistString(&b, "\t\t", arg)
+	}
+	b.WriteString("\t</array>\n")
+	if j.UserName != "" {
+		writePlistKey(&b, "UserName")
+		writePlistString(&b, "\t", j.UserName)
+	}
+	writePlistKey(&b, "RunAtLoad")
+	fmt.Fprintf(&b, "\t<%t/>\n", j.RunAtLoad)
+	writePlistKey(&b, "KeepAlive")
+	b.WriteString("\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	writePlistKey(&b, "ThrottleInterval")
+	b.WriteString("\t<integer>60</integer>\n")
+	writePlistKey(&b, "ExitTimeOut")
+	b.WriteString("\t<integer>300</integer>\n")
+	b.WriteString("</dict>\n</plist>\n")
+	return b.Bytes()
+}
+
+// writePlistKey writes a key of the top-level dictionary.
+func writePlistKey(b *bytes.Buffer, key string) {
+	b.WriteString("\t<key>" + key + "</key>\n")
+}
+
+// writePlistString writes a string value, indented by indent.
+func writePlistString(b *bytes.Buffer, indent, s string) {
+	b.WriteString(indent + "<string>")
+	_ = xml.EscapeText(b, []byte(s))
+	b.WriteString("</string>\n")
+}
+
+// parseLaunchdJob reads the job from a property list written by marshal,
+// ignoring keys it doesn't set.
+func parseLaunchdJob(data []byte) (*launchdJob, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	if _, err := nextStart(d, "dict"); err != nil {
+		return nil, fmt.Errorf("failed to parse launchd job: %w", err)
+	}
+
+	job := &launchdJob{}
+	for {
+		start, err := nextStart(d, "")
+		if errors.Is(err, io.EOF) {
+			return job, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse launchd job: %w", err)
+		}
+		if start.Name.Local != "key" {
+			return nil, fmt.Errorf("failed to parse launchd job: unexpected <%s>", start.Name.Local)
+		}
+		var key string
+		if err := d.DecodeElement(&key, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse launchd job: %w", err)
+		}
+		value, err := nextStart(d, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse launchd job: value of %s: %w", key, err)
+		}
+
+		switch strings.TrimSpace(key) {
+		case "Label":
+			err = d.DecodeElement(&job.Label, &value)
+		case "UserName":
+			err = d.DecodeElement(&job.UserName, &value)
+		case "ProgramArguments":
+			var array struct {
+				Strings []string `xml:"string"`
+			}
+			err = d.DecodeElement(&array, &value)
+			job.ProgramArguments = array.Strings
+		case "RunAtLoad":
+			job.RunAtLoad = value.Name.Local == "true"
+			err = d.Skip()
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse launchd job: %s: %w", key, err)
+		}
+	}
+}
+
+// nextStart returns the next start element, named name unless empty, or
+// io.EOF at the end of the enclosing element.
+func nextStart(d *xml.Decoder, name string) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if name == "" || t.Name.Local == name {
+				return t, nil
+			}
+		case xml.EndElement:
+			if name == "" {
+				return xml.StartElement{}, io.EOF
+			}
+		}
+	}
+}
+
+// parseLaunchctlPrint parses the top-level "key = value" lines printed by
+// launchctl print.
+func parseLaunchctlPrint(out []byte) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Top-level properties are indented by one tab
+		if !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), " = "); ok {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// jobState returns the service state of a job's launchctl state.
+func jobState(state string) ServiceState {
+	switch state {
+	case "running":
+		return ServiceStateRunning
+	case "spawn scheduled", "xpcproxy":
+		return ServiceStateStarting
+	case "exiting":
+		return ServiceStateStopping
+	case "not running", "waiting":
+		return ServiceStateStopped
+	default:
+		return ServiceStateUnknown
+	}
+}
@@ -0,0 +1,353 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// launchdLabel is the label of the launchd job.
+	launchdLabel = "io.github.sharkusmanch.ludusavi-runner"
+	// daemonDir holds the jobs of the system domain.
+	daemonDir = "/Library/LaunchDaemons"
+)
+
+// jobFile is the location of the launchd job's property list.
+type jobFile struct {
+	path string
+	// agent is set for a LaunchAgent, run in the user's GUI domain rather
+	// than the system domain.
+	agent bool
+}
+
+// LaunchdServiceManager manages the service as a launchd job: a LaunchAgent
+// of the user in the user scope, or a LaunchDaemon in the system scope or
+// for another user.
+type LaunchdServiceManager struct {
+	daemonDir   string
+	agentDir    func() (string, error)
+	uid         int
+	currentUser func() (string, error)
+	executable  func() (string, error)
+	launchctl   func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// NewServiceManager creates a new service manager for the current platform.
+func NewServiceManager() ServiceManager {
+	return &LaunchdServiceManager{
+		daemonDir:   daemonDir,
+		agentDir:    launchAgentDir,
+		uid:         os.Getuid(),
+		currentUser: currentUser,
+		executable:  os.Executable,
+		launchctl:   runLaunchctl,
+	}
+}
+
+// IsSupported returns true on macOS.
+func (l *LaunchdServiceManager) IsSupported() bool {
+	return true
+}
+
+// Install writes the job's property list. It is loaded on the next login
+// or boot if opts.AutoStart is set, or by Start.
+func (l *LaunchdServiceManager) Install(ctx context.Context, opts InstallOptions) error {
+	if f, err := l.installed(); err != nil {
+		return err
+	} else if f != nil {
+		return fmt.Errorf("service %s already exists at %s", launchdLabel, f.path)
+	}
+
+	f, account, err := l.target(opts)
+	if err != nil {
+		return err
+	}
+	exePath, err := absExecutable(l.executable)
+	if err != nil {
+		return err
+	}
+
+	job := &launchdJob{
+		Label:            launchdLabel,
+		ProgramArguments: append([]string{exePath}, serviceArgs(opts)...),
+		UserName:         account,
+		RunAtLoad:        opts.AutoStart,
+	}
+	if err := writeJob(f.path, job); err != nil {
+		return err
+	}
+
+	fmt.Printf("Service installed: %s\n", f.path)
+	return nil
+}
+
+// Update rewrites the command line of the installed job, and its account if
+// opts has a scope, keeping its location. A loaded job picks it up when
+// started again.
+func (l *LaunchdServiceManager) Update(ctx context.Context, opts InstallOptions) error {
+	f, err := l.installed()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("service %s not found", launchdLabel)
+	}
+
+	job, err := readJob(f.path)
+	if err != nil {
+		return err
+	}
+	if opts.Scope != "" {
+		target, account, err := l.target(opts)
+		if err != nil {
+			return err
+		}
+		if target.path != f.path {
+			return fmt.Errorf("the service is installed at %s, uninstall it and install it again to move it to %s", f.path, target.path)
+		}
+		job.UserName = account
+	}
+	exePath, err := absExecutable(l.executable)
+	if err != nil {
+		return err
+	}
+	job.ProgramArguments = append([]string{exePath}, serviceArgs(opts)...)
+
+	if err := writeJob(f.path, job); err != nil {
+		return err
+	}
+
+	fmt.Printf("Service updated: %s\n", f.path)
+	return nil
+}
+
+// Uninstall unloads the job, stopping it, and removes its property list.
+func (l *LaunchdServiceManager) Uninstall(ctx context.Context) error {
+	f, err := l.installed()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("service %s not found", launchdLabel)
+	}
+
+	if l.loaded(ctx, f) {
+		if _, err := l.launchctl(ctx, "bootout", l.service(f)); err != nil {
+			fmt.Printf("Warning: failed to stop service: %v\n", err)
+		}
+	}
+	if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	return nil
+}
+
+// Start loads the job if it isn't, and starts it.
+func (l *LaunchdServiceManager) Start(ctx context.Context) error {
+	f, err := l.installed()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("service %s not found", launchdLabel)
+	}
+
+	if !l.loaded(ctx, f) {
+		if _, err := l.launchctl(ctx, "bootstrap", l.domain(f), f.path); err != nil {
+			return fmt.Errorf("failed to load service: %w", err)
+		}
+	}
+	// Starts the job unless running already, as after loading it with
+	// RunAtLoad
+	if _, err := l.launchctl(ctx, "kickstart", l.service(f)); err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	return nil
+}
+
+// Stop unloads the job, which launchd would otherwise restart, waiting for
+// it to exit.
+func (l *LaunchdServiceManager) Stop(ctx context.Context) error {
+	f, err := l.installed()
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("service %s not found", launchdLabel)
+	}
+	if !l.loaded(ctx, f) {
+		return fmt.Errorf("service %s is not running", launchdLabel)
+	}
+	if _, err := l.launchctl(ctx, "bootout", l.service(f)); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+	return nil
+}
+
+// Status returns the current service status.
+func (l *LaunchdServiceManager) Status(ctx context.Context) (*ServiceStatus, error) {
+	f, err := l.installed()
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return &ServiceStatus{
+			State:   ServiceStateNotInstalled,
+			Message: "Service is not installed",
+		}, nil
+	}
+
+	result := &ServiceStatus{State: ServiceStateStopped, Message: "Service is not loaded"}
+	if out, err := l.launchctl(ctx, "print", l.service(f)); err == nil {
+		props := parseLaunchctlPrint(out)
+		result.State = jobState(props["state"])
+		result.Message = ""
+		if result.State == ServiceStateRunning {
+			result.PID, _ = strconv.Atoi(props["pid"])
+		} else if code := props["last exit code"]; code != "" && code != "0" && code != "(never exited)" {
+			result.Message = fmt.Sprintf("Service exited with %s", code)
+		}
+	}
+
+	// The options the service was installed with are in its property list
+	if job, err := readJob(f.path); err == nil {
+		if len(job.ProgramArguments) > 0 {
+			result.BinaryPath, result.Args = job.ProgramArguments[0], job.ProgramArguments[1:]
+			result.ConfigPath, result.Portable = parseServiceArgs(result.Args)
+		}
+		result.Account = job.UserName
+		result.StartType = "manual"
+		if job.RunAtLoad {
+			result.StartType = "automatic"
+		}
+	}
+	switch {
+	case f.agent:
+		result.Scope = ServiceScopeUser
+		result.Account, _ = l.currentUser()
+	case result.Account != "":
+		result.Scope = ServiceScopeUser
+	default:
+		result.Scope = ServiceScopeSystem
+		result.Account = "root"
+	}
+
+	return result, nil
+}
+
+// installed returns the property list of the installed job, an agent before
+// a daemon, or nil if there is none.
+func (l *LaunchdServiceManager) installed() (*jobFile, error) {
+	files := []jobFile{{path: filepath.Join(l.daemonDir, launchdLabel+".plist")}}
+	if dir, err := l.agentDir(); err == nil {
+		files = append([]jobFile{{path: filepath.Join(dir, launchdLabel+".plist"), agent: true}}, files...)
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil {
+			return &f, nil
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read launchd job: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// target returns where the job for opts goes and the account it runs as: an
+// agent of the current user, or a daemon running as root or as another
+// user.
+func (l *LaunchdServiceManager) target(opts InstallOptions) (*jobFile, string, error) {
+	daemon := &jobFile{path: filepath.Join(l.daemonDir, launchdLabel+".plist")}
+	switch opts.Scope {
+	case ServiceScopeSystem:
+		if opts.Username != "" {
+			return nil, "", fmt.Errorf("a username can't be given in the system scope")
+		}
+		return daemon, "", nil
+	case ServiceScopeUser:
+		current, err := l.currentUser()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine current user: %w", err)
+		}
+		if opts.Username != "" && opts.Username != current {
+			return daemon, opts.Username, nil
+		}
+		dir, err := l.agentDir()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine LaunchAgents directory: %w", err)
+		}
+		return &jobFile{path: filepath.Join(dir, launchdLabel+".plist"), agent: true}, "", nil
+	default:
+		return nil, "", fmt.Errorf("invalid scope %q: must be user or system", opts.Scope)
+	}
+}
+
+// domain returns the launchd domain of the job: the user's GUI session for
+// an agent, or the system.
+func (l *LaunchdServiceManager) domain(f *jobFile) string {
+	if f.agent {
+		return fmt.Sprintf("gui/%d", l.uid)
+	}
+	return "system"
+}
+
+// service returns the launchctl target of the job.
+func (l *LaunchdServiceManager) service(f *jobFile) string {
+	return l.domain(f) + "/" + launchdLabel
+}
+
+// loaded returns true if the job is loaded.
+func (l *LaunchdServiceManager) loaded(ctx context.Context, f *jobFile) bool {
+	_, err := l.launchctl(ctx, "print", l.service(f))
+	return err == nil
+}
+
+// writeJob writes the property list of job at path.
+func writeJob(path string, job *launchdJob) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+	// #nosec G306 -- launchd refuses property lists writable by others, and
+	// they contain no secrets
+	if err := os.WriteFile(path, job.marshal(), 0644); err != nil {
+		return fmt.Errorf("failed to write launchd job: %w", err)
+	}
+	return nil
+}
+
+// readJob reads the property list of the job at path.
+func readJob(path string) (*launchdJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read launchd job: %w", err)
+	}
+	return parseLaunchdJob(data)
+}
+
+// launchAgentDir returns the directory of the current user's LaunchAgents.
+func launchAgentDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents"), nil
+}
+
+// runLaunchctl runs launchctl with args and returns its output.
+func runLaunchctl(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "launchctl", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("launchctl %s: %w: %s", strings.Join(args, " "), err, msg)
+		}
+		return nil, fmt.Errorf("launchctl %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLaunchd is a LaunchdServiceManager on temporary directories recording
+// the launchctl commands run, with the job loaded while loaded is set.
+type fakeLaunchd struct {
+	*LaunchdServiceManager
+	calls  []string
+	loaded bool
+	print  string
+}
+
+func newFakeLaunchd(t *testing.T) *fakeLaunchd {
+	dir := t.TempDir()
+	f := &fakeLaunchd{}
+	f.LaunchdServiceManager = &LaunchdServiceManager{
+		daemonDir:   filepath.Join(dir, "LaunchDaemons"),
+		agentDir:    func() (string, error) { return filepath.Join(dir, "LaunchAgents"), nil },
+		uid:         501,
+		currentUser: func() (string, error) { return "me", nil },
+		executable:  func() (string, error) { return "/usr/local/bin/ludusavi-runner", nil },
+		launchctl: func(ctx context.Context, args ...string) ([]byte, error) {
+			f.calls = append(f.calls, strings.Join(args, " "))
+			switch args[0] {
+			case "print":
+				if !f.loaded {
+					return nil, errors.New("could not find service")
+				}
+				return []byte(f.print), nil
+			case "bootstrap":
+				f.loaded = true
+			case "bootout":
+				f.loaded = false
+			}
+			return nil, nil
+		},
+	}
+	return f
+}
+
+func TestLaunchdServiceManager(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLaunchd(t)
+	service := "gui/501/" + launchdLabel
+
+	status, err := f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateNotInstalled, status.State)
+
+	require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, ConfigPath: "/Users/me/config.toml", AutoStart: true}))
+	assert.Empty(t, f.calls, "installing doesn't load the job")
+	dir, _ := f.agentDir()
+	job, err := readJob(filepath.Join(dir, launchdLabel+".plist"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/usr/local/bin/ludusavi-runner", "serve", "--config", "/Users/me/config.toml"}, job.ProgramArguments)
+	assert.True(t, job.RunAtLoad)
+
+	status, err = f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateStopped, status.State)
+	assert.Equal(t, ServiceScopeUser, status.Scope)
+	assert.Equal(t, "/Users/me/config.toml", status.ConfigPath)
+	assert.Equal(t, "automatic", status.StartType)
+
+	f.calls = nil
+	require.NoError(t, f.Start(ctx))
+	assert.Equal(t, []string{"print " + service, "bootstrap gui/501 " + filepath.Join(dir, launchdLabel+".plist"), "kickstart " + service}, f.calls)
+
+	f.print = "\tstate = running\n\tpid = 4242\n"
+	status, err = f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateRunning, status.State)
+	assert.Equal(t, 4242, status.PID)
+
+	f.calls = nil
+	require.NoError(t, f.Stop(ctx))
+	assert.Equal(t, []string{"print " + service, "bootout " + service}, f.calls)
+
+	require.NoError(t, f.Uninstall(ctx))
+	assert.NoFileExists(t, filepath.Join(dir, launchdLabel+".plist"))
+}
+
+func TestLaunchdServiceManager_Daemon(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLaunchd(t)
+
+	require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, Username: "gamer"}))
+	path := filepath.Join(f.daemonDir, launchdLabel+".plist")
+	job, err := readJob(path)
+	require.NoError(t, err)
+	assert.Equal(t, "gamer", job.UserName)
+
+	require.NoError(t, f.Update(ctx, InstallOptions{ConfigPath: "/etc/ludusavi.toml"}))
+	job, err = readJob(path)
+	require.NoError(t, err)
+	assert.Equal(t, "gamer", job.UserName, "the account is kept without a scope")
+	assert.Equal(t, "/etc/ludusavi.toml", job.ProgramArguments[3])
+
+	assert.ErrorContains(t, f.Update(ctx, InstallOptions{Scope: ServiceScopeUser}), "uninstall it and install it again")
+
+	require.NoError(t, f.Start(ctx))
+	assert.Contains(t, f.calls, "bootstrap system "+path)
+
+	require.NoError(t, f.Uninstall(ctx))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,55 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLaunchdJob(t *testing.T) {
+	job := &launchdJob{
+		Label:            "io.github.sharkusmanch.ludusavi-runner",
+		ProgramArguments: []string{"/Applications/Ludusavi Runner/ludusavi-runner", "serve", "--config", "/Users/me/<saves> & config.toml"},
+		UserName:         "gamer",
+		RunAtLoad:        true,
+	}
+	data := job.marshal()
+	assert.Contains(t, string(data), "<string>/Users/me/&lt;saves&gt; &amp; config.toml</string>")
+	assert.Contains(t, string(data), "<key>SuccessfulExit</key>")
+
+	parsed, err := parseLaunchdJob(data)
+	require.NoError(t, err)
+	assert.Equal(t, job, parsed)
+
+	job.UserName, job.RunAtLoad = "", false
+	parsed, err = parseLaunchdJob(job.marshal())
+	require.NoError(t, err)
+	assert.Equal(t, job, parsed)
+
+	_, err = parseLaunchdJob([]byte("not a plist"))
+	assert.Error(t, err)
+}
+
+func TestParseLaunchctlPrint(t *testing.T) {
+	out := "gui/501/io.github.sharkusmanch.ludusavi-runner = {\n" +
+		"\tactive count = 1\n" +
+		"\tpath = /Users/me/Library/LaunchAgents/io.github.sharkusmanch.ludusavi-runner.plist\n" +
+		"\tstate = running\n" +
+		"\n" +
+		"\tprogram = /usr/local/bin/ludusavi-runner\n" +
+		"\targuments = {\n" +
+		"\t\tstate = nested\n" +
+		"\t}\n" +
+		"\tpid = 4242\n" +
+		"\tlast exit code = (never exited)\n" +
+		"}\n"
+	props := parseLaunchctlPrint([]byte(out))
+	assert.Equal(t, "running", props["state"])
+	assert.Equal(t, "4242", props["pid"])
+	assert.Equal(t, "(never exited)", props["last exit code"])
+
+	assert.Equal(t, ServiceStateRunning, jobState("running"))
+	assert.Equal(t, ServiceStateStopped, jobState("not running"))
+	assert.Equal(t, ServiceStateUnknown, jobState("confused"))
+}
@@ -0,0 +1,78 @@
+package platform
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxShortPath is the length from which paths get the extended-length prefix.
+// Win32 limits paths to MAX_PATH (260) characters, and directories to that
+// less room for an 8.3 file name.
+const maxShortPath = 248
+
+// LongPath returns path in its extended-length form (\\?\C:\... or
+// \\?\UNC\server\share\...) on Windows when it is too long for the MAX_PATH
+// limit, for passing to Win32 APIs and child processes that don't add the
+// prefix themselves; the os package already does this for its own calls.
+// Relative, short and already prefixed paths are returned unchanged, as are
+// all paths on other platforms.
+func LongPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	return extendedLengthPath(path)
+}
+
+// SamePath reports whether two absolute paths name the same file, ignoring
+// case on Windows, where the file system does.
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// extendedLengthPath implements LongPath for Windows paths. The length is
+// measured in bytes, which overestimates the UTF-16 length Windows checks for
+// non-ASCII paths, so those are prefixed a little early rather than too late.
+func extendedLengthPath(path string) string {
+	if len(path) < maxShortPath || strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+
+	// Extended-length paths are passed to the file system as is, so they
+	// must use backslashes and can't contain . or .. elements
+	p := strings.ReplaceAll(path, "/", `\`)
+	var prefix string
+	var root int
+	switch {
+	case len(p) >= 3 && isDriveLetter(p[0]) && p[1] == ':' && p[2] == '\\':
+		prefix, p = `\\?\`+p[:2], p[3:]
+	case strings.HasPrefix(p, `\\`) && !strings.HasPrefix(p, `\\?\`) && !strings.HasPrefix(p, `\\.\`):
+		// Keep the server and share when resolving ..
+		prefix, p, root = `\\?\UNC`, p[2:], 2
+	default:
+		return path
+	}
+
+	var elems []string
+	for _, elem := range strings.Split(p, `\`) {
+		switch elem {
+		case "", ".":
+		case "..":
+			if len(elems) > root {
+				elems = elems[:len(elems)-1]
+			}
+		default:
+			elems = append(elems, elem)
+		}
+	}
+	return prefix + `\` + strings.Join(elems, `\`)
+}
+
+// isDriveLetter reports whether c is an ASCII letter.
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
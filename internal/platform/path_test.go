@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtendedLengthPath(t *testing.T) {
+	long := strings.Repeat(`ファイナルファンタジー\`, 12)
+	trimmed := strings.TrimSuffix(long, `\`)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"short path", `C:\Games\ludusavi.exe`, `C:\Games\ludusavi.exe`},
+		{"drive path", `C:\` + long + `save.dat`, `\\?\C:\` + long + `save.dat`},
+		{"forward slashes", `c:/` + strings.ReplaceAll(long, `\`, "/") + `save.dat`, `\\?\c:\` + long + `save.dat`},
+		{"dot elements", `C:\` + long + `.\tmp\..\save.dat`, `\\?\C:\` + long + `save.dat`},
+		{"trailing separator", `C:\` + long, `\\?\C:\` + trimmed},
+		{"unc path", `\\nas\saves\` + long + `save.dat`, `\\?\UNC\nas\saves\` + long + `save.dat`},
+		{"unc path keeps share", `\\nas\saves\..\..\..\` + long, `\\?\UNC\nas\saves\` + trimmed},
+		{"already prefixed", `\\?\C:\` + long, `\\?\C:\` + long},
+		{"device path", `\\.\` + long, `\\.\` + long},
+		{"relative path", long + `save.dat`, long + `save.dat`},
+		{"drive relative path", `C:` + long, `C:` + long},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extendedLengthPath(tt.path))
+		})
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	path := `C:\` + strings.Repeat(`セーブデータ\`, 20) + "save.dat"
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, `\\?\`+path, LongPath(path))
+	} else {
+		assert.Equal(t, path, LongPath(path))
+	}
+}
@@ -0,0 +1,23 @@
+package platform
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// processName returns the name processes of the executable at path run
+// under: its base name without the .exe extension.
+func processName(path string) string {
+	name := filepath.Base(path)
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// FindProcesses returns the PIDs of the running processes of the executable
+// at path, or named path, other than this process and those it started,
+// such as a ludusavi GUI opened next to the runner.
+func FindProcesses(path string) ([]int, error) {
+	return findProcesses(processName(path))
+}
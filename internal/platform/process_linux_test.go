@@ -0,0 +1,41 @@
+package platform
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProcesses(t *testing.T) {
+	// A child of this process is left out, one started by another is found
+	child := exec.Command("sleep", "30")
+	require.NoError(t, child.Start())
+	t.Cleanup(func() { _ = child.Process.Kill(); _ = child.Wait() })
+
+	shell := exec.Command("sh", "-c", "sleep 30; true")
+	require.NoError(t, shell.Start())
+	t.Cleanup(func() { _ = shell.Process.Kill(); _ = shell.Wait() })
+
+	var pids []int
+	require.Eventually(t, func() bool {
+		var err error
+		pids, err = FindProcesses("/usr/bin/sleep")
+		require.NoError(t, err)
+		return len(pids) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, pids, child.Process.Pid)
+	assert.NotContains(t, pids, shell.Process.Pid)
+
+	pids, err := FindProcesses("no-such-process")
+	require.NoError(t, err)
+	assert.Empty(t, pids)
+}
+
+func TestProcessName(t *testing.T) {
+	assert.Equal(t, "ludusavi", processName("ludusavi"))
+	assert.Equal(t, "ludusavi", processName("/opt/ludusavi/ludusavi"))
+	assert.Equal(t, "ludusavi", processName("ludusavi.EXE"))
+}
@@ -0,0 +1,45 @@
+//go:build !linux && !windows
+
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// findProcesses lists processes with ps, which has no stable API to read
+// them from on BSD and macOS.
+func findProcesses(name string) ([]int, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	self := os.Getpid()
+	var pids []int
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil || pid == self {
+			continue
+		}
+		if ppid, _ := strconv.Atoi(fields[1]); ppid == self {
+			continue
+		}
+		// comm is the path the process was started with, which may
+		// contain spaces
+		if processName(strings.Join(fields[2:], " ")) == name {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, scanner.Err()
+}
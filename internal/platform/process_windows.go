@@ -0,0 +1,35 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// findProcesses lists processes with a Toolhelp snapshot.
+func findProcesses(name string) ([]int, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	defer windows.CloseHandle(snapshot)
+
+	self := uint32(os.Getpid())
+	var pids []int
+	entry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		if entry.ProcessID == self || entry.ParentProcessID == self {
+			continue
+		}
+		if strings.EqualFold(processName(windows.UTF16ToString(entry.ExeFile[:])), name) {
+			pids = append(pids, int(entry.ProcessID))
+		}
+	}
+	if err != windows.ERROR_NO_MORE_FILES {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	return pids, nil
+}
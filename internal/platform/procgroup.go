@@ -0,0 +1,21 @@
+package platform
+
+import (
+	"strings"
+	"time"
+)
+
+// orphanWaitDelay bounds how long RunProcessGroup waits for the output of a
+// command once it has exited, when processes it left behind still hold its
+// stdout or stderr open.
+const orphanWaitDelay = 5 * time.Second
+
+// LastLine returns the last line of the output of a command, without
+// surrounding whitespace: usually the error of a command that failed.
+func LastLine(output string) string {
+	output = strings.TrimSpace(output)
+	if i := strings.LastIndexByte(output, '\n'); i >= 0 {
+		return strings.TrimSpace(output[i+1:])
+	}
+	return output
+}
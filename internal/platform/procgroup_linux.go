@@ -0,0 +1,8 @@
+package platform
+
+import "syscall"
+
+// setDeathSignal has the kernel kill the command if the runner dies.
+func setDeathSignal(attr *syscall.SysProcAttr) {
+	attr.Pdeathsig = syscall.SIGKILL
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !linux
+
+package platform
+
+import "syscall"
+
+// setDeathSignal does nothing, as only Linux can kill a command when its
+// parent dies.
+func setDeathSignal(*syscall.SysProcAttr) {}
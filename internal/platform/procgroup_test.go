@@ -0,0 +1,13 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastLine(t *testing.T) {
+	assert.Equal(t, "", LastLine(""))
+	assert.Equal(t, "permission denied", LastLine("permission denied"))
+	assert.Equal(t, "exit 2: no such file", LastLine("copying saves\r\nexit 2: no such file \r\n\n"))
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package platform
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// RunProcessGroup runs cmd in a new process group, along with every process
+// it starts, such as the rclone transfers of a ludusavi cloud upload. The
+// whole group is killed when the command's context is cancelled, and any
+// processes left behind once the command exits are killed too. On Linux the
+// command is also killed if the runner itself dies.
+func RunProcessGroup(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	setDeathSignal(cmd.SysProcAttr)
+
+	// Only commands created with exec.CommandContext can be cancelled
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error {
+			return killGroup(cmd.Process.Pid)
+		}
+	}
+	cmd.WaitDelay = orphanWaitDelay
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	err := cmd.Wait()
+
+	// The group ID is the command's PID, so it stays valid while any
+	// process is left in the group
+	_ = killGroup(cmd.Process.Pid)
+	return err
+}
+
+// killGroup kills every process in the process group pgid.
+func killGroup(pgid int) error {
+	err := syscall.Kill(-pgid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package platform
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alive reports whether the process pid still runs. Zombies left for a
+// non-reaping init count as dead.
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat))
+	return len(fields) < 3 || fields[2] != "Z"
+}
+
+func TestRunProcessGroup_KillsOrphans(t *testing.T) {
+	pidFile := t.TempDir() + "/pid"
+	// The shell exits right away, leaving sleep behind
+	cmd := exec.Command("sh", "-c", "sleep 60 >/dev/null 2>&1 & echo $! > "+pidFile)
+	require.NoError(t, RunProcessGroup(cmd))
+
+	data, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return !alive(pid) }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRunProcessGroup_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 60 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+
+	pids := make(chan int, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		pid, _ := strconv.Atoi(strings.TrimSpace(line))
+		pids <- pid
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- RunProcessGroup(cmd) }()
+
+	var pid int
+	select {
+	case pid = <-pids:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not start")
+	}
+	require.Positive(t, pid)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled command did not return")
+	}
+	// The shell's child is killed along with it
+	assert.Eventually(t, func() bool { return !alive(pid) }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// RunProcessGroup runs cmd in a job object, along with every process it
+// starts, such as the rclone transfers of a ludusavi cloud upload. The job
+// is terminated when the command's context is cancelled, and any processes
+// left in it once the command exits are killed when the job is closed. As
+// the job handle is closed when the runner exits, this also holds if the
+// runner dies.
+func RunProcessGroup(cmd *exec.Cmd) error {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create job object: %w", err)
+	}
+	defer func() { _ = windows.CloseHandle(job) }()
+
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		return fmt.Errorf("failed to configure job object: %w", err)
+	}
+
+	// Only commands created with exec.CommandContext can be cancelled
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error {
+			return windows.TerminateJobObject(job, 1)
+		}
+	}
+	cmd.WaitDelay = orphanWaitDelay
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// The command runs briefly before it is assigned, but ludusavi only
+	// starts rclone once it has scanned for saves
+	if err := assignToJob(job, cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	return cmd.Wait()
+}
+
+// assignToJob assigns the process pid to job.
+func assignToJob(job windows.Handle, pid int) error {
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %w", err)
+	}
+	defer func() { _ = windows.CloseHandle(process) }()
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		return fmt.Errorf("failed to assign process to job object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ErrSandboxUnsupported is returned by Sandbox on platforms it can't
+// restrict commands on.
+var ErrSandboxUnsupported = errors.New("sandboxing is not supported on this platform")
+
+// restrictedEnvNames are the variables of the runner's environment passed
+// on by RestrictedEnviron: what ludusavi and rclone need to find the user's
+// directories, temporary files and locale. On Windows, names are matched
+// case-insensitively.
+var restrictedEnvNames = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "LANG", "TZ", "TMPDIR", "WINEPREFIX",
+	// Windows
+	"SystemRoot", "SystemDrive", "windir", "ComSpec", "PATHEXT", "TEMP", "TMP",
+	"USERNAME", "USERPROFILE", "HOMEDRIVE", "HOMEPATH", "APPDATA", "LOCALAPPDATA",
+	"ProgramData", "ProgramFiles", "ProgramFiles(x86)", "PUBLIC",
+}
+
+// restrictedEnvPrefixes are the prefixes of the variables of the runner's
+// environment passed on by RestrictedEnviron.
+var restrictedEnvPrefixes = []string{"LC_", "XDG_"}
+
+// RestrictedEnviron is like Environ, but passes on only the variables of
+// the runner's environment ludusavi needs, so secrets and settings such as
+// proxies or library paths set for the runner don't reach it.
+func RestrictedEnviron(env, vars map[string]string) []string {
+	environ := []string{}
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if restrictedEnvName(name) {
+			environ = append(environ, entry)
+		}
+	}
+	for name, value := range ExpandEnv(env, vars) {
+		environ = append(environ, name+"="+value)
+	}
+	return environ
+}
+
+// restrictedEnvName returns true if the variable name is passed on by
+// RestrictedEnviron.
+func restrictedEnvName(name string) bool {
+	for _, prefix := range restrictedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, allowed := range restrictedEnvNames {
+		if name == allowed || (runtime.GOOS == "windows" && strings.EqualFold(name, allowed)) {
+			return true
+		}
+	}
+	return false
+}
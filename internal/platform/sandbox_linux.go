@@ -0,0 +1,49 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxExecArg is the first argument of the runner started to restrict
+// itself and then execute a sandboxed command, as set up by Sandbox.
+const sandboxExecArg = "__sandbox-exec"
+
+// Sandbox has cmd started through the runner itself, which sets
+// no-new-privileges before replacing itself with the command, so neither it
+// nor anything it starts can gain privileges through setuid binaries or file
+// capabilities. The returned function releases what Sandbox allocated, once
+// cmd has exited.
+func Sandbox(cmd *exec.Cmd) (func(), error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	cmd.Args = append([]string{self, sandboxExecArg, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+	return func() {}, nil
+}
+
+// HandleSandboxExec restricts this process and executes the command given
+// in its arguments if it was started by Sandbox, and returns otherwise. It
+// must be called first thing in main.
+func HandleSandboxExec() {
+	if len(os.Args) < 3 || os.Args[1] != sandboxExecArg {
+		return
+	}
+	// The exit code of a command that can't be executed, as in shells
+	const cannotExecute = 126
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set no-new-privileges: %v\n", err)
+		os.Exit(cannotExecute)
+	}
+	// #nosec G204 -- the command was set up by Sandbox
+	err := syscall.Exec(os.Args[2], os.Args[2:], os.Environ())
+	fmt.Fprintf(os.Stderr, "failed to execute %s: %v\n", os.Args[2], err)
+	os.Exit(cannotExecute)
+}
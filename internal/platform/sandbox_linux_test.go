@@ -0,0 +1,36 @@
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary act as the runner executing sandboxed
+// commands.
+func TestMain(m *testing.M) {
+	HandleSandboxExec()
+	os.Exit(m.Run())
+}
+
+func TestSandbox(t *testing.T) {
+	cmd := exec.Command("grep", "NoNewPrivs", "/proc/self/status")
+	release, err := Sandbox(cmd)
+	require.NoError(t, err)
+	defer release()
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "NoNewPrivs:\t1\n", string(out))
+
+	// Failing to execute the command is reported like a shell does
+	cmd = exec.Command("/nonexistent/ludusavi")
+	_, err = Sandbox(cmd)
+	require.NoError(t, err)
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, cmd.Run(), &exitErr)
+	assert.Equal(t, 126, exitErr.ExitCode())
+}
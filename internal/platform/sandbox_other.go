@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+
+package platform
+
+import "os/exec"
+
+// Sandbox is not supported on this platform.
+func Sandbox(cmd *exec.Cmd) (func(), error) {
+	return nil, ErrSandboxUnsupported
+}
+
+// HandleSandboxExec does nothing on this platform.
+func HandleSandboxExec() {}
@@ -0,0 +1,46 @@
+package platform
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// disableMaxPrivilege has CreateRestrictedToken remove every privilege but
+// SeChangeNotifyPrivilege.
+const disableMaxPrivilege = 0x1
+
+var procCreateRestrictedToken = windows.NewLazySystemDLL("advapi32.dll").NewProc("CreateRestrictedToken")
+
+// Sandbox has cmd run with a restricted copy of the runner's token, without
+// its privileges, such as those of an elevated or LocalSystem service to
+// back up or restore any file, take ownership or debug processes. The
+// returned function closes the token, once cmd has exited.
+func Sandbox(cmd *exec.Cmd) (func(), error) {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(),
+		windows.TOKEN_DUPLICATE|windows.TOKEN_QUERY|windows.TOKEN_ASSIGN_PRIMARY, &token); err != nil {
+		return nil, fmt.Errorf("failed to open process token: %w", err)
+	}
+	defer token.Close()
+
+	var restricted windows.Token
+	r, _, err := procCreateRestrictedToken.Call(uintptr(token), disableMaxPrivilege,
+		0, 0, 0, 0, 0, 0, uintptr(unsafe.Pointer(&restricted)))
+	if r == 0 {
+		return nil, fmt.Errorf("failed to create restricted token: %w", err)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Token = syscall.Token(restricted)
+	return func() { _ = restricted.Close() }, nil
+}
+
+// HandleSandboxExec does nothing on Windows, where Sandbox starts commands
+// directly.
+func HandleSandboxExec() {}
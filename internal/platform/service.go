@@ -3,6 +3,10 @@ package platform
 
 import (
 	"context"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strings"
 
 	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
 )
@@ -16,6 +20,15 @@ type ServiceStatus = domain.ServiceStatus
 // ServiceState represents service state.
 type ServiceState = domain.ServiceState
 
+// ServiceScope is whether a service runs for a user or the whole system.
+type ServiceScope = domain.ServiceScope
+
+// Service scope constants.
+const (
+	ServiceScopeUser   = domain.ServiceScopeUser
+	ServiceScopeSystem = domain.ServiceScopeSystem
+)
+
 // Service state constants.
 const (
 	ServiceStateUnknown      = domain.ServiceStateUnknown
@@ -29,9 +42,103 @@ const (
 // ServiceManager defines the interface for managing system services.
 type ServiceManager interface {
 	Install(ctx context.Context, opts InstallOptions) error
+	Update(ctx context.Context, opts InstallOptions) error
 	Uninstall(ctx context.Context) error
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
 	Status(ctx context.Context) (*ServiceStatus, error)
 	IsSupported() bool
 }
+
+// absExecutable returns the absolute path of the running executable, as
+// returned by executable.
+func absExecutable(executable func() (string, error)) (string, error) {
+	exePath, err := executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return exePath, nil
+}
+
+// currentUser returns the name of the current user, as DOMAIN\user on
+// Windows.
+func currentUser() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// serviceArgs returns the arguments the service is started with for opts.
+func serviceArgs(opts InstallOptions) []string {
+	args := []string{"serve"}
+	if opts.ConfigPath != "" {
+		args = append(args, "--config", opts.ConfigPath)
+	}
+	if opts.Portable {
+		args = append(args, "--portable")
+	}
+	return args
+}
+
+// parseServiceArgs returns the config path and portable mode of the
+// arguments a service is started with, as built by serviceArgs.
+func parseServiceArgs(args []string) (configPath string, portable bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--config" || arg == "-c":
+			if i+1 < len(args) {
+				configPath = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--config="):
+			configPath = strings.TrimPrefix(arg, "--config=")
+		case arg == "--portable" || arg == "--portable=true":
+			portable = true
+		}
+	}
+	return configPath, portable
+}
+
+// serviceAccount returns the account a service installed with opts runs
+// as: empty for the system account, or opts.Username or else the current
+// user, as returned by currentUser, in the user scope.
+func serviceAccount(opts InstallOptions, currentUser func() (string, error)) (string, error) {
+	switch opts.Scope {
+	case ServiceScopeSystem:
+		if opts.Username != "" {
+			return "", fmt.Errorf("a username can't be given in the system scope")
+		}
+		return "", nil
+	case ServiceScopeUser:
+		account := opts.Username
+		if account == "" {
+			var err error
+			if account, err = currentUser(); err != nil {
+				return "", fmt.Errorf("failed to determine current user: %w", err)
+			}
+		}
+		if opts.Password == "" {
+			return "", fmt.Errorf("a password is required to run the service as %s", account)
+		}
+		return account, nil
+	default:
+		return "", fmt.Errorf("invalid scope %q: must be user or system", opts.Scope)
+	}
+}
+
+// accountScope returns the scope of a service running as account.
+func accountScope(account string) ServiceScope {
+	switch strings.ToLower(account) {
+	case "", "localsystem", `nt authority\system`, `nt authority\localservice`, `nt authority\networkservice`:
+		return ServiceScopeSystem
+	default:
+		return ServiceScopeUser
+	}
+}
@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServiceArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantConfig   string
+		wantPortable bool
+	}{
+		{"no options", []string{"serve"}, "", false},
+		{"config", []string{"serve", "--config", `C:\ludusavi\config.toml`}, `C:\ludusavi\config.toml`, false},
+		{"short config", []string{"serve", "-c", "/etc/ludusavi.toml"}, "/etc/ludusavi.toml", false},
+		{"config with equals", []string{"serve", "--config=/etc/ludusavi.toml", "--portable"}, "/etc/ludusavi.toml", true},
+		{"portable", []string{"serve", "--portable", "--config", "config.toml"}, "config.toml", true},
+		{"config without value", []string{"serve", "--config"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, portable := parseServiceArgs(tt.args)
+			assert.Equal(t, tt.wantConfig, configPath)
+			assert.Equal(t, tt.wantPortable, portable)
+		})
+	}
+
+	// What Install writes is read back
+	opts := InstallOptions{ConfigPath: `C:\Users\me\config.toml`, Portable: true}
+	configPath, portable := parseServiceArgs(serviceArgs(opts))
+	assert.Equal(t, opts.ConfigPath, configPath)
+	assert.True(t, portable)
+}
+
+func TestSamePath(t *testing.T) {
+	assert.True(t, SamePath("/etc/ludusavi/config.toml", "/etc/ludusavi/../ludusavi/config.toml"))
+	assert.False(t, SamePath("/etc/ludusavi/config.toml", "/etc/ludusavi/other.toml"))
+}
+
+func TestServiceAccount(t *testing.T) {
+	currentUser := func() (string, error) { return `DESKTOP\me`, nil }
+
+	account, err := serviceAccount(InstallOptions{Scope: ServiceScopeSystem}, currentUser)
+	require.NoError(t, err)
+	assert.Empty(t, account)
+
+	_, err = serviceAccount(InstallOptions{Scope: ServiceScopeSystem, Username: "me"}, currentUser)
+	assert.ErrorContains(t, err, "a username can't be given in the system scope")
+
+	account, err = serviceAccount(InstallOptions{Scope: ServiceScopeUser, Password: "secret"}, currentUser)
+	require.NoError(t, err)
+	assert.Equal(t, `DESKTOP\me`, account)
+
+	account, err = serviceAccount(InstallOptions{Scope: ServiceScopeUser, Username: `.\gamer`, Password: "secret"}, currentUser)
+	require.NoError(t, err)
+	assert.Equal(t, `.\gamer`, account)
+
+	_, err = serviceAccount(InstallOptions{Scope: ServiceScopeUser}, currentUser)
+	assert.ErrorContains(t, err, `a password is required to run the service as DESKTOP\me`)
+
+	_, err = serviceAccount(InstallOptions{Scope: ServiceScopeUser, Password: "secret"}, func() (string, error) {
+		return "", errors.New("no user")
+	})
+	assert.ErrorContains(t, err, "failed to determine current user")
+
+	_, err = serviceAccount(InstallOptions{Scope: "global"}, currentUser)
+	assert.ErrorContains(t, err, `invalid scope "global"`)
+}
+
+func TestAccountScope(t *testing.T) {
+	assert.Equal(t, ServiceScopeSystem, accountScope(""))
+	assert.Equal(t, ServiceScopeSystem, accountScope("LocalSystem"))
+	assert.Equal(t, ServiceScopeSystem, accountScope(`NT AUTHORITY\NetworkService`))
+	assert.Equal(t, ServiceScopeUser, accountScope(`.\gamer`))
+}
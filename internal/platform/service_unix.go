@@ -0,0 +1,19 @@
+//go:build !windows
+
+package platform
+
+import (
+	"context"
+	"fmt"
+)
+
+// RunAsService is not needed outside Windows: systemd and launchd run the
+// serve command like a shell does, stopping it with SIGTERM.
+func RunAsService(handler func(ctx context.Context) error) error {
+	return fmt.Errorf("running as a Windows service is only supported on Windows")
+}
+
+// IsRunningAsService returns false outside Windows.
+func IsRunningAsService() bool {
+	return false
+}
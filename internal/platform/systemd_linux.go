@@ -0,0 +1,472 @@
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// unitName is the name of the systemd unit.
+	unitName = "ludusavi-runner.service"
+	// unitDescription describes the systemd unit.
+	unitDescription = "Automated Ludusavi game save backup service"
+	// systemUnitDir holds the units of the system manager.
+	systemUnitDir = "/etc/systemd/system"
+	// systemdRuntimeDir exists if the system was booted with systemd.
+	systemdRuntimeDir = "/run/systemd/system"
+)
+
+// unit is the location of the systemd unit file.
+type unit struct {
+	path string
+	// userManager is set for a unit of the user's service manager, run
+	// with systemctl --user.
+	userManager bool
+}
+
+// SystemdServiceManager manages the service as a systemd unit: a unit of
+// the user's service manager in the user scope, or of the system manager in
+// the system scope or for another user.
+type SystemdServiceManager struct {
+	systemDir   string
+	userDir     func() (string, error)
+	currentUser func() (string, error)
+	executable  func() (string, error)
+	systemctl   func(ctx context.Context, userManager bool, args ...string) ([]byte, error)
+}
+
+// NewServiceManager creates a new service manager for the current platform.
+func NewServiceManager() ServiceManager {
+	return &SystemdServiceManager{
+		systemDir:   systemUnitDir,
+		userDir:     userUnitDir,
+		currentUser: currentUser,
+		executable:  os.Executable,
+		systemctl:   runSystemctl,
+	}
+}
+
+// IsSupported returns true if the system was booted with systemd.
+func (s *SystemdServiceManager) IsSupported() bool {
+	_, err := os.Stat(systemdRuntimeDir)
+	return err == nil
+}
+
+// Install writes the unit file, and enables the unit if opts.AutoStart is
+// set.
+func (s *SystemdServiceManager) Install(ctx context.Context, opts InstallOptions) error {
+	if u, err := s.installed(); err != nil {
+		return err
+	} else if u != nil {
+		return fmt.Errorf("service %s already exists at %s", unitName, u.path)
+	}
+
+	u, account, err := s.target(opts)
+	if err != nil {
+		return err
+	}
+	exePath, err := absExecutable(s.executable)
+	if err != nil {
+		return err
+	}
+
+	content := unitFile(exePath, serviceArgs(opts), account, u.userManager)
+	if err := writeUnit(u.path, content); err != nil {
+		return err
+	}
+	if _, err := s.systemctl(ctx, u.userManager, "daemon-reload"); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	if opts.AutoStart {
+		if _, err := s.systemctl(ctx, u.userManager, "enable", unitName); err != nil {
+			return fmt.Errorf("failed to enable service: %w", err)
+		}
+	}
+
+	fmt.Printf("Service installed: %s\n", u.path)
+	if u.userManager && opts.AutoStart {
+		// User units only run while the user is logged in otherwise
+		fmt.Println("Run 'loginctl enable-linger' to start it at boot without logging in.")
+	}
+	return nil
+}
+
+// Update rewrites the command line of the installed unit, and its account
+// if opts has a scope, keeping its location.
+func (s *SystemdServiceManager) Update(ctx context.Context, opts InstallOptions) error {
+	u, err := s.installed()
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return fmt.Errorf("service %s not found", unitName)
+	}
+
+	_, account, err := parseUnit(u.path)
+	if err != nil {
+		return err
+	}
+	if opts.Scope != "" {
+		target, targetAccount, err := s.target(opts)
+		if err != nil {
+			return err
+		}
+		if target.path != u.path {
+			return fmt.Errorf("the service is installed at %s, uninstall it and install it again to move it to %s", u.path, target.path)
+		}
+		account = targetAccount
+	}
+	exePath, err := absExecutable(s.executable)
+	if err != nil {
+		return err
+	}
+
+	// The unit stays enabled, as its [Install] section doesn't change
+	if err := writeUnit(u.path, unitFile(exePath, serviceArgs(opts), account, u.userManager)); err != nil {
+		return err
+	}
+	if _, err := s.systemctl(ctx, u.userManager, "daemon-reload"); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+
+	fmt.Printf("Service updated: %s\n", u.path)
+	return nil
+}
+
+// Uninstall stops and disables the unit and removes its unit file.
+func (s *SystemdServiceManager) Uninstall(ctx context.Context) error {
+	u, err := s.installed()
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return fmt.Errorf("service %s not found", unitName)
+	}
+
+	if _, err := s.systemctl(ctx, u.userManager, "stop", unitName); err != nil {
+		fmt.Printf("Warning: failed to stop service: %v\n", err)
+	}
+	if _, err := s.systemctl(ctx, u.userManager, "disable", unitName); err != nil {
+		fmt.Printf("Warning: failed to disable service: %v\n", err)
+	}
+	if err := os.Remove(u.path); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	if _, err := s.systemctl(ctx, u.userManager, "daemon-reload"); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	return nil
+}
+
+// Start starts the unit.
+func (s *SystemdServiceManager) Start(ctx context.Context) error {
+	return s.control(ctx, "start")
+}
+
+// Stop stops the unit, waiting for it to exit.
+func (s *SystemdServiceManager) Stop(ctx context.Context) error {
+	return s.control(ctx, "stop")
+}
+
+// control runs systemctl command on the installed unit.
+func (s *SystemdServiceManager) control(ctx context.Context, command string) error {
+	u, err := s.installed()
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return fmt.Errorf("service %s not found", unitName)
+	}
+	if _, err := s.systemctl(ctx, u.userManager, command, unitName); err != nil {
+		return fmt.Errorf("failed to %s service: %w", command, err)
+	}
+	return nil
+}
+
+// Status returns the current service status.
+func (s *SystemdServiceManager) Status(ctx context.Context) (*ServiceStatus, error) {
+	u, err := s.installed()
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return &ServiceStatus{
+			State:   ServiceStateNotInstalled,
+			Message: "Service is not installed",
+		}, nil
+	}
+
+	out, err := s.systemctl(ctx, u.userManager, "show", unitName,
+		"--property=ActiveState,MainPID,ActiveEnterTimestamp,UnitFileState,Result")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service status: %w", err)
+	}
+	props := parseProperties(out)
+
+	result := &ServiceStatus{
+		State:     unitState(props["ActiveState"]),
+		StartType: startTypeName(props["UnitFileState"]),
+	}
+	if result.State == ServiceStateRunning {
+		result.PID, _ = strconv.Atoi(props["MainPID"])
+		result.StartTime = props["ActiveEnterTimestamp"]
+	}
+	if props["ActiveState"] == "failed" {
+		result.Message = fmt.Sprintf("Service failed (%s)", props["Result"])
+	}
+
+	// The options the service was installed with are in its unit file
+	if args, account, err := parseUnit(u.path); err == nil && len(args) > 0 {
+		result.BinaryPath, result.Args = args[0], args[1:]
+		result.ConfigPath, result.Portable = parseServiceArgs(result.Args)
+		result.Account = account
+	}
+	switch {
+	case u.userManager:
+		result.Scope = ServiceScopeUser
+		result.Account, _ = s.currentUser()
+	case result.Account != "":
+		result.Scope = ServiceScopeUser
+	default:
+		result.Scope = ServiceScopeSystem
+		result.Account = "root"
+	}
+
+	return result, nil
+}
+
+// installed returns the unit file of the installed service, a user unit
+// before a system one, or nil if there is none.
+func (s *SystemdServiceManager) installed() (*unit, error) {
+	units := []unit{{path: filepath.Join(s.systemDir, unitName)}}
+	if dir, err := s.userDir(); err == nil {
+		units = append([]unit{{path: filepath.Join(dir, unitName), userManager: true}}, units...)
+	}
+	for _, u := range units {
+		if _, err := os.Stat(u.path); err == nil {
+			return &u, nil
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read unit file: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// target returns where the unit for opts goes and the account it runs as:
+// a user unit for the current user, or a system unit running as root or as
+// another user.
+func (s *SystemdServiceManager) target(opts InstallOptions) (*unit, string, error) {
+	system := &unit{path: filepath.Join(s.systemDir, unitName)}
+	switch opts.Scope {
+	case ServiceScopeSystem:
+		if opts.Username != "" {
+			return nil, "", fmt.Errorf("a username can't be given in the system scope")
+		}
+		return system, "", nil
+	case ServiceScopeUser:
+		current, err := s.currentUser()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine current user: %w", err)
+		}
+		if opts.Username != "" && opts.Username != current {
+			return system, opts.Username, nil
+		}
+		dir, err := s.userDir()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to determine user unit directory: %w", err)
+		}
+		return &unit{path: filepath.Join(dir, unitName), userManager: true}, "", nil
+	default:
+		return nil, "", fmt.Errorf("invalid scope %q: must be user or system", opts.Scope)
+	}
+}
+
+// unitFile returns the unit file running exePath with args, as account
+// unless empty.
+func unitFile(exePath string, args []string, account string, userManager bool) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", unitDescription)
+	if !userManager {
+		// The user manager can't wait for the network
+		b.WriteString("Wants=network-online.target\nAfter=network-online.target\n")
+	}
+
+	b.WriteString("\n[Service]\nType=simple\n")
+	quoted := []string{quoteExecArg(exePath)}
+	for _, arg := range args {
+		quoted = append(quoted, quoteExecArg(arg))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	if account != "" {
+		fmt.Fprintf(&b, "User=%s\n", account)
+	}
+	// Restart like the Windows service does, giving a backup in progress
+	// time to finish on stop
+	b.WriteString("Restart=on-failure\nRestartSec=60\nTimeoutStopSec=300\n")
+
+	b.WriteString("\n[Install]\n")
+	if userManager {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// writeUnit writes the unit file at path.
+func writeUnit(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create unit directory: %w", err)
+	}
+	// #nosec G306 -- systemd reads unit files, which contain no secrets
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write unit file: %w", err)
+	}
+	return nil
+}
+
+// parseUnit returns the command line and account of the unit file at path,
+// as written by unitFile.
+func parseUnit(path string) (args []string, account string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read unit file: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "ExecStart":
+			args = splitExecArgs(strings.TrimSpace(value))
+		case "User":
+			account = strings.TrimSpace(value)
+		}
+	}
+	return args, account, scanner.Err()
+}
+
+// quoteExecArg quotes arg for a command line of a unit file, escaping the
+// specifiers and variables systemd would expand.
+func quoteExecArg(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// splitExecArgs splits a command line of a unit file quoted by
+// quoteExecArg into its arguments.
+func splitExecArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quote := false, rune(0)
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			arg.WriteRune(runes[i])
+			inArg = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote, inArg = r, true
+		case quote == 0 && (r == ' ' || r == '\t'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	unescape := strings.NewReplacer("%%", "%", "$$", "$")
+	for i := range args {
+		args[i] = unescape.Replace(args[i])
+	}
+	return args
+}
+
+// parseProperties parses the KEY=value lines printed by systemctl show.
+func parseProperties(out []byte) map[string]string {
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// unitState returns the service state of a unit's ActiveState.
+func unitState(activeState string) ServiceState {
+	switch activeState {
+	case "active", "reloading":
+		return ServiceStateRunning
+	case "activating":
+		return ServiceStateStarting
+	case "deactivating":
+		return ServiceStateStopping
+	case "inactive", "failed":
+		return ServiceStateStopped
+	default:
+		return ServiceStateUnknown
+	}
+}
+
+// startTypeName describes how a unit with unitFileState is started.
+func startTypeName(unitFileState string) string {
+	switch unitFileState {
+	case "enabled", "enabled-runtime":
+		return "automatic"
+	case "disabled":
+		return "manual"
+	default:
+		return unitFileState
+	}
+}
+
+// userUnitDir returns the directory of the current user's units.
+func userUnitDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// runSystemctl runs systemctl with args, for the user's service manager if
+// userManager is set, and returns its output.
+func runSystemctl(ctx context.Context, userManager bool, args ...string) ([]byte, error) {
+	if userManager {
+		args = append([]string{"--user"}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "systemctl", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, msg)
+		}
+		return nil, fmt.Errorf("systemctl %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,165 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSystemd is a SystemdServiceManager on temporary unit directories
+// recording the systemctl commands run.
+type fakeSystemd struct {
+	*SystemdServiceManager
+	calls []string
+	show  string
+}
+
+func newFakeSystemd(t *testing.T) *fakeSystemd {
+	dir := t.TempDir()
+	f := &fakeSystemd{}
+	f.SystemdServiceManager = &SystemdServiceManager{
+		systemDir:   filepath.Join(dir, "system"),
+		userDir:     func() (string, error) { return filepath.Join(dir, "user"), nil },
+		currentUser: func() (string, error) { return "me", nil },
+		executable:  func() (string, error) { return "/opt/ludusavi runner/ludusavi-runner", nil },
+		systemctl: func(ctx context.Context, userManager bool, args ...string) ([]byte, error) {
+			call := strings.Join(args, " ")
+			if userManager {
+				call = "--user " + call
+			}
+			f.calls = append(f.calls, call)
+			return []byte(f.show), nil
+		},
+	}
+	return f
+}
+
+func TestSystemdServiceManager_Install(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user scope", func(t *testing.T) {
+		f := newFakeSystemd(t)
+		opts := InstallOptions{Scope: ServiceScopeUser, ConfigPath: "/home/me/config 100%.toml", AutoStart: true}
+		require.NoError(t, f.Install(ctx, opts))
+
+		dir, _ := f.userDir()
+		data, err := os.ReadFile(filepath.Join(dir, unitName))
+		require.NoError(t, err)
+		unit := string(data)
+		assert.Contains(t, unit, `ExecStart="/opt/ludusavi runner/ludusavi-runner" serve --config "/home/me/config 100%%.toml"`)
+		assert.Contains(t, unit, "WantedBy=default.target")
+		assert.NotContains(t, unit, "User=")
+		assert.NotContains(t, unit, "network-online.target")
+		assert.Equal(t, []string{"--user daemon-reload", "--user enable " + unitName}, f.calls)
+
+		assert.ErrorContains(t, f.Install(ctx, opts), "already exists")
+	})
+
+	t.Run("system scope", func(t *testing.T) {
+		f := newFakeSystemd(t)
+		require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeSystem}))
+
+		data, err := os.ReadFile(filepath.Join(f.systemDir, unitName))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "WantedBy=multi-user.target")
+		assert.NotContains(t, string(data), "User=")
+		assert.Equal(t, []string{"daemon-reload"}, f.calls)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		f := newFakeSystemd(t)
+		require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, Username: "gamer"}))
+
+		data, err := os.ReadFile(filepath.Join(f.systemDir, unitName))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "User=gamer\n")
+	})
+
+	t.Run("username in system scope", func(t *testing.T) {
+		f := newFakeSystemd(t)
+		err := f.Install(ctx, InstallOptions{Scope: ServiceScopeSystem, Username: "gamer"})
+		assert.ErrorContains(t, err, "a username can't be given in the system scope")
+	})
+}
+
+func TestSystemdServiceManager_Status(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSystemd(t)
+
+	status, err := f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateNotInstalled, status.State)
+
+	require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, Username: "gamer", ConfigPath: "/etc/ludusavi.toml", Portable: true}))
+	f.show = "ActiveState=active\nMainPID=4242\nActiveEnterTimestamp=Thu 2026-10-15 09:00:00 UTC\nUnitFileState=enabled\nResult=success\n"
+
+	status, err = f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateRunning, status.State)
+	assert.Equal(t, 4242, status.PID)
+	assert.Equal(t, "Thu 2026-10-15 09:00:00 UTC", status.StartTime)
+	assert.Equal(t, "automatic", status.StartType)
+	assert.Equal(t, "/opt/ludusavi runner/ludusavi-runner", status.BinaryPath)
+	assert.Equal(t, "/etc/ludusavi.toml", status.ConfigPath)
+	assert.True(t, status.Portable)
+	assert.Equal(t, "gamer", status.Account)
+	assert.Equal(t, ServiceScopeUser, status.Scope)
+
+	f.show = "ActiveState=failed\nMainPID=0\nUnitFileState=disabled\nResult=exit-code\n"
+	status, err = f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServiceStateStopped, status.State)
+	assert.Zero(t, status.PID)
+	assert.Equal(t, "manual", status.StartType)
+	assert.Equal(t, "Service failed (exit-code)", status.Message)
+}
+
+func TestSystemdServiceManager_Update(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSystemd(t)
+
+	assert.ErrorContains(t, f.Update(ctx, InstallOptions{}), "not found")
+
+	require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, Username: "gamer"}))
+	require.NoError(t, f.Update(ctx, InstallOptions{ConfigPath: "/srv/config.toml"}))
+
+	args, account, err := parseUnit(filepath.Join(f.systemDir, unitName))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/ludusavi runner/ludusavi-runner", "serve", "--config", "/srv/config.toml"}, args)
+	assert.Equal(t, "gamer", account, "the account is kept without a scope")
+
+	err = f.Update(ctx, InstallOptions{Scope: ServiceScopeUser})
+	assert.ErrorContains(t, err, "uninstall it and install it again")
+}
+
+func TestSystemdServiceManager_Uninstall(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeSystemd(t)
+
+	assert.ErrorContains(t, f.Uninstall(ctx), "not found")
+
+	require.NoError(t, f.Install(ctx, InstallOptions{Scope: ServiceScopeUser, AutoStart: true}))
+	f.calls = nil
+	require.NoError(t, f.Uninstall(ctx))
+
+	dir, _ := f.userDir()
+	assert.NoFileExists(t, filepath.Join(dir, unitName))
+	assert.Equal(t, []string{"--user stop " + unitName, "--user disable " + unitName, "--user daemon-reload"}, f.calls)
+}
+
+func TestExecArgs(t *testing.T) {
+	args := []string{"/usr/bin/ludusavi-runner", "serve", "--config", `/home/me/my "saves"\config.toml`, "100%", "$HOME", ""}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteExecArg(arg)
+	}
+	assert.Equal(t, "/usr/bin/ludusavi-runner", quoted[0])
+	assert.Equal(t, "100%%", quoted[4])
+	assert.Equal(t, "$$HOME", quoted[5])
+	assert.Equal(t, args, splitExecArgs(strings.Join(quoted, " ")))
+}
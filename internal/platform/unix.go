@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !linux && !darwin
 
 package platform
 
@@ -7,7 +7,8 @@ import (
 	"fmt"
 )
 
-// UnixServiceManager is a stub service manager for non-Windows platforms.
+// UnixServiceManager is a stub service manager for platforms without a
+// supported service manager.
 type UnixServiceManager struct{}
 
 // NewServiceManager creates a new service manager for the current platform.
@@ -15,45 +16,40 @@ func NewServiceManager() ServiceManager {
 	return &UnixServiceManager{}
 }
 
-// IsSupported returns false on non-Windows platforms (for now).
+// IsSupported returns false on platforms without a supported service manager.
 func (u *UnixServiceManager) IsSupported() bool {
 	return false
 }
 
-// Install is not implemented on non-Windows platforms.
+// Install is not implemented on this platform.
 func (u *UnixServiceManager) Install(ctx context.Context, opts InstallOptions) error {
 	return fmt.Errorf("service installation is not yet supported on this platform")
 }
 
-// Uninstall is not implemented on non-Windows platforms.
+// Update is not implemented on this platform.
+func (u *UnixServiceManager) Update(ctx context.Context, opts InstallOptions) error {
+	return fmt.Errorf("service update is not yet supported on this platform")
+}
+
+// Uninstall is not implemented on this platform.
 func (u *UnixServiceManager) Uninstall(ctx context.Context) error {
 	return fmt.Errorf("service uninstallation is not yet supported on this platform")
 }
 
-// Start is not implemented on non-Windows platforms.
+// Start is not implemented on this platform.
 func (u *UnixServiceManager) Start(ctx context.Context) error {
 	return fmt.Errorf("service start is not yet supported on this platform")
 }
 
-// Stop is not implemented on non-Windows platforms.
+// Stop is not implemented on this platform.
 func (u *UnixServiceManager) Stop(ctx context.Context) error {
 	return fmt.Errorf("service stop is not yet supported on this platform")
 }
 
-// Status is not implemented on non-Windows platforms.
+// Status is not implemented on this platform.
 func (u *UnixServiceManager) Status(ctx context.Context) (*ServiceStatus, error) {
 	return &ServiceStatus{
 		State:   ServiceStateUnknown,
 		Message: "Service management is not yet supported on this platform",
 	}, nil
 }
-
-// RunAsService is not implemented on non-Windows platforms.
-func RunAsService(handler func(ctx context.Context) error) error {
-	return fmt.Errorf("running as service is not yet supported on this platform")
-}
-
-// IsRunningAsService returns false on non-Windows platforms.
-func IsRunningAsService() bool {
-	return false
-}
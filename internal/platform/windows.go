@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 )
@@ -60,12 +61,14 @@ func (w *WindowsServiceManager) Install(ctx context.Context, opts InstallOptions
 		return fmt.Errorf("service %s already exists", serviceName)
 	}
 
-	// Build service arguments
-	args := []string{"serve"}
-	if opts.ConfigPath != "" {
-		args = append(args, "--config", opts.ConfigPath)
+	account, err := serviceAccount(opts, currentUser)
+	if err != nil {
+		return err
 	}
 
+	// Build service arguments
+	args := serviceArgs(opts)
+
 	// Build full command line
 	binPath := fmt.Sprintf(`"%s" %s`, exePath, strings.Join(args, " "))
 
@@ -80,7 +83,7 @@ func (w *WindowsServiceManager) Install(ctx context.Context, opts InstallOptions
 		DisplayName:      serviceDisplayName,
 		Description:      serviceDescription,
 		StartType:        startType,
-		ServiceStartName: opts.Username,
+		ServiceStartName: account,
 		Password:         opts.Password,
 	}
 
@@ -106,6 +109,56 @@ func (w *WindowsServiceManager) Install(ctx context.Context, opts InstallOptions
 	return nil
 }
 
+// Update rewrites the command line of the installed Windows service, and its
+// account if opts has a username, keeping the rest of its configuration.
+func (w *WindowsServiceManager) Update(ctx context.Context, opts InstallOptions) error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s not found: %w", serviceName, err)
+	}
+	defer s.Close()
+
+	config, err := s.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read service config: %w", err)
+	}
+	config.BinaryPathName = windows.ComposeCommandLine(append([]string{exePath}, serviceArgs(opts)...))
+	// An empty account and password leave them unchanged
+	config.ServiceStartName, config.Password = "", ""
+	if opts.Scope != "" {
+		account, err := serviceAccount(opts, currentUser)
+		if err != nil {
+			return err
+		}
+		config.ServiceStartName, config.Password = account, opts.Password
+		if account == "" {
+			config.ServiceStartName = "LocalSystem"
+		}
+	}
+
+	if err := s.UpdateConfig(config); err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	fmt.Printf("Service updated: %s\n", config.BinaryPathName)
+	return nil
+}
+
 // Uninstall removes the Windows service.
 func (w *WindowsServiceManager) Uninstall(ctx context.Context) error {
 	m, err := mgr.Connect()
@@ -239,10 +292,40 @@ func (w *WindowsServiceManager) Status(ctx context.Context) (*ServiceStatus, err
 		state = ServiceStateUnknown
 	}
 
-	return &ServiceStatus{
+	result := &ServiceStatus{
 		State: state,
 		PID:   int(status.ProcessId),
-	}, nil
+	}
+
+	// The options the service was installed with are in its command line
+	if config, err := s.Config(); err == nil {
+		if args, err := windows.DecomposeCommandLine(config.BinaryPathName); err == nil && len(args) > 0 {
+			result.BinaryPath, result.Args = args[0], args[1:]
+			result.ConfigPath, result.Portable = parseServiceArgs(result.Args)
+		}
+		result.Account = config.ServiceStartName
+		result.Scope = accountScope(config.ServiceStartName)
+		result.StartType = startTypeName(config)
+	}
+
+	return result, nil
+}
+
+// startTypeName describes how a service with config is started.
+func startTypeName(config mgr.Config) string {
+	switch config.StartType {
+	case mgr.StartAutomatic:
+		if config.DelayedAutoStart {
+			return "automatic (delayed)"
+		}
+		return "automatic"
+	case mgr.StartManual:
+		return "manual"
+	case mgr.StartDisabled:
+		return "disabled"
+	default:
+		return fmt.Sprintf("unknown (%d)", config.StartType)
+	}
 }
 
 // RunAsService runs the application as a Windows service.
@@ -296,16 +379,37 @@ func (ws *windowsService) Execute(args []string, r <-chan svc.ChangeRequest, cha
 				changes <- c.CurrentStatus
 
 			case svc.Stop, svc.Shutdown:
-				changes <- svc.Status{State: svc.StopPending}
+				changes <- svc.Status{State: svc.StopPending, WaitHint: stopWaitHint}
 				cancel()
-				// Wait for handler to finish
-				<-errCh
+				// Wait for handler to finish, reporting progress so the service
+				// control manager doesn't consider a draining backup hung
+				ws.drain(errCh, changes)
 				return false, 0
 			}
 		}
 	}
 }
 
+// stopWaitHint is how long the service control manager should wait between
+// stop progress updates, in milliseconds.
+const stopWaitHint = 30000
+
+// drain waits for the handler to return, advancing the stop checkpoint while
+// a backup in progress finishes.
+func (ws *windowsService) drain(errCh <-chan error, changes chan<- svc.Status) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for checkpoint := uint32(1); ; checkpoint++ {
+		select {
+		case <-errCh:
+			return
+		case <-ticker.C:
+			changes <- svc.Status{State: svc.StopPending, CheckPoint: checkpoint, WaitHint: stopWaitHint}
+		}
+	}
+}
+
 // getServicePID gets the PID of a running service using sc.exe
 // This is a fallback if the mgr API doesn't provide it.
 func getServicePID(serviceName string) int {
@@ -0,0 +1,33 @@
+// Package procstats reads the resource usage of the runner process and of the
+// ludusavi processes it starts, to show the overhead the backup system
+// itself imposes.
+package procstats
+
+import (
+	"errors"
+	"os"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// ErrUnsupported is returned when the usage of the runner process can't be
+// read on this platform.
+var ErrUnsupported = errors.New("reading process usage is not supported on this platform")
+
+// Self returns the resource usage of the runner process. Fields that can't
+// be read on this platform are zero.
+func Self() (*domain.ProcessStats, error) {
+	return self()
+}
+
+// Child returns the resource usage of a child process that has exited. Peak
+// memory is zero where the platform doesn't report it.
+func Child(state *os.ProcessState) domain.ProcessUsage {
+	if state == nil {
+		return domain.ProcessUsage{}
+	}
+	return domain.ProcessUsage{
+		CPUSeconds:      (state.UserTime() + state.SystemTime()).Seconds(),
+		PeakMemoryBytes: peakMemory(state),
+	}
+}
@@ -0,0 +1,39 @@
+package procstats
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// self reads CPU time from getrusage and open file descriptors from /dev/fd.
+// The current resident memory can only be read through Mach calls, so the
+// peak is reported instead.
+func self() (*domain.ProcessStats, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return nil, fmt.Errorf("failed to get resource usage: %w", err)
+	}
+	stats := &domain.ProcessStats{
+		CPUSeconds:  (time.Duration(ru.Utime.Nano()) + time.Duration(ru.Stime.Nano())).Seconds(),
+		MemoryBytes: ru.Maxrss,
+	}
+
+	if fds, err := os.ReadDir("/dev/fd"); err == nil {
+		stats.OpenFDs = len(fds)
+	}
+
+	return stats, nil
+}
+
+// peakMemory returns the maximum resident set size of the process, which
+// macOS reports in bytes.
+func peakMemory(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package procstats
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// self reads CPU time from getrusage and resident memory and open file
+// descriptors from /proc.
+func self() (*domain.ProcessStats, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return nil, fmt.Errorf("failed to get resource usage: %w", err)
+	}
+	stats := &domain.ProcessStats{
+		CPUSeconds: (time.Duration(ru.Utime.Nano()) + time.Duration(ru.Stime.Nano())).Seconds(),
+	}
+
+	// The second field of statm is the resident set size in pages
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(statm)); len(fields) > 1 {
+			if pages, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				stats.MemoryBytes = pages * int64(os.Getpagesize())
+			}
+		}
+	}
+
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		stats.OpenFDs = len(fds)
+	}
+
+	return stats, nil
+}
+
+// peakMemory returns the maximum resident set size of the process, which
+// Linux reports in kilobytes.
+func peakMemory(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss * 1024
+	}
+	return 0
+}
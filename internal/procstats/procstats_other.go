@@ -0,0 +1,19 @@
+//go:build !linux && !windows && !darwin
+
+package procstats
+
+import (
+	"os"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// self is not supported on this platform.
+func self() (*domain.ProcessStats, error) {
+	return nil, ErrUnsupported
+}
+
+// peakMemory is not reported on this platform.
+func peakMemory(*os.ProcessState) int64 {
+	return 0
+}
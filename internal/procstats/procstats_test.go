@@ -0,0 +1,38 @@
+package procstats
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelf(t *testing.T) {
+	stats, err := Self()
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, stats.CPUSeconds, 0.0)
+	assert.Positive(t, stats.MemoryBytes)
+	assert.Positive(t, stats.OpenFDs)
+}
+
+func TestChild(t *testing.T) {
+	assert.Zero(t, Child(nil))
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$") // #nosec G204 -- the test binary itself
+	require.NoError(t, cmd.Run())
+
+	usage := Child(cmd.ProcessState)
+	assert.GreaterOrEqual(t, usage.CPUSeconds, 0.0)
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		// Any Go binary takes at least a megabyte of memory
+		assert.Greater(t, usage.PeakMemoryBytes, int64(1<<20))
+	}
+}
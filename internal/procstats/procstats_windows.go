@@ -0,0 +1,67 @@
+package procstats
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                  = windows.NewLazySystemDLL("kernel32.dll")
+	procGetProcessMemoryInfo  = kernel32.NewProc("K32GetProcessMemoryInfo")
+	procGetProcessHandleCount = kernel32.NewProc("GetProcessHandleCount")
+)
+
+// processMemoryCounters is PROCESS_MEMORY_COUNTERS.
+type processMemoryCounters struct {
+	cb                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// self reads CPU time, the working set and the number of open handles of the
+// current process.
+func self() (*domain.ProcessStats, error) {
+	process := windows.CurrentProcess()
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(process, &creation, &exit, &kernel, &user); err != nil {
+		return nil, fmt.Errorf("failed to get process times: %w", err)
+	}
+	stats := &domain.ProcessStats{
+		CPUSeconds: (filetimeDuration(kernel) + filetimeDuration(user)).Seconds(),
+	}
+
+	counters := processMemoryCounters{cb: uint32(unsafe.Sizeof(processMemoryCounters{}))}
+	if ok, _, _ := procGetProcessMemoryInfo.Call(uintptr(process), uintptr(unsafe.Pointer(&counters)), uintptr(counters.cb)); ok != 0 {
+		stats.MemoryBytes = int64(counters.WorkingSetSize)
+	}
+
+	var handles uint32
+	if ok, _, _ := procGetProcessHandleCount.Call(uintptr(process), uintptr(unsafe.Pointer(&handles))); ok != 0 {
+		stats.OpenFDs = int(handles)
+	}
+
+	return stats, nil
+}
+
+// filetimeDuration converts a FILETIME holding a duration in 100ns units.
+func filetimeDuration(ft windows.Filetime) time.Duration {
+	return time.Duration(uint64(ft.HighDateTime)<<32|uint64(ft.LowDateTime)) * 100
+}
+
+// peakMemory is not reported by Windows once the process has been waited for.
+func peakMemory(*os.ProcessState) int64 {
+	return 0
+}
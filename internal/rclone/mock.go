@@ -0,0 +1,23 @@
+package rclone
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockTokenSource is a mock implementation of domain.CloudTokenSource for testing.
+type MockTokenSource struct {
+	TokensFunc func(ctx context.Context) ([]domain.CloudToken, error)
+}
+
+// Tokens calls the mock TokensFunc.
+func (m *MockTokenSource) Tokens(ctx context.Context) ([]domain.CloudToken, error) {
+	if m.TokensFunc != nil {
+		return m.TokensFunc(ctx)
+	}
+	return nil, nil
+}
+
+// Ensure MockTokenSource implements domain.CloudTokenSource.
+var _ domain.CloudTokenSource = (*MockTokenSource)(nil)
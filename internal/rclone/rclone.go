@@ -0,0 +1,174 @@
+// Package rclone reads the OAuth tokens of the rclone remotes ludusavi
+// uploads to, to warn before they expire and uploads start failing.
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/platform"
+)
+
+// defaultBinary is the rclone binary looked up in PATH.
+const defaultBinary = "rclone"
+
+// refreshLifetimes are how long refresh tokens of backends that document it
+// stay valid after the last refresh. rclone refreshes tokens whenever it
+// uses a remote, so these only lapse when no upload ran for that long.
+var refreshLifetimes = map[string]time.Duration{
+	"box": 60 * 24 * time.Hour,
+}
+
+// token is an OAuth token as rclone stores it in its config.
+type token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Client reads tokens through the rclone CLI.
+type Client struct {
+	binaryPath string
+	env        map[string]string
+	envVars    map[string]string
+	remotes    []string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBinaryPath sets the path to the rclone binary.
+func WithBinaryPath(path string) Option {
+	return func(c *Client) {
+		c.binaryPath = path
+	}
+}
+
+// WithEnv sets environment variables to pass to rclone, such as
+// RCLONE_CONFIG.
+func WithEnv(env map[string]string) Option {
+	return func(c *Client) {
+		c.env = env
+	}
+}
+
+// WithEnvVars sets the variables ${name} references in env values expand to.
+func WithEnvVars(vars map[string]string) Option {
+	return func(c *Client) {
+		c.envVars = vars
+	}
+}
+
+// WithRemotes limits the tokens to the named remotes.
+func WithRemotes(remotes ...string) Option {
+	return func(c *Client) {
+		c.remotes = remotes
+	}
+}
+
+// NewClient creates a new Client.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		binaryPath: defaultBinary,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Tokens returns the tokens of the remotes in the rclone config, read with
+// `rclone config dump`.
+func (c *Client) Tokens(ctx context.Context) ([]domain.CloudToken, error) {
+	// #nosec G204 -- path is from config, not user input
+	cmd := exec.CommandContext(ctx, c.binaryPath, "config", "dump")
+	cmd.Env = platform.Environ(c.env, c.envVars)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := platform.RunProcessGroup(cmd); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errMsg := strings.TrimSpace(stderr.String()); errMsg != "" {
+			return nil, fmt.Errorf("rclone config dump failed: %s: %w", errMsg, err)
+		}
+		return nil, fmt.Errorf("rclone config dump failed: %w", err)
+	}
+
+	tokens, err := ParseConfigDump(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(c.remotes) > 0 {
+		tokens = slices.DeleteFunc(tokens, func(t domain.CloudToken) bool {
+			return !slices.Contains(c.remotes, t.Remote)
+		})
+	}
+	return tokens, nil
+}
+
+// ParseConfigDump returns the tokens of the remotes in the output of
+// `rclone config dump`, sorted by remote. Remotes without a token are left
+// out.
+func ParseConfigDump(data []byte) ([]domain.CloudToken, error) {
+	var remotes map[string]map[string]any
+	if err := json.Unmarshal(data, &remotes); err != nil {
+		return nil, fmt.Errorf("failed to parse rclone config: %w", err)
+	}
+
+	var tokens []domain.CloudToken
+	for name, remote := range remotes {
+		raw, _ := remote["token"].(string)
+		if raw == "" {
+			continue
+		}
+		var tok token
+		if err := json.Unmarshal([]byte(raw), &tok); err != nil {
+			return nil, fmt.Errorf("failed to parse token of remote %s: %w", name, err)
+		}
+
+		backend, _ := remote["type"].(string)
+		tokens = append(tokens, domain.CloudToken{
+			Remote:   name,
+			Type:     backend,
+			Deadline: deadline(backend, tok),
+		})
+	}
+
+	slices.SortFunc(tokens, func(a, b domain.CloudToken) int {
+		return strings.Compare(a.Remote, b.Remote)
+	})
+	return tokens, nil
+}
+
+// deadline returns when tok stops working unless the user signs in again,
+// or zero if that isn't known. A token without a refresh token can't be
+// renewed, so it lasts until it expires; refresh tokens only lapse on
+// backends that limit their lifetime.
+func deadline(backend string, tok token) time.Time {
+	if tok.Expiry.IsZero() {
+		return time.Time{}
+	}
+	if tok.RefreshToken == "" {
+		return tok.Expiry
+	}
+	if lifetime, ok := refreshLifetimes[backend]; ok {
+		return tok.Expiry.Add(lifetime)
+	}
+	return time.Time{}
+}
+
+// Ensure Client implements domain.CloudTokenSource.
+var _ domain.CloudTokenSource = (*Client)(nil)
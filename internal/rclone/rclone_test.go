@@ -0,0 +1,54 @@
+package rclone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigDump(t *testing.T) {
+	data := []byte(`{
+		"box": {
+			"type": "box",
+			"token": "{\"access_token\":\"a\",\"refresh_token\":\"r\",\"expiry\":\"2026-01-01T10:00:00Z\"}"
+		},
+		"gdrive": {
+			"type": "drive",
+			"token": "{\"access_token\":\"a\",\"refresh_token\":\"r\",\"expiry\":\"2026-01-01T10:00:00Z\"}"
+		},
+		"dropbox": {
+			"type": "dropbox",
+			"token": "{\"access_token\":\"a\",\"expiry\":\"2026-01-01T10:00:00Z\"}"
+		},
+		"nas": {
+			"type": "sftp",
+			"host": "nas.local"
+		}
+	}`)
+
+	tokens, err := ParseConfigDump(data)
+	require.NoError(t, err)
+	require.Len(t, tokens, 3)
+
+	expiry := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "box", tokens[0].Remote)
+	assert.Equal(t, "box", tokens[0].Type)
+	assert.True(t, tokens[0].Deadline.Equal(expiry.Add(60*24*time.Hour)), "box refresh tokens last 60 days")
+
+	assert.Equal(t, "dropbox", tokens[1].Remote)
+	assert.True(t, tokens[1].Deadline.Equal(expiry), "tokens without a refresh token last until they expire")
+
+	assert.Equal(t, "gdrive", tokens[2].Remote)
+	assert.True(t, tokens[2].Deadline.IsZero(), "refresh tokens without a known lifetime have no deadline")
+}
+
+func TestParseConfigDump_Invalid(t *testing.T) {
+	_, err := ParseConfigDump([]byte("not json"))
+	assert.ErrorContains(t, err, "failed to parse rclone config")
+
+	_, err = ParseConfigDump([]byte(`{"box": {"type": "box", "token": "{"}}`))
+	assert.ErrorContains(t, err, "failed to parse token of remote box")
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/units"
+)
+
+// Markdown renders the report as Markdown, with the size trend drawn as a
+// sparkline, for notifications and chat.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+	fmt.Fprintf(&b, "Host: %s\n\n", r.Hostname)
+
+	if r.Total() == 0 {
+		b.WriteString("No backups ran.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- Runs: %d (%s)\n", r.Total(), r.runs())
+	fmt.Fprintf(&b, "- Failed: %d (%.0f%%)\n", r.Failed, r.FailureRate()*100)
+	if r.LastSuccess.IsZero() {
+		b.WriteString("- Last successful full backup: none this period\n")
+	} else {
+		fmt.Fprintf(&b, "- Last successful full backup: %s\n", r.LastSuccess.Format("2006-01-02 15:04"))
+	}
+	if len(r.Bytes) > 0 {
+		fmt.Fprintf(&b, "- Saves: %s (%s) %s\n", units.FormatBytes(r.Size()), signedBytes(r.BytesChange()), r.sparkline())
+	}
+
+	if len(r.Growth) > 0 {
+		b.WriteString("\n## Fastest growing saves\n\n")
+		for i, g := range r.Growth {
+			fmt.Fprintf(&b, "%d. %s: %s (%s → %s)\n", i+1, g.Game, signedBytes(g.Delta), units.FormatBytes(g.From), units.FormatBytes(g.To))
+		}
+	}
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\n## Most frequent errors\n\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "- %dx %s\n", e.Count, e.Error)
+		}
+	}
+	return b.String()
+}
+
+// signedBytes formats a change of n bytes with its sign.
+func signedBytes(n int64) string {
+	if n < 0 {
+		return units.FormatBytes(n)
+	}
+	return "+" + units.FormatBytes(n)
+}
+
+// chart dimensions of the size trend in the HTML report.
+const (
+	chartWidth  = 480
+	chartHeight = 120
+	chartMargin = 8
+)
+
+// chart returns the points of the SVG polyline of the size trend.
+func (r *Report) chart() string {
+	if len(r.Bytes) < 2 {
+		return ""
+	}
+	lo, hi := r.Bytes[0].Bytes, r.Bytes[0].Bytes
+	for _, p := range r.Bytes {
+		lo, hi = min(lo, p.Bytes), max(hi, p.Bytes)
+	}
+	span := r.Bytes[len(r.Bytes)-1].Day.Sub(r.Bytes[0].Day)
+
+	var points []string
+	for _, p := range r.Bytes {
+		x := chartMargin + float64(p.Day.Sub(r.Bytes[0].Day))/float64(span)*(chartWidth-2*chartMargin)
+		y := float64(chartHeight) / 2
+		if hi > lo {
+			y = chartHeight - chartMargin - float64(p.Bytes-lo)/float64(hi-lo)*(chartHeight-2*chartMargin)
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(points, " ")
+}
+
+// htmlTemplate is the HTML report, a self-contained page for a dashboard or
+// an email.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes":  units.FormatBytes,
+	"signed": signedBytes,
+	"date":   func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"day":    func(t time.Time) string { return t.Format("Mon 01-02") },
+	"pct":    func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40em; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 0.8em 0.2em 0; text-align: left; }
+td.num { text-align: right; }
+.failed { color: #e05d44; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Host: {{.Hostname}}</p>
+{{if eq .Total 0}}<p>No backups ran.</p>{{else}}
+<table>
+<tr><th>Runs</th><td>{{.Total}} ({{.RunsByKind}})</td></tr>
+<tr><th>Failed</th><td{{if gt .Failed 0}} class="failed"{{end}}>{{.Failed}} ({{pct .FailureRate}})</td></tr>
+<tr><th>Last successful full backup</th><td>{{if .LastSuccess.IsZero}}none this period{{else}}{{date .LastSuccess}}{{end}}</td></tr>
+{{if .Bytes}}<tr><th>Saves</th><td>{{bytes .Size}} ({{signed .BytesChange}})</td></tr>{{end}}
+</table>
+{{with .Chart}}
+<h2>Save size</h2>
+<svg xmlns="http://www.w3.org/2000/svg" width="` + fmt.Sprint(chartWidth) + `" height="` + fmt.Sprint(chartHeight) + `" role="img" aria-label="Save size by day">
+<rect width="100%" height="100%" fill="#f6f6f6"/>
+<polyline fill="none" stroke="#4c1" stroke-width="2" points="{{.}}"/>
+</svg>
+<p>{{range $i, $p := $.Bytes}}{{if $i}} · {{end}}{{day $p.Day}}: {{bytes $p.Bytes}}{{end}}</p>
+{{end}}
+{{with .Growth}}
+<h2>Fastest growing saves</h2>
+<table>
+{{range .}}<tr><td>{{.Game}}</td><td class="num">{{signed .Delta}}</td><td>{{bytes .From}} → {{bytes .To}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Errors}}
+<h2>Most frequent errors</h2>
+<ul>
+{{range .}}<li>{{.Count}}× {{.Error}}</li>
+{{end}}</ul>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the report as a self-contained HTML page, with the size trend
+// drawn as a chart.
+func (r *Report) HTML() (string, error) {
+	var b bytes.Buffer
+	err := htmlTemplate.Execute(&b, struct {
+		*Report
+		RunsByKind string
+		Chart      string
+	}{r, r.runs(), r.chart()})
+	if err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,184 @@
+// Package report builds periodic reports on the backups from the run
+// history: how many runs failed, how the size of the saves developed and
+// which games' saves grew the most.
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+)
+
+// topGames is how many of the fastest growing games a report lists.
+const topGames = 5
+
+// topErrors is how many of the most frequent errors a report lists.
+const topErrors = 3
+
+// Report summarizes the runs of a period.
+type Report struct {
+	Hostname string
+	From     time.Time
+	To       time.Time
+
+	// Runs counts the runs by kind, and Failed the failed ones.
+	Runs   map[history.Kind]int
+	Failed int
+
+	// LastSuccess is the start of the latest successful full run, if any.
+	LastSuccess time.Time
+
+	// Bytes is the size of the saves after the last full backup of each
+	// day that had one.
+	Bytes []Point
+
+	// Growth lists the games whose saves grew the most, largest first.
+	Growth []history.Growth
+
+	// Errors lists the most frequent errors, most frequent first.
+	Errors []ErrorCount
+}
+
+// Point is the size of the saves on a day.
+type Point struct {
+	Day   time.Time
+	Bytes int64
+}
+
+// ErrorCount is an error and how many runs failed with it.
+type ErrorCount struct {
+	Error string
+	Count int
+}
+
+// New builds the report on the records of the runs started from from until
+// to, in days of loc. Dry runs are left out.
+func New(hostname string, records []history.Record, from, to time.Time, loc *time.Location) *Report {
+	r := &Report{
+		Hostname: hostname,
+		From:     from.In(loc),
+		To:       to.In(loc),
+		Runs:     make(map[history.Kind]int),
+	}
+
+	var period []history.Record
+	errs := make(map[string]int)
+	for _, rec := range records {
+		if rec.DryRun || rec.Start.Before(from) || !rec.Start.Before(to) {
+			continue
+		}
+		period = append(period, rec)
+		r.Runs[rec.Kind]++
+		if !rec.Success {
+			r.Failed++
+			for _, err := range rec.Errors {
+				errs[err]++
+			}
+		}
+		if rec.Kind != history.KindFull || !rec.Success {
+			continue
+		}
+
+		r.LastSuccess = rec.Start.In(loc)
+		if backup := rec.Backup(); backup != nil {
+			y, m, d := rec.Start.In(loc).Date()
+			day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+			if n := len(r.Bytes); n > 0 && r.Bytes[n-1].Day.Equal(day) {
+				r.Bytes[n-1].Bytes = backup.TotalBytes
+			} else {
+				r.Bytes = append(r.Bytes, Point{Day: day, Bytes: backup.TotalBytes})
+			}
+		}
+	}
+	r.Growth = history.Leaderboard(period, topGames)
+
+	for _, err := range slices.Sorted(maps.Keys(errs)) {
+		r.Errors = append(r.Errors, ErrorCount{Error: err, Count: errs[err]})
+	}
+	slices.SortStableFunc(r.Errors, func(a, b ErrorCount) int { return cmp.Compare(b.Count, a.Count) })
+	r.Errors = r.Errors[:min(len(r.Errors), topErrors)]
+
+	return r
+}
+
+// Total returns the number of runs.
+func (r *Report) Total() int {
+	total := 0
+	for _, n := range r.Runs {
+		total += n
+	}
+	return total
+}
+
+// FailureRate returns the share of runs that failed, from 0 to 1.
+func (r *Report) FailureRate() float64 {
+	if r.Total() == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(r.Total())
+}
+
+// Size returns the size of the saves after the last full backup of the
+// period, or zero without one.
+func (r *Report) Size() int64 {
+	if len(r.Bytes) == 0 {
+		return 0
+	}
+	return r.Bytes[len(r.Bytes)-1].Bytes
+}
+
+// BytesChange returns how much the saves grew over the period, negative if
+// they shrank.
+func (r *Report) BytesChange() int64 {
+	if len(r.Bytes) == 0 {
+		return 0
+	}
+	return r.Size() - r.Bytes[0].Bytes
+}
+
+// Title returns the report's title.
+func (r *Report) Title() string {
+	return fmt.Sprintf("Ludusavi Backup Report %s – %s", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))
+}
+
+// kinds are the kinds of runs, in the order they are reported.
+var kinds = []history.Kind{history.KindFull, history.KindFast, history.KindGame, history.KindDestination, history.KindRestore}
+
+// runs describes the number of runs by kind, e.g. "12 full, 30 fast".
+func (r *Report) runs() string {
+	var parts []string
+	for _, kind := range kinds {
+		if n := r.Runs[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sparkBlocks are the bars of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the size of the saves over the period as a line of bars.
+func (r *Report) sparkline() string {
+	if len(r.Bytes) == 0 {
+		return ""
+	}
+	lo, hi := r.Bytes[0].Bytes, r.Bytes[0].Bytes
+	for _, p := range r.Bytes {
+		lo, hi = min(lo, p.Bytes), max(hi, p.Bytes)
+	}
+	var b strings.Builder
+	for _, p := range r.Bytes {
+		i := len(sparkBlocks) / 2
+		if hi > lo {
+			i = int(float64(p.Bytes-lo) / float64(hi-lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
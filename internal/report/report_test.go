@@ -0,0 +1,96 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fullRun returns the record of a full run started at start.
+func fullRun(start time.Time, success bool, games map[string]int64) history.Record {
+	var total int64
+	for _, n := range games {
+		total += n
+	}
+	rec := history.Record{
+		Kind:       history.KindFull,
+		Start:      start,
+		Success:    success,
+		Operations: []history.Operation{{Operation: "backup", Success: success, TotalBytes: total}},
+		GameBytes:  games,
+	}
+	if !success {
+		rec.Errors = []string{"backup: disk full"}
+	}
+	return rec
+}
+
+func TestNew(t *testing.T) {
+	from := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	records := []history.Record{
+		fullRun(from.Add(-time.Hour), true, map[string]int64{"Celeste": 1}),
+		fullRun(from.Add(time.Hour), true, map[string]int64{"Celeste": 100, "Hades": 500, "Tunic": 50}),
+		fullRun(from.Add(2*time.Hour), true, map[string]int64{"Celeste": 120, "Hades": 500, "Tunic": 50}),
+		{Kind: history.KindFast, Start: from.Add(24 * time.Hour), Success: true},
+		{Kind: history.KindFast, Start: from.Add(25 * time.Hour), Success: true, DryRun: true},
+		fullRun(from.Add(48*time.Hour), false, nil),
+		fullRun(from.Add(72*time.Hour), true, map[string]int64{"Celeste": 120, "Hades": 2500, "Tunic": 40}),
+		fullRun(to, true, map[string]int64{"Celeste": 9999}),
+	}
+
+	r := New("gaming-pc", records, from, to, time.UTC)
+	assert.Equal(t, map[history.Kind]int{history.KindFull: 4, history.KindFast: 1}, r.Runs)
+	assert.Equal(t, 5, r.Total())
+	assert.Equal(t, 1, r.Failed)
+	assert.InDelta(t, 0.2, r.FailureRate(), 0.001)
+	assert.Equal(t, from.Add(72*time.Hour), r.LastSuccess)
+
+	// One point a day, after the day's last full backup
+	require.Len(t, r.Bytes, 2)
+	assert.Equal(t, int64(670), r.Bytes[0].Bytes)
+	assert.Equal(t, int64(2660), r.Size())
+	assert.Equal(t, int64(1990), r.BytesChange())
+
+	// Shrinking saves aren't growth
+	require.Len(t, r.Growth, 2)
+	assert.Equal(t, "Hades", r.Growth[0].Game)
+	assert.Equal(t, int64(2000), r.Growth[0].Delta)
+	assert.Equal(t, "Celeste", r.Growth[1].Game)
+	assert.Equal(t, int64(20), r.Growth[1].Delta)
+	assert.Equal(t, []ErrorCount{{Error: "backup: disk full", Count: 1}}, r.Errors)
+}
+
+func TestReport_Render(t *testing.T) {
+	from := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	records := []history.Record{
+		fullRun(from.Add(time.Hour), true, map[string]int64{"Hades": 1 << 20}),
+		fullRun(from.Add(25*time.Hour), true, map[string]int64{"Hades": 3 << 20}),
+		fullRun(from.Add(49*time.Hour), false, nil),
+	}
+	r := New("gaming-pc", records, from, to, time.UTC)
+
+	md := r.Markdown()
+	assert.Contains(t, md, "# Ludusavi Backup Report 2026-03-02 – 2026-03-09")
+	assert.Contains(t, md, "- Runs: 3 (3 full)")
+	assert.Contains(t, md, "- Failed: 1 (33%)")
+	assert.Contains(t, md, "- Saves: 3.0 MiB (+2.0 MiB) ▁█")
+	assert.Contains(t, md, "1. Hades: +2.0 MiB (1.0 MiB → 3.0 MiB)")
+	assert.Contains(t, md, "- 1x backup: disk full")
+
+	page, err := r.HTML()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "<polyline")
+	assert.Contains(t, page, "Hades")
+
+	empty := New("gaming-pc", nil, from, to, time.UTC)
+	assert.Contains(t, empty.Markdown(), "No backups ran.")
+	_, err = empty.HTML()
+	assert.NoError(t, err)
+}
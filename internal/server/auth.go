@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
+)
+
+// Authenticated returns a handler that passes requests authenticated with
+// secret on to next and refuses others with 401 Unauthorized. Simple clients
+// such as launcher scripts send "Authorization: Bearer <secret>"; others sign
+// requests as the on_complete webhook does (see webhook.SetSignature), which
+// keeps the secret off the wire. A signed request is refused once its
+// timestamp is outside webhook.DefaultTolerance, and a repeat of one seen
+// within it is refused as a replay.
+func Authenticated(secret string, next http.Handler) http.Handler {
+	seen := &replayGuard{seen: make(map[string]time.Time)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+				unauthorized(w, "invalid token")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		if err := webhook.Verify(r.Header, secret, body, webhook.DefaultTolerance, now); err != nil {
+			unauthorized(w, err.Error())
+			return
+		}
+		if !seen.first(r.Header.Get(webhook.SignatureHeader), now) {
+			unauthorized(w, "replayed request")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// replayGuard remembers the signatures of verified requests for as long as
+// they would pass verification. The signature covers the timestamp and body,
+// so unlike the unsigned delivery ID a replay can't change it.
+type replayGuard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // signature -> when it can be forgotten
+}
+
+// first reports whether signature is seen for the first time at now.
+func (g *replayGuard) first(signature string, now time.Time) bool {
+	signature = strings.ToLower(signature)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for s, expires := range g.seen {
+		if now.After(expires) {
+			delete(g.seen, s)
+		}
+	}
+	if _, ok := g.seen[signature]; ok {
+		return false
+	}
+	// Signed up to the tolerance in the future, it stays valid for twice that
+	g.seen[signature] = now.Add(2 * webhook.DefaultTolerance)
+	return true
+}
+
+// unauthorized responds with 401 Unauthorized and msg.
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, msg, http.StatusUnauthorized)
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// maxRequestBody bounds the size of request bodies decoded by Request.
+const maxRequestBody = 64 << 10
+
+// startTime is used to report process uptime.
+var startTime = time.Now()
+
+// RuntimeStats is a snapshot of Go runtime statistics, used to spot goroutine
+// leaks and memory growth in long-running serve mode.
+type RuntimeStats struct {
+	Goroutines    int     `json:"goroutines"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	HeapAlloc     uint64  `json:"heap_alloc_bytes"`
+	HeapInuse     uint64  `json:"heap_inuse_bytes"`
+	HeapObjects   uint64  `json:"heap_objects"`
+	Sys           uint64  `json:"sys_bytes"`
+	TotalAlloc    uint64  `json:"total_alloc_bytes"`
+	NumGC         uint32  `json:"num_gc"`
+	LastGC        string  `json:"last_gc,omitempty"`
+	GCPauseTotal  float64 `json:"gc_pause_total_seconds"`
+}
+
+// ReadRuntimeStats returns the current runtime statistics.
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:    runtime.NumGoroutine(),
+		UptimeSeconds: time.Since(startTime).Seconds(),
+		HeapAlloc:     m.HeapAlloc,
+		HeapInuse:     m.HeapInuse,
+		HeapObjects:   m.HeapObjects,
+		Sys:           m.Sys,
+		TotalAlloc:    m.TotalAlloc,
+		NumGC:         m.NumGC,
+		GCPauseTotal:  time.Duration(m.PauseTotalNs).Seconds(), // #nosec G115 -- pause totals never exceed int64
+	}
+	if m.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339) // #nosec G115 -- nanosecond timestamps fit in int64
+	}
+
+	return stats
+}
+
+// handleRuntime serves the current runtime statistics as JSON.
+func handleRuntime(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ReadRuntimeStats())
+}
+
+// JSON returns a handler that serves the value returned by fn as JSON.
+func JSON(fn func() any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, fn())
+	})
+}
+
+// Action returns a handler for endpoints that change the service's state: it
+// calls fn and responds with the JSON encoding of its result. Requests from
+// browsers, which carry an Origin header, are refused, so that a web page
+// can't pause backups through a cross-site request.
+func Action(fn func() any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if crossOrigin(w, r) {
+			return
+		}
+		writeJSON(w, fn())
+	})
+}
+
+// Request returns a handler for endpoints that change the service's state
+// as described by the request, such as its path or JSON body: it calls fn
+// and responds with the JSON encoding of its result, or with the error fn
+// returns as a bad request, unless it is marked with NotFound or Internal.
+// Cross-origin requests are refused as by Action.
+func Request(fn func(r *http.Request) (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if crossOrigin(w, r) {
+			return
+		}
+		v, err := fn(r)
+		var (
+			notFound notFoundError
+			internal internalError
+		)
+		switch {
+		case errors.As(err, &notFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.As(err, &internal):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeJSON(w, v)
+		}
+	})
+}
+
+// DecodeJSON decodes the JSON body of r into v, refusing unknown fields.
+func DecodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// notFoundError is an error Request responds to with 404 Not Found.
+type notFoundError struct {
+	error
+}
+
+// NotFound marks err as the requested thing not existing, so that Request
+// responds with 404 Not Found rather than 400 Bad Request.
+func NotFound(err error) error {
+	return notFoundError{err}
+}
+
+// internalError is an error Request responds to with 500 Internal Server Error.
+type internalError struct {
+	error
+}
+
+// Internal marks err as the service failing to handle a valid request, such
+// as a store it couldn't read, so that Request responds with 500 Internal
+// Server Error rather than 400 Bad Request.
+func Internal(err error) error {
+	return internalError{err}
+}
+
+// crossOrigin refuses requests from browsers, which carry an Origin header,
+// and reports whether it did.
+func crossOrigin(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Origin") != "" {
+		http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// SVG returns a handler that serves the image returned by fn. Responses are
+// not cached, so dashboards embedding it always show the current image.
+func SVG(fn func() []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(fn())
+	})
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
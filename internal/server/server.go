@@ -0,0 +1,128 @@
+// Package server provides the embedded HTTP server used in serve mode.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take once the server stops.
+const shutdownTimeout = 5 * time.Second
+
+// Server is a small HTTP server exposing health and diagnostic endpoints.
+type Server struct {
+	addr   string
+	debug  bool
+	mux    *http.ServeMux
+	logger *slog.Logger
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithDebug enables the pprof handlers and runtime statistics under /debug/.
+func WithDebug(enabled bool) Option {
+	return func(s *Server) {
+		s.debug = enabled
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// New creates a new Server listening on addr.
+func New(addr string, opts ...Option) *Server {
+	s := &Server{
+		addr:   addr,
+		mux:    http.NewServeMux(),
+		logger: slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+
+	if s.debug {
+		s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		s.mux.HandleFunc("GET /debug/runtime", handleRuntime)
+	}
+
+	return s
+}
+
+// Handle registers a handler on the server. It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the server's request handler.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start listens on the configured address and serves requests until ctx is
+// cancelled, then shuts down gracefully.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves requests on listener until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	// Long-lived requests such as event streams end when the server shuts down
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	srv := &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+	srv.RegisterOnShutdown(cancelBase)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	s.logger.Info("http server started", "address", listener.Addr().String(), "debug", s.debug)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+
+	s.logger.Info("http server stopped")
+	return nil
+}
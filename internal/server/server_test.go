@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/webhook"
+)
+
+func TestServer_Healthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New("127.0.0.1:0").Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok\n", rec.Body.String())
+}
+
+func TestServer_Debug(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		handler := New("127.0.0.1:0").Handler()
+
+		for _, path := range []string{"/debug/pprof/", "/debug/runtime"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		handler := New("127.0.0.1:0", WithDebug(true)).Handler()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "goroutine")
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var stats RuntimeStats
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		assert.Positive(t, stats.Goroutines)
+		assert.Positive(t, stats.HeapAlloc)
+		assert.Positive(t, stats.Sys)
+	})
+}
+
+func TestServer_Handle(t *testing.T) {
+	srv := New("127.0.0.1:0")
+	srv.Handle("GET /custom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/custom", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+}
+
+func TestServer_Serve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(listener.Addr().String()).Serve(ctx, listener)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/healthz")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "ok\n", string(body))
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after context cancellation")
+	}
+}
+
+func TestServer_Serve_EndsStreams(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := New(listener.Addr().String())
+	srv.Handle("GET /stream", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = http.NewResponseController(w).Flush()
+		<-r.Context().Done()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, listener)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Shutdown doesn't wait out its timeout on the open stream
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(shutdownTimeout / 2):
+		t.Fatal("server did not end the open stream on shutdown")
+	}
+}
+
+func TestServer_Start_AddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	err = New(listener.Addr().String()).Start(context.Background())
+	assert.ErrorContains(t, err, "failed to listen on")
+}
+
+func TestJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	JSON(func() any {
+		return map[string]string{"state": "draining"}
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"state": "draining"}`, rec.Body.String())
+}
+
+func TestAction(t *testing.T) {
+	calls := 0
+	handler := Action(func() any {
+		calls++
+		return map[string]int{"calls": calls}
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/run", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"calls": 1}`, rec.Body.String())
+
+	// Requests from web pages are refused
+	req := httptest.NewRequest(http.MethodPost, "/run", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestSVG(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SVG(func() []byte {
+		return []byte("<svg/>")
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge.svg", nil))
+
+	assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "<svg/>", rec.Body.String())
+}
+
+func TestRequest(t *testing.T) {
+	handler := Request(func(r *http.Request) (any, error) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := DecodeJSON(r, &body); err != nil {
+			return nil, err
+		}
+		switch body.Name {
+		case "missing":
+			return nil, NotFound(fmt.Errorf("no %s", body.Name))
+		case "broken":
+			return nil, Internal(fmt.Errorf("failed to read %s", body.Name))
+		}
+		return map[string]string{"hello": body.Name}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader(`{"name": "world"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"hello": "world"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader(`{"nmae": "world"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid request body")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader(`{"name": "missing"}`)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "no missing\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader(`{"name": "broken"}`)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "failed to read broken\n", rec.Body.String())
+
+	// Requests from web pages are refused
+	req := httptest.NewRequest(http.MethodPost, "/hello", strings.NewReader(`{"name": "world"}`))
+	req.Header.Set("Origin", "https://example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuthenticated(t *testing.T) {
+	handler := Authenticated("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	body := `{"title": "Hades"}`
+
+	t.Run("bearer token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer guess")
+		rec = serve(req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		webhook.SetSignature(req.Header, "s3cret", []byte(body), time.Now())
+		rec := serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String(), "body is passed on after verifying it")
+
+		// Replayed at once, under another delivery ID
+		replay := httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		replay.Header = req.Header.Clone()
+		replay.Header.Set(webhook.DeliveryHeader, "another")
+		rec = serve(replay)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "replayed request")
+
+		// Replayed later
+		req = httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		webhook.SetSignature(req.Header, "s3cret", []byte(body), time.Now().Add(-time.Hour))
+		assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+
+		// Signed with another secret
+		req = httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body))
+		webhook.SetSignature(req.Header, "guess", []byte(body), time.Now())
+		assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rec := serve(httptest.NewRequest(http.MethodPost, "/event", strings.NewReader(body)))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "missing signature")
+	})
+}
@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// BtrfsSnapshotter snapshots a btrfs subvolume into a directory on the same
+// filesystem, using the btrfs command.
+type BtrfsSnapshotter struct {
+	subvolume string
+	dir       string
+	run       commandFunc
+}
+
+// NewBtrfsSnapshotter snapshots subvolume into dir.
+func NewBtrfsSnapshotter(subvolume, dir string) *BtrfsSnapshotter {
+	return &BtrfsSnapshotter{
+		subvolume: subvolume,
+		dir:       dir,
+		run:       runCommand,
+	}
+}
+
+// Create takes a read-only snapshot of the subvolume.
+func (b *BtrfsSnapshotter) Create(ctx context.Context, name string) error {
+	if err := os.MkdirAll(b.dir, 0750); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	_, err := b.run(ctx, "btrfs", "subvolume", "snapshot", "-r", b.subvolume, filepath.Join(b.dir, name))
+	return err
+}
+
+// List returns the names of the entries in the snapshot directory.
+func (b *BtrfsSnapshotter) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete deletes a snapshot subvolume.
+func (b *BtrfsSnapshotter) Delete(ctx context.Context, name string) error {
+	_, err := b.run(ctx, "btrfs", "subvolume", "delete", filepath.Join(b.dir, name))
+	return err
+}
+
+// Ensure BtrfsSnapshotter implements domain.StoreSnapshotter.
+var _ domain.StoreSnapshotter = (*BtrfsSnapshotter)(nil)
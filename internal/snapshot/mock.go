@@ -0,0 +1,41 @@
+package snapshot
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockSnapshotter is a mock implementation of domain.StoreSnapshotter for testing.
+type MockSnapshotter struct {
+	CreateFunc func(ctx context.Context, name string) error
+
+	// Snapshots stores the names of existing snapshots.
+	Snapshots []string
+}
+
+// Create calls the mock CreateFunc and stores the name.
+func (m *MockSnapshotter) Create(ctx context.Context, name string) error {
+	if m.CreateFunc != nil {
+		if err := m.CreateFunc(ctx, name); err != nil {
+			return err
+		}
+	}
+	m.Snapshots = append(m.Snapshots, name)
+	return nil
+}
+
+// List returns the stored names.
+func (m *MockSnapshotter) List(ctx context.Context) ([]string, error) {
+	return slices.Clone(m.Snapshots), nil
+}
+
+// Delete removes the name.
+func (m *MockSnapshotter) Delete(ctx context.Context, name string) error {
+	m.Snapshots = slices.DeleteFunc(m.Snapshots, func(s string) bool { return s == name })
+	return nil
+}
+
+// Ensure MockSnapshotter implements domain.StoreSnapshotter.
+var _ domain.StoreSnapshotter = (*MockSnapshotter)(nil)
@@ -0,0 +1,133 @@
+// Package snapshot takes btrfs and ZFS snapshots of the backup store, giving
+// point-in-time rollback of the backups themselves, and prunes old ones.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+// timestampFormat is the layout of snapshot name timestamps; names sort in
+// creation order.
+const timestampFormat = "20060102T150405Z"
+
+// Manager takes snapshots named <prefix>-<UTC timestamp> and keeps only the
+// newest ones. Snapshots without the prefix are never touched.
+type Manager struct {
+	snapshotter domain.StoreSnapshotter
+	prefix      string
+	keep        int
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithKeep sets how many snapshots to keep; older ones are deleted after
+// each new snapshot. Zero keeps all snapshots.
+func WithKeep(n int) Option {
+	return func(m *Manager) {
+		m.keep = n
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager creates a Manager taking snapshots with snapshotter.
+func NewManager(snapshotter domain.StoreSnapshotter, prefix string, opts ...Option) *Manager {
+	m := &Manager{
+		snapshotter: snapshotter,
+		prefix:      prefix,
+		logger:      slog.Default(),
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Take creates a new snapshot and prunes old ones, returning the new
+// snapshot's name. A failure to prune is logged but not returned.
+func (m *Manager) Take(ctx context.Context) (string, error) {
+	log := logging.FromContext(ctx, m.logger)
+	name := m.prefix + "-" + m.now().UTC().Format(timestampFormat)
+	if err := m.snapshotter.Create(ctx, name); err != nil {
+		return "", fmt.Errorf("failed to create snapshot %s: %w", name, err)
+	}
+	log.Info("created backup store snapshot", "name", name)
+
+	if err := m.prune(ctx); err != nil {
+		log.Warn("failed to prune backup store snapshots", "error", err)
+	}
+	return name, nil
+}
+
+// prune deletes all but the newest keep snapshots.
+func (m *Manager) prune(ctx context.Context) error {
+	if m.keep <= 0 {
+		return nil
+	}
+
+	names, err := m.snapshotter.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var ours []string
+	for _, name := range names {
+		if strings.HasPrefix(name, m.prefix+"-") {
+			ours = append(ours, name)
+		}
+	}
+	if len(ours) <= m.keep {
+		return nil
+	}
+	slices.Sort(ours)
+
+	for _, name := range ours[:len(ours)-m.keep] {
+		if err := m.snapshotter.Delete(ctx, name); err != nil {
+			return fmt.Errorf("failed to delete snapshot %s: %w", name, err)
+		}
+		logging.FromContext(ctx, m.logger).Info("deleted old backup store snapshot", "name", name)
+	}
+	return nil
+}
+
+// commandFunc runs a command and returns its standard output.
+type commandFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCommand runs a command, including its standard error in any error.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	// #nosec G204 -- arguments come from config and generated snapshot names
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s: %w", name, msg, err)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Take(t *testing.T) {
+	mock := &MockSnapshotter{Snapshots: []string{
+		"manual-before-upgrade",
+		"ludusavi-runner-20260101T000000Z",
+		"ludusavi-runner-20260102T000000Z",
+		"ludusavi-runner-20260103T000000Z",
+	}}
+	m := NewManager(mock, "ludusavi-runner", WithKeep(2))
+	m.now = func() time.Time { return time.Date(2026, 1, 4, 12, 30, 0, 0, time.UTC) }
+
+	name, err := m.Take(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ludusavi-runner-20260104T123000Z", name)
+
+	// Only the newest of our own snapshots are kept
+	assert.Equal(t, []string{
+		"manual-before-upgrade",
+		"ludusavi-runner-20260103T000000Z",
+		"ludusavi-runner-20260104T123000Z",
+	}, mock.Snapshots)
+}
+
+func TestManager_Take_KeepAll(t *testing.T) {
+	mock := &MockSnapshotter{Snapshots: []string{"ludusavi-runner-20260101T000000Z"}}
+
+	_, err := NewManager(mock, "ludusavi-runner").Take(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, mock.Snapshots, 2)
+}
+
+func TestManager_Take_Failure(t *testing.T) {
+	mock := &MockSnapshotter{CreateFunc: func(context.Context, string) error {
+		return errors.New("read-only filesystem")
+	}}
+
+	_, err := NewManager(mock, "ludusavi-runner", WithKeep(1)).Take(context.Background())
+	assert.ErrorContains(t, err, "read-only filesystem")
+}
+
+// recordCommands returns a commandFunc that records each command line and
+// replies with output.
+func recordCommands(commands *[]string, output string) commandFunc {
+	return func(_ context.Context, name string, args ...string) ([]byte, error) {
+		*commands = append(*commands, name+" "+strings.Join(args, " "))
+		return []byte(output), nil
+	}
+}
+
+func TestBtrfsSnapshotter(t *testing.T) {
+	dir := t.TempDir()
+	var commands []string
+	b := NewBtrfsSnapshotter("/srv/ludusavi", dir)
+	b.run = recordCommands(&commands, "")
+
+	require.NoError(t, b.Create(context.Background(), "snap-1"))
+	require.NoError(t, b.Delete(context.Background(), "snap-0"))
+	assert.Equal(t, []string{
+		"btrfs subvolume snapshot -r /srv/ludusavi " + filepath.Join(dir, "snap-1"),
+		"btrfs subvolume delete " + filepath.Join(dir, "snap-0"),
+	}, commands)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "snap-1"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+	names, err := b.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap-1"}, names)
+}
+
+func TestZFSSnapshotter(t *testing.T) {
+	var commands []string
+	z := NewZFSSnapshotter("tank/ludusavi")
+	z.run = recordCommands(&commands, "tank/ludusavi@snap-0\ntank/ludusavi@snap-1\n")
+
+	require.NoError(t, z.Create(context.Background(), "snap-2"))
+	names, err := z.List(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, z.Delete(context.Background(), "snap-0"))
+
+	assert.Equal(t, []string{"snap-0", "snap-1"}, names)
+	assert.Equal(t, []string{
+		"zfs snapshot tank/ludusavi@snap-2",
+		"zfs list -H -t snapshot -o name -d 1 tank/ludusavi",
+		"zfs destroy tank/ludusavi@snap-0",
+	}, commands)
+}
@@ -0,0 +1,53 @@
+package snapshot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// ZFSSnapshotter snapshots a ZFS dataset, using the zfs command.
+type ZFSSnapshotter struct {
+	dataset string
+	run     commandFunc
+}
+
+// NewZFSSnapshotter snapshots dataset.
+func NewZFSSnapshotter(dataset string) *ZFSSnapshotter {
+	return &ZFSSnapshotter{
+		dataset: dataset,
+		run:     runCommand,
+	}
+}
+
+// Create takes a snapshot of the dataset.
+func (z *ZFSSnapshotter) Create(ctx context.Context, name string) error {
+	_, err := z.run(ctx, "zfs", "snapshot", z.dataset+"@"+name)
+	return err
+}
+
+// List returns the names of the dataset's snapshots.
+func (z *ZFSSnapshotter) List(ctx context.Context) ([]string, error) {
+	out, err := z.run(ctx, "zfs", "list", "-H", "-t", "snapshot", "-o", "name", "-d", "1", z.dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if _, name, ok := strings.Cut(strings.TrimSpace(line), "@"); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Delete destroys a snapshot of the dataset.
+func (z *ZFSSnapshotter) Delete(ctx context.Context, name string) error {
+	_, err := z.run(ctx, "zfs", "destroy", z.dataset+"@"+name)
+	return err
+}
+
+// Ensure ZFSSnapshotter implements domain.StoreSnapshotter.
+var _ domain.StoreSnapshotter = (*ZFSSnapshotter)(nil)
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// MockExporter is a mock implementation of Exporter for testing.
+type MockExporter struct {
+	ExportFunc func(ctx context.Context, spans []*Span) error
+
+	mu sync.Mutex
+	// Exported stores every exported batch of spans.
+	Exported [][]*Span
+}
+
+// Export calls the mock ExportFunc and stores the spans.
+func (m *MockExporter) Export(ctx context.Context, spans []*Span) error {
+	m.mu.Lock()
+	m.Exported = append(m.Exported, spans)
+	m.mu.Unlock()
+
+	if m.ExportFunc != nil {
+		return m.ExportFunc(ctx, spans)
+	}
+	return nil
+}
+
+// Spans returns every exported span.
+func (m *MockExporter) Spans() []*Span {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var spans []*Span
+	for _, batch := range m.Exported {
+		spans = append(spans, batch...)
+	}
+	return spans
+}
+
+// Ensure MockExporter implements Exporter.
+var _ Exporter = (*MockExporter)(nil)
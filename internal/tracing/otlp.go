@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/pkg/version"
+)
+
+const (
+	otlpTracesPath = "/v1/traces"
+	scopeName      = "github.com/sharkusmanch/ludusavi-runner"
+)
+
+// OTLPExporter exports spans to an OpenTelemetry collector (or Jaeger/Tempo)
+// using OTLP/HTTP with JSON encoding.
+type OTLPExporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	httpClient  *http.Client
+}
+
+// OTLPOption configures an OTLPExporter.
+type OTLPOption func(*OTLPExporter)
+
+// WithHeaders sets extra headers sent with every export (e.g. authentication).
+func WithHeaders(headers map[string]string) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.headers = headers
+	}
+}
+
+// WithServiceName sets the service.name resource attribute.
+func WithServiceName(name string) OTLPOption {
+	return func(e *OTLPExporter) {
+		if name != "" {
+			e.serviceName = name
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for exports.
+func WithHTTPClient(client *http.Client) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.httpClient = client
+	}
+}
+
+// NewOTLPExporter creates a new OTLPExporter for the given collector endpoint
+// (e.g. http://localhost:4318). The /v1/traces path is appended unless present.
+func NewOTLPExporter(endpoint string, opts ...OTLPOption) *OTLPExporter {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, otlpTracesPath) {
+		endpoint += otlpTracesPath
+	}
+
+	e := &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: defaultServiceName,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Export sends the spans to the collector.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("collector returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+// OTLP JSON payload types. IDs are hex strings and 64-bit integers are
+// decimal strings, as the OTLP JSON encoding requires.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    StatusCode `json:"code"`
+		Message string     `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// encode converts spans to an OTLP export request.
+func (e *OTLPExporter) encode(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		})
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: encodeAttributes(map[string]any{
+					"service.name":    e.serviceName,
+					"service.version": version.Version,
+				}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName, Version: version.Version},
+				Spans: encoded,
+			}},
+		}},
+	}
+}
+
+// encodeAttributes converts attributes to OTLP key-values, sorted by key.
+func encodeAttributes(attrs map[string]any) []otlpAttribute {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpAttribute, 0, len(keys))
+	for _, k := range keys {
+		encoded = append(encoded, otlpAttribute{Key: k, Value: encodeValue(attrs[k])})
+	}
+	return encoded
+}
+
+// encodeValue converts an attribute value to its OTLP representation.
+func encodeValue(v any) otlpValue {
+	switch val := v.(type) {
+	case string:
+		return otlpValue{StringValue: &val}
+	case bool:
+		return otlpValue{BoolValue: &val}
+	case int:
+		s := strconv.Itoa(val)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(val, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &val}
+	case time.Duration:
+		seconds := val.Seconds()
+		return otlpValue{DoubleValue: &seconds}
+	default:
+		s := fmt.Sprint(val)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// Ensure OTLPExporter implements Exporter.
+var _ Exporter = (*OTLPExporter)(nil)
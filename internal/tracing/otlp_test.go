@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExporter_Export(t *testing.T) {
+	var (
+		receivedPath    string
+		receivedHeaders http.Header
+		received        map[string]any
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedHeaders = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL,
+		WithServiceName("gaming-pc"),
+		WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+	)
+
+	start := time.Unix(1700000000, 0)
+	span := &Span{
+		Name:       "backup",
+		Kind:       SpanKindInternal,
+		TraceID:    "0af7651916cd43dd8448eb211c80319c",
+		SpanID:     "b7ad6b7169203331",
+		ParentID:   "00f067aa0ba902b7",
+		StartTime:  start,
+		EndTime:    start.Add(1500 * time.Millisecond),
+		Attributes: map[string]any{"games_processed": 12, "dry_run": false, "host.name": "pc"},
+		Status:     StatusOK,
+	}
+
+	require.NoError(t, exporter.Export(context.Background(), []*Span{span}))
+
+	assert.Equal(t, "/v1/traces", receivedPath)
+	assert.Equal(t, "application/json", receivedHeaders.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", receivedHeaders.Get("Authorization"))
+
+	resourceSpans := received["resourceSpans"].([]any)[0].(map[string]any)
+	resourceAttrs := resourceSpans["resource"].(map[string]any)["attributes"].([]any)
+	assert.Contains(t, resourceAttrs, map[string]any{
+		"key": "service.name", "value": map[string]any{"stringValue": "gaming-pc"},
+	})
+
+	encoded := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, "backup", encoded["name"])
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", encoded["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", encoded["parentSpanId"])
+	assert.Equal(t, "1700000000000000000", encoded["startTimeUnixNano"])
+	assert.Equal(t, "1700000001500000000", encoded["endTimeUnixNano"])
+	assert.Equal(t, float64(StatusOK), encoded["status"].(map[string]any)["code"])
+	assert.Equal(t, []any{
+		map[string]any{"key": "dry_run", "value": map[string]any{"boolValue": false}},
+		map[string]any{"key": "games_processed", "value": map[string]any{"intValue": "12"}},
+		map[string]any{"key": "host.name", "value": map[string]any{"stringValue": "pc"}},
+	}, encoded["attributes"])
+}
+
+func TestOTLPExporter_Export_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid payload"))
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL)
+	err := exporter.Export(context.Background(), []*Span{{Name: "run", Attributes: map[string]any{}}})
+
+	assert.ErrorContains(t, err, "collector returned status 400: invalid payload")
+}
+
+func TestNewOTLPExporter_Endpoint(t *testing.T) {
+	assert.Equal(t, "http://collector:4318/v1/traces", NewOTLPExporter("http://collector:4318").endpoint)
+	assert.Equal(t, "http://collector:4318/v1/traces", NewOTLPExporter("http://collector:4318/").endpoint)
+	assert.Equal(t, "http://collector:4318/v1/traces", NewOTLPExporter("http://collector:4318/v1/traces").endpoint)
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship of a span to its callers and callees.
+type SpanKind int
+
+// Span kinds, matching the OTLP enum values.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the status of a finished span, matching the OTLP enum values.
+type StatusCode int
+
+// Span status codes.
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Span is a timed operation within a trace.
+// All methods are safe to call on a nil Span, which records nothing.
+type Span struct {
+	Name          string
+	Kind          SpanKind
+	TraceID       string
+	SpanID        string
+	ParentID      string
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	Status        StatusCode
+	StatusMessage string
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SetAttribute sets an attribute on the span. Supported values are strings,
+// bools, integers, floats, and durations (recorded in seconds).
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = StatusError
+	s.StatusMessage = err.Error()
+}
+
+// SetSuccess marks the span as successful, or failed with message.
+func (s *Span) SetSuccess(success bool, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if success {
+		s.Status = StatusOK
+		return
+	}
+	s.Status = StatusError
+	s.StatusMessage = message
+}
+
+// End ends the span. Ending the root span of a trace exports the trace.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.finish(s)
+}
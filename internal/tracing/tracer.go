@@ -0,0 +1,129 @@
+// Package tracing records OpenTelemetry-compatible spans for backup runs and
+// exports them over OTLP/HTTP.
+//
+// Spans are started from a context: code that may run inside a traced run calls
+// Start, which is a no-op unless a Tracer has been attached with ContextWithTracer.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServiceName = "ludusavi-runner"
+	exportTimeout      = 10 * time.Second
+)
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Tracer collects the spans of a trace and exports them when its root span ends.
+type Tracer struct {
+	exporter Exporter
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string][]*Span
+}
+
+// TracerOption configures a Tracer.
+type TracerOption func(*Tracer)
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) TracerOption {
+	return func(t *Tracer) {
+		t.logger = logger
+	}
+}
+
+// NewTracer creates a new Tracer that exports to the given exporter.
+func NewTracer(exporter Exporter, opts ...TracerOption) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		logger:   slog.Default(),
+		pending:  make(map[string][]*Span),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// finish records an ended span, exporting the whole trace once its root ends.
+func (t *Tracer) finish(span *Span) {
+	t.mu.Lock()
+	t.pending[span.TraceID] = append(t.pending[span.TraceID], span)
+	if span.ParentID != "" {
+		t.mu.Unlock()
+		return
+	}
+	spans := t.pending[span.TraceID]
+	delete(t.pending, span.TraceID)
+	t.mu.Unlock()
+
+	// The run's context may already be cancelled (e.g. on shutdown), so export
+	// with a fresh one.
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		t.logger.Warn("failed to export trace", "trace_id", span.TraceID, "error", err)
+		return
+	}
+	t.logger.Debug("trace exported", "trace_id", span.TraceID, "spans", len(spans))
+}
+
+type (
+	tracerKey struct{}
+	spanKey   struct{}
+)
+
+// ContextWithTracer returns a context in which Start records spans with t.
+func ContextWithTracer(ctx context.Context, t *Tracer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// Start starts a span as a child of the span in ctx, or as the root of a new
+// trace. It returns a nil span, whose methods do nothing, if ctx has no tracer.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	tracer, _ := ctx.Value(tracerKey{}).(*Tracer)
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		SpanID:     newID(8),
+		StartTime:  time.Now(),
+		Attributes: make(map[string]any),
+		tracer:     tracer,
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = newID(16)
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// newID returns a random hex-encoded ID of n bytes.
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
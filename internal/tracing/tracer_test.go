@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart_WithoutTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop", SpanKindInternal)
+
+	assert.Nil(t, span)
+	assert.Equal(t, context.Background(), ctx)
+
+	// Methods on a nil span are no-ops
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.SetSuccess(false, "ignored")
+	span.End()
+}
+
+func TestTracer_ExportsTraceWhenRootEnds(t *testing.T) {
+	exporter := &MockExporter{}
+	ctx := ContextWithTracer(context.Background(), NewTracer(exporter))
+
+	ctx, root := Start(ctx, "run", SpanKindInternal)
+	childCtx, child := Start(ctx, "backup", SpanKindInternal)
+	_, grandchild := Start(childCtx, "ludusavi backup", SpanKindInternal)
+
+	grandchild.End()
+	child.RecordError(errors.New("disk full"))
+	child.End()
+	assert.Empty(t, exporter.Exported, "trace must not be exported before the root span ends")
+
+	root.SetAttribute("dry_run", false)
+	root.End()
+	root.End() // ending twice is a no-op
+
+	require.Len(t, exporter.Exported, 1)
+	spans := exporter.Exported[0]
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "ludusavi backup", spans[0].Name)
+	assert.Equal(t, child.SpanID, spans[0].ParentID)
+	assert.Equal(t, root.SpanID, spans[1].ParentID)
+	assert.Equal(t, StatusError, spans[1].Status)
+	assert.Equal(t, "disk full", spans[1].StatusMessage)
+	assert.Empty(t, spans[2].ParentID)
+
+	for _, span := range spans {
+		assert.Equal(t, root.TraceID, span.TraceID)
+		assert.Len(t, span.TraceID, 32)
+		assert.Len(t, span.SpanID, 16)
+		assert.False(t, span.EndTime.Before(span.StartTime))
+	}
+}
+
+func TestTracer_SeparateTraces(t *testing.T) {
+	exporter := &MockExporter{}
+	ctx := ContextWithTracer(context.Background(), NewTracer(exporter))
+
+	_, first := Start(ctx, "run", SpanKindInternal)
+	first.End()
+	_, second := Start(ctx, "run", SpanKindInternal)
+	second.End()
+
+	require.Len(t, exporter.Exported, 2)
+	assert.NotEqual(t, first.TraceID, second.TraceID)
+}
+
+func TestTracer_ExportError(t *testing.T) {
+	exporter := &MockExporter{
+		ExportFunc: func(ctx context.Context, spans []*Span) error {
+			return errors.New("collector unavailable")
+		},
+	}
+	ctx := ContextWithTracer(context.Background(), NewTracer(exporter))
+
+	// Export failures are logged, never surfaced to the traced code
+	_, span := Start(ctx, "run", SpanKindInternal)
+	span.End()
+
+	assert.Len(t, exporter.Exported, 1)
+}
@@ -0,0 +1,443 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+	"github.com/sharkusmanch/ludusavi-runner/internal/units"
+)
+
+// maxLogLines is how many log lines are kept for display.
+const maxLogLines = 200
+
+// The growth leaderboard shows the growthGames games whose saves grew the
+// most over the last growthDays days.
+const (
+	growthDays  = 30
+	growthGames = 5
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	headerStyle  = lipgloss.NewStyle().Bold(true).Underline(true)
+	okStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	dimStyle     = lipgloss.NewStyle().Faint(true)
+	sectionStyle = lipgloss.NewStyle().MarginTop(1)
+)
+
+// eventMsg is an event received from the service.
+type eventMsg events.Event
+
+// disconnectedMsg reports that the event stream was lost.
+type disconnectedMsg struct{ err error }
+
+// actionMsg reports the outcome of a run or pause request, with the new status.
+type actionMsg struct {
+	action string
+	status *app.SchedulerStatus
+	err    error
+}
+
+// growthMsg carries the growth leaderboard.
+type growthMsg []history.Growth
+
+// tickMsg refreshes relative times.
+type tickMsg time.Time
+
+// model is the dashboard state.
+type model struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+
+	connected bool
+	err       error
+	notice    string
+
+	status  *app.SchedulerStatus
+	lastRun *domain.RunResult
+	// operations holds the latest result of each operation and destination,
+	// in the order first seen.
+	operations []*domain.BackupResult
+	// growth ranks the games whose saves grew the most, if the service
+	// keeps a run history.
+	growth []history.Growth
+	logs   []string
+
+	width, height int
+	now           func() time.Time
+}
+
+// newModel creates the dashboard model. Control requests are sent with
+// secret as a bearer token, if set.
+func newModel(baseURL, secret string, client *http.Client) *model {
+	return &model{baseURL: baseURL, secret: secret, client: client, now: time.Now}
+}
+
+// Init starts the clock for relative times and loads the growth
+// leaderboard.
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(tick(), m.fetchGrowth())
+}
+
+// tick schedules the next refresh.
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// Update handles a message.
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "r":
+			m.notice = "requesting backup..."
+			return m, m.post("run")
+		case "p":
+			if m.status != nil && m.status.Paused {
+				m.notice = "resuming..."
+				return m, m.post("resume")
+			}
+			m.notice = "pausing..."
+			return m, m.post("pause")
+		}
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case tickMsg:
+		return m, tick()
+
+	case eventMsg:
+		reconnected := !m.connected
+		m.connected, m.err = true, nil
+		m.handleEvent(events.Event(msg))
+		// Each full run may change the leaderboard
+		if reconnected || msg.Type == events.TypeRun {
+			return m, m.fetchGrowth()
+		}
+
+	case growthMsg:
+		m.growth = msg
+
+	case disconnectedMsg:
+		// The service replays its recent log on reconnect
+		m.connected, m.err = false, msg.err
+		m.logs = nil
+
+	case actionMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("%s failed: %s", msg.action, msg.err)
+			break
+		}
+		// Show the new status without waiting for its event
+		m.notice = ""
+		m.status = msg.status
+	}
+
+	return m, nil
+}
+
+// handleEvent applies a service event.
+func (m *model) handleEvent(e events.Event) {
+	switch e.Type {
+	case events.TypeStatus:
+		var status app.SchedulerStatus
+		if e.Decode(&status) == nil {
+			m.status = &status
+		}
+
+	case events.TypeRun:
+		var result domain.RunResult
+		if e.Decode(&result) != nil {
+			return
+		}
+		m.lastRun = &result
+		ops := append([]*domain.BackupResult{result.Backup, result.CloudUpload, result.Archive, result.Custom, result.Extras}, result.Destinations...)
+		for _, op := range ops {
+			if op != nil {
+				m.setOperation(op)
+			}
+		}
+
+	case events.TypeLog:
+		var line string
+		if e.Decode(&line) == nil {
+			m.logs = append(m.logs, line)
+			if len(m.logs) > maxLogLines {
+				m.logs = m.logs[len(m.logs)-maxLogLines:]
+			}
+		}
+	}
+}
+
+// setOperation records the latest result of an operation.
+func (m *model) setOperation(op *domain.BackupResult) {
+	i := slices.IndexFunc(m.operations, func(o *domain.BackupResult) bool {
+		return o.Operation == op.Operation && o.Destination == op.Destination
+	})
+	if i < 0 {
+		m.operations = append(m.operations, op)
+	} else {
+		m.operations[i] = op
+	}
+}
+
+// post sends a control request to the service.
+func (m *model) post(action string) tea.Cmd {
+	return func() tea.Msg {
+		req, err := http.NewRequest(http.MethodPost, m.baseURL+"/"+action, nil)
+		if err != nil {
+			return actionMsg{action: action, err: err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if m.secret != "" {
+			req.Header.Set("Authorization", "Bearer "+m.secret)
+		}
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return actionMsg{action: action, err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return actionMsg{action: action, err: fmt.Errorf("service returned status %d", resp.StatusCode)}
+		}
+
+		var status app.SchedulerStatus
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			return actionMsg{action: action, err: err}
+		}
+		return actionMsg{action: action, status: &status}
+	}
+}
+
+// fetchGrowth loads the growth leaderboard. It is left empty when the
+// service keeps no run history or can't be reached.
+func (m *model) fetchGrowth() tea.Cmd {
+	return func() tea.Msg {
+		url := fmt.Sprintf("%s/games/growth?days=%d&limit=%d", m.baseURL, growthDays, growthGames)
+		resp, err := m.client.Get(url)
+		if err != nil {
+			return nil
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil
+		}
+
+		var growth []history.Growth
+		if err := json.NewDecoder(resp.Body).Decode(&growth); err != nil {
+			return nil
+		}
+		return growthMsg(growth)
+	}
+}
+
+// View renders the dashboard.
+func (m *model) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("ludusavi-runner"))
+	if m.connected {
+		b.WriteString(okStyle.Render("  ● connected to " + m.baseURL))
+	} else {
+		b.WriteString(errorStyle.Render("  ● not connected to " + m.baseURL))
+		if m.err != nil {
+			b.WriteString(dimStyle.Render(" (" + m.err.Error() + ")"))
+		}
+	}
+	b.WriteString("\n")
+
+	b.WriteString(m.viewStatus())
+	b.WriteString(sectionStyle.Render(m.viewOperations()))
+	b.WriteString("\n")
+	if len(m.growth) > 0 {
+		b.WriteString(sectionStyle.Render(m.viewGrowth()))
+		b.WriteString("\n")
+	}
+
+	logs := m.viewLogs(strings.Count(b.String(), "\n"))
+	if logs != "" {
+		b.WriteString(sectionStyle.Render(logs))
+		b.WriteString("\n")
+	}
+
+	help := "r run now · p pause/resume · q quit"
+	if m.notice != "" {
+		help = m.notice + "  " + help
+	}
+	b.WriteString(sectionStyle.Render(dimStyle.Render(help)))
+	return b.String()
+}
+
+// viewStatus renders the scheduler status and last run.
+func (m *model) viewStatus() string {
+	if m.status == nil {
+		return "Scheduler: unknown\n"
+	}
+
+	var b strings.Builder
+	state := string(m.status.State)
+	switch m.status.State {
+	case app.SchedulerStateRunning:
+		state = okStyle.Render(state)
+	case app.SchedulerStatePaused, app.SchedulerStateDraining:
+		state = warnStyle.Render(state)
+	}
+	fmt.Fprintf(&b, "Scheduler: %s, %s", state, m.status.Message)
+	if m.status.RunStartedAt != nil {
+		fmt.Fprintf(&b, " (for %s)", formatDuration(m.now().Sub(*m.status.RunStartedAt)))
+	}
+	if m.status.Paused && m.status.State != app.SchedulerStatePaused {
+		b.WriteString(warnStyle.Render(" [paused]"))
+	}
+	if m.status.NextRunAt != nil && !m.status.Paused {
+		fmt.Fprintf(&b, "\nNext backup: in %s", formatDuration(m.status.NextRunAt.Sub(m.now())))
+	}
+	b.WriteString("\n")
+
+	if m.lastRun != nil {
+		outcome := okStyle.Render("✓ succeeded")
+		if !m.lastRun.Success {
+			outcome = errorStyle.Render("✗ failed")
+		}
+		fmt.Fprintf(&b, "Last run: %s %s ago in %s %s\n", outcome,
+			formatDuration(m.now().Sub(m.lastRun.EndTime)), formatDuration(m.lastRun.Duration),
+			dimStyle.Render("("+m.lastRun.ID+")"))
+		for _, err := range m.lastRun.Errors {
+			b.WriteString(errorStyle.Render("  "+err) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// viewOperations renders a table of the latest result per operation.
+func (m *model) viewOperations() string {
+	if len(m.operations) == 0 {
+		return dimStyle.Render("No runs yet")
+	}
+
+	rows := [][]string{{"OPERATION", "RESULT", "GAMES", "SIZE", "DURATION", "WHEN"}}
+	for _, op := range m.operations {
+		name := op.Operation.String()
+		if op.Destination != "" {
+			name += " → " + op.Destination
+		}
+		result := "ok"
+		switch {
+		case op.Skipped:
+			result = "skipped"
+		case op.Offline:
+			result = "offline"
+		case !op.Success:
+			result = "failed"
+		}
+		rows = append(rows, []string{
+			name,
+			result,
+			fmt.Sprintf("%d/%d", op.Stats.ProcessedGames, op.Stats.TotalGames),
+			units.FormatBytes(op.Stats.ProcessedBytes),
+			formatDuration(op.Duration),
+			formatDuration(m.now().Sub(op.EndTime)) + " ago",
+		})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell)+2))
+		}
+		text := strings.TrimRight(line.String(), " ")
+		switch {
+		case r == 0:
+			text = headerStyle.Render(text)
+		case row[1] == "failed":
+			text = errorStyle.Render(text)
+		case row[1] == "offline" || row[1] == "skipped":
+			text = warnStyle.Render(text)
+		}
+		b.WriteString(text + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// viewGrowth renders the games whose saves grew the most.
+func (m *model) viewGrowth() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Fastest growing saves (%d days)", growthDays)))
+	width := 0
+	for _, g := range m.growth {
+		width = max(width, lipgloss.Width(g.Game))
+	}
+	for _, g := range m.growth {
+		fmt.Fprintf(&b, "\n%s%s  +%s  %s → %s  %s", g.Game, strings.Repeat(" ", width-lipgloss.Width(g.Game)),
+			units.FormatBytes(g.Delta), units.FormatBytes(g.From), units.FormatBytes(g.To),
+			dimStyle.Render("+"+units.FormatBytes(g.PerDay)+"/day"))
+	}
+	return b.String()
+}
+
+// viewLogs renders as many recent log lines as fit below used lines.
+func (m *model) viewLogs(used int) string {
+	n := len(m.logs)
+	if m.height > 0 {
+		// Leave room for the section spacing, header and help line
+		n = min(n, m.height-used-5)
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Recent log"))
+	for _, line := range m.logs[len(m.logs)-n:] {
+		if m.width > 0 && lipgloss.Width(line) > m.width {
+			line = string([]rune(line)[:max(m.width-1, 0)]) + "…"
+		}
+		switch {
+		case strings.Contains(line, "level=ERROR"):
+			line = errorStyle.Render(line)
+		case strings.Contains(line, "level=WARN"):
+			line = warnStyle.Render(line)
+		case strings.Contains(line, "level=DEBUG"):
+			line = dimStyle.Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	return b.String()
+}
+
+// formatDuration formats d to the second, or to 0.1s when shorter than a minute.
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/app"
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+	"github.com/sharkusmanch/ludusavi-runner/internal/history"
+)
+
+// event returns an event message with data.
+func event(t *testing.T, typ events.Type, data any) eventMsg {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	return eventMsg{Type: typ, Time: time.Now(), Data: raw}
+}
+
+// backupResult returns a completed result ending at end.
+func backupResult(op domain.OperationType, dest string, success bool, end time.Time) *domain.BackupResult {
+	return &domain.BackupResult{
+		Operation: op, Destination: dest, Success: success,
+		StartTime: end.Add(-42 * time.Second), EndTime: end, Duration: 42 * time.Second,
+		Stats: domain.BackupStats{TotalGames: 178, ProcessedGames: 174, ProcessedBytes: 3 << 30},
+	}
+}
+
+func TestModel_Events(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newModel("http://127.0.0.1:9180", "", http.DefaultClient)
+	m.now = func() time.Time { return now }
+
+	next := now.Add(12 * time.Minute)
+	m.Update(event(t, events.TypeStatus, app.SchedulerStatus{
+		State: app.SchedulerStateIdle, Message: "waiting for next backup", NextRunAt: &next,
+	}))
+	m.Update(event(t, events.TypeRun, domain.RunResult{
+		Success: true, EndTime: now.Add(-3 * time.Minute), Duration: time.Minute,
+		Backup:       backupResult(domain.OperationBackup, "", true, now.Add(-3*time.Minute)),
+		Destinations: []*domain.BackupResult{backupResult(domain.OperationBackup, "usb", false, now.Add(-3*time.Minute))},
+	}))
+	m.Update(event(t, events.TypeRun, domain.RunResult{
+		Success: true, EndTime: now, Duration: time.Second,
+		Backup: backupResult(domain.OperationFastBackup, "", true, now),
+	}))
+	m.Update(event(t, events.TypeLog, `level=INFO msg="backup run completed"`))
+
+	assert.True(t, m.connected)
+	require.Len(t, m.operations, 3)
+
+	view := m.View()
+	assert.Contains(t, view, "connected to http://127.0.0.1:9180")
+	assert.Contains(t, view, "Scheduler: idle, waiting for next backup")
+	assert.Contains(t, view, "Next backup: in 12m0s")
+	assert.Contains(t, view, "Last run: ✓ succeeded 0s ago")
+	assert.Contains(t, view, "backup → usb")
+	assert.Contains(t, view, "fast_backup")
+	assert.Contains(t, view, "174/178")
+	assert.Contains(t, view, "3.0 GiB")
+	assert.Contains(t, view, `msg="backup run completed"`)
+
+	// Logs are replayed on reconnect
+	m.Update(disconnectedMsg{})
+	assert.False(t, m.connected)
+	assert.Empty(t, m.logs)
+	assert.Contains(t, m.View(), "not connected")
+}
+
+func TestModel_Keys(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(app.SchedulerStatus{State: app.SchedulerStatePaused, Paused: true})
+	}))
+	defer srv.Close()
+
+	m := newModel(srv.URL, "s3cret", srv.Client())
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	require.NotNil(t, cmd)
+	m.Update(cmd())
+	require.NotNil(t, m.status)
+	assert.True(t, m.status.Paused)
+
+	// Pausing again resumes
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m.Update(cmd())
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m.Update(cmd())
+
+	assert.Equal(t, []string{
+		"POST /pause Bearer s3cret",
+		"POST /resume Bearer s3cret",
+		"POST /run Bearer s3cret",
+	}, requests)
+	assert.Empty(t, m.notice)
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	assert.Equal(t, tea.Quit(), cmd())
+}
+
+func TestModel_Growth(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode([]history.Growth{
+			{Game: "Cyberpunk 2077", From: 1 << 30, To: 3 << 30, Delta: 2 << 30, PerDay: 100 << 20},
+			{Game: "Celeste", From: 1 << 20, To: 2 << 20, Delta: 1 << 20, PerDay: 34 << 10},
+		})
+	}))
+	defer srv.Close()
+
+	m := newModel(srv.URL, "", srv.Client())
+	assert.NotContains(t, m.View(), "Fastest growing saves")
+
+	// Loaded again after each run
+	_, cmd := m.Update(event(t, events.TypeRun, domain.RunResult{Success: true}))
+	require.NotNil(t, cmd)
+	m.Update(cmd())
+	assert.Equal(t, "days=30&limit=5", query)
+
+	view := m.View()
+	assert.Contains(t, view, "Fastest growing saves (30 days)")
+	assert.Contains(t, view, "Cyberpunk 2077  +2.0 GiB  1.0 GiB → 3.0 GiB")
+	assert.Contains(t, view, "Celeste         +1.0 MiB")
+	assert.Contains(t, view, "+100.0 MiB/day")
+}
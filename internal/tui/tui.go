@@ -0,0 +1,60 @@
+// Package tui implements the terminal dashboard for a running service.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/events"
+)
+
+const (
+	// reconnectDelay is how long to wait before reconnecting to the service.
+	reconnectDelay = 2 * time.Second
+	// actionTimeout bounds requests to run a backup or pause.
+	actionTimeout = 10 * time.Second
+)
+
+// Run shows the dashboard for the service whose HTTP server is at baseURL
+// until the user quits or ctx is cancelled. Running a backup or pausing
+// needs the server's secret.
+func Run(ctx context.Context, baseURL, secret string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m := newModel(baseURL, secret, &http.Client{Timeout: actionTimeout})
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+
+	go stream(ctx, baseURL+"/events", p)
+
+	if _, err := p.Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to run dashboard: %w", err)
+	}
+	return nil
+}
+
+// stream forwards service events to the program, reconnecting whenever the
+// connection drops, e.g. while the service restarts.
+func stream(ctx context.Context, url string, p *tea.Program) {
+	// The stream is long-lived, so this client has no timeout
+	client := &http.Client{}
+	for {
+		err := events.Listen(ctx, client, url, func(e events.Event) {
+			p.Send(eventMsg(e))
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		p.Send(disconnectedMsg{err: err})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
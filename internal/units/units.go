@@ -0,0 +1,22 @@
+// Package units formats quantities such as byte counts for people to read.
+package units
+
+import "fmt"
+
+// FormatBytes formats n bytes with a binary unit, such as "1.5 KiB".
+func FormatBytes(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%s%d B", sign, n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%s%.1f %ciB", sign, float64(n)/float64(div), "KMGTPE"[exp])
+}
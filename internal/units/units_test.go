@@ -0,0 +1,15 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "0 B", FormatBytes(0))
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", FormatBytes(1536))
+	assert.Equal(t, "3.0 GiB", FormatBytes(3<<30))
+	assert.Equal(t, "-2.0 GiB", FormatBytes(-2<<30))
+}
@@ -0,0 +1,97 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Minisign signature algorithms: Ed25519 over the file, or over its BLAKE2b
+// hash, as minisign signs by default.
+const (
+	algEd        = "Ed"
+	algPrehashed = "ED"
+)
+
+const (
+	untrustedPrefix = "untrusted comment:"
+	trustedPrefix   = "trusted comment: "
+)
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 key alone,
+// as passed to minisign -P, or the contents of a minisign.pub file.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	var line string
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, untrustedPrefix) {
+			line = l
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algEd {
+		return nil, errors.New("invalid minisign public key")
+	}
+	pk := &PublicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(pk.id[:], raw[2:10])
+	return pk, nil
+}
+
+// Verify checks the minisign signature sig of data, including its trusted
+// comment.
+func (pk *PublicKey) Verify(data, sig []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return errors.New("invalid minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+
+	alg, id, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(id, pk.id[:]) {
+		return fmt.Errorf("signed with key %X, not %X", reverse(id), reverse(pk.id[:]))
+	}
+	msg := data
+	switch alg {
+	case algEd:
+	case algPrehashed:
+		sum := blake2b.Sum512(data)
+		msg = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", alg)
+	}
+	if !ed25519.Verify(pk.key, msg, signature) {
+		return errors.New("signature doesn't match")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], trustedPrefix)
+	if !ed25519.Verify(pk.key, append(append([]byte{}, signature...), trusted...), global) {
+		return errors.New("trusted comment signature doesn't match")
+	}
+	return nil
+}
+
+// reverse returns a key ID the way minisign prints it, little-endian.
+func reverse(id []byte) []byte {
+	r := make([]byte, len(id))
+	for i, b := range id {
+		r[len(id)-1-i] = b
+	}
+	return r
+}
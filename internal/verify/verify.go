@@ -0,0 +1,194 @@
+// Package verify checks files against published minisign signatures and
+// checksums before they are run or installed.
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+)
+
+// ErrUnverified is returned when a file has neither a signature nor a
+// checksum to verify it against.
+var ErrUnverified = errors.New("no signature or checksum to verify against")
+
+// Sources are where the signature and checksums of a file are published,
+// each a path or an http(s) URL.
+type Sources struct {
+	// Signature is the file's minisign signature.
+	Signature string
+	// Checksums is a file in the format of sha256sum or sha512sum listing
+	// the file.
+	Checksums string
+}
+
+// Verifier checks files against their signatures and checksums. Unless
+// strict, a file that fails verification is only logged as a warning.
+type Verifier struct {
+	key    *PublicKey
+	strict bool
+	client *http.Client
+	logger *slog.Logger
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithPublicKey sets the minisign public key signatures are checked with.
+// Without one, only checksums are verified.
+func WithPublicKey(key *PublicKey) Option {
+	return func(v *Verifier) {
+		v.key = key
+	}
+}
+
+// WithStrict makes the Verifier fail closed: files that fail verification, or
+// can't be verified at all, are rejected.
+func WithStrict(strict bool) Option {
+	return func(v *Verifier) {
+		v.strict = strict
+	}
+}
+
+// WithHTTPClient sets the HTTP client signatures and checksums are
+// downloaded with.
+func WithHTTPClient(c *http.Client) Option {
+	return func(v *Verifier) {
+		v.client = c
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(v *Verifier) {
+		v.logger = l
+	}
+}
+
+// NewVerifier creates a new Verifier.
+func NewVerifier(opts ...Option) *Verifier {
+	v := &Verifier{
+		client: http.NewClient(),
+		logger: slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// Verify checks data, the contents of the file called name, against the
+// signature and checksums in src that are set, and the signature only with
+// a public key. It returns an error if the file fails verification, or
+// can't be verified at all, in strict mode; otherwise that is logged.
+func (v *Verifier) Verify(ctx context.Context, name string, data []byte, src Sources) error {
+	err := v.verify(ctx, name, data, src)
+	if err == nil {
+		v.logger.Debug("verified file", "name", name)
+		return nil
+	}
+	if v.strict {
+		return fmt.Errorf("failed to verify %s: %w", name, err)
+	}
+	v.logger.Warn("failed to verify file, using it anyway outside strict mode", "name", name, "error", err)
+	return nil
+}
+
+// verify checks data against src.
+func (v *Verifier) verify(ctx context.Context, name string, data []byte, src Sources) error {
+	verified := false
+	if src.Signature != "" && v.key != nil {
+		sig, err := v.fetch(ctx, src.Signature)
+		if err != nil {
+			return fmt.Errorf("failed to read signature: %w", err)
+		}
+		if err := v.key.Verify(data, sig); err != nil {
+			return fmt.Errorf("bad signature: %w", err)
+		}
+		verified = true
+	}
+	if src.Checksums != "" {
+		list, err := v.fetch(ctx, src.Checksums)
+		if err != nil {
+			return fmt.Errorf("failed to read checksums: %w", err)
+		}
+		if err := checkSum(name, data, list); err != nil {
+			return err
+		}
+		verified = true
+	}
+	if !verified {
+		return ErrUnverified
+	}
+	return nil
+}
+
+// fetch reads the file at location, a path or an http(s) URL.
+func (v *Verifier) fetch(ctx context.Context, location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location) // #nosec G304 -- configured or derived signature path
+	}
+	resp, err := v.client.Get(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, location)
+	}
+	return resp.Body, nil
+}
+
+// checkSum checks data against its entry in list, a file in the format of
+// sha256sum or sha512sum, looked up by name.
+func checkSum(name string, data []byte, list []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(list))
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if sum == "" {
+			continue
+		}
+		if !ok {
+			// A checksum alone, as in ludusavi-linux.tar.gz.sha256, is the
+			// file's
+			file = name
+		}
+		// Binary mode entries start with "*"
+		file = strings.TrimPrefix(strings.TrimSpace(file), "*")
+		if path.Base(file) != name {
+			continue
+		}
+
+		var actual string
+		switch len(sum) {
+		case sha256.Size * 2:
+			digest := sha256.Sum256(data)
+			actual = hex.EncodeToString(digest[:])
+		case sha512.Size * 2:
+			digest := sha512.Sum512(data)
+			actual = hex.EncodeToString(digest[:])
+		default:
+			return fmt.Errorf("unsupported checksum for %s", name)
+		}
+		if !strings.EqualFold(actual, sum) {
+			return fmt.Errorf("checksum mismatch: %s, not the published %s", actual, strings.ToLower(sum))
+		}
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return fmt.Errorf("%s is not listed in the checksums", name)
+}
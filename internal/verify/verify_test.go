@@ -0,0 +1,126 @@
+package verify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey is a minisign key pair for signing test files.
+type testKey struct {
+	id   [8]byte
+	priv ed25519.PrivateKey
+	pub  string
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k := &testKey{priv: priv}
+	copy(k.id[:], "testkey1")
+	k.pub = base64.StdEncoding.EncodeToString(append(append([]byte(algEd), k.id[:]...), pub...))
+	return k
+}
+
+// sign returns the minisign signature of data, of its BLAKE2b hash if
+// prehashed.
+func (k *testKey) sign(data []byte, prehashed bool) []byte {
+	alg, msg := algEd, data
+	if prehashed {
+		sum := blake2b.Sum512(data)
+		alg, msg = algPrehashed, sum[:]
+	}
+	sig := ed25519.Sign(k.priv, msg)
+	trusted := "timestamp:1760000000\tfile:ludusavi"
+	global := ed25519.Sign(k.priv, append(append([]byte{}, sig...), trusted...))
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), k.id[:]...), sig...)) + "\n" +
+		trustedPrefix + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestPublicKey_Verify(t *testing.T) {
+	k := newTestKey(t)
+	data := []byte("ludusavi binary")
+
+	pk, err := ParsePublicKey("untrusted comment: minisign public key\n" + k.pub + "\n")
+	require.NoError(t, err)
+	assert.NoError(t, pk.Verify(data, k.sign(data, true)))
+	assert.NoError(t, pk.Verify(data, k.sign(data, false)))
+
+	assert.ErrorContains(t, pk.Verify([]byte("tampered"), k.sign(data, true)), "signature doesn't match")
+	assert.ErrorContains(t, pk.Verify(data, []byte("not a signature")), "invalid minisign signature")
+
+	other := newTestKey(t)
+	copy(other.id[:], "otherkey")
+	assert.ErrorContains(t, pk.Verify(data, other.sign(data, true)), "signed with key")
+
+	// The trusted comment is signed too
+	tampered := strings.Replace(string(k.sign(data, true)), "timestamp:1760000000", "timestamp:0", 1)
+	assert.ErrorContains(t, pk.Verify(data, []byte(tampered)), "trusted comment signature doesn't match")
+
+	_, err = ParsePublicKey("RWQ not a key")
+	assert.ErrorContains(t, err, "invalid minisign public key")
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	ctx := context.Background()
+	k := newTestKey(t)
+	pk, err := ParsePublicKey(k.pub)
+	require.NoError(t, err)
+
+	data := []byte("ludusavi binary")
+	sum := sha256.Sum256(data)
+	dir := t.TempDir()
+	sigPath := filepath.Join(dir, "ludusavi.minisig")
+	require.NoError(t, os.WriteFile(sigPath, k.sign(data, true), 0600))
+
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/SHA256SUMS":
+			_, _ = w.Write([]byte(hex.EncodeToString(sum[:]) + " *ludusavi\n" +
+				"0000000000000000000000000000000000000000000000000000000000000000  other\n"))
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	strict := NewVerifier(WithPublicKey(pk), WithStrict(true))
+	assert.NoError(t, strict.Verify(ctx, "ludusavi", data, Sources{Signature: sigPath}))
+	assert.NoError(t, strict.Verify(ctx, "ludusavi", data, Sources{Checksums: srv.URL + "/SHA256SUMS"}))
+
+	err = strict.Verify(ctx, "ludusavi", []byte("tampered"), Sources{Checksums: srv.URL + "/SHA256SUMS"})
+	assert.ErrorContains(t, err, "failed to verify ludusavi: checksum mismatch")
+	err = strict.Verify(ctx, "other", data, Sources{Checksums: srv.URL + "/SHA256SUMS"})
+	assert.ErrorContains(t, err, "checksum mismatch")
+	err = strict.Verify(ctx, "ludusavi.exe", data, Sources{Checksums: srv.URL + "/SHA256SUMS"})
+	assert.ErrorContains(t, err, "ludusavi.exe is not listed in the checksums")
+	err = strict.Verify(ctx, "ludusavi", data, Sources{Checksums: srv.URL + "/missing"})
+	assert.ErrorContains(t, err, "failed to read checksums: HTTP 404")
+	err = strict.Verify(ctx, "ludusavi", data, Sources{Signature: filepath.Join(dir, "missing.minisig")})
+	assert.ErrorContains(t, err, "failed to read signature")
+	assert.ErrorIs(t, strict.Verify(ctx, "ludusavi", data, Sources{}), ErrUnverified)
+
+	// Without a public key, signatures aren't checked
+	assert.ErrorIs(t, NewVerifier(WithStrict(true)).Verify(ctx, "ludusavi", data, Sources{Signature: sigPath}), ErrUnverified)
+
+	// Outside strict mode, failures are only logged
+	lenient := NewVerifier(WithPublicKey(pk))
+	assert.NoError(t, lenient.Verify(ctx, "ludusavi", []byte("tampered"), Sources{Signature: sigPath}))
+	assert.NoError(t, lenient.Verify(ctx, "ludusavi", data, Sources{}))
+}
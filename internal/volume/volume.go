@@ -0,0 +1,38 @@
+// Package volume locates mounted volumes, such as USB drives, by label or
+// UUID, so destinations on them can be found wherever they are mounted.
+package volume
+
+import "errors"
+
+// ErrUnsupported is returned when volumes can't be located on this platform.
+var ErrUnsupported = errors.New("locating volumes is not supported on this platform")
+
+// Volume identifies a volume by label, UUID, or both.
+//
+// The UUID is the filesystem UUID on Linux, as listed in /dev/disk/by-uuid,
+// and the volume serial number (e.g. "1A2B-3C4D", as shown by vol) on Windows.
+type Volume struct {
+	Label string
+	UUID  string
+}
+
+// String returns a description of the volume for logs and messages.
+func (v Volume) String() string {
+	switch {
+	case v.Label != "" && v.UUID != "":
+		return v.Label + " (" + v.UUID + ")"
+	case v.Label != "":
+		return v.Label
+	default:
+		return v.UUID
+	}
+}
+
+// Find returns the mount point of the volume, or an empty string if it isn't
+// mounted. Both the label and the UUID must match when both are set.
+func Find(v Volume) (string, error) {
+	if v.Label == "" && v.UUID == "" {
+		return "", errors.New("volume label or UUID is required")
+	}
+	return find(v)
+}
@@ -0,0 +1,39 @@
+package volume
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// volumesDir is where macOS mounts external volumes, by label.
+const volumesDir = "/Volumes"
+
+// find looks for the volume under /Volumes by label. UUIDs aren't supported,
+// as they can only be read through diskutil.
+func find(v Volume) (string, error) {
+	if v.UUID != "" {
+		return "", errors.New("volume UUIDs are not supported on macOS, use the volume label")
+	}
+
+	path := filepath.Join(volumesDir, v.Label)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// A leftover directory from an unclean eject is on the same device as
+	// /Volumes itself rather than a mount point
+	parent, err := os.Stat(volumesDir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || info.Sys().(*syscall.Stat_t).Dev == parent.Sys().(*syscall.Stat_t).Dev {
+		return "", nil
+	}
+	return path, nil
+}
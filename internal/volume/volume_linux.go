@@ -0,0 +1,108 @@
+package volume
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Paths udev maintains symlinks to block devices in, by label and by UUID.
+const (
+	byLabelDir = "/dev/disk/by-label"
+	byUUIDDir  = "/dev/disk/by-uuid"
+	mountsFile = "/proc/self/mounts"
+)
+
+// find resolves the volume to its block device through udev's symlinks and
+// looks the device up in the mount table.
+func find(v Volume) (string, error) {
+	var device string
+	for _, link := range []struct{ dir, name string }{
+		{byLabelDir, v.Label},
+		{byUUIDDir, strings.ToLower(v.UUID)},
+	} {
+		if link.name == "" {
+			continue
+		}
+		dev, err := filepath.EvalSymlinks(filepath.Join(link.dir, encodeDevName(link.name)))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve volume %s: %w", v, err)
+		}
+		if device != "" && dev != device {
+			// Label and UUID belong to different volumes
+			return "", nil
+		}
+		device = dev
+	}
+
+	return mountPoint(device)
+}
+
+// mountPoint returns where device is mounted, or an empty string if it isn't.
+func mountPoint(device string) (string, error) {
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read mount table: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/") {
+			continue
+		}
+		dev, err := filepath.EvalSymlinks(unescapeMount(fields[0]))
+		if err != nil || dev != device {
+			continue
+		}
+		return unescapeMount(fields[1]), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read mount table: %w", err)
+	}
+	return "", nil
+}
+
+// encodeDevName escapes a label the way udev does for /dev/disk/by-label:
+// bytes outside a safe set become \xNN.
+func encodeDevName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 0x80 || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+			strings.IndexByte("#+-.:=@_", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, `\x%02x`, c)
+	}
+	return b.String()
+}
+
+// unescapeMount decodes the octal escapes (e.g. \040 for a space) used in
+// the mount table.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
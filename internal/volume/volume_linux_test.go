@@ -0,0 +1,20 @@
+package volume
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDevName(t *testing.T) {
+	assert.Equal(t, "BACKUP", encodeDevName("BACKUP"))
+	assert.Equal(t, `My\x20Drive`, encodeDevName("My Drive"))
+	assert.Equal(t, `a\x2fb`, encodeDevName("a/b"))
+	assert.Equal(t, "Sauvegarde_é", encodeDevName("Sauvegarde_é"))
+}
+
+func TestUnescapeMount(t *testing.T) {
+	assert.Equal(t, "/media/user/My Drive", unescapeMount(`/media/user/My\040Drive`))
+	assert.Equal(t, `/media/a\b`, unescapeMount(`/media/a\134b`))
+	assert.Equal(t, `/media/trailing\04`, unescapeMount(`/media/trailing\04`))
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows && !darwin
+
+package volume
+
+// find is not supported on this platform.
+func find(Volume) (string, error) {
+	return "", ErrUnsupported
+}
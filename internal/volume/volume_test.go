@@ -0,0 +1,27 @@
+package volume
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind_RequiresIdentifier(t *testing.T) {
+	_, err := Find(Volume{})
+	assert.Error(t, err)
+}
+
+func TestFind_NotMounted(t *testing.T) {
+	root, err := Find(Volume{Label: "ludusavi-runner-test-volume-that-does-not-exist"})
+	if err == ErrUnsupported {
+		t.Skip(err)
+	}
+	assert.NoError(t, err)
+	assert.Empty(t, root)
+}
+
+func TestVolume_String(t *testing.T) {
+	assert.Equal(t, "BACKUP", Volume{Label: "BACKUP"}.String())
+	assert.Equal(t, "1A2B-3C4D", Volume{UUID: "1A2B-3C4D"}.String())
+	assert.Equal(t, "BACKUP (1A2B-3C4D)", Volume{Label: "BACKUP", UUID: "1A2B-3C4D"}.String())
+}
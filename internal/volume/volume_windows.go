@@ -0,0 +1,59 @@
+package volume
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// find checks the volume label and serial number of every drive letter.
+func find(v Volume) (string, error) {
+	drives, err := windows.GetLogicalDrives()
+	if err != nil {
+		return "", fmt.Errorf("failed to list drives: %w", err)
+	}
+
+	for i := 0; i < 26; i++ {
+		if drives&(1<<i) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+
+		label, serial, ok := volumeInfo(root)
+		if !ok {
+			continue
+		}
+		if v.Label != "" && !strings.EqualFold(label, v.Label) {
+			continue
+		}
+		if v.UUID != "" && !strings.EqualFold(serial, v.UUID) {
+			continue
+		}
+		return root, nil
+	}
+	return "", nil
+}
+
+// volumeInfo returns the label and serial number (formatted as XXXX-XXXX) of
+// the volume at root. ok is false for drives without media, such as an empty
+// card reader.
+func volumeInfo(root string) (label, serial string, ok bool) {
+	rootPtr, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return "", "", false
+	}
+	if windows.GetDriveType(rootPtr) == windows.DRIVE_NO_ROOT_DIR {
+		return "", "", false
+	}
+
+	var (
+		name   [windows.MAX_PATH + 1]uint16
+		number uint32
+	)
+	if err := windows.GetVolumeInformation(rootPtr, &name[0], uint32(len(name)), &number, nil, nil, nil, 0); err != nil {
+		return "", "", false
+	}
+
+	return windows.UTF16ToString(name[:]), fmt.Sprintf("%04X-%04X", number>>16, number&0xffff), true
+}
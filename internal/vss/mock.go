@@ -0,0 +1,43 @@
+package vss
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockSnapshotter is a mock implementation of domain.Snapshotter for testing.
+type MockSnapshotter struct {
+	CreateFunc func(ctx context.Context, volume string) (*domain.Snapshot, error)
+	DeleteFunc func(ctx context.Context, snapshot *domain.Snapshot) error
+
+	// Created and Deleted store the volumes of created and deleted snapshots.
+	Created []string
+	Deleted []string
+}
+
+// Create calls the mock CreateFunc and stores the volume.
+func (m *MockSnapshotter) Create(ctx context.Context, volume string) (*domain.Snapshot, error) {
+	if m.CreateFunc != nil {
+		snapshot, err := m.CreateFunc(ctx, volume)
+		if err != nil {
+			return nil, err
+		}
+		m.Created = append(m.Created, volume)
+		return snapshot, nil
+	}
+	m.Created = append(m.Created, volume)
+	return &domain.Snapshot{ID: "mock-" + volume, Volume: volume}, nil
+}
+
+// Delete calls the mock DeleteFunc and stores the volume.
+func (m *MockSnapshotter) Delete(ctx context.Context, snapshot *domain.Snapshot) error {
+	m.Deleted = append(m.Deleted, snapshot.Volume)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, snapshot)
+	}
+	return nil
+}
+
+// Ensure MockSnapshotter implements domain.Snapshotter.
+var _ domain.Snapshotter = (*MockSnapshotter)(nil)
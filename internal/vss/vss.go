@@ -0,0 +1,54 @@
+// Package vss takes Volume Shadow Copy snapshots on Windows, exposing each at
+// a stable path through a directory symlink.
+package vss
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// ErrUnsupported is returned when shadow copies are not available on this platform.
+var ErrUnsupported = errors.New("volume shadow copies are only supported on Windows")
+
+// Manager creates and deletes shadow copies. While a snapshot exists, its
+// files can be read under <linkDir>\<drive letter>.
+type Manager struct {
+	linkDir string
+	logger  *slog.Logger
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager creates a Manager exposing snapshots under linkDir.
+func NewManager(linkDir string, opts ...Option) *Manager {
+	m := &Manager{
+		linkDir: linkDir,
+		logger:  slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// LinkPath returns the stable path a snapshot of volume is exposed at.
+func (m *Manager) LinkPath(volume string) string {
+	return filepath.Join(m.linkDir, strings.ToUpper(strings.TrimRight(volume, `:\/`)))
+}
+
+// Ensure Manager implements domain.Snapshotter.
+var _ domain.Snapshotter = (*Manager)(nil)
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package vss
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// Create is not supported on this platform.
+func (m *Manager) Create(ctx context.Context, volume string) (*domain.Snapshot, error) {
+	return nil, ErrUnsupported
+}
+
+// Delete is not supported on this platform.
+func (m *Manager) Delete(ctx context.Context, snapshot *domain.Snapshot) error {
+	return ErrUnsupported
+}
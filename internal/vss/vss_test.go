@@ -0,0 +1,14 @@
+package vss
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_LinkPath(t *testing.T) {
+	m := NewManager("state")
+	assert.Equal(t, filepath.Join("state", "C"), m.LinkPath("C:"))
+	assert.Equal(t, filepath.Join("state", "D"), m.LinkPath(`d:\`))
+}
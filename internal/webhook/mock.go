@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+)
+
+// MockPublisher is a mock implementation of domain.RunPublisher for testing.
+type MockPublisher struct {
+	PublishFunc func(ctx context.Context, result *domain.RunResult) error
+
+	// Published stores all run results that have been published.
+	Published []*domain.RunResult
+}
+
+// Publish calls the mock PublishFunc and stores the result.
+func (m *MockPublisher) Publish(ctx context.Context, result *domain.RunResult) error {
+	m.Published = append(m.Published, result)
+	if m.PublishFunc != nil {
+		return m.PublishFunc(ctx, result)
+	}
+	return nil
+}
+
+// Reset clears all stored run results.
+func (m *MockPublisher) Reset() {
+	m.Published = nil
+}
+
+// Ensure MockPublisher implements domain.RunPublisher.
+var _ domain.RunPublisher = (*MockPublisher)(nil)
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the signature of a request, "sha256=<hex>":
+	// the HMAC-SHA256, keyed with the shared secret, of the timestamp in
+	// TimestampHeader, a dot, and the body.
+	SignatureHeader = "X-Ludusavi-Signature"
+
+	// TimestampHeader carries when a request was signed, in Unix seconds.
+	TimestampHeader = "X-Ludusavi-Timestamp"
+
+	// DeliveryHeader carries a random ID of each delivery, for receivers
+	// to drop a request they've seen before within the tolerance.
+	DeliveryHeader = "X-Ludusavi-Delivery"
+
+	// DefaultTolerance is how far the timestamp of a signed request may be
+	// from the receiver's clock before it is rejected as a replay.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	// ErrMissingSignature is returned for a request without a signature or timestamp.
+	ErrMissingSignature = errors.New("missing signature")
+	// ErrInvalidSignature is returned for a request whose signature doesn't match.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrStaleTimestamp is returned for a request signed too long ago, or
+	// too far in the future, to rule out a replay.
+	ErrStaleTimestamp = errors.New("timestamp outside tolerance")
+)
+
+// Sign returns the signature sent in SignatureHeader for body signed at
+// timestamp, in Unix seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetSignature signs a request with body at now, setting SignatureHeader and
+// TimestampHeader.
+func SetSignature(header nethttp.Header, secret string, body []byte, now time.Time) {
+	timestamp := now.Unix()
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Verify checks the signature of a request with header and body, as set by
+// SetSignature, and that it was signed within tolerance of now.
+func Verify(header nethttp.Header, secret string, body []byte, tolerance time.Duration, now time.Time) error {
+	signature, stamp := header.Get(SignatureHeader), header.Get(TimestampHeader)
+	if signature == "" || stamp == "" {
+		return ErrMissingSignature
+	}
+	timestamp, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, stamp)
+	}
+	// Compared before the age, so an unsigned request can't probe the clock
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrStaleTimestamp, age.Round(time.Second))
+	}
+	return nil
+}
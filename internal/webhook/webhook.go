@@ -0,0 +1,109 @@
+// Package webhook posts run results to HTTP endpoints, for external systems
+// such as n8n, Zapier or custom scripts to react to backups.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/sharkusmanch/ludusavi-runner/internal/logging"
+)
+
+const (
+	// EventHeader names the event a request reports.
+	EventHeader = "X-Ludusavi-Event"
+
+	// EventRunCompleted is the event of a finished run.
+	EventRunCompleted = "run.completed"
+)
+
+// Client posts run results to a webhook URL.
+type Client struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets a custom HTTP client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithSecret signs each request with secret; see SetSignature.
+func WithSecret(secret string) Option {
+	return func(c *Client) {
+		c.secret = secret
+	}
+}
+
+// NewClient creates a new Client posting to url.
+func NewClient(url string, opts ...Option) *Client {
+	c := &Client{
+		url:        url,
+		httpClient: http.NewClient(),
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Publish posts result as JSON to the webhook URL.
+func (c *Client) Publish(ctx context.Context, result *domain.RunResult) error {
+	log := logging.FromContext(ctx, c.logger)
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode run result: %w", err)
+	}
+
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, EventRunCompleted)
+	req.Header.Set(DeliveryHeader, domain.NewRunID())
+	if c.secret != "" {
+		SetSignature(req.Header, c.secret, body, time.Now())
+	}
+
+	log.Debug("posting run result to webhook", "run_id", result.ID)
+
+	resp, err := c.httpClient.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to post run result: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	log.Debug("run result posted to webhook")
+	return nil
+}
+
+// Ensure Client implements domain.RunPublisher.
+var _ domain.RunPublisher = (*Client)(nil)
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sharkusmanch/ludusavi-runner/internal/domain"
+	"github.com/sharkusmanch/ludusavi-runner/internal/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noRetryClient() *http.Client {
+	return http.NewClient(http.WithRetryConfig(http.RetryConfig{MaxAttempts: 1}))
+}
+
+func TestClient_Publish(t *testing.T) {
+	result := domain.NewRunResult(false)
+	result.Backup = domain.NewBackupResult(domain.OperationBackup)
+	result.Backup.Complete(true, nil)
+	result.Complete()
+
+	var got *nethttp.Request
+	var body []byte
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(nethttp.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/hook", WithSecret("s3cret"), WithHTTPClient(noRetryClient()))
+	require.NoError(t, client.Publish(context.Background(), result))
+
+	require.NotNil(t, got)
+	assert.Equal(t, nethttp.MethodPost, got.Method)
+	assert.Equal(t, "/hook", got.URL.Path)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, EventRunCompleted, got.Header.Get(EventHeader))
+	assert.NotEmpty(t, got.Header.Get(DeliveryHeader))
+	assert.NoError(t, Verify(got.Header, "s3cret", body, DefaultTolerance, time.Now()))
+
+	var decoded domain.RunResult
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, result.ID, decoded.ID)
+	assert.True(t, decoded.Success)
+	require.NotNil(t, decoded.Backup)
+	assert.Equal(t, domain.OperationBackup, decoded.Backup.Operation)
+}
+
+func TestClient_Publish_Unsigned(t *testing.T) {
+	var header nethttp.Header
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithHTTPClient(noRetryClient()))
+	require.NoError(t, client.Publish(context.Background(), domain.NewRunResult(false)))
+	assert.Empty(t, header.Values(SignatureHeader))
+	assert.Empty(t, header.Values(TimestampHeader))
+}
+
+func TestClient_Publish_Error(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nethttp.Error(w, "nope", nethttp.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithHTTPClient(noRetryClient()))
+	err := client.Publish(context.Background(), domain.NewRunResult(false))
+	assert.ErrorContains(t, err, "webhook returned status 403")
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.what do ya want for nothing?" keyed "Jefe"
+	assert.Equal(t,
+		"sha256=1cdd0650c8be1cb0974b1788d458b1e781206cfef59b85faafc582d2e182c57e",
+		Sign("Jefe", 1_700_000_000, []byte("what do ya want for nothing?")))
+	assert.NotEqual(t, Sign("a", 1, []byte("body")), Sign("b", 1, []byte("body")))
+	assert.NotEqual(t, Sign("a", 1, []byte("body")), Sign("a", 2, []byte("body")))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"success":true}`)
+	signed := func() nethttp.Header {
+		header := nethttp.Header{}
+		SetSignature(header, "s3cret", body, now)
+		return header
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, Verify(signed(), "s3cret", body, DefaultTolerance, now.Add(time.Minute)))
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		err := Verify(signed(), "s3cret", []byte(`{"success":false}`), DefaultTolerance, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		err := Verify(signed(), "other", body, DefaultTolerance, now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("changed timestamp", func(t *testing.T) {
+		header := signed()
+		header.Set(TimestampHeader, strconv.FormatInt(now.Unix()+60, 10))
+		assert.ErrorIs(t, Verify(header, "s3cret", body, DefaultTolerance, now), ErrInvalidSignature)
+	})
+
+	t.Run("replayed", func(t *testing.T) {
+		err := Verify(signed(), "s3cret", body, DefaultTolerance, now.Add(10*time.Minute))
+		assert.ErrorIs(t, err, ErrStaleTimestamp)
+	})
+
+	t.Run("from the future", func(t *testing.T) {
+		err := Verify(signed(), "s3cret", body, DefaultTolerance, now.Add(-10*time.Minute))
+		assert.ErrorIs(t, err, ErrStaleTimestamp)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(nethttp.Header{}, "s3cret", body, DefaultTolerance, now), ErrMissingSignature)
+	})
+}